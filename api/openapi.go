@@ -0,0 +1,11 @@
+// Package api holds the OpenAPI description of the HTTP API. It is served at
+// /openapi.json, checked against the handlers by the tests of the router and
+// pkg/client is generated from it.
+package api
+
+import _ "embed"
+
+// Spec is the OpenAPI 3.1 document of the API.
+//
+//go:embed openapi.json
+var Spec []byte
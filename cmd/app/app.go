@@ -1,14 +1,18 @@
 package app
 
 import (
+	"errors"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	iot_persistence "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/infrastructure/events"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/infrastructure/persistence"
 	"github.com/joho/godotenv"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type AppContainer struct {
@@ -16,12 +20,52 @@ type AppContainer struct {
 	SensorUC          *application.SensorUseCase
 	ReadingsUC        *application.ReadingsUsecase
 	SimulatorUC       *application.SimulatorUseCase
+	RetentionUC       *application.RetentionUseCase
+	PresenceUC        *application.PresenceUseCase
+	TwinUC            *application.TwinUseCase
+	CommandUC         *application.CommandUseCase
+	FirmwareUC        *application.FirmwareUseCase
+	CredentialUC      *application.CredentialUseCase
+	GroupUC           *application.GroupUseCase
+	LocationUC        *application.LocationUseCase
+	AuthUC            *application.AuthUseCase
+	AuditUC           *application.AuditUseCase
+	Tenancy           *application.Tenancy
 	Metrics           *persistence.PrometheusMetricsImpl
 	EventPublisher    domain.EventPublisher
 	SensorRepo        domain.SensorRepository
 	SensorReadingRepo domain.SensorReadingRepository
+	ReadingFeed       domain.ReadingFeed
+	RollupRepo        domain.ReadingRollupRepository
 	DeviceRepo        domain.DeviceRepository
 	SimulatorRepo     domain.SimulatorRepository
+	RetentionRepo     domain.SensorReadingRetentionRepository
+	PresenceRepo      domain.PresenceRepository
+	TwinRepo          domain.TwinRepository
+	CommandRepo       domain.CommandRepository
+	FirmwareRepo      domain.FirmwareRepository
+	CampaignRepo      domain.CampaignRepository
+	CredentialRepo    domain.CredentialRepository
+	ClaimTokenRepo    domain.ClaimTokenRepository
+	GroupRepo         domain.GroupRepository
+	LocationRepo      domain.LocationRepository
+	APIKeyRepo        domain.APIKeyRepository
+	AuditRepo         domain.AuditRepository
+	// DeviceCA issues device client certificates; the TLS server trusts it.
+	DeviceCA *iot_persistence.LocalCertificateAuthority
+	// DeviceAuthRequired makes devices authenticate on the ingestion and
+	// reporting endpoints.
+	DeviceAuthRequired bool
+	// APIAuthRequired makes every other caller authenticate with an API key
+	// or a JWT.
+	APIAuthRequired bool
+
+	retentionJobInterval time.Duration
+	presenceJobInterval  time.Duration
+	commandJobInterval   time.Duration
+	firmwareJobInterval  time.Duration
+	auditJobInterval     time.Duration
+	stopJobs             []func()
 }
 
 func NewAppContainer() *AppContainer {
@@ -30,42 +74,529 @@ func NewAppContainer() *AppContainer {
 		log.Println("No .env file found")
 	}
 
-	db := iot_persistence.NewDB()
+	storage := openStorage(os.Getenv("STORAGE_DRIVER"))
+	sensorRepo := storage.sensors
+	rollupRepo := storage.rollups
+	presenceRepo := storage.presence
+	// Every reading is stored through the feed, so those watching see what
+	// devices send and what the simulator generates alike.
+	readingFeed := iot_persistence.NewFeedSensorReadingRepository(iot_persistence.NewPresenceSensorReadingRepository(
+		iot_persistence.NewRollupSensorReadingRepository(storage.readings, rollupRepo),
+		presenceRepo,
+	))
+	sensorReadingRepo := domain.SensorReadingRepository(readingFeed)
+	deviceRepo := storage.devices
+	retentionRepo := storage.retention
+	twinRepo := storage.twins
+	commandRepo := storage.commands
+
+	metics := persistence.NewPrometheusMetrics()
+
+	eventPub := openEventPublisher(os.Getenv("EVENT_BUS"), metics)
+	simulatorRepo := iot_persistence.NewSimulatorRepository(sensorRepo, sensorReadingRepo, eventPub)
 
-	sensorRepo := iot_persistence.NewPostgresSensorRepository(db)
-	sensorReadingRepo := iot_persistence.NewPostgresSensorReadingRepository(db)
-	deviceRepo := iot_persistence.NewPostgresDeviceRepository(db)
-	simulatorRepo := iot_persistence.NewSimulatorRepository(sensorRepo, sensorReadingRepo, nil)
+	sensorUC := application.NewSensorUseCase(sensorRepo, storage.configs, deviceRepo, simulatorRepo, metics, eventPub)
+	deviceUC := application.NewDeviceUseCase(deviceRepo, sensorRepo, sensorUC, simulatorRepo, eventPub)
+	readingsUC := application.NewReadingsUsecase(sensorReadingRepo, rollupRepo, sensorRepo, deviceRepo, readingFeed, eventPub)
+	simulatorUC := application.NewSimulatorUseCase(sensorRepo, simulatorRepo, eventPub)
 
-	natsURL := os.Getenv("NATS_URL")
-	if natsURL == "" {
-		natsURL = "nats://localhost:4222"
+	partitionInterval, err := domain.ParsePartitionInterval(os.Getenv("READINGS_PARTITION_INTERVAL"))
+	if err != nil {
+		log.Fatalf("Invalid READINGS_PARTITION_INTERVAL: %v", err)
 	}
 
-	eventPub, err := events.NewNatsPublisher(&natsURL)
+	retentionPolicies, err := domain.ParseRetentionPolicies(os.Getenv("READINGS_RETENTION"))
 	if err != nil {
-		log.Fatalf("Failed to create NATS publisher: %v", err)
+		log.Fatalf("Invalid READINGS_RETENTION: %v", err)
 	}
 
-	metics := persistence.NewPrometheusMetrics()
+	retentionUC := application.NewRetentionUseCase(
+		retentionRepo,
+		partitionInterval,
+		envInt("READINGS_PARTITIONS_AHEAD", 2),
+		retentionPolicies,
+	)
+
+	presencePolicy, err := domain.ParsePresencePolicy(os.Getenv("PRESENCE_TIMEOUTS"))
+	if err != nil {
+		log.Fatalf("Invalid PRESENCE_TIMEOUTS: %v", err)
+	}
+
+	presenceUC := application.NewPresenceUseCase(deviceRepo, presenceRepo, presencePolicy, metics, eventPub)
+	twinUC := application.NewTwinUseCase(deviceRepo, twinRepo, eventPub)
+
+	commandChannel := openCommandChannel(os.Getenv("EVENT_BUS"))
+	commandUC := application.NewCommandUseCase(
+		deviceRepo,
+		commandRepo,
+		commandChannel,
+		envDuration("COMMAND_DEFAULT_TTL", 30*time.Second),
+		envDuration("COMMAND_MAX_TTL", 24*time.Hour),
+	)
+
+	err = commandChannel.OnReply(func(reply domain.CommandReply) {
+		if err := commandUC.HandleReply(reply, time.Now().UTC()); err != nil {
+			log.Printf("command %s: reply %s rejected: %v", reply.CommandID, reply.Status, err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to subscribe to command replies: %v", err)
+	}
+
+	firmwareDir := os.Getenv("FIRMWARE_DIR")
+	if firmwareDir == "" {
+		firmwareDir = "data/firmware"
+	}
+
+	artifacts, err := iot_persistence.NewDiskArtifactStore(firmwareDir)
+	if err != nil {
+		log.Fatalf("Failed to open firmware store: %v", err)
+	}
+
+	firmwareUC := application.NewFirmwareUseCase(
+		deviceRepo,
+		storage.firmwares,
+		storage.campaigns,
+		artifacts,
+		commandUC,
+		eventPub,
+		envDuration("FIRMWARE_UPDATE_TIMEOUT", 30*time.Minute),
+	)
+
+	firmwareSimulation := firmwareSimulation{
+		firmware:    firmwareUC,
+		step:        envDuration("FIRMWARE_SIMULATED_STEP", time.Second),
+		failureRate: envFloat("FIRMWARE_SIMULATED_FAILURE_RATE", 0.05),
+	}
+
+	if err := serveSimulatedDevices(commandChannel, simulatorRepo, firmwareSimulation); err != nil {
+		log.Fatalf("Failed to subscribe the simulator to commands: %v", err)
+	}
+
+	caDir := os.Getenv("DEVICE_CA_DIR")
+	if caDir == "" {
+		caDir = "data/ca"
+	}
+
+	deviceCA, err := iot_persistence.OpenLocalCertificateAuthority(caDir, envDuration("DEVICE_CERT_VALIDITY", 365*24*time.Hour))
+	if err != nil {
+		log.Fatalf("Failed to open device CA: %v", err)
+	}
 
-	deviceUC := application.NewDeviceUseCase(deviceRepo)
-	sensorUC := application.NewSensorUseCase(sensorRepo, metics, eventPub)
-	readingsUC := application.NewReadingsUsecase(sensorReadingRepo)
-	simulatorUC := application.NewSimulatorUseCase(sensorRepo, simulatorRepo)
+	credentialUC := application.NewCredentialUseCase(
+		deviceRepo,
+		storage.credentials,
+		storage.claimTokens,
+		deviceUC,
+		deviceCA,
+		eventPub,
+		envDuration("CREDENTIAL_ROTATION_GRACE", 24*time.Hour),
+	)
 
-	simulatorRepo = iot_persistence.NewSimulatorRepository(sensorRepo, sensorReadingRepo, simulatorUC)
+	groupUC := application.NewGroupUseCase(storage.groups, deviceRepo, sensorRepo, eventPub)
+	locationUC := application.NewLocationUseCase(storage.locations, deviceRepo, sensorRepo, sensorReadingRepo, rollupRepo, eventPub)
+
+	tenantQuotas, err := domain.ParseTenantQuotas(os.Getenv("TENANT_QUOTAS"))
+	if err != nil {
+		log.Fatalf("Invalid TENANT_QUOTAS: %v", err)
+	}
+
+	deviceAuthRequired := true
+	switch mode := os.Getenv("DEVICE_AUTH"); mode {
+	case "required", "":
+	case "optional":
+		deviceAuthRequired = false
+		log.Println("DEVICE_AUTH=optional: devices may call the ingestion endpoints without credentials")
+	default:
+		log.Fatalf("Invalid DEVICE_AUTH: %q", mode)
+	}
+
+	var tokens domain.TokenVerifier
+	if os.Getenv("JWT_HS256_SECRET") != "" || os.Getenv("JWT_JWKS_FILE") != "" {
+		verifier, err := iot_persistence.NewJWTTokenVerifier(iot_persistence.JWTOptions{
+			HMACSecret: os.Getenv("JWT_HS256_SECRET"),
+			JWKSFile:   os.Getenv("JWT_JWKS_FILE"),
+			Issuer:     os.Getenv("JWT_ISSUER"),
+			Audience:   os.Getenv("JWT_AUDIENCE"),
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure JWT verification: %v", err)
+		}
+		tokens = verifier
+	}
+
+	authUC := application.NewAuthUseCase(storage.apiKeys, tokens, os.Getenv("API_BOOTSTRAP_KEY"))
+
+	apiAuthRequired := true
+	switch mode := os.Getenv("API_AUTH"); mode {
+	case "required", "":
+	case "disabled":
+		apiAuthRequired = false
+		log.Println("API_AUTH=disabled: requests without credentials act as an admin of the whole deployment")
+	default:
+		log.Fatalf("Invalid API_AUTH: %q", mode)
+	}
 
 	return &AppContainer{
 		DeviceUC:          deviceUC,
 		SensorUC:          sensorUC,
 		ReadingsUC:        readingsUC,
 		SimulatorUC:       simulatorUC,
+		RetentionUC:       retentionUC,
+		PresenceUC:        presenceUC,
+		TwinUC:            twinUC,
+		CommandUC:         commandUC,
+		FirmwareUC:        firmwareUC,
+		CredentialUC:      credentialUC,
+		GroupUC:           groupUC,
+		LocationUC:        locationUC,
+		AuthUC:            authUC,
+		AuditUC:           application.NewAuditUseCase(storage.audit),
+		Tenancy:           application.NewTenancy(tenantQuotas, storage.audit, metics),
 		Metrics:           metics,
 		EventPublisher:    eventPub,
 		SensorRepo:        sensorRepo,
 		SensorReadingRepo: sensorReadingRepo,
+		ReadingFeed:       readingFeed,
+		RollupRepo:        rollupRepo,
 		DeviceRepo:        deviceRepo,
 		SimulatorRepo:     simulatorRepo,
+		RetentionRepo:     retentionRepo,
+		PresenceRepo:      presenceRepo,
+		TwinRepo:          twinRepo,
+		CommandRepo:       commandRepo,
+		FirmwareRepo:      storage.firmwares,
+		CampaignRepo:      storage.campaigns,
+		CredentialRepo:    storage.credentials,
+		ClaimTokenRepo:    storage.claimTokens,
+		GroupRepo:         storage.groups,
+		LocationRepo:      storage.locations,
+		APIKeyRepo:        storage.apiKeys,
+		AuditRepo:         storage.audit,
+		DeviceCA:          deviceCA,
+
+		DeviceAuthRequired: deviceAuthRequired,
+		APIAuthRequired:    apiAuthRequired,
+
+		retentionJobInterval: envDuration("RETENTION_JOB_INTERVAL", time.Hour),
+		presenceJobInterval:  envDuration("PRESENCE_CHECK_INTERVAL", 10*time.Second),
+		commandJobInterval:   envDuration("COMMAND_RETRY_INTERVAL", 5*time.Second),
+		firmwareJobInterval:  envDuration("FIRMWARE_CHECK_INTERVAL", 10*time.Second),
+		auditJobInterval:     envDuration("AUDIT_RETRY_INTERVAL", 5*time.Second),
+	}
+}
+
+func (c *AppContainer) StartJobs() {
+	c.stopJobs = append(c.stopJobs,
+		c.RetentionUC.Start(c.retentionJobInterval),
+		c.PresenceUC.Start(c.presenceJobInterval),
+		c.CommandUC.Start(c.commandJobInterval),
+		c.FirmwareUC.Start(c.firmwareJobInterval),
+		c.Tenancy.Start(c.auditJobInterval),
+	)
+}
+
+// Close stops the jobs and then closes the event publisher, so the events
+// still in flight are flushed to the broker or, with EVENT_BUFFER_DIR, left
+// in the buffer for the next start. Call it once the servers have stopped.
+func (c *AppContainer) Close() error {
+	for _, stop := range c.stopJobs {
+		stop()
+	}
+	c.stopJobs = nil
+
+	if closer, ok := c.EventPublisher.(io.Closer); ok {
+		return closer.Close()
 	}
+
+	return nil
+}
+
+type storage struct {
+	devices     domain.DeviceRepository
+	sensors     domain.SensorRepository
+	readings    domain.SensorReadingRepository
+	rollups     domain.ReadingRollupRepository
+	retention   domain.SensorReadingRetentionRepository
+	twins       domain.TwinRepository
+	commands    domain.CommandRepository
+	firmwares   domain.FirmwareRepository
+	campaigns   domain.CampaignRepository
+	credentials domain.CredentialRepository
+	claimTokens domain.ClaimTokenRepository
+	groups      domain.GroupRepository
+	locations   domain.LocationRepository
+	apiKeys     domain.APIKeyRepository
+	audit       domain.AuditRepository
+	configs     domain.SensorConfigHistoryRepository
+	presence    domain.PresenceRepository
+}
+
+// openStorage picks the repository implementations. The memory driver keeps
+// everything in the process and is meant for demos and tests: data is lost on
+// restart.
+func openStorage(driver string) storage {
+	switch driver {
+	case "memory":
+		readings := iot_persistence.NewInMemorySensorReadingRepository()
+
+		return storage{
+			devices:     iot_persistence.NewInMemoryDeviceRepository(),
+			sensors:     iot_persistence.NewInMemorySensorRepository(),
+			readings:    readings,
+			rollups:     iot_persistence.NewInMemoryReadingRollupRepository(),
+			retention:   iot_persistence.NewInMemorySensorReadingRetentionRepository(readings),
+			twins:       iot_persistence.NewInMemoryTwinRepository(),
+			commands:    iot_persistence.NewInMemoryCommandRepository(),
+			firmwares:   iot_persistence.NewInMemoryFirmwareRepository(),
+			campaigns:   iot_persistence.NewInMemoryCampaignRepository(),
+			credentials: iot_persistence.NewInMemoryCredentialRepository(),
+			claimTokens: iot_persistence.NewInMemoryClaimTokenRepository(),
+			groups:      iot_persistence.NewInMemoryGroupRepository(),
+			locations:   iot_persistence.NewInMemoryLocationRepository(),
+			apiKeys:     iot_persistence.NewInMemoryAPIKeyRepository(),
+			audit:       iot_persistence.NewInMemoryAuditRepository(),
+			configs:     iot_persistence.NewInMemorySensorConfigHistoryRepository(),
+			presence:    iot_persistence.NewInMemoryPresenceRepository(),
+		}
+	case "sqlite":
+		db := iot_persistence.NewSQLiteDB()
+		checkSchema(db)
+
+		return storage{
+			devices:     iot_persistence.NewSQLiteDeviceRepository(db),
+			sensors:     iot_persistence.NewSQLiteSensorRepository(db),
+			readings:    iot_persistence.NewSQLiteSensorReadingRepository(db),
+			rollups:     iot_persistence.NewSQLiteReadingRollupRepository(db),
+			retention:   iot_persistence.NewSQLiteSensorReadingRetentionRepository(db),
+			twins:       iot_persistence.NewSQLiteTwinRepository(db),
+			commands:    iot_persistence.NewSQLiteCommandRepository(db),
+			firmwares:   iot_persistence.NewSQLiteFirmwareRepository(db),
+			campaigns:   iot_persistence.NewSQLiteCampaignRepository(db),
+			credentials: iot_persistence.NewSQLiteCredentialRepository(db),
+			claimTokens: iot_persistence.NewSQLiteClaimTokenRepository(db),
+			groups:      iot_persistence.NewSQLiteGroupRepository(db),
+			locations:   iot_persistence.NewSQLiteLocationRepository(db),
+			apiKeys:     iot_persistence.NewSQLiteAPIKeyRepository(db),
+			audit:       iot_persistence.NewSQLiteAuditRepository(db),
+			configs:     iot_persistence.NewSQLiteSensorConfigHistoryRepository(db),
+			presence:    iot_persistence.NewSQLitePresenceRepository(db),
+		}
+	case "postgres", "":
+		db := iot_persistence.NewDB()
+		checkSchema(db)
+
+		return storage{
+			devices:     iot_persistence.NewPostgresDeviceRepository(db),
+			sensors:     iot_persistence.NewPostgresSensorRepository(db),
+			readings:    iot_persistence.NewPostgresSensorReadingRepository(db),
+			rollups:     iot_persistence.NewPostgresReadingRollupRepository(db),
+			retention:   iot_persistence.NewPostgresSensorReadingRetentionRepository(db),
+			twins:       iot_persistence.NewPostgresTwinRepository(db),
+			commands:    iot_persistence.NewPostgresCommandRepository(db),
+			firmwares:   iot_persistence.NewPostgresFirmwareRepository(db),
+			campaigns:   iot_persistence.NewPostgresCampaignRepository(db),
+			credentials: iot_persistence.NewPostgresCredentialRepository(db),
+			claimTokens: iot_persistence.NewPostgresClaimTokenRepository(db),
+			groups:      iot_persistence.NewPostgresGroupRepository(db),
+			locations:   iot_persistence.NewPostgresLocationRepository(db),
+			apiKeys:     iot_persistence.NewPostgresAPIKeyRepository(db),
+			audit:       iot_persistence.NewPostgresAuditRepository(db),
+			configs:     iot_persistence.NewPostgresSensorConfigHistoryRepository(db),
+			presence:    iot_persistence.NewPostgresPresenceRepository(db),
+		}
+	default:
+		log.Fatalf("Invalid STORAGE_DRIVER: %q", driver)
+		return storage{}
+	}
+}
+
+func openEventPublisher(bus string, metrics *persistence.PrometheusMetricsImpl) domain.EventPublisher {
+	switch bus {
+	case "memory":
+		return events.NewInMemoryPublisher()
+	case "nats", "":
+		natsURL := natsURL()
+
+		bufferDir := os.Getenv("EVENT_BUFFER_DIR")
+		if bufferDir == "" {
+			eventPub, err := events.NewNatsPublisher(&natsURL)
+			if err != nil {
+				log.Fatalf("Failed to create NATS publisher: %v", err)
+			}
+
+			return eventPub
+		}
+
+		return openBufferedPublisher(natsURL, bufferDir, metrics)
+	default:
+		log.Fatalf("Invalid EVENT_BUS: %q", bus)
+		return nil
+	}
+}
+
+// openBufferedPublisher puts a disk-backed store-and-forward queue in front
+// of NATS so events survive broker outages and are replayed in order.
+func openBufferedPublisher(natsURL string, bufferDir string, metrics *persistence.PrometheusMetricsImpl) domain.EventPublisher {
+	dropPolicy, err := events.ParseDropPolicy(os.Getenv("EVENT_BUFFER_DROP_POLICY"))
+	if err != nil {
+		log.Fatalf("Invalid EVENT_BUFFER_DROP_POLICY: %v", err)
+	}
+
+	wal, err := events.OpenWAL(bufferDir, events.WALOptions{
+		SegmentBytes: int64(envInt("EVENT_BUFFER_SEGMENT_BYTES", 4<<20)),
+		MaxBytes:     int64(envInt("EVENT_BUFFER_MAX_BYTES", 256<<20)),
+		DropPolicy:   dropPolicy,
+		SyncEvery:    envInt("EVENT_BUFFER_SYNC_EVERY", 1),
+	})
+	if err != nil {
+		log.Fatalf("Failed to open event buffer: %v", err)
+	}
+
+	natsPub, err := events.NewNatsPublisher(&natsURL, events.StoreAndForwardOptions()...)
+	if err != nil {
+		log.Fatalf("Failed to create NATS publisher: %v", err)
+	}
+
+	buffered := events.NewBufferedPublisher(natsPub, wal, metrics, envDuration("EVENT_BUFFER_RETRY_INTERVAL", time.Second))
+	natsPub.OnReconnect(buffered.Notify)
+
+	return buffered
+}
+
+// commandChannel is both sides of the command transport: the app dispatches
+// commands and listens for replies, the simulator listens for commands and
+// replies as a device.
+type commandChannel interface {
+	domain.CommandChannel
+	Reply(reply domain.CommandReply) error
+	OnCommand(handler func(command domain.Command)) error
+	OnReply(handler func(reply domain.CommandReply)) error
+}
+
+func openCommandChannel(bus string) commandChannel {
+	switch bus {
+	case "memory":
+		return events.NewInMemoryCommandChannel()
+	case "nats", "":
+		url := natsURL()
+		channel, err := events.NewNatsCommandChannel(&url, events.StoreAndForwardOptions()...)
+		if err != nil {
+			log.Fatalf("Failed to create NATS command channel: %v", err)
+		}
+
+		return channel
+	default:
+		log.Fatalf("Invalid EVENT_BUS: %q", bus)
+		return nil
+	}
+}
+
+// serveSimulatedDevices lets the simulator answer the commands of devices
+// whose sensors it is simulating. Commands for other devices are left to the
+// real hardware.
+func serveSimulatedDevices(channel commandChannel, simulator domain.SimulatorRepository, firmware firmwareSimulation) error {
+	return channel.OnCommand(func(command domain.Command) {
+		reply := func(status domain.CommandReplyStatus, result map[string]interface{}, message string) {
+			err := channel.Reply(domain.CommandReply{
+				CommandID: command.ID,
+				DeviceID:  command.DeviceID,
+				Status:    status,
+				Result:    result,
+				Error:     message,
+			})
+			if err != nil {
+				log.Printf("simulator: failed to reply to command %s: %v", command.ID, err)
+			}
+		}
+
+		if command.Name == domain.CommandUpdateFirmware && simulator.Simulates(command.DeviceID) {
+			reply(domain.CommandReplyAck, nil, "")
+			go firmware.install(command, simulator, reply)
+			return
+		}
+
+		result, err := simulator.Execute(command)
+		switch {
+		case errors.Is(err, domain.ErrSimulationNotActive):
+			return
+		case err != nil:
+			reply(domain.CommandReplyAck, nil, "")
+			reply(domain.CommandReplyFailed, nil, err.Error())
+		default:
+			reply(domain.CommandReplyAck, nil, "")
+			reply(domain.CommandReplySucceeded, result, "")
+		}
+	})
+}
+
+func natsURL() string {
+	if url := os.Getenv("NATS_URL"); url != "" {
+		return url
+	}
+
+	return "nats://localhost:4222"
+}
+
+func checkSchema(db *iot_persistence.DB) {
+	migrator, err := iot_persistence.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+
+	if err := migrator.Check(); err != nil {
+		log.Fatalf("Refusing to start: %v (run `sensor-app migrate up`)", err)
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+
+	return n
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+
+	return f
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s: %q", key, value)
+	}
+
+	return d
 }
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"io"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// firmwareDownloadSteps is how many progress reports a simulated download
+// sends.
+const firmwareDownloadSteps = 4
+
+// firmwareSimulation plays the device side of a firmware update for the
+// devices the simulator stands in for: it downloads and verifies the image,
+// reports its progress, fails now and then like real hardware and finally
+// restarts the device's sensors.
+type firmwareSimulation struct {
+	firmware    *application.FirmwareUseCase
+	step        time.Duration
+	failureRate float64
+}
+
+func (s firmwareSimulation) install(
+	command domain.Command,
+	simulator domain.SimulatorRepository,
+	reply func(status domain.CommandReplyStatus, result map[string]interface{}, message string),
+) {
+	campaignID, _ := command.Params["campaign_id"].(string)
+	firmwareID, _ := command.Params["firmware_id"].(string)
+	checksum, _ := command.Params["checksum"].(string)
+
+	report := func(status domain.DeviceUpdateStatus, progress int, message string) bool {
+		_, err := s.firmware.ReportUpdate(command.DeviceID, domain.CampaignID(campaignID), status, progress, message, time.Now())
+		if err != nil {
+			log.Printf("simulator: device %s stopped updating: %v", command.DeviceID, err)
+			return false
+		}
+
+		return true
+	}
+
+	fail := func(message string) {
+		if report(domain.DeviceUpdateFailed, 0, message) {
+			reply(domain.CommandReplyFailed, nil, message)
+		}
+	}
+
+	firmware, content, err := s.firmware.OpenArtifact(domain.FirmwareID(firmwareID))
+	if err != nil {
+		fail("download failed: " + err.Error())
+		return
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	chunk := max(firmware.Size/firmwareDownloadSteps, 1)
+	var downloaded int64
+	for {
+		n, err := io.CopyN(hash, content, chunk)
+		downloaded += n
+		if err != nil && !errors.Is(err, io.EOF) {
+			fail("download failed: " + err.Error())
+			return
+		}
+
+		if !report(domain.DeviceUpdateDownloading, int(downloaded*100/firmware.Size), "") {
+			return
+		}
+		time.Sleep(s.step)
+
+		if errors.Is(err, io.EOF) || downloaded >= firmware.Size {
+			break
+		}
+	}
+
+	if hex.EncodeToString(hash.Sum(nil)) != checksum {
+		fail("checksum mismatch")
+		return
+	}
+
+	for _, progress := range []int{0, 50} {
+		if !report(domain.DeviceUpdateInstalling, progress, "") {
+			return
+		}
+		time.Sleep(s.step)
+	}
+
+	if rand.Float64() < s.failureRate {
+		fail("installation failed")
+		return
+	}
+
+	result, err := simulator.Execute(command)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	if report(domain.DeviceUpdateSucceeded, 100, "") {
+		reply(domain.CommandReplySucceeded, result, "")
+	}
+}
@@ -0,0 +1,735 @@
+// Command clientgen generates the Go client of the API in pkg/client from
+// its OpenAPI document:
+//
+//	clientgen -spec api/openapi.json -out pkg/client/client.gen.go
+//
+// Every schema of the document becomes a type and every operation a method of
+// Client, named after its operationId. Deprecated operations and those marked
+// x-client-ignore are left out.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const header = "// Code generated by clientgen from api/openapi.json; DO NOT EDIT.\n\n"
+
+func main() {
+	specPath := flag.String("spec", "api/openapi.json", "OpenAPI document to generate the client from")
+	outPath := flag.String("out", "pkg/client/client.gen.go", "file to write the client to")
+	flag.Parse()
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := generate(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*outPath, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ordered is a JSON object that keeps the order of its keys, so the code is
+// generated in the order the document is written in.
+type ordered[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errors.New("expected an object")
+	}
+
+	o.values = map[string]T{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		var value T
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		o.keys = append(o.keys, key)
+		o.values[key] = value
+	}
+
+	return nil
+}
+
+// typeSet is the type of a schema, which OpenAPI 3.1 writes either as a
+// string or as a list such as ["number", "null"].
+type typeSet []string
+
+func (t *typeSet) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeSet{one}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t typeSet) has(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// main returns the type other than null.
+func (t typeSet) main() string {
+	for _, n := range t {
+		if n != "null" {
+			return n
+		}
+	}
+	return ""
+}
+
+type schema struct {
+	Ref                  string           `json:"$ref"`
+	Type                 typeSet          `json:"type"`
+	Format               string           `json:"format"`
+	Description          string           `json:"description"`
+	Properties           ordered[*schema] `json:"properties"`
+	Required             []string         `json:"required"`
+	Items                *schema          `json:"items"`
+	AdditionalProperties *schema          `json:"additionalProperties"`
+	AllOf                []*schema        `json:"allOf"`
+	Enum                 []string         `json:"enum"`
+}
+
+func (s *schema) requires(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Deprecated  bool         `json:"deprecated"`
+	Ignore      bool         `json:"x-client-ignore"`
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"responses"`
+}
+
+type document struct {
+	Paths      ordered[ordered[json.RawMessage]] `json:"paths"`
+	Components struct {
+		Schemas    ordered[*schema]      `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+var methods = map[string]string{
+	"get":    "http.MethodGet",
+	"post":   "http.MethodPost",
+	"put":    "http.MethodPut",
+	"patch":  "http.MethodPatch",
+	"delete": "http.MethodDelete",
+}
+
+// generator writes the client of one document.
+type generator struct {
+	doc *document
+	buf bytes.Buffer
+}
+
+// generate returns the gofmt'ed source of the client described by spec.
+func generate(spec []byte) ([]byte, error) {
+	g := &generator{doc: &document{}}
+	if err := json.Unmarshal(spec, g.doc); err != nil {
+		return nil, fmt.Errorf("reading the document: %w", err)
+	}
+
+	for _, name := range g.doc.Components.Schemas.keys {
+		if err := g.writeSchema(name, g.doc.Components.Schemas.values[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for _, path := range g.doc.Paths.keys {
+		item := g.doc.Paths.values[path]
+
+		var shared []*parameter
+		if raw, ok := item.values["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		for _, method := range item.keys {
+			if method == "parameters" {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(item.values[method], &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if op.Deprecated || op.Ignore {
+				continue
+			}
+			if err := g.writeOperation(method, path, shared, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	body := g.buf.String()
+	var src strings.Builder
+	src.WriteString(header)
+	src.WriteString("package client\n\nimport (\n")
+	for _, imp := range []string{"context", "io", "net/http", "net/url", "strconv", "time"} {
+		name := imp[strings.LastIndex(imp, "/")+1:]
+		used := regexp.MustCompile(`\b` + name + `\.[A-Z]`)
+		if used.MatchString(body) {
+			src.WriteString(strconv.Quote(imp) + "\n")
+		}
+	}
+	src.WriteString(")\n\n")
+	src.WriteString(body)
+
+	return format.Source([]byte(src.String()))
+}
+
+// resolve follows the $ref of s, if any.
+func (g *generator) resolve(s *schema) (*schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+
+	name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	target, ok := g.doc.Components.Schemas.values[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %s", s.Ref)
+	}
+	return target, nil
+}
+
+func (g *generator) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+
+	name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+	target, ok := g.doc.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %s", p.Ref)
+	}
+	return target, nil
+}
+
+// goType returns the Go type of the values of s.
+func (g *generator) goType(s *schema) (string, error) {
+	if s.Ref != "" {
+		if _, err := g.resolve(s); err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(s.Ref, "#/components/schemas/"), nil
+	}
+
+	switch s.Type.main() {
+	case "":
+		return "interface{}", nil
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if s.Items == nil {
+			return "[]interface{}", nil
+		}
+		item, err := g.goType(s.Items)
+		return "[]" + item, err
+	case "object":
+		if len(s.Properties.keys) > 0 {
+			return "", errors.New("objects with properties must be declared as schemas")
+		}
+		if s.AdditionalProperties == nil {
+			return "map[string]interface{}", nil
+		}
+		value, err := g.goType(s.AdditionalProperties)
+		return "map[string]" + value, err
+	}
+
+	return "", fmt.Errorf("unsupported type %v", s.Type)
+}
+
+// How the zero value of a field is told apart from the values sent.
+const (
+	// zeroNil fields are maps, slices and free-form values. Nil is sent as
+	// null, which the API takes as unset, so an empty one is still sent.
+	zeroNil = iota
+	// zeroEmpty fields are strings, for which the API takes the empty string
+	// as unset.
+	zeroEmpty
+	// zeroValue fields need a pointer for their zero value to be sent.
+	zeroValue
+)
+
+func (g *generator) zero(s *schema) (int, error) {
+	s, err := g.resolve(s)
+	if err != nil {
+		return 0, err
+	}
+
+	switch s.Type.main() {
+	case "":
+		if len(s.AllOf) == 0 {
+			return zeroNil, nil
+		}
+	case "array":
+		return zeroNil, nil
+	case "object":
+		if len(s.Properties.keys) == 0 {
+			return zeroNil, nil
+		}
+	case "string":
+		if s.Format != "date-time" {
+			return zeroEmpty, nil
+		}
+	}
+	return zeroValue, nil
+}
+
+func (g *generator) writeSchema(name string, s *schema) error {
+	if s.Description != "" {
+		writeComment(&g.buf, "", name+" is "+lowerFirst(s.Description))
+	}
+
+	switch {
+	case len(s.Enum) > 0:
+		fmt.Fprintf(&g.buf, "type %s string\n\nconst (\n", name)
+		for _, value := range s.Enum {
+			fmt.Fprintf(&g.buf, "%s%s %s = %q\n", name, goName(value), name, value)
+		}
+		g.buf.WriteString(")\n\n")
+		return nil
+
+	case len(s.AllOf) > 0:
+		fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+		for _, part := range s.AllOf {
+			if part.Ref != "" {
+				embedded, err := g.goType(part)
+				if err != nil {
+					return err
+				}
+				g.buf.WriteString(embedded + "\n")
+				continue
+			}
+			if err := g.writeFields(part); err != nil {
+				return err
+			}
+		}
+		g.buf.WriteString("}\n\n")
+		return nil
+
+	case s.Type.main() == "object" && len(s.Properties.keys) > 0:
+		fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+		if err := g.writeFields(s); err != nil {
+			return err
+		}
+		g.buf.WriteString("}\n\n")
+		return nil
+	}
+
+	typ, err := g.goType(s)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&g.buf, "type %s %s\n\n", name, typ)
+	return nil
+}
+
+// writeFields writes a field for every property of s. Optional fields are
+// left out of the JSON when unset; required ones that may be null need a
+// pointer to be sent as null.
+func (g *generator) writeFields(s *schema) error {
+	for _, prop := range s.Properties.keys {
+		field := s.Properties.values[prop]
+
+		typ, err := g.goType(field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", prop, err)
+		}
+		zero, err := g.zero(field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", prop, err)
+		}
+
+		tag := prop
+		required := s.requires(prop)
+		if !required && zero != zeroNil {
+			tag += ",omitempty"
+		}
+		if (zero == zeroValue && !required) || (zero != zeroNil && field.Type.has("null")) {
+			typ = "*" + typ
+		}
+
+		writeComment(&g.buf, "", field.Description)
+		fmt.Fprintf(&g.buf, "%s %s `json:%q`\n", goName(prop), typ, tag)
+	}
+	return nil
+}
+
+// call describes the method of an operation while it is written.
+type call struct {
+	name    string
+	args    []string
+	path    string
+	params  []*parameter
+	body    string
+	returns string
+}
+
+func (g *generator) writeOperation(method, path string, shared []*parameter, op *operation) error {
+	if op.OperationID == "" {
+		return errors.New("operationId missing")
+	}
+
+	c := call{name: upperFirst(op.OperationID), args: []string{"ctx context.Context"}}
+
+	// Parameters of the operation override those of the path.
+	params := map[string]*parameter{}
+	var order []string
+	for _, p := range append(append([]*parameter{}, shared...), op.Parameters...) {
+		p, err := g.parameter(p)
+		if err != nil {
+			return err
+		}
+		key := p.In + " " + p.Name
+		if _, seen := params[key]; !seen {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+
+	segments := strings.Split(path, "/")
+	var pathExpr []string
+	literal := ""
+	for i, segment := range segments {
+		if i > 0 {
+			literal += "/"
+		}
+		if !strings.HasPrefix(segment, "{") {
+			literal += segment
+			continue
+		}
+		name := strings.Trim(segment, "{}")
+		if _, ok := params["path "+name]; !ok {
+			return fmt.Errorf("path parameter %s not declared", name)
+		}
+		pathExpr = append(pathExpr, strconv.Quote(literal), "url.PathEscape("+name+")")
+		literal = ""
+		c.args = append(c.args, name+" string")
+	}
+	if literal != "" {
+		pathExpr = append(pathExpr, strconv.Quote(literal))
+	}
+	c.path = strings.Join(pathExpr, " + ")
+
+	for _, key := range order {
+		if p := params[key]; p.In == "query" || p.In == "header" {
+			c.params = append(c.params, p)
+		}
+	}
+	if len(c.params) > 0 {
+		if err := g.writeParams(c.name, c.params); err != nil {
+			return err
+		}
+		c.args = append(c.args, "params *"+c.name+"Params")
+	}
+
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok {
+			typ, err := g.goType(media.Schema)
+			if err != nil {
+				return err
+			}
+			c.args = append(c.args, "body "+typ)
+			c.body = "json"
+		} else if _, ok := op.RequestBody.Content["application/octet-stream"]; ok {
+			c.args = append(c.args, "body io.Reader")
+			c.body = "binary"
+		} else {
+			return errors.New("unsupported request body")
+		}
+	}
+
+	var statuses []string
+	for status := range op.Responses {
+		if strings.HasPrefix(status, "2") {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses)
+	if len(statuses) == 0 {
+		return errors.New("no successful response")
+	}
+
+	content := op.Responses[statuses[0]].Content
+	var media string
+	switch {
+	case len(content) == 0:
+	case content["application/json"].Schema != nil:
+		typ, err := g.goType(content["application/json"].Schema)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") {
+			c.returns = typ
+		} else {
+			c.returns = "*" + typ
+		}
+		media = "json"
+	case content["text/plain"].Schema != nil:
+		c.returns = "string"
+		media = "text"
+	case content["application/octet-stream"].Schema != nil:
+		c.returns = "io.ReadCloser"
+		media = "binary"
+	default:
+		return errors.New("unsupported response")
+	}
+
+	writeComment(&g.buf, "", c.name+" "+lowerFirst(op.Summary))
+	results := "error"
+	if c.returns != "" {
+		results = "(" + c.returns + ", error)"
+	}
+	fmt.Fprintf(&g.buf, "func (c *Client) %s(%s) %s {\n", c.name, strings.Join(c.args, ", "), results)
+	fmt.Fprintf(&g.buf, "req := request{method: %s, path: %s}\n", methods[method], c.path)
+	if c.body != "" {
+		g.buf.WriteString("req.body = body\n")
+	}
+	if len(c.params) > 0 {
+		g.writeParamsEncoding(c.params)
+	}
+
+	switch media {
+	case "":
+		g.buf.WriteString("return c.do(ctx, req, nil)\n")
+	case "binary":
+		g.buf.WriteString("return c.open(ctx, req)\n")
+	case "text":
+		g.buf.WriteString("var out string\nerr := c.do(ctx, req, &out)\nreturn out, err\n")
+	default:
+		typ := strings.TrimPrefix(c.returns, "*")
+		fmt.Fprintf(&g.buf, "var out %s\nif err := c.do(ctx, req, &out); err != nil {\nreturn nil, err\n}\n", typ)
+		if strings.HasPrefix(c.returns, "*") {
+			g.buf.WriteString("return &out, nil\n")
+		} else {
+			g.buf.WriteString("return out, nil\n")
+		}
+	}
+	g.buf.WriteString("}\n\n")
+
+	return nil
+}
+
+// paramType returns the type of the field of p, and whether it is a pointer
+// to tell a zero value apart from an unset one. Strings are only sent when
+// not empty, so they never are.
+func (g *generator) paramType(p *parameter) (string, bool, error) {
+	typ, err := g.goType(p.Schema)
+	if err != nil {
+		return "", false, err
+	}
+
+	zero, err := g.zero(p.Schema)
+	if err != nil {
+		return "", false, err
+	}
+	if p.Required || zero == zeroEmpty {
+		return typ, false, nil
+	}
+	return "*" + typ, true, nil
+}
+
+func (g *generator) writeParams(name string, params []*parameter) error {
+	doc := name + "Params are the parameters of " + name + "."
+	for _, p := range params {
+		if !p.Required {
+			doc += " Those left unset are not sent."
+			break
+		}
+	}
+	writeComment(&g.buf, "", doc)
+	fmt.Fprintf(&g.buf, "type %sParams struct {\n", name)
+	for _, p := range params {
+		typ, _, err := g.paramType(p)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		writeComment(&g.buf, "", p.Description)
+		fmt.Fprintf(&g.buf, "%s %s\n", goName(p.Name), typ)
+	}
+	g.buf.WriteString("}\n\n")
+	return nil
+}
+
+func (g *generator) writeParamsEncoding(params []*parameter) {
+	g.buf.WriteString("if params != nil {\n")
+	for _, p := range params {
+		typ, pointer, _ := g.paramType(p)
+		field := "params." + goName(p.Name)
+
+		value := field
+		if pointer {
+			value = "(*" + field + ")"
+		}
+		base := strings.TrimPrefix(typ, "*")
+		switch {
+		case base == "time.Time":
+			value += ".Format(time.RFC3339Nano)"
+		case base == "int" || base == "int64":
+			value = "strconv.FormatInt(int64(" + value + "), 10)"
+		case base == "float64":
+			value = "strconv.FormatFloat(" + value + ", 'f', -1, 64)"
+		case base == "bool":
+			value = "strconv.FormatBool(" + value + ")"
+		case base != "string":
+			value = "string(" + value + ")"
+		}
+
+		set := fmt.Sprintf("req.setQuery(%q, %s)\n", p.Name, value)
+		if p.In == "header" {
+			set = fmt.Sprintf("req.setHeader(%q, %s)\n", p.Name, value)
+		}
+
+		switch {
+		case pointer:
+			fmt.Fprintf(&g.buf, "if %s != nil {\n%s}\n", field, set)
+		case !p.Required:
+			fmt.Fprintf(&g.buf, "if %s != \"\" {\n%s}\n", field, set)
+		default:
+			g.buf.WriteString(set)
+		}
+	}
+	g.buf.WriteString("}\n")
+}
+
+// initialisms are written in upper case in Go names, as golint expects.
+var initialisms = map[string]string{
+	"api":  "API",
+	"ca":   "CA",
+	"hmac": "HMAC",
+	"id":   "ID",
+	"ids":  "IDs",
+	"ip":   "IP",
+	"json": "JSON",
+	"ttl":  "TTL",
+	"url":  "URL",
+}
+
+// goName turns a name of the document such as device_ids, If-Match or
+// device.create into an exported Go name.
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ' ' || r == '/'
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		if upper, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(upperFirst(word))
+	}
+	return b.String()
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// lowerFirst lowers the first letter of a sentence to follow a Go name,
+// leaving acronyms such as RFC as they are.
+func lowerFirst(s string) string {
+	if s == "" || (len(s) > 1 && unicode.IsUpper(rune(s[1]))) {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// writeComment writes text as a comment wrapped at 77 columns.
+func writeComment(buf *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(indent)+len(line)+len(word)+4 > 77 {
+			buf.WriteString(indent + "// " + line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	buf.WriteString(indent + "// " + line + "\n")
+}
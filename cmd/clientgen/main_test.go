@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	spec, err := os.ReadFile("../../api/openapi.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	committed, err := os.ReadFile("../../pkg/client/client.gen.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generated, err := generate(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(generated, committed) {
+		t.Error("pkg/client/client.gen.go is stale: run go generate ./pkg/client")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "device_ids", expected: "DeviceIDs"},
+		{name: "If-Match", expected: "IfMatch"},
+		{name: "credentialID", expected: "CredentialID"},
+		{name: "device.create", expected: "DeviceCreate"},
+		{name: "ca_certificate", expected: "CACertificate"},
+		{name: "sampling_rate_ms", expected: "SamplingRateMs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goName(tt.name); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
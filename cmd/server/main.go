@@ -1,16 +1,138 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"github.com/SeiyaJapon/iot-sensor-app/cmd/app"
 	"github.com/SeiyaJapon/iot-sensor-app/internal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	container := app.NewAppContainer()
+	container.StartJobs()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: internal.NewRouter(container),
+	}
+	served := make(chan error, 1)
+
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	var grpcServer *grpc.Server
+	if certFile == "" || keyFile == "" {
+		grpcServer = serveGRPC(container, nil)
+		go func() {
+			served <- server.ListenAndServe()
+		}()
+	} else {
+		// Devices with an x509 credential present it here. Clients without a
+		// certificate are still let in and may sign their requests instead.
+		server.TLSConfig = &tls.Config{
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  container.DeviceCA.CertPool(),
+		}
+
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("Failed to load the TLS certificate: %v", err)
+		}
+		grpcTLS := server.TLSConfig.Clone()
+		grpcTLS.Certificates = []tls.Certificate{certificate}
+		grpcServer = serveGRPC(container, grpcTLS)
+
+		go func() {
+			served <- server.ListenAndServeTLS(certFile, keyFile)
+		}()
+	}
+
+	select {
+	case err := <-served:
+		log.Println(err)
+	case <-ctx.Done():
+		log.Println("Shutting down")
+	}
+
+	shutdown(server, grpcServer)
+
+	if err := container.Close(); err != nil {
+		log.Printf("Closing the app: %v", err)
+	}
+}
+
+// shutdownTimeout is how long the calls in flight are given to finish once
+// the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// shutdown stops taking new requests and calls and waits for those in
+// flight, up to shutdownTimeout. Streams still open by then, like watches and
+// subscriptions that only end when the client leaves, are cut.
+func shutdown(server *http.Server, grpcServer *grpc.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP shutdown: %v", err)
+		server.Close()
+	}
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		<-stopped
+	}
+}
+
+// serveGRPC serves the gRPC API on GRPC_ADDR, :9090 by default, alongside
+// the REST API. With TLS, devices authenticate with their client
+// certificate as they do on REST.
+func serveGRPC(container *app.AppContainer, tlsConfig *tls.Config) *grpc.Server {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen for gRPC on %s: %v", addr, err)
+	}
 
-	router := internal.NewRouter(container)
+	server := internal.NewGRPCServer(container, opts...)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Println(err)
+		}
+	}()
 
-	log.Println(http.ListenAndServe(":8080", router))
+	return server
 }
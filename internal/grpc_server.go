@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/cmd/app"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	iot_grpc "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/grpc"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"google.golang.org/grpc"
+)
+
+// NewGRPCServer serves the device, sensor, reading and simulator services
+// over gRPC, backed by the same use cases as the REST API and guarded the
+// same way: every method has the roles of its REST counterpart.
+func NewGRPCServer(container *app.AppContainer, opts ...grpc.ServerOption) *grpc.Server {
+	auth := iot_grpc.NewAuthenticator(
+		container.AuthUC,
+		container.CredentialUC,
+		container.Tenancy,
+		container.DeviceRepo,
+		container.APIAuthRequired,
+		container.DeviceAuthRequired,
+	)
+
+	viewer := iot_grpc.Policy{Roles: []domain.Role{domain.RoleViewer}}
+	operator := iot_grpc.Policy{Roles: []domain.Role{domain.RoleOperator}}
+	device := iot_grpc.Policy{Roles: []domain.Role{domain.RoleDevice}, Device: true}
+
+	auth.Allow(iotv1.DeviceService_CreateDevice_FullMethodName, operator)
+	auth.Allow(iotv1.DeviceService_GetDevice_FullMethodName, viewer)
+	auth.Allow(iotv1.DeviceService_ListDevices_FullMethodName, viewer)
+	auth.Allow(iotv1.DeviceService_UpdateDevice_FullMethodName, operator)
+	auth.Allow(iotv1.DeviceService_SetDeviceLabels_FullMethodName, operator)
+	auth.Allow(iotv1.DeviceService_SetDeviceStatus_FullMethodName, operator)
+	auth.Allow(iotv1.DeviceService_DeleteDevice_FullMethodName, operator)
+
+	auth.Allow(iotv1.SensorService_CreateSensor_FullMethodName, operator)
+	auth.Allow(iotv1.SensorService_GetSensor_FullMethodName, viewer)
+	auth.Allow(iotv1.SensorService_ListSensors_FullMethodName, viewer)
+	auth.Allow(iotv1.SensorService_UpdateSensorConfig_FullMethodName, operator)
+	auth.Allow(iotv1.SensorService_DeleteSensor_FullMethodName, operator)
+
+	auth.Allow(iotv1.ReadingService_ListReadings_FullMethodName, viewer)
+	auth.Allow(iotv1.ReadingService_GetReadingSeries_FullMethodName, viewer)
+	auth.Allow(iotv1.ReadingService_WatchReadings_FullMethodName, viewer)
+	auth.Allow(iotv1.ReadingService_IngestReading_FullMethodName, device)
+	auth.Allow(iotv1.ReadingService_IngestReadings_FullMethodName, device)
+
+	// Simulations put load on the database, so only operators start them.
+	auth.Allow(iotv1.SimulatorService_ControlSimulation_FullMethodName, operator)
+
+	server := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(auth.StreamInterceptor()),
+	)...)
+
+	iotv1.RegisterDeviceServiceServer(server, iot_grpc.NewDeviceService(container.DeviceUC))
+	iotv1.RegisterSensorServiceServer(server, iot_grpc.NewSensorService(container.SensorUC))
+	iotv1.RegisterReadingServiceServer(server, iot_grpc.NewReadingService(container.ReadingsUC))
+	iotv1.RegisterSimulatorServiceServer(server, iot_grpc.NewSimulatorService(container.SimulatorUC))
+
+	return server
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+// dialGRPC serves the gRPC API of the test container in memory.
+func dialGRPC(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(testContainer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestGRPCServer(t *testing.T) {
+	conn := dialGRPC(t)
+	devices := iotv1.NewDeviceServiceClient(conn)
+	sensors := iotv1.NewSensorServiceClient(conn)
+	readings := iotv1.NewReadingServiceClient(conn)
+	simulator := iotv1.NewSimulatorServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := devices.CreateDevice(ctx, &iotv1.CreateDeviceRequest{Name: "greenhouse", Type: "gateway"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	device := created.GetDevice()
+
+	if _, err := devices.UpdateDevice(ctx, &iotv1.UpdateDeviceRequest{Id: device.GetId(), Version: device.GetVersion(), Name: "greenhouse 1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = devices.UpdateDevice(ctx, &iotv1.UpdateDeviceRequest{Id: device.GetId(), Version: device.GetVersion(), Name: "greenhouse 2"})
+	if status.Code(err) != codes.FailedPrecondition || reason(err) != "device_version_conflict" {
+		t.Fatalf("expected a version conflict, got %v", err)
+	}
+
+	_, err = sensors.CreateSensor(ctx, &iotv1.CreateSensorRequest{DeviceId: device.GetId()})
+	if status.Code(err) != codes.InvalidArgument || len(fieldViolations(err)) != 2 {
+		t.Fatalf("expected the name and type to be invalid, got %v", err)
+	}
+
+	createdSensor, err := sensors.CreateSensor(ctx, &iotv1.CreateSensorRequest{
+		DeviceId: device.GetId(),
+		Name:     "soil",
+		Type:     "humidity",
+		Config:   &iotv1.SensorConfig{SamplingRateMs: 1000, Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sensor := createdSensor.GetSensor()
+
+	watch, err := readings.WatchReadings(ctx, &iotv1.WatchReadingsRequest{SensorIds: []string{sensor.GetId()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ingest, err := readings.IngestReadings(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for sequence, sensorID := range []string{sensor.GetId(), "ghost"} {
+		if err := ingest.Send(&iotv1.IngestReadingsRequest{SensorId: sensorID, Value: 41.5, Sequence: uint64(sequence)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := ingest.CloseSend(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, err := ingest.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.GetSequence() != 0 || stored.GetReading().GetSensorId() != sensor.GetId() {
+		t.Errorf("expected the reading to be stored, got %v", stored)
+	}
+
+	rejected, err := ingest.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rejected.GetSequence() != 1 || rejected.GetError().GetCode() != "sensor_not_found" {
+		t.Errorf("expected the reading for an unknown sensor to be rejected, got %v", rejected)
+	}
+
+	watched, err := watch.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if watched.GetReading().GetId() != stored.GetReading().GetId() {
+		t.Errorf("expected to watch reading %s, got %s", stored.GetReading().GetId(), watched.GetReading().GetId())
+	}
+
+	listed, err := readings.ListReadings(ctx, &iotv1.ListReadingsRequest{SensorId: sensor.GetId(), From: 0, To: 10, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(listed.GetReadings()) != 1 || listed.GetReadings()[0].GetValue() != 41.5 {
+		t.Errorf("expected the stored reading, got %v", listed.GetReadings())
+	}
+
+	_, err = simulator.ControlSimulation(ctx, &iotv1.ControlSimulationRequest{SensorId: sensor.GetId()})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an unspecified action to be invalid, got %v", err)
+	}
+
+	for _, action := range []iotv1.SimulationAction{iotv1.SimulationAction_SIMULATION_ACTION_START, iotv1.SimulationAction_SIMULATION_ACTION_STOP} {
+		if _, err := simulator.ControlSimulation(ctx, &iotv1.ControlSimulationRequest{SensorId: sensor.GetId(), Action: action}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
+
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			return badRequest.GetFieldViolations()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package application
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+)
+
+// MaxAuditEntries caps how many audit entries one query returns.
+const MaxAuditEntries = 1000
+
+// AuditUseCase queries the audit log. Entries are appended by the use cases
+// that change something, through their TenantScope.
+type AuditUseCase struct {
+	auditRepo domain.AuditRepository
+}
+
+func NewAuditUseCase(auditRepo domain.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{
+		auditRepo: auditRepo,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the entries of the
+// tenant. The zero scope returns uc itself, which sees the entries of every
+// tenant and those of the whole deployment.
+func (uc *AuditUseCase) ForTenant(scope TenantScope) *AuditUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.auditRepo = scopeAudit(uc.auditRepo, scope.Tenant)
+
+	return &scoped
+}
+
+// ListEntries returns the entries passing the filter, newest first. The limit
+// defaults to and is capped at MaxAuditEntries.
+func (uc *AuditUseCase) ListEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if filter.Limit < 0 {
+		return nil, fmt.Errorf("%w: limit must not be negative", domain.ErrInvalidAuditFilter)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidAuditFilter)
+	}
+
+	if filter.Limit == 0 || filter.Limit > MaxAuditEntries {
+		filter.Limit = MaxAuditEntries
+	}
+
+	return uc.auditRepo.Find(filter)
+}
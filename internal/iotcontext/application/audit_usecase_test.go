@@ -0,0 +1,218 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit_RecordsChanges(t *testing.T) {
+	auditRepo := persistence.NewInMemoryAuditRepository()
+	tenancy := NewTenancy(domain.TenantQuotas{}, auditRepo, nil)
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	simulatorRepo := NewMockSimulatorRepository()
+	publisher := NewMockEventPublisher()
+
+	alice := domain.AuditActor{Subject: "alice", Role: domain.RoleOperator, RequestID: "req-1", SourceIP: "10.0.0.7"}
+	scope := tenancy.Scope("acme").By(alice)
+	devices := newDeviceUseCase(deviceRepo, sensorRepo, simulatorRepo, publisher).ForTenant(scope)
+	sensors := NewSensorUseCase(sensorRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, simulatorRepo, NewMockMetrics(), publisher).ForTenant(scope)
+	simulator := NewSimulatorUseCase(sensorRepo, simulatorRepo, publisher).ForTenant(scope)
+
+	if _, err := devices.CreateDevice("gateway-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := domain.SensorConfig{SensorID: "s1", SamplingRateMs: 1000, Enabled: true}
+	if err := sensors.CreateSensor("s1", "gateway-1", "Temperature", domain.Temperature, config, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config.SamplingRateMs = 5000
+	if _, err := sensors.UpdateSensorConfigById("s1", config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := simulator.ControlSensor("s1", "start"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A failed change is not recorded.
+	if err := simulator.ControlSensor("s1", "explode"); !errors.Is(err, domain.ErrInvalidAction) {
+		t.Fatalf("expected ErrInvalidAction, got %v", err)
+	}
+
+	entries, err := auditRepo.Find(domain.AuditFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []domain.AuditAction{domain.AuditDeviceCreate, domain.AuditSensorCreate, domain.AuditSensorConfigure, domain.AuditSensorControl}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+
+	byAction := make(map[domain.AuditAction]*domain.AuditEntry)
+	for _, entry := range entries {
+		byAction[entry.Action] = entry
+
+		if entry.Actor != alice || entry.TenantID != "acme" {
+			t.Errorf("expected %s by alice in acme, got %+v in %s", entry.Action, entry.Actor, entry.TenantID)
+		}
+	}
+
+	for _, action := range expected {
+		if byAction[action] == nil {
+			t.Errorf("expected a %s entry, got %+v", action, entries)
+		}
+	}
+
+	created := byAction[domain.AuditDeviceCreate]
+	if created == nil || created.Before != nil || created.After == nil || created.ResourceType != "device" || created.ResourceID != "gateway-1" {
+		t.Errorf("unexpected creation entry %+v", created)
+	}
+
+	configured := byAction[domain.AuditSensorConfigure]
+	found := false
+	if configured != nil {
+		for _, change := range configured.Changes {
+			if change.Field == "config.sampling_rate_ms" {
+				found = string(change.Before) == "1000" && string(change.After) == "5000"
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected the sampling rate change from 1000 to 5000, got %+v", configured)
+	}
+}
+
+func TestAudit_ScopeWithoutActor(t *testing.T) {
+	auditRepo := persistence.NewInMemoryAuditRepository()
+	tenancy := NewTenancy(domain.TenantQuotas{}, auditRepo, nil)
+	devices := newDeviceUseCase(persistence.NewInMemoryDeviceRepository(), persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), NewMockEventPublisher())
+
+	if _, err := devices.ForTenant(tenancy.Scope("acme")).CreateDevice("gateway-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := devices.ChangeDeviceStatus("gateway-1", domain.DeviceActive); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries, _ := auditRepo.Find(domain.AuditFilter{}); len(entries) != 0 {
+		t.Errorf("expected nothing audited, got %+v", entries)
+	}
+}
+
+func TestAudit_AppendFailure(t *testing.T) {
+	auditRepo := NewFaultyAuditRepository()
+	auditRepo.appendErr = errors.New("disk full")
+	metrics := &MockAuditMetrics{}
+	tenancy := NewTenancy(domain.TenantQuotas{}, auditRepo, metrics)
+	publisher := NewMockEventPublisher()
+	devices := newDeviceUseCase(persistence.NewInMemoryDeviceRepository(), persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), publisher)
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	// The device is stored before it is audited: failing the request would
+	// report a change that took effect as one that did not.
+	scope := tenancy.Scope("acme").By(domain.AuditActor{Subject: "alice"})
+	if _, err := devices.ForTenant(scope).CreateDevice("gateway-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := devices.ForTenant(scope).CreateDevice("gateway-2", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(publisher.events) != 2 {
+		t.Errorf("expected the changes to be published, got %+v", publisher.events)
+	}
+
+	if !strings.Contains(logged.String(), "device.create of gateway-1 by alice, will retry: disk full") {
+		t.Errorf("expected the audit failure to be logged, got %q", logged.String())
+	}
+
+	if metrics.failures != 2 || metrics.backlog != 2 {
+		t.Errorf("expected 2 failures and 2 queued entries, got %+v", metrics)
+	}
+
+	if err := tenancy.RetryAudit(); err == nil {
+		t.Error("expected error but got none")
+	}
+
+	auditRepo.appendErr = nil
+	if err := tenancy.RetryAudit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := auditRepo.Find(domain.AuditFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Newest first: the queue keeps the order the changes were made in.
+	if len(entries) != 2 || entries[0].ResourceID != "gateway-2" || entries[1].ResourceID != "gateway-1" {
+		t.Errorf("expected both entries appended in order, got %+v", entries)
+	}
+
+	if metrics.backlog != 0 {
+		t.Errorf("expected an empty queue, got %d", metrics.backlog)
+	}
+}
+
+func TestAuditUseCase_ListEntries(t *testing.T) {
+	auditRepo := persistence.NewInMemoryAuditRepository()
+	tenancy := NewTenancy(domain.TenantQuotas{}, auditRepo, nil)
+	useCase := NewAuditUseCase(auditRepo)
+	now := time.Now()
+
+	for _, tenant := range []domain.TenantID{"acme", "globex", "acme"} {
+		scope := tenancy.Scope(tenant).By(domain.AuditActor{Subject: "alice"})
+		scope.record(domain.AuditGroupCreate, "g1", nil, []byte(`{"name":"Floor 1"}`))
+	}
+
+	tests := []struct {
+		name        string
+		scope       TenantScope
+		filter      domain.AuditFilter
+		expected    int
+		expectError bool
+	}{
+		{name: "tenant", scope: tenancy.Scope("acme"), expected: 2},
+		{name: "whole deployment", expected: 3},
+		{name: "filter", scope: tenancy.Scope("globex"), filter: domain.AuditFilter{Actor: "bob"}, expected: 0},
+		{name: "limit", filter: domain.AuditFilter{Limit: 1}, expected: 1},
+		{name: "negative limit", filter: domain.AuditFilter{Limit: -1}, expectError: true},
+		{name: "empty time range", filter: domain.AuditFilter{From: now, To: now}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := useCase.ForTenant(tt.scope).ListEntries(tt.filter)
+
+			if tt.expectError {
+				if !errors.Is(err, domain.ErrInvalidAuditFilter) {
+					t.Errorf("expected ErrInvalidAuditFilter, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(entries) != tt.expected {
+				t.Errorf("expected %d entries, got %d", tt.expected, len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package application
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+// IssuedAPIKey carries the plain key, which is not stored anywhere.
+type IssuedAPIKey struct {
+	*domain.APIKey
+	Key string `json:"key"`
+}
+
+type AuthUseCase struct {
+	apiKeyRepo   domain.APIKeyRepository
+	tokens       domain.TokenVerifier
+	bootstrapKey []byte
+	scope        TenantScope
+}
+
+// NewAuthUseCase builds the use case. tokens may be nil, in which case bearer
+// tokens are rejected. bootstrapKey, when set, is an admin key for the whole
+// deployment that is not stored: it is how the first API keys get created.
+func NewAuthUseCase(apiKeyRepo domain.APIKeyRepository, tokens domain.TokenVerifier, bootstrapKey string) *AuthUseCase {
+	uc := &AuthUseCase{
+		apiKeyRepo: apiKeyRepo,
+		tokens:     tokens,
+	}
+
+	if bootstrapKey != "" {
+		sum := sha256.Sum256([]byte(bootstrapKey))
+		uc.bootstrapKey = sum[:]
+	}
+
+	return uc
+}
+
+// ForTenant returns a copy of the use case that only manages the API keys of
+// the tenant. The zero scope returns uc itself.
+func (uc *AuthUseCase) ForTenant(scope TenantScope) *AuthUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.apiKeyRepo = scopeAPIKeys(uc.apiKeyRepo, scope.Tenant)
+
+	return &scoped
+}
+
+// CreateAPIKey creates a key with the given role. It never expires when ttl
+// is zero.
+func (uc *AuthUseCase) CreateAPIKey(name string, role domain.Role, ttl time.Duration, now time.Time) (*IssuedAPIKey, error) {
+	secret, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := domain.NewAPIKey(domain.APIKeyID(uuid.NewString()), name, role, secret, ttl, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.apiKeyRepo.Save(key); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditAPIKeyCreate, string(key.ID), nil, snapshot(key))
+
+	return &IssuedAPIKey{APIKey: key, Key: domain.FormatAPIKey(key.ID, secret)}, nil
+}
+
+// ListAPIKeys returns the keys, revoked and expired ones included, oldest
+// first.
+func (uc *AuthUseCase) ListAPIKeys() ([]*domain.APIKey, error) {
+	return uc.apiKeyRepo.FindAll()
+}
+
+func (uc *AuthUseCase) RevokeAPIKey(id domain.APIKeyID, now time.Time) (*domain.APIKey, error) {
+	key, err := uc.apiKeyRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(key)
+	if err := key.Revoke(now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.apiKeyRepo.Update(key); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditAPIKeyRevoke, string(key.ID), before, snapshot(key))
+
+	return key, nil
+}
+
+// AuthenticateAPIKey returns who a request made with the key acts as. Every
+// failure is reported as ErrAuthenticationFailed so callers cannot tell an
+// unknown key from a revoked one.
+func (uc *AuthUseCase) AuthenticateAPIKey(apiKey string, now time.Time) (domain.Principal, error) {
+	if uc.bootstrapKey != nil {
+		sum := sha256.Sum256([]byte(apiKey))
+		if hmac.Equal(sum[:], uc.bootstrapKey) {
+			return domain.Principal{Subject: "bootstrap", Role: domain.RoleAdmin}, nil
+		}
+	}
+
+	id, secret, err := domain.ParseAPIKey(apiKey)
+	if err != nil {
+		return domain.Principal{}, domain.ErrAuthenticationFailed
+	}
+
+	key, err := uc.apiKeyRepo.FindByID(id)
+	if err != nil {
+		return domain.Principal{}, domain.ErrAuthenticationFailed
+	}
+
+	if !key.IsActive(now) || !key.Verify(secret) {
+		return domain.Principal{}, domain.ErrAuthenticationFailed
+	}
+
+	return key.Principal(), nil
+}
+
+// AuthenticateToken returns who a bearer token was issued to.
+func (uc *AuthUseCase) AuthenticateToken(token string, now time.Time) (domain.Principal, error) {
+	if uc.tokens == nil {
+		return domain.Principal{}, domain.ErrAuthenticationFailed
+	}
+
+	return uc.tokens.Verify(token, now)
+}
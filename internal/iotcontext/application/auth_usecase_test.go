@@ -0,0 +1,115 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"testing"
+	"time"
+)
+
+func TestAuthUseCase_AuthenticateAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tenancy := NewTenancy(domain.TenantQuotas{}, nil, nil)
+	useCase := NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), nil, "bootstrap-s3cret")
+
+	issued, err := useCase.ForTenant(tenancy.Scope("acme")).CreateAPIKey("ingest", domain.RoleOperator, time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	revoked, _ := useCase.CreateAPIKey("old", domain.RoleAdmin, 0, now)
+	if _, err := useCase.RevokeAPIKey(revoked.ID, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, secret, _ := domain.ParseAPIKey(issued.Key)
+
+	tests := []struct {
+		name     string
+		key      string
+		at       time.Time
+		expected domain.Principal
+		rejected bool
+	}{
+		{name: "issued key", key: issued.Key, at: now, expected: domain.Principal{Subject: "api-key:" + string(issued.ID), Role: domain.RoleOperator, TenantID: "acme"}},
+		{name: "bootstrap key", key: "bootstrap-s3cret", at: now, expected: domain.Principal{Subject: "bootstrap", Role: domain.RoleAdmin}},
+		{name: "expired", key: issued.Key, at: now.Add(time.Hour), rejected: true},
+		{name: "revoked", key: revoked.Key, at: now, rejected: true},
+		{name: "wrong secret", key: domain.FormatAPIKey(issued.ID, "guess"), at: now, rejected: true},
+		{name: "unknown id", key: domain.FormatAPIKey("ghost", secret), at: now, rejected: true},
+		{name: "malformed", key: "not-a-key", at: now, rejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := useCase.AuthenticateAPIKey(tt.key, tt.at)
+
+			if tt.rejected {
+				if !errors.Is(err, domain.ErrAuthenticationFailed) {
+					t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if principal != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, principal)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_ManageAPIKeysInTenant(t *testing.T) {
+	now := time.Now()
+	tenancy := NewTenancy(domain.TenantQuotas{}, nil, nil)
+	useCase := NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), nil, "")
+	acme := useCase.ForTenant(tenancy.Scope("acme"))
+	globex := useCase.ForTenant(tenancy.Scope("globex"))
+
+	issued, err := acme.CreateAPIKey("dashboard", domain.RoleViewer, 0, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if issued.TenantID != "acme" || issued.Key == "" {
+		t.Errorf("expected a key of acme handed out once, got %+v", issued)
+	}
+
+	if keys, _ := globex.ListAPIKeys(); len(keys) != 0 {
+		t.Errorf("expected globex to see no keys, got %+v", keys)
+	}
+
+	if _, err := globex.RevokeAPIKey(issued.ID, now); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+	}
+
+	if _, err := acme.RevokeAPIKey(issued.ID, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := acme.RevokeAPIKey(issued.ID, now); !errors.Is(err, domain.ErrAPIKeyRevoked) {
+		t.Errorf("expected ErrAPIKeyRevoked, got %v", err)
+	}
+
+	if _, err := acme.CreateAPIKey("dashboard", "root", 0, now); !errors.Is(err, domain.ErrInvalidRole) {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+}
+
+func TestAuthUseCase_AuthenticateToken(t *testing.T) {
+	alice := domain.Principal{Subject: "alice", Role: domain.RoleViewer, TenantID: "acme"}
+
+	withTokens := NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), &MockTokenVerifier{principals: map[string]domain.Principal{"token": alice}}, "")
+	if principal, err := withTokens.AuthenticateToken("token", time.Now()); err != nil || principal != alice {
+		t.Errorf("expected alice, got %+v, %v", principal, err)
+	}
+
+	withoutTokens := NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), nil, "")
+	if _, err := withoutTokens.AuthenticateToken("token", time.Now()); !errors.Is(err, domain.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+}
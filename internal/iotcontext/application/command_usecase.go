@@ -0,0 +1,223 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"log"
+	"time"
+)
+
+// CommandUseCase sends commands to devices and tracks them until the device
+// reports a result or the command expires. Delivery is at least once: pending
+// commands are dispatched again on every Run until they are acknowledged, so
+// devices must ignore command ids they have already executed.
+type CommandUseCase struct {
+	deviceRepo  domain.DeviceRepository
+	commandRepo domain.CommandRepository
+	channel     domain.CommandChannel
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+	scope       TenantScope
+}
+
+func NewCommandUseCase(
+	deviceRepo domain.DeviceRepository,
+	commandRepo domain.CommandRepository,
+	channel domain.CommandChannel,
+	defaultTTL time.Duration,
+	maxTTL time.Duration,
+) *CommandUseCase {
+	return &CommandUseCase{
+		deviceRepo:  deviceRepo,
+		commandRepo: commandRepo,
+		channel:     channel,
+		defaultTTL:  defaultTTL,
+		maxTTL:      maxTTL,
+	}
+}
+
+// ForTenant returns a copy of the use case that only reaches the commands of
+// the devices of the tenant. The zero scope returns uc itself.
+func (uc *CommandUseCase) ForTenant(scope TenantScope) *CommandUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+
+	return &scoped
+}
+
+func (uc *CommandUseCase) CreateCommand(
+	id domain.CommandID,
+	deviceID domain.DeviceID,
+	name string,
+	params map[string]interface{},
+	ttl time.Duration,
+) (*domain.Command, error) {
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.ID == "" {
+		return nil, domain.ErrDeviceNotFound
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+
+	if ttl == 0 {
+		ttl = uc.defaultTTL
+	}
+	if uc.maxTTL > 0 && ttl > uc.maxTTL {
+		return nil, fmt.Errorf("%w: ttl cannot exceed %s", domain.ErrInvalidCommand, uc.maxTTL)
+	}
+
+	command, err := domain.NewCommand(id, deviceID, name, params, ttl, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.commandRepo.Save(command); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditCommandCreate, string(command.ID), nil, snapshot(command))
+
+	// A failed dispatch is retried by Run; the command is already stored.
+	if err := uc.channel.Dispatch(command); err != nil {
+		log.Printf("command %s: dispatch failed, will retry: %v", command.ID, err)
+	}
+
+	return command, nil
+}
+
+func (uc *CommandUseCase) GetCommand(deviceID domain.DeviceID, id domain.CommandID) (*domain.Command, error) {
+	if _, err := uc.deviceRepo.FindByID(deviceID); err != nil {
+		return nil, err
+	}
+
+	command, err := uc.commandRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.DeviceID != deviceID {
+		return nil, domain.ErrCommandNotFound
+	}
+
+	return command, nil
+}
+
+func (uc *CommandUseCase) ListCommands(deviceID domain.DeviceID, limit int) ([]*domain.Command, error) {
+	if _, err := uc.deviceRepo.FindByID(deviceID); err != nil {
+		return nil, err
+	}
+
+	return uc.commandRepo.FindByDeviceID(deviceID, limit)
+}
+
+// HandleReply applies an ack or a result sent by a device. Replies for
+// commands of another device, or arriving after the command finished, are
+// rejected.
+func (uc *CommandUseCase) HandleReply(reply domain.CommandReply, now time.Time) error {
+	command, err := uc.commandRepo.FindByID(reply.CommandID)
+	if err != nil {
+		return err
+	}
+
+	if command.DeviceID != reply.DeviceID {
+		return domain.ErrCommandNotFound
+	}
+
+	if !command.IsFinal() && command.IsExpiredAt(now) {
+		if err := command.Expire(now); err != nil {
+			return err
+		}
+
+		if err := uc.commandRepo.Update(command); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: %s", domain.ErrCommandFinished, command.Status)
+	}
+
+	switch reply.Status {
+	case domain.CommandReplyAck:
+		err = command.MarkDelivered(now)
+	case domain.CommandReplySucceeded:
+		err = command.Complete(true, reply.Result, "", now)
+	case domain.CommandReplyFailed:
+		err = command.Complete(false, reply.Result, reply.Error, now)
+	default:
+		err = fmt.Errorf("%w: unknown reply status %q", domain.ErrInvalidCommand, reply.Status)
+	}
+	if err != nil {
+		return err
+	}
+
+	return uc.commandRepo.Update(command)
+}
+
+// Run expires overdue commands and dispatches again those the device has not
+// acknowledged yet.
+func (uc *CommandUseCase) Run(now time.Time) error {
+	commands, err := uc.commandRepo.FindOpen()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, command := range commands {
+		if command.IsExpiredAt(now) {
+			if err := command.Expire(now); err != nil {
+				return err
+			}
+
+			if err := uc.commandRepo.Update(command); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if command.Status == domain.CommandPending {
+			if err := uc.channel.Dispatch(command); err != nil {
+				errs = append(errs, fmt.Errorf("command %s: %w", command.ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (uc *CommandUseCase) Start(every time.Duration) func() {
+	stopCh := make(chan struct{})
+	ticker := time.NewTicker(every)
+
+	run := func() {
+		if err := uc.Run(time.Now().UTC()); err != nil {
+			log.Printf("device commands job failed: %v", err)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+	}
+}
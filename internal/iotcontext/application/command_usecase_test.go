@@ -0,0 +1,175 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"testing"
+	"time"
+)
+
+func newCommandFixture(t *testing.T) (*CommandUseCase, domain.CommandRepository, *MockCommandChannel) {
+	t.Helper()
+
+	commandRepo := persistence.NewInMemoryCommandRepository()
+	channel := &MockCommandChannel{}
+
+	return NewCommandUseCase(newGatewayRepository(t, "device-123"), commandRepo, channel, 30*time.Second, time.Hour), commandRepo, channel
+}
+
+func TestCommandUseCase_CreateCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		deviceID    domain.DeviceID
+		ttl         time.Duration
+		dispatchErr error
+		expectTTL   time.Duration
+		expectError error
+	}{
+		{name: "default ttl", deviceID: "device-123", expectTTL: 30 * time.Second},
+		{name: "custom ttl", deviceID: "device-123", ttl: time.Minute, expectTTL: time.Minute},
+		{name: "dispatch failure keeps the command", deviceID: "device-123", dispatchErr: errors.New("broker down"), expectTTL: 30 * time.Second},
+		{name: "ttl above the maximum", deviceID: "device-123", ttl: 2 * time.Hour, expectError: domain.ErrInvalidCommand},
+		{name: "unknown device", deviceID: "device-404", expectError: domain.ErrDeviceNotFound},
+		{name: "decommissioned device", deviceID: "device-456", expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, commandRepo, channel := newCommandFixture(t)
+			channel.dispatchErr = tt.dispatchErr
+
+			command, err := useCase.CreateCommand("command-1", tt.deviceID, domain.CommandReboot, nil, tt.ttl)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				if _, err := commandRepo.FindByID("command-1"); !errors.Is(err, domain.ErrCommandNotFound) {
+					t.Errorf("expected no stored command, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ttl := command.ExpiresAt.Sub(command.CreatedAt); ttl != tt.expectTTL {
+				t.Errorf("expected ttl %s, got %s", tt.expectTTL, ttl)
+			}
+
+			if _, err := commandRepo.FindByID(command.ID); err != nil {
+				t.Errorf("expected the command to be stored: %v", err)
+			}
+
+			if dispatched := len(channel.dispatched); (tt.dispatchErr == nil) != (dispatched == 1) {
+				t.Errorf("unexpected dispatches %v", channel.dispatched)
+			}
+		})
+	}
+}
+
+func TestCommandUseCase_HandleReply(t *testing.T) {
+	tests := []struct {
+		name        string
+		replies     []domain.CommandReply
+		after       time.Duration
+		expected    domain.CommandStatus
+		expectError error
+	}{
+		{
+			name:     "ack marks the command delivered",
+			replies:  []domain.CommandReply{{Status: domain.CommandReplyAck}},
+			expected: domain.CommandDelivered,
+		},
+		{
+			name:     "result completes the command",
+			replies:  []domain.CommandReply{{Status: domain.CommandReplyAck}, {Status: domain.CommandReplySucceeded, Result: map[string]interface{}{"sensors": 1}}},
+			expected: domain.CommandSucceeded,
+		},
+		{
+			name:     "failure keeps the device error",
+			replies:  []domain.CommandReply{{Status: domain.CommandReplyFailed, Error: "sensor busy"}},
+			expected: domain.CommandFailed,
+		},
+		{
+			name:        "late reply expires the command",
+			replies:     []domain.CommandReply{{Status: domain.CommandReplySucceeded}},
+			after:       time.Minute,
+			expected:    domain.CommandExpired,
+			expectError: domain.ErrCommandFinished,
+		},
+		{
+			name:        "another device cannot answer",
+			replies:     []domain.CommandReply{{DeviceID: "device-999", Status: domain.CommandReplySucceeded}},
+			expected:    domain.CommandPending,
+			expectError: domain.ErrCommandNotFound,
+		},
+		{
+			name:        "unknown reply status",
+			replies:     []domain.CommandReply{{Status: "done"}},
+			expected:    domain.CommandPending,
+			expectError: domain.ErrInvalidCommand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, commandRepo, _ := newCommandFixture(t)
+
+			command, err := useCase.CreateCommand("command-1", "device-123", domain.CommandReboot, nil, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			now := command.CreatedAt.Add(tt.after)
+			for _, reply := range tt.replies {
+				reply.CommandID = command.ID
+				if reply.DeviceID == "" {
+					reply.DeviceID = command.DeviceID
+				}
+				err = useCase.HandleReply(reply, now)
+			}
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored, _ := commandRepo.FindByID(command.ID)
+			if stored.Status != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, stored.Status)
+			}
+		})
+	}
+}
+
+func TestCommandUseCase_Run(t *testing.T) {
+	useCase, commandRepo, channel := newCommandFixture(t)
+
+	pending, _ := useCase.CreateCommand("command-1", "device-123", domain.CommandReboot, nil, time.Minute)
+	delivered, _ := useCase.CreateCommand("command-2", "device-123", domain.CommandReboot, nil, time.Minute)
+	overdue, _ := useCase.CreateCommand("command-3", "device-123", domain.CommandReboot, nil, time.Second)
+	_ = useCase.HandleReply(domain.CommandReply{CommandID: delivered.ID, DeviceID: delivered.DeviceID, Status: domain.CommandReplyAck}, time.Now())
+	channel.dispatched = nil
+
+	if err := useCase.Run(time.Now().Add(10 * time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(channel.dispatched) != 1 || channel.dispatched[0] != pending.ID {
+		t.Errorf("expected only the pending command to be dispatched again, got %v", channel.dispatched)
+	}
+
+	if stored, _ := commandRepo.FindByID(overdue.ID); stored.Status != domain.CommandExpired {
+		t.Errorf("expected the overdue command to expire, got %s", stored.Status)
+	}
+
+	if stored, _ := commandRepo.FindByID(delivered.ID); stored.Status != domain.CommandDelivered {
+		t.Errorf("expected the delivered command to be left alone, got %s", stored.Status)
+	}
+}
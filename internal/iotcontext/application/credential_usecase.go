@@ -0,0 +1,355 @@
+package application
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+// secretBytes is the entropy of generated HMAC secrets and claim tokens.
+const secretBytes = 32
+
+// IssuedCredential is a credential as handed to the device. It is the only
+// time the secret or the private key leave the server.
+type IssuedCredential struct {
+	*domain.Credential
+	Secret        string `json:"secret,omitempty"`
+	Certificate   string `json:"certificate,omitempty"`
+	PrivateKey    string `json:"private_key,omitempty"`
+	CACertificate string `json:"ca_certificate,omitempty"`
+}
+
+// IssuedClaimToken carries the plain token, which is not stored anywhere.
+type IssuedClaimToken struct {
+	*domain.ClaimToken
+	Token string `json:"token"`
+}
+
+// deviceCreator is the part of DeviceUseCase self-registration needs.
+type deviceCreator interface {
+	CreateDeviceIn(scope TenantScope, id domain.DeviceID, name string, typ string, labels domain.Labels) (*domain.Device, error)
+}
+
+type CredentialUseCase struct {
+	deviceRepo     domain.DeviceRepository
+	credentialRepo domain.CredentialRepository
+	claimTokenRepo domain.ClaimTokenRepository
+	devices        deviceCreator
+	ca             domain.CertificateAuthority
+	eventPublisher domain.EventPublisher
+	rotationGrace  time.Duration
+	scope          TenantScope
+}
+
+// NewCredentialUseCase builds the use case. ca may be nil, in which case only
+// HMAC credentials can be issued.
+func NewCredentialUseCase(
+	deviceRepo domain.DeviceRepository,
+	credentialRepo domain.CredentialRepository,
+	claimTokenRepo domain.ClaimTokenRepository,
+	devices deviceCreator,
+	ca domain.CertificateAuthority,
+	publisher domain.EventPublisher,
+	rotationGrace time.Duration,
+) *CredentialUseCase {
+	return &CredentialUseCase{
+		deviceRepo:     deviceRepo,
+		credentialRepo: credentialRepo,
+		claimTokenRepo: claimTokenRepo,
+		devices:        devices,
+		ca:             ca,
+		eventPublisher: publisher,
+		rotationGrace:  rotationGrace,
+	}
+}
+
+// ForTenant returns a copy of the use case that only manages the credentials
+// of the devices of the tenant and creates claim tokens for it. The zero
+// scope returns uc itself.
+func (uc *CredentialUseCase) ForTenant(scope TenantScope) *CredentialUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+// IssueCredential gives an existing device a new credential. Credentials it
+// already has keep working.
+func (uc *CredentialUseCase) IssueCredential(deviceID domain.DeviceID, typ domain.CredentialType, now time.Time) (*IssuedCredential, error) {
+	device, err := uc.findDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+
+	return uc.issue(device.ID, typ, now)
+}
+
+func (uc *CredentialUseCase) ListCredentials(deviceID domain.DeviceID) ([]*domain.Credential, error) {
+	if _, err := uc.findDevice(deviceID); err != nil {
+		return nil, err
+	}
+
+	return uc.credentialRepo.FindByDeviceID(deviceID)
+}
+
+// RotateCredential issues a credential of the same type and lets the old one
+// work for the rotation grace period, so the device can switch without
+// dropping requests.
+func (uc *CredentialUseCase) RotateCredential(deviceID domain.DeviceID, credentialID domain.CredentialID, now time.Time) (*IssuedCredential, error) {
+	current, err := uc.findCredential(deviceID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !current.IsActive(now) {
+		return nil, fmt.Errorf("%w: credential is no longer active", domain.ErrInvalidCredential)
+	}
+
+	issued, err := uc.IssueCredential(deviceID, current.Type, now)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(current)
+	if err := current.Retire(now.Add(uc.rotationGrace)); err != nil {
+		return nil, err
+	}
+
+	if err := uc.credentialRepo.Update(current); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditCredentialRotate, string(current.ID), before, snapshot(current))
+
+	return issued, nil
+}
+
+func (uc *CredentialUseCase) RevokeCredential(deviceID domain.DeviceID, credentialID domain.CredentialID, now time.Time) (*domain.Credential, error) {
+	credential, err := uc.findCredential(deviceID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(credential)
+	if err := credential.Revoke(now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.credentialRepo.Update(credential); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditCredentialRevoke, string(credential.ID), before, snapshot(credential))
+
+	event := &domain.DeviceCredentialRevokedEvent{
+		DeviceID:     credential.DeviceID,
+		CredentialID: credential.ID,
+	}
+
+	return credential, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// CreateClaimToken creates a token that lets up to maxUses devices of the
+// given type register themselves before ttl runs out.
+func (uc *CredentialUseCase) CreateClaimToken(deviceType string, maxUses int, ttl time.Duration, now time.Time) (*IssuedClaimToken, error) {
+	token, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := domain.NewClaimToken(uuid.NewString(), token, deviceType, maxUses, ttl, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.scope.Tenant != "" {
+		claim.TenantID = uc.scope.Tenant
+	}
+
+	if err := uc.claimTokenRepo.Save(claim); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditClaimTokenCreate, claim.ID, nil, snapshot(claim))
+
+	return &IssuedClaimToken{ClaimToken: claim, Token: token}, nil
+}
+
+// Register lets a device enroll itself with a claim token. The device gets
+// the type and the tenant the token was created for and its first
+// credential.
+func (uc *CredentialUseCase) Register(token string, name string, typ domain.CredentialType, now time.Time) (*domain.Device, *IssuedCredential, error) {
+	if name == "" {
+		return nil, nil, fmt.Errorf("%w: name empty", domain.ErrInvalidCredential)
+	}
+
+	if typ == domain.CredentialX509 && uc.ca == nil {
+		return nil, nil, fmt.Errorf("%w: x509 credentials are not enabled", domain.ErrInvalidCredential)
+	}
+
+	claim, err := uc.claimTokenRepo.Redeem(domain.HashClaimToken(token), now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The device registers itself: what it creates is audited for the claim
+	// token, from wherever the request came.
+	actor := uc.scope.Actor
+	actor.Subject, actor.Role = "claim-token:"+claim.ID, ""
+	scope := uc.scope.For(claim.TenantID).By(actor)
+
+	device, err := uc.devices.CreateDeviceIn(scope, domain.DeviceID(uuid.NewString()), name, claim.DeviceType, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issued, err := uc.ForTenant(scope).issue(device.ID, typ, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return device, issued, nil
+}
+
+// AuthenticateSignature returns the device that signed a request with the
+// given HMAC credential. Every failure is reported as ErrUnauthenticated so
+// callers cannot tell an unknown credential from a bad signature.
+func (uc *CredentialUseCase) AuthenticateSignature(
+	credentialID domain.CredentialID,
+	signature string,
+	method string,
+	target string,
+	timestamp time.Time,
+	body []byte,
+	now time.Time,
+) (domain.DeviceID, error) {
+	credential, err := uc.credentialRepo.FindByID(credentialID)
+	if err != nil {
+		return "", domain.ErrUnauthenticated
+	}
+
+	if !credential.IsActive(now) || !credential.VerifySignature(signature, method, target, timestamp, body, now) {
+		return "", domain.ErrUnauthenticated
+	}
+
+	return credential.DeviceID, nil
+}
+
+// AuthenticateCertificate returns the device a client certificate was issued
+// to. The TLS handshake has already checked the chain; this catches revoked
+// and rotated certificates.
+func (uc *CredentialUseCase) AuthenticateCertificate(fingerprint string, now time.Time) (domain.DeviceID, error) {
+	credential, err := uc.credentialRepo.FindByFingerprint(fingerprint)
+	if err != nil {
+		return "", domain.ErrUnauthenticated
+	}
+
+	if credential.Type != domain.CredentialX509 || !credential.IsActive(now) {
+		return "", domain.ErrUnauthenticated
+	}
+
+	return credential.DeviceID, nil
+}
+
+func (uc *CredentialUseCase) issue(deviceID domain.DeviceID, typ domain.CredentialType, now time.Time) (*IssuedCredential, error) {
+	id := domain.CredentialID(uuid.NewString())
+
+	var credential *domain.Credential
+	issued := &IssuedCredential{}
+
+	switch typ {
+	case domain.CredentialHMAC:
+		secret, err := randomHex()
+		if err != nil {
+			return nil, err
+		}
+
+		credential, err = domain.NewHMACCredential(id, deviceID, secret, now)
+		if err != nil {
+			return nil, err
+		}
+		issued.Secret = secret
+	case domain.CredentialX509:
+		if uc.ca == nil {
+			return nil, fmt.Errorf("%w: x509 credentials are not enabled", domain.ErrInvalidCredential)
+		}
+
+		certificate, err := uc.ca.Issue(deviceID, now)
+		if err != nil {
+			return nil, err
+		}
+
+		credential, err = domain.NewX509Credential(id, deviceID, certificate.Fingerprint, certificate.NotAfter, now)
+		if err != nil {
+			return nil, err
+		}
+		issued.Certificate = certificate.CertificatePEM
+		issued.PrivateKey = certificate.PrivateKeyPEM
+		issued.CACertificate = uc.ca.CertificatePEM()
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", domain.ErrInvalidCredential, typ)
+	}
+
+	if err := uc.credentialRepo.Save(credential); err != nil {
+		return nil, err
+	}
+	issued.Credential = credential
+
+	uc.scope.record(domain.AuditCredentialIssue, string(credential.ID), nil, snapshot(credential))
+
+	event := &domain.DeviceCredentialIssuedEvent{
+		DeviceID:     credential.DeviceID,
+		CredentialID: credential.ID,
+		Type:         credential.Type,
+	}
+
+	return issued, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+func (uc *CredentialUseCase) findDevice(deviceID domain.DeviceID) (*domain.Device, error) {
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.ID == "" {
+		return nil, domain.ErrDeviceNotFound
+	}
+
+	return &device, nil
+}
+
+func (uc *CredentialUseCase) findCredential(deviceID domain.DeviceID, credentialID domain.CredentialID) (*domain.Credential, error) {
+	credential, err := uc.credentialRepo.FindByID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.DeviceID != deviceID {
+		return nil, domain.ErrCredentialNotFound
+	}
+
+	return credential, nil
+}
+
+func randomHex() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
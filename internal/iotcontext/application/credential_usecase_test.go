@@ -0,0 +1,239 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"testing"
+	"time"
+)
+
+type credentialFixture struct {
+	useCase     *CredentialUseCase
+	devices     domain.DeviceRepository
+	credentials domain.CredentialRepository
+	publisher   *MockEventPublisher
+}
+
+func newCredentialFixture(t *testing.T, ca domain.CertificateAuthority) *credentialFixture {
+	t.Helper()
+
+	f := &credentialFixture{
+		devices:     newGatewayRepository(t, "device-123"),
+		credentials: persistence.NewInMemoryCredentialRepository(),
+		publisher:   NewMockEventPublisher(),
+	}
+
+	deviceUseCase := newDeviceUseCase(f.devices, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), f.publisher)
+	f.useCase = NewCredentialUseCase(f.devices, f.credentials, persistence.NewInMemoryClaimTokenRepository(), deviceUseCase, ca, f.publisher, time.Hour)
+
+	return f
+}
+
+func TestCredentialUseCase_IssueCredential(t *testing.T) {
+	tests := []struct {
+		name        string
+		deviceID    domain.DeviceID
+		typ         domain.CredentialType
+		ca          domain.CertificateAuthority
+		expectError error
+	}{
+		{name: "hmac", deviceID: "device-123", typ: domain.CredentialHMAC},
+		{name: "x509", deviceID: "device-123", typ: domain.CredentialX509, ca: NewMockCertificateAuthority()},
+		{name: "x509 without a CA", deviceID: "device-123", typ: domain.CredentialX509, expectError: domain.ErrInvalidCredential},
+		{name: "unknown device", deviceID: "ghost", typ: domain.CredentialHMAC, expectError: domain.ErrDeviceNotFound},
+		{name: "decommissioned device", deviceID: "device-456", typ: domain.CredentialHMAC, expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCredentialFixture(t, tt.ca)
+
+			issued, err := f.useCase.IssueCredential(tt.deviceID, tt.typ, time.Now())
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected error %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if issued.DeviceID != tt.deviceID || issued.Type != tt.typ {
+				t.Errorf("unexpected credential %+v", issued.Credential)
+			}
+
+			switch tt.typ {
+			case domain.CredentialHMAC:
+				if issued.Secret == "" || issued.Secret != issued.Credential.Secret {
+					t.Errorf("expected the secret to be handed out")
+				}
+			case domain.CredentialX509:
+				if issued.Certificate == "" || issued.PrivateKey == "" || issued.CACertificate == "" || issued.Fingerprint == "" {
+					t.Errorf("expected the certificate to be handed out, got %+v", issued)
+				}
+			}
+
+			if len(f.publisher.events) != 1 || f.publisher.events[0].Type != "device.credential.issued" {
+				t.Errorf("expected a device.credential.issued event, got %+v", f.publisher.events)
+			}
+		})
+	}
+}
+
+func TestCredentialUseCase_AuthenticateSignature(t *testing.T) {
+	f := newCredentialFixture(t, nil)
+	now := time.Now()
+
+	issued, err := f.useCase.IssueCredential("device-123", domain.CredentialHMAC, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := []byte(`{"value":21.5}`)
+	signature := domain.SignRequest(issued.Secret, "POST", "/sensors/s-1/readings", now, body)
+
+	tests := []struct {
+		name         string
+		credentialID domain.CredentialID
+		signature    string
+		body         []byte
+		timestamp    time.Time
+		expectError  bool
+	}{
+		{name: "valid signature", credentialID: issued.ID, signature: signature, body: body, timestamp: now},
+		{name: "tampered body", credentialID: issued.ID, signature: signature, body: []byte(`{"value":99}`), timestamp: now, expectError: true},
+		{name: "unknown credential", credentialID: "ghost", signature: signature, body: body, timestamp: now, expectError: true},
+		{
+			name:         "stale timestamp",
+			credentialID: issued.ID,
+			signature:    domain.SignRequest(issued.Secret, "POST", "/sensors/s-1/readings", now.Add(-time.Hour), body),
+			body:         body,
+			timestamp:    now.Add(-time.Hour),
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceID, err := f.useCase.AuthenticateSignature(tt.credentialID, tt.signature, "POST", "/sensors/s-1/readings", tt.timestamp, tt.body, now)
+
+			if tt.expectError {
+				if !errors.Is(err, domain.ErrUnauthenticated) {
+					t.Errorf("expected ErrUnauthenticated, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if deviceID != "device-123" {
+				t.Errorf("expected gateway-1, got %s", deviceID)
+			}
+		})
+	}
+}
+
+func TestCredentialUseCase_RotateAndRevoke(t *testing.T) {
+	f := newCredentialFixture(t, NewMockCertificateAuthority())
+	now := time.Now()
+
+	original, err := f.useCase.IssueCredential("device-123", domain.CredentialX509, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rotated, err := f.useCase.RotateCredential("device-123", original.ID, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rotated.ID == original.ID || rotated.Type != domain.CredentialX509 || rotated.Fingerprint == original.Fingerprint {
+		t.Errorf("expected a new certificate, got %+v", rotated.Credential)
+	}
+
+	if _, err := f.useCase.AuthenticateCertificate(original.Fingerprint, now.Add(30*time.Minute)); err != nil {
+		t.Errorf("expected the old certificate to work during the grace period, got %v", err)
+	}
+
+	if _, err := f.useCase.AuthenticateCertificate(original.Fingerprint, now.Add(2*time.Hour)); !errors.Is(err, domain.ErrUnauthenticated) {
+		t.Errorf("expected ErrUnauthenticated after the grace period, got %v", err)
+	}
+
+	if _, err := f.useCase.RotateCredential("device-456", rotated.ID, now); !errors.Is(err, domain.ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound for another device's credential, got %v", err)
+	}
+
+	if _, err := f.useCase.RevokeCredential("device-123", rotated.ID, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := f.useCase.AuthenticateCertificate(rotated.Fingerprint, now); !errors.Is(err, domain.ErrUnauthenticated) {
+		t.Errorf("expected ErrUnauthenticated for a revoked certificate, got %v", err)
+	}
+
+	if _, err := f.useCase.RevokeCredential("device-123", rotated.ID, now); !errors.Is(err, domain.ErrCredentialRevoked) {
+		t.Errorf("expected ErrCredentialRevoked, got %v", err)
+	}
+
+	if _, err := f.useCase.RotateCredential("device-123", rotated.ID, now); !errors.Is(err, domain.ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential when rotating a revoked credential, got %v", err)
+	}
+
+	credentials, err := f.useCase.ListCredentials("device-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(credentials) != 2 {
+		t.Errorf("expected 2 credentials, got %d", len(credentials))
+	}
+}
+
+func TestCredentialUseCase_Register(t *testing.T) {
+	f := newCredentialFixture(t, nil)
+	now := time.Now()
+
+	claim, err := f.useCase.CreateClaimToken("sensor_node", 1, time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if claim.Token == "" || claim.TokenHash != domain.HashClaimToken(claim.Token) {
+		t.Fatalf("unexpected claim token %+v", claim)
+	}
+
+	if _, _, err := f.useCase.Register(claim.Token, "", domain.CredentialHMAC, now); !errors.Is(err, domain.ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential for a missing name, got %v", err)
+	}
+
+	if _, _, err := f.useCase.Register(claim.Token, "Node", domain.CredentialX509, now); !errors.Is(err, domain.ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential without a CA, got %v", err)
+	}
+
+	device, issued, err := f.useCase.Register(claim.Token, "Node", domain.CredentialHMAC, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if device.Type != "sensor_node" || device.Name != "Node" || issued.DeviceID != device.ID || issued.Secret == "" {
+		t.Errorf("unexpected registration %+v %+v", device, issued.Credential)
+	}
+
+	if _, err := f.devices.FindByID(device.ID); err != nil {
+		t.Errorf("expected the device to be saved, got %v", err)
+	}
+
+	if _, _, err := f.useCase.Register(claim.Token, "Another", domain.CredentialHMAC, now); !errors.Is(err, domain.ErrClaimTokenRejected) {
+		t.Errorf("expected ErrClaimTokenRejected once used up, got %v", err)
+	}
+
+	if _, err := f.useCase.CreateClaimToken("", 1, time.Hour, now); !errors.Is(err, domain.ErrInvalidClaimToken) {
+		t.Errorf("expected ErrInvalidClaimToken, got %v", err)
+	}
+}
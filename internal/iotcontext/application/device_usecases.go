@@ -1,31 +1,118 @@
 package application
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"time"
 )
 
 type DeviceUseCase struct {
-	deviceRepo domain.DeviceRepository
+	deviceRepo     domain.DeviceRepository
+	sensorRepo     domain.SensorRepository
+	sensorUseCase  *SensorUseCase
+	simulatorRepo  domain.SimulatorRepository
+	eventPublisher domain.EventPublisher
+	scope          TenantScope
+	// expectedVersion is the version changes are made against; 0 accepts
+	// any.
+	expectedVersion int64
 }
 
-func NewDeviceUseCase(deviceRepo domain.DeviceRepository) *DeviceUseCase {
+// DevicePatch is a partial update of a device; the fields left nil are kept.
+type DevicePatch struct {
+	Name   *string
+	Type   *string
+	Labels *domain.Labels
+	Status *domain.DeviceStatus
+}
+
+func NewDeviceUseCase(
+	deviceRepo domain.DeviceRepository,
+	sensorRepo domain.SensorRepository,
+	sensorUseCase *SensorUseCase,
+	simulatorRepo domain.SimulatorRepository,
+	publisher domain.EventPublisher,
+) *DeviceUseCase {
 	return &DeviceUseCase{
-		deviceRepo: deviceRepo,
+		deviceRepo:     deviceRepo,
+		sensorRepo:     sensorRepo,
+		sensorUseCase:  sensorUseCase,
+		simulatorRepo:  simulatorRepo,
+		eventPublisher: publisher,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the devices and
+// sensors of the tenant and enforces its device quota. The zero scope
+// returns uc itself.
+func (uc *DeviceUseCase) ForTenant(scope TenantScope) *DeviceUseCase {
+	if scope.Tenant == "" {
+		return uc
 	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.sensorRepo = scopeSensors(uc.sensorRepo, scope.Tenant)
+	scoped.sensorUseCase = uc.sensorUseCase.ForTenant(scope)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+// ExpectVersion returns a copy of the use case whose changes fail with
+// ErrDeviceVersionConflict unless the device is still at version, the one
+// the caller last read.
+func (uc *DeviceUseCase) ExpectVersion(version int64) *DeviceUseCase {
+	expecting := *uc
+	expecting.expectedVersion = version
+
+	return &expecting
 }
 
-func (uc *DeviceUseCase) CreateDevice(id domain.DeviceID, name string, typ string) (*domain.Device, error) {
+func (uc *DeviceUseCase) CreateDevice(id domain.DeviceID, name string, typ string, labels domain.Labels) (*domain.Device, error) {
 	device, err := domain.NewDevice(id, name, typ)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := device.Relabel(labels); err != nil {
+		return nil, err
+	}
+
+	if uc.scope.Quota.MaxDevices > 0 {
+		devices, err := uc.deviceRepo.FindAll()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := uc.scope.checkQuota(len(devices), uc.scope.Quota.MaxDevices, "devices"); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := uc.deviceRepo.Save(device); err != nil {
 		return nil, err
 	}
 
-	return device, nil
+	uc.scope.record(domain.AuditDeviceCreate, string(device.ID), nil, snapshot(device))
+
+	event := &domain.DeviceCreatedEvent{
+		DeviceID: device.ID,
+		Name:     device.Name,
+		Type:     device.Type,
+		Status:   device.Status,
+		Labels:   device.Labels,
+	}
+
+	return device, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// CreateDeviceIn creates a device owned by the tenant of scope.
+func (uc *DeviceUseCase) CreateDeviceIn(scope TenantScope, id domain.DeviceID, name string, typ string, labels domain.Labels) (*domain.Device, error) {
+	return uc.ForTenant(scope).CreateDevice(id, name, typ, labels)
 }
 
 func (uc *DeviceUseCase) GetDeviceByID(id domain.DeviceID) (*domain.Device, error) {
@@ -33,7 +120,7 @@ func (uc *DeviceUseCase) GetDeviceByID(id domain.DeviceID) (*domain.Device, erro
 	if err != nil {
 		return nil, err
 	}
-	if device == (domain.Device{}) {
+	if device.ID == "" {
 		return nil, domain.ErrDeviceNotFound
 	}
 
@@ -55,8 +142,269 @@ func (uc *DeviceUseCase) GetAllDevices() ([]*domain.Device, error) {
 	return domainDevices, nil
 }
 
+// GetDevicesByIDs returns the devices of the ids that exist in a single
+// lookup, leaving the rest out.
+func (uc *DeviceUseCase) GetDevicesByIDs(ids []domain.DeviceID) ([]*domain.Device, error) {
+	devices, err := uc.deviceRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	domainDevices := make([]*domain.Device, 0, len(devices))
+	for _, device := range devices {
+		d := device
+		domainDevices = append(domainDevices, &d)
+	}
+
+	return domainDevices, nil
+}
+
 func (uc *DeviceUseCase) UpdateDevice(device *domain.Device) error {
+	var before json.RawMessage
+	if previous, err := uc.deviceRepo.FindByID(device.ID); err == nil {
+		before = snapshot(previous)
+	}
+
 	device.UpdatedAt = time.Now().UTC()
 
-	return uc.deviceRepo.Update(device)
+	if err := uc.deviceRepo.Update(device); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditDeviceUpdate, string(device.ID), before, snapshot(device))
+
+	return nil
+}
+
+func (uc *DeviceUseCase) RenameDevice(id domain.DeviceID, name string, typ string) (*domain.Device, error) {
+	device, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(device)
+	if err := device.Rename(name, typ); err != nil {
+		return nil, err
+	}
+
+	if err := uc.deviceRepo.Update(device); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditDeviceRename, string(device.ID), before, snapshot(device))
+
+	event := &domain.DeviceUpdatedEvent{
+		DeviceID: device.ID,
+		Name:     device.Name,
+		Type:     device.Type,
+	}
+
+	return device, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// RelabelDevice replaces the labels of the device, which may move it in or
+// out of dynamic groups.
+func (uc *DeviceUseCase) RelabelDevice(id domain.DeviceID, labels domain.Labels) (*domain.Device, error) {
+	device, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(device)
+	if err := device.Relabel(labels); err != nil {
+		return nil, err
+	}
+
+	if err := uc.deviceRepo.Update(device); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditDeviceRelabel, string(device.ID), before, snapshot(device))
+
+	event := &domain.DeviceLabelsChangedEvent{
+		DeviceID: device.ID,
+		Labels:   device.Labels,
+	}
+
+	return device, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// PatchDevice applies every change of the patch or none: they are all
+// validated before the device is written, once, as a new version. Labels
+// replace the current ones as a whole. The patch is published as the events
+// of the separate changes it makes.
+func (uc *DeviceUseCase) PatchDevice(id domain.DeviceID, patch DevicePatch) (*domain.Device, error) {
+	device, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(device)
+	previous := *device
+
+	if patch.Name != nil || patch.Type != nil {
+		name, typ := device.Name, device.Type
+		if patch.Name != nil {
+			name = *patch.Name
+		}
+		if patch.Type != nil {
+			typ = *patch.Type
+		}
+
+		if err := device.Rename(name, typ); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Labels != nil {
+		if err := device.Relabel(*patch.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Status != nil && *patch.Status != device.Status {
+		if err := device.TransitionTo(*patch.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.deviceRepo.Update(device); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditDeviceUpdate, string(device.ID), before, snapshot(device))
+
+	if device.Status == domain.DeviceDecommissioned && previous.Status != domain.DeviceDecommissioned {
+		if err := uc.sensorUseCase.disableDeviceSensors(device.ID); err != nil {
+			return device, err
+		}
+	}
+
+	var events []domain.IoTEvent
+	if device.Name != previous.Name || device.Type != previous.Type {
+		event := &domain.DeviceUpdatedEvent{DeviceID: device.ID, Name: device.Name, Type: device.Type}
+		events = append(events, event.ToDomainEvent())
+	}
+	if patch.Labels != nil {
+		event := &domain.DeviceLabelsChangedEvent{DeviceID: device.ID, Labels: device.Labels}
+		events = append(events, event.ToDomainEvent())
+	}
+	if device.Status != previous.Status {
+		event := &domain.DeviceStatusChangedEvent{DeviceID: device.ID, From: previous.Status, To: device.Status}
+		events = append(events, event.ToDomainEvent())
+	}
+
+	for _, event := range events {
+		if err := uc.eventPublisher.Publish(event); err != nil {
+			return device, err
+		}
+	}
+
+	return device, nil
+}
+
+// ChangeDeviceStatus moves the device through its lifecycle. Decommissioning
+// stops the simulations of its sensors and disables them, each with a new
+// config revision, so they cannot be started again. The sensors are changed
+// once the device is written; if that fails half way, decommissioning the
+// device again finishes it.
+func (uc *DeviceUseCase) ChangeDeviceStatus(id domain.DeviceID, status domain.DeviceStatus) (*domain.Device, error) {
+	device, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == domain.DeviceDecommissioned && device.Status == domain.DeviceDecommissioned {
+		return device, uc.sensorUseCase.disableDeviceSensors(device.ID)
+	}
+
+	before := snapshot(device)
+	previous := device.Status
+	if err := device.TransitionTo(status); err != nil {
+		return nil, err
+	}
+
+	if err := uc.deviceRepo.Update(device); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditDeviceStatus, string(device.ID), before, snapshot(device))
+
+	if status == domain.DeviceDecommissioned {
+		if err := uc.sensorUseCase.disableDeviceSensors(device.ID); err != nil {
+			return device, err
+		}
+	}
+
+	event := &domain.DeviceStatusChangedEvent{
+		DeviceID: device.ID,
+		From:     previous,
+		To:       device.Status,
+	}
+
+	return device, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// DeleteDevice soft deletes the device together with its sensors, stopping
+// any simulation first. Readings are kept for historical queries.
+func (uc *DeviceUseCase) DeleteDevice(id domain.DeviceID) error {
+	device, err := uc.GetDeviceByID(id)
+	if err != nil {
+		return err
+	}
+
+	sensors, err := uc.sensorRepo.FindByDeviceID(id)
+	if err != nil {
+		return err
+	}
+
+	sensorIDs := make([]domain.SensorID, 0, len(sensors))
+	for _, sensor := range sensors {
+		if err := stopSimulation(uc.simulatorRepo, sensor.ID); err != nil {
+			return err
+		}
+
+		if err := uc.sensorRepo.Delete(sensor.ID); err != nil && !errors.Is(err, domain.ErrSensorNotFound) {
+			return err
+		}
+
+		sensorIDs = append(sensorIDs, sensor.ID)
+	}
+
+	if err := uc.deviceRepo.Delete(id); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditDeviceDelete, string(id), snapshot(device), nil)
+
+	event := &domain.DeviceDeletedEvent{
+		DeviceID:  id,
+		SensorIDs: sensorIDs,
+	}
+
+	return uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// findForUpdate loads the device to change, checking it is still at the
+// expected version. The repository checks it again when the change is
+// written, so a change made in between is not lost either.
+func (uc *DeviceUseCase) findForUpdate(id domain.DeviceID) (*domain.Device, error) {
+	device, err := uc.GetDeviceByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.expectedVersion != 0 && device.Version != uc.expectedVersion {
+		return nil, fmt.Errorf("%w: device is at version %d, not %d", domain.ErrDeviceVersionConflict, device.Version, uc.expectedVersion)
+	}
+
+	return device, nil
+}
+
+func stopSimulation(simulatorRepo domain.SimulatorRepository, sensorID domain.SensorID) error {
+	if err := simulatorRepo.Stop(sensorID); err != nil && !errors.Is(err, domain.ErrSimulationNotActive) {
+		return err
+	}
+
+	return nil
 }
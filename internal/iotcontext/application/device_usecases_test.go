@@ -3,6 +3,7 @@ package application
 import (
 	"errors"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
 	"testing"
 )
 
@@ -43,12 +44,12 @@ func TestDeviceUseCase_CreateDevice(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockDeviceRepository()
+			mockRepo := NewFaultyDeviceRepository()
 			mockRepo.saveErr = tt.repoSaveErr
 
-			useCase := NewDeviceUseCase(mockRepo)
+			useCase := newDeviceUseCase(mockRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), NewMockEventPublisher())
 
-			device, err := useCase.CreateDevice(tt.id, tt.deviceName, tt.deviceType)
+			device, err := useCase.CreateDevice(tt.id, tt.deviceName, tt.deviceType, nil)
 
 			if tt.expectError {
 				if err == nil {
@@ -106,7 +107,7 @@ func TestDeviceUseCase_GetDeviceByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockDeviceRepository()
+			mockRepo := NewFaultyDeviceRepository()
 			mockRepo.findErr = tt.repoFindErr
 
 			if tt.deviceID == "device-123" && tt.repoFindErr == nil {
@@ -114,7 +115,7 @@ func TestDeviceUseCase_GetDeviceByID(t *testing.T) {
 				mockRepo.Save(device)
 			}
 
-			useCase := NewDeviceUseCase(mockRepo)
+			useCase := newDeviceUseCase(mockRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), NewMockEventPublisher())
 
 			device, err := useCase.GetDeviceByID(tt.deviceID)
 
@@ -160,7 +161,7 @@ func TestDeviceUseCase_GetAllDevices(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockDeviceRepository()
+			mockRepo := NewFaultyDeviceRepository()
 			mockRepo.findErr = tt.repoFindErr
 
 			if tt.repoFindErr == nil {
@@ -170,7 +171,7 @@ func TestDeviceUseCase_GetAllDevices(t *testing.T) {
 				mockRepo.Save(device2)
 			}
 
-			useCase := NewDeviceUseCase(mockRepo)
+			useCase := newDeviceUseCase(mockRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), NewMockEventPublisher())
 
 			devices, err := useCase.GetAllDevices()
 
@@ -203,9 +204,10 @@ func TestDeviceUseCase_UpdateDevice(t *testing.T) {
 		{
 			name: "successful update",
 			device: &domain.Device{
-				ID:   "device-123",
-				Name: "Updated Device",
-				Type: "sensor_hub",
+				ID:      "device-123",
+				Name:    "Updated Device",
+				Type:    "sensor_hub",
+				Version: 1,
 			},
 			repoUpdateErr: nil,
 			expectError:   false,
@@ -213,9 +215,10 @@ func TestDeviceUseCase_UpdateDevice(t *testing.T) {
 		{
 			name: "repository update error",
 			device: &domain.Device{
-				ID:   "device-123",
-				Name: "Updated Device",
-				Type: "sensor_hub",
+				ID:      "device-123",
+				Name:    "Updated Device",
+				Type:    "sensor_hub",
+				Version: 1,
 			},
 			repoUpdateErr: errors.New("update error"),
 			expectError:   true,
@@ -224,10 +227,13 @@ func TestDeviceUseCase_UpdateDevice(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockDeviceRepository()
+			mockRepo := NewFaultyDeviceRepository()
 			mockRepo.updateErr = tt.repoUpdateErr
 
-			useCase := NewDeviceUseCase(mockRepo)
+			device, _ := domain.NewDevice("device-123", "Test Device", "sensor_hub")
+			mockRepo.Save(device)
+
+			useCase := newDeviceUseCase(mockRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), NewMockEventPublisher())
 
 			err := useCase.UpdateDevice(tt.device)
 
@@ -249,3 +255,407 @@ func TestDeviceUseCase_UpdateDevice(t *testing.T) {
 		})
 	}
 }
+
+// newDeviceUseCase builds the device use case with the sensor use case it
+// disables the sensors of decommissioned devices through.
+func newDeviceUseCase(deviceRepo domain.DeviceRepository, sensorRepo domain.SensorRepository, simulatorRepo domain.SimulatorRepository, publisher domain.EventPublisher) *DeviceUseCase {
+	sensors := NewSensorUseCase(sensorRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, simulatorRepo, NewMockMetrics(), publisher)
+
+	return NewDeviceUseCase(deviceRepo, sensorRepo, sensors, simulatorRepo, publisher)
+}
+
+func newLifecycleFixture(t *testing.T, status domain.DeviceStatus) (*DeviceUseCase, *domain.Device, domain.SensorRepository, *MockSimulatorRepository, *MockEventPublisher) {
+	t.Helper()
+
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	simulatorRepo := NewMockSimulatorRepository()
+	publisher := NewMockEventPublisher()
+
+	device, _ := domain.NewDevice("device-123", "Gateway", "gateway")
+	device.Status = status
+	deviceRepo.Save(device)
+
+	for _, id := range []domain.SensorID{"sensor-1", "sensor-2"} {
+		sensor, _ := domain.NewSensor(id, device.ID, "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+		sensorRepo.Save(sensor)
+	}
+	other, _ := domain.NewSensor("sensor-3", "device-456", "Other", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+	sensorRepo.Save(other)
+
+	simulatorRepo.Start("sensor-1")
+	simulatorRepo.Start("sensor-3")
+
+	return newDeviceUseCase(deviceRepo, sensorRepo, simulatorRepo, publisher), device, sensorRepo, simulatorRepo, publisher
+}
+
+func TestDeviceUseCase_ChangeDeviceStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        domain.DeviceStatus
+		to          domain.DeviceStatus
+		expectError error
+	}{
+		{name: "activate provisioned device", from: domain.DeviceProvisioned, to: domain.DeviceActive},
+		{name: "send active device to maintenance", from: domain.DeviceActive, to: domain.DeviceMaintenance},
+		{name: "return from maintenance", from: domain.DeviceMaintenance, to: domain.DeviceActive},
+		{name: "decommission active device", from: domain.DeviceActive, to: domain.DeviceDecommissioned},
+		{name: "maintenance requires an active device", from: domain.DeviceProvisioned, to: domain.DeviceMaintenance, expectError: domain.ErrInvalidDeviceTransition},
+		{name: "decommissioned is final", from: domain.DeviceDecommissioned, to: domain.DeviceActive, expectError: domain.ErrInvalidDeviceTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, device, sensorRepo, simulatorRepo, publisher := newLifecycleFixture(t, tt.from)
+
+			updated, err := useCase.ChangeDeviceStatus(device.ID, tt.to)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				if len(publisher.GetEvents()) != 0 {
+					t.Errorf("expected no events, got %d", len(publisher.GetEvents()))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if updated.Status != tt.to {
+				t.Errorf("expected status %s, got %s", tt.to, updated.Status)
+			}
+
+			decommissioned := tt.to == domain.DeviceDecommissioned
+			var statusEvents, configEvents []domain.IoTEvent
+			for _, event := range publisher.GetEvents() {
+				switch event.Type {
+				case "device.status.changed":
+					statusEvents = append(statusEvents, event)
+				case "sensor.config.updated":
+					configEvents = append(configEvents, event)
+				}
+			}
+
+			if len(statusEvents) != 1 {
+				t.Fatalf("expected a device.status.changed event, got %+v", publisher.GetEvents())
+			}
+
+			payload := statusEvents[0].Payload.(*domain.DeviceStatusChangedEvent)
+			if payload.From != tt.from || payload.To != tt.to {
+				t.Errorf("unexpected event payload %+v", payload)
+			}
+
+			// Disabling goes through a config revision of each sensor.
+			expectedConfigEvents := 0
+			if decommissioned {
+				expectedConfigEvents = 2
+			}
+			if len(configEvents) != expectedConfigEvents {
+				t.Errorf("expected %d sensor.config.updated events, got %d", expectedConfigEvents, len(configEvents))
+			}
+
+			sensor, _ := sensorRepo.FindByID("sensor-1")
+			if sensor.Config.Enabled == decommissioned {
+				t.Errorf("expected sensor enabled=%v, got %v", !decommissioned, sensor.Config.Enabled)
+			}
+
+			if decommissioned && sensor.Config.Revision != 2 {
+				t.Errorf("expected the disabled config to be revision 2, got %d", sensor.Config.Revision)
+			}
+
+			if simulatorRepo.active["sensor-1"] == decommissioned {
+				t.Errorf("expected simulation running=%v", !decommissioned)
+			}
+
+			if !simulatorRepo.active["sensor-3"] {
+				t.Error("expected simulations of other devices to keep running")
+			}
+		})
+	}
+}
+
+func TestDeviceUseCase_DecommissionWriteFailure(t *testing.T) {
+	deviceRepo := NewFaultyDeviceRepository()
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	simulatorRepo := NewMockSimulatorRepository()
+	useCase := newDeviceUseCase(deviceRepo, sensorRepo, simulatorRepo, NewMockEventPublisher())
+
+	device, _ := domain.NewDevice("device-123", "Gateway", "gateway")
+	device.Status = domain.DeviceActive
+	deviceRepo.Save(device)
+
+	sensor, _ := domain.NewSensor("sensor-1", device.ID, "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+	sensorRepo.Save(sensor)
+	simulatorRepo.Start("sensor-1")
+
+	// A write made between loading and updating the device.
+	deviceRepo.updateErr = domain.ErrDeviceVersionConflict
+	decommissioned := domain.DeviceDecommissioned
+
+	if _, err := useCase.ChangeDeviceStatus(device.ID, decommissioned); !errors.Is(err, domain.ErrDeviceVersionConflict) {
+		t.Fatalf("expected ErrDeviceVersionConflict, got %v", err)
+	}
+	if _, err := useCase.PatchDevice(device.ID, DevicePatch{Status: &decommissioned}); !errors.Is(err, domain.ErrDeviceVersionConflict) {
+		t.Fatalf("expected ErrDeviceVersionConflict, got %v", err)
+	}
+
+	stored, _ := sensorRepo.FindByID("sensor-1")
+	if !stored.Config.Enabled || stored.Config.Revision != 1 {
+		t.Errorf("expected the sensor untouched, got %+v", stored.Config)
+	}
+	if !simulatorRepo.active["sensor-1"] {
+		t.Error("expected the simulation to keep running")
+	}
+
+	// A cascade that failed after the device was written is finished by
+	// decommissioning the device again.
+	deviceRepo.updateErr = nil
+	device.Status = domain.DeviceDecommissioned
+	deviceRepo.Save(device)
+
+	if _, err := useCase.ChangeDeviceStatus(device.ID, decommissioned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _ = sensorRepo.FindByID("sensor-1")
+	if stored.Config.Enabled || simulatorRepo.active["sensor-1"] {
+		t.Errorf("expected the sensor disabled and stopped, got %+v", stored.Config)
+	}
+}
+
+func TestDeviceUseCase_PatchDevice(t *testing.T) {
+	name := "Boiler room"
+	maintenance := domain.DeviceMaintenance
+	provisioned := domain.DeviceProvisioned
+
+	tests := []struct {
+		name            string
+		version         int64
+		patch           DevicePatch
+		expectError     error
+		expectedName    string
+		expectedStatus  domain.DeviceStatus
+		expectedVersion int64
+		expectedEvents  []string
+	}{
+		{
+			name:            "every field in one version",
+			patch:           DevicePatch{Name: &name, Labels: &domain.Labels{"zone": "north"}, Status: &maintenance},
+			expectedName:    "Boiler room",
+			expectedStatus:  domain.DeviceMaintenance,
+			expectedVersion: 2,
+			expectedEvents:  []string{"device.updated", "device.labels.changed", "device.status.changed"},
+		},
+		{
+			name:            "invalid labels keep the name",
+			patch:           DevicePatch{Name: &name, Labels: &domain.Labels{"bad key": "x"}},
+			expectError:     domain.ErrInvalidLabels,
+			expectedName:    "Gateway",
+			expectedStatus:  domain.DeviceActive,
+			expectedVersion: 1,
+		},
+		{
+			name:            "invalid transition keeps the name",
+			patch:           DevicePatch{Name: &name, Status: &provisioned},
+			expectError:     domain.ErrInvalidDeviceTransition,
+			expectedName:    "Gateway",
+			expectedStatus:  domain.DeviceActive,
+			expectedVersion: 1,
+		},
+		{
+			name:            "stale version",
+			version:         7,
+			patch:           DevicePatch{Name: &name},
+			expectError:     domain.ErrDeviceVersionConflict,
+			expectedName:    "Gateway",
+			expectedStatus:  domain.DeviceActive,
+			expectedVersion: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceRepo := persistence.NewInMemoryDeviceRepository()
+			publisher := NewMockEventPublisher()
+			useCase := newDeviceUseCase(deviceRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), publisher)
+
+			device, _ := domain.NewDevice("device-1", "Gateway", "gateway")
+			device.Status = domain.DeviceActive
+			deviceRepo.Save(device)
+
+			_, err := useCase.ExpectVersion(tt.version).PatchDevice("device-1", tt.patch)
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored, _ := deviceRepo.FindByID("device-1")
+			if stored.Name != tt.expectedName || stored.Status != tt.expectedStatus || stored.Version != tt.expectedVersion {
+				t.Errorf("expected %q %s at version %d, got %q %s at version %d", tt.expectedName, tt.expectedStatus, tt.expectedVersion, stored.Name, stored.Status, stored.Version)
+			}
+
+			var events []string
+			for _, event := range publisher.GetEvents() {
+				events = append(events, event.Type)
+			}
+			if len(events) != len(tt.expectedEvents) {
+				t.Fatalf("expected events %v, got %v", tt.expectedEvents, events)
+			}
+			for i := range events {
+				if events[i] != tt.expectedEvents[i] {
+					t.Errorf("expected events %v, got %v", tt.expectedEvents, events)
+				}
+			}
+		})
+	}
+}
+
+func TestDeviceUseCase_DeleteDevice(t *testing.T) {
+	useCase, device, sensorRepo, simulatorRepo, publisher := newLifecycleFixture(t, domain.DeviceActive)
+
+	if err := useCase.DeleteDevice(device.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := useCase.GetDeviceByID(device.ID); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+
+	if sensors, _ := sensorRepo.FindByDeviceID(device.ID); len(sensors) != 0 {
+		t.Errorf("expected sensors to be deleted, got %d", len(sensors))
+	}
+
+	if _, err := sensorRepo.FindByID("sensor-3"); err != nil {
+		t.Errorf("expected sensors of other devices to be kept: %v", err)
+	}
+
+	if len(simulatorRepo.stopped) != 1 || simulatorRepo.stopped[0] != "sensor-1" {
+		t.Errorf("expected the running simulation to be stopped, got %v", simulatorRepo.stopped)
+	}
+
+	events := publisher.GetEvents()
+	if len(events) != 1 || events[0].Type != "device.deleted" {
+		t.Fatalf("expected a device.deleted event, got %+v", events)
+	}
+
+	if payload := events[0].Payload.(*domain.DeviceDeletedEvent); len(payload.SensorIDs) != 2 {
+		t.Errorf("expected 2 deleted sensors in the event, got %v", payload.SensorIDs)
+	}
+
+	if err := useCase.DeleteDevice(device.ID); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+}
+
+func TestDeviceUseCase_RenameDevice(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      domain.DeviceStatus
+		newName     string
+		expectError bool
+	}{
+		{name: "rename active device", status: domain.DeviceActive, newName: "Renamed"},
+		{name: "empty name", status: domain.DeviceActive, newName: "", expectError: true},
+		{name: "decommissioned device", status: domain.DeviceDecommissioned, newName: "Renamed", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, device, _, _, publisher := newLifecycleFixture(t, tt.status)
+
+			updated, err := useCase.RenameDevice(device.ID, tt.newName, "hub")
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if updated.Name != tt.newName || updated.Type != "hub" {
+				t.Errorf("unexpected device %+v", updated)
+			}
+
+			if events := publisher.GetEvents(); len(events) != 1 || events[0].Type != "device.updated" {
+				t.Errorf("expected a device.updated event, got %+v", events)
+			}
+		})
+	}
+}
+
+func TestDeviceUseCase_RelabelDevice(t *testing.T) {
+	mockRepo := persistence.NewInMemoryDeviceRepository()
+	publisher := NewMockEventPublisher()
+	useCase := newDeviceUseCase(mockRepo, persistence.NewInMemorySensorRepository(), NewMockSimulatorRepository(), publisher)
+
+	if _, err := useCase.CreateDevice("device-1", "Gateway", "gateway", domain.Labels{"bad key": "x"}); !errors.Is(err, domain.ErrInvalidLabels) {
+		t.Errorf("expected ErrInvalidLabels, got %v", err)
+	}
+
+	if _, err := useCase.CreateDevice("device-1", "Gateway", "gateway", domain.Labels{"site": "madrid"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	device, err := useCase.RelabelDevice("device-1", domain.Labels{"site": "bilbao", "floor": "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _ := mockRepo.FindByID("device-1")
+	if len(stored.Labels) != 2 || stored.Labels["site"] != "bilbao" || device.Labels["floor"] != "2" {
+		t.Errorf("expected the labels to be replaced, got %v", stored.Labels)
+	}
+
+	events := publisher.GetEvents()
+	if len(events) != 2 || events[1].Type != "device.labels.changed" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+
+	if _, err := useCase.RelabelDevice("unknown", domain.Labels{}); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+}
+
+func TestDeviceUseCase_ExpectVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+		conflict bool
+	}{
+		{name: "any version", expected: 0},
+		{name: "current version", expected: 1},
+		{name: "stale version", expected: 2, conflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, device, _, _, publisher := newLifecycleFixture(t, domain.DeviceActive)
+
+			_, err := useCase.ExpectVersion(tt.expected).RenameDevice(device.ID, "Renamed", device.Type)
+
+			if tt.conflict {
+				if !errors.Is(err, domain.ErrDeviceVersionConflict) {
+					t.Errorf("expected ErrDeviceVersionConflict, got %v", err)
+				}
+				if events := publisher.GetEvents(); len(events) != 0 {
+					t.Errorf("expected no events, got %+v", events)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,540 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultCampaignStages and DefaultFailureThreshold apply when a campaign is
+// created without them: 10% of the fleet first, then half, then everyone,
+// halting once more than 10% of the dispatched updates failed.
+var DefaultCampaignStages = []int{10, 50, 100}
+
+const DefaultFailureThreshold = 0.1
+
+// commandSender is the part of CommandUseCase used to reach the devices.
+type commandSender interface {
+	CreateCommand(id domain.CommandID, deviceID domain.DeviceID, name string, params map[string]interface{}, ttl time.Duration) (*domain.Command, error)
+}
+
+// CampaignDetail is a campaign with the update of every target device.
+type CampaignDetail struct {
+	*domain.Campaign
+	Summary map[domain.DeviceUpdateStatus]int `json:"summary"`
+	Updates []*domain.DeviceUpdate            `json:"updates"`
+}
+
+// FirmwareUseCase stores firmware images and rolls them out in campaigns.
+// Device reports and the rollout job both move campaigns forward, so they are
+// serialized by mu, which the copies made by ForActor share.
+type FirmwareUseCase struct {
+	deviceRepo     domain.DeviceRepository
+	firmwareRepo   domain.FirmwareRepository
+	campaignRepo   domain.CampaignRepository
+	artifacts      domain.ArtifactStore
+	commands       commandSender
+	eventPublisher domain.EventPublisher
+	updateTimeout  time.Duration
+	scope          TenantScope
+	mu             *sync.Mutex
+}
+
+func NewFirmwareUseCase(
+	deviceRepo domain.DeviceRepository,
+	firmwareRepo domain.FirmwareRepository,
+	campaignRepo domain.CampaignRepository,
+	artifacts domain.ArtifactStore,
+	commands commandSender,
+	publisher domain.EventPublisher,
+	updateTimeout time.Duration,
+) *FirmwareUseCase {
+	return &FirmwareUseCase{
+		deviceRepo:     deviceRepo,
+		firmwareRepo:   firmwareRepo,
+		campaignRepo:   campaignRepo,
+		artifacts:      artifacts,
+		commands:       commands,
+		eventPublisher: publisher,
+		updateTimeout:  updateTimeout,
+		mu:             &sync.Mutex{},
+	}
+}
+
+// ForActor returns a copy of the use case whose changes are audited for the
+// actor of scope. Firmware and campaigns belong to the whole deployment, so
+// the use case sees every tenant whatever the scope.
+func (uc *FirmwareUseCase) ForActor(scope TenantScope) *FirmwareUseCase {
+	scoped := *uc
+	scoped.scope = scope
+
+	return &scoped
+}
+
+// UploadFirmware stores an image and its metadata. When checksum is given it
+// must match the sha256 of what was received.
+func (uc *FirmwareUseCase) UploadFirmware(id domain.FirmwareID, version string, deviceType string, checksum string, content io.Reader) (*domain.Firmware, error) {
+	size, actual, err := uc.artifacts.Put(id, content)
+	if err != nil {
+		return nil, err
+	}
+
+	firmware, err := domain.NewFirmware(id, version, deviceType, actual, size, time.Now())
+	if err == nil && checksum != "" && checksum != actual {
+		err = fmt.Errorf("%w: checksum mismatch, received %s", domain.ErrInvalidFirmware, actual)
+	}
+	if err == nil {
+		err = uc.firmwareRepo.Save(firmware)
+	}
+
+	if err != nil {
+		if deleteErr := uc.artifacts.Delete(id); deleteErr != nil {
+			log.Printf("firmware %s: failed to delete rejected image: %v", id, deleteErr)
+		}
+
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditFirmwareUpload, string(firmware.ID), nil, snapshot(firmware))
+
+	return firmware, nil
+}
+
+func (uc *FirmwareUseCase) GetFirmware(id domain.FirmwareID) (*domain.Firmware, error) {
+	return uc.firmwareRepo.FindByID(id)
+}
+
+func (uc *FirmwareUseCase) ListFirmware() ([]*domain.Firmware, error) {
+	return uc.firmwareRepo.FindAll()
+}
+
+// OpenArtifact returns the firmware and its image. The caller closes it.
+func (uc *FirmwareUseCase) OpenArtifact(id domain.FirmwareID) (*domain.Firmware, io.ReadCloser, error) {
+	firmware, err := uc.firmwareRepo.FindByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := uc.artifacts.Open(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return firmware, content, nil
+}
+
+// CreateCampaign targets every device of the firmware's type that is not
+// decommissioned and dispatches the first stage right away.
+func (uc *FirmwareUseCase) CreateCampaign(id domain.CampaignID, firmwareID domain.FirmwareID, stages []int, failureThreshold *float64) (*CampaignDetail, error) {
+	firmware, err := uc.firmwareRepo.FindByID(firmwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stages == nil {
+		stages = DefaultCampaignStages
+	}
+
+	threshold := DefaultFailureThreshold
+	if failureThreshold != nil {
+		threshold = *failureThreshold
+	}
+
+	now := time.Now()
+	campaign, err := domain.NewCampaign(id, firmware, stages, threshold, now)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []domain.DeviceID
+	for _, device := range devices {
+		if device.Type == firmware.DeviceType && device.Status != domain.DeviceDecommissioned {
+			targets = append(targets, device.ID)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: no devices of type %q", domain.ErrInvalidCampaign, firmware.DeviceType)
+	}
+
+	updates := make([]*domain.DeviceUpdate, 0, len(targets))
+	for position, deviceID := range domain.OrderCampaignTargets(id, targets) {
+		updates = append(updates, domain.NewDeviceUpdate(id, deviceID, position, now))
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if err := uc.campaignRepo.Save(campaign, updates); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditCampaignCreate, string(campaign.ID), nil, snapshot(campaign))
+
+	if err := uc.publishCampaign(campaign, ""); err != nil {
+		return nil, err
+	}
+
+	if err := uc.process(campaign, now); err != nil {
+		return nil, err
+	}
+
+	return uc.detail(campaign)
+}
+
+func (uc *FirmwareUseCase) GetCampaign(id domain.CampaignID) (*CampaignDetail, error) {
+	campaign, err := uc.campaignRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.detail(campaign)
+}
+
+func (uc *FirmwareUseCase) ListCampaigns() ([]*domain.Campaign, error) {
+	return uc.campaignRepo.FindAll()
+}
+
+// PauseCampaign stops dispatching new updates. Updates already sent keep
+// reporting.
+func (uc *FirmwareUseCase) PauseCampaign(id domain.CampaignID) (*CampaignDetail, error) {
+	return uc.changeCampaign(domain.AuditCampaignPause, id, func(campaign *domain.Campaign, updates []*domain.DeviceUpdate, now time.Time) error {
+		return campaign.Pause(now)
+	})
+}
+
+func (uc *FirmwareUseCase) ResumeCampaign(id domain.CampaignID) (*CampaignDetail, error) {
+	return uc.changeCampaign(domain.AuditCampaignResume, id, func(campaign *domain.Campaign, updates []*domain.DeviceUpdate, now time.Time) error {
+		failed := 0
+		for _, update := range updates {
+			if update.Status == domain.DeviceUpdateFailed {
+				failed++
+			}
+		}
+
+		return campaign.Resume(failed, now)
+	})
+}
+
+// AbortCampaign ends the campaign and cancels the updates not sent yet.
+func (uc *FirmwareUseCase) AbortCampaign(id domain.CampaignID) (*CampaignDetail, error) {
+	return uc.changeCampaign(domain.AuditCampaignAbort, id, func(campaign *domain.Campaign, updates []*domain.DeviceUpdate, now time.Time) error {
+		if err := campaign.Abort(now); err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			if update.Status != domain.DeviceUpdateScheduled {
+				continue
+			}
+
+			if err := update.Cancel(now); err != nil {
+				return err
+			}
+
+			if err := uc.campaignRepo.UpdateDeviceUpdate(update); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (uc *FirmwareUseCase) changeCampaign(
+	action domain.AuditAction,
+	id domain.CampaignID,
+	change func(campaign *domain.Campaign, updates []*domain.DeviceUpdate, now time.Time) error,
+) (*CampaignDetail, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	campaign, err := uc.campaignRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := uc.campaignRepo.FindDeviceUpdates(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := campaign.Status
+	before := snapshot(campaign)
+	if err := change(campaign, updates, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.campaignRepo.Update(campaign); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(action, string(campaign.ID), before, snapshot(campaign))
+
+	if err := uc.publishCampaign(campaign, from); err != nil {
+		return nil, err
+	}
+
+	if err := uc.process(campaign, now); err != nil {
+		return nil, err
+	}
+
+	return uc.detail(campaign)
+}
+
+// ReportUpdate applies the status a device reports for its update and moves
+// the campaign forward: a failure may halt it, the last success of a stage
+// starts the next one.
+func (uc *FirmwareUseCase) ReportUpdate(
+	deviceID domain.DeviceID,
+	campaignID domain.CampaignID,
+	status domain.DeviceUpdateStatus,
+	progress int,
+	message string,
+	now time.Time,
+) (*domain.DeviceUpdate, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	campaign, err := uc.campaignRepo.FindByID(campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := uc.campaignRepo.FindDeviceUpdate(campaignID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := update.Status
+	if err := update.Report(status, progress, message, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.saveUpdate(update, previous); err != nil {
+		return nil, err
+	}
+
+	return update, uc.process(campaign, now)
+}
+
+// Run fails the updates that did not finish within the update timeout and
+// moves every active campaign forward.
+func (uc *FirmwareUseCase) Run(now time.Time) error {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	campaigns, err := uc.campaignRepo.FindActive()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, campaign := range campaigns {
+		if err := uc.process(campaign, now); err != nil {
+			errs = append(errs, fmt.Errorf("campaign %s: %w", campaign.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (uc *FirmwareUseCase) Start(every time.Duration) func() {
+	stopCh := make(chan struct{})
+	ticker := time.NewTicker(every)
+
+	run := func() {
+		if err := uc.Run(time.Now().UTC()); err != nil {
+			log.Printf("firmware campaigns job failed: %v", err)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+	}
+}
+
+// process must be called with mu held.
+func (uc *FirmwareUseCase) process(campaign *domain.Campaign, now time.Time) error {
+	if campaign.IsFinal() {
+		return nil
+	}
+
+	updates, err := uc.campaignRepo.FindDeviceUpdates(campaign.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		if !update.IsInFlight() || update.StartedAt.Add(uc.updateTimeout).After(now) {
+			continue
+		}
+
+		previous := update.Status
+		if err := update.Fail("timed out", now); err != nil {
+			return err
+		}
+
+		if err := uc.saveUpdate(update, previous); err != nil {
+			return err
+		}
+	}
+
+	for campaign.Status == domain.CampaignRunning {
+		if halt, rate := campaign.ShouldHalt(updates); halt {
+			reason := fmt.Sprintf("failure rate %.0f%% above threshold %.0f%%", rate*100, campaign.FailureThreshold*100)
+			return uc.setCampaignStatus(campaign, func() error { return campaign.Halt(reason, now) })
+		}
+
+		stage := updates[:campaign.StageSize(len(updates))]
+		if err := uc.dispatch(campaign, stage, now); err != nil {
+			return err
+		}
+
+		for _, update := range stage {
+			if !update.IsFinal() {
+				return nil
+			}
+		}
+
+		if campaign.IsLastStage() {
+			return uc.setCampaignStatus(campaign, func() error { return campaign.Complete(now) })
+		}
+
+		if err := campaign.NextStage(now); err != nil {
+			return err
+		}
+
+		if err := uc.campaignRepo.Update(campaign); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *FirmwareUseCase) dispatch(campaign *domain.Campaign, updates []*domain.DeviceUpdate, now time.Time) error {
+	firmware, err := uc.firmwareRepo.FindByID(campaign.FirmwareID)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"campaign_id": string(campaign.ID),
+		"firmware_id": string(firmware.ID),
+		"version":     firmware.Version,
+		"checksum":    firmware.Checksum,
+		"size":        firmware.Size,
+		"url":         "/firmware/" + string(firmware.ID) + "/artifact",
+	}
+
+	for _, update := range updates {
+		if update.Status != domain.DeviceUpdateScheduled {
+			continue
+		}
+
+		commandID := domain.CommandID(uuid.New().String())
+		_, err := uc.commands.CreateCommand(commandID, update.DeviceID, domain.CommandUpdateFirmware, params, uc.updateTimeout)
+		if err != nil {
+			// The device cannot be reached any more, e.g. it was
+			// decommissioned after the campaign started.
+			if err := update.Dispatch("", now); err != nil {
+				return err
+			}
+
+			if err := update.Fail(err.Error(), now); err != nil {
+				return err
+			}
+		} else if err := update.Dispatch(commandID, now); err != nil {
+			return err
+		}
+
+		if err := uc.saveUpdate(update, domain.DeviceUpdateScheduled); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *FirmwareUseCase) saveUpdate(update *domain.DeviceUpdate, previous domain.DeviceUpdateStatus) error {
+	if err := uc.campaignRepo.UpdateDeviceUpdate(update); err != nil {
+		return err
+	}
+
+	if update.Status == previous {
+		return nil
+	}
+
+	event := &domain.DeviceUpdateStatusChangedEvent{
+		CampaignID: update.CampaignID,
+		DeviceID:   update.DeviceID,
+		Status:     update.Status,
+		Error:      update.Error,
+	}
+
+	return uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+func (uc *FirmwareUseCase) setCampaignStatus(campaign *domain.Campaign, change func() error) error {
+	from := campaign.Status
+	if err := change(); err != nil {
+		return err
+	}
+
+	if err := uc.campaignRepo.Update(campaign); err != nil {
+		return err
+	}
+
+	return uc.publishCampaign(campaign, from)
+}
+
+func (uc *FirmwareUseCase) publishCampaign(campaign *domain.Campaign, from domain.CampaignStatus) error {
+	event := &domain.CampaignStatusChangedEvent{
+		CampaignID: campaign.ID,
+		From:       from,
+		To:         campaign.Status,
+		Reason:     campaign.Reason,
+	}
+
+	return uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+func (uc *FirmwareUseCase) detail(campaign *domain.Campaign) (*CampaignDetail, error) {
+	updates, err := uc.campaignRepo.FindDeviceUpdates(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(map[domain.DeviceUpdateStatus]int)
+	for _, update := range updates {
+		summary[update.Status]++
+	}
+
+	return &CampaignDetail{Campaign: campaign, Summary: summary, Updates: updates}, nil
+}
@@ -0,0 +1,230 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"strings"
+	"testing"
+	"time"
+)
+
+type firmwareFixture struct {
+	useCase   *FirmwareUseCase
+	devices   domain.DeviceRepository
+	campaigns domain.CampaignRepository
+	artifacts *MockArtifactStore
+	commands  *MockCommandSender
+	publisher *MockEventPublisher
+	firmware  *domain.Firmware
+}
+
+func newFirmwareFixture(t *testing.T, gateways int) *firmwareFixture {
+	t.Helper()
+
+	ids := make([]domain.DeviceID, gateways)
+	for i := range ids {
+		ids[i] = domain.DeviceID("gateway-" + string(rune('a'+i)))
+	}
+
+	f := &firmwareFixture{
+		devices:   newGatewayRepository(t, ids...),
+		campaigns: persistence.NewInMemoryCampaignRepository(),
+		artifacts: NewMockArtifactStore(),
+		commands:  NewMockCommandSender(),
+		publisher: NewMockEventPublisher(),
+	}
+
+	sensor, _ := domain.NewDevice("sensor-node", "Node", "sensor_node")
+	f.devices.Save(sensor)
+
+	f.useCase = NewFirmwareUseCase(f.devices, persistence.NewInMemoryFirmwareRepository(), f.campaigns, f.artifacts, f.commands, f.publisher, time.Hour)
+
+	firmware, err := f.useCase.UploadFirmware("firmware-1", "2.0.0", "gateway", "", strings.NewReader("image"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.firmware = firmware
+
+	return f
+}
+
+func (f *firmwareFixture) report(t *testing.T, campaignID domain.CampaignID, deviceID domain.DeviceID, status domain.DeviceUpdateStatus) {
+	t.Helper()
+
+	if _, err := f.useCase.ReportUpdate(deviceID, campaignID, status, 0, "", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFirmwareUseCase_UploadFirmware(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		checksum    string
+		expectError error
+	}{
+		{name: "matching checksum", version: "2.1.0", checksum: "6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d"},
+		{name: "checksum mismatch", version: "2.1.0", checksum: strings.Repeat("0", 64), expectError: domain.ErrInvalidFirmware},
+		{name: "version already uploaded", version: "2.0.0", expectError: domain.ErrFirmwareAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFirmwareFixture(t, 1)
+
+			firmware, err := f.useCase.UploadFirmware("firmware-2", tt.version, "gateway", tt.checksum, strings.NewReader("image"))
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				if _, ok := f.artifacts.artifacts["firmware-2"]; ok {
+					t.Errorf("expected the rejected image to be deleted")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if firmware.Size != 5 || firmware.Checksum != tt.checksum {
+				t.Errorf("unexpected firmware %+v", firmware)
+			}
+		})
+	}
+}
+
+func TestFirmwareUseCase_CreateCampaign(t *testing.T) {
+	f := newFirmwareFixture(t, 4)
+
+	campaign, err := f.useCase.CreateCampaign("campaign-1", f.firmware.ID, []int{25, 100}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(campaign.Updates) != 4 {
+		t.Fatalf("expected only the active gateways to be targeted, got %+v", campaign.Updates)
+	}
+
+	if campaign.FailureThreshold != DefaultFailureThreshold {
+		t.Errorf("expected the default threshold, got %v", campaign.FailureThreshold)
+	}
+
+	if len(f.commands.sent) != 1 || f.commands.sent[0] != campaign.Updates[0].DeviceID {
+		t.Errorf("expected only the first device of the rollout to be updated, got %v", f.commands.sent)
+	}
+
+	if campaign.Summary[domain.DeviceUpdatePending] != 1 || campaign.Summary[domain.DeviceUpdateScheduled] != 3 {
+		t.Errorf("unexpected summary %v", campaign.Summary)
+	}
+
+	if _, err := f.useCase.CreateCampaign("campaign-2", "firmware-404", nil, nil); !errors.Is(err, domain.ErrFirmwareNotFound) {
+		t.Errorf("expected ErrFirmwareNotFound, got %v", err)
+	}
+}
+
+func TestFirmwareUseCase_RolloutStages(t *testing.T) {
+	f := newFirmwareFixture(t, 4)
+
+	campaign, _ := f.useCase.CreateCampaign("campaign-1", f.firmware.ID, []int{25, 100}, nil)
+	first := campaign.Updates[0].DeviceID
+
+	f.report(t, campaign.ID, first, domain.DeviceUpdateDownloading)
+	if len(f.commands.sent) != 1 {
+		t.Fatalf("expected the next stage to wait, got %v", f.commands.sent)
+	}
+
+	f.report(t, campaign.ID, first, domain.DeviceUpdateSucceeded)
+	if len(f.commands.sent) != 4 {
+		t.Fatalf("expected the last stage to be dispatched, got %v", f.commands.sent)
+	}
+
+	for _, update := range campaign.Updates[1:] {
+		f.report(t, campaign.ID, update.DeviceID, domain.DeviceUpdateSucceeded)
+	}
+
+	detail, _ := f.useCase.GetCampaign(campaign.ID)
+	if detail.Status != domain.CampaignCompleted || detail.Summary[domain.DeviceUpdateSucceeded] != 4 {
+		t.Errorf("expected a completed campaign, got %s %v", detail.Status, detail.Summary)
+	}
+}
+
+func TestFirmwareUseCase_HaltsOnFailures(t *testing.T) {
+	f := newFirmwareFixture(t, 4)
+	threshold := 0.4
+
+	campaign, _ := f.useCase.CreateCampaign("campaign-1", f.firmware.ID, []int{50, 100}, &threshold)
+
+	f.report(t, campaign.ID, campaign.Updates[0].DeviceID, domain.DeviceUpdateFailed)
+
+	detail, _ := f.useCase.GetCampaign(campaign.ID)
+	if detail.Status != domain.CampaignHalted {
+		t.Fatalf("expected a halted campaign, got %s", detail.Status)
+	}
+
+	f.report(t, campaign.ID, campaign.Updates[1].DeviceID, domain.DeviceUpdateSucceeded)
+	if len(f.commands.sent) != 2 {
+		t.Errorf("expected no dispatch while halted, got %v", f.commands.sent)
+	}
+
+	// Resuming accepts the failure seen so far and starts the next stage.
+	detail, err := f.useCase.ResumeCampaign(campaign.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detail.Status != domain.CampaignRunning || len(f.commands.sent) != 4 {
+		t.Errorf("expected the rollout to continue, got %s %v", detail.Status, f.commands.sent)
+	}
+}
+
+func TestFirmwareUseCase_PauseAndAbort(t *testing.T) {
+	f := newFirmwareFixture(t, 4)
+
+	campaign, _ := f.useCase.CreateCampaign("campaign-1", f.firmware.ID, []int{25, 100}, nil)
+
+	if _, err := f.useCase.PauseCampaign(campaign.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.report(t, campaign.ID, campaign.Updates[0].DeviceID, domain.DeviceUpdateSucceeded)
+	if len(f.commands.sent) != 1 {
+		t.Errorf("expected no dispatch while paused, got %v", f.commands.sent)
+	}
+
+	detail, err := f.useCase.AbortCampaign(campaign.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if detail.Status != domain.CampaignAborted || detail.Summary[domain.DeviceUpdateCancelled] != 3 {
+		t.Errorf("expected the scheduled updates to be cancelled, got %s %v", detail.Status, detail.Summary)
+	}
+
+	if _, err := f.useCase.ResumeCampaign(campaign.ID); !errors.Is(err, domain.ErrInvalidCampaignTransition) {
+		t.Errorf("expected ErrInvalidCampaignTransition, got %v", err)
+	}
+}
+
+func TestFirmwareUseCase_Run(t *testing.T) {
+	f := newFirmwareFixture(t, 2)
+	f.commands.sendErr["gateway-b"] = domain.ErrDeviceDecommissioned
+
+	campaign, _ := f.useCase.CreateCampaign("campaign-1", f.firmware.ID, []int{100}, nil)
+
+	if err := f.useCase.Run(time.Now().Add(2 * time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	detail, _ := f.useCase.GetCampaign(campaign.ID)
+	for _, update := range detail.Updates {
+		if update.Status != domain.DeviceUpdateFailed {
+			t.Errorf("expected %s to fail, got %s", update.DeviceID, update.Status)
+		}
+	}
+
+	if detail.Status != domain.CampaignHalted {
+		t.Errorf("expected a halted campaign, got %s", detail.Status)
+	}
+}
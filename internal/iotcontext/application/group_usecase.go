@@ -0,0 +1,257 @@
+package application
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+// Target picks devices or sensors by label selector, by device group or by
+// both, in which case a resource has to satisfy the two.
+type Target struct {
+	Selector domain.Selector
+	GroupID  domain.GroupID
+}
+
+// ParseTarget builds a target from the raw selector and group id, either of
+// which may be empty.
+func ParseTarget(selector string, groupID string) (Target, error) {
+	target := Target{GroupID: domain.GroupID(groupID)}
+	if selector != "" {
+		parsed, err := domain.ParseSelector(selector)
+		if err != nil {
+			return Target{}, err
+		}
+		target.Selector = parsed
+	}
+
+	return target, nil
+}
+
+func (t Target) IsEmpty() bool {
+	return t.Selector.IsEmpty() && t.GroupID == ""
+}
+
+// BulkResult reports an operation applied to every resource of a target.
+type BulkResult struct {
+	Matched   int               `json:"matched"`
+	Succeeded []string          `json:"succeeded"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
+func newBulkResult() BulkResult {
+	return BulkResult{Succeeded: []string{}, Failed: map[string]string{}}
+}
+
+func (r *BulkResult) record(id string, err error) {
+	r.Matched++
+	if err != nil {
+		r.Failed[id] = err.Error()
+		return
+	}
+
+	r.Succeeded = append(r.Succeeded, id)
+}
+
+// GroupUseCase manages device groups and resolves targets to the devices and
+// sensors they select.
+type GroupUseCase struct {
+	groupRepo      domain.GroupRepository
+	deviceRepo     domain.DeviceRepository
+	sensorRepo     domain.SensorRepository
+	eventPublisher domain.EventPublisher
+	scope          TenantScope
+}
+
+func NewGroupUseCase(
+	groupRepo domain.GroupRepository,
+	deviceRepo domain.DeviceRepository,
+	sensorRepo domain.SensorRepository,
+	publisher domain.EventPublisher,
+) *GroupUseCase {
+	return &GroupUseCase{
+		groupRepo:      groupRepo,
+		deviceRepo:     deviceRepo,
+		sensorRepo:     sensorRepo,
+		eventPublisher: publisher,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the groups,
+// devices and sensors of the tenant. The zero scope returns uc itself.
+func (uc *GroupUseCase) ForTenant(scope TenantScope) *GroupUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.groupRepo = scopeGroups(uc.groupRepo, scope.Tenant)
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.sensorRepo = scopeSensors(uc.sensorRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+func (uc *GroupUseCase) CreateGroup(id domain.GroupID, name string, selector string, deviceIDs []domain.DeviceID, now time.Time) (*domain.DeviceGroup, error) {
+	group, err := domain.NewDeviceGroup(id, name, selector, deviceIDs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkMembers(group.DeviceIDs); err != nil {
+		return nil, err
+	}
+
+	if err := uc.groupRepo.Save(group); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditGroupCreate, string(group.ID), nil, snapshot(group))
+
+	return group, uc.publish(group, false)
+}
+
+func (uc *GroupUseCase) GetGroup(id domain.GroupID) (*domain.DeviceGroup, error) {
+	return uc.groupRepo.FindByID(id)
+}
+
+func (uc *GroupUseCase) ListGroups() ([]*domain.DeviceGroup, error) {
+	return uc.groupRepo.FindAll()
+}
+
+// UpdateGroup replaces the definition of the group. Static groups can be
+// turned into dynamic ones and back.
+func (uc *GroupUseCase) UpdateGroup(id domain.GroupID, name string, selector string, deviceIDs []domain.DeviceID, now time.Time) (*domain.DeviceGroup, error) {
+	group, err := uc.groupRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(group)
+	if err := group.Update(name, selector, deviceIDs, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkMembers(group.DeviceIDs); err != nil {
+		return nil, err
+	}
+
+	if err := uc.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditGroupUpdate, string(group.ID), before, snapshot(group))
+
+	return group, uc.publish(group, false)
+}
+
+// DeleteGroup removes the group. Its devices are left untouched.
+func (uc *GroupUseCase) DeleteGroup(id domain.GroupID) error {
+	group, err := uc.groupRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.groupRepo.Delete(id); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditGroupDelete, string(group.ID), snapshot(group), nil)
+
+	return uc.publish(group, true)
+}
+
+// Devices returns the devices of the target, in creation order. Members of a
+// static group that were deleted since are skipped.
+func (uc *GroupUseCase) Devices(target Target) ([]*domain.Device, error) {
+	var group *domain.DeviceGroup
+	if target.GroupID != "" {
+		found, err := uc.groupRepo.FindByID(target.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		group = found
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*domain.Device, 0)
+	for i := range devices {
+		device := devices[i]
+		if group != nil && !group.Contains(device) {
+			continue
+		}
+		if !target.Selector.Matches(device.Labels) {
+			continue
+		}
+		selected = append(selected, &device)
+	}
+
+	return selected, nil
+}
+
+// Sensors returns the sensors of the target. A sensor inherits the labels of
+// its device, overriding those it sets itself, so site=madrid also selects
+// the sensors of the devices in Madrid. With a group, only sensors of its
+// devices are selected.
+func (uc *GroupUseCase) Sensors(target Target) ([]*domain.Sensor, error) {
+	devices, err := uc.Devices(Target{GroupID: target.GroupID})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[domain.DeviceID]*domain.Device, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+
+	sensors, err := uc.sensorRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*domain.Sensor, 0)
+	for _, sensor := range sensors {
+		device, ok := byID[sensor.DeviceID]
+		if !ok {
+			continue
+		}
+		if !target.Selector.Matches(device.Labels.Merge(sensor.Labels)) {
+			continue
+		}
+		selected = append(selected, sensor)
+	}
+
+	return selected, nil
+}
+
+func (uc *GroupUseCase) checkMembers(deviceIDs []domain.DeviceID) error {
+	for _, id := range deviceIDs {
+		device, err := uc.deviceRepo.FindByID(id)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, id)
+		}
+		if device.ID == "" {
+			return fmt.Errorf("%w: %s", domain.ErrDeviceNotFound, id)
+		}
+	}
+
+	return nil
+}
+
+func (uc *GroupUseCase) publish(group *domain.DeviceGroup, deleted bool) error {
+	event := &domain.DeviceGroupChangedEvent{
+		GroupID:   group.ID,
+		Name:      group.Name,
+		Selector:  group.Selector,
+		DeviceIDs: group.DeviceIDs,
+		Deleted:   deleted,
+	}
+
+	return uc.eventPublisher.Publish(event.ToDomainEvent())
+}
@@ -0,0 +1,260 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLabelledFleet stores two devices in Madrid, on floors 1 and 2, and one
+// in Bilbao, each with a temperature sensor. The sensor on floor 2 is
+// labelled as being in the north zone.
+func newLabelledFleet(t *testing.T) (domain.DeviceRepository, domain.SensorRepository) {
+	t.Helper()
+
+	devices := persistence.NewInMemoryDeviceRepository()
+	sensors := persistence.NewInMemorySensorRepository()
+	fleet := []struct {
+		id     domain.DeviceID
+		labels domain.Labels
+	}{
+		{id: "madrid-1", labels: domain.Labels{"site": "madrid", "floor": "1"}},
+		{id: "madrid-2", labels: domain.Labels{"site": "madrid", "floor": "2"}},
+		{id: "bilbao-1", labels: domain.Labels{"site": "bilbao", "floor": "1"}},
+	}
+
+	for _, d := range fleet {
+		device, _ := domain.NewDevice(d.id, "Gateway", "gateway")
+		if err := device.Relabel(d.labels); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		devices.Save(device)
+
+		sensor, _ := domain.NewSensor(domain.SensorID("sensor-"+d.id), d.id, "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000})
+		if d.id == "madrid-2" {
+			sensor.Relabel(domain.Labels{"zone": "north"})
+		}
+		sensors.Save(sensor)
+	}
+
+	return devices, sensors
+}
+
+func deviceIDs(devices []*domain.Device) []string {
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, string(device.ID))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sensorIDs(sensors []*domain.Sensor) []string {
+	ids := make([]string, 0, len(sensors))
+	for _, sensor := range sensors {
+		ids = append(ids, string(sensor.ID))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestGroupUseCase_Devices(t *testing.T) {
+	devices, sensors := newLabelledFleet(t)
+	groups := persistence.NewInMemoryGroupRepository()
+	useCase := NewGroupUseCase(groups, devices, sensors, NewMockEventPublisher())
+	now := time.Now()
+
+	if _, err := useCase.CreateGroup("madrid", "Madrid", "site=madrid", nil, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.CreateGroup("lab", "Lab", "", []domain.DeviceID{"madrid-2", "bilbao-1"}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		selector string
+		group    string
+		expected []string
+	}{
+		{name: "everything", expected: []string{"bilbao-1", "madrid-1", "madrid-2"}},
+		{name: "selector", selector: "site=madrid,floor in (1,2)", expected: []string{"madrid-1", "madrid-2"}},
+		{name: "dynamic group", group: "madrid", expected: []string{"madrid-1", "madrid-2"}},
+		{name: "static group", group: "lab", expected: []string{"bilbao-1", "madrid-2"}},
+		{name: "group and selector", group: "lab", selector: "floor=1", expected: []string{"bilbao-1"}},
+		{name: "no match", selector: "site=paris", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := ParseTarget(tt.selector, tt.group)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			found, err := useCase.Devices(target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := deviceIDs(found); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := useCase.Devices(Target{GroupID: "unknown"}); !errors.Is(err, domain.ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestGroupUseCase_Sensors(t *testing.T) {
+	devices, sensors := newLabelledFleet(t)
+	useCase := NewGroupUseCase(persistence.NewInMemoryGroupRepository(), devices, sensors, NewMockEventPublisher())
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "site=madrid", expected: []string{"sensor-madrid-1", "sensor-madrid-2"}},
+		{selector: "site=madrid,zone=north", expected: []string{"sensor-madrid-2"}},
+		{selector: "floor=1,!zone", expected: []string{"sensor-bilbao-1", "sensor-madrid-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			target, _ := ParseTarget(tt.selector, "")
+
+			found, err := useCase.Sensors(target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := sensorIDs(found); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGroupUseCase_CreateAndUpdateGroup(t *testing.T) {
+	devices, sensors := newLabelledFleet(t)
+	publisher := NewMockEventPublisher()
+	useCase := NewGroupUseCase(persistence.NewInMemoryGroupRepository(), devices, sensors, publisher)
+	now := time.Now()
+
+	if _, err := useCase.CreateGroup("lab", "Lab", "", []domain.DeviceID{"unknown"}, now); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+
+	if _, err := useCase.CreateGroup("lab", "Lab", "site in (", nil, now); !errors.Is(err, domain.ErrInvalidSelector) {
+		t.Errorf("expected ErrInvalidSelector, got %v", err)
+	}
+
+	if _, err := useCase.CreateGroup("lab", "Lab", "", []domain.DeviceID{"madrid-1"}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	group, err := useCase.UpdateGroup("lab", "Lab", "floor=2", nil, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !group.IsDynamic() || len(group.DeviceIDs) != 0 {
+		t.Errorf("expected the group to become dynamic, got %+v", group)
+	}
+
+	if err := useCase.DeleteGroup("lab"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := publisher.GetEvents()
+	if len(events) != 3 || events[0].Type != "device.group.changed" || events[2].Type != "device.group.deleted" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestSimulatorUseCase_ControlSensors(t *testing.T) {
+	devices, sensors := newLabelledFleet(t)
+	simulator := NewMockSimulatorRepository()
+	groups := NewGroupUseCase(persistence.NewInMemoryGroupRepository(), devices, sensors, NewMockEventPublisher())
+	useCase := NewSimulatorUseCase(sensors, simulator, NewMockEventPublisher())
+
+	target, _ := ParseTarget("site=madrid", "")
+	selected, err := groups.Sensors(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := useCase.ControlSensors(selected, "explode"); !errors.Is(err, domain.ErrInvalidAction) {
+		t.Errorf("expected ErrInvalidAction, got %v", err)
+	}
+
+	simulator.Start("sensor-madrid-1")
+
+	result, err := useCase.ControlSensors(selected, "stop")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Matched != 2 || len(result.Succeeded) != 1 || result.Succeeded[0] != "sensor-madrid-1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, ok := result.Failed["sensor-madrid-2"]; !ok {
+		t.Errorf("expected the sensor that was not running to fail, got %+v", result)
+	}
+}
+
+func TestSensorUseCase_UpdateSensorConfigs(t *testing.T) {
+	devices, sensors := newLabelledFleet(t)
+	groups := NewGroupUseCase(persistence.NewInMemoryGroupRepository(), devices, sensors, NewMockEventPublisher())
+	useCase := NewSensorUseCase(sensors, persistence.NewInMemorySensorConfigHistoryRepository(), devices, NewMockSimulatorRepository(), NewMockMetrics(), NewMockEventPublisher())
+
+	target, _ := ParseTarget("floor=1", "")
+	selected, _ := groups.Sensors(target)
+
+	versions := map[domain.SensorID]int64{"sensor-madrid-1": 1, "sensor-bilbao-1": 1}
+	result := useCase.UpdateSensorConfigs(selected, domain.SensorConfig{SamplingRateMs: 5000, Enabled: true}, versions)
+	if result.Matched != 2 || len(result.Succeeded) != 2 || len(result.Failed) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	for _, id := range []domain.SensorID{"sensor-madrid-1", "sensor-bilbao-1"} {
+		sensor, _ := sensors.FindByID(id)
+		if sensor.Config.SamplingRateMs != 5000 || sensor.Config.SensorID != id {
+			t.Errorf("expected %s to be reconfigured, got %+v", id, sensor.Config)
+		}
+	}
+
+	untouched, _ := sensors.FindByID("sensor-madrid-2")
+	if untouched.Config.SamplingRateMs != 1000 {
+		t.Errorf("expected sensor-madrid-2 to keep its config, got %+v", untouched.Config)
+	}
+
+	versions = map[domain.SensorID]int64{}
+	for _, id := range []domain.SensorID{"sensor-madrid-1", "sensor-bilbao-1"} {
+		sensor, _ := sensors.FindByID(id)
+		versions[id] = sensor.Version
+	}
+	result = useCase.UpdateSensorConfigs(selected, domain.SensorConfig{SamplingRateMs: 0}, versions)
+	if len(result.Failed) != 2 {
+		t.Errorf("expected every invalid update to fail, got %+v", result)
+	}
+
+	// Stale or missing versions leave the sensors as they are.
+	versions = map[domain.SensorID]int64{"sensor-madrid-1": versions["sensor-madrid-1"] + 1}
+	result = useCase.UpdateSensorConfigs(selected, domain.SensorConfig{SamplingRateMs: 7000, Enabled: true}, versions)
+	if len(result.Succeeded) != 0 || len(result.Failed) != 2 {
+		t.Fatalf("expected both sensors to fail, got %+v", result)
+	}
+	for _, id := range []domain.SensorID{"sensor-madrid-1", "sensor-bilbao-1"} {
+		sensor, _ := sensors.FindByID(id)
+		if sensor.Config.SamplingRateMs != 5000 {
+			t.Errorf("expected %s to keep its config, got %+v", id, sensor.Config)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"time"
+)
+
+// NearbyDevice is a device found by a distance query.
+type NearbyDevice struct {
+	Device     domain.Device   `json:"device"`
+	Position   domain.GeoPoint `json:"position"`
+	DistanceKm float64         `json:"distance_km"`
+}
+
+// LocationUseCase manages the location hierarchy, places devices in it and
+// answers queries over a whole subtree of locations.
+type LocationUseCase struct {
+	locationRepo   domain.LocationRepository
+	deviceRepo     domain.DeviceRepository
+	sensorRepo     domain.SensorRepository
+	readingsRepo   domain.SensorReadingRepository
+	rollupRepo     domain.ReadingRollupRepository
+	eventPublisher domain.EventPublisher
+	scope          TenantScope
+	// expectedVersion is the device version AssignDevice is made against;
+	// 0 accepts any.
+	expectedVersion int64
+}
+
+func NewLocationUseCase(
+	locationRepo domain.LocationRepository,
+	deviceRepo domain.DeviceRepository,
+	sensorRepo domain.SensorRepository,
+	readingsRepo domain.SensorReadingRepository,
+	rollupRepo domain.ReadingRollupRepository,
+	publisher domain.EventPublisher,
+) *LocationUseCase {
+	return &LocationUseCase{
+		locationRepo:   locationRepo,
+		deviceRepo:     deviceRepo,
+		sensorRepo:     sensorRepo,
+		readingsRepo:   readingsRepo,
+		rollupRepo:     rollupRepo,
+		eventPublisher: publisher,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the locations,
+// devices, sensors and readings of the tenant. The zero scope returns uc
+// itself.
+func (uc *LocationUseCase) ForTenant(scope TenantScope) *LocationUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.locationRepo = scopeLocations(uc.locationRepo, scope.Tenant)
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.sensorRepo = scopeSensors(uc.sensorRepo, scope.Tenant)
+	scoped.readingsRepo = scopeReadings(uc.readingsRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+// ExpectVersion returns a copy of the use case whose AssignDevice fails with
+// ErrDeviceVersionConflict unless the device is still at version, the one
+// the caller last read.
+func (uc *LocationUseCase) ExpectVersion(version int64) *LocationUseCase {
+	expecting := *uc
+	expecting.expectedVersion = version
+
+	return &expecting
+}
+
+func (uc *LocationUseCase) CreateLocation(id domain.LocationID, kind domain.LocationKind, name string, parentID domain.LocationID, geo *domain.GeoPoint, indoor *domain.IndoorPosition, now time.Time) (*domain.Location, error) {
+	location, err := domain.NewLocation(id, kind, name, parentID, geo, indoor, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkParent(location); err != nil {
+		return nil, err
+	}
+
+	if err := uc.locationRepo.Save(location); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditLocationCreate, string(location.ID), nil, snapshot(location))
+
+	return location, uc.publish(location, false)
+}
+
+func (uc *LocationUseCase) GetLocation(id domain.LocationID) (*domain.Location, error) {
+	return uc.locationRepo.FindByID(id)
+}
+
+func (uc *LocationUseCase) ListLocations() ([]*domain.Location, error) {
+	return uc.locationRepo.FindAll()
+}
+
+// UpdateLocation renames, moves or repositions a location. Moving it takes
+// its whole subtree and devices along.
+func (uc *LocationUseCase) UpdateLocation(id domain.LocationID, name string, parentID domain.LocationID, geo *domain.GeoPoint, indoor *domain.IndoorPosition, now time.Time) (*domain.Location, error) {
+	location, err := uc.locationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(location)
+	if err := location.Update(name, parentID, geo, indoor, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkParent(location); err != nil {
+		return nil, err
+	}
+
+	if err := uc.locationRepo.Update(location); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditLocationUpdate, string(location.ID), before, snapshot(location))
+
+	return location, uc.publish(location, false)
+}
+
+// DeleteLocation removes a location that has neither child locations nor
+// devices; those have to be moved or deleted first.
+func (uc *LocationUseCase) DeleteLocation(id domain.LocationID) error {
+	location, err := uc.locationRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		if device.LocationID == id {
+			return fmt.Errorf("%w: device %s", domain.ErrLocationInUse, device.ID)
+		}
+	}
+
+	if err := uc.locationRepo.Delete(id); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditLocationDelete, string(location.ID), snapshot(location), nil)
+
+	return uc.publish(location, true)
+}
+
+// AssignDevice places a device in a location, or takes it out of any when
+// locationID is empty. geo overrides the position inherited from the
+// location.
+func (uc *LocationUseCase) AssignDevice(deviceID domain.DeviceID, locationID domain.LocationID, geo *domain.GeoPoint) (*domain.Device, error) {
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.expectedVersion != 0 && device.Version != uc.expectedVersion {
+		return nil, fmt.Errorf("%w: device is at version %d, not %d", domain.ErrDeviceVersionConflict, device.Version, uc.expectedVersion)
+	}
+
+	if locationID != "" {
+		if _, err := uc.locationRepo.FindByID(locationID); err != nil {
+			return nil, uc.unknownLocation(locationID, err)
+		}
+	}
+
+	before := snapshot(device)
+	if err := device.Locate(locationID, geo); err != nil {
+		return nil, err
+	}
+
+	if err := uc.deviceRepo.Update(&device); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditDeviceLocate, string(device.ID), before, snapshot(device))
+
+	event := &domain.DeviceLocationChangedEvent{
+		DeviceID:   device.ID,
+		LocationID: device.LocationID,
+		Geo:        device.Geo,
+	}
+
+	return &device, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// Devices returns the devices placed in the location or in any location
+// below it, in creation order.
+func (uc *LocationUseCase) Devices(id domain.LocationID) ([]domain.Device, error) {
+	tree, err := uc.tree()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := tree.Find(id); !ok {
+		return nil, domain.ErrLocationNotFound
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	subtree := tree.Subtree(id)
+	selected := make([]domain.Device, 0)
+	for _, device := range devices {
+		if device.LocationID != "" && subtree[device.LocationID] {
+			selected = append(selected, device)
+		}
+	}
+
+	return selected, nil
+}
+
+// Nearby returns the devices within radiusKm of center, closest first. A
+// device without coordinates of its own is placed at its location, or at the
+// closest ancestor of it that has coordinates; devices with no position at
+// all are left out.
+func (uc *LocationUseCase) Nearby(center domain.GeoPoint, radiusKm float64) ([]NearbyDevice, error) {
+	if err := center.Validate(); err != nil {
+		return nil, err
+	}
+
+	if radiusKm <= 0 {
+		return nil, fmt.Errorf("%w: radius must be positive", domain.ErrInvalidLocation)
+	}
+
+	tree, err := uc.tree()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := make([]NearbyDevice, 0)
+	for _, device := range devices {
+		position := device.Geo
+		if position == nil {
+			position = tree.Position(device.LocationID)
+		}
+		if position == nil {
+			continue
+		}
+
+		distance := center.DistanceKm(*position)
+		if distance <= radiusKm {
+			nearby = append(nearby, NearbyDevice{Device: device, Position: *position, DistanceKm: distance})
+		}
+	}
+
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+
+	return nearby, nil
+}
+
+// Readings returns the raw readings in [from, to) of every sensor in the
+// subtree of the location, oldest first and at most limit of them. An empty
+// sensorType matches every sensor.
+func (uc *LocationUseCase) Readings(id domain.LocationID, sensorType domain.SensorType, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	if limit <= 0 || !to.After(from) {
+		return nil, domain.ErrInvalidTimeRange
+	}
+
+	sensors, err := uc.sensors(id)
+	if err != nil {
+		return nil, err
+	}
+
+	readings := make([]domain.SensorReading, 0)
+	for _, sensor := range sensors {
+		if sensorType != "" && sensor.Type != sensorType {
+			continue
+		}
+
+		found, err := uc.readingsRepo.FindBySensorIDBetween(sensor.ID, from, to, limit)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, found...)
+	}
+
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].Timestamp.Before(readings[j].Timestamp)
+	})
+
+	if len(readings) > limit {
+		readings = readings[:limit]
+	}
+
+	return readings, nil
+}
+
+// Metrics returns the metrics tree of the location. Reading stats come from
+// the hourly rollups, so from is widened to the start of its hour.
+func (uc *LocationUseCase) Metrics(id domain.LocationID, from, to time.Time) (*domain.LocationMetrics, error) {
+	if !to.After(from) {
+		return nil, domain.ErrInvalidTimeRange
+	}
+
+	tree, err := uc.tree()
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := tree.Find(id)
+	if !ok {
+		return nil, domain.ErrLocationNotFound
+	}
+
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sensors, err := uc.sensorRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sensorsByDevice := make(map[domain.DeviceID][]*domain.Sensor)
+	for _, sensor := range sensors {
+		sensorsByDevice[sensor.DeviceID] = append(sensorsByDevice[sensor.DeviceID], sensor)
+	}
+
+	devicesByLocation := make(map[domain.LocationID][]domain.Device)
+	for _, device := range devices {
+		if device.LocationID != "" {
+			devicesByLocation[device.LocationID] = append(devicesByLocation[device.LocationID], device)
+		}
+	}
+
+	var build func(location *domain.Location) (*domain.LocationMetrics, error)
+	build = func(location *domain.Location) (*domain.LocationMetrics, error) {
+		metrics := domain.NewLocationMetrics(location)
+
+		for _, device := range devicesByLocation[location.ID] {
+			metrics.Devices++
+			for _, sensor := range sensorsByDevice[device.ID] {
+				metrics.Sensors++
+
+				aggregates, err := uc.rollupRepo.FindAggregates(sensor.ID, domain.ResolutionHour, from, to)
+				if err != nil {
+					return nil, err
+				}
+
+				var stats domain.ReadingStats
+				for _, aggregate := range aggregates {
+					stats.Add(aggregate)
+				}
+				metrics.AddStats(sensor.Type, stats)
+			}
+		}
+
+		for _, child := range tree.Children(location.ID) {
+			childMetrics, err := build(child)
+			if err != nil {
+				return nil, err
+			}
+			metrics.Absorb(childMetrics)
+		}
+
+		return metrics, nil
+	}
+
+	return build(root)
+}
+
+func (uc *LocationUseCase) sensors(id domain.LocationID) ([]*domain.Sensor, error) {
+	devices, err := uc.Devices(id)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*domain.Sensor, 0)
+	for _, device := range devices {
+		sensors, err := uc.sensorRepo.FindByDeviceID(device.ID)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, sensors...)
+	}
+
+	return selected, nil
+}
+
+func (uc *LocationUseCase) tree() (*domain.LocationTree, error) {
+	locations, err := uc.locationRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewLocationTree(locations), nil
+}
+
+func (uc *LocationUseCase) checkParent(location *domain.Location) error {
+	if location.ParentID == "" {
+		return nil
+	}
+
+	parent, err := uc.locationRepo.FindByID(location.ParentID)
+	if err != nil {
+		return uc.unknownLocation(location.ParentID, err)
+	}
+
+	return location.CanHang(parent)
+}
+
+// unknownLocation reports a reference to a missing location as invalid
+// input, so it is not mistaken for the requested resource being missing.
+func (uc *LocationUseCase) unknownLocation(id domain.LocationID, err error) error {
+	if errors.Is(err, domain.ErrLocationNotFound) {
+		return fmt.Errorf("%w: location %s does not exist", domain.ErrInvalidLocation, id)
+	}
+
+	return err
+}
+
+func (uc *LocationUseCase) publish(location *domain.Location, deleted bool) error {
+	event := &domain.LocationChangedEvent{
+		LocationID: location.ID,
+		Kind:       location.Kind,
+		Name:       location.Name,
+		ParentID:   location.ParentID,
+		Deleted:    deleted,
+	}
+
+	return uc.eventPublisher.Publish(event.ToDomainEvent())
+}
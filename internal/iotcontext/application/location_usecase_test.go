@@ -0,0 +1,310 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// locationFixture is Acme with a Madrid site, which has coordinates, and an
+// office area in it, plus a Bilbao site with one device pinned to its own
+// coordinates. Every device has a temperature and a humidity sensor.
+type locationFixture struct {
+	useCase  *LocationUseCase
+	devices  domain.DeviceRepository
+	readings *persistence.InMemorySensorReadingRepository
+	rollups  domain.ReadingRollupRepository
+}
+
+func newLocationFixture(t *testing.T) locationFixture {
+	t.Helper()
+
+	f := locationFixture{
+		devices:  persistence.NewInMemoryDeviceRepository(),
+		readings: persistence.NewInMemorySensorReadingRepository(),
+		rollups:  persistence.NewInMemoryReadingRollupRepository(),
+	}
+	sensors := persistence.NewInMemorySensorRepository()
+	f.useCase = NewLocationUseCase(persistence.NewInMemoryLocationRepository(), f.devices, sensors, f.readings, f.rollups, NewMockEventPublisher())
+	now := time.Now()
+
+	hierarchy := []struct {
+		id     domain.LocationID
+		kind   domain.LocationKind
+		parent domain.LocationID
+		geo    *domain.GeoPoint
+	}{
+		{id: "acme", kind: domain.LocationOrganization},
+		{id: "madrid", kind: domain.LocationSite, parent: "acme", geo: &domain.GeoPoint{Lat: 40.4168, Lon: -3.7038}},
+		{id: "office", kind: domain.LocationArea, parent: "madrid"},
+		{id: "bilbao", kind: domain.LocationSite, parent: "acme"},
+	}
+	for _, l := range hierarchy {
+		if _, err := f.useCase.CreateLocation(l.id, l.kind, string(l.id), l.parent, l.geo, nil, now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	fleet := []struct {
+		id       domain.DeviceID
+		location domain.LocationID
+		geo      *domain.GeoPoint
+	}{
+		{id: "madrid-1", location: "madrid"},
+		{id: "office-1", location: "office"},
+		{id: "bilbao-1", location: "bilbao", geo: &domain.GeoPoint{Lat: 43.263, Lon: -2.935}},
+		{id: "spare-1"},
+	}
+	for _, d := range fleet {
+		device, _ := domain.NewDevice(d.id, "Gateway", "gateway")
+		f.devices.Save(device)
+		if d.location != "" {
+			if _, err := f.useCase.AssignDevice(d.id, d.location, d.geo); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		for _, typ := range []domain.SensorType{domain.Temperature, domain.Humidity} {
+			sensor, _ := domain.NewSensor(domain.SensorID(string(typ)+"-"+string(d.id)), d.id, "Sensor", typ, domain.SensorConfig{SamplingRateMs: 1000})
+			sensors.Save(sensor)
+		}
+	}
+
+	return f
+}
+
+func TestLocationUseCase_CreateLocation(t *testing.T) {
+	f := newLocationFixture(t)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		kind        domain.LocationKind
+		parent      domain.LocationID
+		expectError error
+	}{
+		{name: "zone in an area", kind: domain.LocationZone, parent: "office"},
+		{name: "zone in a site", kind: domain.LocationZone, parent: "madrid", expectError: domain.ErrInvalidLocation},
+		{name: "unknown parent", kind: domain.LocationArea, parent: "unknown", expectError: domain.ErrInvalidLocation},
+		{name: "site without parent", kind: domain.LocationSite, expectError: domain.ErrInvalidLocation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.useCase.CreateLocation(domain.LocationID(tt.name), tt.kind, tt.name, tt.parent, nil, nil, now)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocationUseCase_DeleteLocation(t *testing.T) {
+	f := newLocationFixture(t)
+
+	if err := f.useCase.DeleteLocation("madrid"); !errors.Is(err, domain.ErrLocationInUse) {
+		t.Errorf("expected ErrLocationInUse, got %v", err)
+	}
+
+	if err := f.useCase.DeleteLocation("office"); !errors.Is(err, domain.ErrLocationInUse) {
+		t.Errorf("expected ErrLocationInUse while office-1 is there, got %v", err)
+	}
+
+	if _, err := f.useCase.AssignDevice("office-1", "", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := f.useCase.DeleteLocation("office"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLocationUseCase_AssignDevice(t *testing.T) {
+	f := newLocationFixture(t)
+
+	if _, err := f.useCase.AssignDevice("spare-1", "unknown", nil); !errors.Is(err, domain.ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation, got %v", err)
+	}
+
+	if _, err := f.useCase.AssignDevice("unknown", "madrid", nil); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+
+	if _, err := f.useCase.AssignDevice("spare-1", "madrid", &domain.GeoPoint{Lat: 91}); !errors.Is(err, domain.ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation, got %v", err)
+	}
+
+	spare, _ := f.devices.FindByID("spare-1")
+	if _, err := f.useCase.ExpectVersion(spare.Version+1).AssignDevice("spare-1", "office", nil); !errors.Is(err, domain.ErrDeviceVersionConflict) {
+		t.Errorf("expected ErrDeviceVersionConflict, got %v", err)
+	}
+
+	device, err := f.useCase.ExpectVersion(spare.Version).AssignDevice("spare-1", "office", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _ := f.devices.FindByID("spare-1")
+	if device.LocationID != "office" || stored.LocationID != "office" {
+		t.Errorf("expected spare-1 in the office, got %+v", stored)
+	}
+}
+
+func TestLocationUseCase_Devices(t *testing.T) {
+	f := newLocationFixture(t)
+
+	tests := []struct {
+		location domain.LocationID
+		expected []string
+	}{
+		{location: "acme", expected: []string{"bilbao-1", "madrid-1", "office-1"}},
+		{location: "madrid", expected: []string{"madrid-1", "office-1"}},
+		{location: "office", expected: []string{"office-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.location), func(t *testing.T) {
+			found, err := f.useCase.Devices(tt.location)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pointers := make([]*domain.Device, 0, len(found))
+			for i := range found {
+				pointers = append(pointers, &found[i])
+			}
+
+			if got := deviceIDs(pointers); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := f.useCase.Devices("unknown"); !errors.Is(err, domain.ErrLocationNotFound) {
+		t.Errorf("expected ErrLocationNotFound, got %v", err)
+	}
+}
+
+func TestLocationUseCase_Nearby(t *testing.T) {
+	f := newLocationFixture(t)
+	puertaDelSol := domain.GeoPoint{Lat: 40.4169, Lon: -3.7035}
+
+	nearby, err := f.useCase.Nearby(puertaDelSol, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// office-1 inherits the coordinates of Madrid through its area.
+	if len(nearby) != 2 || nearby[0].DistanceKm > 0.1 || nearby[0].Position != nearby[1].Position {
+		t.Fatalf("expected the two Madrid devices, got %+v", nearby)
+	}
+
+	nearby, err = f.useCase.Nearby(puertaDelSol, 400)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(nearby) != 3 || nearby[2].Device.ID != "bilbao-1" || math.Abs(nearby[2].DistanceKm-323) > 5 {
+		t.Errorf("expected bilbao-1 last, about 323 km away, got %+v", nearby)
+	}
+
+	if _, err := f.useCase.Nearby(puertaDelSol, 0); !errors.Is(err, domain.ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation, got %v", err)
+	}
+}
+
+func TestLocationUseCase_Readings(t *testing.T) {
+	f := newLocationFixture(t)
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	for i, id := range []domain.SensorID{"humidity-office-1", "humidity-madrid-1", "temperature-madrid-1", "humidity-bilbao-1"} {
+		reading := domain.NewSensorReading(id, "", "", float64(i), "", now.Add(-time.Duration(i+1)*time.Hour))
+		f.readings.Save(&reading)
+	}
+	old := domain.NewSensorReading("humidity-madrid-1", "", "", 99, "", now.Add(-48*time.Hour))
+	f.readings.Save(&old)
+
+	readings, err := f.useCase.Readings("madrid", domain.Humidity, now.Add(-24*time.Hour), now, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(readings) != 2 || readings[0].SensorID != "humidity-madrid-1" || readings[1].SensorID != "humidity-office-1" {
+		t.Errorf("expected the humidity readings of Madrid of the last day, oldest first, got %+v", readings)
+	}
+
+	readings, err = f.useCase.Readings("acme", "", now.Add(-24*time.Hour), now, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(readings) != 2 || readings[0].SensorID != "humidity-bilbao-1" {
+		t.Errorf("expected the two oldest readings of Acme, got %+v", readings)
+	}
+
+	if _, err := f.useCase.Readings("madrid", "", now, now, 10); !errors.Is(err, domain.ErrInvalidTimeRange) {
+		t.Errorf("expected ErrInvalidTimeRange, got %v", err)
+	}
+}
+
+func TestLocationUseCase_Metrics(t *testing.T) {
+	f := newLocationFixture(t)
+	bucket := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	samples := map[domain.DeviceID][]float64{
+		"madrid-1": {18, 22, 20, 20},
+		"office-1": {20, 26},
+		"bilbao-1": {12},
+	}
+	for deviceID, values := range samples {
+		for i, value := range values {
+			sensorID := domain.SensorID("temperature-" + string(deviceID))
+			f.rollups.Apply(domain.NewSensorReading(sensorID, deviceID, domain.Temperature, value, "°C", bucket.Add(time.Duration(i)*time.Minute)))
+		}
+	}
+
+	metrics, err := f.useCase.Metrics("acme", bucket, bucket.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if metrics.Devices != 3 || metrics.Sensors != 6 || len(metrics.Children) != 2 {
+		t.Fatalf("unexpected metrics for acme: %+v", metrics)
+	}
+
+	temperature := metrics.Readings[domain.Temperature]
+	if temperature.Count != 7 || temperature.Min != 12 || temperature.Max != 26 || temperature.Avg != 138.0/7 {
+		t.Errorf("unexpected temperature stats for acme: %+v", temperature)
+	}
+
+	if _, ok := metrics.Readings[domain.Humidity]; ok {
+		t.Errorf("expected no humidity stats without readings, got %+v", metrics.Readings)
+	}
+
+	var madrid *domain.LocationMetrics
+	for _, child := range metrics.Children {
+		if child.LocationID == "madrid" {
+			madrid = child
+		}
+	}
+
+	if madrid == nil || madrid.Devices != 2 || madrid.Readings[domain.Temperature].Avg != 21 || len(madrid.Children) != 1 {
+		t.Errorf("unexpected metrics for madrid: %+v", madrid)
+	}
+
+	if _, err := f.useCase.Metrics("unknown", bucket, bucket.Add(time.Hour)); !errors.Is(err, domain.ErrLocationNotFound) {
+		t.Errorf("expected ErrLocationNotFound, got %v", err)
+	}
+}
@@ -1,167 +1,196 @@
 package application
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"io"
+	"testing"
+	"time"
 )
 
-type MockSensorRepository struct {
-	sensors   map[domain.SensorID]*domain.Sensor
+// newGatewayRepository returns an in-memory device repository holding an
+// active gateway for each id and the decommissioned gateway device-456.
+func newGatewayRepository(t *testing.T, ids ...domain.DeviceID) domain.DeviceRepository {
+	t.Helper()
+
+	devices := persistence.NewInMemoryDeviceRepository()
+	for _, id := range ids {
+		device, _ := domain.NewDevice(id, "Gateway", "gateway")
+		if err := devices.Save(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	retired, _ := domain.NewDevice("device-456", "Old gateway", "gateway")
+	retired.Status = domain.DeviceDecommissioned
+	if err := devices.Save(retired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return devices
+}
+
+// The repositories below are the in-memory ones with errors injected, for
+// the tests of how failures are handled. Tests that need no failure use the
+// in-memory repositories as they are.
+
+// FaultyDeviceRepository fails Save, the finds or Update with the error set
+// for them.
+type FaultyDeviceRepository struct {
+	domain.DeviceRepository
 	saveErr   error
 	findErr   error
 	updateErr error
 }
 
-func NewMockSensorRepository() *MockSensorRepository {
-	return &MockSensorRepository{
-		sensors: make(map[domain.SensorID]*domain.Sensor),
-	}
+func NewFaultyDeviceRepository() *FaultyDeviceRepository {
+	return &FaultyDeviceRepository{DeviceRepository: persistence.NewInMemoryDeviceRepository()}
 }
 
-func (m *MockSensorRepository) Save(sensor *domain.Sensor) error {
-	if m.saveErr != nil {
-		return m.saveErr
+func (r *FaultyDeviceRepository) Save(device *domain.Device) error {
+	if r.saveErr != nil {
+		return r.saveErr
 	}
-	m.sensors[sensor.ID] = sensor
-	return nil
+	return r.DeviceRepository.Save(device)
 }
 
-func (m *MockSensorRepository) FindByID(id domain.SensorID) (*domain.Sensor, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	sensor, exists := m.sensors[id]
-	if !exists {
-		return nil, domain.ErrSensorNotFound
+func (r *FaultyDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
+	if r.findErr != nil {
+		return domain.Device{}, r.findErr
 	}
-	return sensor, nil
+	return r.DeviceRepository.FindByID(id)
 }
 
-func (m *MockSensorRepository) FindAll() ([]*domain.Sensor, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	var sensors []*domain.Sensor
-	for _, sensor := range m.sensors {
-		sensors = append(sensors, sensor)
+func (r *FaultyDeviceRepository) FindAll() ([]domain.Device, error) {
+	if r.findErr != nil {
+		return nil, r.findErr
 	}
-	return sensors, nil
+	return r.DeviceRepository.FindAll()
 }
 
-func (m *MockSensorRepository) Update(sensor *domain.Sensor) error {
-	if m.updateErr != nil {
-		return m.updateErr
+func (r *FaultyDeviceRepository) Update(device *domain.Device) error {
+	if r.updateErr != nil {
+		return r.updateErr
 	}
-	m.sensors[sensor.ID] = sensor
-	return nil
+	return r.DeviceRepository.Update(device)
 }
 
-func (m *MockSensorRepository) Delete(id domain.SensorID) error {
-	delete(m.sensors, id)
-	return nil
-}
-
-type MockDeviceRepository struct {
-	devices   map[domain.DeviceID]domain.Device
+// FaultySensorRepository fails Save, FindByID or Update with the error set
+// for them.
+type FaultySensorRepository struct {
+	domain.SensorRepository
 	saveErr   error
 	findErr   error
 	updateErr error
 }
 
-func NewMockDeviceRepository() *MockDeviceRepository {
-	return &MockDeviceRepository{
-		devices: make(map[domain.DeviceID]domain.Device),
-	}
+func NewFaultySensorRepository() *FaultySensorRepository {
+	return &FaultySensorRepository{SensorRepository: persistence.NewInMemorySensorRepository()}
 }
 
-func (m *MockDeviceRepository) Save(device *domain.Device) error {
-	if m.saveErr != nil {
-		return m.saveErr
+func (r *FaultySensorRepository) Save(sensor *domain.Sensor) error {
+	if r.saveErr != nil {
+		return r.saveErr
 	}
-	m.devices[device.ID] = *device
-	return nil
+	return r.SensorRepository.Save(sensor)
 }
 
-func (m *MockDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
-	if m.findErr != nil {
-		return domain.Device{}, m.findErr
+func (r *FaultySensorRepository) FindByID(id domain.SensorID) (*domain.Sensor, error) {
+	if r.findErr != nil {
+		return nil, r.findErr
 	}
-	device, exists := m.devices[id]
-	if !exists {
-		return domain.Device{}, domain.ErrDeviceNotFound
-	}
-	return device, nil
+	return r.SensorRepository.FindByID(id)
 }
 
-func (m *MockDeviceRepository) FindAll() ([]domain.Device, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
+func (r *FaultySensorRepository) Update(sensor *domain.Sensor) error {
+	if r.updateErr != nil {
+		return r.updateErr
 	}
-	var devices []domain.Device
-	for _, device := range m.devices {
-		devices = append(devices, device)
+	return r.SensorRepository.Update(sensor)
+}
+
+// FaultySensorReadingRepository fails the queries by sensor with findErr.
+type FaultySensorReadingRepository struct {
+	*persistence.InMemorySensorReadingRepository
+	findErr error
+}
+
+func NewFaultySensorReadingRepository() *FaultySensorReadingRepository {
+	return &FaultySensorReadingRepository{InMemorySensorReadingRepository: persistence.NewInMemorySensorReadingRepository()}
+}
+
+func (r *FaultySensorReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
+	if r.findErr != nil {
+		return nil, r.findErr
 	}
-	return devices, nil
+	return r.InMemorySensorReadingRepository.FindBySensorID(sensorID, limit)
 }
 
-func (m *MockDeviceRepository) Update(device *domain.Device) error {
-	if m.updateErr != nil {
-		return m.updateErr
+func (r *FaultySensorReadingRepository) FindBySensorIDBetween(sensorID domain.SensorID, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	if r.findErr != nil {
+		return nil, r.findErr
 	}
-	m.devices[device.ID] = *device
-	return nil
+	return r.InMemorySensorReadingRepository.FindBySensorIDBetween(sensorID, from, to, limit)
 }
 
-func (m *MockDeviceRepository) Delete(id domain.DeviceID) error {
-	delete(m.devices, id)
-	return nil
+// FaultySensorConfigHistoryRepository fails Append with appendErr.
+type FaultySensorConfigHistoryRepository struct {
+	domain.SensorConfigHistoryRepository
+	appendErr error
 }
 
-type MockSensorReadingRepository struct {
-	readings map[domain.SensorID][]domain.SensorReading
-	saveErr  error
-	findErr  error
+func NewFaultySensorConfigHistoryRepository() *FaultySensorConfigHistoryRepository {
+	return &FaultySensorConfigHistoryRepository{SensorConfigHistoryRepository: persistence.NewInMemorySensorConfigHistoryRepository()}
 }
 
-func NewMockSensorReadingRepository() *MockSensorReadingRepository {
-	return &MockSensorReadingRepository{
-		readings: make(map[domain.SensorID][]domain.SensorReading),
+func (r *FaultySensorConfigHistoryRepository) Append(revision *domain.SensorConfigRevision) error {
+	if r.appendErr != nil {
+		return r.appendErr
 	}
+	return r.SensorConfigHistoryRepository.Append(revision)
 }
 
-func (m *MockSensorReadingRepository) Save(reading *domain.SensorReading) error {
-	if m.saveErr != nil {
-		return m.saveErr
+type MockSimulatorRepository struct {
+	active  map[domain.SensorID]bool
+	stopped []domain.SensorID
+}
+
+func NewMockSimulatorRepository() *MockSimulatorRepository {
+	return &MockSimulatorRepository{
+		active: make(map[domain.SensorID]bool),
 	}
-	m.readings[reading.SensorID] = append(m.readings[reading.SensorID], *reading)
+}
+
+func (m *MockSimulatorRepository) Start(sensorID domain.SensorID) error {
+	m.active[sensorID] = true
 	return nil
 }
 
-func (m *MockSensorReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	readings, exists := m.readings[sensorID]
-	if !exists {
-		return []domain.SensorReading{}, nil
+func (m *MockSimulatorRepository) Stop(sensorID domain.SensorID) error {
+	if !m.active[sensorID] {
+		return domain.ErrSimulationNotActive
 	}
-	if limit > 0 && limit < len(readings) {
-		return readings[:limit], nil
-	}
-	return readings, nil
+	delete(m.active, sensorID)
+	m.stopped = append(m.stopped, sensorID)
+	return nil
 }
 
-func (m *MockSensorReadingRepository) FindByDeviceID(deviceID domain.DeviceID, limit int) ([]domain.SensorReading, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	var allReadings []domain.SensorReading
-	for _, readings := range m.readings {
-		allReadings = append(allReadings, readings...)
-	}
-	if limit > 0 && limit < len(allReadings) {
-		return allReadings[:limit], nil
+func (m *MockSimulatorRepository) InjectError(sensorID domain.SensorID) error {
+	if !m.active[sensorID] {
+		return domain.ErrSimulationNotActive
 	}
-	return allReadings, nil
+	return nil
+}
+
+func (m *MockSimulatorRepository) Execute(command domain.Command) (map[string]interface{}, error) {
+	return nil, domain.ErrSimulationNotActive
+}
+
+func (m *MockSimulatorRepository) Simulates(deviceID domain.DeviceID) bool {
+	return false
 }
 
 type MockEventPublisher struct {
@@ -222,3 +251,257 @@ func (m *MockMetrics) GetSensorErrors(sensorType domain.SensorType, deviceID dom
 	key := string(sensorType) + "_" + string(deviceID)
 	return m.sensorErrors[key]
 }
+
+type deleteReadingsCall struct {
+	scope  domain.RetentionScope
+	cutoff time.Time
+}
+
+type MockRetentionRepository struct {
+	partitions  []domain.ReadingsPartition
+	created     []domain.ReadingsPartition
+	dropped     []domain.ReadingsPartition
+	deletes     []deleteReadingsCall
+	deleteCount int64
+	listErr     error
+	createErr   error
+	deleteErr   error
+}
+
+func NewMockRetentionRepository() *MockRetentionRepository {
+	return &MockRetentionRepository{}
+}
+
+func (m *MockRetentionRepository) ListPartitions() ([]domain.ReadingsPartition, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	return append([]domain.ReadingsPartition{}, m.partitions...), nil
+}
+
+func (m *MockRetentionRepository) CreatePartition(partition domain.ReadingsPartition) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.created = append(m.created, partition)
+	m.partitions = append(m.partitions, partition)
+	return nil
+}
+
+func (m *MockRetentionRepository) DropPartition(partition domain.ReadingsPartition) error {
+	m.dropped = append(m.dropped, partition)
+	return nil
+}
+
+func (m *MockRetentionRepository) DeleteReadingsBefore(scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	if m.deleteErr != nil {
+		return 0, m.deleteErr
+	}
+	m.deletes = append(m.deletes, deleteReadingsCall{scope: scope, cutoff: cutoff})
+	return m.deleteCount, nil
+}
+
+type mockSubscription struct {
+	match    func(reading domain.SensorReading) bool
+	readings chan domain.SensorReading
+}
+
+type MockReadingFeed struct {
+	subscriptions []*mockSubscription
+}
+
+func NewMockReadingFeed() *MockReadingFeed {
+	return &MockReadingFeed{}
+}
+
+func (m *MockReadingFeed) Subscribe(match func(reading domain.SensorReading) bool, buffer int) (<-chan domain.SensorReading, func()) {
+	subscription := &mockSubscription{match: match, readings: make(chan domain.SensorReading, buffer)}
+	m.subscriptions = append(m.subscriptions, subscription)
+
+	return subscription.readings, func() {
+		for i, s := range m.subscriptions {
+			if s == subscription {
+				m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+				close(s.readings)
+				return
+			}
+		}
+	}
+}
+
+// Deliver hands the reading to the subscriptions it matches.
+func (m *MockReadingFeed) Deliver(reading domain.SensorReading) {
+	for _, subscription := range m.subscriptions {
+		if subscription.match(reading) {
+			subscription.readings <- reading
+		}
+	}
+}
+
+type MockPresenceMetrics struct {
+	counts map[domain.PresenceStatus]int
+}
+
+func NewMockPresenceMetrics() *MockPresenceMetrics {
+	return &MockPresenceMetrics{
+		counts: make(map[domain.PresenceStatus]int),
+	}
+}
+
+func (m *MockPresenceMetrics) SetDevicesByPresence(status domain.PresenceStatus, count int) {
+	m.counts[status] = count
+}
+
+type MockAuditMetrics struct {
+	failures int
+	backlog  int
+	dropped  int
+}
+
+func (m *MockAuditMetrics) IncAuditFailures() {
+	m.failures++
+}
+
+func (m *MockAuditMetrics) SetAuditBacklog(entries int) {
+	m.backlog = entries
+}
+
+func (m *MockAuditMetrics) IncAuditDropped() {
+	m.dropped++
+}
+
+// FaultyTwinRepository rejects the next conflicts saves as made against a
+// stale twin, as if another writer got there first.
+type FaultyTwinRepository struct {
+	domain.TwinRepository
+	conflicts int
+}
+
+func NewFaultyTwinRepository() *FaultyTwinRepository {
+	return &FaultyTwinRepository{TwinRepository: persistence.NewInMemoryTwinRepository()}
+}
+
+func (r *FaultyTwinRepository) Save(twin *domain.DeviceTwin, previousVersion int64) error {
+	if r.conflicts > 0 {
+		r.conflicts--
+		return domain.ErrTwinVersionConflict
+	}
+	return r.TwinRepository.Save(twin, previousVersion)
+}
+
+type MockCommandChannel struct {
+	dispatched  []domain.CommandID
+	dispatchErr error
+}
+
+func (m *MockCommandChannel) Dispatch(command *domain.Command) error {
+	if m.dispatchErr != nil {
+		return m.dispatchErr
+	}
+	m.dispatched = append(m.dispatched, command.ID)
+	return nil
+}
+
+type MockArtifactStore struct {
+	artifacts map[domain.FirmwareID][]byte
+}
+
+func NewMockArtifactStore() *MockArtifactStore {
+	return &MockArtifactStore{
+		artifacts: make(map[domain.FirmwareID][]byte),
+	}
+}
+
+func (m *MockArtifactStore) Put(id domain.FirmwareID, content io.Reader) (int64, string, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, "", err
+	}
+	m.artifacts[id] = data
+	sum := sha256.Sum256(data)
+	return int64(len(data)), hex.EncodeToString(sum[:]), nil
+}
+
+func (m *MockArtifactStore) Open(id domain.FirmwareID) (io.ReadCloser, error) {
+	data, ok := m.artifacts[id]
+	if !ok {
+		return nil, domain.ErrArtifactNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MockArtifactStore) Delete(id domain.FirmwareID) error {
+	delete(m.artifacts, id)
+	return nil
+}
+
+type MockCommandSender struct {
+	sent    []domain.DeviceID
+	sendErr map[domain.DeviceID]error
+}
+
+func NewMockCommandSender() *MockCommandSender {
+	return &MockCommandSender{
+		sendErr: make(map[domain.DeviceID]error),
+	}
+}
+
+func (m *MockCommandSender) CreateCommand(id domain.CommandID, deviceID domain.DeviceID, name string, params map[string]interface{}, ttl time.Duration) (*domain.Command, error) {
+	if err := m.sendErr[deviceID]; err != nil {
+		return nil, err
+	}
+	m.sent = append(m.sent, deviceID)
+	return domain.NewCommand(id, deviceID, name, params, ttl, time.Now())
+}
+
+type MockCertificateAuthority struct {
+	issued int
+}
+
+func NewMockCertificateAuthority() *MockCertificateAuthority {
+	return &MockCertificateAuthority{}
+}
+
+func (m *MockCertificateAuthority) Issue(deviceID domain.DeviceID, now time.Time) (*domain.IssuedCertificate, error) {
+	m.issued++
+	fingerprint := sha256.Sum256([]byte(string(deviceID) + string(rune('0'+m.issued))))
+	return &domain.IssuedCertificate{
+		CertificatePEM: "certificate of " + string(deviceID),
+		PrivateKeyPEM:  "key of " + string(deviceID),
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		NotAfter:       now.Add(24 * time.Hour),
+	}, nil
+}
+
+func (m *MockCertificateAuthority) CertificatePEM() string {
+	return "ca certificate"
+}
+
+type MockTokenVerifier struct {
+	principals map[string]domain.Principal
+}
+
+func (m *MockTokenVerifier) Verify(token string, now time.Time) (domain.Principal, error) {
+	principal, ok := m.principals[token]
+	if !ok {
+		return domain.Principal{}, domain.ErrAuthenticationFailed
+	}
+	return principal, nil
+}
+
+// FaultyAuditRepository fails Append with appendErr.
+type FaultyAuditRepository struct {
+	domain.AuditRepository
+	appendErr error
+}
+
+func NewFaultyAuditRepository() *FaultyAuditRepository {
+	return &FaultyAuditRepository{AuditRepository: persistence.NewInMemoryAuditRepository()}
+}
+
+func (r *FaultyAuditRepository) Append(entry *domain.AuditEntry) error {
+	if r.appendErr != nil {
+		return r.appendErr
+	}
+	return r.AuditRepository.Append(entry)
+}
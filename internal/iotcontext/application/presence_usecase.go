@@ -0,0 +1,184 @@
+package application
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	domain_metrics "github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/domain"
+	"log"
+	"sync"
+	"time"
+)
+
+// PresenceUseCase derives online/stale/offline status from last-seen times
+// and publishes device.online and device.offline when a device crosses
+// between being reachable and not.
+type PresenceUseCase struct {
+	deviceRepo     domain.DeviceRepository
+	presenceRepo   domain.PresenceRepository
+	policy         domain.PresencePolicy
+	metrics        domain_metrics.PresenceMetrics
+	eventPublisher domain.EventPublisher
+
+	mu       *sync.Mutex
+	statuses map[domain.DeviceID]domain.PresenceStatus
+}
+
+func NewPresenceUseCase(
+	deviceRepo domain.DeviceRepository,
+	presenceRepo domain.PresenceRepository,
+	policy domain.PresencePolicy,
+	metrics domain_metrics.PresenceMetrics,
+	publisher domain.EventPublisher,
+) *PresenceUseCase {
+	return &PresenceUseCase{
+		deviceRepo:     deviceRepo,
+		presenceRepo:   presenceRepo,
+		policy:         policy,
+		metrics:        metrics,
+		eventPublisher: publisher,
+		mu:             &sync.Mutex{},
+		statuses:       make(map[domain.DeviceID]domain.PresenceStatus),
+	}
+}
+
+// ForTenant returns the use case limited to the devices of the scope's
+// tenant. The last known statuses stay shared with uc.
+func (uc *PresenceUseCase) ForTenant(scope TenantScope) *PresenceUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+
+	return &scoped
+}
+
+func (uc *PresenceUseCase) Heartbeat(id domain.DeviceID, now time.Time) (domain.DevicePresence, error) {
+	device, err := uc.deviceRepo.FindByID(id)
+	if err != nil {
+		return domain.DevicePresence{}, err
+	}
+	if device.ID == "" {
+		return domain.DevicePresence{}, domain.ErrDeviceNotFound
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return domain.DevicePresence{}, domain.ErrDeviceDecommissioned
+	}
+
+	if err := uc.presenceRepo.Touch(id, now); err != nil {
+		return domain.DevicePresence{}, err
+	}
+
+	presence := uc.presenceOf(device, now)
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	return presence, uc.transition(device, presence)
+}
+
+func (uc *PresenceUseCase) GetPresence(device domain.Device, now time.Time) domain.DevicePresence {
+	return uc.presenceOf(device, now)
+}
+
+// Evaluate recomputes the status of every device, publishing transitions and
+// refreshing the per status gauge. Decommissioned devices are left out.
+func (uc *PresenceUseCase) Evaluate(now time.Time) error {
+	devices, err := uc.deviceRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	counts := make(map[domain.PresenceStatus]int, len(domain.PresenceStatuses))
+	for _, device := range devices {
+		if device.Status == domain.DeviceDecommissioned {
+			delete(uc.statuses, device.ID)
+			continue
+		}
+
+		presence := uc.presenceOf(device, now)
+		counts[presence.Status]++
+
+		if err := uc.transition(device, presence); err != nil {
+			return err
+		}
+	}
+
+	if uc.metrics != nil {
+		for _, status := range domain.PresenceStatuses {
+			uc.metrics.SetDevicesByPresence(status, counts[status])
+		}
+	}
+
+	return nil
+}
+
+func (uc *PresenceUseCase) Start(every time.Duration) func() {
+	stopCh := make(chan struct{})
+	ticker := time.NewTicker(every)
+
+	run := func() {
+		if err := uc.Evaluate(time.Now().UTC()); err != nil {
+			log.Printf("device presence job failed: %v", err)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+	}
+}
+
+func (uc *PresenceUseCase) presenceOf(device domain.Device, now time.Time) domain.DevicePresence {
+	lastSeen, err := uc.presenceRepo.LastSeen(device.ID)
+	if err != nil || lastSeen.IsZero() {
+		return domain.DevicePresence{Status: domain.PresenceOffline}
+	}
+
+	return domain.DevicePresence{
+		Status:     uc.policy.Status(device.Type, lastSeen, now),
+		LastSeenAt: &lastSeen,
+	}
+}
+
+// transition must be called with uc.mu held. Devices never evaluated before
+// count as offline, so a restart does not flood the bus with offline events.
+// The events go to the tenant of the device.
+func (uc *PresenceUseCase) transition(device domain.Device, presence domain.DevicePresence) error {
+	id := device.ID
+	previous, ok := uc.statuses[id]
+	if !ok {
+		previous = domain.PresenceOffline
+	}
+	uc.statuses[id] = presence.Status
+
+	switch {
+	case presence.Status == domain.PresenceOnline && previous != domain.PresenceOnline:
+		event := &domain.DeviceOnlineEvent{DeviceID: id, LastSeenAt: *presence.LastSeenAt}
+		return uc.eventPublisher.Publish(event.ToDomainEvent().WithTenant(device.TenantID))
+	case presence.Status == domain.PresenceOffline && previous != domain.PresenceOffline:
+		event := &domain.DeviceOfflineEvent{DeviceID: id}
+		if presence.LastSeenAt != nil {
+			event.LastSeenAt = *presence.LastSeenAt
+		}
+		return uc.eventPublisher.Publish(event.ToDomainEvent().WithTenant(device.TenantID))
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"testing"
+	"time"
+)
+
+func eventTypes(events []domain.IoTEvent) []string {
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+
+	return types
+}
+
+func TestPresenceUseCase_Transitions(t *testing.T) {
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	presenceRepo := persistence.NewInMemoryPresenceRepository()
+	metrics := NewMockPresenceMetrics()
+	publisher := NewMockEventPublisher()
+
+	gateway, _ := domain.NewDevice("gateway-1", "Gateway", "gateway")
+	deviceRepo.Save(gateway)
+	meter, _ := domain.NewDevice("meter-1", "Meter", "meter")
+	deviceRepo.Save(meter)
+
+	policy := domain.DefaultPresencePolicy()
+	policy.ByType["gateway"] = domain.PresenceTimeout{Stale: 10 * time.Second, Offline: 30 * time.Second}
+
+	useCase := NewPresenceUseCase(deviceRepo, presenceRepo, policy, metrics, publisher)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := useCase.Evaluate(start); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(publisher.GetEvents()) != 0 {
+		t.Errorf("expected unseen devices not to publish events, got %v", eventTypes(publisher.GetEvents()))
+	}
+	if metrics.counts[domain.PresenceOffline] != 2 {
+		t.Errorf("expected 2 offline devices, got %d", metrics.counts[domain.PresenceOffline])
+	}
+
+	presence, err := useCase.Heartbeat(gateway.ID, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if presence.Status != domain.PresenceOnline {
+		t.Errorf("expected online after a heartbeat, got %s", presence.Status)
+	}
+
+	steps := []struct {
+		after    time.Duration
+		expected []string
+	}{
+		{after: 5 * time.Second, expected: []string{"device.online"}},
+		{after: 15 * time.Second, expected: []string{"device.online"}},
+		{after: 45 * time.Second, expected: []string{"device.online", "device.offline"}},
+		{after: time.Minute, expected: []string{"device.online", "device.offline"}},
+	}
+
+	for _, step := range steps {
+		if err := useCase.Evaluate(start.Add(step.after)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := eventTypes(publisher.GetEvents()); len(got) != len(step.expected) {
+			t.Errorf("after %s expected %v, got %v", step.after, step.expected, got)
+		}
+	}
+
+	presenceRepo.Touch(meter.ID, start.Add(time.Minute))
+	if err := useCase.Evaluate(start.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := publisher.GetEvents()
+	last := events[len(events)-1]
+	if last.Type != "device.online" || last.Payload.(*domain.DeviceOnlineEvent).DeviceID != meter.ID {
+		t.Errorf("expected a reading to bring the meter online, got %+v", last)
+	}
+	if metrics.counts[domain.PresenceOnline] != 1 || metrics.counts[domain.PresenceOffline] != 1 {
+		t.Errorf("unexpected gauge values %v", metrics.counts)
+	}
+}
+
+func TestPresenceUseCase_Heartbeat(t *testing.T) {
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	decommissioned, _ := domain.NewDevice("device-1", "Old", "gateway")
+	decommissioned.Status = domain.DeviceDecommissioned
+	deviceRepo.Save(decommissioned)
+	acme, _ := domain.NewDevice("device-2", "Gateway", "gateway")
+	acme.TenantID = "acme"
+	deviceRepo.Save(acme)
+
+	useCase := NewPresenceUseCase(deviceRepo, persistence.NewInMemoryPresenceRepository(), domain.DefaultPresencePolicy(), nil, NewMockEventPublisher())
+	tenancy := NewTenancy(domain.TenantQuotas{}, nil, nil)
+
+	tests := []struct {
+		name        string
+		tenant      domain.TenantID
+		id          domain.DeviceID
+		expectError error
+	}{
+		{name: "unknown device", id: "device-404", expectError: domain.ErrDeviceNotFound},
+		{name: "decommissioned device", id: "device-1", expectError: domain.ErrDeviceDecommissioned},
+		{name: "device of the tenant", tenant: "acme", id: "device-2"},
+		{name: "device of another tenant", tenant: "globex", id: "device-2", expectError: domain.ErrDeviceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoped := useCase
+			if tt.tenant != "" {
+				scoped = useCase.ForTenant(tenancy.Scope(tt.tenant))
+			}
+
+			if _, err := scoped.Heartbeat(tt.id, time.Now()); !errors.Is(err, tt.expectError) {
+				t.Errorf("expected %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
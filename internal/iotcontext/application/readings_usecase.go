@@ -1,15 +1,136 @@
 package application
 
-import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+// watchBuffer is how many readings a watcher may fall behind before it
+// misses some.
+const watchBuffer = 256
 
 type ReadingsUsecase struct {
-	readingsRepo domain.SensorReadingRepository
+	readingsRepo   domain.SensorReadingRepository
+	rollupRepo     domain.ReadingRollupRepository
+	sensorRepo     domain.SensorRepository
+	deviceRepo     domain.DeviceRepository
+	feed           domain.ReadingFeed
+	eventPublisher domain.EventPublisher
+	scope          TenantScope
 }
 
-func NewReadingsUsecase(readingsRepo domain.SensorReadingRepository) *ReadingsUsecase {
+func NewReadingsUsecase(
+	readingsRepo domain.SensorReadingRepository,
+	rollupRepo domain.ReadingRollupRepository,
+	sensorRepo domain.SensorRepository,
+	deviceRepo domain.DeviceRepository,
+	feed domain.ReadingFeed,
+	publisher domain.EventPublisher,
+) *ReadingsUsecase {
 	return &ReadingsUsecase{
-		readingsRepo: readingsRepo,
+		readingsRepo:   readingsRepo,
+		rollupRepo:     rollupRepo,
+		sensorRepo:     sensorRepo,
+		deviceRepo:     deviceRepo,
+		feed:           feed,
+		eventPublisher: publisher,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the sensors and
+// readings of the tenant and enforces its ingestion rate. The zero scope
+// returns uc itself.
+func (uc *ReadingsUsecase) ForTenant(scope TenantScope) *ReadingsUsecase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.readingsRepo = scopeReadings(uc.readingsRepo, scope.Tenant)
+	scoped.sensorRepo = scopeSensors(uc.sensorRepo, scope.Tenant)
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+// IngestReading stores a reading sent by a device. deviceID is the device the
+// request authenticated as and must own the sensor; it is empty only when
+// device authentication is turned off. Readings of a disabled sensor or of a
+// decommissioned device are refused.
+func (uc *ReadingsUsecase) IngestReading(deviceID domain.DeviceID, sensorID domain.SensorID, value float64, ts time.Time, now time.Time) (*domain.SensorReading, error) {
+	sensor, err := uc.sensorRepo.FindByID(sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deviceID != "" && sensor.DeviceID != deviceID {
+		return nil, domain.ErrDeviceNotAuthorized
+	}
+
+	device, err := uc.deviceRepo.FindByID(sensor.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+	if !sensor.Config.Enabled {
+		return nil, domain.ErrSensorDisabled
+	}
+
+	if err := uc.scope.AllowReading(now); err != nil {
+		return nil, err
+	}
+
+	if ts.IsZero() {
+		ts = now
+	}
+
+	reading := domain.NewSensorReading(sensor.ID, sensor.DeviceID, sensor.Type, value, string(sensor.Type), ts)
+	reading.TenantID = sensor.TenantID
+	if err := uc.readingsRepo.Save(&reading); err != nil {
+		return nil, err
+	}
+
+	event := &domain.SensorReadingPublishedEvent{
+		SensorID: reading.SensorID,
+		Reading:  reading.ID,
+	}
+
+	return &reading, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// WatchReadings delivers the readings of the sensors stored from now on until
+// stop is called. Without sensors it delivers those of every sensor of the
+// tenant; a sensor the tenant does not own is ErrSensorNotFound.
+func (uc *ReadingsUsecase) WatchReadings(sensorIDs []domain.SensorID) (readings <-chan domain.SensorReading, stop func(), err error) {
+	watched := make(map[domain.SensorID]bool, len(sensorIDs))
+	for _, id := range sensorIDs {
+		if _, err := uc.sensorRepo.FindByID(id); err != nil {
+			return nil, nil, err
+		}
+		watched[id] = true
 	}
+
+	tenant := uc.scope.Tenant
+	readings, stop = uc.feed.Subscribe(func(reading domain.SensorReading) bool {
+		if tenant != "" && reading.TenantID != tenant {
+			return false
+		}
+
+		return len(watched) == 0 || watched[reading.SensorID]
+	}, watchBuffer)
+
+	return readings, stop, nil
+}
+
+// GetLatestReadings returns the latest reading of each of the sensors in a
+// single lookup. Sensors without readings, or of another tenant, are left
+// out.
+func (uc *ReadingsUsecase) GetLatestReadings(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	return uc.readingsRepo.FindLatestBySensorIDs(sensorIDs)
 }
 
 func (uc *ReadingsUsecase) GetPaginatedReadings(id domain.SensorID, from int, to int, limit int) ([]domain.SensorReading, error) {
@@ -32,3 +153,56 @@ func (uc *ReadingsUsecase) GetPaginatedReadings(id domain.SensorID, from int, to
 
 	return readings[from:to], nil
 }
+
+func (uc *ReadingsUsecase) GetReadingSeries(id domain.SensorID, from time.Time, to time.Time, maxPoints int) (*domain.ReadingSeries, error) {
+	if maxPoints <= 0 || !to.After(from) {
+		return nil, domain.ErrInvalidTimeRange
+	}
+
+	sensor, err := uc.sensorRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sampling := time.Duration(sensor.Config.SamplingRateMs) * time.Millisecond
+	resolution := domain.SelectResolution(from, to, maxPoints, sampling)
+
+	series := &domain.ReadingSeries{
+		SensorID:   id,
+		Resolution: resolution,
+		From:       from.UTC(),
+		To:         to.UTC(),
+		Points:     []domain.ReadingPoint{},
+	}
+
+	if resolution == domain.ResolutionRaw {
+		readings, err := uc.readingsRepo.FindBySensorIDBetween(id, from, to, maxPoints+1)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(readings) <= maxPoints {
+			for _, reading := range readings {
+				series.Points = append(series.Points, domain.NewReadingAggregate(reading, domain.ResolutionRaw).Point())
+			}
+
+			return series, nil
+		}
+
+		// The sampling rate underestimated the amount of raw data, use the
+		// finest rollup instead of silently truncating the range.
+		resolution = domain.SelectResolution(from, to, maxPoints, 0)
+		series.Resolution = resolution
+	}
+
+	aggregates, err := uc.rollupRepo.FindAggregates(id, resolution, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, aggregate := range aggregates {
+		series.Points = append(series.Points, aggregate.Point())
+	}
+
+	return series, nil
+}
@@ -3,7 +3,10 @@ package application
 import (
 	"errors"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestReadingsUsecase_GetPaginatedReadings(t *testing.T) {
@@ -111,7 +114,7 @@ func TestReadingsUsecase_GetPaginatedReadings(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockSensorReadingRepository()
+			mockRepo := NewFaultySensorReadingRepository()
 			mockRepo.findErr = tt.repoFindErr
 
 			if tt.repoFindErr == nil {
@@ -128,7 +131,7 @@ func TestReadingsUsecase_GetPaginatedReadings(t *testing.T) {
 				}
 			}
 
-			useCase := NewReadingsUsecase(mockRepo)
+			useCase := NewReadingsUsecase(mockRepo, persistence.NewInMemoryReadingRollupRepository(), persistence.NewInMemorySensorRepository(), persistence.NewInMemoryDeviceRepository(), NewMockReadingFeed(), NewMockEventPublisher())
 
 			readings, err := useCase.GetPaginatedReadings(tt.sensorID, tt.from, tt.to, tt.limit)
 
@@ -150,3 +153,250 @@ func TestReadingsUsecase_GetPaginatedReadings(t *testing.T) {
 		})
 	}
 }
+
+func TestReadingsUsecase_GetReadingSeries(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		sensorID           domain.SensorID
+		from               time.Time
+		to                 time.Time
+		maxPoints          int
+		rawReadings        int
+		expectError        error
+		expectedResolution domain.Resolution
+		expectedPoints     int
+	}{
+		{
+			name:               "short range served from raw readings",
+			sensorID:           "sensor-123",
+			from:               start,
+			to:                 start.Add(time.Minute),
+			maxPoints:          100,
+			rawReadings:        5,
+			expectedResolution: domain.ResolutionRaw,
+			expectedPoints:     5,
+		},
+		{
+			name:               "raw budget exceeded falls back to minute rollups",
+			sensorID:           "sensor-123",
+			from:               start,
+			to:                 start.Add(time.Minute),
+			maxPoints:          60,
+			rawReadings:        61,
+			expectedResolution: domain.ResolutionMinute,
+			expectedPoints:     1,
+		},
+		{
+			name:               "week range uses hourly rollups",
+			sensorID:           "sensor-123",
+			from:               start,
+			to:                 start.Add(7 * 24 * time.Hour),
+			maxPoints:          500,
+			expectedResolution: domain.ResolutionHour,
+			expectedPoints:     2,
+		},
+		{
+			name:               "year range uses daily rollups",
+			sensorID:           "sensor-123",
+			from:               start,
+			to:                 start.AddDate(1, 0, 0),
+			maxPoints:          500,
+			expectedResolution: domain.ResolutionDay,
+			expectedPoints:     1,
+		},
+		{
+			name:        "invalid range",
+			sensorID:    "sensor-123",
+			from:        start,
+			to:          start,
+			maxPoints:   10,
+			expectError: domain.ErrInvalidTimeRange,
+		},
+		{
+			name:        "unknown sensor",
+			sensorID:    "nonexistent",
+			from:        start,
+			to:          start.Add(time.Hour),
+			maxPoints:   10,
+			expectError: domain.ErrSensorNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensorRepo := persistence.NewInMemorySensorRepository()
+			config, _ := domain.NewSensorConfig("sensor-123", 1000, domain.Thresholds{}, 0, true)
+			sensor, _ := domain.NewSensor("sensor-123", "device-123", "Test Sensor", domain.Temperature, config)
+			sensorRepo.Save(sensor)
+
+			readingsRepo := persistence.NewInMemorySensorReadingRepository()
+			for i := 0; i < tt.rawReadings; i++ {
+				reading := domain.NewSensorReading("sensor-123", "device-123", domain.Temperature, float64(i), "°C", start.Add(time.Duration(i)*time.Millisecond))
+				readingsRepo.Save(&reading)
+			}
+
+			rollupRepo := persistence.NewInMemoryReadingRollupRepository()
+			rollupRepo.Apply(domain.NewSensorReading("sensor-123", "device-123", domain.Temperature, 21, "°C", start))
+			rollupRepo.Apply(domain.NewSensorReading("sensor-123", "device-123", domain.Temperature, 22, "°C", start.Add(time.Hour)))
+
+			useCase := NewReadingsUsecase(readingsRepo, rollupRepo, sensorRepo, persistence.NewInMemoryDeviceRepository(), NewMockReadingFeed(), NewMockEventPublisher())
+
+			series, err := useCase.GetReadingSeries(tt.sensorID, tt.from, tt.to, tt.maxPoints)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected error %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if series.Resolution != tt.expectedResolution {
+				t.Errorf("expected resolution %s, got %s", tt.expectedResolution, series.Resolution)
+			}
+
+			if len(series.Points) != tt.expectedPoints {
+				t.Errorf("expected %d points, got %d", tt.expectedPoints, len(series.Points))
+			}
+		})
+	}
+}
+
+func TestReadingsUsecase_IngestReading(t *testing.T) {
+	tests := []struct {
+		name        string
+		deviceID    domain.DeviceID
+		sensorID    domain.SensorID
+		expectError error
+	}{
+		{name: "owner device", deviceID: "device-123", sensorID: "sensor-123"},
+		{name: "authentication disabled", deviceID: "", sensorID: "sensor-123"},
+		{name: "another device", deviceID: "device-456", sensorID: "sensor-123", expectError: domain.ErrDeviceNotAuthorized},
+		{name: "unknown sensor", deviceID: "device-123", sensorID: "ghost", expectError: domain.ErrSensorNotFound},
+		{name: "disabled sensor", deviceID: "device-123", sensorID: "sensor-456", expectError: domain.ErrSensorDisabled},
+		{name: "decommissioned device", deviceID: "device-789", sensorID: "sensor-789", expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceRepo := persistence.NewInMemoryDeviceRepository()
+			device, _ := domain.NewDevice("device-123", "Gateway", "gateway")
+			deviceRepo.Save(device)
+			decommissioned, _ := domain.NewDevice("device-789", "Old", "gateway")
+			decommissioned.Status = domain.DeviceDecommissioned
+			deviceRepo.Save(decommissioned)
+
+			sensorRepo := persistence.NewInMemorySensorRepository()
+			sensor, _ := domain.NewSensor("sensor-123", "device-123", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+			sensorRepo.Save(sensor)
+			disabled, _ := domain.NewSensor("sensor-456", "device-123", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000})
+			sensorRepo.Save(disabled)
+			retired, _ := domain.NewSensor("sensor-789", "device-789", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+			sensorRepo.Save(retired)
+
+			readingsRepo := persistence.NewInMemorySensorReadingRepository()
+			publisher := NewMockEventPublisher()
+			useCase := NewReadingsUsecase(readingsRepo, persistence.NewInMemoryReadingRollupRepository(), sensorRepo, deviceRepo, NewMockReadingFeed(), publisher)
+
+			now := time.Now()
+			reading, err := useCase.IngestReading(tt.deviceID, tt.sensorID, 21.5, time.Time{}, now)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected error %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if reading.DeviceID != "device-123" || reading.Value != 21.5 || !reading.Timestamp.Equal(now) {
+				t.Errorf("unexpected reading %+v", reading)
+			}
+
+			stored, _ := readingsRepo.FindBySensorID("sensor-123", 10)
+			if len(stored) != 1 {
+				t.Errorf("expected 1 stored reading, got %d", len(stored))
+			}
+
+			if len(publisher.events) != 1 || publisher.events[0].Type != "sensor.reading.published" {
+				t.Errorf("expected a sensor.reading.published event, got %+v", publisher.events)
+			}
+		})
+	}
+}
+
+func TestReadingsUsecase_WatchReadings(t *testing.T) {
+	tests := []struct {
+		name        string
+		tenant      domain.TenantID
+		sensorIDs   []domain.SensorID
+		expected    []domain.SensorID
+		expectError error
+	}{
+		{name: "one sensor", sensorIDs: []domain.SensorID{"sensor-1"}, expected: []domain.SensorID{"sensor-1"}},
+		{name: "every sensor", expected: []domain.SensorID{"sensor-1", "sensor-2", "sensor-3"}},
+		{name: "every sensor of the tenant", tenant: "acme", expected: []domain.SensorID{"sensor-3"}},
+		{name: "sensor of another tenant", tenant: "acme", sensorIDs: []domain.SensorID{"sensor-1"}, expectError: domain.ErrSensorNotFound},
+		{name: "unknown sensor", sensorIDs: []domain.SensorID{"ghost"}, expectError: domain.ErrSensorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensorRepo := persistence.NewInMemorySensorRepository()
+			var readings []domain.SensorReading
+			for _, s := range []struct {
+				id     domain.SensorID
+				tenant domain.TenantID
+			}{{"sensor-1", domain.DefaultTenant}, {"sensor-2", domain.DefaultTenant}, {"sensor-3", "acme"}} {
+				sensor, _ := domain.NewSensor(s.id, "device-1", "Temperature", domain.Temperature, domain.SensorConfig{})
+				sensor.TenantID = s.tenant
+				sensorRepo.Save(sensor)
+
+				reading := domain.NewSensorReading(s.id, "device-1", domain.Temperature, 21.5, "celsius", time.Now())
+				reading.TenantID = s.tenant
+				readings = append(readings, reading)
+			}
+
+			feed := NewMockReadingFeed()
+			useCase := NewReadingsUsecase(persistence.NewInMemorySensorReadingRepository(), persistence.NewInMemoryReadingRollupRepository(), sensorRepo, persistence.NewInMemoryDeviceRepository(), feed, NewMockEventPublisher())
+			if tt.tenant != "" {
+				useCase = useCase.ForTenant(NewTenancy(domain.TenantQuotas{}, nil, nil).Scope(tt.tenant))
+			}
+
+			watched, stop, err := useCase.WatchReadings(tt.sensorIDs)
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected error %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, reading := range readings {
+				feed.Deliver(reading)
+			}
+			stop()
+
+			var got []domain.SensorID
+			for reading := range watched {
+				got = append(got, reading.SensorID)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected readings of %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
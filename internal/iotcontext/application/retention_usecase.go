@@ -0,0 +1,196 @@
+package application
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"log"
+	"time"
+)
+
+type RetentionUseCase struct {
+	retentionRepo   domain.SensorReadingRetentionRepository
+	interval        domain.PartitionInterval
+	partitionsAhead int
+	policies        []domain.RetentionPolicy
+}
+
+func NewRetentionUseCase(
+	retentionRepo domain.SensorReadingRetentionRepository,
+	interval domain.PartitionInterval,
+	partitionsAhead int,
+	policies []domain.RetentionPolicy,
+) *RetentionUseCase {
+	if partitionsAhead < 0 {
+		partitionsAhead = 0
+	}
+
+	return &RetentionUseCase{
+		retentionRepo:   retentionRepo,
+		interval:        interval,
+		partitionsAhead: partitionsAhead,
+		policies:        policies,
+	}
+}
+
+func (uc *RetentionUseCase) Policies() []domain.RetentionPolicy {
+	return uc.policies
+}
+
+func (uc *RetentionUseCase) EnsurePartitions(now time.Time) (int, error) {
+	existing, err := uc.retentionRepo.ListPartitions()
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	start := uc.interval.Truncate(now)
+	for i := 0; i <= uc.partitionsAhead; i++ {
+		candidate := domain.ReadingsPartition{From: start, To: uc.interval.Next(start)}
+		start = candidate.To
+
+		if overlapsAny(candidate, existing) {
+			continue
+		}
+
+		if err := uc.retentionRepo.CreatePartition(candidate); err != nil {
+			return created, err
+		}
+
+		existing = append(existing, candidate)
+		created++
+	}
+
+	return created, nil
+}
+
+func (uc *RetentionUseCase) ApplyRetention(now time.Time) (int64, int, error) {
+	var deleted int64
+	var typed []domain.SensorType
+	var devices []domain.DeviceID
+	var fallback *domain.RetentionPolicy
+
+	for i, policy := range uc.policies {
+		switch {
+		case policy.DeviceID != "":
+			devices = append(devices, policy.DeviceID)
+		case policy.SensorType != "":
+			typed = append(typed, policy.SensorType)
+		default:
+			fallback = &uc.policies[i]
+		}
+	}
+
+	for _, policy := range uc.policies {
+		var scope domain.RetentionScope
+		switch {
+		case policy.DeviceID != "":
+			scope = domain.RetentionScope{DeviceID: policy.DeviceID}
+		case policy.SensorType != "":
+			scope = domain.RetentionScope{SensorType: policy.SensorType, ExcludeDevices: devices}
+		default:
+			continue
+		}
+
+		n, err := uc.retentionRepo.DeleteReadingsBefore(scope, policy.Cutoff(now))
+		if err != nil {
+			return deleted, 0, err
+		}
+		deleted += n
+	}
+
+	if fallback == nil {
+		return deleted, 0, nil
+	}
+
+	n, err := uc.retentionRepo.DeleteReadingsBefore(
+		domain.RetentionScope{ExcludeTypes: typed, ExcludeDevices: devices},
+		fallback.Cutoff(now),
+	)
+	if err != nil {
+		return deleted, 0, err
+	}
+	deleted += n
+
+	dropped, err := uc.dropExpiredPartitions(now)
+
+	return deleted, dropped, err
+}
+
+// dropExpiredPartitions only removes partitions that no policy still needs,
+// so the cutoff is driven by the longest configured max age.
+func (uc *RetentionUseCase) dropExpiredPartitions(now time.Time) (int, error) {
+	longest := uc.policies[0]
+	for _, policy := range uc.policies[1:] {
+		if policy.MaxAge > longest.MaxAge {
+			longest = policy
+		}
+	}
+	cutoff := longest.Cutoff(now)
+
+	partitions, err := uc.retentionRepo.ListPartitions()
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			continue
+		}
+
+		if err := uc.retentionRepo.DropPartition(partition); err != nil {
+			return dropped, err
+		}
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+func (uc *RetentionUseCase) Run(now time.Time) error {
+	if _, err := uc.EnsurePartitions(now); err != nil {
+		return err
+	}
+
+	_, _, err := uc.ApplyRetention(now)
+
+	return err
+}
+
+func (uc *RetentionUseCase) Start(every time.Duration) func() {
+	stopCh := make(chan struct{})
+	ticker := time.NewTicker(every)
+
+	run := func() {
+		if err := uc.Run(time.Now().UTC()); err != nil {
+			log.Printf("readings retention job failed: %v", err)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+	}
+}
+
+func overlapsAny(candidate domain.ReadingsPartition, partitions []domain.ReadingsPartition) bool {
+	for _, partition := range partitions {
+		if candidate.Overlaps(partition) {
+			return true
+		}
+	}
+
+	return false
+}
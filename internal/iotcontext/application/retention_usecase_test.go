@@ -0,0 +1,198 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"testing"
+	"time"
+)
+
+func TestRetentionUseCase_EnsurePartitions(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		interval        domain.PartitionInterval
+		partitionsAhead int
+		existing        []domain.ReadingsPartition
+		listErr         error
+		expectError     bool
+		expectedFrom    []time.Time
+	}{
+		{
+			name:            "monthly partitions from scratch",
+			interval:        domain.PartitionMonthly,
+			partitionsAhead: 2,
+			expectedFrom: []time.Time{
+				time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:            "daily partitions skip existing ones",
+			interval:        domain.PartitionDaily,
+			partitionsAhead: 2,
+			existing: []domain.ReadingsPartition{
+				{
+					Name: "sensor_readings_models_p20260315",
+					From: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			expectedFrom: []time.Time{
+				time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:            "daily partitions covered by an existing monthly one",
+			interval:        domain.PartitionDaily,
+			partitionsAhead: 1,
+			existing: []domain.ReadingsPartition{
+				{
+					Name: "sensor_readings_models_p202603",
+					From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			expectedFrom: nil,
+		},
+		{
+			name:            "repository error",
+			interval:        domain.PartitionMonthly,
+			partitionsAhead: 1,
+			listErr:         errors.New("database error"),
+			expectError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRetentionRepository()
+			mockRepo.partitions = tt.existing
+			mockRepo.listErr = tt.listErr
+
+			useCase := NewRetentionUseCase(mockRepo, tt.interval, tt.partitionsAhead, nil)
+
+			created, err := useCase.EnsurePartitions(now)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if created != len(tt.expectedFrom) {
+				t.Fatalf("expected %d partitions, got %d", len(tt.expectedFrom), created)
+			}
+
+			for i, from := range tt.expectedFrom {
+				if !mockRepo.created[i].From.Equal(from) {
+					t.Errorf("expected partition %d to start at %v, got %v", i, from, mockRepo.created[i].From)
+				}
+			}
+		})
+	}
+}
+
+func TestRetentionUseCase_ApplyRetention(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	partitions := []domain.ReadingsPartition{
+		{
+			Name: "sensor_readings_models_p202512",
+			From: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Name: "sensor_readings_models_p202602",
+			From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	t.Run("scoped policies delete rows and never drop partitions", func(t *testing.T) {
+		mockRepo := NewMockRetentionRepository()
+		mockRepo.partitions = partitions
+
+		policies, _ := domain.ParseRetentionPolicies("temperature=24h,device:device-1=1h")
+		useCase := NewRetentionUseCase(mockRepo, domain.PartitionMonthly, 0, policies)
+
+		_, dropped, err := useCase.ApplyRetention(now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if dropped != 0 || len(mockRepo.dropped) != 0 {
+			t.Errorf("expected no dropped partitions, got %d", len(mockRepo.dropped))
+		}
+
+		if len(mockRepo.deletes) != 2 {
+			t.Fatalf("expected 2 delete calls, got %d", len(mockRepo.deletes))
+		}
+
+		typed := mockRepo.deletes[0]
+		if typed.scope.SensorType != domain.Temperature {
+			t.Errorf("expected temperature scope, got %q", typed.scope.SensorType)
+		}
+		if len(typed.scope.ExcludeDevices) != 1 || typed.scope.ExcludeDevices[0] != "device-1" {
+			t.Errorf("expected device-1 to be excluded from the type policy, got %v", typed.scope.ExcludeDevices)
+		}
+		if !typed.cutoff.Equal(now.Add(-24 * time.Hour)) {
+			t.Errorf("unexpected cutoff %v", typed.cutoff)
+		}
+
+		device := mockRepo.deletes[1]
+		if device.scope.DeviceID != "device-1" {
+			t.Errorf("expected device-1 scope, got %q", device.scope.DeviceID)
+		}
+	})
+
+	t.Run("default policy drops partitions older than the longest max age", func(t *testing.T) {
+		mockRepo := NewMockRetentionRepository()
+		mockRepo.partitions = partitions
+		mockRepo.deleteCount = 3
+
+		policies, _ := domain.ParseRetentionPolicies("*=7d,humidity=60d")
+		useCase := NewRetentionUseCase(mockRepo, domain.PartitionMonthly, 0, policies)
+
+		deleted, dropped, err := useCase.ApplyRetention(now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if deleted != 6 {
+			t.Errorf("expected 6 deleted readings, got %d", deleted)
+		}
+
+		if dropped != 1 || mockRepo.dropped[0].Name != "sensor_readings_models_p202512" {
+			t.Errorf("expected only the december partition to be dropped, got %v", mockRepo.dropped)
+		}
+
+		fallback := mockRepo.deletes[1]
+		if len(fallback.scope.ExcludeTypes) != 1 || fallback.scope.ExcludeTypes[0] != domain.Humidity {
+			t.Errorf("expected humidity to be excluded from the default policy, got %v", fallback.scope.ExcludeTypes)
+		}
+		if !fallback.cutoff.Equal(now.Add(-7 * 24 * time.Hour)) {
+			t.Errorf("unexpected cutoff %v", fallback.cutoff)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := NewMockRetentionRepository()
+		mockRepo.deleteErr = errors.New("database error")
+
+		policies, _ := domain.ParseRetentionPolicies("*=7d")
+		useCase := NewRetentionUseCase(mockRepo, domain.PartitionMonthly, 0, policies)
+
+		if _, _, err := useCase.ApplyRetention(now); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+}
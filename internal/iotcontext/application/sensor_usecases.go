@@ -1,30 +1,77 @@
 package application
 
 import (
+	"errors"
+	"fmt"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	domain_metrics "github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/domain"
 )
 
 type SensorUseCase struct {
 	sensorRepo     domain.SensorRepository
+	configHistory  domain.SensorConfigHistoryRepository
+	deviceRepo     domain.DeviceRepository
+	simulatorRepo  domain.SimulatorRepository
 	metrics        domain_metrics.Metrics
 	eventPublisher domain.EventPublisher
+	scope          TenantScope
+	// expectedVersion is the version changes are made against; 0 accepts
+	// any.
+	expectedVersion int64
 }
 
-func NewSensorUseCase(sensorRepo domain.SensorRepository, metrics domain_metrics.Metrics, publisher domain.EventPublisher) *SensorUseCase {
+func NewSensorUseCase(
+	sensorRepo domain.SensorRepository,
+	configHistory domain.SensorConfigHistoryRepository,
+	deviceRepo domain.DeviceRepository,
+	simulatorRepo domain.SimulatorRepository,
+	metrics domain_metrics.Metrics,
+	publisher domain.EventPublisher,
+) *SensorUseCase {
 	return &SensorUseCase{
 		sensorRepo:     sensorRepo,
+		configHistory:  configHistory,
+		deviceRepo:     deviceRepo,
+		simulatorRepo:  simulatorRepo,
 		metrics:        metrics,
 		eventPublisher: publisher,
 	}
 }
 
+// ForTenant returns a copy of the use case that only sees the sensors and
+// devices of the tenant and enforces its sensor quota. The zero scope
+// returns uc itself.
+func (uc *SensorUseCase) ForTenant(scope TenantScope) *SensorUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.sensorRepo = scopeSensors(uc.sensorRepo, scope.Tenant)
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+// ExpectVersion returns a copy of the use case whose changes fail with
+// ErrSensorVersionConflict unless the sensor is still at version, the one
+// the caller last read.
+func (uc *SensorUseCase) ExpectVersion(version int64) *SensorUseCase {
+	expecting := *uc
+	expecting.expectedVersion = version
+
+	return &expecting
+}
+
 func (uc *SensorUseCase) CreateSensor(
 	id domain.SensorID,
 	deviceID domain.DeviceID,
 	name string,
 	typ domain.SensorType,
 	config domain.SensorConfig,
+	labels domain.Labels,
 ) error {
 	sensor, err := domain.NewSensor(id, deviceID, name, typ, config)
 	if err != nil {
@@ -32,6 +79,34 @@ func (uc *SensorUseCase) CreateSensor(
 		return err
 	}
 
+	if err := sensor.Relabel(labels); err != nil {
+		return err
+	}
+
+	// The device has to exist and, within a tenant, be one of its own,
+	// otherwise a sensor could be hung from the device of another tenant.
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return err
+	}
+	if device.ID == "" {
+		return domain.ErrDeviceNotFound
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return domain.ErrDeviceDecommissioned
+	}
+
+	if uc.scope.Quota.MaxSensors > 0 {
+		sensors, err := uc.sensorRepo.FindAll()
+		if err != nil {
+			return err
+		}
+
+		if err := uc.scope.checkQuota(len(sensors), uc.scope.Quota.MaxSensors, "sensors"); err != nil {
+			return err
+		}
+	}
+
 	if err := uc.sensorRepo.Save(sensor); err != nil {
 		uc.metrics.IncSensorError(typ, deviceID)
 		return err
@@ -39,11 +114,23 @@ func (uc *SensorUseCase) CreateSensor(
 
 	uc.metrics.IncSensorReading(typ, deviceID)
 
+	revision, err := domain.NewSensorConfigRevision(sensor.Config, uc.scope.Actor.Subject, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.configHistory.Append(revision); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditSensorCreate, string(sensor.ID), nil, snapshot(sensor))
+
 	event := &domain.SensorCreatedEvent{
 		SensorID: id,
 		DeviceID: deviceID,
 		Type:     typ,
 		Name:     name,
+		Labels:   sensor.Labels,
 	}
 
 	return uc.eventPublisher.Publish(event.ToDomainEvent())
@@ -62,27 +149,445 @@ func (uc *SensorUseCase) GetAllSensors() ([]*domain.Sensor, error) {
 	return uc.sensorRepo.FindAll()
 }
 
-func (uc *SensorUseCase) UpdateSensorConfigById(id domain.SensorID, config domain.SensorConfig) error {
-	sensor, err := uc.sensorRepo.FindByID(id)
+// GetSensorsByDevice lists the sensors of the device, failing with
+// ErrDeviceNotFound when there is no such device.
+func (uc *SensorUseCase) GetSensorsByDevice(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	if _, err := uc.deviceRepo.FindByID(deviceID); err != nil {
+		return nil, err
+	}
+
+	return uc.sensorRepo.FindByDeviceID(deviceID)
+}
+
+// GetSensorsByDevices lists the sensors of all the devices in a single
+// lookup. Unlike GetSensorsByDevice, a device that does not exist simply has
+// no sensors.
+func (uc *SensorUseCase) GetSensorsByDevices(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	return uc.sensorRepo.FindByDeviceIDs(deviceIDs)
+}
+
+// UpdateSensorConfigById replaces the config of the sensor with a new
+// revision; the previous ones are kept in its history.
+func (uc *SensorUseCase) UpdateSensorConfigById(id domain.SensorID, config domain.SensorConfig) (*domain.Sensor, error) {
+	return uc.applyConfig(id, config, domain.AuditSensorConfigure, 0)
+}
+
+// RollbackSensorConfig applies the config of an earlier revision again. It
+// is validated, stored, audited and published like any other change, so the
+// rollback is itself a new revision, and like any other it is refused once
+// the device is decommissioned.
+func (uc *SensorUseCase) RollbackSensorConfig(id domain.SensorID, revision int64) (*domain.Sensor, error) {
+	if _, err := uc.sensorRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	restored, err := uc.configHistory.FindRevision(id, revision)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	return uc.applyConfig(id, restored.Config, domain.AuditSensorRollback, revision)
+}
+
+// ListConfigRevisions returns the config history of the sensor, newest
+// first. Sensors configured before the history was kept have no revisions
+// until their config first changes.
+func (uc *SensorUseCase) ListConfigRevisions(id domain.SensorID, limit int) ([]*domain.SensorConfigRevision, error) {
+	if _, err := uc.sensorRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	return uc.configHistory.FindBySensorID(id, limit)
+}
+
+// DiffConfigRevisions compares two revisions of the config of the sensor.
+func (uc *SensorUseCase) DiffConfigRevisions(id domain.SensorID, from, to int64) (*domain.SensorConfigDiff, error) {
+	if _, err := uc.sensorRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := uc.configHistory.FindRevision(id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := uc.configHistory.FindRevision(id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.DiffSensorConfigs(fromRevision, toRevision)
+}
+
+// applyConfig is the path of every config change. The revision is appended
+// to the history before the sensor is updated, so of two concurrent changes
+// only one gets the next number and the other fails with
+// ErrSensorConfigConflict. The sensors of a decommissioned device keep the
+// config they were disabled with.
+func (uc *SensorUseCase) applyConfig(id domain.SensorID, config domain.SensorConfig, action domain.AuditAction, restoredFrom int64) (*domain.Sensor, error) {
+	sensor, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := uc.deviceRepo.FindByID(sensor.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+
+	return uc.writeConfig(sensor, config, action, restoredFrom)
+}
+
+// writeConfig stores the config as the next revision of the sensor and
+// writes the sensor with it.
+func (uc *SensorUseCase) writeConfig(sensor *domain.Sensor, config domain.SensorConfig, action domain.AuditAction, restoredFrom int64) (*domain.Sensor, error) {
+	before := snapshot(sensor)
+	latest, err := uc.latestRevision(sensor)
+	if err != nil {
+		return nil, err
+	}
+
+	sensor.Config.Revision = latest
 	if err := sensor.UpdateConfig(config); err != nil {
 		uc.metrics.IncSensorError(sensor.Type, sensor.DeviceID)
-		return err
+		return nil, err
+	}
+
+	revision, err := domain.NewSensorConfigRevision(sensor.Config, uc.scope.Actor.Subject, restoredFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.configHistory.Append(revision); err != nil {
+		return nil, err
 	}
 
 	if err := uc.sensorRepo.Update(sensor); err != nil {
 		uc.metrics.IncSensorError(sensor.Type, sensor.DeviceID)
-		return err
+		return nil, err
 	}
 
 	uc.metrics.IncSensorReading(sensor.Type, sensor.DeviceID)
 
+	uc.scope.record(action, string(sensor.ID), before, snapshot(sensor))
+
 	event := &domain.SensorConfigUpdatedEvent{
-		SensorID: id,
-		Config:   config,
+		SensorID:     sensor.ID,
+		Config:       sensor.Config,
+		RestoredFrom: restoredFrom,
+	}
+
+	return sensor, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// disableDeviceSensors stops the simulations of the sensors of the device
+// and disables those still enabled, each with a revision in its history. It
+// runs once the device is written as decommissioned, so a failed write
+// leaves the sensors untouched; applyConfig would refuse the change by then,
+// which is why the revisions are written directly. Sensors already disabled
+// are skipped, so running it again finishes a cascade that failed half way.
+func (uc *SensorUseCase) disableDeviceSensors(deviceID domain.DeviceID) error {
+	sensors, err := uc.sensorRepo.FindByDeviceID(deviceID)
+	if err != nil {
+		return err
+	}
+
+	for _, sensor := range sensors {
+		if err := stopSimulation(uc.simulatorRepo, sensor.ID); err != nil {
+			return err
+		}
+
+		if !sensor.Config.Enabled {
+			continue
+		}
+
+		config := sensor.Config
+		config.SensorID = sensor.ID
+		config.Enabled = false
+		if _, err := uc.writeConfig(sensor, config, domain.AuditSensorConfigure, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findForUpdate loads the sensor to change, checking it is still at the
+// expected version. The repository checks it again when the change is
+// written, so a change made in between is not lost either.
+func (uc *SensorUseCase) findForUpdate(id domain.SensorID) (*domain.Sensor, error) {
+	sensor, err := uc.sensorRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.expectedVersion != 0 && sensor.Version != uc.expectedVersion {
+		return nil, fmt.Errorf("%w: sensor is at version %d, not %d", domain.ErrSensorVersionConflict, sensor.Version, uc.expectedVersion)
+	}
+
+	return sensor, nil
+}
+
+// latestRevision is the number of the newest revision of the config of the
+// sensor. A config the history is missing, because the sensor was
+// configured before the history was kept, is stored first so it is not
+// lost. A revision newer than the sensor, left by a change whose update
+// failed, is skipped so its number is not reused.
+func (uc *SensorUseCase) latestRevision(sensor *domain.Sensor) (int64, error) {
+	revisions, err := uc.configHistory.FindBySensorID(sensor.ID, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(revisions) > 0 && revisions[0].Revision >= sensor.Config.Revision {
+		return revisions[0].Revision, nil
+	}
+
+	config := sensor.Config
+	config.Revision = max(config.Revision, 1)
+	revision, err := domain.NewSensorConfigRevision(config, "", 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := uc.configHistory.Append(revision); err != nil && !errors.Is(err, domain.ErrSensorConfigConflict) {
+		return 0, err
+	}
+
+	return config.Revision, nil
+}
+
+// UpdateSensorConfigs applies the same config to every sensor, reporting the
+// outcome per sensor rather than stopping at the first failure. Each sensor
+// is changed only if it is still at the version given for it in versions;
+// one left out of versions fails with ErrSensorVersionConflict.
+func (uc *SensorUseCase) UpdateSensorConfigs(sensors []*domain.Sensor, config domain.SensorConfig, versions map[domain.SensorID]int64) BulkResult {
+	result := newBulkResult()
+	for _, sensor := range sensors {
+		version, ok := versions[sensor.ID]
+		if !ok {
+			result.record(string(sensor.ID), fmt.Errorf("%w: no version given for the sensor", domain.ErrSensorVersionConflict))
+			continue
+		}
+
+		sensorConfig := config
+		sensorConfig.SensorID = sensor.ID
+		_, err := uc.ExpectVersion(version).UpdateSensorConfigById(sensor.ID, sensorConfig)
+		result.record(string(sensor.ID), err)
+	}
+
+	return result
+}
+
+// RelabelSensor replaces the labels of the sensor.
+func (uc *SensorUseCase) RelabelSensor(id domain.SensorID, labels domain.Labels) (*domain.Sensor, error) {
+	sensor, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(sensor)
+	if err := sensor.Relabel(labels); err != nil {
+		return nil, err
+	}
+
+	if err := uc.sensorRepo.Update(sensor); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditSensorRelabel, string(sensor.ID), before, snapshot(sensor))
+
+	event := &domain.SensorLabelsChangedEvent{
+		SensorID: sensor.ID,
+		Labels:   sensor.Labels,
+	}
+
+	return sensor, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+func (uc *SensorUseCase) RenameSensor(id domain.SensorID, name string) (*domain.Sensor, error) {
+	sensor, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(sensor)
+	if err := sensor.Rename(name); err != nil {
+		return nil, err
+	}
+
+	if err := uc.sensorRepo.Update(sensor); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditSensorRename, string(sensor.ID), before, snapshot(sensor))
+
+	event := &domain.SensorRenamedEvent{
+		SensorID: sensor.ID,
+		Name:     sensor.Name,
+	}
+
+	return sensor, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// SensorPatch is a partial update of a sensor; the fields left nil are kept.
+type SensorPatch struct {
+	Name     *string
+	Labels   *domain.Labels
+	DeviceID *domain.DeviceID
+}
+
+// PatchSensor applies every change of the patch or none: they are all
+// validated, the device moved to included, before the sensor is written,
+// once, as a new version. Moving stops a running simulation, as MoveSensor
+// does. The patch is published as the events of the separate changes it
+// makes.
+func (uc *SensorUseCase) PatchSensor(id domain.SensorID, patch SensorPatch) (*domain.Sensor, error) {
+	sensor, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(sensor)
+	previous := *sensor
+
+	if patch.Name != nil {
+		if err := sensor.Rename(*patch.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Labels != nil {
+		if err := sensor.Relabel(*patch.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.DeviceID != nil && *patch.DeviceID != sensor.DeviceID {
+		if err := sensor.MoveTo(*patch.DeviceID); err != nil {
+			return nil, err
+		}
+
+		device, err := uc.deviceRepo.FindByID(sensor.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		if device.ID == "" {
+			return nil, domain.ErrDeviceNotFound
+		}
+		if device.Status == domain.DeviceDecommissioned {
+			return nil, domain.ErrDeviceDecommissioned
+		}
+	}
+
+	if sensor.DeviceID != previous.DeviceID {
+		if err := stopSimulation(uc.simulatorRepo, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.sensorRepo.Update(sensor); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditSensorUpdate, string(sensor.ID), before, snapshot(sensor))
+
+	var events []domain.IoTEvent
+	if sensor.Name != previous.Name {
+		event := &domain.SensorRenamedEvent{SensorID: sensor.ID, Name: sensor.Name}
+		events = append(events, event.ToDomainEvent())
+	}
+	if patch.Labels != nil {
+		event := &domain.SensorLabelsChangedEvent{SensorID: sensor.ID, Labels: sensor.Labels}
+		events = append(events, event.ToDomainEvent())
+	}
+	if sensor.DeviceID != previous.DeviceID {
+		event := &domain.SensorMovedEvent{SensorID: sensor.ID, From: previous.DeviceID, To: sensor.DeviceID}
+		events = append(events, event.ToDomainEvent())
+	}
+
+	for _, event := range events {
+		if err := uc.eventPublisher.Publish(event); err != nil {
+			return sensor, err
+		}
+	}
+
+	return sensor, nil
+}
+
+// MoveSensor reassigns the sensor to another device. A running simulation is
+// stopped because it would keep stamping readings with the old device; the
+// readings already stored are left untouched.
+func (uc *SensorUseCase) MoveSensor(id domain.SensorID, deviceID domain.DeviceID) (*domain.Sensor, error) {
+	sensor, err := uc.findForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sensor.DeviceID == deviceID {
+		return sensor, nil
+	}
+
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.ID == "" {
+		return nil, domain.ErrDeviceNotFound
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+
+	before := snapshot(sensor)
+	previous := sensor.DeviceID
+	if err := sensor.MoveTo(deviceID); err != nil {
+		return nil, err
+	}
+
+	if err := stopSimulation(uc.simulatorRepo, id); err != nil {
+		return nil, err
+	}
+
+	if err := uc.sensorRepo.Update(sensor); err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditSensorMove, string(sensor.ID), before, snapshot(sensor))
+
+	event := &domain.SensorMovedEvent{
+		SensorID: sensor.ID,
+		From:     previous,
+		To:       sensor.DeviceID,
+	}
+
+	return sensor, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// DeleteSensor stops any running simulation and soft deletes the sensor.
+// Its readings are kept for historical queries.
+func (uc *SensorUseCase) DeleteSensor(id domain.SensorID) error {
+	sensor, err := uc.sensorRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := stopSimulation(uc.simulatorRepo, id); err != nil {
+		return err
+	}
+
+	if err := uc.sensorRepo.Delete(id); err != nil {
+		return err
+	}
+
+	uc.scope.record(domain.AuditSensorDelete, string(sensor.ID), snapshot(sensor), nil)
+
+	event := &domain.SensorDeletedEvent{
+		SensorID: sensor.ID,
+		DeviceID: sensor.DeviceID,
 	}
 
 	return uc.eventPublisher.Publish(event.ToDomainEvent())
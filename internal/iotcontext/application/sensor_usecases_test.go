@@ -3,6 +3,7 @@ package application
 import (
 	"errors"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
 	"testing"
 )
 
@@ -16,6 +17,7 @@ func TestSensorUseCase_CreateSensor(t *testing.T) {
 		config      domain.SensorConfig
 		repoSaveErr error
 		expectError bool
+		expectIs    error
 		expectEvent bool
 	}{
 		{
@@ -63,23 +65,53 @@ func TestSensorUseCase_CreateSensor(t *testing.T) {
 			expectError: true,
 			expectEvent: false,
 		},
+		{
+			name:        "unknown device",
+			id:          "sensor-123",
+			deviceID:    "device-404",
+			sensorName:  "Temperature Sensor",
+			sensorType:  domain.Temperature,
+			config:      domain.SensorConfig{SamplingRateMs: 1000, Enabled: true},
+			expectError: true,
+			expectIs:    domain.ErrDeviceNotFound,
+		},
+		{
+			name:        "decommissioned device",
+			id:          "sensor-123",
+			deviceID:    "device-456",
+			sensorName:  "Temperature Sensor",
+			sensorType:  domain.Temperature,
+			config:      domain.SensorConfig{SamplingRateMs: 1000, Enabled: true},
+			expectError: true,
+			expectIs:    domain.ErrDeviceDecommissioned,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockSensorRepository()
+			mockRepo := NewFaultySensorRepository()
 			mockRepo.saveErr = tt.repoSaveErr
 			mockPublisher := NewMockEventPublisher()
 			mockMetrics := NewMockMetrics()
 
-			useCase := NewSensorUseCase(mockRepo, mockMetrics, mockPublisher)
+			deviceRepo := persistence.NewInMemoryDeviceRepository()
+			device, _ := domain.NewDevice("device-123", "Gateway", "gateway")
+			deviceRepo.Save(device)
+			retired, _ := domain.NewDevice("device-456", "Old gateway", "gateway")
+			retired.Status = domain.DeviceDecommissioned
+			deviceRepo.Save(retired)
 
-			err := useCase.CreateSensor(tt.id, tt.deviceID, tt.sensorName, tt.sensorType, tt.config)
+			useCase := NewSensorUseCase(mockRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, NewMockSimulatorRepository(), mockMetrics, mockPublisher)
+
+			err := useCase.CreateSensor(tt.id, tt.deviceID, tt.sensorName, tt.sensorType, tt.config, nil)
 
 			if tt.expectError {
 				if err == nil {
 					t.Errorf("expected error but got none")
 				}
+				if tt.expectIs != nil && !errors.Is(err, tt.expectIs) {
+					t.Errorf("expected %v, got %v", tt.expectIs, err)
+				}
 				return
 			}
 
@@ -121,11 +153,11 @@ func TestSensorUseCase_CreateSensor(t *testing.T) {
 }
 
 func TestSensorUseCase_GetSensorByID(t *testing.T) {
-	mockRepo := NewMockSensorRepository()
+	mockRepo := persistence.NewInMemorySensorRepository()
 	mockPublisher := NewMockEventPublisher()
 	mockMetrics := NewMockMetrics()
 
-	useCase := NewSensorUseCase(mockRepo, mockMetrics, mockPublisher)
+	useCase := NewSensorUseCase(mockRepo, persistence.NewInMemorySensorConfigHistoryRepository(), persistence.NewInMemoryDeviceRepository(), NewMockSimulatorRepository(), mockMetrics, mockPublisher)
 
 	sensor, err := domain.NewSensor("sensor-123", "device-123", "Test Sensor", domain.Temperature, domain.SensorConfig{})
 	if err != nil {
@@ -150,11 +182,11 @@ func TestSensorUseCase_GetSensorByID(t *testing.T) {
 }
 
 func TestSensorUseCase_GetAllSensors(t *testing.T) {
-	mockRepo := NewMockSensorRepository()
+	mockRepo := persistence.NewInMemorySensorRepository()
 	mockPublisher := NewMockEventPublisher()
 	mockMetrics := NewMockMetrics()
 
-	useCase := NewSensorUseCase(mockRepo, mockMetrics, mockPublisher)
+	useCase := NewSensorUseCase(mockRepo, persistence.NewInMemorySensorConfigHistoryRepository(), persistence.NewInMemoryDeviceRepository(), NewMockSimulatorRepository(), mockMetrics, mockPublisher)
 
 	sensor1, _ := domain.NewSensor("sensor-1", "device-1", "Sensor 1", domain.Temperature, domain.SensorConfig{})
 	sensor2, _ := domain.NewSensor("sensor-2", "device-2", "Sensor 2", domain.Humidity, domain.SensorConfig{})
@@ -179,6 +211,7 @@ func TestSensorUseCase_UpdateSensorConfigById(t *testing.T) {
 		config        domain.SensorConfig
 		repoFindErr   error
 		repoUpdateErr error
+		deviceStatus  domain.DeviceStatus
 		expectError   bool
 		expectEvent   bool
 	}{
@@ -224,11 +257,24 @@ func TestSensorUseCase_UpdateSensorConfigById(t *testing.T) {
 			expectError:   true,
 			expectEvent:   false,
 		},
+		{
+			name:     "decommissioned device",
+			sensorID: "sensor-123",
+			config: domain.SensorConfig{
+				SensorID:       "sensor-123",
+				SamplingRateMs: 2000,
+				ErrorRate:      0.2,
+				Enabled:        true,
+			},
+			deviceStatus: domain.DeviceDecommissioned,
+			expectError:  true,
+			expectEvent:  false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockSensorRepository()
+			mockRepo := NewFaultySensorRepository()
 			mockRepo.findErr = tt.repoFindErr
 			mockRepo.updateErr = tt.repoUpdateErr
 			mockPublisher := NewMockEventPublisher()
@@ -239,9 +285,16 @@ func TestSensorUseCase_UpdateSensorConfigById(t *testing.T) {
 				mockRepo.Save(sensor)
 			}
 
-			useCase := NewSensorUseCase(mockRepo, mockMetrics, mockPublisher)
+			deviceRepo := persistence.NewInMemoryDeviceRepository()
+			device, _ := domain.NewDevice("device-123", "Gateway", "gateway")
+			if tt.deviceStatus != "" {
+				device.Status = tt.deviceStatus
+			}
+			deviceRepo.Save(device)
+
+			useCase := NewSensorUseCase(mockRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, NewMockSimulatorRepository(), mockMetrics, mockPublisher)
 
-			err := useCase.UpdateSensorConfigById(tt.sensorID, tt.config)
+			_, err := useCase.UpdateSensorConfigById(tt.sensorID, tt.config)
 
 			if tt.expectError {
 				if err == nil {
@@ -268,3 +321,411 @@ func TestSensorUseCase_UpdateSensorConfigById(t *testing.T) {
 		})
 	}
 }
+
+func newSensorLifecycleFixture(t *testing.T) (*SensorUseCase, domain.SensorRepository, *MockSimulatorRepository, *MockEventPublisher) {
+	t.Helper()
+
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	simulatorRepo := NewMockSimulatorRepository()
+	publisher := NewMockEventPublisher()
+
+	for _, id := range []domain.DeviceID{"device-1", "device-2", "device-3"} {
+		device, _ := domain.NewDevice(id, "Gateway", "gateway")
+		device.Status = domain.DeviceActive
+		deviceRepo.Save(device)
+	}
+	decommissioned, _ := deviceRepo.FindByID("device-3")
+	decommissioned.Status = domain.DeviceDecommissioned
+	deviceRepo.Update(&decommissioned)
+
+	sensor, _ := domain.NewSensor("sensor-1", "device-1", "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+	sensorRepo.Save(sensor)
+	simulatorRepo.Start("sensor-1")
+
+	return NewSensorUseCase(sensorRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, simulatorRepo, NewMockMetrics(), publisher), sensorRepo, simulatorRepo, publisher
+}
+
+func TestSensorUseCase_MoveSensor(t *testing.T) {
+	tests := []struct {
+		name        string
+		sensorID    domain.SensorID
+		deviceID    domain.DeviceID
+		expectError error
+		expectMoved bool
+	}{
+		{name: "move to another device", sensorID: "sensor-1", deviceID: "device-2", expectMoved: true},
+		{name: "same device is a no-op", sensorID: "sensor-1", deviceID: "device-1"},
+		{name: "unknown sensor", sensorID: "sensor-404", deviceID: "device-2", expectError: domain.ErrSensorNotFound},
+		{name: "unknown device", sensorID: "sensor-1", deviceID: "device-404", expectError: domain.ErrDeviceNotFound},
+		{name: "decommissioned device", sensorID: "sensor-1", deviceID: "device-3", expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, sensorRepo, simulatorRepo, publisher := newSensorLifecycleFixture(t)
+
+			sensor, err := useCase.MoveSensor(tt.sensorID, tt.deviceID)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				if !simulatorRepo.active["sensor-1"] {
+					t.Errorf("expected the simulation to keep running")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if sensor.DeviceID != tt.deviceID {
+				t.Errorf("expected device %s, got %s", tt.deviceID, sensor.DeviceID)
+			}
+
+			if stored, _ := sensorRepo.FindByID(tt.sensorID); stored.DeviceID != tt.deviceID {
+				t.Errorf("expected stored device %s, got %s", tt.deviceID, stored.DeviceID)
+			}
+
+			events := publisher.GetEvents()
+			if !tt.expectMoved {
+				if len(events) != 0 {
+					t.Errorf("expected no events, got %+v", events)
+				}
+				return
+			}
+
+			if simulatorRepo.active["sensor-1"] {
+				t.Errorf("expected the simulation to be stopped")
+			}
+
+			if len(events) != 1 || events[0].Type != "sensor.moved" {
+				t.Fatalf("expected a sensor.moved event, got %+v", events)
+			}
+
+			payload := events[0].Payload.(*domain.SensorMovedEvent)
+			if payload.From != "device-1" || payload.To != tt.deviceID {
+				t.Errorf("unexpected event payload %+v", payload)
+			}
+		})
+	}
+}
+
+func TestSensorUseCase_RenameSensor(t *testing.T) {
+	useCase, _, simulatorRepo, publisher := newSensorLifecycleFixture(t)
+
+	if _, err := useCase.RenameSensor("sensor-1", ""); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	sensor, err := useCase.RenameSensor("sensor-1", "Boiler temperature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sensor.Name != "Boiler temperature" {
+		t.Errorf("expected the new name, got %s", sensor.Name)
+	}
+
+	if !simulatorRepo.active["sensor-1"] {
+		t.Errorf("expected renaming to keep the simulation running")
+	}
+
+	events := publisher.GetEvents()
+	if len(events) != 1 || events[0].Type != "sensor.renamed" {
+		t.Errorf("expected a sensor.renamed event, got %+v", events)
+	}
+}
+
+func TestSensorUseCase_GetSensorsByDevice(t *testing.T) {
+	useCase, _, _, _ := newSensorLifecycleFixture(t)
+
+	sensors, err := useCase.GetSensorsByDevice("device-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sensors) != 1 || sensors[0].ID != "sensor-1" {
+		t.Errorf("expected sensor-1, got %+v", sensors)
+	}
+
+	if sensors, err := useCase.GetSensorsByDevice("device-2"); err != nil || len(sensors) != 0 {
+		t.Errorf("expected no sensors, got %+v, %v", sensors, err)
+	}
+
+	if _, err := useCase.GetSensorsByDevice("unknown"); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+}
+
+func TestSensorUseCase_ExpectVersion(t *testing.T) {
+	useCase, _, _, publisher := newSensorLifecycleFixture(t)
+
+	if _, err := useCase.ExpectVersion(2).RenameSensor("sensor-1", "Boiler"); !errors.Is(err, domain.ErrSensorVersionConflict) {
+		t.Errorf("expected ErrSensorVersionConflict, got %v", err)
+	}
+
+	if _, err := useCase.ExpectVersion(2).UpdateSensorConfigById("sensor-1", domain.SensorConfig{SamplingRateMs: 500}); !errors.Is(err, domain.ErrSensorVersionConflict) {
+		t.Errorf("expected ErrSensorVersionConflict, got %v", err)
+	}
+
+	if events := publisher.GetEvents(); len(events) != 0 {
+		t.Errorf("expected no events, got %+v", events)
+	}
+
+	if _, err := useCase.ExpectVersion(1).RenameSensor("sensor-1", "Boiler"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSensorUseCase_PatchSensor(t *testing.T) {
+	name := "Boiler"
+	active := domain.DeviceID("device-2")
+	decommissioned := domain.DeviceID("device-3")
+	unknown := domain.DeviceID("device-404")
+
+	tests := []struct {
+		name             string
+		patch            SensorPatch
+		expectError      error
+		expectedName     string
+		expectedDevice   domain.DeviceID
+		expectedVersion  int64
+		expectedEvents   []string
+		expectSimulation bool
+	}{
+		{
+			name:            "every field in one version",
+			patch:           SensorPatch{Name: &name, Labels: &domain.Labels{"zone": "north"}, DeviceID: &active},
+			expectedName:    "Boiler",
+			expectedDevice:  "device-2",
+			expectedVersion: 2,
+			expectedEvents:  []string{"sensor.renamed", "sensor.labels.changed", "sensor.moved"},
+		},
+		{
+			name:             "invalid labels keep the name",
+			patch:            SensorPatch{Name: &name, Labels: &domain.Labels{"bad key": "x"}},
+			expectError:      domain.ErrInvalidLabels,
+			expectedName:     "Sensor",
+			expectedDevice:   "device-1",
+			expectedVersion:  1,
+			expectSimulation: true,
+		},
+		{
+			name:             "decommissioned device keeps the name",
+			patch:            SensorPatch{Name: &name, DeviceID: &decommissioned},
+			expectError:      domain.ErrDeviceDecommissioned,
+			expectedName:     "Sensor",
+			expectedDevice:   "device-1",
+			expectedVersion:  1,
+			expectSimulation: true,
+		},
+		{
+			name:             "unknown device",
+			patch:            SensorPatch{DeviceID: &unknown},
+			expectError:      domain.ErrDeviceNotFound,
+			expectedName:     "Sensor",
+			expectedDevice:   "device-1",
+			expectedVersion:  1,
+			expectSimulation: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceRepo := persistence.NewInMemoryDeviceRepository()
+			sensorRepo := persistence.NewInMemorySensorRepository()
+			simulatorRepo := NewMockSimulatorRepository()
+			publisher := NewMockEventPublisher()
+			useCase := NewSensorUseCase(sensorRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, simulatorRepo, NewMockMetrics(), publisher)
+
+			for _, id := range []domain.DeviceID{"device-1", "device-2", "device-3"} {
+				device, _ := domain.NewDevice(id, "Gateway", "gateway")
+				device.Status = domain.DeviceActive
+				if id == decommissioned {
+					device.Status = domain.DeviceDecommissioned
+				}
+				deviceRepo.Save(device)
+			}
+			sensor, _ := domain.NewSensor("sensor-1", "device-1", "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+			sensorRepo.Save(sensor)
+			simulatorRepo.Start("sensor-1")
+
+			_, err := useCase.ExpectVersion(1).PatchSensor("sensor-1", tt.patch)
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored, _ := sensorRepo.FindByID("sensor-1")
+			if stored.Name != tt.expectedName || stored.DeviceID != tt.expectedDevice || stored.Version != tt.expectedVersion {
+				t.Errorf("expected %q on %s at version %d, got %q on %s at version %d", tt.expectedName, tt.expectedDevice, tt.expectedVersion, stored.Name, stored.DeviceID, stored.Version)
+			}
+
+			if simulatorRepo.active["sensor-1"] != tt.expectSimulation {
+				t.Errorf("expected simulation running=%v", tt.expectSimulation)
+			}
+
+			var events []string
+			for _, event := range publisher.GetEvents() {
+				events = append(events, event.Type)
+			}
+			if len(events) != len(tt.expectedEvents) {
+				t.Fatalf("expected events %v, got %v", tt.expectedEvents, events)
+			}
+			for i := range events {
+				if events[i] != tt.expectedEvents[i] {
+					t.Errorf("expected events %v, got %v", tt.expectedEvents, events)
+				}
+			}
+		})
+	}
+}
+
+func TestSensorUseCase_DeleteSensor(t *testing.T) {
+	useCase, sensorRepo, simulatorRepo, publisher := newSensorLifecycleFixture(t)
+
+	if err := useCase.DeleteSensor("sensor-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := sensorRepo.FindByID("sensor-1"); !errors.Is(err, domain.ErrSensorNotFound) {
+		t.Errorf("expected ErrSensorNotFound, got %v", err)
+	}
+
+	if len(simulatorRepo.stopped) != 1 || simulatorRepo.stopped[0] != "sensor-1" {
+		t.Errorf("expected the running simulation to be stopped, got %v", simulatorRepo.stopped)
+	}
+
+	events := publisher.GetEvents()
+	if len(events) != 1 || events[0].Type != "sensor.deleted" {
+		t.Fatalf("expected a sensor.deleted event, got %+v", events)
+	}
+
+	if payload := events[0].Payload.(*domain.SensorDeletedEvent); payload.DeviceID != "device-1" {
+		t.Errorf("expected the event to carry the device, got %+v", payload)
+	}
+
+	if err := useCase.DeleteSensor("sensor-1"); !errors.Is(err, domain.ErrSensorNotFound) {
+		t.Errorf("expected ErrSensorNotFound, got %v", err)
+	}
+}
+
+func TestSensorUseCase_ConfigHistory(t *testing.T) {
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	configHistory := NewFaultySensorConfigHistoryRepository()
+	publisher := NewMockEventPublisher()
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	useCase := NewSensorUseCase(sensorRepo, configHistory, deviceRepo, NewMockSimulatorRepository(), NewMockMetrics(), publisher)
+
+	device, _ := domain.NewDevice("device-1", "Gateway", "gateway")
+	deviceRepo.Save(device)
+
+	// Configured before the history was kept: its config is stored as the
+	// first revision when it first changes.
+	sensor, _ := domain.NewSensor("sensor-1", "device-1", "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true})
+	sensorRepo.Save(sensor)
+
+	for _, rate := range []int{2000, 3000} {
+		config := domain.SensorConfig{SensorID: "sensor-1", SamplingRateMs: rate, Enabled: true}
+		if _, err := useCase.UpdateSensorConfigById("sensor-1", config); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := useCase.UpdateSensorConfigById("sensor-1", domain.SensorConfig{SensorID: "sensor-1"}); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	rolledBack, err := useCase.RollbackSensorConfig("sensor-1", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rolledBack.Config.Revision != 4 || rolledBack.Config.SamplingRateMs != 1000 {
+		t.Errorf("expected revision 4 with the config of revision 1, got %+v", rolledBack.Config)
+	}
+
+	revisions, err := useCase.ListConfigRevisions("sensor-1", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rates := []int{1000, 3000, 2000, 1000}
+	if len(revisions) != len(rates) {
+		t.Fatalf("expected %d revisions, got %d", len(rates), len(revisions))
+	}
+
+	for i, revision := range revisions {
+		if revision.Revision != int64(len(rates)-i) || revision.Config.SamplingRateMs != rates[i] {
+			t.Errorf("unexpected revision %d: %+v", i, revision)
+		}
+	}
+
+	if revisions[0].RestoredFrom != 1 {
+		t.Errorf("expected the rollback to name revision 1, got %d", revisions[0].RestoredFrom)
+	}
+
+	events := publisher.GetEvents()
+	last := events[len(events)-1].Payload.(*domain.SensorConfigUpdatedEvent)
+	if len(events) != 3 || last.RestoredFrom != 1 || last.Config.Revision != 4 {
+		t.Errorf("expected the rollback to be published like any change, got %+v", events)
+	}
+
+	diff, err := useCase.DiffConfigRevisions("sensor-1", 1, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "sampling_rate_ms" || string(diff.Changes[0].After) != "3000" {
+		t.Errorf("expected only the sampling rate to change, got %+v", diff.Changes)
+	}
+
+	tests := []struct {
+		name        string
+		run         func() error
+		expectError error
+	}{
+		{name: "rollback to unknown revision", run: func() error { _, err := useCase.RollbackSensorConfig("sensor-1", 9); return err }, expectError: domain.ErrSensorConfigRevisionNotFound},
+		{name: "diff with unknown revision", run: func() error { _, err := useCase.DiffConfigRevisions("sensor-1", 1, 9); return err }, expectError: domain.ErrSensorConfigRevisionNotFound},
+		{name: "history of unknown sensor", run: func() error { _, err := useCase.ListConfigRevisions("sensor-404", 0); return err }, expectError: domain.ErrSensorNotFound},
+		{name: "rollback on decommissioned device", run: func() error {
+			decommissioned := *device
+			decommissioned.Status = domain.DeviceDecommissioned
+			deviceRepo.Update(&decommissioned)
+			_, err := useCase.RollbackSensorConfig("sensor-1", 1)
+			return err
+		}, expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.expectError) {
+				t.Errorf("expected %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestSensorUseCase_ConfigConflict(t *testing.T) {
+	useCase, _, _, publisher := newSensorLifecycleFixture(t)
+	configHistory := &FaultySensorConfigHistoryRepository{SensorConfigHistoryRepository: useCase.configHistory, appendErr: domain.ErrSensorConfigConflict}
+	useCase.configHistory = configHistory
+
+	config := domain.SensorConfig{SensorID: "sensor-1", SamplingRateMs: 5000, Enabled: true}
+	if _, err := useCase.UpdateSensorConfigById("sensor-1", config); !errors.Is(err, domain.ErrSensorConfigConflict) {
+		t.Fatalf("expected ErrSensorConfigConflict, got %v", err)
+	}
+
+	if revisions, _ := configHistory.FindBySensorID("sensor-1", 0); len(revisions) != 0 {
+		t.Errorf("expected no revision, got %+v", revisions)
+	}
+
+	if events := publisher.GetEvents(); len(events) != 0 {
+		t.Errorf("expected no events, got %+v", events)
+	}
+}
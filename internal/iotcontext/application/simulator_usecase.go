@@ -9,15 +9,32 @@ type SimulatorUseCase struct {
 	sensorRepository domain.SensorRepository
 	simulatorRepo    domain.SimulatorRepository
 	eventPublisher   domain.EventPublisher
+	scope            TenantScope
 }
 
-func NewSimulatorUseCase(sensorRepo domain.SensorRepository, simulatorRepo domain.SimulatorRepository) *SimulatorUseCase {
+func NewSimulatorUseCase(sensorRepo domain.SensorRepository, simulatorRepo domain.SimulatorRepository, eventPublisher domain.EventPublisher) *SimulatorUseCase {
 	return &SimulatorUseCase{
 		sensorRepository: sensorRepo,
 		simulatorRepo:    simulatorRepo,
+		eventPublisher:   eventPublisher,
 	}
 }
 
+// ForTenant returns a copy of the use case that only controls the sensors of
+// the tenant. The zero scope returns uc itself.
+func (uc *SimulatorUseCase) ForTenant(scope TenantScope) *SimulatorUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.sensorRepository = scopeSensors(uc.sensorRepository, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
 func (uc *SimulatorUseCase) Publish(event domain.IoTEvent) error {
 	event.Type = "simulator." + event.Type
 
@@ -53,6 +70,11 @@ func (uc *SimulatorUseCase) ControlSensor(sensorID domain.SensorID, action strin
 		return err
 	}
 
+	// The simulation keeps no state worth a before snapshot: the entry
+	// records what was done to it.
+	after := snapshot(map[string]string{"simulation": action})
+	uc.scope.record(domain.AuditSensorControl, string(sensorID), nil, after)
+
 	event := domain.IoTEvent{
 		Type:      eventType,
 		Payload:   map[string]interface{}{"sensor_id": sensorID},
@@ -61,3 +83,20 @@ func (uc *SimulatorUseCase) ControlSensor(sensorID domain.SensorID, action strin
 
 	return uc.Publish(event)
 }
+
+// ControlSensors applies the action to every sensor, reporting the outcome
+// per sensor. An unknown action is rejected before touching any of them.
+func (uc *SimulatorUseCase) ControlSensors(sensors []*domain.Sensor, action string) (BulkResult, error) {
+	switch action {
+	case "start", "stop", "inject_error":
+	default:
+		return BulkResult{}, domain.ErrInvalidAction
+	}
+
+	result := newBulkResult()
+	for _, sensor := range sensors {
+		result.record(string(sensor.ID), uc.ControlSensor(sensor.ID, action))
+	}
+
+	return result, nil
+}
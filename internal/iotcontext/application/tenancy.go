@@ -0,0 +1,651 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	domain_metrics "github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/domain"
+	"github.com/google/uuid"
+	"log"
+	"sync"
+	"time"
+)
+
+// auditQueueSize is how many audit entries that failed to be appended are
+// kept for a retry. Past it the oldest are dropped.
+const auditQueueSize = 10000
+
+// Tenancy knows the quota of every tenant, counts their readings to enforce
+// the ingestion rate and keeps the audit log of what is changed in them.
+type Tenancy struct {
+	quotas  domain.TenantQuotas
+	audit   domain.AuditRepository
+	metrics domain_metrics.AuditMetrics
+	windows map[domain.TenantID]*domain.RateWindow
+	mu      sync.Mutex
+
+	auditMu      sync.Mutex
+	pendingAudit []*domain.AuditEntry
+}
+
+// NewTenancy builds the tenancy. audit may be nil, in which case changes are
+// not audited, and so may metrics.
+func NewTenancy(quotas domain.TenantQuotas, audit domain.AuditRepository, metrics domain_metrics.AuditMetrics) *Tenancy {
+	return &Tenancy{
+		quotas:  quotas,
+		audit:   audit,
+		metrics: metrics,
+		windows: make(map[domain.TenantID]*domain.RateWindow),
+	}
+}
+
+func (t *Tenancy) Scope(tenant domain.TenantID) TenantScope {
+	return TenantScope{
+		Tenant:  tenant,
+		Quota:   t.quotas.For(tenant),
+		tenancy: t,
+	}
+}
+
+// Deployment is the scope of what belongs to the whole deployment, like
+// firmware: it sees every tenant and has no quota.
+func (t *Tenancy) Deployment() TenantScope {
+	return TenantScope{tenancy: t}
+}
+
+func (t *Tenancy) allow(tenant domain.TenantID, limit int, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window, ok := t.windows[tenant]
+	if !ok {
+		window = &domain.RateWindow{}
+		t.windows[tenant] = window
+	}
+
+	return window.Allow(limit, now)
+}
+
+// TenantScope is the tenant a request acts for and who makes the request.
+// The zero scope is not bound to any tenant: use cases built with it see
+// every tenant, which is what the background jobs need.
+type TenantScope struct {
+	Tenant  domain.TenantID
+	Quota   domain.TenantQuota
+	Actor   domain.AuditActor
+	tenancy *Tenancy
+}
+
+// For returns the scope of another tenant, with its own quota and the same
+// actor.
+func (s TenantScope) For(tenant domain.TenantID) TenantScope {
+	if s.tenancy == nil {
+		return TenantScope{Tenant: tenant, Actor: s.Actor}
+	}
+
+	scope := s.tenancy.Scope(tenant)
+	scope.Actor = s.Actor
+
+	return scope
+}
+
+// By returns the scope with the actor the changes made in it are audited
+// for.
+func (s TenantScope) By(actor domain.AuditActor) TenantScope {
+	s.Actor = actor
+	return s
+}
+
+// AllowReading counts one reading against the ingestion rate of the tenant.
+func (s TenantScope) AllowReading(now time.Time) error {
+	if s.tenancy == nil || s.tenancy.allow(s.Tenant, s.Quota.ReadingsPerMinute, now) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d readings per minute", domain.ErrRateLimited, s.Quota.ReadingsPerMinute)
+}
+
+// checkQuota fails when used already reached limit, a quota of the scope.
+func (s TenantScope) checkQuota(used int, limit int, resource string) error {
+	if s.tenancy != nil && limit > 0 && used >= limit {
+		return fmt.Errorf("%w: %d %s", domain.ErrQuotaExceeded, limit, resource)
+	}
+
+	return nil
+}
+
+// record appends a change made in the scope to the audit log. Scopes without
+// an actor, like those of the background jobs, record nothing. The change is
+// already stored when it is recorded, so an entry that cannot be appended is
+// queued for RetryAudit rather than failing a request that took effect.
+func (s TenantScope) record(action domain.AuditAction, resourceID string, before, after json.RawMessage) {
+	if s.tenancy == nil || s.tenancy.audit == nil || s.Actor.Subject == "" {
+		return
+	}
+
+	entry, err := domain.NewAuditEntry(domain.AuditEntryID(uuid.NewString()), s.Actor, action, resourceID, before, after, time.Now())
+	if err != nil {
+		log.Printf("audit: failed to record %s of %s by %s: %v", action, resourceID, s.Actor.Subject, err)
+		return
+	}
+
+	entry.TenantID = s.Tenant
+	if err := s.tenancy.audit.Append(entry); err != nil {
+		log.Printf("audit: failed to record %s of %s by %s, will retry: %v", action, resourceID, s.Actor.Subject, err)
+		s.tenancy.queueAudit(entry)
+	}
+}
+
+// queueAudit keeps an entry the audit log failed to append for RetryAudit.
+func (t *Tenancy) queueAudit(entry *domain.AuditEntry) {
+	t.auditMu.Lock()
+	defer t.auditMu.Unlock()
+
+	if t.metrics != nil {
+		t.metrics.IncAuditFailures()
+	}
+
+	if len(t.pendingAudit) >= auditQueueSize {
+		dropped := t.pendingAudit[0]
+		t.pendingAudit = t.pendingAudit[1:]
+		log.Printf("audit: queue full, dropping %s of %s by %s", dropped.Action, dropped.ResourceID, dropped.Actor.Subject)
+		if t.metrics != nil {
+			t.metrics.IncAuditDropped()
+		}
+	}
+
+	t.pendingAudit = append(t.pendingAudit, entry)
+	t.reportAuditBacklog()
+}
+
+// RetryAudit appends the queued audit entries in the order they were made,
+// stopping at the first that fails again.
+func (t *Tenancy) RetryAudit() error {
+	t.auditMu.Lock()
+	defer t.auditMu.Unlock()
+	defer t.reportAuditBacklog()
+
+	for len(t.pendingAudit) > 0 {
+		if err := t.audit.Append(t.pendingAudit[0]); err != nil {
+			return fmt.Errorf("%d audit entries pending: %w", len(t.pendingAudit), err)
+		}
+		t.pendingAudit = t.pendingAudit[1:]
+	}
+
+	return nil
+}
+
+// Start retries the queued audit entries every interval. Stopping it makes
+// one last attempt, so the entries are not lost on a graceful shutdown.
+func (t *Tenancy) Start(every time.Duration) func() {
+	stopCh := make(chan struct{})
+	ticker := time.NewTicker(every)
+
+	run := func() {
+		if err := t.RetryAudit(); err != nil {
+			log.Printf("audit retry job failed: %v", err)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		run()
+	}
+}
+
+func (t *Tenancy) reportAuditBacklog() {
+	if t.metrics != nil {
+		t.metrics.SetAuditBacklog(len(t.pendingAudit))
+	}
+}
+
+// snapshot is how a resource is kept in the audit log: as the API shows it.
+// It must be taken before the resource is changed.
+func snapshot(resource any) json.RawMessage {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// The repositories below only let a use case see the resources of one
+// tenant. A resource of another tenant is reported as not found, so its id
+// does not leak, and new resources are stamped with the tenant.
+//
+// Scoping an already scoped repository replaces its tenant, so a use case
+// can be moved from one tenant to another.
+
+func scopeDevices(repo domain.DeviceRepository, tenant domain.TenantID) domain.DeviceRepository {
+	if scoped, ok := repo.(tenantDeviceRepository); ok {
+		repo = scoped.DeviceRepository
+	}
+
+	return tenantDeviceRepository{DeviceRepository: repo, tenant: tenant}
+}
+
+func scopeSensors(repo domain.SensorRepository, tenant domain.TenantID) domain.SensorRepository {
+	if scoped, ok := repo.(tenantSensorRepository); ok {
+		repo = scoped.SensorRepository
+	}
+
+	return tenantSensorRepository{SensorRepository: repo, tenant: tenant}
+}
+
+func scopeReadings(repo domain.SensorReadingRepository, tenant domain.TenantID) domain.SensorReadingRepository {
+	if scoped, ok := repo.(tenantReadingRepository); ok {
+		repo = scoped.SensorReadingRepository
+	}
+
+	return tenantReadingRepository{SensorReadingRepository: repo, tenant: tenant}
+}
+
+func scopeGroups(repo domain.GroupRepository, tenant domain.TenantID) domain.GroupRepository {
+	if scoped, ok := repo.(tenantGroupRepository); ok {
+		repo = scoped.GroupRepository
+	}
+
+	return tenantGroupRepository{GroupRepository: repo, tenant: tenant}
+}
+
+func scopeLocations(repo domain.LocationRepository, tenant domain.TenantID) domain.LocationRepository {
+	if scoped, ok := repo.(tenantLocationRepository); ok {
+		repo = scoped.LocationRepository
+	}
+
+	return tenantLocationRepository{LocationRepository: repo, tenant: tenant}
+}
+
+func scopeAPIKeys(repo domain.APIKeyRepository, tenant domain.TenantID) domain.APIKeyRepository {
+	if scoped, ok := repo.(tenantAPIKeyRepository); ok {
+		repo = scoped.APIKeyRepository
+	}
+
+	return tenantAPIKeyRepository{APIKeyRepository: repo, tenant: tenant}
+}
+
+func scopeAudit(repo domain.AuditRepository, tenant domain.TenantID) domain.AuditRepository {
+	if scoped, ok := repo.(tenantAuditRepository); ok {
+		repo = scoped.AuditRepository
+	}
+
+	return tenantAuditRepository{AuditRepository: repo, tenant: tenant}
+}
+
+func scopePublisher(publisher domain.EventPublisher, tenant domain.TenantID) domain.EventPublisher {
+	if scoped, ok := publisher.(tenantPublisher); ok {
+		publisher = scoped.EventPublisher
+	}
+
+	return tenantPublisher{EventPublisher: publisher, tenant: tenant}
+}
+
+type tenantDeviceRepository struct {
+	domain.DeviceRepository
+	tenant domain.TenantID
+}
+
+func (r tenantDeviceRepository) Save(device *domain.Device) error {
+	device.TenantID = r.tenant
+
+	return r.DeviceRepository.Save(device)
+}
+
+func (r tenantDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
+	device, err := r.DeviceRepository.FindByID(id)
+	if err != nil {
+		return domain.Device{}, err
+	}
+
+	if device.TenantID != r.tenant {
+		return domain.Device{}, domain.ErrDeviceNotFound
+	}
+
+	return device, nil
+}
+
+func (r tenantDeviceRepository) FindByIDs(ids []domain.DeviceID) ([]domain.Device, error) {
+	devices, err := r.DeviceRepository.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []domain.Device
+	for _, device := range devices {
+		if device.TenantID == r.tenant {
+			owned = append(owned, device)
+		}
+	}
+
+	return owned, nil
+}
+
+func (r tenantDeviceRepository) FindAll() ([]domain.Device, error) {
+	return r.DeviceRepository.FindByTenant(r.tenant)
+}
+
+func (r tenantDeviceRepository) FindByTenant(tenant domain.TenantID) ([]domain.Device, error) {
+	if tenant != r.tenant {
+		return nil, nil
+	}
+
+	return r.DeviceRepository.FindByTenant(tenant)
+}
+
+func (r tenantDeviceRepository) Update(device *domain.Device) error {
+	if _, err := r.FindByID(device.ID); err != nil {
+		return err
+	}
+
+	return r.DeviceRepository.Update(device)
+}
+
+func (r tenantDeviceRepository) Delete(id domain.DeviceID) error {
+	if _, err := r.FindByID(id); err != nil {
+		return err
+	}
+
+	return r.DeviceRepository.Delete(id)
+}
+
+type tenantSensorRepository struct {
+	domain.SensorRepository
+	tenant domain.TenantID
+}
+
+func (r tenantSensorRepository) Save(sensor *domain.Sensor) error {
+	sensor.TenantID = r.tenant
+
+	return r.SensorRepository.Save(sensor)
+}
+
+func (r tenantSensorRepository) FindByID(id domain.SensorID) (*domain.Sensor, error) {
+	sensor, err := r.SensorRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sensor.TenantID != r.tenant {
+		return nil, domain.ErrSensorNotFound
+	}
+
+	return sensor, nil
+}
+
+func (r tenantSensorRepository) FindAll() ([]*domain.Sensor, error) {
+	return r.SensorRepository.FindByTenant(r.tenant)
+}
+
+func (r tenantSensorRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Sensor, error) {
+	if tenant != r.tenant {
+		return nil, nil
+	}
+
+	return r.SensorRepository.FindByTenant(tenant)
+}
+
+func (r tenantSensorRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	sensors, err := r.SensorRepository.FindByDeviceID(deviceID)
+
+	return r.owned(sensors), err
+}
+
+func (r tenantSensorRepository) FindByDeviceIDs(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	sensors, err := r.SensorRepository.FindByDeviceIDs(deviceIDs)
+
+	return r.owned(sensors), err
+}
+
+func (r tenantSensorRepository) owned(sensors []*domain.Sensor) []*domain.Sensor {
+	var owned []*domain.Sensor
+	for _, sensor := range sensors {
+		if sensor.TenantID == r.tenant {
+			owned = append(owned, sensor)
+		}
+	}
+
+	return owned
+}
+
+func (r tenantSensorRepository) Update(sensor *domain.Sensor) error {
+	if _, err := r.FindByID(sensor.ID); err != nil {
+		return err
+	}
+
+	return r.SensorRepository.Update(sensor)
+}
+
+func (r tenantSensorRepository) Delete(id domain.SensorID) error {
+	if _, err := r.FindByID(id); err != nil {
+		return err
+	}
+
+	return r.SensorRepository.Delete(id)
+}
+
+// tenantReadingRepository drops the readings of other tenants. All the
+// readings of a sensor belong to the tenant of the sensor, so filtering after
+// the limit does not cut a page short.
+type tenantReadingRepository struct {
+	domain.SensorReadingRepository
+	tenant domain.TenantID
+}
+
+func (r tenantReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
+	readings, err := r.SensorReadingRepository.FindBySensorID(sensorID, limit)
+
+	return r.owned(readings), err
+}
+
+func (r tenantReadingRepository) FindBySensorIDBetween(sensorID domain.SensorID, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	readings, err := r.SensorReadingRepository.FindBySensorIDBetween(sensorID, from, to, limit)
+
+	return r.owned(readings), err
+}
+
+func (r tenantReadingRepository) FindLatestBySensorIDs(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	readings, err := r.SensorReadingRepository.FindLatestBySensorIDs(sensorIDs)
+
+	return r.owned(readings), err
+}
+
+func (r tenantReadingRepository) owned(readings []domain.SensorReading) []domain.SensorReading {
+	var owned []domain.SensorReading
+	for _, reading := range readings {
+		if reading.TenantID == r.tenant {
+			owned = append(owned, reading)
+		}
+	}
+
+	return owned
+}
+
+type tenantGroupRepository struct {
+	domain.GroupRepository
+	tenant domain.TenantID
+}
+
+func (r tenantGroupRepository) Save(group *domain.DeviceGroup) error {
+	group.TenantID = r.tenant
+
+	return r.GroupRepository.Save(group)
+}
+
+func (r tenantGroupRepository) FindByID(id domain.GroupID) (*domain.DeviceGroup, error) {
+	group, err := r.GroupRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if group.TenantID != r.tenant {
+		return nil, domain.ErrGroupNotFound
+	}
+
+	return group, nil
+}
+
+func (r tenantGroupRepository) FindAll() ([]*domain.DeviceGroup, error) {
+	return r.GroupRepository.FindByTenant(r.tenant)
+}
+
+func (r tenantGroupRepository) FindByTenant(tenant domain.TenantID) ([]*domain.DeviceGroup, error) {
+	if tenant != r.tenant {
+		return nil, nil
+	}
+
+	return r.GroupRepository.FindByTenant(tenant)
+}
+
+func (r tenantGroupRepository) Update(group *domain.DeviceGroup) error {
+	if _, err := r.FindByID(group.ID); err != nil {
+		return err
+	}
+
+	return r.GroupRepository.Update(group)
+}
+
+func (r tenantGroupRepository) Delete(id domain.GroupID) error {
+	if _, err := r.FindByID(id); err != nil {
+		return err
+	}
+
+	return r.GroupRepository.Delete(id)
+}
+
+type tenantLocationRepository struct {
+	domain.LocationRepository
+	tenant domain.TenantID
+}
+
+func (r tenantLocationRepository) Save(location *domain.Location) error {
+	location.TenantID = r.tenant
+
+	return r.LocationRepository.Save(location)
+}
+
+func (r tenantLocationRepository) FindByID(id domain.LocationID) (*domain.Location, error) {
+	location, err := r.LocationRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if location.TenantID != r.tenant {
+		return nil, domain.ErrLocationNotFound
+	}
+
+	return location, nil
+}
+
+func (r tenantLocationRepository) FindAll() ([]*domain.Location, error) {
+	return r.LocationRepository.FindByTenant(r.tenant)
+}
+
+func (r tenantLocationRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Location, error) {
+	if tenant != r.tenant {
+		return nil, nil
+	}
+
+	return r.LocationRepository.FindByTenant(tenant)
+}
+
+func (r tenantLocationRepository) Update(location *domain.Location) error {
+	if _, err := r.FindByID(location.ID); err != nil {
+		return err
+	}
+
+	return r.LocationRepository.Update(location)
+}
+
+func (r tenantLocationRepository) Delete(id domain.LocationID) error {
+	if _, err := r.FindByID(id); err != nil {
+		return err
+	}
+
+	return r.LocationRepository.Delete(id)
+}
+
+type tenantAPIKeyRepository struct {
+	domain.APIKeyRepository
+	tenant domain.TenantID
+}
+
+func (r tenantAPIKeyRepository) Save(key *domain.APIKey) error {
+	key.TenantID = r.tenant
+
+	return r.APIKeyRepository.Save(key)
+}
+
+func (r tenantAPIKeyRepository) FindByID(id domain.APIKeyID) (*domain.APIKey, error) {
+	key, err := r.APIKeyRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.TenantID != r.tenant {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (r tenantAPIKeyRepository) FindAll() ([]*domain.APIKey, error) {
+	return r.APIKeyRepository.FindByTenant(r.tenant)
+}
+
+func (r tenantAPIKeyRepository) FindByTenant(tenant domain.TenantID) ([]*domain.APIKey, error) {
+	if tenant != r.tenant {
+		return nil, nil
+	}
+
+	return r.APIKeyRepository.FindByTenant(tenant)
+}
+
+func (r tenantAPIKeyRepository) Update(key *domain.APIKey) error {
+	if _, err := r.FindByID(key.ID); err != nil {
+		return err
+	}
+
+	return r.APIKeyRepository.Update(key)
+}
+
+type tenantAuditRepository struct {
+	domain.AuditRepository
+	tenant domain.TenantID
+}
+
+func (r tenantAuditRepository) Append(entry *domain.AuditEntry) error {
+	entry.TenantID = r.tenant
+
+	return r.AuditRepository.Append(entry)
+}
+
+func (r tenantAuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	filter.TenantID = r.tenant
+
+	return r.AuditRepository.Find(filter)
+}
+
+// tenantPublisher stamps the tenant on every event, which puts it on the
+// tenant's NATS subjects.
+type tenantPublisher struct {
+	domain.EventPublisher
+	tenant domain.TenantID
+}
+
+func (p tenantPublisher) Publish(event domain.IoTEvent) error {
+	return p.EventPublisher.Publish(event.WithTenant(p.tenant))
+}
@@ -0,0 +1,233 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"testing"
+	"time"
+)
+
+// tenancyFixture shares one set of repositories between the tenants, the way
+// a deployment does.
+type tenancyFixture struct {
+	tenancy   *Tenancy
+	devices   *DeviceUseCase
+	sensors   *SensorUseCase
+	readings  *ReadingsUsecase
+	publisher *MockEventPublisher
+}
+
+func newTenancyFixture(quotas domain.TenantQuotas) tenancyFixture {
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	publisher := NewMockEventPublisher()
+
+	return tenancyFixture{
+		tenancy:   NewTenancy(quotas, nil, nil),
+		devices:   newDeviceUseCase(deviceRepo, sensorRepo, NewMockSimulatorRepository(), publisher),
+		sensors:   NewSensorUseCase(sensorRepo, persistence.NewInMemorySensorConfigHistoryRepository(), deviceRepo, NewMockSimulatorRepository(), NewMockMetrics(), publisher),
+		readings:  NewReadingsUsecase(persistence.NewInMemorySensorReadingRepository(), persistence.NewInMemoryReadingRollupRepository(), sensorRepo, deviceRepo, NewMockReadingFeed(), publisher),
+		publisher: publisher,
+	}
+}
+
+func TestTenancy_Isolation(t *testing.T) {
+	f := newTenancyFixture(domain.TenantQuotas{})
+	acme := f.devices.ForTenant(f.tenancy.Scope("acme"))
+	globex := f.devices.ForTenant(f.tenancy.Scope("globex"))
+
+	device, err := acme.CreateDevice("gateway-1", "Gateway", "gateway", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if device.TenantID != "acme" {
+		t.Errorf("expected the device to belong to acme, got %q", device.TenantID)
+	}
+
+	if event := f.publisher.events[0]; event.TenantID != "acme" || domain.EventSubject(event) != "tenants.acme.device.created" {
+		t.Errorf("expected the event on the subjects of acme, got %+v", event)
+	}
+
+	if devices, _ := globex.GetAllDevices(); len(devices) != 0 {
+		t.Errorf("expected globex to see no devices, got %+v", devices)
+	}
+
+	if _, err := globex.GetDeviceByID("gateway-1"); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+
+	if err := globex.DeleteDevice("gateway-1"); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+
+	if devices, _ := acme.GetAllDevices(); len(devices) != 1 {
+		t.Errorf("expected acme to keep its device, got %+v", devices)
+	}
+
+	if devices, _ := f.devices.GetAllDevices(); len(devices) != 1 {
+		t.Errorf("expected the unscoped use case to see every tenant, got %+v", devices)
+	}
+
+	sensors := f.sensors.ForTenant(f.tenancy.Scope("globex"))
+	err = sensors.CreateSensor("temperature-1", "gateway-1", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000}, nil)
+	if !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected globex not to reach the device of acme, got %v", err)
+	}
+}
+
+func TestTenancy_BatchLookups(t *testing.T) {
+	f := newTenancyFixture(domain.TenantQuotas{})
+	acme := f.tenancy.Scope("acme")
+	globex := f.tenancy.Scope("globex")
+	now := time.Now().UTC()
+
+	if _, err := f.devices.ForTenant(acme).CreateDevice("gateway-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.sensors.ForTenant(acme).CreateSensor("temperature-1", "gateway-1", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, value := range []float64{20, 21} {
+		if _, err := f.readings.ForTenant(acme).IngestReading("", "temperature-1", value, now, now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		scope            TenantScope
+		expectedDevices  int
+		expectedSensors  int
+		expectedReadings int
+	}{
+		{name: "owner", scope: acme, expectedDevices: 1, expectedSensors: 1, expectedReadings: 1},
+		{name: "another tenant", scope: globex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := f.devices.ForTenant(tt.scope).GetDevicesByIDs([]domain.DeviceID{"gateway-1", "missing"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(devices) != tt.expectedDevices {
+				t.Errorf("expected %d devices, got %d", tt.expectedDevices, len(devices))
+			}
+
+			sensors, err := f.sensors.ForTenant(tt.scope).GetSensorsByDevices([]domain.DeviceID{"gateway-1", "missing"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sensors) != tt.expectedSensors {
+				t.Errorf("expected %d sensors, got %d", tt.expectedSensors, len(sensors))
+			}
+
+			readings, err := f.readings.ForTenant(tt.scope).GetLatestReadings([]domain.SensorID{"temperature-1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(readings) != tt.expectedReadings {
+				t.Fatalf("expected %d readings, got %d", tt.expectedReadings, len(readings))
+			}
+			if len(readings) == 1 && readings[0].Value != 21 {
+				t.Errorf("expected the latest reading, got %v", readings[0].Value)
+			}
+		})
+	}
+}
+
+func TestTenancy_Quotas(t *testing.T) {
+	f := newTenancyFixture(domain.TenantQuotas{
+		Default: domain.TenantQuota{MaxDevices: 1, MaxSensors: 1},
+		Tenants: map[domain.TenantID]domain.TenantQuota{"acme": {MaxDevices: 2}},
+	})
+	config := domain.SensorConfig{SamplingRateMs: 1000}
+
+	globex := f.tenancy.Scope("globex")
+	if _, err := f.devices.ForTenant(globex).CreateDevice("globex-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := f.devices.ForTenant(globex).CreateDevice("globex-2", "Gateway", "gateway", nil); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	if err := f.sensors.ForTenant(globex).CreateSensor("sensor-1", "globex-1", "Temperature", domain.Temperature, config, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := f.sensors.ForTenant(globex).CreateSensor("sensor-2", "globex-1", "Humidity", domain.Humidity, config, nil); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	acme := f.tenancy.Scope("acme")
+	for _, id := range []domain.DeviceID{"acme-1", "acme-2"} {
+		if _, err := f.devices.ForTenant(acme).CreateDevice(id, "Gateway", "gateway", nil); err != nil {
+			t.Errorf("expected the quota of acme to allow two devices, got %v", err)
+		}
+	}
+}
+
+func TestTenancy_IngestionRate(t *testing.T) {
+	f := newTenancyFixture(domain.TenantQuotas{
+		Tenants: map[domain.TenantID]domain.TenantQuota{"acme": {ReadingsPerMinute: 2}},
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	acme := f.tenancy.Scope("acme")
+
+	if _, err := f.devices.ForTenant(acme).CreateDevice("gateway-1", "Gateway", "gateway", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.sensors.ForTenant(acme).CreateSensor("sensor-1", "gateway-1", "Temperature", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000, Enabled: true}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		reading, err := f.readings.ForTenant(acme).IngestReading("gateway-1", "sensor-1", 21, time.Time{}, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.TenantID != "acme" {
+			t.Errorf("expected the reading to belong to acme, got %q", reading.TenantID)
+		}
+	}
+
+	if _, err := f.readings.ForTenant(acme).IngestReading("gateway-1", "sensor-1", 21, time.Time{}, now); !errors.Is(err, domain.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+
+	if _, err := f.readings.ForTenant(acme).IngestReading("gateway-1", "sensor-1", 21, time.Time{}, now.Add(time.Minute)); err != nil {
+		t.Errorf("expected a new minute to be allowed, got %v", err)
+	}
+
+	globex := f.readings.ForTenant(f.tenancy.Scope("globex"))
+	if _, err := globex.IngestReading("", "sensor-1", 21, time.Time{}, now); !errors.Is(err, domain.ErrSensorNotFound) {
+		t.Errorf("expected ErrSensorNotFound, got %v", err)
+	}
+
+	if readings, err := globex.GetPaginatedReadings("sensor-1", 0, 10, 10); err != nil || len(readings) != 0 {
+		t.Errorf("expected globex to see no readings, got %+v, %v", readings, err)
+	}
+}
+
+func TestCredentialUseCase_RegisterInTenant(t *testing.T) {
+	f := newCredentialFixture(t, nil)
+	tenancy := NewTenancy(domain.TenantQuotas{}, nil, nil)
+	now := time.Now()
+
+	claim, err := f.useCase.ForTenant(tenancy.Scope("acme")).CreateClaimToken("sensor_node", 1, time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	device, issued, err := f.useCase.ForTenant(tenancy.Scope(domain.DefaultTenant)).Register(claim.Token, "Node", domain.CredentialHMAC, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if device.TenantID != "acme" || issued.DeviceID != device.ID {
+		t.Errorf("expected the device to join acme, got %+v", device)
+	}
+}
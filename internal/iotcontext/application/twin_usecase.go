@@ -0,0 +1,144 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+)
+
+// twinSaveAttempts bounds the retries when another writer saved the twin
+// between our read and our write.
+const twinSaveAttempts = 3
+
+type TwinUseCase struct {
+	deviceRepo     domain.DeviceRepository
+	twinRepo       domain.TwinRepository
+	eventPublisher domain.EventPublisher
+	scope          TenantScope
+}
+
+func NewTwinUseCase(deviceRepo domain.DeviceRepository, twinRepo domain.TwinRepository, publisher domain.EventPublisher) *TwinUseCase {
+	return &TwinUseCase{
+		deviceRepo:     deviceRepo,
+		twinRepo:       twinRepo,
+		eventPublisher: publisher,
+	}
+}
+
+// ForTenant returns a copy of the use case that only reaches the twins of
+// the devices of the tenant. The zero scope returns uc itself.
+func (uc *TwinUseCase) ForTenant(scope TenantScope) *TwinUseCase {
+	if scope.Tenant == "" {
+		return uc
+	}
+
+	scoped := *uc
+	scoped.scope = scope
+	scoped.deviceRepo = scopeDevices(uc.deviceRepo, scope.Tenant)
+	scoped.eventPublisher = scopePublisher(uc.eventPublisher, scope.Tenant)
+
+	return &scoped
+}
+
+func (uc *TwinUseCase) GetTwin(deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	if _, err := uc.findDevice(deviceID); err != nil {
+		return nil, err
+	}
+
+	return uc.loadTwin(deviceID)
+}
+
+// UpdateDesired is used by the API. Whatever the device still has to apply
+// is published as a twin.delta event.
+func (uc *TwinUseCase) UpdateDesired(deviceID domain.DeviceID, patch domain.TwinProperties, expectedVersion int64) (*domain.DeviceTwin, error) {
+	device, err := uc.findDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == domain.DeviceDecommissioned {
+		return nil, domain.ErrDeviceDecommissioned
+	}
+
+	var before json.RawMessage
+	twin, err := uc.update(deviceID, func(twin *domain.DeviceTwin) error {
+		before = snapshot(twin)
+		return twin.UpdateDesired(patch, expectedVersion)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.scope.record(domain.AuditTwinDesire, string(twin.DeviceID), before, snapshot(twin))
+
+	delta := twin.Delta()
+	if len(delta) == 0 {
+		return twin, nil
+	}
+
+	event := &domain.TwinDeltaEvent{
+		DeviceID:       twin.DeviceID,
+		DesiredVersion: twin.DesiredVersion,
+		Delta:          delta,
+	}
+
+	return twin, uc.eventPublisher.Publish(event.ToDomainEvent())
+}
+
+// UpdateReported is used by the device to acknowledge the configuration it
+// has applied. What a device reports is not audited.
+func (uc *TwinUseCase) UpdateReported(deviceID domain.DeviceID, patch domain.TwinProperties, expectedVersion int64) (*domain.DeviceTwin, error) {
+	if _, err := uc.findDevice(deviceID); err != nil {
+		return nil, err
+	}
+
+	return uc.update(deviceID, func(twin *domain.DeviceTwin) error {
+		return twin.UpdateReported(patch, expectedVersion)
+	})
+}
+
+func (uc *TwinUseCase) update(deviceID domain.DeviceID, mutate func(twin *domain.DeviceTwin) error) (*domain.DeviceTwin, error) {
+	var err error
+	for attempt := 0; attempt < twinSaveAttempts; attempt++ {
+		var twin *domain.DeviceTwin
+		twin, err = uc.loadTwin(deviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		previous := twin.Version
+		if err := mutate(twin); err != nil {
+			return nil, err
+		}
+
+		err = uc.twinRepo.Save(twin, previous)
+		if err == nil {
+			return twin, nil
+		}
+		if !errors.Is(err, domain.ErrTwinVersionConflict) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (uc *TwinUseCase) loadTwin(deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	twin, err := uc.twinRepo.FindByDeviceID(deviceID)
+	if errors.Is(err, domain.ErrTwinNotFound) {
+		return domain.NewDeviceTwin(deviceID), nil
+	}
+
+	return twin, err
+}
+
+func (uc *TwinUseCase) findDevice(deviceID domain.DeviceID) (domain.Device, error) {
+	device, err := uc.deviceRepo.FindByID(deviceID)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	if device.ID == "" {
+		return domain.Device{}, domain.ErrDeviceNotFound
+	}
+
+	return device, nil
+}
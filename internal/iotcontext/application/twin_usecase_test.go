@@ -0,0 +1,104 @@
+package application
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"testing"
+)
+
+func newTwinFixture(t *testing.T) (*TwinUseCase, *FaultyTwinRepository, *MockEventPublisher) {
+	t.Helper()
+
+	twinRepo := NewFaultyTwinRepository()
+	publisher := NewMockEventPublisher()
+
+	return NewTwinUseCase(newGatewayRepository(t, "device-123"), twinRepo, publisher), twinRepo, publisher
+}
+
+func TestTwinUseCase_UpdateDesired(t *testing.T) {
+	tests := []struct {
+		name        string
+		deviceID    domain.DeviceID
+		version     int64
+		conflicts   int
+		expectError error
+	}{
+		{name: "first version", deviceID: "device-123", version: 0},
+		{name: "blind write", deviceID: "device-123", version: domain.AnyVersion},
+		{name: "retries concurrent writes", deviceID: "device-123", version: domain.AnyVersion, conflicts: 2},
+		{name: "gives up after repeated conflicts", deviceID: "device-123", version: domain.AnyVersion, conflicts: twinSaveAttempts, expectError: domain.ErrTwinVersionConflict},
+		{name: "stale version", deviceID: "device-123", version: 4, expectError: domain.ErrTwinVersionConflict},
+		{name: "unknown device", deviceID: "device-404", version: 0, expectError: domain.ErrDeviceNotFound},
+		{name: "decommissioned device", deviceID: "device-456", version: 0, expectError: domain.ErrDeviceDecommissioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, twinRepo, publisher := newTwinFixture(t)
+			twinRepo.conflicts = tt.conflicts
+
+			twin, err := useCase.UpdateDesired(tt.deviceID, domain.TwinProperties{"sampling_rate_ms": 500}, tt.version)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				if len(publisher.GetEvents()) != 0 {
+					t.Errorf("expected no events, got %d", len(publisher.GetEvents()))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if twin.DesiredVersion != 1 {
+				t.Errorf("expected desired version 1, got %d", twin.DesiredVersion)
+			}
+
+			events := publisher.GetEvents()
+			if len(events) != 1 || events[0].Type != "twin.delta" {
+				t.Fatalf("expected a twin.delta event, got %+v", events)
+			}
+
+			if payload := events[0].Payload.(*domain.TwinDeltaEvent); payload.Delta["sampling_rate_ms"] != float64(500) {
+				t.Errorf("unexpected delta %v", payload.Delta)
+			}
+		})
+	}
+}
+
+func TestTwinUseCase_ConvergesWhenReported(t *testing.T) {
+	useCase, _, publisher := newTwinFixture(t)
+
+	if _, err := useCase.UpdateDesired("device-123", domain.TwinProperties{"sampling_rate_ms": 500}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	twin, err := useCase.UpdateReported("device-123", domain.TwinProperties{"sampling_rate_ms": 500, "firmware": "1.2.0"}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(twin.Delta()) != 0 {
+		t.Errorf("expected an empty delta, got %v", twin.Delta())
+	}
+
+	if _, err := useCase.UpdateDesired("device-123", domain.TwinProperties{"firmware": "1.2.0"}, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(publisher.GetEvents()) != 1 {
+		t.Errorf("expected no twin.delta once the device already matches, got %d events", len(publisher.GetEvents()))
+	}
+
+	twin, err = useCase.GetTwin("device-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if twin.Version != 3 || twin.DesiredVersion != 2 || twin.ReportedVersion != 1 {
+		t.Errorf("unexpected versions %+v", twin)
+	}
+}
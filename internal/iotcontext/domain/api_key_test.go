@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		id            APIKeyID
+		keyName       string
+		role          Role
+		secret        string
+		ttl           time.Duration
+		expectedError error
+	}{
+		{name: "valid", id: "key-1", keyName: "ingest", role: RoleOperator, secret: "s3cret"},
+		{name: "with ttl", id: "key-1", keyName: "ingest", role: RoleViewer, secret: "s3cret", ttl: time.Hour},
+		{name: "missing name", id: "key-1", role: RoleViewer, secret: "s3cret", expectedError: ErrInvalidAPIKey},
+		{name: "unknown role", id: "key-1", keyName: "ingest", role: "root", secret: "s3cret", expectedError: ErrInvalidRole},
+		{name: "missing secret", id: "key-1", keyName: "ingest", role: RoleViewer, expectedError: ErrInvalidAPIKey},
+		{name: "negative ttl", id: "key-1", keyName: "ingest", role: RoleViewer, secret: "s3cret", ttl: -time.Hour, expectedError: ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewAPIKey(tt.id, tt.keyName, tt.role, tt.secret, tt.ttl, now)
+
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("expected %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if key.SecretHash == tt.secret || !key.Verify(tt.secret) || key.Verify("guess") {
+				t.Errorf("expected only the hash of the secret to be kept and verified")
+			}
+
+			if (key.ExpiresAt != nil) != (tt.ttl > 0) {
+				t.Errorf("expected an expiry only with a ttl, got %v", key.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAPIKey_Lifecycle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key, _ := NewAPIKey("key-1", "ingest", RoleOperator, "s3cret", time.Hour, now)
+
+	if !key.IsActive(now) || key.IsActive(now.Add(time.Hour)) {
+		t.Errorf("expected the key to be active until it expires")
+	}
+
+	if err := key.Revoke(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if key.IsActive(now) {
+		t.Errorf("expected a revoked key to be inactive")
+	}
+
+	if err := key.Revoke(now); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("expected ErrAPIKeyRevoked, got %v", err)
+	}
+
+	if principal := key.Principal(); principal.Role != RoleOperator || principal.TenantID != DefaultTenant {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	id, secret, err := ParseAPIKey(FormatAPIKey("key-1", "s3cret"))
+	if err != nil || id != "key-1" || secret != "s3cret" {
+		t.Errorf("expected the key to round trip, got %q, %q, %v", id, secret, err)
+	}
+
+	for _, value := range []string{"", "key-1", ".s3cret", "key-1."} {
+		if _, _, err := ParseAPIKey(value); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("expected ErrInvalidAPIKey for %q, got %v", value, err)
+		}
+	}
+}
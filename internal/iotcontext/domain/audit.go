@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AuditEntryID string
+
+// AuditAction names a change: the kind of resource changed, a dot and what
+// was done to it.
+type AuditAction string
+
+const (
+	AuditDeviceCreate     AuditAction = "device.create"
+	AuditDeviceUpdate     AuditAction = "device.update"
+	AuditDeviceRename     AuditAction = "device.rename"
+	AuditDeviceRelabel    AuditAction = "device.relabel"
+	AuditDeviceStatus     AuditAction = "device.status"
+	AuditDeviceLocate     AuditAction = "device.locate"
+	AuditDeviceDelete     AuditAction = "device.delete"
+	AuditSensorCreate     AuditAction = "sensor.create"
+	AuditSensorUpdate     AuditAction = "sensor.update"
+	AuditSensorConfigure  AuditAction = "sensor.configure"
+	AuditSensorRelabel    AuditAction = "sensor.relabel"
+	AuditSensorRename     AuditAction = "sensor.rename"
+	AuditSensorMove       AuditAction = "sensor.move"
+	AuditSensorDelete     AuditAction = "sensor.delete"
+	AuditSensorControl    AuditAction = "sensor.control"
+	AuditSensorRollback   AuditAction = "sensor.rollback"
+	AuditGroupCreate      AuditAction = "group.create"
+	AuditGroupUpdate      AuditAction = "group.update"
+	AuditGroupDelete      AuditAction = "group.delete"
+	AuditLocationCreate   AuditAction = "location.create"
+	AuditLocationUpdate   AuditAction = "location.update"
+	AuditLocationDelete   AuditAction = "location.delete"
+	AuditTwinDesire       AuditAction = "twin.desire"
+	AuditCommandCreate    AuditAction = "command.create"
+	AuditCredentialIssue  AuditAction = "credential.issue"
+	AuditCredentialRotate AuditAction = "credential.rotate"
+	AuditCredentialRevoke AuditAction = "credential.revoke"
+	AuditClaimTokenCreate AuditAction = "claim_token.create"
+	AuditAPIKeyCreate     AuditAction = "api_key.create"
+	AuditAPIKeyRevoke     AuditAction = "api_key.revoke"
+	AuditFirmwareUpload   AuditAction = "firmware.upload"
+	AuditCampaignCreate   AuditAction = "campaign.create"
+	AuditCampaignPause    AuditAction = "campaign.pause"
+	AuditCampaignResume   AuditAction = "campaign.resume"
+	AuditCampaignAbort    AuditAction = "campaign.abort"
+)
+
+// Resource is the kind of resource the action changes.
+func (a AuditAction) Resource() string {
+	resource, _, _ := strings.Cut(string(a), ".")
+	return resource
+}
+
+// AuditActor is who made a change and where the request came from.
+type AuditActor struct {
+	Subject   string `json:"subject"`
+	Role      Role   `json:"role,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	SourceIP  string `json:"source_ip,omitempty"`
+}
+
+// AuditChange is one field that differs between the snapshots of an entry.
+// Nested fields are named by their path, like "config.max_threshold".
+type AuditChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditEntry records one change made through the API. Entries are only ever
+// appended: nothing updates or deletes them. Before is absent for what was
+// created and After for what was deleted.
+type AuditEntry struct {
+	ID           AuditEntryID    `json:"id"`
+	TenantID     TenantID        `json:"tenant_id"`
+	Actor        AuditActor      `json:"actor"`
+	Action       AuditAction     `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	Changes      []AuditChange   `json:"changes"`
+	At           time.Time       `json:"at"`
+}
+
+// NewAuditEntry records a change between the JSON snapshots of a resource,
+// either of which may be nil.
+func NewAuditEntry(id AuditEntryID, actor AuditActor, action AuditAction, resourceID string, before, after json.RawMessage, at time.Time) (*AuditEntry, error) {
+	if id == "" {
+		return nil, errors.New("audit entry id empty")
+	}
+
+	if action == "" || action.Resource() == string(action) {
+		return nil, fmt.Errorf("%w: action %q", ErrInvalidAuditEntry, action)
+	}
+
+	if actor.Subject == "" {
+		return nil, fmt.Errorf("%w: actor empty", ErrInvalidAuditEntry)
+	}
+
+	changes, err := DiffSnapshots(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEntry{
+		ID:           id,
+		TenantID:     DefaultTenant,
+		Actor:        actor,
+		Action:       action,
+		ResourceType: action.Resource(),
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+		Changes:      changes,
+		At:           at.UTC(),
+	}, nil
+}
+
+// DiffSnapshots lists the fields that differ between two JSON snapshots,
+// ordered by field. Objects are compared field by field; any other value,
+// lists included, is compared as a whole.
+func DiffSnapshots(before, after json.RawMessage) ([]AuditChange, error) {
+	fields := make(map[string][2]json.RawMessage)
+	if err := flattenSnapshot("", before, 0, fields); err != nil {
+		return nil, err
+	}
+	if err := flattenSnapshot("", after, 1, fields); err != nil {
+		return nil, err
+	}
+
+	changes := make([]AuditChange, 0)
+	for field, values := range fields {
+		if bytes.Equal(values[0], values[1]) {
+			continue
+		}
+
+		changes = append(changes, AuditChange{Field: field, Before: values[0], After: values[1]})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes, nil
+}
+
+func flattenSnapshot(prefix string, snapshot json.RawMessage, side int, fields map[string][2]json.RawMessage) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot, &object); err != nil || object == nil {
+		// Not an object: a leaf, compacted so formatting is not a change.
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, snapshot); err != nil {
+			return fmt.Errorf("%w: snapshot is not JSON", ErrInvalidAuditEntry)
+		}
+
+		values := fields[prefix]
+		values[side] = compact.Bytes()
+		fields[prefix] = values
+		return nil
+	}
+
+	for key, value := range object {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+
+		if err := flattenSnapshot(field, value, side, fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AuditFilter narrows a query of the audit log. Empty fields match every
+// entry; From is inclusive and To exclusive.
+type AuditFilter struct {
+	TenantID     TenantID
+	Actor        string
+	Action       AuditAction
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	From         time.Time
+	To           time.Time
+	Limit        int
+}
+
+// Matches reports whether the entry passes the filter, Limit aside.
+func (f AuditFilter) Matches(entry *AuditEntry) bool {
+	switch {
+	case f.TenantID != "" && entry.TenantID != f.TenantID:
+		return false
+	case f.Actor != "" && entry.Actor.Subject != f.Actor:
+		return false
+	case f.Action != "" && entry.Action != f.Action:
+		return false
+	case f.ResourceType != "" && entry.ResourceType != f.ResourceType:
+		return false
+	case f.ResourceID != "" && entry.ResourceID != f.ResourceID:
+		return false
+	case f.RequestID != "" && entry.Actor.RequestID != f.RequestID:
+		return false
+	case !f.From.IsZero() && entry.At.Before(f.From):
+		return false
+	case !f.To.IsZero() && !entry.At.Before(f.To):
+		return false
+	default:
+		return true
+	}
+}
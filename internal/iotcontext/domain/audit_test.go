@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected []AuditChange
+	}{
+		{
+			name:   "changed nested field",
+			before: `{"name":"Probe","config":{"thresholds":{"max":30},"enabled":true}}`,
+			after:  `{"name":"Probe","config":{"thresholds":{"max":35},"enabled":true}}`,
+			expected: []AuditChange{
+				{Field: "config.thresholds.max", Before: []byte("30"), After: []byte("35")},
+			},
+		},
+		{
+			name:  "created",
+			after: `{"id":"d1","labels":{"zone":"a"}}`,
+			expected: []AuditChange{
+				{Field: "id", After: []byte(`"d1"`)},
+				{Field: "labels.zone", After: []byte(`"a"`)},
+			},
+		},
+		{
+			name:   "deleted",
+			before: `{"id":"d1"}`,
+			expected: []AuditChange{
+				{Field: "id", Before: []byte(`"d1"`)},
+			},
+		},
+		{
+			name:   "lists compared whole",
+			before: `{"device_ids":["a","b"]}`,
+			after:  `{"device_ids":["a", "c"]}`,
+			expected: []AuditChange{
+				{Field: "device_ids", Before: []byte(`["a","b"]`), After: []byte(`["a","c"]`)},
+			},
+		},
+		{
+			name:     "formatting only",
+			before:   `{"a": 1, "b": [1, 2]}`,
+			after:    `{"b":[1,2],"a":1}`,
+			expected: []AuditChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after []byte
+			if tt.before != "" {
+				before = []byte(tt.before)
+			}
+			if tt.after != "" {
+				after = []byte(tt.after)
+			}
+
+			changes, err := DiffSnapshots(before, after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(changes) != len(tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, changes)
+			}
+
+			for i, change := range changes {
+				expected := tt.expected[i]
+				if change.Field != expected.Field || string(change.Before) != string(expected.Before) || string(change.After) != string(expected.After) {
+					t.Errorf("expected %s: %s -> %s, got %s: %s -> %s", expected.Field, expected.Before, expected.After, change.Field, change.Before, change.After)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	actor := AuditActor{Subject: "alice", Role: RoleOperator}
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		actor       AuditActor
+		action      AuditAction
+		after       []byte
+		expectError bool
+	}{
+		{name: "valid", actor: actor, action: AuditSensorConfigure, after: []byte(`{"enabled":true}`)},
+		{name: "no actor", action: AuditSensorConfigure, expectError: true},
+		{name: "action without resource", actor: actor, action: "configure", expectError: true},
+		{name: "snapshot not JSON", actor: actor, action: AuditSensorConfigure, after: []byte(`{`), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := NewAuditEntry("e1", tt.actor, tt.action, "s1", nil, tt.after, now)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidAuditEntry) {
+					t.Errorf("expected ErrInvalidAuditEntry, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.ResourceType != "sensor" || entry.TenantID != DefaultTenant || len(entry.Changes) != 1 {
+				t.Errorf("unexpected entry %+v", entry)
+			}
+		})
+	}
+}
+
+func TestAuditFilter_Matches(t *testing.T) {
+	now := time.Now()
+	entry, _ := NewAuditEntry("e1", AuditActor{Subject: "alice", RequestID: "req-1"}, AuditDeviceRename, "d1", nil, nil, now)
+
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected bool
+	}{
+		{name: "empty", expected: true},
+		{name: "actor", filter: AuditFilter{Actor: "alice"}, expected: true},
+		{name: "other actor", filter: AuditFilter{Actor: "bob"}},
+		{name: "resource", filter: AuditFilter{ResourceType: "device", ResourceID: "d1"}, expected: true},
+		{name: "request", filter: AuditFilter{RequestID: "req-2"}},
+		{name: "other tenant", filter: AuditFilter{TenantID: "acme"}},
+		{name: "from is inclusive", filter: AuditFilter{From: entry.At}, expected: true},
+		{name: "to is exclusive", filter: AuditFilter{To: entry.At}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(entry); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type CommandID string
+
+type CommandStatus string
+
+const (
+	CommandPending   CommandStatus = "pending"
+	CommandDelivered CommandStatus = "delivered"
+	CommandSucceeded CommandStatus = "succeeded"
+	CommandFailed    CommandStatus = "failed"
+	CommandExpired   CommandStatus = "expired"
+)
+
+// Commands understood by the simulator. Real devices may accept others.
+const (
+	CommandReboot          = "reboot"
+	CommandRecalibrate     = "recalibrate"
+	CommandSetSamplingRate = "set_sampling_rate"
+	CommandUpdateFirmware  = "update_firmware"
+)
+
+type Command struct {
+	ID          CommandID              `json:"id"`
+	DeviceID    DeviceID               `json:"device_id"`
+	Name        string                 `json:"name"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Status      CommandStatus          `json:"status"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	DeliveredAt *time.Time             `json:"delivered_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+func NewCommand(id CommandID, deviceID DeviceID, name string, params map[string]interface{}, ttl time.Duration, now time.Time) (*Command, error) {
+	if id == "" {
+		return nil, errors.New("command id empty")
+	}
+
+	if deviceID == "" {
+		return nil, errors.New("device id empty")
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: name empty", ErrInvalidCommand)
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: ttl must be positive", ErrInvalidCommand)
+	}
+
+	now = now.UTC()
+
+	return &Command{
+		ID:        id,
+		DeviceID:  deviceID,
+		Name:      name,
+		Params:    params,
+		Status:    CommandPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		UpdatedAt: now,
+	}, nil
+}
+
+func (c *Command) IsFinal() bool {
+	return c.Status == CommandSucceeded || c.Status == CommandFailed || c.Status == CommandExpired
+}
+
+func (c *Command) IsExpiredAt(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *Command) MarkDelivered(now time.Time) error {
+	if c.IsFinal() {
+		return fmt.Errorf("%w: %s", ErrCommandFinished, c.Status)
+	}
+
+	if c.Status == CommandDelivered {
+		return nil
+	}
+
+	now = now.UTC()
+	c.Status = CommandDelivered
+	c.DeliveredAt = &now
+	c.UpdatedAt = now
+
+	return nil
+}
+
+// Complete records the outcome reported by the device. A result may arrive
+// without a previous ack, which then counts as delivered too.
+func (c *Command) Complete(succeeded bool, result map[string]interface{}, message string, now time.Time) error {
+	if c.IsFinal() {
+		return fmt.Errorf("%w: %s", ErrCommandFinished, c.Status)
+	}
+
+	now = now.UTC()
+	if c.DeliveredAt == nil {
+		c.DeliveredAt = &now
+	}
+
+	c.Status = CommandFailed
+	if succeeded {
+		c.Status = CommandSucceeded
+	}
+	c.Result = result
+	c.Error = message
+	c.CompletedAt = &now
+	c.UpdatedAt = now
+
+	return nil
+}
+
+func (c *Command) Expire(now time.Time) error {
+	if c.IsFinal() {
+		return fmt.Errorf("%w: %s", ErrCommandFinished, c.Status)
+	}
+
+	now = now.UTC()
+	c.Status = CommandExpired
+	c.CompletedAt = &now
+	c.UpdatedAt = now
+
+	return nil
+}
+
+type CommandReplyStatus string
+
+const (
+	CommandReplyAck       CommandReplyStatus = "ack"
+	CommandReplySucceeded CommandReplyStatus = "succeeded"
+	CommandReplyFailed    CommandReplyStatus = "failed"
+)
+
+// CommandReply is what a device sends back for a command: an ack when it
+// receives it and a result once it has been executed.
+type CommandReply struct {
+	CommandID CommandID              `json:"command_id"`
+	DeviceID  DeviceID               `json:"device_id"`
+	Status    CommandReplyStatus     `json:"status"`
+	Result    map[string]interface{} `json:"result,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// CommandChannel delivers commands to devices.
+type CommandChannel interface {
+	Dispatch(command *Command) error
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCommand(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		commandName string
+		ttl         time.Duration
+		expectError bool
+	}{
+		{name: "valid command", commandName: CommandReboot, ttl: time.Minute},
+		{name: "missing name", commandName: "", ttl: time.Minute, expectError: true},
+		{name: "non positive ttl", commandName: CommandReboot, ttl: 0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := NewCommand("command-1", "device-1", tt.commandName, nil, tt.ttl, now)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidCommand) {
+					t.Errorf("expected ErrInvalidCommand, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if command.Status != CommandPending || !command.ExpiresAt.Equal(now.Add(tt.ttl)) {
+				t.Errorf("unexpected command %+v", command)
+			}
+		})
+	}
+}
+
+func TestCommand_Transitions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		apply    func(c *Command) error
+		expected CommandStatus
+	}{
+		{name: "ack", apply: func(c *Command) error { return c.MarkDelivered(now) }, expected: CommandDelivered},
+		{name: "result without ack", apply: func(c *Command) error { return c.Complete(true, nil, "", now) }, expected: CommandSucceeded},
+		{name: "ack then failure", apply: func(c *Command) error {
+			_ = c.MarkDelivered(now)
+			return c.Complete(false, nil, "sensor busy", now)
+		}, expected: CommandFailed},
+		{name: "expire", apply: func(c *Command) error { return c.Expire(now) }, expected: CommandExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, _ := NewCommand("command-1", "device-1", CommandReboot, nil, time.Minute, now)
+
+			if err := tt.apply(command); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if command.Status != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, command.Status)
+			}
+
+			if command.IsFinal() {
+				if command.DeliveredAt == nil && tt.expected != CommandExpired {
+					t.Errorf("expected a finished command to be delivered")
+				}
+				if err := command.MarkDelivered(now); !errors.Is(err, ErrCommandFinished) {
+					t.Errorf("expected ErrCommandFinished, got %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type CredentialID string
+
+type CredentialType string
+
+const (
+	// CredentialHMAC is a shared secret the device signs its requests with.
+	CredentialHMAC CredentialType = "hmac"
+	// CredentialX509 is a client certificate issued by the local CA and
+	// presented during the TLS handshake.
+	CredentialX509 CredentialType = "x509"
+)
+
+// MaxSignatureSkew is how far the timestamp of a signed request may be from
+// the server clock. It bounds how long a captured request can be replayed.
+const MaxSignatureSkew = 5 * time.Minute
+
+func ParseCredentialType(value string) (CredentialType, error) {
+	typ := CredentialType(value)
+	switch typ {
+	case CredentialHMAC, CredentialX509:
+		return typ, nil
+	case "":
+		return CredentialHMAC, nil
+	default:
+		return "", fmt.Errorf("%w: unknown type %q", ErrInvalidCredential, value)
+	}
+}
+
+// Credential is what a device proves its identity with. The secret of an HMAC
+// credential is never serialized: it is handed out once, when issued.
+type Credential struct {
+	ID          CredentialID   `json:"id"`
+	DeviceID    DeviceID       `json:"device_id"`
+	Type        CredentialType `json:"type"`
+	Secret      string         `json:"-"`
+	Fingerprint string         `json:"fingerprint,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time     `json:"revoked_at,omitempty"`
+}
+
+func NewHMACCredential(id CredentialID, deviceID DeviceID, secret string, now time.Time) (*Credential, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("%w: secret empty", ErrInvalidCredential)
+	}
+
+	return newCredential(id, deviceID, CredentialHMAC, secret, "", nil, now)
+}
+
+// NewX509Credential records a certificate by the hex sha256 of its DER
+// encoding, which is what the TLS handshake hands back.
+func NewX509Credential(id CredentialID, deviceID DeviceID, fingerprint string, notAfter time.Time, now time.Time) (*Credential, error) {
+	if len(fingerprint) != sha256.Size*2 {
+		return nil, fmt.Errorf("%w: fingerprint must be a hex sha256", ErrInvalidCredential)
+	}
+
+	return newCredential(id, deviceID, CredentialX509, "", fingerprint, &notAfter, now)
+}
+
+func newCredential(id CredentialID, deviceID DeviceID, typ CredentialType, secret string, fingerprint string, expiresAt *time.Time, now time.Time) (*Credential, error) {
+	if id == "" {
+		return nil, errors.New("credential id empty")
+	}
+
+	if deviceID == "" {
+		return nil, errors.New("device id empty")
+	}
+
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	return &Credential{
+		ID:          id,
+		DeviceID:    deviceID,
+		Type:        typ,
+		Secret:      secret,
+		Fingerprint: fingerprint,
+		CreatedAt:   now.UTC(),
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (c *Credential) IsActive(now time.Time) bool {
+	if c.RevokedAt != nil {
+		return false
+	}
+
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
+
+// Retire lets the credential keep working until at, so a device has time to
+// switch to its rotated credential. It never extends an earlier expiry.
+func (c *Credential) Retire(at time.Time) error {
+	if c.RevokedAt != nil {
+		return ErrCredentialRevoked
+	}
+
+	at = at.UTC()
+	if c.ExpiresAt == nil || at.Before(*c.ExpiresAt) {
+		c.ExpiresAt = &at
+	}
+
+	return nil
+}
+
+func (c *Credential) Revoke(now time.Time) error {
+	if c.RevokedAt != nil {
+		return ErrCredentialRevoked
+	}
+
+	now = now.UTC()
+	c.RevokedAt = &now
+
+	return nil
+}
+
+// VerifySignature checks a request signed with SignRequest, including that
+// its timestamp is within MaxSignatureSkew of now.
+func (c *Credential) VerifySignature(signature string, method string, target string, timestamp time.Time, body []byte, now time.Time) bool {
+	if c.Type != CredentialHMAC || c.Secret == "" {
+		return false
+	}
+
+	skew := now.Sub(timestamp)
+	if skew > MaxSignatureSkew || skew < -MaxSignatureSkew {
+		return false
+	}
+
+	expected := SignRequest(c.Secret, method, target, timestamp, body)
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SignRequest returns the hex HMAC-SHA256 a device sends along with a
+// request. The signed string is the method, the request target (path and
+// query), the unix timestamp and the hex sha256 of the body, one per line.
+func SignRequest(secret string, method string, target string, timestamp time.Time, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + target + "\n" + strconv.FormatInt(timestamp.Unix(), 10) + "\n" + hex.EncodeToString(bodyHash[:])))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IssuedCertificate is a client certificate fresh from the CA, with the
+// private key the device will use it with.
+type IssuedCertificate struct {
+	CertificatePEM string
+	PrivateKeyPEM  string
+	Fingerprint    string
+	NotAfter       time.Time
+}
+
+// ClaimToken lets devices register themselves. Only the sha256 of the token
+// is kept, so a leaked database does not leak usable tokens.
+type ClaimToken struct {
+	ID         string    `json:"id"`
+	TenantID   TenantID  `json:"tenant_id"`
+	TokenHash  string    `json:"-"`
+	DeviceType string    `json:"device_type"`
+	MaxUses    int       `json:"max_uses"`
+	Uses       int       `json:"uses"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewClaimToken(id string, token string, deviceType string, maxUses int, ttl time.Duration, now time.Time) (*ClaimToken, error) {
+	if id == "" {
+		return nil, errors.New("claim token id empty")
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("%w: token empty", ErrInvalidClaimToken)
+	}
+
+	if deviceType == "" {
+		return nil, fmt.Errorf("%w: device type empty", ErrInvalidClaimToken)
+	}
+
+	if maxUses <= 0 {
+		return nil, fmt.Errorf("%w: max uses must be positive", ErrInvalidClaimToken)
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: ttl must be positive", ErrInvalidClaimToken)
+	}
+
+	now = now.UTC()
+
+	return &ClaimToken{
+		ID:         id,
+		TenantID:   DefaultTenant,
+		TokenHash:  HashClaimToken(token),
+		DeviceType: deviceType,
+		MaxUses:    maxUses,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+	}, nil
+}
+
+func HashClaimToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *ClaimToken) IsRedeemable(now time.Time) bool {
+	return t.Uses < t.MaxUses && now.Before(t.ExpiresAt)
+}
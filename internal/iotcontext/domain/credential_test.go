@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCredential_VerifySignature(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	credential, err := NewHMACCredential("credential-1", "device-1", "s3cret", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := []byte(`{"value":21.5}`)
+	signature := SignRequest("s3cret", "POST", "/sensors/sensor-1/readings", now, body)
+
+	tests := []struct {
+		name      string
+		signature string
+		method    string
+		target    string
+		timestamp time.Time
+		body      []byte
+		expected  bool
+	}{
+		{name: "valid", signature: signature, method: "POST", target: "/sensors/sensor-1/readings", timestamp: now, body: body, expected: true},
+		{name: "other method", signature: signature, method: "PUT", target: "/sensors/sensor-1/readings", timestamp: now, body: body},
+		{name: "other target", signature: signature, method: "POST", target: "/sensors/sensor-2/readings", timestamp: now, body: body},
+		{name: "other body", signature: signature, method: "POST", target: "/sensors/sensor-1/readings", timestamp: now, body: []byte(`{}`)},
+		{name: "other secret", signature: SignRequest("guess", "POST", "/sensors/sensor-1/readings", now, body), method: "POST", target: "/sensors/sensor-1/readings", timestamp: now, body: body},
+		{
+			name:      "timestamp too old",
+			signature: SignRequest("s3cret", "POST", "/sensors/sensor-1/readings", now.Add(-MaxSignatureSkew-time.Second), body),
+			method:    "POST",
+			target:    "/sensors/sensor-1/readings",
+			timestamp: now.Add(-MaxSignatureSkew - time.Second),
+			body:      body,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credential.VerifySignature(tt.signature, tt.method, tt.target, tt.timestamp, tt.body, now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCredential_Lifecycle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	credential, _ := NewHMACCredential("credential-1", "device-1", "s3cret", now)
+
+	if !credential.IsActive(now.Add(365 * 24 * time.Hour)) {
+		t.Errorf("expected a credential without expiry to stay active")
+	}
+
+	if err := credential.Retire(now.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := credential.Retire(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !credential.IsActive(now.Add(59*time.Minute)) || credential.IsActive(now.Add(time.Hour)) {
+		t.Errorf("expected the credential to expire at the first retirement, got %v", credential.ExpiresAt)
+	}
+
+	if err := credential.Revoke(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if credential.IsActive(now) {
+		t.Errorf("expected a revoked credential to be inactive")
+	}
+
+	if err := credential.Revoke(now); !errors.Is(err, ErrCredentialRevoked) {
+		t.Errorf("expected ErrCredentialRevoked, got %v", err)
+	}
+
+	if err := credential.Retire(now); !errors.Is(err, ErrCredentialRevoked) {
+		t.Errorf("expected ErrCredentialRevoked, got %v", err)
+	}
+}
+
+func TestNewCredential_Validation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := NewHMACCredential("credential-1", "device-1", "", now); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential, got %v", err)
+	}
+
+	if _, err := NewX509Credential("credential-1", "device-1", "abc", now.Add(time.Hour), now); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential, got %v", err)
+	}
+
+	credential, err := NewX509Credential("credential-1", "device-1", strings.Repeat("a", 64), now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if credential.IsActive(now.Add(time.Hour)) {
+		t.Errorf("expected the credential to expire with its certificate")
+	}
+
+	if _, err := ParseCredentialType("psk"); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential, got %v", err)
+	}
+
+	if typ, err := ParseCredentialType(""); err != nil || typ != CredentialHMAC {
+		t.Errorf("expected hmac by default, got %q, %v", typ, err)
+	}
+}
+
+func TestNewClaimToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		deviceType  string
+		maxUses     int
+		ttl         time.Duration
+		expectError bool
+	}{
+		{name: "valid token", deviceType: "gateway", maxUses: 10, ttl: time.Hour},
+		{name: "missing device type", deviceType: "", maxUses: 10, ttl: time.Hour, expectError: true},
+		{name: "no uses", deviceType: "gateway", maxUses: 0, ttl: time.Hour, expectError: true},
+		{name: "non positive ttl", deviceType: "gateway", maxUses: 10, ttl: 0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := NewClaimToken("token-1", "plain-token", tt.deviceType, tt.maxUses, tt.ttl, now)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidClaimToken) {
+					t.Errorf("expected ErrInvalidClaimToken, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if token.TokenHash == "plain-token" || token.TokenHash != HashClaimToken("plain-token") {
+				t.Errorf("expected only the hash of the token to be kept, got %q", token.TokenHash)
+			}
+
+			if !token.IsRedeemable(now) || token.IsRedeemable(now.Add(tt.ttl)) {
+				t.Errorf("unexpected redeemability for %+v", token)
+			}
+		})
+	}
+}
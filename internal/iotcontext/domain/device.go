@@ -1,21 +1,70 @@
 package domain
 
 import (
-	"errors"
+	"fmt"
 	"time"
 )
 
+type DeviceStatus string
+
+const (
+	DeviceProvisioned    DeviceStatus = "provisioned"
+	DeviceActive         DeviceStatus = "active"
+	DeviceMaintenance    DeviceStatus = "maintenance"
+	DeviceDecommissioned DeviceStatus = "decommissioned"
+)
+
+// deviceTransitions lists the allowed status changes. Decommissioned is final.
+var deviceTransitions = map[DeviceStatus][]DeviceStatus{
+	DeviceProvisioned: {DeviceActive, DeviceDecommissioned},
+	DeviceActive:      {DeviceMaintenance, DeviceDecommissioned},
+	DeviceMaintenance: {DeviceActive, DeviceDecommissioned},
+}
+
+func ParseDeviceStatus(value string) (DeviceStatus, error) {
+	status := DeviceStatus(value)
+	switch status {
+	case DeviceProvisioned, DeviceActive, DeviceMaintenance, DeviceDecommissioned:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidDeviceStatus, value)
+	}
+}
+
+func (s DeviceStatus) CanTransitionTo(next DeviceStatus) bool {
+	for _, allowed := range deviceTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Device is a physical gateway or node. Version starts at 1 and is moved on
+// by the repository on every update, so a change made to a stale copy is
+// rejected with ErrDeviceVersionConflict.
 type Device struct {
-	ID        DeviceID  `json:"id"`
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         DeviceID     `json:"id"`
+	TenantID   TenantID     `json:"tenant_id"`
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	Status     DeviceStatus `json:"status"`
+	Labels     Labels       `json:"labels"`
+	LocationID LocationID   `json:"location_id,omitempty"`
+	Geo        *GeoPoint    `json:"geo,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Version    int64        `json:"version"`
 }
 
 func NewDevice(id DeviceID, name string, typ string) (*Device, error) {
-	if id == "" || name == "" {
-		return nil, errors.New("device id or name empty")
+	if id == "" {
+		return nil, Invalid(ErrInvalidDevice, FieldError{Field: "id", Reason: "must not be empty"})
+	}
+
+	if name == "" {
+		return nil, Invalid(ErrInvalidDevice, FieldError{Field: "name", Reason: "must not be empty"})
 	}
 
 	now := time.Now().UTC()
@@ -23,8 +72,78 @@ func NewDevice(id DeviceID, name string, typ string) (*Device, error) {
 	return &Device{
 		ID:        id,
 		Name:      name,
+		TenantID:  DefaultTenant,
 		Type:      typ,
+		Status:    DeviceProvisioned,
+		Labels:    Labels{},
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
 	}, nil
 }
+
+func (d *Device) Rename(name string, typ string) error {
+	if d.Status == DeviceDecommissioned {
+		return ErrDeviceDecommissioned
+	}
+
+	if name == "" {
+		return Invalid(ErrInvalidDevice, FieldError{Field: "name", Reason: "must not be empty"})
+	}
+
+	d.Name = name
+	d.Type = typ
+	d.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// Relabel replaces every label of the device.
+func (d *Device) Relabel(labels Labels) error {
+	if d.Status == DeviceDecommissioned {
+		return ErrDeviceDecommissioned
+	}
+
+	if err := ValidateLabels(labels); err != nil {
+		return err
+	}
+
+	d.Labels = labels.Copy()
+	d.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// Locate assigns the device to a location, or unassigns it when locationID
+// is empty. geo pins a device whose position differs from its location's;
+// without it the device is placed where its location is.
+func (d *Device) Locate(locationID LocationID, geo *GeoPoint) error {
+	if d.Status == DeviceDecommissioned {
+		return ErrDeviceDecommissioned
+	}
+
+	if geo != nil {
+		if err := geo.Validate(); err != nil {
+			return err
+		}
+		copied := *geo
+		geo = &copied
+	}
+
+	d.LocationID = locationID
+	d.Geo = geo
+	d.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+func (d *Device) TransitionTo(status DeviceStatus) error {
+	if !d.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidDeviceTransition, d.Status, status)
+	}
+
+	d.Status = status
+	d.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
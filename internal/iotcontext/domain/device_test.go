@@ -77,6 +77,46 @@ func TestNewDevice(t *testing.T) {
 			if device.UpdatedAt.IsZero() {
 				t.Error("expected UpdatedAt to be set")
 			}
+
+			if device.Status != DeviceProvisioned {
+				t.Errorf("expected status %s, got %s", DeviceProvisioned, device.Status)
+			}
 		})
 	}
 }
+
+func TestDeviceStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from     DeviceStatus
+		to       DeviceStatus
+		expected bool
+	}{
+		{from: DeviceProvisioned, to: DeviceActive, expected: true},
+		{from: DeviceProvisioned, to: DeviceMaintenance, expected: false},
+		{from: DeviceProvisioned, to: DeviceDecommissioned, expected: true},
+		{from: DeviceActive, to: DeviceMaintenance, expected: true},
+		{from: DeviceActive, to: DeviceProvisioned, expected: false},
+		{from: DeviceMaintenance, to: DeviceActive, expected: true},
+		{from: DeviceMaintenance, to: DeviceDecommissioned, expected: true},
+		{from: DeviceDecommissioned, to: DeviceActive, expected: false},
+		{from: DeviceActive, to: DeviceActive, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseDeviceStatus(t *testing.T) {
+	if status, err := ParseDeviceStatus("maintenance"); err != nil || status != DeviceMaintenance {
+		t.Errorf("expected maintenance, got %q (%v)", status, err)
+	}
+
+	if _, err := ParseDeviceStatus("retired"); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
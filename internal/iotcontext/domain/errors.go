@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorKind classifies a domain error by what went wrong, leaving to each
+// adapter how to report it.
+type ErrorKind string
+
+const (
+	KindInternal           ErrorKind = "internal"
+	KindNotFound           ErrorKind = "not_found"
+	KindValidation         ErrorKind = "validation"
+	KindConflict           ErrorKind = "conflict"
+	KindPreconditionFailed ErrorKind = "precondition_failed"
+	KindUnauthenticated    ErrorKind = "unauthenticated"
+	KindForbidden          ErrorKind = "forbidden"
+	KindRateLimited        ErrorKind = "rate_limited"
+)
+
+// Error is a domain error of a known kind. Code is stable, so clients may
+// branch on it, unlike on the message.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func newError(kind ErrorKind, code string, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError names an invalid field of the input and why it is invalid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError is a validation failure with the fields at fault. It wraps
+// the domain error, so errors.Is(err, ErrInvalidSensorConfig) still holds.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// Invalid reports err as caused by the fields.
+func Invalid(err error, fields ...FieldError) error {
+	return &ValidationError{Err: err, Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		reasons = append(reasons, field.Field+" "+field.Reason)
+	}
+
+	return e.Err.Error() + ": " + strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the domain error err wraps, or KindInternal
+// when it wraps none.
+func KindOf(err error) ErrorKind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+
+	return KindInternal
+}
+
+// CodeOf returns the code of the domain error err wraps, or "internal" when
+// it wraps none.
+func CodeOf(err error) string {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+
+	return string(KindInternal)
+}
+
+// FieldsOf returns the invalid fields err reports, if any.
+func FieldsOf(err error) []FieldError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedKind ErrorKind
+		expectedCode string
+	}{
+		{name: "sentinel", err: ErrSensorNotFound, expectedKind: KindNotFound, expectedCode: "sensor_not_found"},
+		{name: "wrapped sentinel", err: fmt.Errorf("%w: %q", ErrInvalidRole, "root"), expectedKind: KindValidation, expectedCode: "invalid_role"},
+		{name: "version conflict", err: ErrDeviceVersionConflict, expectedKind: KindPreconditionFailed, expectedCode: "device_version_conflict"},
+		{name: "validation error", err: Invalid(ErrInvalidSensorConfig, FieldError{Field: "error_rate", Reason: "must be between 0 and 1"}), expectedKind: KindValidation, expectedCode: "invalid_sensor_config"},
+		{name: "foreign error", err: errors.New("connection refused"), expectedKind: KindInternal, expectedCode: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind := KindOf(tt.err); kind != tt.expectedKind {
+				t.Errorf("expected kind %q, got %q", tt.expectedKind, kind)
+			}
+			if code := CodeOf(tt.err); code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestSensorUpdateConfigReportsTheInvalidField(t *testing.T) {
+	sensor, err := NewSensor("sensor-1", "device-1", "probe", Temperature, SensorConfig{SensorID: "sensor-1", SamplingRateMs: 1000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = sensor.UpdateConfig(SensorConfig{SensorID: "sensor-1", SamplingRateMs: 0})
+
+	if !errors.Is(err, ErrInvalidSensorConfig) {
+		t.Fatalf("expected ErrInvalidSensorConfig, got %v", err)
+	}
+	fields := FieldsOf(err)
+	if len(fields) != 1 || fields[0].Field != "sampling_rate_ms" {
+		t.Errorf("expected sampling_rate_ms to be reported, got %+v", fields)
+	}
+	if err.Error() != "invalid sensor config: sampling_rate_ms must be positive" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
@@ -8,6 +8,7 @@ type EventPublisher interface {
 
 type IoTEvent struct {
 	Type      string    `json:"type"`
+	TenantID  TenantID  `json:"tenant_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	Payload   any       `json:"payload"`
 }
@@ -17,11 +18,34 @@ type SensorCreatedEvent struct {
 	DeviceID DeviceID   `json:"device_id"`
 	Type     SensorType `json:"type"`
 	Name     string     `json:"name"`
+	Labels   Labels     `json:"labels"`
 }
 
 type SensorConfigUpdatedEvent struct {
-	SensorID SensorID     `json:"sensor_id"`
-	Config   SensorConfig `json:"config"`
+	SensorID     SensorID     `json:"sensor_id"`
+	Config       SensorConfig `json:"config"`
+	RestoredFrom int64        `json:"restored_from,omitempty"`
+}
+
+type SensorRenamedEvent struct {
+	SensorID SensorID `json:"sensor_id"`
+	Name     string   `json:"name"`
+}
+
+type SensorLabelsChangedEvent struct {
+	SensorID SensorID `json:"sensor_id"`
+	Labels   Labels   `json:"labels"`
+}
+
+type SensorMovedEvent struct {
+	SensorID SensorID `json:"sensor_id"`
+	From     DeviceID `json:"from_device_id"`
+	To       DeviceID `json:"to_device_id"`
+}
+
+type SensorDeletedEvent struct {
+	SensorID SensorID `json:"sensor_id"`
+	DeviceID DeviceID `json:"device_id"`
 }
 
 type SensorReadingPublishedEvent struct {
@@ -34,6 +58,99 @@ type SensorReadingErrorEvent struct {
 	Type     string   `json:"type"`
 }
 
+type DeviceCreatedEvent struct {
+	DeviceID DeviceID     `json:"device_id"`
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	Status   DeviceStatus `json:"status"`
+	Labels   Labels       `json:"labels"`
+}
+
+type DeviceUpdatedEvent struct {
+	DeviceID DeviceID `json:"device_id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+}
+
+type DeviceLabelsChangedEvent struct {
+	DeviceID DeviceID `json:"device_id"`
+	Labels   Labels   `json:"labels"`
+}
+
+type DeviceStatusChangedEvent struct {
+	DeviceID DeviceID     `json:"device_id"`
+	From     DeviceStatus `json:"from"`
+	To       DeviceStatus `json:"to"`
+}
+
+type DeviceDeletedEvent struct {
+	DeviceID  DeviceID   `json:"device_id"`
+	SensorIDs []SensorID `json:"sensor_ids"`
+}
+
+type DeviceGroupChangedEvent struct {
+	GroupID   GroupID    `json:"group_id"`
+	Name      string     `json:"name"`
+	Selector  string     `json:"selector,omitempty"`
+	DeviceIDs []DeviceID `json:"device_ids,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+}
+
+type LocationChangedEvent struct {
+	LocationID LocationID   `json:"location_id"`
+	Kind       LocationKind `json:"kind"`
+	Name       string       `json:"name"`
+	ParentID   LocationID   `json:"parent_id,omitempty"`
+	Deleted    bool         `json:"deleted,omitempty"`
+}
+
+type DeviceLocationChangedEvent struct {
+	DeviceID   DeviceID   `json:"device_id"`
+	LocationID LocationID `json:"location_id,omitempty"`
+	Geo        *GeoPoint  `json:"geo,omitempty"`
+}
+
+type DeviceOnlineEvent struct {
+	DeviceID   DeviceID  `json:"device_id"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type DeviceOfflineEvent struct {
+	DeviceID   DeviceID  `json:"device_id"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type TwinDeltaEvent struct {
+	DeviceID       DeviceID       `json:"device_id"`
+	DesiredVersion int64          `json:"desired_version"`
+	Delta          TwinProperties `json:"delta"`
+}
+
+type CampaignStatusChangedEvent struct {
+	CampaignID CampaignID     `json:"campaign_id"`
+	From       CampaignStatus `json:"from,omitempty"`
+	To         CampaignStatus `json:"to"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+type DeviceUpdateStatusChangedEvent struct {
+	CampaignID CampaignID         `json:"campaign_id"`
+	DeviceID   DeviceID           `json:"device_id"`
+	Status     DeviceUpdateStatus `json:"status"`
+	Error      string             `json:"error,omitempty"`
+}
+
+type DeviceCredentialIssuedEvent struct {
+	DeviceID     DeviceID       `json:"device_id"`
+	CredentialID CredentialID   `json:"credential_id"`
+	Type         CredentialType `json:"type"`
+}
+
+type DeviceCredentialRevokedEvent struct {
+	DeviceID     DeviceID     `json:"device_id"`
+	CredentialID CredentialID `json:"credential_id"`
+}
+
 func (e *SensorCreatedEvent) ToDomainEvent() IoTEvent {
 	return IoTEvent{
 		Type:      "sensor.created",
@@ -50,6 +167,38 @@ func (e *SensorConfigUpdatedEvent) ToDomainEvent() IoTEvent {
 	}
 }
 
+func (e *SensorRenamedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "sensor.renamed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *SensorLabelsChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "sensor.labels.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *SensorMovedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "sensor.moved",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *SensorDeletedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "sensor.deleted",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
 func (e *SensorReadingPublishedEvent) ToDomainEvent() IoTEvent {
 	return IoTEvent{
 		Type:      "sensor.reading.published",
@@ -65,3 +214,137 @@ func (e *SensorReadingErrorEvent) ToDomainEvent() IoTEvent {
 		Payload:   e,
 	}
 }
+
+func (e *DeviceCreatedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.created",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceUpdatedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.updated",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceLabelsChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.labels.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceStatusChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.status.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceDeletedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.deleted",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+// ToDomainEvent reports a deleted group as device.group.deleted and any other
+// change as device.group.changed.
+func (e *DeviceGroupChangedEvent) ToDomainEvent() IoTEvent {
+	eventType := "device.group.changed"
+	if e.Deleted {
+		eventType = "device.group.deleted"
+	}
+
+	return IoTEvent{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+// ToDomainEvent reports a deleted location as location.deleted and any other
+// change as location.changed.
+func (e *LocationChangedEvent) ToDomainEvent() IoTEvent {
+	eventType := "location.changed"
+	if e.Deleted {
+		eventType = "location.deleted"
+	}
+
+	return IoTEvent{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceLocationChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.location.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceOnlineEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.online",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceOfflineEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.offline",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *TwinDeltaEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "twin.delta",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *CampaignStatusChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "firmware.campaign.status.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceUpdateStatusChangedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "firmware.update.status.changed",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceCredentialIssuedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.credential.issued",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
+
+func (e *DeviceCredentialRevokedEvent) ToDomainEvent() IoTEvent {
+	return IoTEvent{
+		Type:      "device.credential.revoked",
+		Timestamp: time.Now().UTC(),
+		Payload:   e,
+	}
+}
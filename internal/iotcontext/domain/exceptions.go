@@ -1,8 +1,68 @@
 package domain
 
-import "errors"
-
-var ErrInvalidPaginationParams = errors.New("invalid pagination parameters")
-var ErrSensorNotFound = errors.New("sensor not found")
-var ErrInvalidAction = errors.New("invalid action")
-var ErrDeviceNotFound = errors.New("device not found")
+var ErrInvalidPaginationParams = newError(KindValidation, "invalid_pagination_params", "invalid pagination parameters")
+var ErrSensorNotFound = newError(KindNotFound, "sensor_not_found", "sensor not found")
+var ErrInvalidAction = newError(KindValidation, "invalid_action", "invalid action")
+var ErrDeviceNotFound = newError(KindNotFound, "device_not_found", "device not found")
+var ErrInvalidPartitionInterval = newError(KindValidation, "invalid_partition_interval", "invalid partition interval")
+var ErrInvalidRetentionPolicy = newError(KindValidation, "invalid_retention_policy", "invalid retention policy")
+var ErrInvalidTimeRange = newError(KindValidation, "invalid_time_range", "invalid time range")
+var ErrInvalidSensor = newError(KindValidation, "invalid_sensor", "invalid sensor")
+var ErrInvalidSensorConfig = newError(KindValidation, "invalid_sensor_config", "invalid sensor config")
+var ErrSensorAlreadyExists = newError(KindConflict, "sensor_already_exists", "sensor already exists")
+var ErrSensorConfigRevisionNotFound = newError(KindNotFound, "sensor_config_revision_not_found", "sensor config revision not found")
+var ErrInvalidSensorConfigRevision = newError(KindValidation, "invalid_sensor_config_revision", "invalid sensor config revision")
+var ErrSensorConfigConflict = newError(KindConflict, "sensor_config_conflict", "sensor config changed concurrently")
+var ErrSensorVersionConflict = newError(KindPreconditionFailed, "sensor_version_conflict", "sensor version conflict")
+var ErrDeviceVersionConflict = newError(KindPreconditionFailed, "device_version_conflict", "device version conflict")
+var ErrInvalidDevice = newError(KindValidation, "invalid_device", "invalid device")
+var ErrDeviceAlreadyExists = newError(KindConflict, "device_already_exists", "device already exists")
+var ErrInvalidDeviceStatus = newError(KindValidation, "invalid_device_status", "invalid device status")
+var ErrInvalidDeviceTransition = newError(KindConflict, "invalid_device_transition", "invalid device status transition")
+var ErrDeviceDecommissioned = newError(KindConflict, "device_decommissioned", "device is decommissioned")
+var ErrSimulationNotActive = newError(KindConflict, "simulation_not_active", "sensor not active")
+var ErrSimulationAlreadyActive = newError(KindConflict, "simulation_already_active", "sensor already active")
+var ErrSensorDisabled = newError(KindConflict, "sensor_disabled", "sensor is disabled")
+var ErrInvalidPresencePolicy = newError(KindValidation, "invalid_presence_policy", "invalid presence policy")
+var ErrTwinNotFound = newError(KindNotFound, "twin_not_found", "device twin not found")
+var ErrTwinVersionConflict = newError(KindConflict, "twin_version_conflict", "device twin version conflict")
+var ErrCommandNotFound = newError(KindNotFound, "command_not_found", "command not found")
+var ErrInvalidCommand = newError(KindValidation, "invalid_command", "invalid command")
+var ErrCommandFinished = newError(KindConflict, "command_finished", "command already finished")
+var ErrCommandAlreadyExists = newError(KindConflict, "command_already_exists", "command already exists")
+var ErrFirmwareNotFound = newError(KindNotFound, "firmware_not_found", "firmware not found")
+var ErrInvalidFirmware = newError(KindValidation, "invalid_firmware", "invalid firmware")
+var ErrFirmwareAlreadyExists = newError(KindConflict, "firmware_already_exists", "firmware already exists")
+var ErrArtifactNotFound = newError(KindNotFound, "artifact_not_found", "firmware artifact not found")
+var ErrCampaignNotFound = newError(KindNotFound, "campaign_not_found", "campaign not found")
+var ErrInvalidCampaign = newError(KindValidation, "invalid_campaign", "invalid campaign")
+var ErrInvalidCampaignTransition = newError(KindConflict, "invalid_campaign_transition", "invalid campaign status transition")
+var ErrDeviceUpdateNotFound = newError(KindNotFound, "device_update_not_found", "device update not found")
+var ErrInvalidDeviceUpdate = newError(KindValidation, "invalid_device_update", "invalid device update")
+var ErrDeviceUpdateFinished = newError(KindConflict, "device_update_finished", "device update already finished")
+var ErrCredentialNotFound = newError(KindNotFound, "credential_not_found", "credential not found")
+var ErrInvalidCredential = newError(KindValidation, "invalid_credential", "invalid credential")
+var ErrCredentialRevoked = newError(KindConflict, "credential_revoked", "credential already revoked")
+var ErrInvalidClaimToken = newError(KindValidation, "invalid_claim_token", "invalid claim token")
+var ErrClaimTokenRejected = newError(KindUnauthenticated, "claim_token_rejected", "claim token is unknown, expired or used up")
+var ErrUnauthenticated = newError(KindUnauthenticated, "device_unauthenticated", "device authentication failed")
+var ErrDeviceNotAuthorized = newError(KindForbidden, "device_not_authorized", "device is not authorized for this resource")
+var ErrInvalidLabels = newError(KindValidation, "invalid_labels", "invalid labels")
+var ErrInvalidSelector = newError(KindValidation, "invalid_selector", "invalid selector")
+var ErrInvalidGroup = newError(KindValidation, "invalid_group", "invalid device group")
+var ErrGroupNotFound = newError(KindNotFound, "group_not_found", "device group not found")
+var ErrGroupAlreadyExists = newError(KindConflict, "group_already_exists", "device group already exists")
+var ErrInvalidLocation = newError(KindValidation, "invalid_location", "invalid location")
+var ErrLocationNotFound = newError(KindNotFound, "location_not_found", "location not found")
+var ErrLocationAlreadyExists = newError(KindConflict, "location_already_exists", "location already exists")
+var ErrLocationInUse = newError(KindConflict, "location_in_use", "location still has child locations or devices")
+var ErrInvalidTenant = newError(KindValidation, "invalid_tenant", "invalid tenant")
+var ErrQuotaExceeded = newError(KindForbidden, "quota_exceeded", "tenant quota exceeded")
+var ErrRateLimited = newError(KindRateLimited, "rate_limited", "tenant ingestion rate exceeded")
+var ErrInvalidRole = newError(KindValidation, "invalid_role", "invalid role")
+var ErrInvalidAPIKey = newError(KindValidation, "invalid_api_key", "invalid api key")
+var ErrAPIKeyNotFound = newError(KindNotFound, "api_key_not_found", "api key not found")
+var ErrAPIKeyRevoked = newError(KindConflict, "api_key_revoked", "api key already revoked")
+var ErrAuthenticationFailed = newError(KindUnauthenticated, "authentication_failed", "authentication failed")
+var ErrInvalidAuditEntry = newError(KindValidation, "invalid_audit_entry", "invalid audit entry")
+var ErrInvalidAuditFilter = newError(KindValidation, "invalid_audit_filter", "invalid audit filter")
@@ -0,0 +1,375 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type FirmwareID string
+
+// Firmware is the metadata of an uploaded firmware image. The image itself
+// lives in an ArtifactStore under the same id.
+type Firmware struct {
+	ID         FirmwareID `json:"id"`
+	Version    string     `json:"version"`
+	DeviceType string     `json:"device_type"`
+	Checksum   string     `json:"checksum"`
+	Size       int64      `json:"size"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewFirmware(id FirmwareID, version string, deviceType string, checksum string, size int64, now time.Time) (*Firmware, error) {
+	if id == "" {
+		return nil, errors.New("firmware id empty")
+	}
+
+	if version == "" {
+		return nil, fmt.Errorf("%w: version empty", ErrInvalidFirmware)
+	}
+
+	if deviceType == "" {
+		return nil, fmt.Errorf("%w: device type empty", ErrInvalidFirmware)
+	}
+
+	if len(checksum) != sha256.Size*2 {
+		return nil, fmt.Errorf("%w: checksum must be a hex sha256", ErrInvalidFirmware)
+	}
+
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: image is empty", ErrInvalidFirmware)
+	}
+
+	return &Firmware{
+		ID:         id,
+		Version:    version,
+		DeviceType: deviceType,
+		Checksum:   checksum,
+		Size:       size,
+		CreatedAt:  now.UTC(),
+	}, nil
+}
+
+type CampaignID string
+
+type CampaignStatus string
+
+const (
+	CampaignRunning   CampaignStatus = "running"
+	CampaignPaused    CampaignStatus = "paused"
+	CampaignHalted    CampaignStatus = "halted"
+	CampaignAborted   CampaignStatus = "aborted"
+	CampaignCompleted CampaignStatus = "completed"
+)
+
+// campaignTransitions lists the status changes an operator or the rollout
+// job may apply. Aborted and completed are final.
+var campaignTransitions = map[CampaignStatus][]CampaignStatus{
+	CampaignRunning: {CampaignPaused, CampaignHalted, CampaignAborted, CampaignCompleted},
+	CampaignPaused:  {CampaignRunning, CampaignAborted},
+	CampaignHalted:  {CampaignRunning, CampaignAborted},
+}
+
+// Campaign rolls a firmware out to every device of its type in stages. Each
+// stage is the cumulative percentage of devices that receive the update; the
+// next stage starts once every update of the current one has finished.
+type Campaign struct {
+	ID               CampaignID     `json:"id"`
+	FirmwareID       FirmwareID     `json:"firmware_id"`
+	DeviceType       string         `json:"device_type"`
+	Stages           []int          `json:"stages"`
+	Stage            int            `json:"stage"`
+	FailureThreshold float64        `json:"failure_threshold"`
+	Status           CampaignStatus `json:"status"`
+	Reason           string         `json:"reason,omitempty"`
+	// AcceptedFailures is the number of failed updates an operator accepted
+	// when resuming a halted campaign; they no longer count for halting.
+	AcceptedFailures int       `json:"accepted_failures"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func NewCampaign(id CampaignID, firmware *Firmware, stages []int, failureThreshold float64, now time.Time) (*Campaign, error) {
+	if id == "" {
+		return nil, errors.New("campaign id empty")
+	}
+
+	if err := validateCampaignStages(stages); err != nil {
+		return nil, err
+	}
+
+	if failureThreshold < 0 || failureThreshold > 1 {
+		return nil, fmt.Errorf("%w: failure threshold must be between 0 and 1", ErrInvalidCampaign)
+	}
+
+	now = now.UTC()
+
+	return &Campaign{
+		ID:               id,
+		FirmwareID:       firmware.ID,
+		DeviceType:       firmware.DeviceType,
+		Stages:           append([]int(nil), stages...),
+		FailureThreshold: failureThreshold,
+		Status:           CampaignRunning,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}, nil
+}
+
+func validateCampaignStages(stages []int) error {
+	if len(stages) == 0 {
+		return fmt.Errorf("%w: at least one stage is required", ErrInvalidCampaign)
+	}
+
+	previous := 0
+	for _, stage := range stages {
+		if stage <= previous || stage > 100 {
+			return fmt.Errorf("%w: stages must be increasing percentages up to 100, got %v", ErrInvalidCampaign, stages)
+		}
+		previous = stage
+	}
+
+	if previous != 100 {
+		return fmt.Errorf("%w: the last stage must be 100, got %v", ErrInvalidCampaign, stages)
+	}
+
+	return nil
+}
+
+func (c *Campaign) IsFinal() bool {
+	return c.Status == CampaignAborted || c.Status == CampaignCompleted
+}
+
+// StageSize is how many of total targets belong to the current stage or an
+// earlier one.
+func (c *Campaign) StageSize(total int) int {
+	return int(math.Ceil(float64(total) * float64(c.Stages[c.Stage]) / 100))
+}
+
+func (c *Campaign) IsLastStage() bool {
+	return c.Stage == len(c.Stages)-1
+}
+
+func (c *Campaign) NextStage(now time.Time) error {
+	if c.Status != CampaignRunning || c.IsLastStage() {
+		return fmt.Errorf("%w: cannot advance a %s campaign at stage %d", ErrInvalidCampaignTransition, c.Status, c.Stage)
+	}
+
+	c.Stage++
+	c.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+func (c *Campaign) Pause(now time.Time) error {
+	return c.transition(CampaignPaused, "", now)
+}
+
+// Resume restarts a paused or halted campaign. Resuming a halted campaign
+// accepts the failures seen so far, so only new ones can halt it again.
+func (c *Campaign) Resume(failed int, now time.Time) error {
+	halted := c.Status == CampaignHalted
+	if err := c.transition(CampaignRunning, "", now); err != nil {
+		return err
+	}
+
+	if halted {
+		c.AcceptedFailures = failed
+	}
+
+	return nil
+}
+
+func (c *Campaign) Abort(now time.Time) error {
+	return c.transition(CampaignAborted, "", now)
+}
+
+func (c *Campaign) Halt(reason string, now time.Time) error {
+	return c.transition(CampaignHalted, reason, now)
+}
+
+func (c *Campaign) Complete(now time.Time) error {
+	return c.transition(CampaignCompleted, "", now)
+}
+
+func (c *Campaign) transition(next CampaignStatus, reason string, now time.Time) error {
+	allowed := false
+	for _, status := range campaignTransitions[c.Status] {
+		if status == next {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidCampaignTransition, c.Status, next)
+	}
+
+	c.Status = next
+	c.Reason = reason
+	c.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// ShouldHalt reports whether the failed updates not yet accepted by an
+// operator exceed the failure threshold of the dispatched ones.
+func (c *Campaign) ShouldHalt(updates []*DeviceUpdate) (bool, float64) {
+	dispatched, failed := 0, 0
+	for _, update := range updates {
+		switch update.Status {
+		case DeviceUpdateScheduled, DeviceUpdateCancelled:
+		case DeviceUpdateFailed:
+			dispatched++
+			failed++
+		default:
+			dispatched++
+		}
+	}
+
+	failed -= c.AcceptedFailures
+	if dispatched == 0 || failed <= 0 {
+		return false, 0
+	}
+
+	rate := float64(failed) / float64(dispatched)
+
+	return rate > c.FailureThreshold, rate
+}
+
+// OrderCampaignTargets sorts the devices of a campaign in a stable but
+// pseudo-random order, so early stages are a sample of the fleet rather than
+// its oldest devices.
+func OrderCampaignTargets(id CampaignID, devices []DeviceID) []DeviceID {
+	ordered := append([]DeviceID(nil), devices...)
+
+	key := func(deviceID DeviceID) string {
+		sum := sha256.Sum256([]byte(string(id) + "/" + string(deviceID)))
+		return hex.EncodeToString(sum[:])
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return key(ordered[i]) < key(ordered[j])
+	})
+
+	return ordered
+}
+
+type DeviceUpdateStatus string
+
+const (
+	DeviceUpdateScheduled   DeviceUpdateStatus = "scheduled"
+	DeviceUpdatePending     DeviceUpdateStatus = "pending"
+	DeviceUpdateDownloading DeviceUpdateStatus = "downloading"
+	DeviceUpdateInstalling  DeviceUpdateStatus = "installing"
+	DeviceUpdateSucceeded   DeviceUpdateStatus = "succeeded"
+	DeviceUpdateFailed      DeviceUpdateStatus = "failed"
+	DeviceUpdateCancelled   DeviceUpdateStatus = "cancelled"
+)
+
+// DeviceUpdate is the progress of one device within a campaign. Position is
+// the device's place in the rollout order.
+type DeviceUpdate struct {
+	CampaignID  CampaignID         `json:"campaign_id"`
+	DeviceID    DeviceID           `json:"device_id"`
+	Position    int                `json:"position"`
+	Status      DeviceUpdateStatus `json:"status"`
+	Progress    int                `json:"progress"`
+	Error       string             `json:"error,omitempty"`
+	CommandID   CommandID          `json:"command_id,omitempty"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+func NewDeviceUpdate(campaignID CampaignID, deviceID DeviceID, position int, now time.Time) *DeviceUpdate {
+	return &DeviceUpdate{
+		CampaignID: campaignID,
+		DeviceID:   deviceID,
+		Position:   position,
+		Status:     DeviceUpdateScheduled,
+		UpdatedAt:  now.UTC(),
+	}
+}
+
+func (u *DeviceUpdate) IsFinal() bool {
+	return u.Status == DeviceUpdateSucceeded || u.Status == DeviceUpdateFailed || u.Status == DeviceUpdateCancelled
+}
+
+func (u *DeviceUpdate) IsInFlight() bool {
+	return u.Status == DeviceUpdatePending || u.Status == DeviceUpdateDownloading || u.Status == DeviceUpdateInstalling
+}
+
+// Dispatch records that the update command was sent to the device.
+func (u *DeviceUpdate) Dispatch(commandID CommandID, now time.Time) error {
+	if u.Status != DeviceUpdateScheduled {
+		return fmt.Errorf("%w: update is %s", ErrInvalidDeviceUpdate, u.Status)
+	}
+
+	now = now.UTC()
+	u.Status = DeviceUpdatePending
+	u.CommandID = commandID
+	u.StartedAt = &now
+	u.UpdatedAt = now
+
+	return nil
+}
+
+// Report applies a status reported by the device. Progress is the
+// percentage of the current step and is forced to 100 on success.
+func (u *DeviceUpdate) Report(status DeviceUpdateStatus, progress int, message string, now time.Time) error {
+	if u.IsFinal() {
+		return fmt.Errorf("%w: %s", ErrDeviceUpdateFinished, u.Status)
+	}
+
+	if u.Status == DeviceUpdateScheduled {
+		return fmt.Errorf("%w: update was not dispatched yet", ErrInvalidDeviceUpdate)
+	}
+
+	switch status {
+	case DeviceUpdateDownloading, DeviceUpdateInstalling, DeviceUpdateSucceeded, DeviceUpdateFailed:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidDeviceUpdate, status)
+	}
+
+	if progress < 0 || progress > 100 {
+		return fmt.Errorf("%w: progress must be between 0 and 100", ErrInvalidDeviceUpdate)
+	}
+
+	now = now.UTC()
+	u.Status = status
+	u.Progress = progress
+	u.UpdatedAt = now
+
+	switch status {
+	case DeviceUpdateSucceeded:
+		u.Progress = 100
+		u.CompletedAt = &now
+	case DeviceUpdateFailed:
+		u.Error = message
+		u.CompletedAt = &now
+	}
+
+	return nil
+}
+
+func (u *DeviceUpdate) Fail(message string, now time.Time) error {
+	return u.Report(DeviceUpdateFailed, u.Progress, message, now)
+}
+
+func (u *DeviceUpdate) Cancel(now time.Time) error {
+	if u.Status != DeviceUpdateScheduled {
+		return fmt.Errorf("%w: update is %s", ErrInvalidDeviceUpdate, u.Status)
+	}
+
+	now = now.UTC()
+	u.Status = DeviceUpdateCancelled
+	u.CompletedAt = &now
+	u.UpdatedAt = now
+
+	return nil
+}
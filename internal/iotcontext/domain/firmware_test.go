@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCampaign(t *testing.T) {
+	firmware := &Firmware{ID: "firmware-1", DeviceType: "gateway"}
+
+	tests := []struct {
+		name        string
+		stages      []int
+		threshold   float64
+		expectError bool
+	}{
+		{name: "single stage", stages: []int{100}, threshold: 0.1},
+		{name: "staged rollout", stages: []int{5, 25, 100}, threshold: 0},
+		{name: "no stages", stages: nil, threshold: 0.1, expectError: true},
+		{name: "not increasing", stages: []int{50, 25, 100}, threshold: 0.1, expectError: true},
+		{name: "not ending at 100", stages: []int{10, 50}, threshold: 0.1, expectError: true},
+		{name: "above 100", stages: []int{50, 150}, threshold: 0.1, expectError: true},
+		{name: "threshold above 1", stages: []int{100}, threshold: 1.5, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaign, err := NewCampaign("campaign-1", firmware, tt.stages, tt.threshold, time.Now())
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidCampaign) {
+					t.Errorf("expected ErrInvalidCampaign, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if campaign.Status != CampaignRunning || campaign.DeviceType != "gateway" {
+				t.Errorf("unexpected campaign %+v", campaign)
+			}
+		})
+	}
+}
+
+func TestCampaign_StageSize(t *testing.T) {
+	campaign := &Campaign{Stages: []int{10, 50, 100}}
+
+	tests := []struct {
+		stage    int
+		total    int
+		expected int
+	}{
+		{stage: 0, total: 3, expected: 1},
+		{stage: 0, total: 20, expected: 2},
+		{stage: 1, total: 3, expected: 2},
+		{stage: 2, total: 3, expected: 3},
+	}
+
+	for _, tt := range tests {
+		campaign.Stage = tt.stage
+		if size := campaign.StageSize(tt.total); size != tt.expected {
+			t.Errorf("stage %d of %d: expected %d, got %d", tt.stage, tt.total, tt.expected, size)
+		}
+	}
+}
+
+func TestCampaign_Transitions(t *testing.T) {
+	now := time.Now()
+	campaign, _ := NewCampaign("campaign-1", &Firmware{ID: "firmware-1"}, []int{100}, 0.1, now)
+
+	if err := campaign.Halt("too many failures", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := campaign.Pause(now); !errors.Is(err, ErrInvalidCampaignTransition) {
+		t.Errorf("expected ErrInvalidCampaignTransition, got %v", err)
+	}
+
+	if err := campaign.Resume(3, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if campaign.AcceptedFailures != 3 || campaign.Reason != "" {
+		t.Errorf("expected the failures to be accepted, got %+v", campaign)
+	}
+
+	if err := campaign.Complete(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := campaign.Abort(now); !errors.Is(err, ErrInvalidCampaignTransition) {
+		t.Errorf("expected ErrInvalidCampaignTransition, got %v", err)
+	}
+}
+
+func TestCampaign_ShouldHalt(t *testing.T) {
+	updates := func(statuses ...DeviceUpdateStatus) []*DeviceUpdate {
+		var result []*DeviceUpdate
+		for _, status := range statuses {
+			result = append(result, &DeviceUpdate{Status: status})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		accepted int
+		updates  []*DeviceUpdate
+		expected bool
+	}{
+		{name: "nothing dispatched", updates: updates(DeviceUpdateScheduled, DeviceUpdateScheduled)},
+		{name: "below threshold", updates: updates(DeviceUpdateFailed, DeviceUpdateSucceeded, DeviceUpdatePending, DeviceUpdateInstalling, DeviceUpdateScheduled)},
+		{name: "above threshold", updates: updates(DeviceUpdateFailed, DeviceUpdateFailed, DeviceUpdatePending, DeviceUpdateScheduled), expected: true},
+		{name: "accepted failures", accepted: 2, updates: updates(DeviceUpdateFailed, DeviceUpdateFailed, DeviceUpdatePending)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaign := &Campaign{FailureThreshold: 0.25, AcceptedFailures: tt.accepted}
+
+			if halt, rate := campaign.ShouldHalt(tt.updates); halt != tt.expected {
+				t.Errorf("expected %v, got %v (rate %v)", tt.expected, halt, rate)
+			}
+		})
+	}
+}
+
+func TestOrderCampaignTargets(t *testing.T) {
+	devices := []DeviceID{"a", "b", "c", "d", "e"}
+
+	first := OrderCampaignTargets("campaign-1", devices)
+	again := OrderCampaignTargets("campaign-1", []DeviceID{"e", "d", "c", "b", "a"})
+
+	for i := range first {
+		if first[i] != again[i] {
+			t.Fatalf("expected a stable order, got %v and %v", first, again)
+		}
+	}
+
+	if len(first) != len(devices) {
+		t.Errorf("expected %d targets, got %v", len(devices), first)
+	}
+}
+
+func TestDeviceUpdate_Report(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		dispatch    bool
+		status      DeviceUpdateStatus
+		progress    int
+		expected    DeviceUpdateStatus
+		expectError error
+	}{
+		{name: "progress", dispatch: true, status: DeviceUpdateDownloading, progress: 40, expected: DeviceUpdateDownloading},
+		{name: "success", dispatch: true, status: DeviceUpdateSucceeded, expected: DeviceUpdateSucceeded},
+		{name: "not dispatched", status: DeviceUpdateDownloading, expected: DeviceUpdateScheduled, expectError: ErrInvalidDeviceUpdate},
+		{name: "unknown status", dispatch: true, status: "flashing", expected: DeviceUpdatePending, expectError: ErrInvalidDeviceUpdate},
+		{name: "invalid progress", dispatch: true, status: DeviceUpdateInstalling, progress: 120, expected: DeviceUpdatePending, expectError: ErrInvalidDeviceUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := NewDeviceUpdate("campaign-1", "device-1", 0, now)
+			if tt.dispatch {
+				_ = update.Dispatch("command-1", now)
+			}
+
+			err := update.Report(tt.status, tt.progress, "", now)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if update.Status != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, update.Status)
+			}
+		})
+	}
+
+	t.Run("finished", func(t *testing.T) {
+		update := NewDeviceUpdate("campaign-1", "device-1", 0, now)
+		_ = update.Dispatch("command-1", now)
+		_ = update.Fail("timed out", now)
+
+		if err := update.Report(DeviceUpdateSucceeded, 100, "", now); !errors.Is(err, ErrDeviceUpdateFinished) {
+			t.Errorf("expected ErrDeviceUpdateFinished, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type GroupID string
+
+// DeviceGroup is either static, listing its devices, or dynamic, holding a
+// selector that is evaluated against the device labels every time the group
+// is resolved.
+type DeviceGroup struct {
+	ID        GroupID    `json:"id"`
+	TenantID  TenantID   `json:"tenant_id"`
+	Name      string     `json:"name"`
+	Selector  string     `json:"selector,omitempty"`
+	DeviceIDs []DeviceID `json:"device_ids,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func NewDeviceGroup(id GroupID, name string, selector string, deviceIDs []DeviceID, now time.Time) (*DeviceGroup, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: id empty", ErrInvalidGroup)
+	}
+
+	group := &DeviceGroup{
+		ID:        id,
+		TenantID:  DefaultTenant,
+		CreatedAt: now,
+	}
+
+	if err := group.Update(name, selector, deviceIDs, now); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// Update replaces the definition of the group. A group cannot be both static
+// and dynamic; an empty static group is allowed.
+func (g *DeviceGroup) Update(name string, selector string, deviceIDs []DeviceID, now time.Time) error {
+	if name == "" {
+		return fmt.Errorf("%w: name empty", ErrInvalidGroup)
+	}
+
+	if selector != "" && len(deviceIDs) > 0 {
+		return fmt.Errorf("%w: a group has either a selector or device ids", ErrInvalidGroup)
+	}
+
+	if selector != "" {
+		parsed, err := ParseSelector(selector)
+		if err != nil {
+			return err
+		}
+		selector = parsed.String()
+	}
+
+	members := make([]DeviceID, 0, len(deviceIDs))
+	seen := make(map[DeviceID]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		if id == "" {
+			return fmt.Errorf("%w: device id empty", ErrInvalidGroup)
+		}
+		if !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+
+	g.Name = name
+	g.Selector = selector
+	g.DeviceIDs = members
+	g.UpdatedAt = now
+
+	return nil
+}
+
+func (g *DeviceGroup) IsDynamic() bool {
+	return g.Selector != ""
+}
+
+func (g *DeviceGroup) Contains(device Device) bool {
+	if g.IsDynamic() {
+		// The selector was validated when the group was defined.
+		selector, err := ParseSelector(g.Selector)
+		return err == nil && selector.Matches(device.Labels)
+	}
+
+	for _, id := range g.DeviceIDs {
+		if id == device.ID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewDeviceGroup(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		groupName   string
+		selector    string
+		deviceIDs   []DeviceID
+		expectError error
+	}{
+		{name: "static group", groupName: "Lab", deviceIDs: []DeviceID{"device-1", "device-2"}},
+		{name: "empty static group", groupName: "Lab"},
+		{name: "dynamic group", groupName: "Madrid", selector: "site=madrid"},
+		{name: "missing name", selector: "site=madrid", expectError: ErrInvalidGroup},
+		{name: "both selector and devices", groupName: "Lab", selector: "site=madrid", deviceIDs: []DeviceID{"device-1"}, expectError: ErrInvalidGroup},
+		{name: "invalid selector", groupName: "Lab", selector: "site in (", expectError: ErrInvalidSelector},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := NewDeviceGroup("group-1", tt.groupName, tt.selector, tt.deviceIDs, now)
+
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if group.IsDynamic() != (tt.selector != "") {
+				t.Errorf("unexpected group kind for %+v", group)
+			}
+		})
+	}
+}
+
+func TestDeviceGroup_Contains(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	madrid := Device{ID: "device-1", Labels: Labels{"site": "madrid", "floor": "2"}}
+	bilbao := Device{ID: "device-2", Labels: Labels{"site": "bilbao"}}
+
+	dynamic, _ := NewDeviceGroup("group-1", "Madrid", "site=madrid,floor in (1,2)", nil, now)
+	if !dynamic.Contains(madrid) || dynamic.Contains(bilbao) {
+		t.Errorf("expected the dynamic group to contain only the madrid device")
+	}
+
+	static, _ := NewDeviceGroup("group-2", "Lab", "", []DeviceID{"device-2", "device-2"}, now)
+	if static.Contains(madrid) || !static.Contains(bilbao) {
+		t.Errorf("expected the static group to contain only its members")
+	}
+
+	if len(static.DeviceIDs) != 1 {
+		t.Errorf("expected duplicated members to be dropped, got %v", static.DeviceIDs)
+	}
+}
@@ -0,0 +1,270 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Labels are key/value pairs attached to devices and sensors so they can be
+// targeted with a selector instead of one id at a time.
+type Labels map[string]string
+
+const (
+	maxLabelLength = 63
+	maxLabels      = 64
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+)
+
+func ValidateLabels(labels Labels) error {
+	if len(labels) > maxLabels {
+		return Invalid(ErrInvalidLabels, FieldError{Field: "labels", Reason: fmt.Sprintf("must be at most %d", maxLabels)})
+	}
+
+	for key, value := range labels {
+		if err := validateLabelKey(key); err != nil {
+			return Invalid(ErrInvalidLabels, FieldError{Field: "labels", Reason: fmt.Sprintf("has invalid key %q", key)})
+		}
+		if err := validateLabelValue(value); err != nil {
+			return Invalid(ErrInvalidLabels, FieldError{Field: "labels." + key, Reason: fmt.Sprintf("has invalid value %q", value)})
+		}
+	}
+
+	return nil
+}
+
+func validateLabelKey(key string) error {
+	if len(key) > maxLabelLength || !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: key %q", ErrInvalidLabels, key)
+	}
+
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if len(value) > maxLabelLength || !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("%w: value %q", ErrInvalidLabels, value)
+	}
+
+	return nil
+}
+
+// Merge returns a copy of the labels overlaid with others. The receiver is
+// left untouched.
+func (l Labels) Merge(others Labels) Labels {
+	merged := make(Labels, len(l)+len(others))
+	for key, value := range l {
+		merged[key] = value
+	}
+	for key, value := range others {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+func (l Labels) Copy() Labels {
+	return Labels{}.Merge(l)
+}
+
+type selectorOperator string
+
+const (
+	selectorEquals    selectorOperator = "="
+	selectorNotEquals selectorOperator = "!="
+	selectorIn        selectorOperator = "in"
+	selectorNotIn     selectorOperator = "notin"
+	selectorExists    selectorOperator = "exists"
+	selectorNotExists selectorOperator = "!"
+)
+
+type requirement struct {
+	key      string
+	operator selectorOperator
+	values   []string
+}
+
+func (r requirement) matches(labels Labels) bool {
+	value, ok := labels[r.key]
+
+	switch r.operator {
+	case selectorEquals:
+		return ok && value == r.values[0]
+	case selectorNotEquals:
+		return !ok || value != r.values[0]
+	case selectorIn:
+		return ok && contains(r.values, value)
+	case selectorNotIn:
+		return !ok || !contains(r.values, value)
+	case selectorExists:
+		return ok
+	case selectorNotExists:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func (r requirement) String() string {
+	switch r.operator {
+	case selectorExists:
+		return r.key
+	case selectorNotExists:
+		return "!" + r.key
+	case selectorIn, selectorNotIn:
+		return r.key + " " + string(r.operator) + " (" + strings.Join(r.values, ",") + ")"
+	default:
+		return r.key + string(r.operator) + r.values[0]
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Selector picks labelled resources. It is a comma separated list of
+// requirements that must all hold:
+//
+//	site=madrid          site == madrid     site!=madrid
+//	floor in (1,2)       floor notin (3)
+//	gateway              !decommissioned
+type Selector struct {
+	requirements []requirement
+}
+
+var (
+	setRequirementPattern      = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	equalityRequirementPattern = regexp.MustCompile(`^([^=!\s]+)\s*(==|=|!=)\s*(\S*)$`)
+)
+
+func ParseSelector(value string) (Selector, error) {
+	terms, err := splitSelector(value)
+	if err != nil {
+		return Selector{}, err
+	}
+
+	selector := Selector{requirements: make([]requirement, 0, len(terms))}
+	for _, term := range terms {
+		req, err := parseRequirement(term)
+		if err != nil {
+			return Selector{}, err
+		}
+		selector.requirements = append(selector.requirements, req)
+	}
+
+	// Sorting gives every selector a canonical form to store and compare.
+	sort.SliceStable(selector.requirements, func(i, j int) bool {
+		return selector.requirements[i].key < selector.requirements[j].key
+	})
+
+	return selector, nil
+}
+
+// splitSelector splits on the commas that are not inside a value set.
+func splitSelector(value string) ([]string, error) {
+	var terms []string
+	depth, start := 0, 0
+	for i, char := range value {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return nil, fmt.Errorf("%w: unbalanced parentheses in %q", ErrInvalidSelector, value)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced parentheses in %q", ErrInvalidSelector, value)
+	}
+	terms = append(terms, strings.TrimSpace(value[start:]))
+
+	for _, term := range terms {
+		if term == "" {
+			return nil, fmt.Errorf("%w: empty requirement in %q", ErrInvalidSelector, value)
+		}
+	}
+
+	return terms, nil
+}
+
+func parseRequirement(term string) (requirement, error) {
+	var req requirement
+
+	switch {
+	case setRequirementPattern.MatchString(term):
+		match := setRequirementPattern.FindStringSubmatch(term)
+		req = requirement{key: match[1], operator: selectorOperator(match[2])}
+		for _, value := range strings.Split(match[3], ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return requirement{}, fmt.Errorf("%w: empty value in %q", ErrInvalidSelector, term)
+			}
+			req.values = append(req.values, value)
+		}
+		sort.Strings(req.values)
+	case equalityRequirementPattern.MatchString(term):
+		match := equalityRequirementPattern.FindStringSubmatch(term)
+		req = requirement{key: match[1], operator: selectorEquals, values: []string{match[3]}}
+		if match[2] == "!=" {
+			req.operator = selectorNotEquals
+		}
+	case strings.HasPrefix(term, "!"):
+		req = requirement{key: strings.TrimSpace(term[1:]), operator: selectorNotExists}
+	default:
+		req = requirement{key: term, operator: selectorExists}
+	}
+
+	if err := validateLabelKey(req.key); err != nil {
+		return requirement{}, fmt.Errorf("%w: %q", ErrInvalidSelector, term)
+	}
+	for _, value := range req.values {
+		if err := validateLabelValue(value); err != nil {
+			return requirement{}, fmt.Errorf("%w: %q", ErrInvalidSelector, term)
+		}
+	}
+
+	return req, nil
+}
+
+// Matches reports whether the labels satisfy every requirement. The empty
+// selector matches everything.
+func (s Selector) Matches(labels Labels) bool {
+	for _, req := range s.requirements {
+		if !req.matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s Selector) IsEmpty() bool {
+	return len(s.requirements) == 0
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s.requirements))
+	for _, req := range s.requirements {
+		terms = append(terms, req.String())
+	}
+
+	return strings.Join(terms, ",")
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      Labels
+		expectError bool
+	}{
+		{name: "valid labels", labels: Labels{"site": "madrid", "floor": "1", "example.com/owner": "team-a"}},
+		{name: "empty value", labels: Labels{"gateway": ""}},
+		{name: "empty key", labels: Labels{"": "madrid"}, expectError: true},
+		{name: "key with spaces", labels: Labels{"my site": "madrid"}, expectError: true},
+		{name: "value with comma", labels: Labels{"site": "madrid,bilbao"}, expectError: true},
+		{name: "value too long", labels: Labels{"site": strings.Repeat("a", 64)}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabels(tt.labels)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidLabels) {
+					t.Errorf("expected ErrInvalidLabels, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := Labels{"site": "madrid", "floor": "1", "gateway": ""}
+
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{selector: "site=madrid", expected: true},
+		{selector: "site==madrid", expected: true},
+		{selector: "site=bilbao"},
+		{selector: "site!=bilbao", expected: true},
+		{selector: "zone!=north", expected: true},
+		{selector: "site=madrid,floor in (1,2)", expected: true},
+		{selector: "site=madrid, floor in (2, 3)"},
+		{selector: "floor notin (2,3)", expected: true},
+		{selector: "zone notin (north)", expected: true},
+		{selector: "gateway", expected: true},
+		{selector: "!gateway"},
+		{selector: "!zone", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := selector.Matches(labels); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name        string
+		selector    string
+		canonical   string
+		expectError bool
+	}{
+		{name: "sorted by key", selector: "site = madrid,floor in (2,1)", canonical: "floor in (1,2),site=madrid"},
+		{name: "existence", selector: "!zone, gateway", canonical: "gateway,!zone"},
+		{name: "empty", selector: "", expectError: true},
+		{name: "trailing comma", selector: "site=madrid,", expectError: true},
+		{name: "unbalanced parentheses", selector: "floor in (1,2", expectError: true},
+		{name: "empty set value", selector: "floor in (1,)", expectError: true},
+		{name: "invalid key", selector: "my site=madrid", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidSelector) {
+					t.Errorf("expected ErrInvalidSelector, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if selector.String() != tt.canonical {
+				t.Errorf("expected %q, got %q", tt.canonical, selector.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type LocationID string
+
+type LocationKind string
+
+const (
+	LocationOrganization LocationKind = "organization"
+	LocationSite         LocationKind = "site"
+	LocationArea         LocationKind = "area"
+	LocationZone         LocationKind = "zone"
+)
+
+// locationParents lists the kind every location kind hangs from. An
+// organization is always a root.
+var locationParents = map[LocationKind]LocationKind{
+	LocationSite: LocationOrganization,
+	LocationArea: LocationSite,
+	LocationZone: LocationArea,
+}
+
+func ParseLocationKind(value string) (LocationKind, error) {
+	kind := LocationKind(value)
+	switch kind {
+	case LocationOrganization, LocationSite, LocationArea, LocationZone:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("%w: unknown kind %q", ErrInvalidLocation, value)
+	}
+}
+
+// ParentKind returns the kind the parent of a location of this kind must
+// have, or "" for an organization.
+func (k LocationKind) ParentKind() LocationKind {
+	return locationParents[k]
+}
+
+const earthRadiusKm = 6371.0
+
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func (p GeoPoint) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("%w: latitude %v out of range", ErrInvalidLocation, p.Lat)
+	}
+
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("%w: longitude %v out of range", ErrInvalidLocation, p.Lon)
+	}
+
+	return nil
+}
+
+// DistanceKm is the great-circle distance between both points, using the
+// haversine formula on a spherical Earth.
+func (p GeoPoint) DistanceKm(other GeoPoint) float64 {
+	lat1 := p.Lat * math.Pi / 180
+	lat2 := other.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (other.Lon - p.Lon) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// IndoorPosition places a location on a floor plan, in metres from the plan
+// origin of its site.
+type IndoorPosition struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Floor int     `json:"floor"`
+}
+
+// Location is a node of the organization → site → area → zone hierarchy.
+type Location struct {
+	ID        LocationID      `json:"id"`
+	TenantID  TenantID        `json:"tenant_id"`
+	Kind      LocationKind    `json:"kind"`
+	Name      string          `json:"name"`
+	ParentID  LocationID      `json:"parent_id,omitempty"`
+	Geo       *GeoPoint       `json:"geo,omitempty"`
+	Indoor    *IndoorPosition `json:"indoor,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// NewLocation validates the location on its own; whether parentID exists and
+// has the right kind is checked against the stored parent with CanHang.
+func NewLocation(id LocationID, kind LocationKind, name string, parentID LocationID, geo *GeoPoint, indoor *IndoorPosition, now time.Time) (*Location, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: id empty", ErrInvalidLocation)
+	}
+
+	if _, err := ParseLocationKind(string(kind)); err != nil {
+		return nil, err
+	}
+
+	location := &Location{
+		ID:        id,
+		TenantID:  DefaultTenant,
+		Kind:      kind,
+		CreatedAt: now,
+	}
+
+	if err := location.Update(name, parentID, geo, indoor, now); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// Update replaces the name, parent and position of the location. The kind
+// cannot change.
+func (l *Location) Update(name string, parentID LocationID, geo *GeoPoint, indoor *IndoorPosition, now time.Time) error {
+	if name == "" {
+		return fmt.Errorf("%w: name empty", ErrInvalidLocation)
+	}
+
+	if l.Kind == LocationOrganization && parentID != "" {
+		return fmt.Errorf("%w: an organization has no parent", ErrInvalidLocation)
+	}
+
+	if l.Kind != LocationOrganization && parentID == "" {
+		return fmt.Errorf("%w: a %s needs a parent %s", ErrInvalidLocation, l.Kind, l.Kind.ParentKind())
+	}
+
+	if geo != nil {
+		if err := geo.Validate(); err != nil {
+			return err
+		}
+		copied := *geo
+		geo = &copied
+	}
+
+	if indoor != nil {
+		copied := *indoor
+		indoor = &copied
+	}
+
+	l.Name = name
+	l.ParentID = parentID
+	l.Geo = geo
+	l.Indoor = indoor
+	l.UpdatedAt = now
+
+	return nil
+}
+
+// CanHang reports an error unless parent may be the parent of l.
+func (l *Location) CanHang(parent *Location) error {
+	if parent.Kind != l.Kind.ParentKind() {
+		return fmt.Errorf("%w: a %s cannot hang from a %s", ErrInvalidLocation, l.Kind, parent.Kind)
+	}
+
+	return nil
+}
+
+// LocationTree indexes a set of locations to walk the hierarchy without
+// going back to the repository.
+type LocationTree struct {
+	byID     map[LocationID]*Location
+	children map[LocationID][]*Location
+}
+
+func NewLocationTree(locations []*Location) *LocationTree {
+	tree := &LocationTree{
+		byID:     make(map[LocationID]*Location, len(locations)),
+		children: make(map[LocationID][]*Location),
+	}
+
+	for _, location := range locations {
+		tree.byID[location.ID] = location
+		if location.ParentID != "" {
+			tree.children[location.ParentID] = append(tree.children[location.ParentID], location)
+		}
+	}
+
+	return tree
+}
+
+func (t *LocationTree) Find(id LocationID) (*Location, bool) {
+	location, ok := t.byID[id]
+	return location, ok
+}
+
+func (t *LocationTree) Children(id LocationID) []*Location {
+	return t.children[id]
+}
+
+// Subtree returns the ids of the location and of all its descendants.
+func (t *LocationTree) Subtree(id LocationID) map[LocationID]bool {
+	ids := map[LocationID]bool{}
+	pending := []LocationID{id}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if ids[current] {
+			continue
+		}
+		ids[current] = true
+		for _, child := range t.children[current] {
+			pending = append(pending, child.ID)
+		}
+	}
+
+	return ids
+}
+
+// Position returns the coordinates of the location or, when it has none, of
+// its closest ancestor that has them.
+func (t *LocationTree) Position(id LocationID) *GeoPoint {
+	for depth := 0; id != "" && depth < len(locationParents)+1; depth++ {
+		location, ok := t.byID[id]
+		if !ok {
+			return nil
+		}
+		if location.Geo != nil {
+			return location.Geo
+		}
+		id = location.ParentID
+	}
+
+	return nil
+}
+
+// ReadingStats summarises readings of one sensor type over a time range.
+type ReadingStats struct {
+	Count int64   `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Sum   float64 `json:"-"`
+}
+
+func (s *ReadingStats) Add(aggregate ReadingAggregate) {
+	s.merge(ReadingStats{Count: aggregate.Count, Min: aggregate.Min, Max: aggregate.Max, Sum: aggregate.Sum})
+}
+
+func (s *ReadingStats) merge(other ReadingStats) {
+	if other.Count == 0 {
+		return
+	}
+
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.Avg = s.Sum / float64(s.Count)
+}
+
+// LocationMetrics is the metrics tree of a location. The counts and stats of
+// a location include those of all its descendants.
+type LocationMetrics struct {
+	LocationID LocationID                  `json:"location_id"`
+	Kind       LocationKind                `json:"kind"`
+	Name       string                      `json:"name"`
+	Devices    int                         `json:"devices"`
+	Sensors    int                         `json:"sensors"`
+	Readings   map[SensorType]ReadingStats `json:"readings"`
+	Children   []*LocationMetrics          `json:"children,omitempty"`
+}
+
+func NewLocationMetrics(location *Location) *LocationMetrics {
+	return &LocationMetrics{
+		LocationID: location.ID,
+		Kind:       location.Kind,
+		Name:       location.Name,
+		Readings:   map[SensorType]ReadingStats{},
+	}
+}
+
+// Absorb rolls the metrics of a child up into m.
+func (m *LocationMetrics) Absorb(child *LocationMetrics) {
+	m.Devices += child.Devices
+	m.Sensors += child.Sensors
+	for typ, stats := range child.Readings {
+		m.AddStats(typ, stats)
+	}
+	m.Children = append(m.Children, child)
+}
+
+// AddStats merges stats into those of the sensor type. Types without
+// readings are left out.
+func (m *LocationMetrics) AddStats(typ SensorType, stats ReadingStats) {
+	if stats.Count == 0 {
+		return
+	}
+
+	current := m.Readings[typ]
+	current.merge(stats)
+	m.Readings[typ] = current
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewLocation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		kind        LocationKind
+		locName     string
+		parentID    LocationID
+		geo         *GeoPoint
+		expectError bool
+	}{
+		{name: "organization", kind: LocationOrganization, locName: "Acme"},
+		{name: "site with coordinates", kind: LocationSite, locName: "Madrid", parentID: "acme", geo: &GeoPoint{Lat: 40.4168, Lon: -3.7038}},
+		{name: "organization with parent", kind: LocationOrganization, locName: "Acme", parentID: "other", expectError: true},
+		{name: "zone without parent", kind: LocationZone, locName: "North", expectError: true},
+		{name: "unknown kind", kind: "building", locName: "HQ", parentID: "acme", expectError: true},
+		{name: "missing name", kind: LocationOrganization, expectError: true},
+		{name: "latitude out of range", kind: LocationSite, locName: "Madrid", parentID: "acme", geo: &GeoPoint{Lat: 91}, expectError: true},
+		{name: "longitude out of range", kind: LocationSite, locName: "Madrid", parentID: "acme", geo: &GeoPoint{Lon: -181}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLocation("location-1", tt.kind, tt.locName, tt.parentID, tt.geo, nil, now)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidLocation) {
+					t.Errorf("expected ErrInvalidLocation, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocation_CanHang(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	organization, _ := NewLocation("acme", LocationOrganization, "Acme", "", nil, nil, now)
+	site, _ := NewLocation("madrid", LocationSite, "Madrid", "acme", nil, nil, now)
+	area, _ := NewLocation("office", LocationArea, "Office", "madrid", nil, nil, now)
+
+	if err := site.CanHang(organization); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := area.CanHang(organization); !errors.Is(err, ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation, got %v", err)
+	}
+}
+
+func TestGeoPoint_DistanceKm(t *testing.T) {
+	madrid := GeoPoint{Lat: 40.4168, Lon: -3.7038}
+	barcelona := GeoPoint{Lat: 41.3874, Lon: 2.1686}
+
+	if distance := madrid.DistanceKm(madrid); distance != 0 {
+		t.Errorf("expected 0, got %v", distance)
+	}
+
+	if distance := madrid.DistanceKm(barcelona); math.Abs(distance-505) > 5 {
+		t.Errorf("expected about 505 km, got %v", distance)
+	}
+}
+
+func TestLocationTree(t *testing.T) {
+	madrid := &GeoPoint{Lat: 40.4168, Lon: -3.7038}
+	tree := NewLocationTree([]*Location{
+		{ID: "acme", Kind: LocationOrganization},
+		{ID: "madrid", Kind: LocationSite, ParentID: "acme", Geo: madrid},
+		{ID: "office", Kind: LocationArea, ParentID: "madrid"},
+		{ID: "north", Kind: LocationZone, ParentID: "office"},
+		{ID: "bilbao", Kind: LocationSite, ParentID: "acme"},
+	})
+
+	subtree := tree.Subtree("madrid")
+	if len(subtree) != 3 || !subtree["north"] || subtree["bilbao"] {
+		t.Errorf("unexpected subtree: %v", subtree)
+	}
+
+	if position := tree.Position("north"); position != madrid {
+		t.Errorf("expected north to inherit the coordinates of madrid, got %v", position)
+	}
+
+	if position := tree.Position("bilbao"); position != nil {
+		t.Errorf("expected no position for bilbao, got %v", position)
+	}
+}
+
+func TestLocationMetrics_Absorb(t *testing.T) {
+	site := NewLocationMetrics(&Location{ID: "madrid", Kind: LocationSite})
+	area := NewLocationMetrics(&Location{ID: "office", Kind: LocationArea})
+
+	var stats ReadingStats
+	stats.Add(ReadingAggregate{Min: 18, Max: 22, Sum: 80, Count: 4})
+	site.Devices = 1
+	site.AddStats(Temperature, stats)
+
+	stats = ReadingStats{}
+	stats.Add(ReadingAggregate{Min: 20, Max: 26, Sum: 46, Count: 2})
+	area.Devices = 2
+	area.AddStats(Temperature, stats)
+
+	site.Absorb(area)
+
+	temperature := site.Readings[Temperature]
+	if site.Devices != 3 || temperature.Count != 6 || temperature.Min != 18 || temperature.Max != 26 || temperature.Avg != 21 {
+		t.Errorf("unexpected rolled up metrics: %+v", site)
+	}
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PresenceStatus string
+
+const (
+	PresenceOnline  PresenceStatus = "online"
+	PresenceStale   PresenceStatus = "stale"
+	PresenceOffline PresenceStatus = "offline"
+)
+
+var PresenceStatuses = []PresenceStatus{PresenceOnline, PresenceStale, PresenceOffline}
+
+// PresenceTimeout says how long a device may stay silent before it is
+// reported as stale and, later, as offline.
+type PresenceTimeout struct {
+	Stale   time.Duration `json:"stale"`
+	Offline time.Duration `json:"offline"`
+}
+
+type PresencePolicy struct {
+	Default PresenceTimeout
+	ByType  map[string]PresenceTimeout
+}
+
+func DefaultPresencePolicy() PresencePolicy {
+	return PresencePolicy{
+		Default: PresenceTimeout{Stale: time.Minute, Offline: 5 * time.Minute},
+		ByType:  map[string]PresenceTimeout{},
+	}
+}
+
+func (p PresencePolicy) TimeoutFor(deviceType string) PresenceTimeout {
+	if timeout, ok := p.ByType[deviceType]; ok {
+		return timeout
+	}
+
+	return p.Default
+}
+
+func (p PresencePolicy) Status(deviceType string, lastSeen time.Time, now time.Time) PresenceStatus {
+	if lastSeen.IsZero() {
+		return PresenceOffline
+	}
+
+	timeout := p.TimeoutFor(deviceType)
+	silence := now.Sub(lastSeen)
+	switch {
+	case silence >= timeout.Offline:
+		return PresenceOffline
+	case silence >= timeout.Stale:
+		return PresenceStale
+	default:
+		return PresenceOnline
+	}
+}
+
+// ParsePresencePolicy reads a comma separated list of per device type
+// timeouts such as "gateway=30s/2m,*=1m/5m", where each value is the stale
+// and the offline timeout. The "*" key overrides the default.
+func ParsePresencePolicy(spec string) (PresencePolicy, error) {
+	policy := DefaultPresencePolicy()
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return PresencePolicy{}, fmt.Errorf("%w: %q", ErrInvalidPresencePolicy, entry)
+		}
+
+		timeout, err := parsePresenceTimeout(strings.TrimSpace(value))
+		if err != nil {
+			return PresencePolicy{}, fmt.Errorf("%w: %q: %v", ErrInvalidPresencePolicy, entry, err)
+		}
+
+		if key == "*" {
+			policy.Default = timeout
+		} else {
+			policy.ByType[key] = timeout
+		}
+	}
+
+	return policy, nil
+}
+
+func parsePresenceTimeout(value string) (PresenceTimeout, error) {
+	staleValue, offlineValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return PresenceTimeout{}, fmt.Errorf("expected <stale>/<offline>")
+	}
+
+	stale, err := time.ParseDuration(staleValue)
+	if err != nil {
+		return PresenceTimeout{}, err
+	}
+
+	offline, err := time.ParseDuration(offlineValue)
+	if err != nil {
+		return PresenceTimeout{}, err
+	}
+
+	if stale <= 0 || offline <= stale {
+		return PresenceTimeout{}, fmt.Errorf("timeouts must satisfy 0 < stale < offline")
+	}
+
+	return PresenceTimeout{Stale: stale, Offline: offline}, nil
+}
+
+type DevicePresence struct {
+	Status     PresenceStatus `json:"status"`
+	LastSeenAt *time.Time     `json:"last_seen_at,omitempty"`
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPresencePolicy_Status(t *testing.T) {
+	policy := DefaultPresencePolicy()
+	policy.ByType["gateway"] = PresenceTimeout{Stale: 10 * time.Second, Offline: 30 * time.Second}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		deviceType string
+		lastSeen   time.Time
+		expected   PresenceStatus
+	}{
+		{name: "never seen", deviceType: "gateway", expected: PresenceOffline},
+		{name: "recent heartbeat", deviceType: "gateway", lastSeen: now.Add(-5 * time.Second), expected: PresenceOnline},
+		{name: "stale gateway", deviceType: "gateway", lastSeen: now.Add(-15 * time.Second), expected: PresenceStale},
+		{name: "offline gateway", deviceType: "gateway", lastSeen: now.Add(-30 * time.Second), expected: PresenceOffline},
+		{name: "default timeouts for other types", deviceType: "meter", lastSeen: now.Add(-30 * time.Second), expected: PresenceOnline},
+		{name: "default offline", deviceType: "meter", lastSeen: now.Add(-5 * time.Minute), expected: PresenceOffline},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := policy.Status(tt.deviceType, tt.lastSeen, now); status != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestParsePresencePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		deviceType  string
+		expected    PresenceTimeout
+		expectError bool
+	}{
+		{name: "empty spec uses defaults", spec: "", deviceType: "gateway", expected: PresenceTimeout{Stale: time.Minute, Offline: 5 * time.Minute}},
+		{name: "per type timeout", spec: "gateway=30s/2m", deviceType: "gateway", expected: PresenceTimeout{Stale: 30 * time.Second, Offline: 2 * time.Minute}},
+		{name: "wildcard overrides default", spec: "gateway=30s/2m,*=2m/10m", deviceType: "meter", expected: PresenceTimeout{Stale: 2 * time.Minute, Offline: 10 * time.Minute}},
+		{name: "missing offline timeout", spec: "gateway=30s", expectError: true},
+		{name: "offline before stale", spec: "gateway=2m/30s", expectError: true},
+		{name: "missing key", spec: "=30s/2m", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePresencePolicy(tt.spec)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if timeout := policy.TimeoutFor(tt.deviceType); timeout != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, timeout)
+			}
+		})
+	}
+}
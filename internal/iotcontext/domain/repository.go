@@ -1,26 +1,203 @@
 package domain
 
+import (
+	"io"
+	"time"
+)
+
 type SensorRepository interface {
 	Save(sensor *Sensor) error
 	FindByID(id SensorID) (*Sensor, error)
 	FindAll() ([]*Sensor, error)
+	FindByTenant(tenant TenantID) ([]*Sensor, error)
+	FindByDeviceID(deviceID DeviceID) ([]*Sensor, error)
+	// FindByDeviceIDs returns the sensors of all the devices at once.
+	FindByDeviceIDs(deviceIDs []DeviceID) ([]*Sensor, error)
 	Update(sensor *Sensor) error
+	Delete(id SensorID) error
+}
+
+type SensorConfigHistoryRepository interface {
+	// Append stores a new revision. A revision the sensor already has is an
+	// ErrSensorConfigConflict: someone else changed the config first.
+	Append(revision *SensorConfigRevision) error
+	// FindBySensorID returns the revisions of the sensor, newest first.
+	FindBySensorID(sensorID SensorID, limit int) ([]*SensorConfigRevision, error)
+	FindRevision(sensorID SensorID, revision int64) (*SensorConfigRevision, error)
 }
 
 type SensorReadingRepository interface {
 	Save(reading *SensorReading) error
 	FindBySensorID(sensorID SensorID, limit int) ([]SensorReading, error)
+	FindBySensorIDBetween(sensorID SensorID, from, to time.Time, limit int) ([]SensorReading, error)
+	// FindLatestBySensorIDs returns the latest reading of each of the sensors
+	// that has any.
+	FindLatestBySensorIDs(sensorIDs []SensorID) ([]SensorReading, error)
+}
+
+// ReadingFeed delivers the readings stored from the moment of subscribing
+// that match. A subscriber that falls behind misses readings rather than
+// hold up ingestion; cancel ends the subscription and closes the channel.
+type ReadingFeed interface {
+	Subscribe(match func(reading SensorReading) bool, buffer int) (readings <-chan SensorReading, cancel func())
+}
+
+type ReadingRollupRepository interface {
+	Apply(reading SensorReading) error
+	FindAggregates(sensorID SensorID, resolution Resolution, from, to time.Time) ([]ReadingAggregate, error)
 }
 
 type DeviceRepository interface {
 	Save(device *Device) error
 	FindByID(id DeviceID) (Device, error)
+	// FindByIDs returns the devices of the ids that exist, leaving the rest
+	// out.
+	FindByIDs(ids []DeviceID) ([]Device, error)
 	FindAll() ([]Device, error)
+	FindByTenant(tenant TenantID) ([]Device, error)
 	Update(device *Device) error
+	Delete(id DeviceID) error
 }
 
 type SimulatorRepository interface {
 	Start(sensorID SensorID) error
 	Stop(sensorID SensorID) error
 	InjectError(sensorID SensorID) error
+	// Execute runs a command against the simulations of the device's sensors,
+	// returning ErrSimulationNotActive when none of them is running.
+	Execute(command Command) (map[string]interface{}, error)
+	// Simulates reports whether any sensor of the device is being simulated.
+	Simulates(deviceID DeviceID) bool
+}
+
+type SensorReadingRetentionRepository interface {
+	ListPartitions() ([]ReadingsPartition, error)
+	CreatePartition(partition ReadingsPartition) error
+	DropPartition(partition ReadingsPartition) error
+	DeleteReadingsBefore(scope RetentionScope, cutoff time.Time) (int64, error)
+}
+
+// PresenceRepository keeps the last time each device was heard from, either
+// through a heartbeat or a reading of one of its sensors.
+type PresenceRepository interface {
+	Touch(deviceID DeviceID, at time.Time) error
+	LastSeen(deviceID DeviceID) (time.Time, error)
+	All() (map[DeviceID]time.Time, error)
+}
+
+type TwinRepository interface {
+	FindByDeviceID(deviceID DeviceID) (*DeviceTwin, error)
+	// Save stores the twin only if the stored copy still has previousVersion
+	// (0 for a twin that was never saved); otherwise it returns
+	// ErrTwinVersionConflict.
+	Save(twin *DeviceTwin, previousVersion int64) error
+}
+
+type CommandRepository interface {
+	Save(command *Command) error
+	Update(command *Command) error
+	FindByID(id CommandID) (*Command, error)
+	FindByDeviceID(deviceID DeviceID, limit int) ([]*Command, error)
+	// FindOpen returns the pending and delivered commands, oldest first.
+	FindOpen() ([]*Command, error)
+}
+
+type FirmwareRepository interface {
+	// Save returns ErrFirmwareAlreadyExists when the id or the version for
+	// the device type is already taken.
+	Save(firmware *Firmware) error
+	FindByID(id FirmwareID) (*Firmware, error)
+	// FindAll returns every firmware, newest first.
+	FindAll() ([]*Firmware, error)
+}
+
+// ArtifactStore keeps firmware images. Put returns the size and the hex
+// sha256 of what it stored.
+type ArtifactStore interface {
+	Put(id FirmwareID, content io.Reader) (int64, string, error)
+	Open(id FirmwareID) (io.ReadCloser, error)
+	Delete(id FirmwareID) error
+}
+
+type CampaignRepository interface {
+	// Save stores a new campaign together with the updates of its targets.
+	Save(campaign *Campaign, updates []*DeviceUpdate) error
+	Update(campaign *Campaign) error
+	FindByID(id CampaignID) (*Campaign, error)
+	// FindAll returns every campaign, newest first.
+	FindAll() ([]*Campaign, error)
+	// FindActive returns the running and paused campaigns, oldest first.
+	FindActive() ([]*Campaign, error)
+	// FindDeviceUpdates returns the updates of a campaign in rollout order.
+	FindDeviceUpdates(campaignID CampaignID) ([]*DeviceUpdate, error)
+	FindDeviceUpdate(campaignID CampaignID, deviceID DeviceID) (*DeviceUpdate, error)
+	UpdateDeviceUpdate(update *DeviceUpdate) error
+}
+
+type CredentialRepository interface {
+	Save(credential *Credential) error
+	Update(credential *Credential) error
+	FindByID(id CredentialID) (*Credential, error)
+	// FindByDeviceID returns every credential of the device, oldest first.
+	FindByDeviceID(deviceID DeviceID) ([]*Credential, error)
+	FindByFingerprint(fingerprint string) (*Credential, error)
+}
+
+type ClaimTokenRepository interface {
+	Save(token *ClaimToken) error
+	// Redeem uses up one use of the token with the given hash, atomically,
+	// and returns ErrClaimTokenRejected when it cannot be redeemed at now.
+	Redeem(tokenHash string, now time.Time) (*ClaimToken, error)
+}
+
+type APIKeyRepository interface {
+	Save(key *APIKey) error
+	Update(key *APIKey) error
+	FindByID(id APIKeyID) (*APIKey, error)
+	// FindAll returns every key, oldest first.
+	FindAll() ([]*APIKey, error)
+	// FindByTenant returns the keys of the tenant, oldest first.
+	FindByTenant(tenant TenantID) ([]*APIKey, error)
+}
+
+// TokenVerifier checks the bearer tokens of an identity provider and returns
+// who they were issued to.
+type TokenVerifier interface {
+	Verify(token string, now time.Time) (Principal, error)
+}
+
+// CertificateAuthority issues the client certificates devices authenticate
+// with over TLS.
+type CertificateAuthority interface {
+	Issue(deviceID DeviceID, now time.Time) (*IssuedCertificate, error)
+	// CertificatePEM is the CA certificate devices and the TLS server trust.
+	CertificatePEM() string
+}
+
+type GroupRepository interface {
+	Save(group *DeviceGroup) error
+	Update(group *DeviceGroup) error
+	Delete(id GroupID) error
+	FindByID(id GroupID) (*DeviceGroup, error)
+	// FindAll returns the groups ordered by name.
+	FindAll() ([]*DeviceGroup, error)
+	FindByTenant(tenant TenantID) ([]*DeviceGroup, error)
+}
+
+type LocationRepository interface {
+	Save(location *Location) error
+	Update(location *Location) error
+	Delete(id LocationID) error
+	FindByID(id LocationID) (*Location, error)
+	// FindAll returns every location ordered by name.
+	FindAll() ([]*Location, error)
+	FindByTenant(tenant TenantID) ([]*Location, error)
+}
+
+// AuditRepository stores the audit log. It is append only.
+type AuditRepository interface {
+	Append(entry *AuditEntry) error
+	// Find returns the entries passing the filter, newest first, at most
+	// filter.Limit of them when it is set.
+	Find(filter AuditFilter) ([]*AuditEntry, error)
 }
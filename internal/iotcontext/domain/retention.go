@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PartitionInterval string
+
+const (
+	PartitionDaily   PartitionInterval = "daily"
+	PartitionMonthly PartitionInterval = "monthly"
+)
+
+func ParsePartitionInterval(value string) (PartitionInterval, error) {
+	switch PartitionInterval(strings.ToLower(strings.TrimSpace(value))) {
+	case PartitionDaily:
+		return PartitionDaily, nil
+	case PartitionMonthly, "":
+		return PartitionMonthly, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidPartitionInterval, value)
+	}
+}
+
+func (p PartitionInterval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if p == PartitionDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (p PartitionInterval) Next(t time.Time) time.Time {
+	start := p.Truncate(t)
+	if p == PartitionDaily {
+		return start.AddDate(0, 0, 1)
+	}
+
+	return start.AddDate(0, 1, 0)
+}
+
+type ReadingsPartition struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (p ReadingsPartition) Overlaps(other ReadingsPartition) bool {
+	return p.From.Before(other.To) && other.From.Before(p.To)
+}
+
+type RetentionPolicy struct {
+	SensorType SensorType    `json:"sensor_type,omitempty"`
+	DeviceID   DeviceID      `json:"device_id,omitempty"`
+	MaxAge     time.Duration `json:"max_age"`
+}
+
+func NewRetentionPolicy(sensorType SensorType, deviceID DeviceID, maxAge time.Duration) (RetentionPolicy, error) {
+	if sensorType != "" && deviceID != "" {
+		return RetentionPolicy{}, errors.New("retention policy targets either a sensor type or a device")
+	}
+
+	if maxAge <= 0 {
+		return RetentionPolicy{}, errors.New("retention max age must be positive")
+	}
+
+	return RetentionPolicy{
+		SensorType: sensorType,
+		DeviceID:   deviceID,
+		MaxAge:     maxAge,
+	}, nil
+}
+
+func (p RetentionPolicy) IsDefault() bool {
+	return p.SensorType == "" && p.DeviceID == ""
+}
+
+func (p RetentionPolicy) Cutoff(now time.Time) time.Time {
+	return now.UTC().Add(-p.MaxAge)
+}
+
+// RetentionScope selects the readings a single retention policy is allowed to
+// delete. Exclusions keep more specific policies in charge of their own rows.
+type RetentionScope struct {
+	SensorType     SensorType
+	DeviceID       DeviceID
+	ExcludeTypes   []SensorType
+	ExcludeDevices []DeviceID
+}
+
+func (s RetentionScope) Matches(reading SensorReading) bool {
+	if s.SensorType != "" && reading.Type != s.SensorType {
+		return false
+	}
+
+	if s.DeviceID != "" && reading.DeviceID != s.DeviceID {
+		return false
+	}
+
+	for _, typ := range s.ExcludeTypes {
+		if reading.Type == typ {
+			return false
+		}
+	}
+
+	for _, id := range s.ExcludeDevices {
+		if reading.DeviceID == id {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseRetentionPolicies reads a comma separated list such as
+// "temperature=720h,device:abc=24h,*=90d". The "*" key is the default policy.
+func ParseRetentionPolicies(spec string) ([]RetentionPolicy, error) {
+	var policies []RetentionPolicy
+	seen := make(map[string]bool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRetentionPolicy, entry)
+		}
+
+		key = strings.TrimSpace(key)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicated key %q", ErrInvalidRetentionPolicy, key)
+		}
+		seen[key] = true
+
+		maxAge, err := parseRetentionAge(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidRetentionPolicy, entry, err)
+		}
+
+		var sensorType SensorType
+		var deviceID DeviceID
+		switch {
+		case key == "*":
+		case strings.HasPrefix(key, "device:"):
+			deviceID = DeviceID(strings.TrimPrefix(key, "device:"))
+			if deviceID == "" {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRetentionPolicy, entry)
+			}
+		case key == "":
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRetentionPolicy, entry)
+		default:
+			sensorType = SensorType(key)
+		}
+
+		policy, err := NewRetentionPolicy(sensorType, deviceID, maxAge)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidRetentionPolicy, entry, err)
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func parseRetentionAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePartitionInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    PartitionInterval
+		expectError bool
+	}{
+		{name: "daily", value: "daily", expected: PartitionDaily},
+		{name: "monthly uppercase", value: "MONTHLY", expected: PartitionMonthly},
+		{name: "empty defaults to monthly", value: "", expected: PartitionMonthly},
+		{name: "unknown interval", value: "weekly", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval, err := ParsePartitionInterval(tt.value)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidPartitionInterval) {
+					t.Errorf("expected ErrInvalidPartitionInterval, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if interval != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, interval)
+			}
+		})
+	}
+}
+
+func TestPartitionInterval_Boundaries(t *testing.T) {
+	ts := time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC)
+
+	if got := PartitionDaily.Truncate(ts); !got.Equal(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily start %v", got)
+	}
+
+	if got := PartitionDaily.Next(ts); !got.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily end %v", got)
+	}
+
+	if got := PartitionMonthly.Truncate(ts); !got.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected monthly start %v", got)
+	}
+
+	if got := PartitionMonthly.Next(ts); !got.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected monthly end %v", got)
+	}
+}
+
+func TestParseRetentionPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expected    []RetentionPolicy
+		expectError bool
+	}{
+		{
+			name:     "empty spec keeps everything",
+			spec:     "",
+			expected: nil,
+		},
+		{
+			name: "type, device and default policies",
+			spec: "temperature=720h, device:device-1=24h, *=90d",
+			expected: []RetentionPolicy{
+				{SensorType: Temperature, MaxAge: 720 * time.Hour},
+				{DeviceID: "device-1", MaxAge: 24 * time.Hour},
+				{MaxAge: 90 * 24 * time.Hour},
+			},
+		},
+		{name: "missing duration", spec: "temperature", expectError: true},
+		{name: "invalid duration", spec: "temperature=soon", expectError: true},
+		{name: "non positive duration", spec: "*=0s", expectError: true},
+		{name: "empty device id", spec: "device:=1h", expectError: true},
+		{name: "duplicated key", spec: "*=1h,*=2h", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := ParseRetentionPolicies(tt.spec)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidRetentionPolicy) {
+					t.Errorf("expected ErrInvalidRetentionPolicy, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(policies) != len(tt.expected) {
+				t.Fatalf("expected %d policies, got %d", len(tt.expected), len(policies))
+			}
+
+			for i, policy := range policies {
+				if policy != tt.expected[i] {
+					t.Errorf("expected policy %+v, got %+v", tt.expected[i], policy)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRetentionPolicy(t *testing.T) {
+	if _, err := NewRetentionPolicy(Temperature, "device-1", time.Hour); err == nil {
+		t.Error("expected error when targeting both a type and a device")
+	}
+
+	policy, err := NewRetentionPolicy("", "", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !policy.IsDefault() {
+		t.Error("expected policy without target to be the default one")
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if !policy.Cutoff(now).Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected cutoff %v", policy.Cutoff(now))
+	}
+}
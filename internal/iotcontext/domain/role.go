@@ -0,0 +1,52 @@
+package domain
+
+import "fmt"
+
+// Role is what a caller of the API is allowed to do.
+type Role string
+
+const (
+	// RoleViewer may only read.
+	RoleViewer Role = "viewer"
+	// RoleOperator may also create, change and delete resources and drive
+	// devices, simulations and campaigns.
+	RoleOperator Role = "operator"
+	// RoleAdmin may do anything, including managing API keys.
+	RoleAdmin Role = "admin"
+	// RoleDevice may only call the endpoints devices report to.
+	RoleDevice Role = "device"
+)
+
+func ParseRole(value string) (Role, error) {
+	role := Role(value)
+	switch role {
+	case RoleViewer, RoleOperator, RoleAdmin, RoleDevice:
+		return role, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidRole, value)
+	}
+}
+
+// Allows reports whether the role may do what required may. An operator may
+// do what a viewer may, and an admin anything; a device is kept apart from
+// the other roles.
+func (r Role) Allows(required Role) bool {
+	switch r {
+	case RoleAdmin:
+		return true
+	case RoleOperator:
+		return required == RoleOperator || required == RoleViewer
+	case RoleViewer, RoleDevice:
+		return required == r
+	default:
+		return false
+	}
+}
+
+// Principal is who a request is made by. A principal without a tenant acts
+// for the whole deployment and may pick any tenant.
+type Principal struct {
+	Subject  string   `json:"subject"`
+	Role     Role     `json:"role"`
+	TenantID TenantID `json:"tenant_id,omitempty"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		expected bool
+	}{
+		{role: RoleViewer, required: RoleViewer, expected: true},
+		{role: RoleViewer, required: RoleOperator},
+		{role: RoleOperator, required: RoleViewer, expected: true},
+		{role: RoleOperator, required: RoleOperator, expected: true},
+		{role: RoleOperator, required: RoleAdmin},
+		{role: RoleOperator, required: RoleDevice},
+		{role: RoleAdmin, required: RoleAdmin, expected: true},
+		{role: RoleAdmin, required: RoleDevice, expected: true},
+		{role: RoleDevice, required: RoleDevice, expected: true},
+		{role: RoleDevice, required: RoleViewer},
+		{role: "", required: RoleViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.required), func(t *testing.T) {
+			if got := tt.role.Allows(tt.required); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	if role, err := ParseRole("operator"); err != nil || role != RoleOperator {
+		t.Errorf("expected operator, got %q, %v", role, err)
+	}
+
+	for _, value := range []string{"", "root", "Admin"} {
+		if _, err := ParseRole(value); !errors.Is(err, ErrInvalidRole) {
+			t.Errorf("expected ErrInvalidRole for %q, got %v", value, err)
+		}
+	}
+}
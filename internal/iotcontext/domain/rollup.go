@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"time"
+)
+
+type Resolution string
+
+const (
+	ResolutionRaw    Resolution = "raw"
+	ResolutionMinute Resolution = "1m"
+	ResolutionHour   Resolution = "1h"
+	ResolutionDay    Resolution = "1d"
+)
+
+var RollupResolutions = []Resolution{ResolutionMinute, ResolutionHour, ResolutionDay}
+
+func (r Resolution) Duration() time.Duration {
+	switch r {
+	case ResolutionMinute:
+		return time.Minute
+	case ResolutionHour:
+		return time.Hour
+	case ResolutionDay:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (r Resolution) Truncate(t time.Time) time.Time {
+	if r == ResolutionRaw {
+		return t.UTC()
+	}
+
+	return t.UTC().Truncate(r.Duration())
+}
+
+// BucketsBetween returns how many points a series at this resolution needs to
+// cover [from, to). Raw data is estimated from the sensor sampling interval.
+func (r Resolution) BucketsBetween(from, to time.Time, sampling time.Duration) int {
+	step := r.Duration()
+	if r == ResolutionRaw {
+		step = sampling
+	}
+
+	if step <= 0 || !to.After(from) {
+		return 0
+	}
+
+	start := r.Truncate(from)
+	return int((to.Sub(start) + step - 1) / step)
+}
+
+// SelectResolution picks the finest resolution whose point count fits the
+// budget, falling back to coarser rollups for long ranges.
+func SelectResolution(from, to time.Time, maxPoints int, sampling time.Duration) Resolution {
+	if sampling > 0 && ResolutionRaw.BucketsBetween(from, to, sampling) <= maxPoints {
+		return ResolutionRaw
+	}
+
+	for _, resolution := range RollupResolutions {
+		if resolution.BucketsBetween(from, to, sampling) <= maxPoints {
+			return resolution
+		}
+	}
+
+	return ResolutionDay
+}
+
+type ReadingAggregate struct {
+	SensorID   SensorID   `json:"sensor_id"`
+	Resolution Resolution `json:"resolution"`
+	Bucket     time.Time  `json:"bucket"`
+	Min        float64    `json:"min"`
+	Max        float64    `json:"max"`
+	Sum        float64    `json:"-"`
+	Count      int64      `json:"count"`
+	Last       float64    `json:"last"`
+	LastAt     time.Time  `json:"last_at"`
+}
+
+func NewReadingAggregate(reading SensorReading, resolution Resolution) ReadingAggregate {
+	return ReadingAggregate{
+		SensorID:   reading.SensorID,
+		Resolution: resolution,
+		Bucket:     resolution.Truncate(reading.Timestamp),
+		Min:        reading.Value,
+		Max:        reading.Value,
+		Sum:        reading.Value,
+		Count:      1,
+		Last:       reading.Value,
+		LastAt:     reading.Timestamp.UTC(),
+	}
+}
+
+func (a *ReadingAggregate) Add(reading SensorReading) {
+	if reading.Value < a.Min {
+		a.Min = reading.Value
+	}
+
+	if reading.Value > a.Max {
+		a.Max = reading.Value
+	}
+
+	a.Sum += reading.Value
+	a.Count++
+
+	if !reading.Timestamp.Before(a.LastAt) {
+		a.Last = reading.Value
+		a.LastAt = reading.Timestamp.UTC()
+	}
+}
+
+func (a ReadingAggregate) Avg() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+
+	return a.Sum / float64(a.Count)
+}
+
+type ReadingPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Avg    float64   `json:"avg"`
+	Count  int64     `json:"count"`
+	Last   float64   `json:"last"`
+}
+
+func (a ReadingAggregate) Point() ReadingPoint {
+	return ReadingPoint{
+		Bucket: a.Bucket,
+		Min:    a.Min,
+		Max:    a.Max,
+		Avg:    a.Avg(),
+		Count:  a.Count,
+		Last:   a.Last,
+	}
+}
+
+type ReadingSeries struct {
+	SensorID   SensorID       `json:"sensor_id"`
+	Resolution Resolution     `json:"resolution"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Points     []ReadingPoint `json:"points"`
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectResolution(t *testing.T) {
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		to        time.Time
+		maxPoints int
+		sampling  time.Duration
+		expected  Resolution
+	}{
+		{name: "raw when sampling fits", to: from.Add(10 * time.Minute), maxPoints: 1000, sampling: time.Second, expected: ResolutionRaw},
+		{name: "minute when raw is too dense", to: from.Add(10 * time.Minute), maxPoints: 100, sampling: time.Second, expected: ResolutionMinute},
+		{name: "minute without sampling information", to: from.Add(10 * time.Minute), maxPoints: 100, sampling: 0, expected: ResolutionMinute},
+		{name: "hour for a week", to: from.Add(7 * 24 * time.Hour), maxPoints: 500, sampling: time.Second, expected: ResolutionHour},
+		{name: "day for a year", to: from.AddDate(1, 0, 0), maxPoints: 500, sampling: time.Second, expected: ResolutionDay},
+		{name: "day when nothing fits", to: from.AddDate(10, 0, 0), maxPoints: 10, sampling: time.Second, expected: ResolutionDay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectResolution(from, tt.to, tt.maxPoints, tt.sampling); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadingAggregate_Add(t *testing.T) {
+	ts := time.Date(2026, 5, 1, 10, 30, 15, 0, time.UTC)
+
+	aggregate := NewReadingAggregate(NewSensorReading("sensor-1", "device-1", Temperature, 20, "°C", ts), ResolutionHour)
+
+	if !aggregate.Bucket.Equal(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected bucket %v", aggregate.Bucket)
+	}
+
+	aggregate.Add(NewSensorReading("sensor-1", "device-1", Temperature, 30, "°C", ts.Add(time.Minute)))
+	aggregate.Add(NewSensorReading("sensor-1", "device-1", Temperature, 10, "°C", ts.Add(-time.Minute)))
+
+	if aggregate.Min != 10 || aggregate.Max != 30 {
+		t.Errorf("expected min 10 and max 30, got %v and %v", aggregate.Min, aggregate.Max)
+	}
+
+	if aggregate.Count != 3 || aggregate.Avg() != 20 {
+		t.Errorf("expected count 3 and avg 20, got %d and %v", aggregate.Count, aggregate.Avg())
+	}
+
+	if aggregate.Last != 30 {
+		t.Errorf("expected last value from the newest reading, got %v", aggregate.Last)
+	}
+}
+
+func TestResolution_BucketsBetween(t *testing.T) {
+	from := time.Date(2026, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	if got := ResolutionHour.BucketsBetween(from, from.Add(2*time.Hour), 0); got != 3 {
+		t.Errorf("expected 3 hourly buckets for an unaligned range, got %d", got)
+	}
+
+	if got := ResolutionRaw.BucketsBetween(from, from.Add(time.Minute), 0); got != 0 {
+		t.Errorf("expected no raw estimate without sampling, got %d", got)
+	}
+
+	if got := ResolutionDay.BucketsBetween(from, from, 0); got != 0 {
+		t.Errorf("expected empty range to have no buckets, got %d", got)
+	}
+}
@@ -1,31 +1,36 @@
 package domain
 
 import (
-	"errors"
 	"time"
 )
 
+// Sensor measures one magnitude on a device. Like a device, it carries the
+// version the repository moves on with every update; a change made to a
+// stale copy is rejected with ErrSensorVersionConflict.
 type Sensor struct {
 	ID        SensorID     `json:"id"`
+	TenantID  TenantID     `json:"tenant_id"`
 	DeviceID  DeviceID     `json:"device_id"`
 	Name      string       `json:"name"`
 	Type      SensorType   `json:"type"`
 	Config    SensorConfig `json:"config"`
+	Labels    Labels       `json:"labels"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
+	Version   int64        `json:"version"`
 }
 
 func NewSensor(id SensorID, deviceID DeviceID, name string, typ SensorType, config SensorConfig) (*Sensor, error) {
 	if id == "" {
-		return nil, errors.New("sensor id empty")
+		return nil, Invalid(ErrInvalidSensor, FieldError{Field: "id", Reason: "must not be empty"})
 	}
 
 	if deviceID == "" {
-		return nil, errors.New("device id empty")
+		return nil, Invalid(ErrInvalidSensor, FieldError{Field: "device_id", Reason: "must not be empty"})
 	}
 
 	if name == "" {
-		return nil, errors.New("name empty")
+		return nil, Invalid(ErrInvalidSensor, FieldError{Field: "name", Reason: "must not be empty"})
 	}
 
 	if typ == "" {
@@ -34,35 +39,77 @@ func NewSensor(id SensorID, deviceID DeviceID, name string, typ SensorType, conf
 
 	now := time.Now().UTC()
 	config.SensorID = id
+	config.Revision = 1
 	config.UpdatedAt = now
 
 	return &Sensor{
 		ID:        id,
+		TenantID:  DefaultTenant,
 		DeviceID:  deviceID,
 		Name:      name,
 		Type:      typ,
 		Config:    config,
+		Labels:    Labels{},
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
 	}, nil
 }
 
+// UpdateConfig replaces the config of the sensor with its next revision.
 func (s *Sensor) UpdateConfig(cfg SensorConfig) error {
 	if cfg.SensorID != s.ID {
-		return errors.New("config sensor_id mismatch")
+		return Invalid(ErrInvalidSensorConfig, FieldError{Field: "sensor_id", Reason: "must be the id of the sensor"})
 	}
 
 	if cfg.SamplingRateMs <= 0 {
-		return errors.New("sampling rate must be positive")
+		return Invalid(ErrInvalidSensorConfig, FieldError{Field: "sampling_rate_ms", Reason: "must be positive"})
 	}
 
 	if cfg.ErrorRate < 0 || cfg.ErrorRate > 1 {
-		return errors.New("error rate must be between 0 and 1")
+		return Invalid(ErrInvalidSensorConfig, FieldError{Field: "error_rate", Reason: "must be between 0 and 1"})
 	}
 
+	cfg.Revision = s.Config.Revision + 1
 	cfg.UpdatedAt = time.Now().UTC()
 	s.Config = cfg
 	s.UpdatedAt = cfg.UpdatedAt
 
 	return nil
 }
+
+// Relabel replaces every label of the sensor.
+func (s *Sensor) Relabel(labels Labels) error {
+	if err := ValidateLabels(labels); err != nil {
+		return err
+	}
+
+	s.Labels = labels.Copy()
+	s.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+func (s *Sensor) Rename(name string) error {
+	if name == "" {
+		return Invalid(ErrInvalidSensor, FieldError{Field: "name", Reason: "must not be empty"})
+	}
+
+	s.Name = name
+	s.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// MoveTo reassigns the sensor to another device, e.g. after a hardware swap.
+// Readings already stored keep the device they were taken on.
+func (s *Sensor) MoveTo(deviceID DeviceID) error {
+	if deviceID == "" {
+		return Invalid(ErrInvalidSensor, FieldError{Field: "device_id", Reason: "must not be empty"})
+	}
+
+	s.DeviceID = deviceID
+	s.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
@@ -1,12 +1,14 @@
 package domain
 
 import (
-	"errors"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 type SensorConfig struct {
 	SensorID       SensorID               `json:"sensor_id"`
+	Revision       int64                  `json:"revision"`
 	SamplingRateMs int                    `json:"sampling_rate_ms"`
 	Thresholds     Thresholds             `json:"thresholds"`
 	ErrorRate      float64                `json:"error_rate"`
@@ -17,15 +19,15 @@ type SensorConfig struct {
 
 func NewSensorConfig(sensorID SensorID, samplingRateMs int, thresholds Thresholds, errorRate float64, enabled bool) (SensorConfig, error) {
 	if sensorID == "" {
-		return SensorConfig{}, errors.New("sensor id empty")
+		return SensorConfig{}, Invalid(ErrInvalidSensorConfig, FieldError{Field: "sensor_id", Reason: "must not be empty"})
 	}
 
 	if samplingRateMs <= 0 {
-		return SensorConfig{}, errors.New("sampling rate must be positive")
+		return SensorConfig{}, Invalid(ErrInvalidSensorConfig, FieldError{Field: "sampling_rate_ms", Reason: "must be positive"})
 	}
 
 	if errorRate < 0 || errorRate > 1 {
-		return SensorConfig{}, errors.New("error rate between 0 and 1")
+		return SensorConfig{}, Invalid(ErrInvalidSensorConfig, FieldError{Field: "error_rate", Reason: "must be between 0 and 1"})
 	}
 
 	return SensorConfig{
@@ -38,3 +40,86 @@ func NewSensorConfig(sensorID SensorID, samplingRateMs int, thresholds Threshold
 		Meta:           map[string]interface{}{},
 	}, nil
 }
+
+// SensorConfigRevision is one version of the config of a sensor. Revisions
+// of a sensor are numbered from 1 and only ever appended, so the history can
+// be compared and an old revision applied again. A rollback is itself a new
+// revision that names the one it restored.
+type SensorConfigRevision struct {
+	SensorID     SensorID     `json:"sensor_id"`
+	Revision     int64        `json:"revision"`
+	Config       SensorConfig `json:"config"`
+	ChangedBy    string       `json:"changed_by,omitempty"`
+	RestoredFrom int64        `json:"restored_from,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func NewSensorConfigRevision(config SensorConfig, changedBy string, restoredFrom int64) (*SensorConfigRevision, error) {
+	if config.SensorID == "" {
+		return nil, Invalid(ErrInvalidSensorConfig, FieldError{Field: "sensor_id", Reason: "must not be empty"})
+	}
+
+	if config.Revision <= 0 {
+		return nil, fmt.Errorf("%w: revision %d", ErrInvalidSensorConfigRevision, config.Revision)
+	}
+
+	if restoredFrom < 0 || restoredFrom >= config.Revision {
+		return nil, fmt.Errorf("%w: revision %d cannot restore revision %d", ErrInvalidSensorConfigRevision, config.Revision, restoredFrom)
+	}
+
+	return &SensorConfigRevision{
+		SensorID:     config.SensorID,
+		Revision:     config.Revision,
+		Config:       config,
+		ChangedBy:    changedBy,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    config.UpdatedAt,
+	}, nil
+}
+
+// SensorConfigDiff is what changed in the config of a sensor from one
+// revision to another.
+type SensorConfigDiff struct {
+	SensorID SensorID      `json:"sensor_id"`
+	From     int64         `json:"from"`
+	To       int64         `json:"to"`
+	Changes  []AuditChange `json:"changes"`
+}
+
+// DiffSensorConfigs compares two revisions field by field, like the audit
+// log compares snapshots. The revision number and the time of the change
+// differ between any two revisions, so they are left out.
+func DiffSensorConfigs(from, to *SensorConfigRevision) (*SensorConfigDiff, error) {
+	if from.SensorID != to.SensorID {
+		return nil, fmt.Errorf("%w: revisions of different sensors", ErrInvalidSensorConfigRevision)
+	}
+
+	before, err := configSnapshot(from.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := configSnapshot(to.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := DiffSnapshots(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SensorConfigDiff{
+		SensorID: from.SensorID,
+		From:     from.Revision,
+		To:       to.Revision,
+		Changes:  changes,
+	}, nil
+}
+
+func configSnapshot(config SensorConfig) (json.RawMessage, error) {
+	config.Revision = 0
+	config.UpdatedAt = time.Time{}
+
+	return json.Marshal(config)
+}
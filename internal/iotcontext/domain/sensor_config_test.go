@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestNewSensorConfig(t *testing.T) {
@@ -130,3 +132,75 @@ func TestNewSensorConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSensorConfigRevision(t *testing.T) {
+	config := SensorConfig{SensorID: "sensor-123", Revision: 3, SamplingRateMs: 1000}
+
+	tests := []struct {
+		name         string
+		config       SensorConfig
+		restoredFrom int64
+		expectError  bool
+	}{
+		{name: "change", config: config},
+		{name: "rollback", config: config, restoredFrom: 1},
+		{name: "never stored", config: SensorConfig{SensorID: "sensor-123"}, expectError: true},
+		{name: "restores itself", config: config, restoredFrom: 3, expectError: true},
+		{name: "restores a later revision", config: config, restoredFrom: 4, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revision, err := NewSensorConfigRevision(tt.config, "alice", tt.restoredFrom)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidSensorConfigRevision) {
+					t.Errorf("expected ErrInvalidSensorConfigRevision, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if revision.Revision != tt.config.Revision || revision.RestoredFrom != tt.restoredFrom {
+				t.Errorf("unexpected revision %+v", revision)
+			}
+		})
+	}
+}
+
+func TestDiffSensorConfigs(t *testing.T) {
+	max := 30.0
+	from := &SensorConfigRevision{SensorID: "sensor-123", Revision: 1, Config: SensorConfig{
+		SensorID: "sensor-123", Revision: 1, SamplingRateMs: 1000, Enabled: true, UpdatedAt: time.Now(),
+	}}
+	to := &SensorConfigRevision{SensorID: "sensor-123", Revision: 2, Config: SensorConfig{
+		SensorID: "sensor-123", Revision: 2, SamplingRateMs: 5000, Enabled: true, Thresholds: Thresholds{Max: &max}, UpdatedAt: time.Now().Add(time.Minute),
+	}}
+
+	diff, err := DiffSensorConfigs(from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []AuditChange{
+		{Field: "sampling_rate_ms", Before: []byte("1000"), After: []byte("5000")},
+		{Field: "thresholds.max", Before: []byte("null"), After: []byte("30")},
+	}
+	if len(diff.Changes) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, diff.Changes)
+	}
+
+	for i, change := range diff.Changes {
+		if change.Field != expected[i].Field || string(change.Before) != string(expected[i].Before) || string(change.After) != string(expected[i].After) {
+			t.Errorf("expected %s: %s -> %s, got %s: %s -> %s", expected[i].Field, expected[i].Before, expected[i].After, change.Field, change.Before, change.After)
+		}
+	}
+
+	other := &SensorConfigRevision{SensorID: "sensor-456", Revision: 1}
+	if _, err := DiffSensorConfigs(from, other); !errors.Is(err, ErrInvalidSensorConfigRevision) {
+		t.Errorf("expected ErrInvalidSensorConfigRevision, got %v", err)
+	}
+}
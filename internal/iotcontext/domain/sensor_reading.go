@@ -4,6 +4,7 @@ import "time"
 
 type SensorReading struct {
 	ID        string                 `json:"id"`
+	TenantID  TenantID               `json:"tenant_id"`
 	SensorID  SensorID               `json:"sensor_id"`
 	DeviceID  DeviceID               `json:"device_id"`
 	Type      SensorType             `json:"type"`
@@ -15,6 +16,7 @@ type SensorReading struct {
 
 func NewSensorReading(sensorID SensorID, deviceID DeviceID, typ SensorType, value float64, unit string, ts time.Time) SensorReading {
 	return SensorReading{
+		TenantID:  DefaultTenant,
 		SensorID:  sensorID,
 		DeviceID:  deviceID,
 		Type:      typ,
@@ -135,6 +135,10 @@ func TestSensor_UpdateConfig(t *testing.T) {
 		t.Error("expected Enabled to be true")
 	}
 
+	if sensor.Config.Revision != 2 {
+		t.Errorf("expected Revision 2, got %d", sensor.Config.Revision)
+	}
+
 	invalidConfig := SensorConfig{
 		SensorID:       "different-sensor",
 		SamplingRateMs: 1000,
@@ -171,3 +175,19 @@ func TestSensor_UpdateConfig(t *testing.T) {
 		t.Error("expected error for invalid error rate")
 	}
 }
+
+func TestSensor_MoveTo(t *testing.T) {
+	sensor, _ := NewSensor("sensor-123", "device-123", "Temperature Sensor", Temperature, SensorConfig{})
+
+	if err := sensor.MoveTo(""); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	if err := sensor.MoveTo("device-456"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sensor.DeviceID != "device-456" {
+		t.Errorf("expected device-456, got %s", sensor.DeviceID)
+	}
+}
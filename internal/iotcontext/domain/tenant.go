@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// TenantID identifies the customer team that owns a resource. Resources
+// created before tenants existed belong to DefaultTenant.
+type TenantID string
+
+const DefaultTenant TenantID = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ParseTenantID accepts lower case DNS labels, so a tenant id can be used in
+// a NATS subject as is.
+func ParseTenantID(value string) (TenantID, error) {
+	if !tenantPattern.MatchString(value) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, value)
+	}
+
+	return TenantID(value), nil
+}
+
+// TenantQuota caps what a tenant may use. Zero means unlimited.
+type TenantQuota struct {
+	MaxDevices        int `json:"max_devices"`
+	MaxSensors        int `json:"max_sensors"`
+	ReadingsPerMinute int `json:"readings_per_minute"`
+}
+
+func (q TenantQuota) validate() error {
+	if q.MaxDevices < 0 || q.MaxSensors < 0 || q.ReadingsPerMinute < 0 {
+		return fmt.Errorf("%w: quotas cannot be negative", ErrInvalidTenant)
+	}
+
+	return nil
+}
+
+// TenantQuotas holds the quota every tenant gets and the overrides of some.
+type TenantQuotas struct {
+	Default TenantQuota              `json:"default"`
+	Tenants map[TenantID]TenantQuota `json:"tenants"`
+}
+
+// ParseTenantQuotas reads quotas written as JSON, e.g.
+// {"default": {"max_devices": 100}, "tenants": {"acme": {"max_devices": 1000}}}.
+// An empty string means no quotas.
+func ParseTenantQuotas(value string) (TenantQuotas, error) {
+	quotas := TenantQuotas{Tenants: map[TenantID]TenantQuota{}}
+	if value == "" {
+		return quotas, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &quotas); err != nil {
+		return TenantQuotas{}, fmt.Errorf("%w: quotas: %v", ErrInvalidTenant, err)
+	}
+
+	if err := quotas.Default.validate(); err != nil {
+		return TenantQuotas{}, err
+	}
+
+	for tenant, quota := range quotas.Tenants {
+		if _, err := ParseTenantID(string(tenant)); err != nil {
+			return TenantQuotas{}, err
+		}
+		if err := quota.validate(); err != nil {
+			return TenantQuotas{}, err
+		}
+	}
+
+	return quotas, nil
+}
+
+func (q TenantQuotas) For(tenant TenantID) TenantQuota {
+	if quota, ok := q.Tenants[tenant]; ok {
+		return quota
+	}
+
+	return q.Default
+}
+
+// RateWindow counts the readings of a tenant in the current minute.
+type RateWindow struct {
+	Start time.Time
+	Count int
+}
+
+// Allow records one more event at now and reports whether it fits in limit
+// per minute. A limit of zero allows everything.
+func (w *RateWindow) Allow(limit int, now time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	start := now.UTC().Truncate(time.Minute)
+	if !w.Start.Equal(start) {
+		w.Start = start
+		w.Count = 0
+	}
+
+	if w.Count >= limit {
+		return false
+	}
+
+	w.Count++
+
+	return true
+}
+
+// EventSubject is the NATS subject of an event: tenants.<tenant>.<type>, so
+// consumers can subscribe to one tenant, or to every tenant with
+// tenants.*.<type>. Events without a tenant keep the bare type.
+func EventSubject(event IoTEvent) string {
+	if event.TenantID == "" {
+		return event.Type
+	}
+
+	return "tenants." + string(event.TenantID) + "." + event.Type
+}
+
+// WithTenant returns the event owned by tenant, unless it already has one.
+func (e IoTEvent) WithTenant(tenant TenantID) IoTEvent {
+	if e.TenantID == "" {
+		e.TenantID = tenant
+	}
+
+	return e
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTenantID(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectError bool
+	}{
+		{value: "acme"},
+		{value: "team-42"},
+		{value: "", expectError: true},
+		{value: "Acme", expectError: true},
+		{value: "acme.eu", expectError: true},
+		{value: "-acme", expectError: true},
+		{value: "acme*", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseTenantID(tt.value)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidTenant) {
+					t.Errorf("expected ErrInvalidTenant, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseTenantQuotas(t *testing.T) {
+	quotas, err := ParseTenantQuotas(`{"default": {"max_devices": 10}, "tenants": {"acme": {"max_devices": 100, "readings_per_minute": 600}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if quota := quotas.For("acme"); quota.MaxDevices != 100 || quota.ReadingsPerMinute != 600 {
+		t.Errorf("unexpected quota for acme: %+v", quota)
+	}
+
+	if quota := quotas.For("globex"); quota.MaxDevices != 10 || quota.ReadingsPerMinute != 0 {
+		t.Errorf("expected the default quota for globex, got %+v", quota)
+	}
+
+	if quotas, err := ParseTenantQuotas(""); err != nil || quotas.For("acme") != (TenantQuota{}) {
+		t.Errorf("expected no quotas, got %+v, %v", quotas, err)
+	}
+
+	for _, value := range []string{`{"default": {"max_sensors": -1}}`, `{"tenants": {"ACME": {}}}`, `not json`} {
+		if _, err := ParseTenantQuotas(value); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("expected ErrInvalidTenant for %s, got %v", value, err)
+		}
+	}
+}
+
+func TestRateWindow_Allow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+	var window RateWindow
+
+	for i := 0; i < 2; i++ {
+		if !window.Allow(2, start.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("expected reading %d to be allowed", i+1)
+		}
+	}
+
+	if window.Allow(2, start.Add(40*time.Second)) {
+		t.Error("expected the third reading in the same minute to be rejected")
+	}
+
+	if !window.Allow(2, start.Add(50*time.Second)) {
+		t.Error("expected the next minute to start a new window")
+	}
+
+	if !window.Allow(0, start) {
+		t.Error("expected no limit to allow everything")
+	}
+}
+
+func TestEventSubject(t *testing.T) {
+	event := IoTEvent{Type: "device.created"}
+	if subject := EventSubject(event); subject != "device.created" {
+		t.Errorf("expected the bare type without a tenant, got %s", subject)
+	}
+
+	if subject := EventSubject(event.WithTenant("acme")); subject != "tenants.acme.device.created" {
+		t.Errorf("unexpected subject %s", subject)
+	}
+
+	if owned := event.WithTenant("acme").WithTenant("globex"); owned.TenantID != "acme" {
+		t.Errorf("expected the event to keep its tenant, got %s", owned.TenantID)
+	}
+}
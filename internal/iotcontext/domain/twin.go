@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// AnyVersion skips the optimistic concurrency check of a twin update.
+const AnyVersion int64 = -1
+
+type TwinProperties map[string]interface{}
+
+// DeviceTwin holds the configuration the API wants a device to have
+// (desired), what the device says it has applied (reported) and, derived
+// from both, what is still pending (delta).
+//
+// DesiredVersion and ReportedVersion guard each section against concurrent
+// writers; Version changes on every update and is used by the repositories
+// to detect lost updates.
+type DeviceTwin struct {
+	DeviceID        DeviceID       `json:"device_id"`
+	Desired         TwinProperties `json:"desired"`
+	Reported        TwinProperties `json:"reported"`
+	DesiredVersion  int64          `json:"desired_version"`
+	ReportedVersion int64          `json:"reported_version"`
+	Version         int64          `json:"version"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+func NewDeviceTwin(deviceID DeviceID) *DeviceTwin {
+	return &DeviceTwin{
+		DeviceID: deviceID,
+		Desired:  TwinProperties{},
+		Reported: TwinProperties{},
+	}
+}
+
+// UpdateDesired applies patch to the desired properties using JSON merge
+// patch semantics: nested objects are merged and null removes a key.
+func (t *DeviceTwin) UpdateDesired(patch TwinProperties, expectedVersion int64) error {
+	if expectedVersion != AnyVersion && expectedVersion != t.DesiredVersion {
+		return fmt.Errorf("%w: desired version is %d, got %d", ErrTwinVersionConflict, t.DesiredVersion, expectedVersion)
+	}
+
+	desired, err := mergeTwinPatch(t.Desired, patch)
+	if err != nil {
+		return err
+	}
+
+	t.Desired = desired
+	t.DesiredVersion++
+	t.touch()
+
+	return nil
+}
+
+func (t *DeviceTwin) UpdateReported(patch TwinProperties, expectedVersion int64) error {
+	if expectedVersion != AnyVersion && expectedVersion != t.ReportedVersion {
+		return fmt.Errorf("%w: reported version is %d, got %d", ErrTwinVersionConflict, t.ReportedVersion, expectedVersion)
+	}
+
+	reported, err := mergeTwinPatch(t.Reported, patch)
+	if err != nil {
+		return err
+	}
+
+	t.Reported = reported
+	t.ReportedVersion++
+	t.touch()
+
+	return nil
+}
+
+// Delta returns the desired properties the device has not reported yet with
+// the same value.
+func (t *DeviceTwin) Delta() TwinProperties {
+	return twinDelta(t.Desired, t.Reported)
+}
+
+func (t *DeviceTwin) MarshalJSON() ([]byte, error) {
+	type twin DeviceTwin
+
+	return json.Marshal(struct {
+		*twin
+		Delta TwinProperties `json:"delta"`
+	}{
+		twin:  (*twin)(t),
+		Delta: t.Delta(),
+	})
+}
+
+func (t *DeviceTwin) touch() {
+	t.Version++
+	t.UpdatedAt = time.Now().UTC()
+}
+
+// mergeTwinPatch returns a new document; values go through a JSON round trip
+// so numbers compare the same way once the twin is loaded from storage.
+func mergeTwinPatch(document TwinProperties, patch TwinProperties) (TwinProperties, error) {
+	normalized, err := normalizeTwinProperties(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, _ := normalizeTwinProperties(document)
+	if merged == nil {
+		merged = TwinProperties{}
+	}
+
+	applyTwinPatch(merged, normalized)
+
+	return merged, nil
+}
+
+func applyTwinPatch(document map[string]interface{}, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(document, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		current, hasObject := document[key].(map[string]interface{})
+		if isObject && hasObject {
+			applyTwinPatch(current, patchObject)
+			continue
+		}
+
+		if isObject {
+			object := map[string]interface{}{}
+			applyTwinPatch(object, patchObject)
+			value = object
+		}
+
+		document[key] = value
+	}
+}
+
+func twinDelta(desired map[string]interface{}, reported map[string]interface{}) TwinProperties {
+	delta := TwinProperties{}
+
+	for key, want := range desired {
+		have, ok := reported[key]
+		if ok && reflect.DeepEqual(want, have) {
+			continue
+		}
+
+		wantObject, isObject := want.(map[string]interface{})
+		haveObject, hasObject := have.(map[string]interface{})
+		if isObject && hasObject {
+			if nested := twinDelta(wantObject, haveObject); len(nested) > 0 {
+				delta[key] = map[string]interface{}(nested)
+			}
+			continue
+		}
+
+		delta[key] = want
+	}
+
+	return delta
+}
+
+func normalizeTwinProperties(properties TwinProperties) (TwinProperties, error) {
+	if properties == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return nil, fmt.Errorf("invalid twin properties: %w", err)
+	}
+
+	var normalized TwinProperties
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("invalid twin properties: %w", err)
+	}
+
+	return normalized, nil
+}
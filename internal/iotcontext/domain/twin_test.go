@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDeviceTwin_UpdateDesired(t *testing.T) {
+	twin := NewDeviceTwin("device-123")
+
+	if err := twin.UpdateDesired(TwinProperties{"mode": "eco", "thresholds": map[string]interface{}{"min": 10, "max": 30}}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := twin.UpdateDesired(TwinProperties{"mode": nil, "thresholds": map[string]interface{}{"max": 35}}, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := TwinProperties{"thresholds": map[string]interface{}{"min": float64(10), "max": float64(35)}}
+	if !reflect.DeepEqual(twin.Desired, expected) {
+		t.Errorf("expected %v, got %v", expected, twin.Desired)
+	}
+
+	if twin.DesiredVersion != 2 || twin.Version != 2 {
+		t.Errorf("expected desired version 2 and version 2, got %d and %d", twin.DesiredVersion, twin.Version)
+	}
+
+	if err := twin.UpdateDesired(TwinProperties{"mode": "boost"}, 1); !errors.Is(err, ErrTwinVersionConflict) {
+		t.Errorf("expected ErrTwinVersionConflict, got %v", err)
+	}
+
+	if err := twin.UpdateDesired(TwinProperties{"mode": "boost"}, AnyVersion); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDeviceTwin_Delta(t *testing.T) {
+	tests := []struct {
+		name     string
+		desired  TwinProperties
+		reported TwinProperties
+		expected TwinProperties
+	}{
+		{
+			name:     "nothing reported yet",
+			desired:  TwinProperties{"sampling_rate_ms": 500},
+			reported: TwinProperties{},
+			expected: TwinProperties{"sampling_rate_ms": float64(500)},
+		},
+		{
+			name:     "converged",
+			desired:  TwinProperties{"sampling_rate_ms": 500},
+			reported: TwinProperties{"sampling_rate_ms": 500, "firmware": "1.2.0"},
+			expected: TwinProperties{},
+		},
+		{
+			name:     "nested objects only report what differs",
+			desired:  TwinProperties{"thresholds": map[string]interface{}{"min": 10, "max": 35}},
+			reported: TwinProperties{"thresholds": map[string]interface{}{"min": 10, "max": 30}},
+			expected: TwinProperties{"thresholds": map[string]interface{}{"max": float64(35)}},
+		},
+		{
+			name:     "type change",
+			desired:  TwinProperties{"mode": map[string]interface{}{"name": "eco"}},
+			reported: TwinProperties{"mode": "eco"},
+			expected: TwinProperties{"mode": map[string]interface{}{"name": "eco"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			twin := NewDeviceTwin("device-123")
+			_ = twin.UpdateDesired(tt.desired, AnyVersion)
+			_ = twin.UpdateReported(tt.reported, AnyVersion)
+
+			if delta := twin.Delta(); !reflect.DeepEqual(delta, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, delta)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package graphql
+
+import "sync"
+
+// loader batches the lookups of one request. Keys are queued as the objects
+// that may need them are resolved, without fetching anything; the first Load
+// then fetches every key queued so far in a single call. A list of devices
+// thus costs one lookup of their sensors, and those sensors one lookup of
+// their latest readings, however long the lists are. Results are kept for
+// the rest of the request.
+type loader[K comparable, V any] struct {
+	fetch   func(keys []K) (map[K]V, error)
+	mu      sync.Mutex
+	queued  map[K]bool
+	pending map[K]chan struct{}
+	results map[K]result[V]
+}
+
+type result[V any] struct {
+	value V
+	err   error
+}
+
+// newLoader returns a loader fetching with fetch, which leaves out the keys
+// it finds nothing for; those load the zero value.
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  map[K]bool{},
+		pending: map[K]chan struct{}{},
+		results: map[K]result[V]{},
+	}
+}
+
+// Queue adds the keys to the next fetch, unless they are loaded or being
+// loaded already.
+func (l *loader[K, V]) Queue(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if !l.known(key) {
+			l.queued[key] = true
+		}
+	}
+}
+
+// Prime records a value the request already has, sparing its lookup.
+func (l *loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, pending := l.pending[key]; pending {
+		return
+	}
+
+	delete(l.queued, key)
+	l.results[key] = result[V]{value: value}
+}
+
+// Load returns the value of key. A key not loaded yet is fetched together
+// with every queued key; one being fetched by another resolver is waited
+// for.
+func (l *loader[K, V]) Load(key K) (V, error) {
+	l.mu.Lock()
+
+	if done, pending := l.pending[key]; pending {
+		l.mu.Unlock()
+		<-done
+		l.mu.Lock()
+	} else if _, loaded := l.results[key]; !loaded {
+		l.queued[key] = true
+		keys := make([]K, 0, len(l.queued))
+		done := make(chan struct{})
+		for queued := range l.queued {
+			keys = append(keys, queued)
+			l.pending[queued] = done
+		}
+		l.queued = map[K]bool{}
+		l.mu.Unlock()
+
+		// The lock is not held while fetching: building the results may
+		// queue keys on other loaders, which may be fetching and queueing
+		// on this one.
+		values, err := l.fetch(keys)
+
+		l.mu.Lock()
+		for _, fetched := range keys {
+			delete(l.pending, fetched)
+			l.results[fetched] = result[V]{value: values[fetched], err: err}
+		}
+		close(done)
+	}
+
+	defer l.mu.Unlock()
+	r := l.results[key]
+
+	return r.value, r.err
+}
+
+func (l *loader[K, V]) known(key K) bool {
+	_, pending := l.pending[key]
+	_, loaded := l.results[key]
+
+	return l.queued[key] || pending || loaded
+}
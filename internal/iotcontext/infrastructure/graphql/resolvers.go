@@ -0,0 +1,436 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/graph-gophers/graphql-go"
+	"sort"
+	"strings"
+)
+
+// resolver is the root of the schema. It holds nothing: every request
+// resolves with the session of its context.
+type resolver struct{}
+
+type targetArgs struct {
+	Selector *string
+	Group    *graphql.ID
+}
+
+// target returns the devices the arguments pick, and whether they pick any
+// at all rather than every device of the tenant.
+func (a targetArgs) target() (application.Target, bool, error) {
+	if a.Selector == nil && a.Group == nil {
+		return application.Target{}, false, nil
+	}
+
+	var selector, group string
+	if a.Selector != nil {
+		selector = *a.Selector
+	}
+	if a.Group != nil {
+		group = string(*a.Group)
+	}
+
+	target, err := application.ParseTarget(selector, group)
+
+	return target, true, err
+}
+
+func (r *resolver) Devices(ctx context.Context, args targetArgs) ([]*deviceResolver, error) {
+	s := sessionOf(ctx)
+
+	target, targeted, err := args.target()
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	var devices []*domain.Device
+	if targeted {
+		devices, err = s.groups.Devices(target)
+	} else {
+		devices, err = s.devices.GetAllDevices()
+	}
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	return s.deviceResolvers(devices), nil
+}
+
+func (r *resolver) Device(ctx context.Context, args struct{ ID graphql.ID }) (*deviceResolver, error) {
+	s := sessionOf(ctx)
+
+	device, err := s.devices.GetDeviceByID(domain.DeviceID(args.ID))
+	if errors.Is(err, domain.ErrDeviceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	return s.deviceResolvers([]*domain.Device{device})[0], nil
+}
+
+func (r *resolver) Sensors(ctx context.Context, args targetArgs) ([]*sensorResolver, error) {
+	s := sessionOf(ctx)
+
+	target, targeted, err := args.target()
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	var sensors []*domain.Sensor
+	if targeted {
+		sensors, err = s.groups.Sensors(target)
+	} else {
+		sensors, err = s.sensors.GetAllSensors()
+	}
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	return s.sensorResolvers(sensors), nil
+}
+
+func (r *resolver) Sensor(ctx context.Context, args struct{ ID graphql.ID }) (*sensorResolver, error) {
+	s := sessionOf(ctx)
+
+	sensor, err := s.sensors.GetSensorByID(domain.SensorID(args.ID))
+	if errors.Is(err, domain.ErrSensorNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	return s.sensorResolvers([]*domain.Sensor{sensor})[0], nil
+}
+
+// ReadingAdded forwards the readings stored while the subscription lasts. A
+// subscriber too slow to keep up misses some, as on the gRPC watch.
+func (r *resolver) ReadingAdded(ctx context.Context, args struct{ SensorIDs *[]graphql.ID }) (<-chan *readingResolver, error) {
+	s := sessionOf(ctx)
+
+	var sensorIDs []domain.SensorID
+	if args.SensorIDs != nil {
+		for _, id := range *args.SensorIDs {
+			sensorIDs = append(sensorIDs, domain.SensorID(id))
+		}
+	}
+
+	readings, stop, err := s.readings.WatchReadings(sensorIDs)
+	if err != nil {
+		return nil, s.subscriptionError(err)
+	}
+
+	added := make(chan *readingResolver)
+	go func() {
+		defer stop()
+		defer close(added)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case reading, ok := <-readings:
+				if !ok {
+					return
+				}
+
+				select {
+				case added <- &readingResolver{reading: &reading}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return added, nil
+}
+
+type deviceResolver struct {
+	session *session
+	device  *domain.Device
+}
+
+func (r *deviceResolver) ID() graphql.ID {
+	return graphql.ID(r.device.ID)
+}
+
+func (r *deviceResolver) TenantID() graphql.ID {
+	return graphql.ID(r.device.TenantID)
+}
+
+func (r *deviceResolver) Name() string {
+	return r.device.Name
+}
+
+func (r *deviceResolver) Type() string {
+	return r.device.Type
+}
+
+func (r *deviceResolver) Status() string {
+	return strings.ToUpper(string(r.device.Status))
+}
+
+func (r *deviceResolver) Labels() []*labelResolver {
+	return labelResolvers(r.device.Labels)
+}
+
+func (r *deviceResolver) LocationID() *graphql.ID {
+	if r.device.LocationID == "" {
+		return nil
+	}
+
+	id := graphql.ID(r.device.LocationID)
+
+	return &id
+}
+
+func (r *deviceResolver) Geo() *geoPointResolver {
+	if r.device.Geo == nil {
+		return nil
+	}
+
+	return &geoPointResolver{point: *r.device.Geo}
+}
+
+func (r *deviceResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.device.CreatedAt}
+}
+
+func (r *deviceResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.device.UpdatedAt}
+}
+
+func (r *deviceResolver) Version() int32 {
+	return int32(r.device.Version)
+}
+
+func (r *deviceResolver) Sensors() ([]*sensorResolver, error) {
+	sensors, err := r.session.sensorsLoader.Load(r.device.ID)
+	if sensors == nil {
+		sensors = []*sensorResolver{}
+	}
+
+	return sensors, err
+}
+
+type sensorResolver struct {
+	session *session
+	sensor  *domain.Sensor
+}
+
+func (r *sensorResolver) ID() graphql.ID {
+	return graphql.ID(r.sensor.ID)
+}
+
+func (r *sensorResolver) TenantID() graphql.ID {
+	return graphql.ID(r.sensor.TenantID)
+}
+
+func (r *sensorResolver) DeviceID() graphql.ID {
+	return graphql.ID(r.sensor.DeviceID)
+}
+
+// Device is null when the device is gone, which only happens while it is
+// being deleted along with its sensors.
+func (r *sensorResolver) Device() (*deviceResolver, error) {
+	device, err := r.session.deviceLoader.Load(r.sensor.DeviceID)
+	if err != nil || device == nil {
+		return nil, err
+	}
+
+	return &deviceResolver{session: r.session, device: device}, nil
+}
+
+func (r *sensorResolver) Name() string {
+	return r.sensor.Name
+}
+
+func (r *sensorResolver) Type() string {
+	return string(r.sensor.Type)
+}
+
+func (r *sensorResolver) Config() *sensorConfigResolver {
+	return &sensorConfigResolver{config: r.sensor.Config}
+}
+
+func (r *sensorResolver) Labels() []*labelResolver {
+	return labelResolvers(r.sensor.Labels)
+}
+
+func (r *sensorResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.sensor.CreatedAt}
+}
+
+func (r *sensorResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.sensor.UpdatedAt}
+}
+
+func (r *sensorResolver) Version() int32 {
+	return int32(r.sensor.Version)
+}
+
+func (r *sensorResolver) LatestReading() (*readingResolver, error) {
+	reading, err := r.session.latestLoader.Load(r.sensor.ID)
+	if err != nil || reading == nil {
+		return nil, err
+	}
+
+	return &readingResolver{reading: reading}, nil
+}
+
+type sensorConfigResolver struct {
+	config domain.SensorConfig
+}
+
+func (r *sensorConfigResolver) Revision() int32 {
+	return int32(r.config.Revision)
+}
+
+func (r *sensorConfigResolver) SamplingRateMs() int32 {
+	return int32(r.config.SamplingRateMs)
+}
+
+func (r *sensorConfigResolver) Thresholds() *thresholdsResolver {
+	return &thresholdsResolver{thresholds: r.config.Thresholds}
+}
+
+func (r *sensorConfigResolver) ErrorRate() float64 {
+	return r.config.ErrorRate
+}
+
+func (r *sensorConfigResolver) Enabled() bool {
+	return r.config.Enabled
+}
+
+func (r *sensorConfigResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.config.UpdatedAt}
+}
+
+func (r *sensorConfigResolver) Meta() *JSON {
+	return jsonOf(r.config.Meta)
+}
+
+type thresholdsResolver struct {
+	thresholds domain.Thresholds
+}
+
+func (r *thresholdsResolver) Min() *float64 {
+	return r.thresholds.Min
+}
+
+func (r *thresholdsResolver) Max() *float64 {
+	return r.thresholds.Max
+}
+
+type readingResolver struct {
+	reading *domain.SensorReading
+}
+
+func (r *readingResolver) ID() graphql.ID {
+	return graphql.ID(r.reading.ID)
+}
+
+func (r *readingResolver) SensorID() graphql.ID {
+	return graphql.ID(r.reading.SensorID)
+}
+
+func (r *readingResolver) DeviceID() graphql.ID {
+	return graphql.ID(r.reading.DeviceID)
+}
+
+func (r *readingResolver) Type() string {
+	return string(r.reading.Type)
+}
+
+func (r *readingResolver) Value() float64 {
+	return r.reading.Value
+}
+
+func (r *readingResolver) Unit() string {
+	return r.reading.Unit
+}
+
+func (r *readingResolver) Timestamp() graphql.Time {
+	return graphql.Time{Time: r.reading.Timestamp}
+}
+
+func (r *readingResolver) Meta() *JSON {
+	return jsonOf(r.reading.Meta)
+}
+
+type labelResolver struct {
+	key   string
+	value string
+}
+
+// labelResolvers lists the labels sorted by key, as maps have no order.
+func labelResolvers(labels domain.Labels) []*labelResolver {
+	resolvers := make([]*labelResolver, 0, len(labels))
+	for key, value := range labels {
+		resolvers = append(resolvers, &labelResolver{key: key, value: value})
+	}
+	sort.Slice(resolvers, func(i, j int) bool { return resolvers[i].key < resolvers[j].key })
+
+	return resolvers
+}
+
+func (r *labelResolver) Key() string {
+	return r.key
+}
+
+func (r *labelResolver) Value() string {
+	return r.value
+}
+
+type geoPointResolver struct {
+	point domain.GeoPoint
+}
+
+func (r *geoPointResolver) Lat() float64 {
+	return r.point.Lat
+}
+
+func (r *geoPointResolver) Lon() float64 {
+	return r.point.Lon
+}
+
+// JSON is the JSON scalar: the metadata of configs and readings, served as
+// is.
+type JSON struct {
+	value interface{}
+}
+
+func jsonOf(meta map[string]interface{}) *JSON {
+	if meta == nil {
+		return nil
+	}
+
+	return &JSON{value: meta}
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	switch input.(type) {
+	case map[string]interface{}, []interface{}, string, bool, float64, int32, nil:
+		j.value = input
+		return nil
+	default:
+		return fmt.Errorf("unsupported JSON value %T", input)
+	}
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.value)
+}
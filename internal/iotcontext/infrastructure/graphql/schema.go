@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"log"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// maxDepth bounds how deep a query may nest, enough to go from a device to
+// its sensors, their device and its sensors again, but not to loop on it.
+const maxDepth = 8
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response is a GraphQL response, serialized as the spec says.
+type Response = graphql.Response
+
+// Schema is the read side of the API as a GraphQL schema: devices, sensors
+// and their latest readings, resolved through the same use cases as the
+// REST and gRPC APIs, plus a subscription to new readings.
+type Schema struct {
+	schema          *graphql.Schema
+	deviceUseCase   *application.DeviceUseCase
+	sensorUseCase   *application.SensorUseCase
+	readingsUseCase *application.ReadingsUsecase
+	groupUseCase    *application.GroupUseCase
+}
+
+func NewSchema(
+	deviceUseCase *application.DeviceUseCase,
+	sensorUseCase *application.SensorUseCase,
+	readingsUseCase *application.ReadingsUsecase,
+	groupUseCase *application.GroupUseCase,
+) *Schema {
+	return &Schema{
+		schema:          graphql.MustParseSchema(schemaSDL, &resolver{}, graphql.MaxDepth(maxDepth)),
+		deviceUseCase:   deviceUseCase,
+		sensorUseCase:   sensorUseCase,
+		readingsUseCase: readingsUseCase,
+		groupUseCase:    groupUseCase,
+	}
+}
+
+// Exec runs the query for the tenant of scope. requestID is logged with the
+// errors the caller is not told about.
+func (s *Schema) Exec(ctx context.Context, scope application.TenantScope, requestID string, req Request) *Response {
+	return s.schema.Exec(s.withSession(ctx, scope, requestID), req.Query, req.OperationName, req.Variables)
+}
+
+// Subscribe runs the request for the tenant of scope and delivers its
+// responses: one for a query, one per event for a subscription, until ctx is
+// done. The channel is closed once there are no more.
+func (s *Schema) Subscribe(ctx context.Context, scope application.TenantScope, requestID string, req Request) (<-chan interface{}, error) {
+	return s.schema.Subscribe(s.withSession(ctx, scope, requestID), req.Query, req.OperationName, req.Variables)
+}
+
+func (s *Schema) withSession(ctx context.Context, scope application.TenantScope, requestID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, newSession(
+		s.deviceUseCase.ForTenant(scope),
+		s.sensorUseCase.ForTenant(scope),
+		s.readingsUseCase.ForTenant(scope),
+		s.groupUseCase.ForTenant(scope),
+		requestID,
+	))
+}
+
+type sessionKey struct{}
+
+// session is what the resolvers of one request share: the use cases scoped
+// to its tenant and the loaders batching its lookups.
+type session struct {
+	devices   *application.DeviceUseCase
+	sensors   *application.SensorUseCase
+	readings  *application.ReadingsUsecase
+	groups    *application.GroupUseCase
+	requestID string
+
+	deviceLoader  *loader[domain.DeviceID, *domain.Device]
+	sensorsLoader *loader[domain.DeviceID, []*sensorResolver]
+	latestLoader  *loader[domain.SensorID, *domain.SensorReading]
+}
+
+func newSession(
+	devices *application.DeviceUseCase,
+	sensors *application.SensorUseCase,
+	readings *application.ReadingsUsecase,
+	groups *application.GroupUseCase,
+	requestID string,
+) *session {
+	s := &session{
+		devices:   devices,
+		sensors:   sensors,
+		readings:  readings,
+		groups:    groups,
+		requestID: requestID,
+	}
+
+	s.deviceLoader = newLoader(s.fetchDevices)
+	s.sensorsLoader = newLoader(s.fetchSensors)
+	s.latestLoader = newLoader(s.fetchLatestReadings)
+
+	return s
+}
+
+func sessionOf(ctx context.Context) *session {
+	return ctx.Value(sessionKey{}).(*session)
+}
+
+func (s *session) fetchDevices(ids []domain.DeviceID) (map[domain.DeviceID]*domain.Device, error) {
+	devices, err := s.devices.GetDevicesByIDs(ids)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	byID := make(map[domain.DeviceID]*domain.Device, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+
+	return byID, nil
+}
+
+// fetchSensors builds the resolvers of the sensors itself, so their latest
+// readings and devices are queued before any of them is resolved.
+func (s *session) fetchSensors(deviceIDs []domain.DeviceID) (map[domain.DeviceID][]*sensorResolver, error) {
+	sensors, err := s.sensors.GetSensorsByDevices(deviceIDs)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	byDevice := make(map[domain.DeviceID][]*sensorResolver, len(deviceIDs))
+	for _, sensor := range s.sensorResolvers(sensors) {
+		byDevice[sensor.sensor.DeviceID] = append(byDevice[sensor.sensor.DeviceID], sensor)
+	}
+
+	return byDevice, nil
+}
+
+func (s *session) fetchLatestReadings(sensorIDs []domain.SensorID) (map[domain.SensorID]*domain.SensorReading, error) {
+	readings, err := s.readings.GetLatestReadings(sensorIDs)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	bySensor := make(map[domain.SensorID]*domain.SensorReading, len(readings))
+	for i := range readings {
+		bySensor[readings[i].SensorID] = &readings[i]
+	}
+
+	return bySensor, nil
+}
+
+// deviceResolvers resolves the devices, queueing the lookup of their sensors.
+func (s *session) deviceResolvers(devices []*domain.Device) []*deviceResolver {
+	resolvers := make([]*deviceResolver, 0, len(devices))
+	for _, device := range devices {
+		s.deviceLoader.Prime(device.ID, device)
+		s.sensorsLoader.Queue(device.ID)
+		resolvers = append(resolvers, &deviceResolver{session: s, device: device})
+	}
+
+	return resolvers
+}
+
+// sensorResolvers resolves the sensors, queueing the lookup of their devices
+// and latest readings.
+func (s *session) sensorResolvers(sensors []*domain.Sensor) []*sensorResolver {
+	resolvers := make([]*sensorResolver, 0, len(sensors))
+	for _, sensor := range sensors {
+		s.deviceLoader.Queue(sensor.DeviceID)
+		s.latestLoader.Queue(sensor.ID)
+		resolvers = append(resolvers, &sensorResolver{session: s, sensor: sensor})
+	}
+
+	return resolvers
+}
+
+// fail turns err into the error the caller is told about. A domain error
+// keeps its message and carries its code, as on the REST API; any other is
+// internal: it is logged, and the caller is only told so.
+func (s *session) fail(err error) error {
+	if _, ok := err.(*resolverError); ok {
+		return err
+	}
+
+	if domain.KindOf(err) == domain.KindInternal {
+		log.Printf("internal error [%s]: %v", s.requestID, err)
+		return &resolverError{message: "Internal error", extensions: map[string]interface{}{"code": "internal"}}
+	}
+
+	extensions := map[string]interface{}{"code": domain.CodeOf(err)}
+	if fields := domain.FieldsOf(err); len(fields) > 0 {
+		extensions["fields"] = fields
+	}
+
+	return &resolverError{message: err.Error(), extensions: extensions}
+}
+
+// resolverError is an error as the caller sees it, with its code in the
+// extensions of the GraphQL error.
+type resolverError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// subscriptionError is fail for the subscription fields, whose errors the
+// library does not take the extensions of unless they come as query errors.
+func (s *session) subscriptionError(err error) error {
+	failed := s.fail(err).(*resolverError)
+
+	return &gqlerrors.QueryError{Message: failed.message, Extensions: failed.extensions, ResolverError: err}
+}
@@ -0,0 +1,323 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lookups counts the calls made to the repositories by name.
+type lookups struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (l *lookups) count(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls[name]++
+}
+
+func (l *lookups) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls = map[string]int{}
+}
+
+type countingDevices struct {
+	domain.DeviceRepository
+	lookups *lookups
+}
+
+func (r countingDevices) FindByID(id domain.DeviceID) (domain.Device, error) {
+	r.lookups.count("device")
+	return r.DeviceRepository.FindByID(id)
+}
+
+func (r countingDevices) FindByIDs(ids []domain.DeviceID) ([]domain.Device, error) {
+	r.lookups.count("devices by ids")
+	return r.DeviceRepository.FindByIDs(ids)
+}
+
+type countingSensors struct {
+	domain.SensorRepository
+	lookups *lookups
+}
+
+func (r countingSensors) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	r.lookups.count("sensors by device")
+	return r.SensorRepository.FindByDeviceID(deviceID)
+}
+
+func (r countingSensors) FindByDeviceIDs(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	r.lookups.count("sensors by devices")
+	return r.SensorRepository.FindByDeviceIDs(deviceIDs)
+}
+
+type countingReadings struct {
+	domain.SensorReadingRepository
+	lookups *lookups
+}
+
+func (r countingReadings) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
+	r.lookups.count("readings by sensor")
+	return r.SensorReadingRepository.FindBySensorID(sensorID, limit)
+}
+
+func (r countingReadings) FindLatestBySensorIDs(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	r.lookups.count("latest readings")
+	return r.SensorReadingRepository.FindLatestBySensorIDs(sensorIDs)
+}
+
+type fixture struct {
+	schema   *Schema
+	feed     *persistence.FeedSensorReadingRepository
+	lookups  *lookups
+	acme     application.TenantScope
+	globex   application.TenantScope
+	sensorID domain.SensorID
+}
+
+// newFixture stores three devices of acme with two sensors each, the first
+// of which has two readings.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	counted := &lookups{calls: map[string]int{}}
+	deviceRepo := countingDevices{DeviceRepository: persistence.NewInMemoryDeviceRepository(), lookups: counted}
+	sensorRepo := countingSensors{SensorRepository: persistence.NewInMemorySensorRepository(), lookups: counted}
+	feed := persistence.NewFeedSensorReadingRepository(persistence.NewInMemorySensorReadingRepository())
+	readingRepo := countingReadings{SensorReadingRepository: feed, lookups: counted}
+
+	sensorUseCase := application.NewSensorUseCase(sensorRepo, nil, deviceRepo, nil, nil, nil)
+	tenancy := application.NewTenancy(domain.TenantQuotas{}, nil, nil)
+	f := &fixture{
+		schema: NewSchema(
+			application.NewDeviceUseCase(deviceRepo, sensorRepo, sensorUseCase, nil, nil),
+			sensorUseCase,
+			application.NewReadingsUsecase(readingRepo, nil, sensorRepo, deviceRepo, feed, nil),
+			application.NewGroupUseCase(persistence.NewInMemoryGroupRepository(), deviceRepo, sensorRepo, nil),
+		),
+		feed:    feed,
+		lookups: counted,
+		acme:    tenancy.Scope("acme"),
+		globex:  tenancy.Scope("globex"),
+	}
+
+	now := time.Now().UTC()
+	for d := 1; d <= 3; d++ {
+		device, err := domain.NewDevice(domain.DeviceID(fmt.Sprintf("device-%d", d)), fmt.Sprintf("greenhouse %d", d), "gateway")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		device.TenantID = "acme"
+		device.Labels = domain.Labels{"zone": "north", "floor": fmt.Sprint(d)}
+		if err := deviceRepo.Save(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for s := 1; s <= 2; s++ {
+			sensorID := domain.SensorID(fmt.Sprintf("sensor-%d-%d", d, s))
+			config, err := domain.NewSensorConfig(sensorID, 1000, domain.Thresholds{}, 0, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sensor, err := domain.NewSensor(sensorID, device.ID, "soil", "humidity", config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sensor.TenantID = "acme"
+			if err := sensorRepo.Save(sensor); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	f.sensorID = "sensor-1-1"
+	for i, value := range []float64{20, 21} {
+		reading := domain.NewSensorReading(f.sensorID, "device-1", "humidity", value, "%", now.Add(time.Duration(i)*time.Second))
+		reading.TenantID = "acme"
+		if err := feed.Save(&reading); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	counted.reset()
+
+	return f
+}
+
+func TestSchema_Exec(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name            string
+		globex          bool
+		query           string
+		variables       map[string]interface{}
+		expectedData    string
+		expectedCode    string
+		expectedLookups map[string]int
+	}{
+		{
+			name:         "devices with their sensors and latest readings",
+			query:        `{ devices(selector: "floor=1") { id labels { key value } sensors { id device { id } latestReading { value } } } }`,
+			expectedData: `{"devices":[{"id":"device-1","labels":[{"key":"floor","value":"1"},{"key":"zone","value":"north"}],"sensors":[{"id":"sensor-1-1","device":{"id":"device-1"},"latestReading":{"value":21}},{"id":"sensor-1-2","device":{"id":"device-1"},"latestReading":null}]}]}`,
+			// The devices were listed already, so their sensors need not
+			// look them up again.
+			expectedLookups: map[string]int{"sensors by devices": 1, "latest readings": 1},
+		},
+		{
+			name:            "every device costs one lookup per level",
+			query:           `{ devices { sensors { latestReading { value } } } }`,
+			expectedData:    `{"devices":[{"sensors":[{"latestReading":{"value":21}},{"latestReading":null}]},{"sensors":[{"latestReading":null},{"latestReading":null}]},{"sensors":[{"latestReading":null},{"latestReading":null}]}]}`,
+			expectedLookups: map[string]int{"sensors by devices": 1, "latest readings": 1},
+		},
+		{
+			name:            "sensors with their devices",
+			query:           `{ sensors(group: null, selector: null) { device { name status } } }`,
+			expectedData:    `{"sensors":[{"device":{"name":"greenhouse 1","status":"PROVISIONED"}},{"device":{"name":"greenhouse 1","status":"PROVISIONED"}},{"device":{"name":"greenhouse 2","status":"PROVISIONED"}},{"device":{"name":"greenhouse 2","status":"PROVISIONED"}},{"device":{"name":"greenhouse 3","status":"PROVISIONED"}},{"device":{"name":"greenhouse 3","status":"PROVISIONED"}}]}`,
+			expectedLookups: map[string]int{"devices by ids": 1},
+		},
+		{
+			name:         "sensor by id",
+			query:        `query Sensor($id: ID!) { sensor(id: $id) { name config { samplingRateMs enabled thresholds { min } } latestReading { unit } } }`,
+			variables:    map[string]interface{}{"id": "sensor-1-1"},
+			expectedData: `{"sensor":{"name":"soil","config":{"samplingRateMs":1000,"enabled":true,"thresholds":{"min":null}},"latestReading":{"unit":"humidity"}}}`,
+		},
+		{
+			name:         "unknown device",
+			query:        `{ device(id: "ghost") { id } }`,
+			expectedData: `{"device":null}`,
+		},
+		{
+			name:         "device of another tenant",
+			globex:       true,
+			query:        `{ device(id: "device-1") { id } devices { id } sensors { id } }`,
+			expectedData: `{"device":null,"devices":[],"sensors":[]}`,
+		},
+		{
+			name:         "invalid selector",
+			query:        `{ devices(selector: "zone in (") { id } }`,
+			expectedCode: "invalid_selector",
+		},
+		{
+			name:         "unknown group",
+			query:        `{ sensors(group: "ghost") { id } }`,
+			expectedCode: "group_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := f.acme
+			if tt.globex {
+				scope = f.globex
+			}
+
+			f.lookups.reset()
+			response := f.schema.Exec(context.Background(), scope, "test", Request{Query: tt.query, Variables: tt.variables})
+
+			if tt.expectedCode != "" {
+				if len(response.Errors) == 0 {
+					t.Fatal("expected error but got none")
+				}
+				if code := response.Errors[0].Extensions["code"]; code != tt.expectedCode {
+					t.Errorf("expected code %s, got %v: %v", tt.expectedCode, code, response.Errors[0])
+				}
+				return
+			}
+
+			if len(response.Errors) > 0 {
+				t.Fatalf("unexpected error: %v", response.Errors)
+			}
+
+			if string(response.Data) != tt.expectedData {
+				t.Errorf("expected %s, got %s", tt.expectedData, response.Data)
+			}
+
+			if tt.expectedLookups != nil {
+				for name, expected := range tt.expectedLookups {
+					if f.lookups.calls[name] != expected {
+						t.Errorf("expected %d %s lookups, got %d", expected, name, f.lookups.calls[name])
+					}
+				}
+				for name, calls := range f.lookups.calls {
+					if _, ok := tt.expectedLookups[name]; !ok {
+						t.Errorf("expected no %s lookups, got %d", name, calls)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSchema_Subscribe(t *testing.T) {
+	f := newFixture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	refused, err := f.schema.Subscribe(ctx, f.globex, "test", Request{Query: `subscription { readingAdded(sensorIds: ["sensor-1-1"]) { id } }`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response := (<-refused).(*Response)
+	if len(response.Errors) == 0 || response.Errors[0].Extensions["code"] != "sensor_not_found" {
+		t.Errorf("expected a sensor of another tenant to be refused, got %v", response.Errors)
+	}
+
+	responses, err := f.schema.Subscribe(ctx, f.acme, "test", Request{Query: `subscription { readingAdded(sensorIds: ["sensor-1-1"]) { sensorId value meta } }`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The feed only delivers what is stored once the watch is live, which it
+	// is by the time the first reading is; keep storing until one arrives.
+	stored := make(chan struct{})
+	go func() {
+		defer close(stored)
+		for ctx.Err() == nil {
+			reading := domain.NewSensorReading(f.sensorID, "device-1", "humidity", 22.5, "%", time.Now())
+			reading.TenantID = "acme"
+			reading.Meta = map[string]interface{}{"source": "test"}
+			f.feed.Save(&reading)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case next := <-responses:
+		response := next.(*Response)
+		if len(response.Errors) > 0 {
+			t.Fatalf("unexpected error: %v", response.Errors)
+		}
+
+		var data struct {
+			ReadingAdded struct {
+				SensorID string                 `json:"sensorId"`
+				Value    float64                `json:"value"`
+				Meta     map[string]interface{} `json:"meta"`
+			} `json:"readingAdded"`
+		}
+		if err := json.Unmarshal(response.Data, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.ReadingAdded.SensorID != string(f.sensorID) || data.ReadingAdded.Value != 22.5 || data.ReadingAdded.Meta["source"] != "test" {
+			t.Errorf("expected the stored reading, got %+v", data.ReadingAdded)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected a reading before the timeout")
+	}
+
+	cancel()
+	<-stored
+}
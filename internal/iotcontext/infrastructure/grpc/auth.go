@@ -0,0 +1,353 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata keys, the counterparts of the headers of the REST API. An API key
+// may also be sent as "authorization: ApiKey <key>", and a JWT as
+// "authorization: Bearer <jwt>".
+const (
+	MetadataAPIKey        = "x-api-key"
+	MetadataAuthorization = "authorization"
+	MetadataTenant        = "x-tenant-id"
+	MetadataRequestID     = "x-request-id"
+)
+
+// Metadata of a call signed with an HMAC credential, the counterparts of the
+// X-Device-* headers. The signature is made as for a request of the REST API
+// with method POST, the full method name as target and an empty body.
+const (
+	MetadataDeviceCredential = "x-device-credential"
+	MetadataDeviceTimestamp  = "x-device-timestamp"
+	MetadataDeviceSignature  = "x-device-signature"
+)
+
+// maxRequestIDLength bounds the ids accepted from clients.
+const maxRequestIDLength = 128
+
+type callKey struct{}
+
+// call is who a call is made by and what it acts for.
+type call struct {
+	requestID string
+	device    domain.DeviceID
+	scope     application.TenantScope
+}
+
+// Policy is who may call a method. Device methods are those devices call
+// themselves, which they authenticate with the client certificate of the
+// connection or by signing the call with their HMAC credential.
+type Policy struct {
+	Roles  []domain.Role
+	Device bool
+}
+
+// Authenticator checks every call the way the REST middlewares check a
+// request: it authenticates the device or the caller, checks the caller's
+// role against the policy of the method and scopes the call to the caller's
+// tenant. Methods without a policy are refused.
+type Authenticator struct {
+	authUseCase        *application.AuthUseCase
+	credentialUseCase  *application.CredentialUseCase
+	tenancy            *application.Tenancy
+	deviceRepo         domain.DeviceRepository
+	apiAuthRequired    bool
+	deviceAuthRequired bool
+	policies           map[string]Policy
+}
+
+// NewAuthenticator builds the interceptors. When apiAuthRequired is false,
+// calls without credentials act as an admin of the whole deployment; when
+// deviceAuthRequired is false, devices may call without credentials.
+// Credentials that are presented are still checked.
+func NewAuthenticator(
+	authUseCase *application.AuthUseCase,
+	credentialUseCase *application.CredentialUseCase,
+	tenancy *application.Tenancy,
+	deviceRepo domain.DeviceRepository,
+	apiAuthRequired bool,
+	deviceAuthRequired bool,
+) *Authenticator {
+	return &Authenticator{
+		authUseCase:        authUseCase,
+		credentialUseCase:  credentialUseCase,
+		tenancy:            tenancy,
+		deviceRepo:         deviceRepo,
+		apiAuthRequired:    apiAuthRequired,
+		deviceAuthRequired: deviceAuthRequired,
+		policies:           map[string]Policy{},
+	}
+}
+
+// Allow sets the policy of a method, named as in grpc.UnaryServerInfo.
+func (a *Authenticator) Allow(method string, policy Policy) {
+	a.policies[method] = policy
+}
+
+// Methods returns the methods that have a policy.
+func (a *Authenticator) Methods() []string {
+	methods := make([]string, 0, len(a.policies))
+	for method := range a.policies {
+		methods = append(methods, method)
+	}
+
+	return methods
+}
+
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &scopedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// scopedStream is a stream whose context carries the call.
+type scopedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *scopedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := first(md, MetadataRequestID)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, requestID))
+	log.Printf("gRPC %s [%s]", method, requestID)
+
+	policy, ok := a.policies[method]
+	if !ok {
+		return nil, permissionDenied("Method is not allowed")
+	}
+
+	now := time.Now().UTC()
+	deviceID, err := a.authenticateDevice(ctx, md, method, policy, now)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, ok, err := a.authenticateCaller(md, deviceID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, unauthenticated("Authentication required")
+	}
+
+	if !allows(principal.Role, policy.Roles) {
+		return nil, permissionDenied("Role " + string(principal.Role) + " is not allowed to do this")
+	}
+
+	tenant, err := a.resolveTenant(md, deviceID, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := domain.AuditActor{
+		Subject:   principal.Subject,
+		Role:      principal.Role,
+		RequestID: requestID,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		actor.SourceIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(actor.SourceIP); err == nil {
+			actor.SourceIP = host
+		}
+	}
+
+	return context.WithValue(ctx, callKey{}, call{
+		requestID: requestID,
+		device:    deviceID,
+		scope:     a.tenancy.Scope(tenant).By(actor),
+	}), nil
+}
+
+// authenticateDevice returns the device the client certificate of the
+// connection or the HMAC credential of the call belongs to, on device methods
+// only. It is empty when authentication is optional and none was presented.
+func (a *Authenticator) authenticateDevice(ctx context.Context, md metadata.MD, method string, policy Policy, now time.Time) (domain.DeviceID, error) {
+	if !policy.Device {
+		return "", nil
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			fingerprint := sha256.Sum256(info.State.PeerCertificates[0].Raw)
+			deviceID, err := a.credentialUseCase.AuthenticateCertificate(hex.EncodeToString(fingerprint[:]), now)
+			if err != nil {
+				return "", unauthenticated("Device authentication failed")
+			}
+
+			return deviceID, nil
+		}
+	}
+
+	if credential := first(md, MetadataDeviceCredential); credential != "" {
+		seconds, err := strconv.ParseInt(first(md, MetadataDeviceTimestamp), 10, 64)
+		if err != nil {
+			return "", unauthenticated("Device authentication failed")
+		}
+
+		deviceID, err := a.credentialUseCase.AuthenticateSignature(
+			domain.CredentialID(credential),
+			first(md, MetadataDeviceSignature),
+			"POST",
+			method,
+			time.Unix(seconds, 0),
+			nil,
+			now,
+		)
+		if err != nil {
+			return "", unauthenticated("Device authentication failed")
+		}
+
+		return deviceID, nil
+	}
+
+	if a.deviceAuthRequired {
+		return "", unauthenticated("Device authentication required")
+	}
+
+	return "", nil
+}
+
+// authenticateCaller works out who the call is made by. It reports false
+// when authentication is required and no credentials were presented.
+func (a *Authenticator) authenticateCaller(md metadata.MD, deviceID domain.DeviceID, now time.Time) (domain.Principal, bool, error) {
+	if deviceID != "" {
+		return domain.Principal{Subject: "device:" + string(deviceID), Role: domain.RoleDevice}, true, nil
+	}
+
+	scheme, value, _ := strings.Cut(first(md, MetadataAuthorization), " ")
+
+	var principal domain.Principal
+	var err error
+	switch {
+	case first(md, MetadataAPIKey) != "":
+		principal, err = a.authUseCase.AuthenticateAPIKey(first(md, MetadataAPIKey), now)
+	case strings.EqualFold(scheme, "ApiKey"):
+		principal, err = a.authUseCase.AuthenticateAPIKey(strings.TrimSpace(value), now)
+	case strings.EqualFold(scheme, "Bearer"):
+		principal, err = a.authUseCase.AuthenticateToken(strings.TrimSpace(value), now)
+	case a.apiAuthRequired:
+		return domain.Principal{}, false, nil
+	default:
+		return domain.Principal{Subject: "anonymous", Role: domain.RoleAdmin}, true, nil
+	}
+
+	if err != nil {
+		return domain.Principal{}, false, unauthenticated("Authentication failed")
+	}
+
+	return principal, true, nil
+}
+
+// resolveTenant works out the tenant the call acts for, as the
+// TenantResolver of the REST API does: the device's, the one the credential
+// is bound to, or the one named in x-tenant-id, falling back to the default
+// tenant.
+func (a *Authenticator) resolveTenant(md metadata.MD, deviceID domain.DeviceID, principal domain.Principal) (domain.TenantID, error) {
+	tenant := domain.DefaultTenant
+	named := first(md, MetadataTenant)
+	if named != "" {
+		parsed, err := domain.ParseTenantID(named)
+		if err != nil {
+			return "", statusError(err)
+		}
+		tenant = parsed
+	}
+
+	if deviceID != "" {
+		device, err := a.deviceRepo.FindByID(deviceID)
+		if err != nil {
+			return "", unauthenticated("Device authentication failed")
+		}
+
+		if named != "" && tenant != device.TenantID {
+			return "", permissionDenied("Device is not allowed to act for another tenant")
+		}
+		return device.TenantID, nil
+	}
+
+	if principal.TenantID != "" {
+		if named != "" && tenant != principal.TenantID {
+			return "", permissionDenied("Caller is not allowed to act for another tenant")
+		}
+		return principal.TenantID, nil
+	}
+
+	return tenant, nil
+}
+
+func allows(role domain.Role, roles []domain.Role) bool {
+	for _, required := range roles {
+		if role.Allows(required) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// callOf returns the call the Authenticator let through.
+func callOf(ctx context.Context) call {
+	c, _ := ctx.Value(callKey{}).(call)
+	return c
+}
@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/infrastructure/events"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator(t *testing.T) {
+	tenancy := application.NewTenancy(domain.TenantQuotas{}, nil, nil)
+	auth := application.NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), nil, "bootstrap-s3cret")
+	now := time.Now()
+
+	viewer, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("dashboard", domain.RoleViewer, 0, now)
+	operator, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("automation", domain.RoleOperator, 0, now)
+
+	devices := persistence.NewInMemoryDeviceRepository()
+	device, _ := domain.NewDevice("device-1", "Gateway", "gateway")
+	device.TenantID = "acme"
+	devices.Save(device)
+	credentials := application.NewCredentialUseCase(
+		devices,
+		persistence.NewInMemoryCredentialRepository(),
+		persistence.NewInMemoryClaimTokenRepository(),
+		nil,
+		nil,
+		events.NewInMemoryPublisher(),
+		time.Hour,
+	)
+	issued, err := credentials.IssueCredential("device-1", domain.CredentialHMAC, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed := func(secret string) map[string]string {
+		return map[string]string{
+			MetadataDeviceCredential: string(issued.ID),
+			MetadataDeviceTimestamp:  strconv.FormatInt(now.Unix(), 10),
+			MetadataDeviceSignature:  domain.SignRequest(secret, "POST", "/iot.v1.ReadingService/IngestReading", now, nil),
+		}
+	}
+
+	authenticator := func(apiAuthRequired, deviceAuthRequired bool) *Authenticator {
+		a := NewAuthenticator(auth, credentials, tenancy, devices, apiAuthRequired, deviceAuthRequired)
+		a.Allow("/iot.v1.DeviceService/ListDevices", Policy{Roles: []domain.Role{domain.RoleViewer}})
+		a.Allow("/iot.v1.DeviceService/CreateDevice", Policy{Roles: []domain.Role{domain.RoleOperator}})
+		a.Allow("/iot.v1.ReadingService/IngestReading", Policy{Roles: []domain.Role{domain.RoleDevice}, Device: true})
+		return a
+	}
+
+	tests := []struct {
+		name               string
+		apiAuthRequired    bool
+		deviceAuthRequired bool
+		method             string
+		metadata           map[string]string
+		expectedCode       codes.Code
+		expectedReason     string
+		expectedTenant     domain.TenantID
+	}{
+		{name: "no credentials", apiAuthRequired: true, method: "/iot.v1.DeviceService/ListDevices", expectedCode: codes.Unauthenticated, expectedReason: "unauthenticated"},
+		{name: "no credentials when disabled", method: "/iot.v1.DeviceService/CreateDevice", expectedCode: codes.OK, expectedTenant: domain.DefaultTenant},
+		{name: "viewer reads", apiAuthRequired: true, method: "/iot.v1.DeviceService/ListDevices", metadata: map[string]string{MetadataAPIKey: viewer.Key}, expectedCode: codes.OK, expectedTenant: "acme"},
+		{name: "viewer writes", apiAuthRequired: true, method: "/iot.v1.DeviceService/CreateDevice", metadata: map[string]string{MetadataAPIKey: viewer.Key}, expectedCode: codes.PermissionDenied, expectedReason: "forbidden"},
+		{name: "operator writes", apiAuthRequired: true, method: "/iot.v1.DeviceService/CreateDevice", metadata: map[string]string{MetadataAuthorization: "ApiKey " + operator.Key}, expectedCode: codes.OK, expectedTenant: "acme"},
+		{name: "key for another tenant", apiAuthRequired: true, method: "/iot.v1.DeviceService/ListDevices", metadata: map[string]string{MetadataAPIKey: viewer.Key, MetadataTenant: "globex"}, expectedCode: codes.PermissionDenied},
+		{name: "unknown key", apiAuthRequired: true, method: "/iot.v1.DeviceService/ListDevices", metadata: map[string]string{MetadataAPIKey: "ghost.s3cret"}, expectedCode: codes.Unauthenticated},
+		{name: "bootstrap key picks a tenant", apiAuthRequired: true, method: "/iot.v1.DeviceService/ListDevices", metadata: map[string]string{MetadataAPIKey: "bootstrap-s3cret", MetadataTenant: "globex"}, expectedCode: codes.OK, expectedTenant: "globex"},
+		{name: "invalid tenant", method: "/iot.v1.DeviceService/ListDevices", metadata: map[string]string{MetadataTenant: "Not A Tenant"}, expectedCode: codes.InvalidArgument},
+		{name: "device without certificate", deviceAuthRequired: true, method: "/iot.v1.ReadingService/IngestReading", expectedCode: codes.Unauthenticated},
+		{name: "device without certificate when optional", method: "/iot.v1.ReadingService/IngestReading", expectedCode: codes.OK, expectedTenant: domain.DefaultTenant},
+		{name: "device signs the call", apiAuthRequired: true, deviceAuthRequired: true, method: "/iot.v1.ReadingService/IngestReading", metadata: signed(issued.Secret), expectedCode: codes.OK, expectedTenant: "acme"},
+		{name: "device signs with a wrong secret", deviceAuthRequired: true, method: "/iot.v1.ReadingService/IngestReading", metadata: signed("guess"), expectedCode: codes.Unauthenticated},
+		{name: "operator ingests", apiAuthRequired: true, method: "/iot.v1.ReadingService/IngestReading", metadata: map[string]string{MetadataAPIKey: operator.Key}, expectedCode: codes.PermissionDenied},
+		{name: "method without a policy", method: "/iot.v1.DeviceService/DeleteDevice", expectedCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.New(tt.metadata))
+
+			var scoped call
+			handler := func(ctx context.Context, req any) (any, error) {
+				scoped = callOf(ctx)
+				return nil, nil
+			}
+
+			_, err := authenticator(tt.apiAuthRequired, tt.deviceAuthRequired).UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+
+			st := status.Convert(err)
+			if st.Code() != tt.expectedCode {
+				t.Fatalf("expected code %s, got %s: %s", tt.expectedCode, st.Code(), st.Message())
+			}
+
+			if tt.expectedReason != "" && reasonOf(st) != tt.expectedReason {
+				t.Errorf("expected reason %s, got %s", tt.expectedReason, reasonOf(st))
+			}
+
+			if tt.expectedTenant != "" && scoped.scope.Tenant != tt.expectedTenant {
+				t.Errorf("expected the call to act for %s, got %s", tt.expectedTenant, scoped.scope.Tenant)
+			}
+
+			if tt.expectedCode == codes.OK && scoped.requestID == "" {
+				t.Error("expected the call to have a request id")
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedCode   codes.Code
+		expectedReason string
+		expectedFields int
+	}{
+		{name: "not found", err: domain.ErrDeviceNotFound, expectedCode: codes.NotFound, expectedReason: "device_not_found"},
+		{name: "invalid fields", err: domain.Invalid(domain.ErrInvalidSensor, domain.FieldError{Field: "name", Reason: "must not be empty"}), expectedCode: codes.InvalidArgument, expectedReason: "invalid_sensor", expectedFields: 1},
+		{name: "stale version", err: domain.ErrDeviceVersionConflict, expectedCode: codes.FailedPrecondition, expectedReason: "device_version_conflict"},
+		{name: "conflict", err: domain.ErrInvalidDeviceTransition, expectedCode: codes.Aborted, expectedReason: "invalid_device_transition"},
+		{name: "rate limited", err: domain.ErrRateLimited, expectedCode: codes.ResourceExhausted, expectedReason: domain.CodeOf(domain.ErrRateLimited)},
+		{name: "internal", err: context.DeadlineExceeded, expectedCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(statusError(tt.err))
+
+			if st.Code() != tt.expectedCode {
+				t.Fatalf("expected code %s, got %s", tt.expectedCode, st.Code())
+			}
+
+			if reasonOf(st) != tt.expectedReason {
+				t.Errorf("expected reason %q, got %q", tt.expectedReason, reasonOf(st))
+			}
+
+			fields := 0
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					fields = len(badRequest.GetFieldViolations())
+				}
+			}
+			if fields != tt.expectedFields {
+				t.Errorf("expected %d field violations, got %d", tt.expectedFields, fields)
+			}
+		})
+	}
+}
+
+func reasonOf(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
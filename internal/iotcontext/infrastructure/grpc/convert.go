@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+var deviceStatuses = map[domain.DeviceStatus]iotv1.DeviceStatus{
+	domain.DeviceProvisioned:    iotv1.DeviceStatus_DEVICE_STATUS_PROVISIONED,
+	domain.DeviceActive:         iotv1.DeviceStatus_DEVICE_STATUS_ACTIVE,
+	domain.DeviceMaintenance:    iotv1.DeviceStatus_DEVICE_STATUS_MAINTENANCE,
+	domain.DeviceDecommissioned: iotv1.DeviceStatus_DEVICE_STATUS_DECOMMISSIONED,
+}
+
+func toDeviceStatus(status iotv1.DeviceStatus) (domain.DeviceStatus, bool) {
+	for domainStatus, protoStatus := range deviceStatuses {
+		if protoStatus == status {
+			return domainStatus, true
+		}
+	}
+
+	return "", false
+}
+
+func fromDevice(device *domain.Device) *iotv1.Device {
+	message := &iotv1.Device{
+		Id:         string(device.ID),
+		TenantId:   string(device.TenantID),
+		Name:       device.Name,
+		Type:       device.Type,
+		Status:     deviceStatuses[device.Status],
+		Labels:     device.Labels,
+		LocationId: string(device.LocationID),
+		CreatedAt:  timestamppb.New(device.CreatedAt),
+		UpdatedAt:  timestamppb.New(device.UpdatedAt),
+		Version:    device.Version,
+	}
+	if device.Geo != nil {
+		message.Geo = &iotv1.GeoPoint{Lat: device.Geo.Lat, Lon: device.Geo.Lon}
+	}
+
+	return message
+}
+
+func fromSensor(sensor *domain.Sensor) *iotv1.Sensor {
+	return &iotv1.Sensor{
+		Id:        string(sensor.ID),
+		TenantId:  string(sensor.TenantID),
+		DeviceId:  string(sensor.DeviceID),
+		Name:      sensor.Name,
+		Type:      string(sensor.Type),
+		Config:    fromSensorConfig(sensor.Config),
+		Labels:    sensor.Labels,
+		CreatedAt: timestamppb.New(sensor.CreatedAt),
+		UpdatedAt: timestamppb.New(sensor.UpdatedAt),
+		Version:   sensor.Version,
+	}
+}
+
+func fromSensorConfig(config domain.SensorConfig) *iotv1.SensorConfig {
+	return &iotv1.SensorConfig{
+		Revision:       config.Revision,
+		SamplingRateMs: int32(config.SamplingRateMs),
+		Thresholds:     &iotv1.Thresholds{Min: config.Thresholds.Min, Max: config.Thresholds.Max},
+		ErrorRate:      config.ErrorRate,
+		Enabled:        config.Enabled,
+		UpdatedAt:      timestamppb.New(config.UpdatedAt),
+		Meta:           fromMeta(config.Meta),
+	}
+}
+
+// toSensorConfig returns the config a client sent for the sensor. The
+// revision and the time of the change are the server's to set.
+func toSensorConfig(sensorID domain.SensorID, config *iotv1.SensorConfig) domain.SensorConfig {
+	result := domain.SensorConfig{
+		SensorID:       sensorID,
+		SamplingRateMs: int(config.GetSamplingRateMs()),
+		ErrorRate:      config.GetErrorRate(),
+		Enabled:        config.GetEnabled(),
+		Meta:           map[string]interface{}{},
+	}
+	if thresholds := config.GetThresholds(); thresholds != nil {
+		result.Thresholds = domain.Thresholds{Min: thresholds.Min, Max: thresholds.Max}
+	}
+	if config.GetMeta() != nil {
+		result.Meta = config.GetMeta().AsMap()
+	}
+
+	return result
+}
+
+func fromReading(reading domain.SensorReading) *iotv1.SensorReading {
+	return &iotv1.SensorReading{
+		Id:        reading.ID,
+		TenantId:  string(reading.TenantID),
+		SensorId:  string(reading.SensorID),
+		DeviceId:  string(reading.DeviceID),
+		Type:      string(reading.Type),
+		Value:     reading.Value,
+		Unit:      reading.Unit,
+		Timestamp: timestamppb.New(reading.Timestamp),
+		Meta:      fromMeta(reading.Meta),
+	}
+}
+
+func fromReadingSeries(series *domain.ReadingSeries) *iotv1.ReadingSeries {
+	points := make([]*iotv1.ReadingPoint, 0, len(series.Points))
+	for _, point := range series.Points {
+		points = append(points, &iotv1.ReadingPoint{
+			Bucket: timestamppb.New(point.Bucket),
+			Min:    point.Min,
+			Max:    point.Max,
+			Avg:    point.Avg,
+			Count:  point.Count,
+			Last:   point.Last,
+		})
+	}
+
+	return &iotv1.ReadingSeries{
+		SensorId:   string(series.SensorID),
+		Resolution: string(series.Resolution),
+		From:       timestamppb.New(series.From),
+		To:         timestamppb.New(series.To),
+		Points:     points,
+	}
+}
+
+// fromMeta returns the meta of a sensor config or reading. Values that have
+// no protobuf counterpart leave it empty rather than failing the call.
+func fromMeta(meta map[string]interface{}) *structpb.Struct {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	converted, err := structpb.NewStruct(meta)
+	if err != nil {
+		log.Printf("gRPC: dropping meta that is not a protobuf Struct: %v", err)
+		return nil
+	}
+
+	return converted
+}
+
+// toTime returns the time of a timestamp, the zero time when it is unset.
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
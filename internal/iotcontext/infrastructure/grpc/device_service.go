@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"github.com/google/uuid"
+)
+
+// reasonVersionRequired rejects a change that does not say which version of
+// the resource it was made against, as a missing If-Match on the REST API.
+const reasonVersionRequired = "version_required"
+
+type DeviceService struct {
+	iotv1.UnimplementedDeviceServiceServer
+	deviceUseCase *application.DeviceUseCase
+}
+
+func NewDeviceService(deviceUseCase *application.DeviceUseCase) *DeviceService {
+	return &DeviceService{
+		deviceUseCase: deviceUseCase,
+	}
+}
+
+func (s *DeviceService) CreateDevice(ctx context.Context, req *iotv1.CreateDeviceRequest) (*iotv1.CreateDeviceResponse, error) {
+	id := domain.DeviceID(uuid.New().String())
+
+	device, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).CreateDevice(id, req.GetName(), req.GetType(), req.GetLabels())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.CreateDeviceResponse{Device: fromDevice(device)}, nil
+}
+
+func (s *DeviceService) GetDevice(ctx context.Context, req *iotv1.GetDeviceRequest) (*iotv1.GetDeviceResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing device ID")
+	}
+
+	device, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).GetDeviceByID(domain.DeviceID(req.GetId()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.GetDeviceResponse{Device: fromDevice(device)}, nil
+}
+
+func (s *DeviceService) ListDevices(ctx context.Context, _ *iotv1.ListDevicesRequest) (*iotv1.ListDevicesResponse, error) {
+	devices, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).GetAllDevices()
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &iotv1.ListDevicesResponse{Devices: make([]*iotv1.Device, 0, len(devices))}
+	for _, device := range devices {
+		response.Devices = append(response.Devices, fromDevice(device))
+	}
+
+	return response, nil
+}
+
+func (s *DeviceService) UpdateDevice(ctx context.Context, req *iotv1.UpdateDeviceRequest) (*iotv1.UpdateDeviceResponse, error) {
+	if err := requireChange(req.GetId(), req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, statusError(domain.Invalid(domain.ErrInvalidDevice, domain.FieldError{Field: "name", Reason: "must not be empty"}))
+	}
+
+	device, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).ExpectVersion(req.GetVersion()).RenameDevice(domain.DeviceID(req.GetId()), req.GetName(), req.GetType())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.UpdateDeviceResponse{Device: fromDevice(device)}, nil
+}
+
+func (s *DeviceService) SetDeviceLabels(ctx context.Context, req *iotv1.SetDeviceLabelsRequest) (*iotv1.SetDeviceLabelsResponse, error) {
+	if err := requireChange(req.GetId(), req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	device, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).ExpectVersion(req.GetVersion()).RelabelDevice(domain.DeviceID(req.GetId()), req.GetLabels())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.SetDeviceLabelsResponse{Device: fromDevice(device)}, nil
+}
+
+func (s *DeviceService) SetDeviceStatus(ctx context.Context, req *iotv1.SetDeviceStatusRequest) (*iotv1.SetDeviceStatusResponse, error) {
+	if err := requireChange(req.GetId(), req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	status, ok := toDeviceStatus(req.GetStatus())
+	if !ok {
+		return nil, statusError(fmt.Errorf("%w: %s", domain.ErrInvalidDeviceStatus, req.GetStatus()))
+	}
+
+	device, err := s.deviceUseCase.ForTenant(callOf(ctx).scope).ExpectVersion(req.GetVersion()).ChangeDeviceStatus(domain.DeviceID(req.GetId()), status)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.SetDeviceStatusResponse{Device: fromDevice(device)}, nil
+}
+
+func (s *DeviceService) DeleteDevice(ctx context.Context, req *iotv1.DeleteDeviceRequest) (*iotv1.DeleteDeviceResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing device ID")
+	}
+
+	if err := s.deviceUseCase.ForTenant(callOf(ctx).scope).DeleteDevice(domain.DeviceID(req.GetId())); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.DeleteDeviceResponse{}, nil
+}
+
+// requireChange checks a change names the resource and the version it was
+// made against.
+func requireChange(id string, version int64) error {
+	if id == "" {
+		return invalidArgument(reasonMissingParameter, "Missing ID")
+	}
+
+	if version <= 0 {
+		return invalidArgument(reasonVersionRequired, "Missing version: send the version the change was made against")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"log"
+	"time"
+)
+
+// ErrorDomain is the domain of the ErrorInfo every error carries. Its reason
+// is the code the REST API answers the same error with, so clients branch on
+// it rather than on the message.
+const ErrorDomain = "iot-sensor-app"
+
+// rateLimitRetryDelay is how long a rate limited client is told to wait, as
+// the Retry-After of the REST API.
+const rateLimitRetryDelay = 60 * time.Second
+
+// kindCode is the status code each kind of domain error is answered with.
+var kindCode = map[domain.ErrorKind]codes.Code{
+	domain.KindNotFound:           codes.NotFound,
+	domain.KindValidation:         codes.InvalidArgument,
+	domain.KindConflict:           codes.Aborted,
+	domain.KindPreconditionFailed: codes.FailedPrecondition,
+	domain.KindUnauthenticated:    codes.Unauthenticated,
+	domain.KindForbidden:          codes.PermissionDenied,
+	domain.KindRateLimited:        codes.ResourceExhausted,
+}
+
+// statusError returns the status of the domain error err wraps. Any other
+// error is internal: it is logged, and the client is only told so.
+func statusError(err error) error {
+	code, ok := kindCode[domain.KindOf(err)]
+	if !ok {
+		log.Printf("internal error: %v", err)
+		return status.Error(codes.Internal, "Internal error")
+	}
+
+	return detailedStatus(code, domain.CodeOf(err), err.Error(), domain.FieldsOf(err)).Err()
+}
+
+// Reasons of the errors that do not come from a domain error, the codes of
+// the same problems on the REST API.
+const (
+	reasonMissingParameter = "missing_parameter"
+	reasonUnauthenticated  = "unauthenticated"
+	reasonForbidden        = "forbidden"
+)
+
+// invalidArgument reports a malformed request.
+func invalidArgument(reason string, message string) error {
+	return detailedStatus(codes.InvalidArgument, reason, message, nil).Err()
+}
+
+func unauthenticated(message string) error {
+	return detailedStatus(codes.Unauthenticated, reasonUnauthenticated, message, nil).Err()
+}
+
+func permissionDenied(message string) error {
+	return detailedStatus(codes.PermissionDenied, reasonForbidden, message, nil).Err()
+}
+
+func detailedStatus(code codes.Code, reason string, message string, fields []domain.FieldError) *status.Status {
+	st := status.New(code, message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain}}
+	if len(fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+		for _, field := range fields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Reason})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if code == codes.ResourceExhausted {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(rateLimitRetryDelay)})
+	}
+
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+
+	return detailed
+}
@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"io"
+	"log"
+	"time"
+)
+
+const defaultSeriesMaxPoints = 500
+
+type ReadingService struct {
+	iotv1.UnimplementedReadingServiceServer
+	readingsUseCase *application.ReadingsUsecase
+}
+
+func NewReadingService(readingsUseCase *application.ReadingsUsecase) *ReadingService {
+	return &ReadingService{
+		readingsUseCase: readingsUseCase,
+	}
+}
+
+func (s *ReadingService) ListReadings(ctx context.Context, req *iotv1.ListReadingsRequest) (*iotv1.ListReadingsResponse, error) {
+	if req.GetSensorId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing sensor ID")
+	}
+
+	readings, err := s.readingsUseCase.ForTenant(callOf(ctx).scope).GetPaginatedReadings(
+		domain.SensorID(req.GetSensorId()),
+		int(req.GetFrom()),
+		int(req.GetTo()),
+		int(req.GetLimit()),
+	)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &iotv1.ListReadingsResponse{Readings: make([]*iotv1.SensorReading, 0, len(readings))}
+	for _, reading := range readings {
+		response.Readings = append(response.Readings, fromReading(reading))
+	}
+
+	return response, nil
+}
+
+func (s *ReadingService) GetReadingSeries(ctx context.Context, req *iotv1.GetReadingSeriesRequest) (*iotv1.GetReadingSeriesResponse, error) {
+	if req.GetSensorId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing sensor ID")
+	}
+
+	if req.GetFrom() == nil {
+		return nil, invalidArgument(reasonMissingParameter, "Missing 'from'")
+	}
+
+	to := time.Now().UTC()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	maxPoints := defaultSeriesMaxPoints
+	if req.GetMaxPoints() != 0 {
+		maxPoints = int(req.GetMaxPoints())
+	}
+
+	series, err := s.readingsUseCase.ForTenant(callOf(ctx).scope).GetReadingSeries(domain.SensorID(req.GetSensorId()), req.GetFrom().AsTime(), to, maxPoints)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.GetReadingSeriesResponse{Series: fromReadingSeries(series)}, nil
+}
+
+func (s *ReadingService) IngestReading(ctx context.Context, req *iotv1.IngestReadingRequest) (*iotv1.IngestReadingResponse, error) {
+	c := callOf(ctx)
+
+	reading, err := s.readingsUseCase.ForTenant(c.scope).IngestReading(c.device, domain.SensorID(req.GetSensorId()), req.GetValue(), toTime(req.GetTimestamp()), time.Now().UTC())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.IngestReadingResponse{Reading: fromReading(*reading)}, nil
+}
+
+// IngestReadings answers every streamed reading with the stored reading or
+// the reason it was rejected, until the client closes its side.
+func (s *ReadingService) IngestReadings(stream iotv1.ReadingService_IngestReadingsServer) error {
+	c := callOf(stream.Context())
+	readingsUseCase := s.readingsUseCase.ForTenant(c.scope)
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response := &iotv1.IngestReadingsResponse{Sequence: req.GetSequence()}
+		reading, err := readingsUseCase.IngestReading(c.device, domain.SensorID(req.GetSensorId()), req.GetValue(), toTime(req.GetTimestamp()), time.Now().UTC())
+		if err != nil {
+			response.Result = &iotv1.IngestReadingsResponse_Error{Error: ingestError(err, c.requestID)}
+		} else {
+			response.Result = &iotv1.IngestReadingsResponse_Reading{Reading: fromReading(*reading)}
+		}
+
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// ingestError is the reason a streamed reading was rejected. Like
+// statusError, it tells the client no more than that an error that is not a
+// domain error is internal.
+func ingestError(err error, requestID string) *iotv1.IngestError {
+	if _, ok := kindCode[domain.KindOf(err)]; !ok {
+		log.Printf("internal error [%s]: %v", requestID, err)
+		return &iotv1.IngestError{Code: string(domain.KindInternal), Message: "Internal error"}
+	}
+
+	return &iotv1.IngestError{Code: domain.CodeOf(err), Message: err.Error()}
+}
+
+// WatchReadings streams the readings stored from the call on until the
+// client goes away. The headers are sent once the watch is live, so a client
+// that waits for them misses nothing stored afterwards.
+func (s *ReadingService) WatchReadings(req *iotv1.WatchReadingsRequest, stream iotv1.ReadingService_WatchReadingsServer) error {
+	sensorIDs := make([]domain.SensorID, 0, len(req.GetSensorIds()))
+	for _, id := range req.GetSensorIds() {
+		sensorIDs = append(sensorIDs, domain.SensorID(id))
+	}
+
+	readings, stop, err := s.readingsUseCase.ForTenant(callOf(stream.Context()).scope).WatchReadings(sensorIDs)
+	if err != nil {
+		return statusError(err)
+	}
+	defer stop()
+
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case reading, ok := <-readings:
+			if !ok {
+				return nil
+			}
+
+			if err := stream.Send(&iotv1.WatchReadingsResponse{Reading: fromReading(reading)}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+)
+
+type SensorService struct {
+	iotv1.UnimplementedSensorServiceServer
+	sensorUseCase *application.SensorUseCase
+}
+
+func NewSensorService(sensorUseCase *application.SensorUseCase) *SensorService {
+	return &SensorService{
+		sensorUseCase: sensorUseCase,
+	}
+}
+
+func (s *SensorService) CreateSensor(ctx context.Context, req *iotv1.CreateSensorRequest) (*iotv1.CreateSensorResponse, error) {
+	var fields []domain.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"name", req.GetName()},
+		{"type", req.GetType()},
+		{"device_id", req.GetDeviceId()},
+	} {
+		if field.value == "" {
+			fields = append(fields, domain.FieldError{Field: field.name, Reason: "must not be empty"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, statusError(domain.Invalid(domain.ErrInvalidSensor, fields...))
+	}
+
+	id := domain.SensorID(uuid.New().String())
+	sensors := s.sensorUseCase.ForTenant(callOf(ctx).scope)
+
+	err := sensors.CreateSensor(
+		id,
+		domain.DeviceID(req.GetDeviceId()),
+		req.GetName(),
+		domain.SensorType(req.GetType()),
+		toSensorConfig(id, req.GetConfig()),
+		req.GetLabels(),
+	)
+	if err != nil {
+		return nil, referenceError(err)
+	}
+
+	sensor, err := sensors.GetSensorByID(id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.CreateSensorResponse{Sensor: fromSensor(sensor)}, nil
+}
+
+func (s *SensorService) GetSensor(ctx context.Context, req *iotv1.GetSensorRequest) (*iotv1.GetSensorResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing sensor ID")
+	}
+
+	sensor, err := s.sensorUseCase.ForTenant(callOf(ctx).scope).GetSensorByID(domain.SensorID(req.GetId()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.GetSensorResponse{Sensor: fromSensor(sensor)}, nil
+}
+
+func (s *SensorService) ListSensors(ctx context.Context, req *iotv1.ListSensorsRequest) (*iotv1.ListSensorsResponse, error) {
+	sensorUseCase := s.sensorUseCase.ForTenant(callOf(ctx).scope)
+
+	var sensors []*domain.Sensor
+	var err error
+	if req.GetDeviceId() != "" {
+		sensors, err = sensorUseCase.GetSensorsByDevice(domain.DeviceID(req.GetDeviceId()))
+	} else {
+		sensors, err = sensorUseCase.GetAllSensors()
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &iotv1.ListSensorsResponse{Sensors: make([]*iotv1.Sensor, 0, len(sensors))}
+	for _, sensor := range sensors {
+		response.Sensors = append(response.Sensors, fromSensor(sensor))
+	}
+
+	return response, nil
+}
+
+func (s *SensorService) UpdateSensorConfig(ctx context.Context, req *iotv1.UpdateSensorConfigRequest) (*iotv1.UpdateSensorConfigResponse, error) {
+	if err := requireChange(req.GetId(), req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	id := domain.SensorID(req.GetId())
+	sensor, err := s.sensorUseCase.ForTenant(callOf(ctx).scope).ExpectVersion(req.GetVersion()).UpdateSensorConfigById(id, toSensorConfig(id, req.GetConfig()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.UpdateSensorConfigResponse{Sensor: fromSensor(sensor)}, nil
+}
+
+func (s *SensorService) DeleteSensor(ctx context.Context, req *iotv1.DeleteSensorRequest) (*iotv1.DeleteSensorResponse, error) {
+	if req.GetId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing sensor ID")
+	}
+
+	if err := s.sensorUseCase.ForTenant(callOf(ctx).scope).DeleteSensor(domain.SensorID(req.GetId())); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.DeleteSensorResponse{}, nil
+}
+
+// referenceError is statusError for requests naming in their body a device
+// other than the one addressed. That device missing does not make the
+// resource missing, so it is an invalid argument rather than NOT_FOUND.
+func referenceError(err error) error {
+	if errors.Is(err, domain.ErrDeviceNotFound) {
+		return detailedStatus(codes.InvalidArgument, domain.CodeOf(err), err.Error(), nil).Err()
+	}
+
+	return statusError(err)
+}
@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1"
+)
+
+// simulationActions are the actions of the simulator use case.
+var simulationActions = map[iotv1.SimulationAction]string{
+	iotv1.SimulationAction_SIMULATION_ACTION_START:        "start",
+	iotv1.SimulationAction_SIMULATION_ACTION_STOP:         "stop",
+	iotv1.SimulationAction_SIMULATION_ACTION_INJECT_ERROR: "inject_error",
+}
+
+type SimulatorService struct {
+	iotv1.UnimplementedSimulatorServiceServer
+	simulatorUseCase *application.SimulatorUseCase
+}
+
+func NewSimulatorService(simulatorUseCase *application.SimulatorUseCase) *SimulatorService {
+	return &SimulatorService{
+		simulatorUseCase: simulatorUseCase,
+	}
+}
+
+func (s *SimulatorService) ControlSimulation(ctx context.Context, req *iotv1.ControlSimulationRequest) (*iotv1.ControlSimulationResponse, error) {
+	if req.GetSensorId() == "" {
+		return nil, invalidArgument(reasonMissingParameter, "Missing sensor ID")
+	}
+
+	action, ok := simulationActions[req.GetAction()]
+	if !ok {
+		return nil, statusError(domain.ErrInvalidAction)
+	}
+
+	if err := s.simulatorUseCase.ForTenant(callOf(ctx).scope).ControlSensor(domain.SensorID(req.GetSensorId()), action); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &iotv1.ControlSimulationResponse{}, nil
+}
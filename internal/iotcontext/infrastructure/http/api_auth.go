@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderAPIKey carries an API key. A key may also be sent as
+// "Authorization: ApiKey <key>", and a JWT as "Authorization: Bearer <jwt>".
+const HeaderAPIKey = "X-API-Key"
+
+const authenticateChallenge = `Bearer, ApiKey`
+
+type principalKey struct{}
+
+// APIAuthenticator works out who a request is made by: a service with an API
+// key, a user with a JWT of the identity provider, or a device the
+// DeviceAuthenticator, when it runs first, already authenticated. What the
+// caller may do is checked per route by Allow and its variants.
+type APIAuthenticator struct {
+	authUseCase *application.AuthUseCase
+	required    bool
+}
+
+// NewAPIAuthenticator builds the middleware. When required is false,
+// requests without credentials act as an admin of the whole deployment;
+// credentials that are presented are still checked.
+func NewAPIAuthenticator(authUseCase *application.AuthUseCase, required bool) *APIAuthenticator {
+	return &APIAuthenticator{
+		authUseCase: authUseCase,
+		required:    required,
+	}
+}
+
+func (a *APIAuthenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+		var principal domain.Principal
+		var err error
+		if deviceID, ok := AuthenticatedDevice(r.Context()); ok {
+			principal = domain.Principal{Subject: "device:" + string(deviceID), Role: domain.RoleDevice}
+		} else {
+			switch {
+			case r.Header.Get(HeaderAPIKey) != "":
+				principal, err = a.authUseCase.AuthenticateAPIKey(r.Header.Get(HeaderAPIKey), now)
+			case strings.EqualFold(scheme, "ApiKey"):
+				principal, err = a.authUseCase.AuthenticateAPIKey(strings.TrimSpace(credentials), now)
+			case strings.EqualFold(scheme, "Bearer"):
+				principal, err = a.authUseCase.AuthenticateToken(strings.TrimSpace(credentials), now)
+			case a.required:
+				// Whether the route needs a principal is up to Allow.
+				next.ServeHTTP(w, r)
+				return
+			default:
+				principal = domain.Principal{Subject: "anonymous", Role: domain.RoleAdmin}
+			}
+		}
+
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", authenticateChallenge)
+			writeProblem(w, http.StatusUnauthorized, codeUnauthenticated, "Authentication failed")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// RequestPrincipal returns who the request is made by. It is absent when
+// authentication is required and no credentials were presented.
+func RequestPrincipal(ctx context.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return principal, ok
+}
+
+// Allow lets a request through when its principal has one of the roles.
+func Allow(next http.Handler, roles ...domain.Role) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize(w, r, roles) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// AllowDeployment guards what affects every tenant: only a principal that is
+// not bound to a tenant and has the role gets through.
+func AllowDeployment(next http.Handler, role domain.Role) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorize(w, r, []domain.Role{role}) {
+			return
+		}
+
+		if principal, _ := RequestPrincipal(r.Context()); principal.TenantID != "" {
+			writeProblem(w, http.StatusForbidden, codeForbidden, "Only credentials of the whole deployment are allowed to do this")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authorize(w http.ResponseWriter, r *http.Request, roles []domain.Role) bool {
+	principal, ok := RequestPrincipal(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", authenticateChallenge)
+		writeProblem(w, http.StatusUnauthorized, codeUnauthenticated, "Authentication required")
+		return false
+	}
+
+	for _, role := range roles {
+		if principal.Role.Allows(role) {
+			return true
+		}
+	}
+
+	writeProblem(w, http.StatusForbidden, codeForbidden, "Role "+string(principal.Role)+" is not allowed to do this")
+	return false
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAPIAuthenticator(t *testing.T) {
+	tenancy := application.NewTenancy(domain.TenantQuotas{}, nil, nil)
+	tokens, err := persistence.NewJWTTokenVerifier(persistence.JWTOptions{HMACSecret: "jwt-s3cret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auth := application.NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), tokens, "bootstrap-s3cret")
+	now := time.Now()
+
+	viewer, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("dashboard", domain.RoleViewer, 0, now)
+	operator, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("automation", domain.RoleOperator, 0, now)
+	admin, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("tenant admin", domain.RoleAdmin, 0, now)
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","role":"operator","tenant_id":"globex","exp":` + strconv.FormatInt(now.Add(time.Hour).Unix(), 10) + `}`))
+	mac := hmac.New(sha256.New, []byte("jwt-s3cret"))
+	mac.Write([]byte(header + "." + claims))
+	jwt := header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	// The handler answers with the tenant the request was scoped to.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestTenant(r.Context()).Tenant))
+	})
+
+	tenants := NewTenantResolver(tenancy, persistence.NewInMemoryDeviceRepository())
+	mux := func(required bool) *http.ServeMux {
+		apiAuth := NewAPIAuthenticator(auth, required)
+		mux := http.NewServeMux()
+		mux.Handle("GET /devices", apiAuth.Authenticate(Allow(tenants.Resolve(handler), domain.RoleViewer)))
+		mux.Handle("POST /devices", apiAuth.Authenticate(Allow(tenants.Resolve(handler), domain.RoleOperator)))
+		mux.Handle("/api-keys", apiAuth.Authenticate(Allow(tenants.Resolve(handler), domain.RoleAdmin)))
+		mux.Handle("/campaigns", apiAuth.Authenticate(AllowDeployment(handler, domain.RoleOperator)))
+		return mux
+	}
+
+	tests := []struct {
+		name           string
+		required       bool
+		method         string
+		target         string
+		headers        map[string]string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{name: "no credentials", required: true, method: http.MethodGet, target: "/devices", expectedStatus: http.StatusUnauthorized},
+		{name: "no credentials when disabled", method: http.MethodPost, target: "/campaigns", expectedStatus: http.StatusOK},
+		{name: "viewer reads", required: true, method: http.MethodGet, target: "/devices", headers: map[string]string{HeaderAPIKey: viewer.Key}, expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "viewer writes", required: true, method: http.MethodPost, target: "/devices", headers: map[string]string{HeaderAPIKey: viewer.Key}, expectedStatus: http.StatusForbidden},
+		{name: "operator writes", required: true, method: http.MethodPost, target: "/devices", headers: map[string]string{"Authorization": "ApiKey " + operator.Key}, expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "operator manages keys", required: true, method: http.MethodGet, target: "/api-keys", headers: map[string]string{HeaderAPIKey: operator.Key}, expectedStatus: http.StatusForbidden},
+		{name: "admin manages keys", required: true, method: http.MethodGet, target: "/api-keys", headers: map[string]string{HeaderAPIKey: admin.Key}, expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "key for another tenant", required: true, method: http.MethodGet, target: "/devices", headers: map[string]string{HeaderAPIKey: viewer.Key, HeaderTenant: "globex"}, expectedStatus: http.StatusForbidden},
+		{name: "unknown key", required: true, method: http.MethodGet, target: "/devices", headers: map[string]string{HeaderAPIKey: "ghost.s3cret"}, expectedStatus: http.StatusUnauthorized},
+		{name: "unknown key when disabled", method: http.MethodGet, target: "/devices", headers: map[string]string{HeaderAPIKey: "ghost.s3cret"}, expectedStatus: http.StatusUnauthorized},
+		{name: "jwt", required: true, method: http.MethodPost, target: "/devices", headers: map[string]string{"Authorization": "Bearer " + jwt}, expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "forged jwt", required: true, method: http.MethodGet, target: "/devices", headers: map[string]string{"Authorization": "Bearer " + jwt + "x"}, expectedStatus: http.StatusUnauthorized},
+		{name: "tenant admin runs campaigns", required: true, method: http.MethodPost, target: "/campaigns", headers: map[string]string{HeaderAPIKey: admin.Key}, expectedStatus: http.StatusForbidden},
+		{name: "bootstrap key runs campaigns", required: true, method: http.MethodPost, target: "/campaigns", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret"}, expectedStatus: http.StatusOK},
+		{name: "bootstrap key picks a tenant", required: true, method: http.MethodGet, target: "/api-keys", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret", HeaderTenant: "globex"}, expectedStatus: http.StatusOK, expectedTenant: "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			mux(tt.required).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+
+			if tt.expectedTenant != "" && rec.Body.String() != tt.expectedTenant {
+				t.Errorf("expected the request to act for %s, got %s", tt.expectedTenant, rec.Body.String())
+			}
+
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
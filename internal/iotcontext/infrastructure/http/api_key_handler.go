@@ -0,0 +1,81 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+	"time"
+)
+
+type APIKeyHandler struct {
+	authUseCase *application.AuthUseCase
+}
+
+func NewAPIKeyHandler(authUseCase *application.AuthUseCase) *APIKeyHandler {
+	return &APIKeyHandler{
+		authUseCase: authUseCase,
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	TTL  string `json:"ttl"`
+}
+
+// Create handles POST /api-keys. The key is bound to the tenant of the
+// request and never expires unless a ttl is given; the response is the only
+// place it ever appears.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	role, err := domain.ParseRole(req.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid ttl")
+			return
+		}
+	}
+
+	issued, err := h.authUseCase.ForTenant(requestScope(r)).CreateAPIKey(req.Name, role, ttl, time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusCreated, issued)
+}
+
+// List handles GET /api-keys, oldest first.
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.authUseCase.ForTenant(requestScope(r)).ListAPIKeys()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusOK, keys)
+}
+
+// Revoke handles DELETE /api-keys/{id}. The key stops working at once.
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	key, err := h.authUseCase.ForTenant(requestScope(r)).RevokeAPIKey(domain.APIKeyID(r.PathValue("id")), time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusOK, key)
+}
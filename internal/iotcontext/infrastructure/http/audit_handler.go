@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	auditUseCase *application.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *application.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+// List handles GET /audit?actor=&action=&resource_type=&resource_id=&request_id=&from=&to=&limit=,
+// newest first. from and to are RFC3339; every filter is optional. A caller
+// of the whole deployment that names no tenant sees the entries of every
+// tenant and those of the deployment itself, like firmware uploads.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Actor:        query.Get("actor"),
+		Action:       domain.AuditAction(query.Get("action")),
+		ResourceType: query.Get("resource_type"),
+		ResourceID:   query.Get("resource_id"),
+		RequestID:    query.Get("request_id"),
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if value := query.Get(bound.name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid '"+bound.name+"' parameter, expected RFC3339")
+				return
+			}
+			*bound.value = parsed
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'limit' parameter")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	useCase := h.auditUseCase.ForTenant(requestScope(r))
+	if principal, _ := RequestPrincipal(r.Context()); principal.TenantID == "" && r.Header.Get(HeaderTenant) == "" {
+		useCase = h.auditUseCase
+	}
+
+	entries, err := useCase.ListEntries(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditHandler(t *testing.T) {
+	auditRepo := persistence.NewInMemoryAuditRepository()
+	tenancy := application.NewTenancy(domain.TenantQuotas{}, auditRepo, nil)
+	auth := application.NewAuthUseCase(persistence.NewInMemoryAPIKeyRepository(), nil, "bootstrap-s3cret")
+	tenants := NewTenantResolver(tenancy, persistence.NewInMemoryDeviceRepository())
+	apiAuth := NewAPIAuthenticator(auth, true)
+
+	now := time.Now()
+	operator, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("automation", domain.RoleOperator, 0, now)
+	admin, _ := auth.ForTenant(tenancy.Scope("acme")).CreateAPIKey("tenant admin", domain.RoleAdmin, 0, now)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /api-keys", apiAuth.Authenticate(Allow(tenants.Resolve(http.HandlerFunc(NewAPIKeyHandler(auth).Create)), domain.RoleOperator)))
+	mux.Handle("GET /audit", apiAuth.Authenticate(Allow(tenants.Resolve(http.HandlerFunc(NewAuditHandler(application.NewAuditUseCase(auditRepo)).List)), domain.RoleAdmin)))
+	handler := AssignRequestID(mux)
+
+	do := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if body != "" {
+			req = httptest.NewRequest(method, target, strings.NewReader(body))
+		}
+		req.RemoteAddr = "10.0.0.7:51234"
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api-keys", `{"name":"dashboard","role":"viewer"}`, map[string]string{HeaderAPIKey: operator.Key, HeaderRequestID: "req-42"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(HeaderRequestID) != "req-42" {
+		t.Errorf("expected the request id to be echoed, got %q", rec.Header().Get(HeaderRequestID))
+	}
+
+	// A change made for another tenant by the bootstrap key.
+	rec = do(http.MethodPost, "/api-keys", `{"name":"ingest","role":"operator"}`, map[string]string{HeaderAPIKey: "bootstrap-s3cret", HeaderTenant: "globex"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(HeaderRequestID) == "" {
+		t.Error("expected a generated request id")
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		headers        map[string]string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "tenant admin", target: "/audit", headers: map[string]string{HeaderAPIKey: admin.Key}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "whole deployment", target: "/audit", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret"}, expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "deployment picks a tenant", target: "/audit", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret", HeaderTenant: "globex"}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "filter by request", target: "/audit?request_id=req-42", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret"}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "filter by actor", target: "/audit?actor=bootstrap&action=api_key.create", headers: map[string]string{HeaderAPIKey: "bootstrap-s3cret"}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "bad time", target: "/audit?from=yesterday", headers: map[string]string{HeaderAPIKey: admin.Key}, expectedStatus: http.StatusBadRequest},
+		{name: "operator", target: "/audit", headers: map[string]string{HeaderAPIKey: operator.Key}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(http.MethodGet, tt.target, "", tt.headers)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var entries []domain.AuditEntry
+			if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(entries) != tt.expectedCount {
+				t.Errorf("expected %d entries, got %d", tt.expectedCount, len(entries))
+			}
+		})
+	}
+
+	entries, _ := auditRepo.Find(domain.AuditFilter{RequestID: "req-42"})
+	expected := domain.AuditActor{Subject: "api-key:" + string(operator.ID), Role: domain.RoleOperator, RequestID: "req-42", SourceIP: "10.0.0.7"}
+	if len(entries) != 1 || entries[0].Actor != expected || entries[0].TenantID != "acme" || entries[0].Action != domain.AuditAPIKeyCreate {
+		t.Errorf("expected the key created by the operator of acme, got %+v", entries)
+	}
+}
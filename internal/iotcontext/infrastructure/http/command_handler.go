@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CommandHandler struct {
+	commandUseCase application.CommandUseCase
+}
+
+func NewCommandHandler(commandUseCase application.CommandUseCase) *CommandHandler {
+	return &CommandHandler{
+		commandUseCase: commandUseCase,
+	}
+}
+
+type CreateCommandRequest struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params"`
+	TTL    string                 `json:"ttl"`
+}
+
+// Create handles POST /devices/{id}/commands.
+func (h *CommandHandler) Create(w http.ResponseWriter, r *http.Request) {
+	deviceID := domain.DeviceID(r.PathValue("id"))
+
+	var req CreateCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid ttl")
+			return
+		}
+		ttl = parsed
+	}
+
+	command, err := h.commandUseCase.ForTenant(requestScope(r)).CreateCommand(
+		domain.CommandID(uuid.New().String()),
+		deviceID,
+		req.Name,
+		req.Params,
+		ttl,
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/devices/"+string(deviceID)+"/commands/"+string(command.ID))
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(command); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode command")
+		return
+	}
+}
+
+// List handles GET /devices/{id}/commands, newest first.
+func (h *CommandHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	commands, err := h.commandUseCase.ForTenant(requestScope(r)).ListCommands(domain.DeviceID(r.PathValue("id")), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(commands); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode commands")
+		return
+	}
+}
+
+// Get handles GET /devices/{id}/commands/{commandID}.
+func (h *CommandHandler) Get(w http.ResponseWriter, r *http.Request) {
+	command, err := h.commandUseCase.ForTenant(requestScope(r)).GetCommand(domain.DeviceID(r.PathValue("id")), domain.CommandID(r.PathValue("commandID")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(command); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode command")
+		return
+	}
+}
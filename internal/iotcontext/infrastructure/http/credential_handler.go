@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+	"time"
+)
+
+const defaultClaimTokenTTL = 24 * time.Hour
+
+type CredentialHandler struct {
+	credentialUseCase *application.CredentialUseCase
+}
+
+func NewCredentialHandler(credentialUseCase *application.CredentialUseCase) *CredentialHandler {
+	return &CredentialHandler{
+		credentialUseCase: credentialUseCase,
+	}
+}
+
+type IssueCredentialRequest struct {
+	Type string `json:"type"`
+}
+
+// Issue handles POST /devices/{id}/credentials. The response is the only
+// place the secret or private key ever appear.
+func (h *CredentialHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	var req IssueCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	typ, err := domain.ParseCredentialType(req.Type)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	issued, err := h.credentialUseCase.ForTenant(requestScope(r)).IssueCredential(domain.DeviceID(r.PathValue("id")), typ, time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusCreated, issued)
+}
+
+// List handles GET /devices/{id}/credentials, oldest first.
+func (h *CredentialHandler) List(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.credentialUseCase.ForTenant(requestScope(r)).ListCredentials(domain.DeviceID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusOK, credentials)
+}
+
+// Rotate handles POST /devices/{id}/credentials/{credentialID}/rotate.
+func (h *CredentialHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	issued, err := h.credentialUseCase.ForTenant(requestScope(r)).RotateCredential(
+		domain.DeviceID(r.PathValue("id")),
+		domain.CredentialID(r.PathValue("credentialID")),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusCreated, issued)
+}
+
+// Revoke handles DELETE /devices/{id}/credentials/{credentialID}.
+func (h *CredentialHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	credential, err := h.credentialUseCase.ForTenant(requestScope(r)).RevokeCredential(
+		domain.DeviceID(r.PathValue("id")),
+		domain.CredentialID(r.PathValue("credentialID")),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusOK, credential)
+}
+
+type CreateClaimTokenRequest struct {
+	DeviceType string `json:"device_type"`
+	MaxUses    int    `json:"max_uses"`
+	TTL        string `json:"ttl"`
+}
+
+// CreateClaimToken handles POST /claim-tokens. A token is good for one
+// registration within a day unless told otherwise.
+func (h *CredentialHandler) CreateClaimToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateClaimTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+
+	ttl := defaultClaimTokenTTL
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid ttl")
+			return
+		}
+		ttl = parsed
+	}
+
+	token, err := h.credentialUseCase.ForTenant(requestScope(r)).CreateClaimToken(req.DeviceType, req.MaxUses, ttl, time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCredentialJSON(w, http.StatusCreated, token)
+}
+
+type RegisterDeviceRequest struct {
+	ClaimToken     string `json:"claim_token"`
+	Name           string `json:"name"`
+	CredentialType string `json:"credential_type"`
+}
+
+type registeredDevice struct {
+	Device     *domain.Device                `json:"device"`
+	Credential *application.IssuedCredential `json:"credential"`
+}
+
+// Register handles POST /devices/register, where a device enrolls itself
+// with a claim token.
+func (h *CredentialHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.ClaimToken == "" || req.Name == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing claim_token or name")
+		return
+	}
+
+	typ, err := domain.ParseCredentialType(req.CredentialType)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	device, issued, err := h.credentialUseCase.ForTenant(requestScope(r)).Register(req.ClaimToken, req.Name, typ, time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/devices/"+string(device.ID))
+	writeCredentialJSON(w, http.StatusCreated, registeredDevice{Device: device, Credential: issued})
+}
+
+func writeCredentialJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
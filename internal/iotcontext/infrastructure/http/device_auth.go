@@ -0,0 +1,118 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of a request signed with an HMAC credential.
+const (
+	HeaderDeviceCredential = "X-Device-Credential"
+	HeaderDeviceTimestamp  = "X-Device-Timestamp"
+	HeaderDeviceSignature  = "X-Device-Signature"
+)
+
+// maxSignedBodyBytes caps the body read to check a signature.
+const maxSignedBodyBytes = 1 << 20
+
+type authenticatedDeviceKey struct{}
+
+// DeviceAuthenticator guards the endpoints devices call. A device proves who
+// it is either with the client certificate of the TLS connection or by
+// signing the request with its HMAC credential.
+type DeviceAuthenticator struct {
+	credentialUseCase *application.CredentialUseCase
+	required          bool
+}
+
+// NewDeviceAuthenticator builds the middleware. When required is false,
+// requests without credentials go through anonymously; credentials that are
+// presented are still checked.
+func NewDeviceAuthenticator(credentialUseCase *application.CredentialUseCase, required bool) *DeviceAuthenticator {
+	return &DeviceAuthenticator{
+		credentialUseCase: credentialUseCase,
+		required:          required,
+	}
+}
+
+func (a *DeviceAuthenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+
+		var deviceID domain.DeviceID
+		var err error
+		switch {
+		case r.TLS != nil && len(r.TLS.PeerCertificates) > 0:
+			fingerprint := sha256.Sum256(r.TLS.PeerCertificates[0].Raw)
+			deviceID, err = a.credentialUseCase.AuthenticateCertificate(hex.EncodeToString(fingerprint[:]), now)
+		case r.Header.Get(HeaderDeviceCredential) != "":
+			deviceID, err = a.authenticateSignature(w, r, now)
+		case a.required:
+			w.Header().Set("WWW-Authenticate", "HMAC-SHA256")
+			writeProblem(w, http.StatusUnauthorized, codeUnauthenticated, "Device authentication required")
+			return
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "HMAC-SHA256")
+			writeProblem(w, http.StatusUnauthorized, codeUnauthenticated, "Device authentication failed")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authenticatedDeviceKey{}, deviceID)))
+	})
+}
+
+// authenticateSignature reads the body to check the signature and puts it
+// back for the handler.
+func (a *DeviceAuthenticator) authenticateSignature(w http.ResponseWriter, r *http.Request, now time.Time) (domain.DeviceID, error) {
+	seconds, err := strconv.ParseInt(r.Header.Get(HeaderDeviceTimestamp), 10, 64)
+	if err != nil {
+		return "", domain.ErrUnauthenticated
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+	if err != nil {
+		return "", domain.ErrUnauthenticated
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	return a.credentialUseCase.AuthenticateSignature(
+		domain.CredentialID(r.Header.Get(HeaderDeviceCredential)),
+		r.Header.Get(HeaderDeviceSignature),
+		r.Method,
+		r.URL.RequestURI(),
+		time.Unix(seconds, 0),
+		body,
+		now,
+	)
+}
+
+// AuthenticatedDevice returns the device the request authenticated as. It is
+// absent only when authentication is optional and none was presented.
+func AuthenticatedDevice(ctx context.Context) (domain.DeviceID, bool) {
+	deviceID, ok := ctx.Value(authenticatedDeviceKey{}).(domain.DeviceID)
+	return deviceID, ok
+}
+
+// authorizeDevice rejects a request made by one device on behalf of another.
+func authorizeDevice(w http.ResponseWriter, r *http.Request, deviceID domain.DeviceID) bool {
+	authenticated, ok := AuthenticatedDevice(r.Context())
+	if ok && authenticated != deviceID {
+		writeProblem(w, http.StatusForbidden, codeForbidden, "Device is not allowed to act for another device")
+		return false
+	}
+
+	return true
+}
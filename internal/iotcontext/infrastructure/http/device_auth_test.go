@@ -0,0 +1,99 @@
+package http
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(domain.IoTEvent) error { return nil }
+
+func TestDeviceAuthenticator(t *testing.T) {
+	devices := persistence.NewInMemoryDeviceRepository()
+	for _, id := range []domain.DeviceID{"device-1", "device-2"} {
+		device, _ := domain.NewDevice(id, "Gateway", "gateway")
+		devices.Save(device)
+	}
+
+	sensors := persistence.NewInMemorySensorRepository()
+	sensorUseCase := application.NewSensorUseCase(sensors, nil, devices, nil, nil, discardPublisher{})
+	deviceUseCase := application.NewDeviceUseCase(devices, sensors, sensorUseCase, nil, discardPublisher{})
+	credentials := application.NewCredentialUseCase(
+		devices,
+		persistence.NewInMemoryCredentialRepository(),
+		persistence.NewInMemoryClaimTokenRepository(),
+		deviceUseCase,
+		nil,
+		discardPublisher{},
+		time.Hour,
+	)
+
+	issued, err := credentials.IssueCredential("device-1", domain.CredentialHMAC, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The handler echoes the body to prove it survived the signature check.
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeDevice(w, r, domain.DeviceID(r.PathValue("id"))) {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}
+
+	sign := func(req *http.Request, secret string, body string) {
+		now := time.Now()
+		req.Header.Set(HeaderDeviceCredential, string(issued.ID))
+		req.Header.Set(HeaderDeviceTimestamp, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(HeaderDeviceSignature, domain.SignRequest(secret, req.Method, req.URL.RequestURI(), now, []byte(body)))
+	}
+
+	tests := []struct {
+		name           string
+		required       bool
+		target         string
+		secret         string
+		expectedStatus int
+	}{
+		{name: "signed by the device", required: true, target: "/devices/device-1/firmware", secret: issued.Secret, expectedStatus: http.StatusOK},
+		{name: "wrong secret", required: true, target: "/devices/device-1/firmware", secret: "guess", expectedStatus: http.StatusUnauthorized},
+		{name: "acting for another device", required: true, target: "/devices/device-2/firmware", secret: issued.Secret, expectedStatus: http.StatusForbidden},
+		{name: "unsigned when required", required: true, target: "/devices/device-1/firmware", expectedStatus: http.StatusUnauthorized},
+		{name: "unsigned when optional", required: false, target: "/devices/device-2/firmware", expectedStatus: http.StatusOK},
+		{name: "wrong secret when optional", required: false, target: "/devices/device-1/firmware", secret: "guess", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("PUT /devices/{id}/firmware", NewDeviceAuthenticator(credentials, tt.required).Authenticate(http.HandlerFunc(handler)))
+
+			body := `{"status":"downloading"}`
+			req := httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(body))
+			if tt.secret != "" {
+				sign(req, tt.secret, body)
+			}
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if w.Code == http.StatusOK && w.Body.String() != body {
+				t.Errorf("expected the handler to read the body, got %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -6,92 +6,268 @@ import (
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"github.com/google/uuid"
 	"net/http"
+	"time"
 )
 
 type DeviceHandlers struct {
-	deviceUseCase application.DeviceUseCase
+	deviceUseCase   application.DeviceUseCase
+	presenceUseCase *application.PresenceUseCase
+	groupUseCase    *application.GroupUseCase
 }
 
-func NewDeviceHandlers(deviceUseCase application.DeviceUseCase) *DeviceHandlers {
+func NewDeviceHandlers(
+	deviceUseCase application.DeviceUseCase,
+	presenceUseCase *application.PresenceUseCase,
+	groupUseCase *application.GroupUseCase,
+) *DeviceHandlers {
 	return &DeviceHandlers{
-		deviceUseCase: deviceUseCase,
+		deviceUseCase:   deviceUseCase,
+		presenceUseCase: presenceUseCase,
+		groupUseCase:    groupUseCase,
 	}
 }
 
-func (dh *DeviceHandlers) DevicesHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		if r.URL.Path == "/devices?" {
-			dh.GetByID(w, r)
-		} else {
-			dh.All(w)
-		}
-	case http.MethodPost:
-		dh.Create(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+type deviceWithPresence struct {
+	*domain.Device
+	Presence domain.DevicePresence `json:"presence"`
+}
+
+// List handles GET /devices. With the id query parameter it is the
+// deprecated alias of GET /devices/{id}.
+func (h *DeviceHandlers) List(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("id") {
+		Deprecated(h.GetByID, "/devices/{id}", "id")(w, r)
+		return
 	}
+
+	h.All(w, r)
 }
 
-func (h *DeviceHandlers) All(w http.ResponseWriter) {
-	devices, err := h.deviceUseCase.GetAllDevices()
+// All lists every device, or only those matching the selector and/or group
+// query parameters.
+func (h *DeviceHandlers) All(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.findDevices(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve devices", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
+	now := time.Now().UTC()
+	response := make([]deviceWithPresence, 0, len(devices))
+	for _, device := range devices {
+		response = append(response, h.withPresence(device, now))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(devices); err != nil {
-		http.Error(w, "Failed to encode devices", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode devices")
 		return
 	}
 }
 
+func (h *DeviceHandlers) findDevices(r *http.Request) ([]*domain.Device, error) {
+	if !hasTarget(r) {
+		return h.deviceUseCase.ForTenant(requestScope(r)).GetAllDevices()
+	}
+
+	target, err := parseTarget(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.groupUseCase.ForTenant(requestScope(r)).Devices(target)
+}
+
 func (h *DeviceHandlers) GetByID(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := resourceID(r, "id")
 	if id == "" {
-		http.Error(w, "Missing device ID", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
 		return
 	}
 
-	device, err := h.deviceUseCase.GetDeviceByID(domain.DeviceID(id))
+	device, err := h.deviceUseCase.ForTenant(requestScope(r)).GetDeviceByID(domain.DeviceID(id))
 	if err != nil {
-		http.Error(w, "Device not found", http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setVersionETag(w, device.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(device); err != nil {
-		http.Error(w, "Failed to encode device", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(h.withPresence(device, time.Now().UTC())); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode device")
 		return
 	}
 }
 
 func (h *DeviceHandlers) Create(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
+		Name   string        `json:"name"`
+		Type   string        `json:"type"`
+		Labels domain.Labels `json:"labels"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid request payload")
 		return
 	}
 
 	id := domain.DeviceID(uuid.New().String())
 
-	device, err := h.deviceUseCase.CreateDevice(id, req.Name, req.Type)
+	device, err := h.deviceUseCase.ForTenant(requestScope(r)).CreateDevice(id, req.Name, req.Type, req.Labels)
 	if err != nil {
-		http.Error(w, "Failed to create device: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
+	setVersionETag(w, device.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(device); err != nil {
-		http.Error(w, "Failed to encode device", http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode device")
+		return
+	}
+}
+
+func (h *DeviceHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid request payload")
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, domain.Invalid(domain.ErrInvalidDevice, domain.FieldError{Field: "name", Reason: "must not be empty"}))
+		return
+	}
+
+	device, err := h.deviceUseCase.ForTenant(requestScope(r)).ExpectVersion(version).RenameDevice(domain.DeviceID(id), req.Name, req.Type)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeDevice(w, device)
+}
+
+// Patch applies a partial update: any of name, type, labels and status may be
+// sent. Labels replace the current ones as a whole. Either every change is
+// made, as one new version of the device, or none is.
+func (h *DeviceHandlers) Patch(w http.ResponseWriter, r *http.Request) {
+	id := domain.DeviceID(resourceID(r, "id"))
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name   *string        `json:"name"`
+		Type   *string        `json:"type"`
+		Labels *domain.Labels `json:"labels"`
+		Status *string        `json:"status"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid request payload")
+		return
+	}
+
+	patch := application.DevicePatch{Name: req.Name, Type: req.Type, Labels: req.Labels}
+	if req.Status != nil {
+		status, err := domain.ParseDeviceStatus(*req.Status)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		patch.Status = &status
+	}
+
+	device, err := h.deviceUseCase.ForTenant(requestScope(r)).ExpectVersion(version).PatchDevice(id, patch)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeDevice(w, device)
+}
+
+func (h *DeviceHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	if err := h.deviceUseCase.ForTenant(requestScope(r)).DeleteDevice(domain.DeviceID(id)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Heartbeat records that the device is alive and returns its presence.
+func (h *DeviceHandlers) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	if !authorizeDevice(w, r, domain.DeviceID(id)) {
+		return
+	}
+
+	presence, err := h.presenceUseCase.ForTenant(requestScope(r)).Heartbeat(domain.DeviceID(id), time.Now().UTC())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(presence); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode presence")
+		return
+	}
+}
+
+func (h *DeviceHandlers) withPresence(device *domain.Device, now time.Time) deviceWithPresence {
+	response := deviceWithPresence{Device: device}
+	if h.presenceUseCase != nil {
+		response.Presence = h.presenceUseCase.GetPresence(*device, now)
+	}
+
+	return response
+}
+
+func writeDevice(w http.ResponseWriter, device *domain.Device) {
+	setVersionETag(w, device.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(device); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode device")
 		return
 	}
 }
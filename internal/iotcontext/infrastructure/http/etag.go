@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Devices and sensors are sent with their version as a strong ETag, and a
+// PUT or PATCH of one must send that ETag back in If-Match. A change made
+// from a stale copy is rejected with 412 instead of overwriting what someone
+// else changed in between.
+
+// setVersionETag sends version as the ETag of the response.
+func setVersionETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// ifMatchVersion reads the If-Match header of r. It returns the version of
+// the ETag, or 0 for "*", which matches any version. An ETag that is not one
+// of ours, weak ETags included as If-Match compares strongly, can never
+// match: it is reported as not ok.
+func ifMatchVersion(r *http.Request) (version int64, present bool, ok bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false, true
+	}
+
+	if value == "*" {
+		return 0, true, true
+	}
+
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, true, false
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, true, false
+	}
+
+	return version, true, true
+}
+
+// requireIfMatch returns the version the request was made against. Without
+// If-Match it writes 428 and, when the header cannot match any version, 412.
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	version, present, ok := ifMatchVersion(r)
+	if !present {
+		writeProblem(w, http.StatusPreconditionRequired, codeIfMatchRequired, "If-Match header required: send the ETag of the version being changed")
+		return 0, false
+	}
+
+	if !ok {
+		writeProblem(w, http.StatusPreconditionFailed, codeIfMatchFailed, "If-Match does not match the current version")
+		return 0, false
+	}
+
+	return version, true
+}
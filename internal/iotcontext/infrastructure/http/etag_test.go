@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int64
+		expectedStatus  int
+	}{
+		{name: "missing header", expectedStatus: http.StatusPreconditionRequired},
+		{name: "version etag", ifMatch: `"3"`, expectedVersion: 3},
+		{name: "any version", ifMatch: "*"},
+		{name: "weak etag", ifMatch: `W/"3"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "unquoted", ifMatch: "3", expectedStatus: http.StatusPreconditionFailed},
+		{name: "foreign etag", ifMatch: `"abc"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "zero version", ifMatch: `"0"`, expectedStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/devices?id=device-1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			version, ok := requireIfMatch(w, req)
+
+			if tt.expectedStatus != 0 {
+				if ok {
+					t.Fatalf("expected the request to be rejected")
+				}
+				if w.Code != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("unexpected rejection with status %d", w.Code)
+			}
+			if version != tt.expectedVersion {
+				t.Errorf("expected version %d, got %d", tt.expectedVersion, version)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxFirmwareBytes caps the size of an uploaded firmware image.
+const maxFirmwareBytes = 256 << 20
+
+type FirmwareHandler struct {
+	firmwareUseCase *application.FirmwareUseCase
+}
+
+func NewFirmwareHandler(firmwareUseCase *application.FirmwareUseCase) *FirmwareHandler {
+	return &FirmwareHandler{
+		firmwareUseCase: firmwareUseCase,
+	}
+}
+
+// Upload handles POST /firmware?version=&device_type=&checksum=. The body is
+// the raw image; checksum, when given, is the expected hex sha256.
+func (h *FirmwareHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("version") == "" || query.Get("device_type") == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing version or device_type")
+		return
+	}
+
+	firmware, err := h.firmwareUseCase.ForActor(requestScope(r)).UploadFirmware(
+		domain.FirmwareID(uuid.New().String()),
+		query.Get("version"),
+		query.Get("device_type"),
+		query.Get("checksum"),
+		http.MaxBytesReader(w, r.Body, maxFirmwareBytes),
+	)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, http.StatusRequestEntityTooLarge, codeTooLarge, "Firmware image too large")
+			return
+		}
+
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/firmware/"+string(firmware.ID))
+	writeFirmwareJSON(w, http.StatusCreated, firmware)
+}
+
+// List handles GET /firmware, newest first.
+func (h *FirmwareHandler) List(w http.ResponseWriter, r *http.Request) {
+	firmwares, err := h.firmwareUseCase.ListFirmware()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, firmwares)
+}
+
+// Get handles GET /firmware/{id}.
+func (h *FirmwareHandler) Get(w http.ResponseWriter, r *http.Request) {
+	firmware, err := h.firmwareUseCase.GetFirmware(domain.FirmwareID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, firmware)
+}
+
+// Download handles GET /firmware/{id}/artifact, used by devices to fetch the
+// image they were told to install.
+func (h *FirmwareHandler) Download(w http.ResponseWriter, r *http.Request) {
+	firmware, content, err := h.firmwareUseCase.OpenArtifact(domain.FirmwareID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(firmware.Size, 10))
+	w.Header().Set("X-Checksum-Sha256", firmware.Checksum)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("firmware %s: download interrupted: %v", firmware.ID, err)
+	}
+}
+
+type CreateCampaignRequest struct {
+	FirmwareID       string   `json:"firmware_id"`
+	Stages           []int    `json:"stages"`
+	FailureThreshold *float64 `json:"failure_threshold"`
+}
+
+// CreateCampaign handles POST /campaigns.
+func (h *FirmwareHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var req CreateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.FirmwareID == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing firmware_id")
+		return
+	}
+
+	campaign, err := h.firmwareUseCase.ForActor(requestScope(r)).CreateCampaign(
+		domain.CampaignID(uuid.New().String()),
+		domain.FirmwareID(req.FirmwareID),
+		req.Stages,
+		req.FailureThreshold,
+	)
+	if err != nil {
+		if errors.Is(err, domain.ErrFirmwareNotFound) {
+			writeProblem(w, http.StatusUnprocessableEntity, domain.CodeOf(err), err.Error())
+			return
+		}
+
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/campaigns/"+string(campaign.ID))
+	writeFirmwareJSON(w, http.StatusCreated, campaign)
+}
+
+// ListCampaigns handles GET /campaigns, newest first.
+func (h *FirmwareHandler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.firmwareUseCase.ListCampaigns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, campaigns)
+}
+
+// GetCampaign handles GET /campaigns/{id}, including every device update.
+func (h *FirmwareHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := h.firmwareUseCase.GetCampaign(domain.CampaignID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, campaign)
+}
+
+// PauseCampaign handles POST /campaigns/{id}/pause.
+func (h *FirmwareHandler) PauseCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaign(w, r, h.firmwareUseCase.ForActor(requestScope(r)).PauseCampaign)
+}
+
+// ResumeCampaign handles POST /campaigns/{id}/resume.
+func (h *FirmwareHandler) ResumeCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaign(w, r, h.firmwareUseCase.ForActor(requestScope(r)).ResumeCampaign)
+}
+
+// AbortCampaign handles POST /campaigns/{id}/abort.
+func (h *FirmwareHandler) AbortCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaign(w, r, h.firmwareUseCase.ForActor(requestScope(r)).AbortCampaign)
+}
+
+func (h *FirmwareHandler) changeCampaign(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(domain.CampaignID) (*application.CampaignDetail, error),
+) {
+	campaign, err := change(domain.CampaignID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, campaign)
+}
+
+type ReportUpdateRequest struct {
+	CampaignID string                    `json:"campaign_id"`
+	Status     domain.DeviceUpdateStatus `json:"status"`
+	Progress   int                       `json:"progress"`
+	Error      string                    `json:"error"`
+}
+
+// ReportUpdate handles PUT /devices/{id}/firmware, where a device reports
+// the progress of its update.
+func (h *FirmwareHandler) ReportUpdate(w http.ResponseWriter, r *http.Request) {
+	deviceID := domain.DeviceID(r.PathValue("id"))
+	if !authorizeDevice(w, r, deviceID) {
+		return
+	}
+
+	var req ReportUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.CampaignID == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing campaign_id")
+		return
+	}
+
+	update, err := h.firmwareUseCase.ReportUpdate(
+		deviceID,
+		domain.CampaignID(req.CampaignID),
+		req.Status,
+		req.Progress,
+		req.Error,
+		time.Now(),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeFirmwareJSON(w, http.StatusOK, update)
+}
+
+func writeFirmwareJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
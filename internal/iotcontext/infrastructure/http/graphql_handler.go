@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	iot_graphql "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/graphql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxGraphQLBytes bounds the body of a GraphQL request, far above any query
+// a client means to send.
+const maxGraphQLBytes = 1 << 20
+
+// graphQLKeepAlive is how often an idle subscription is sent a comment, so
+// proxies do not take it for a dead connection.
+const graphQLKeepAlive = 15 * time.Second
+
+type GraphQLHandler struct {
+	schema *iot_graphql.Schema
+}
+
+func NewGraphQLHandler(schema *iot_graphql.Schema) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+	}
+}
+
+// Serve handles GET and POST /graphql. POST takes the request as a JSON body
+// {query, operationName, variables}; GET takes the same as query parameters,
+// variables JSON encoded. The response is JSON, errors included as GraphQL
+// puts them, unless the caller accepts text/event-stream: then each result
+// is sent as a server-sent event, which is how subscriptions are served.
+// Only a request that is not GraphQL at all is answered with a problem.
+func (h *GraphQLHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	req, ok := readGraphQLRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.stream(w, r, req)
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), requestScope(r), RequestID(r.Context()), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("encoding GraphQL response: %v", err)
+	}
+}
+
+// stream sends the results of the request as in the distinct connections
+// mode of GraphQL over SSE: a "next" event per result, then "complete" once
+// there are no more. A subscription lasts until the caller hangs up.
+func (h *GraphQLHandler) stream(w http.ResponseWriter, r *http.Request, req iot_graphql.Request) {
+	responses, err := h.schema.Subscribe(r.Context(), requestScope(r), RequestID(r.Context()), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	keepAlive := time.NewTicker(graphQLKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(":\n\n")); err != nil {
+				return
+			}
+		case response, ok := <-responses:
+			if !ok {
+				w.Write([]byte("event: complete\ndata:\n\n"))
+				controller.Flush()
+				return
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				log.Printf("encoding GraphQL response: %v", err)
+				return
+			}
+			if _, err := w.Write([]byte("event: next\ndata: " + string(data) + "\n\n")); err != nil {
+				return
+			}
+		}
+
+		controller.Flush()
+	}
+}
+
+func readGraphQLRequest(w http.ResponseWriter, r *http.Request) (iot_graphql.Request, bool) {
+	var req iot_graphql.Request
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'variables' parameter, expected a JSON object")
+				return req, false
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, http.StatusRequestEntityTooLarge, codeTooLarge, "GraphQL request too large")
+			return req, false
+		}
+
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return req, false
+	}
+
+	if req.Query == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing 'query'")
+		return req, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	iot_graphql "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/graphql"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/persistence"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	deviceRepo := persistence.NewInMemoryDeviceRepository()
+	sensorRepo := persistence.NewInMemorySensorRepository()
+	feed := persistence.NewFeedSensorReadingRepository(persistence.NewInMemorySensorReadingRepository())
+
+	device, _ := domain.NewDevice("device-1", "greenhouse", "gateway")
+	deviceRepo.Save(device)
+	config, _ := domain.NewSensorConfig("sensor-1", 1000, domain.Thresholds{}, 0, true)
+	sensor, _ := domain.NewSensor("sensor-1", device.ID, "soil", "humidity", config)
+	sensorRepo.Save(sensor)
+
+	sensorUseCase := application.NewSensorUseCase(sensorRepo, nil, deviceRepo, nil, nil, nil)
+	schema := iot_graphql.NewSchema(
+		application.NewDeviceUseCase(deviceRepo, sensorRepo, sensorUseCase, nil, nil),
+		sensorUseCase,
+		application.NewReadingsUsecase(feed, nil, sensorRepo, deviceRepo, feed, nil),
+		application.NewGroupUseCase(persistence.NewInMemoryGroupRepository(), deviceRepo, sensorRepo, nil),
+	)
+	tenants := NewTenantResolver(application.NewTenancy(domain.TenantQuotas{}, nil, nil), deviceRepo)
+	server := httptest.NewServer(AssignRequestID(tenants.Resolve(http.HandlerFunc(NewGraphQLHandler(schema).Serve))))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedBody   string
+	}{
+		{name: "post", method: http.MethodPost, target: "/graphql", body: `{"query":"query Device($id: ID!) { device(id: $id) { name sensors { id } } }","variables":{"id":"device-1"}}`, expectedStatus: http.StatusOK, expectedBody: `{"data":{"device":{"name":"greenhouse","sensors":[{"id":"sensor-1"}]}}}`},
+		{name: "get", method: http.MethodGet, target: "/graphql?query=" + url.QueryEscape(`query Sensor($id: ID!) { sensor(id: $id) { name } }`) + "&variables=" + url.QueryEscape(`{"id":"sensor-1"}`), expectedStatus: http.StatusOK, expectedBody: `{"data":{"sensor":{"name":"soil"}}}`},
+		{name: "errors stay in the response", method: http.MethodPost, target: "/graphql", body: `{"query":"{ devices(selector: \"zone in (\") { id } }"}`, expectedStatus: http.StatusOK, expectedCode: "invalid_selector"},
+		{name: "invalid json", method: http.MethodPost, target: "/graphql", body: `{"query":`, expectedStatus: http.StatusBadRequest, expectedCode: codeInvalidJSON},
+		{name: "missing query", method: http.MethodPost, target: "/graphql", body: `{}`, expectedStatus: http.StatusBadRequest, expectedCode: codeMissingParameter},
+		{name: "invalid variables", method: http.MethodGet, target: "/graphql?query=%7B+devices+%7B+id+%7D+%7D&variables=nope", expectedStatus: http.StatusBadRequest, expectedCode: codeInvalidParameter},
+		{name: "too large", method: http.MethodPost, target: "/graphql", body: `{"query":"` + strings.Repeat(" ", maxGraphQLBytes) + `"}`, expectedStatus: http.StatusRequestEntityTooLarge, expectedCode: codeTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.target, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, res.StatusCode)
+			}
+
+			var body struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []struct {
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+				Code string `json:"code"`
+			}
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			code := body.Code
+			if len(body.Errors) > 0 {
+				code, _ = body.Errors[0].Extensions["code"].(string)
+			}
+			if code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, code)
+			}
+
+			if tt.expectedBody != "" && `{"data":`+string(body.Data)+`}` != tt.expectedBody {
+				t.Errorf("expected %s, got data %s", tt.expectedBody, body.Data)
+			}
+		})
+	}
+
+	t.Run("subscription", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/graphql", strings.NewReader(`{"query":"subscription { readingAdded(sensorIds: [\"sensor-1\"]) { sensorId value } }"}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req.Header.Set("Accept", "text/event-stream")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer res.Body.Close()
+
+		if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %q", contentType)
+		}
+
+		// The watch is live by the time a reading arrives; keep storing
+		// until one does.
+		go func() {
+			for ctx.Err() == nil {
+				reading := domain.NewSensorReading("sensor-1", "device-1", "humidity", 40.5, "%", time.Now())
+				feed.Save(&reading)
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		scanner := bufio.NewScanner(res.Body)
+		var event string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if name, found := strings.CutPrefix(line, "event: "); found {
+				event = name
+			}
+			if data, found := strings.CutPrefix(line, "data: "); found {
+				if event != "next" {
+					t.Fatalf("expected a next event, got %q", event)
+				}
+				if data != `{"data":{"readingAdded":{"sensorId":"sensor-1","value":40.5}}}` {
+					t.Errorf("expected the stored reading, got %s", data)
+				}
+				return
+			}
+		}
+
+		t.Fatalf("expected a reading before the stream ended: %v", scanner.Err())
+	})
+}
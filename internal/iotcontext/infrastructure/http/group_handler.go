@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+type GroupHandler struct {
+	groupUseCase *application.GroupUseCase
+}
+
+func NewGroupHandler(groupUseCase *application.GroupUseCase) *GroupHandler {
+	return &GroupHandler{
+		groupUseCase: groupUseCase,
+	}
+}
+
+// GroupRequest defines a static group with device_ids or a dynamic one with
+// a selector such as "site=madrid,floor in (1,2)".
+type GroupRequest struct {
+	Name      string            `json:"name"`
+	Selector  string            `json:"selector"`
+	DeviceIDs []domain.DeviceID `json:"device_ids"`
+}
+
+// Create handles POST /groups.
+func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	group, err := h.groupUseCase.ForTenant(requestScope(r)).CreateGroup(
+		domain.GroupID(uuid.New().String()),
+		req.Name,
+		req.Selector,
+		req.DeviceIDs,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/groups/"+string(group.ID))
+	writeGroupJSON(w, http.StatusCreated, group)
+}
+
+// List handles GET /groups, ordered by name.
+func (h *GroupHandler) List(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groupUseCase.ForTenant(requestScope(r)).ListGroups()
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	writeGroupJSON(w, http.StatusOK, groups)
+}
+
+// Get handles GET /groups/{id}.
+func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
+	group, err := h.groupUseCase.ForTenant(requestScope(r)).GetGroup(domain.GroupID(r.PathValue("id")))
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	writeGroupJSON(w, http.StatusOK, group)
+}
+
+// Update handles PUT /groups/{id}, replacing the whole definition.
+func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	group, err := h.groupUseCase.ForTenant(requestScope(r)).UpdateGroup(
+		domain.GroupID(r.PathValue("id")),
+		req.Name,
+		req.Selector,
+		req.DeviceIDs,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	writeGroupJSON(w, http.StatusOK, group)
+}
+
+// Delete handles DELETE /groups/{id}.
+func (h *GroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.groupUseCase.ForTenant(requestScope(r)).DeleteGroup(domain.GroupID(r.PathValue("id"))); err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Devices handles GET /groups/{id}/devices, resolving a dynamic group against
+// the current labels.
+func (h *GroupHandler) Devices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.groupUseCase.ForTenant(requestScope(r)).Devices(application.Target{GroupID: domain.GroupID(r.PathValue("id"))})
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	writeGroupJSON(w, http.StatusOK, devices)
+}
+
+// parseTarget reads the selector and group query parameters shared by the
+// endpoints that accept a target instead of a single id.
+func parseTarget(r *http.Request) (application.Target, error) {
+	query := r.URL.Query()
+
+	return application.ParseTarget(query.Get("selector"), query.Get("group"))
+}
+
+func hasTarget(r *http.Request) bool {
+	query := r.URL.Query()
+
+	return query.Has("selector") || query.Has("group")
+}
+
+func writeGroupJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLocationWindow        = 24 * time.Hour
+	defaultLocationReadingsLimit = 1000
+)
+
+type LocationHandler struct {
+	locationUseCase *application.LocationUseCase
+}
+
+func NewLocationHandler(locationUseCase *application.LocationUseCase) *LocationHandler {
+	return &LocationHandler{
+		locationUseCase: locationUseCase,
+	}
+}
+
+type LocationRequest struct {
+	Kind     domain.LocationKind    `json:"kind"`
+	Name     string                 `json:"name"`
+	ParentID domain.LocationID      `json:"parent_id"`
+	Geo      *domain.GeoPoint       `json:"geo"`
+	Indoor   *domain.IndoorPosition `json:"indoor"`
+}
+
+// DeviceLocationRequest assigns a device to a location. An empty location_id
+// takes the device out of the hierarchy.
+type DeviceLocationRequest struct {
+	LocationID domain.LocationID `json:"location_id"`
+	Geo        *domain.GeoPoint  `json:"geo"`
+}
+
+// Create handles POST /locations.
+func (h *LocationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	location, err := h.locationUseCase.ForTenant(requestScope(r)).CreateLocation(
+		domain.LocationID(uuid.New().String()),
+		req.Kind,
+		req.Name,
+		req.ParentID,
+		req.Geo,
+		req.Indoor,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/locations/"+string(location.ID))
+	writeLocationJSON(w, http.StatusCreated, location)
+}
+
+// List handles GET /locations, ordered by name.
+func (h *LocationHandler) List(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.locationUseCase.ForTenant(requestScope(r)).ListLocations()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, locations)
+}
+
+// Get handles GET /locations/{id}.
+func (h *LocationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	location, err := h.locationUseCase.ForTenant(requestScope(r)).GetLocation(domain.LocationID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, location)
+}
+
+// Update handles PUT /locations/{id}. The kind in the body is ignored, a
+// location cannot change kind.
+func (h *LocationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	location, err := h.locationUseCase.ForTenant(requestScope(r)).UpdateLocation(
+		domain.LocationID(r.PathValue("id")),
+		req.Name,
+		req.ParentID,
+		req.Geo,
+		req.Indoor,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, location)
+}
+
+// Delete handles DELETE /locations/{id}.
+func (h *LocationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.locationUseCase.ForTenant(requestScope(r)).DeleteLocation(domain.LocationID(r.PathValue("id"))); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Devices handles GET /locations/{id}/devices, including the devices of every
+// location below it.
+func (h *LocationHandler) Devices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.locationUseCase.ForTenant(requestScope(r)).Devices(domain.LocationID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, devices)
+}
+
+// Readings handles GET /locations/{id}/readings?type=humidity&from=&to=&limit=.
+// The range defaults to the last 24 hours.
+func (h *LocationHandler) Readings(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseLocationWindow(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultLocationReadingsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'limit' parameter")
+			return
+		}
+		limit = parsed
+	}
+
+	readings, err := h.locationUseCase.ForTenant(requestScope(r)).Readings(
+		domain.LocationID(r.PathValue("id")),
+		domain.SensorType(r.URL.Query().Get("type")),
+		from,
+		to,
+		limit,
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, readings)
+}
+
+// Metrics handles GET /locations/{id}/metrics?from=&to=, rolling device and
+// sensor counts and reading stats up the hierarchy. The range defaults to the
+// last 24 hours.
+func (h *LocationHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseLocationWindow(w, r)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.locationUseCase.ForTenant(requestScope(r)).Metrics(domain.LocationID(r.PathValue("id")), from, to)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, metrics)
+}
+
+// Nearby handles GET /devices/nearby?lat=&lon=&radius_km=.
+func (h *LocationHandler) Nearby(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var values [3]float64
+	for i, name := range []string{"lat", "lon", "radius_km"} {
+		value, err := strconv.ParseFloat(query.Get(name), 64)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid '"+name+"' parameter")
+			return
+		}
+		values[i] = value
+	}
+
+	devices, err := h.locationUseCase.ForTenant(requestScope(r)).Nearby(domain.GeoPoint{Lat: values[0], Lon: values[1]}, values[2])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeLocationJSON(w, http.StatusOK, devices)
+}
+
+// AssignDevice handles PUT /devices/{id}/location. Like any other change of
+// the device it must send its ETag in If-Match.
+func (h *LocationHandler) AssignDevice(w http.ResponseWriter, r *http.Request) {
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req DeviceLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	device, err := h.locationUseCase.ForTenant(requestScope(r)).ExpectVersion(version).AssignDevice(domain.DeviceID(r.PathValue("id")), req.LocationID, req.Geo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setVersionETag(w, device.Version)
+	writeLocationJSON(w, http.StatusOK, device)
+}
+
+// parseLocationWindow reads the optional RFC3339 from and to parameters,
+// which default to the last 24 hours. It writes the error itself.
+func parseLocationWindow(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'to' parameter, expected RFC3339")
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultLocationWindow)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'from' parameter, expected RFC3339")
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	return from, to, true
+}
+
+func writeLocationJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
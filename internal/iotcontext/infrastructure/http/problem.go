@@ -0,0 +1,103 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"log"
+	"net/http"
+)
+
+// Every error is answered with an RFC 7807 problem. Code is stable: for a
+// domain error it is the error's own code, so clients branch on it rather
+// than on the detail, which is meant for people.
+
+const problemContentType = "application/problem+json"
+
+// Codes of the problems that do not come from a domain error.
+const (
+	codeInvalidJSON      = "invalid_json"
+	codeMissingParameter = "missing_parameter"
+	codeInvalidParameter = "invalid_parameter"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeTooLarge         = "payload_too_large"
+	codeUnauthenticated  = "unauthenticated"
+	codeForbidden        = "forbidden"
+	codeIfMatchRequired  = "if_match_required"
+	codeIfMatchFailed    = "if_match_failed"
+	codeInternal         = "internal"
+)
+
+// Problem is an RFC 7807 problem details object. It has no type of its own,
+// so Type is about:blank and Title the text of the status; Code, Errors and
+// RequestID are extension members.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Code      string              `json:"code"`
+	Detail    string              `json:"detail,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// kindStatus is the status each kind of domain error is answered with.
+var kindStatus = map[domain.ErrorKind]int{
+	domain.KindNotFound:           http.StatusNotFound,
+	domain.KindValidation:         http.StatusBadRequest,
+	domain.KindConflict:           http.StatusConflict,
+	domain.KindPreconditionFailed: http.StatusPreconditionFailed,
+	domain.KindUnauthenticated:    http.StatusUnauthorized,
+	domain.KindForbidden:          http.StatusForbidden,
+	domain.KindRateLimited:        http.StatusTooManyRequests,
+}
+
+// writeError answers with the problem of the domain error err wraps. Any
+// other error is internal: it is logged, and the client is only told so.
+func writeError(w http.ResponseWriter, err error) {
+	status, ok := kindStatus[domain.KindOf(err)]
+	if !ok {
+		log.Printf("internal error [%s]: %v", w.Header().Get(HeaderRequestID), err)
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Internal error")
+		return
+	}
+
+	writeProblemJSON(w, Problem{
+		Status: status,
+		Code:   domain.CodeOf(err),
+		Detail: err.Error(),
+		Errors: domain.FieldsOf(err),
+	})
+}
+
+// writeReferenceError is writeError for requests naming in their body a
+// device other than the one addressed, such as moving a sensor or adding a
+// member to a group. That device missing does not make the resource missing,
+// so it is answered with 422 rather than 404.
+func writeReferenceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrDeviceNotFound) {
+		writeProblem(w, http.StatusUnprocessableEntity, domain.CodeOf(err), err.Error())
+		return
+	}
+
+	writeError(w, err)
+}
+
+// writeProblem answers with a problem that does not come from a domain
+// error, such as a malformed request.
+func writeProblem(w http.ResponseWriter, status int, code string, detail string) {
+	writeProblemJSON(w, Problem{Status: status, Code: code, Detail: detail})
+}
+
+func writeProblemJSON(w http.ResponseWriter, problem Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.RequestID = w.Header().Get(HeaderRequestID)
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("encoding problem: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedFields int
+	}{
+		{name: "not found", err: domain.ErrSensorNotFound, expectedStatus: http.StatusNotFound, expectedCode: "sensor_not_found"},
+		{name: "wrapped validation", err: fmt.Errorf("%w: %q", domain.ErrInvalidTenant, "a b"), expectedStatus: http.StatusBadRequest, expectedCode: "invalid_tenant"},
+		{name: "field errors", err: domain.Invalid(domain.ErrInvalidSensorConfig, domain.FieldError{Field: "sampling_rate_ms", Reason: "must be positive"}), expectedStatus: http.StatusBadRequest, expectedCode: "invalid_sensor_config", expectedFields: 1},
+		{name: "conflict", err: domain.ErrLocationInUse, expectedStatus: http.StatusConflict, expectedCode: "location_in_use"},
+		{name: "version conflict", err: domain.ErrSensorVersionConflict, expectedStatus: http.StatusPreconditionFailed, expectedCode: "sensor_version_conflict"},
+		{name: "unauthenticated", err: domain.ErrClaimTokenRejected, expectedStatus: http.StatusUnauthorized, expectedCode: "claim_token_rejected"},
+		{name: "forbidden", err: domain.ErrQuotaExceeded, expectedStatus: http.StatusForbidden, expectedCode: "quota_exceeded"},
+		{name: "rate limited", err: domain.ErrRateLimited, expectedStatus: http.StatusTooManyRequests, expectedCode: "rate_limited"},
+		{name: "internal", err: errors.New("pq: connection refused"), expectedStatus: http.StatusInternalServerError, expectedCode: codeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set(HeaderRequestID, "req-7")
+
+			writeError(w, tt.err)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != problemContentType {
+				t.Errorf("expected %s, got %q", problemContentType, contentType)
+			}
+
+			var problem Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if problem.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, problem.Code)
+			}
+			if problem.Status != tt.expectedStatus || problem.Title != http.StatusText(tt.expectedStatus) {
+				t.Errorf("unexpected status or title: %+v", problem)
+			}
+			if len(problem.Errors) != tt.expectedFields {
+				t.Errorf("expected %d field errors, got %+v", tt.expectedFields, problem.Errors)
+			}
+			if problem.RequestID != "req-7" {
+				t.Errorf("expected the request id, got %q", problem.RequestID)
+			}
+		})
+	}
+}
+
+func TestWriteErrorHidesInternalDetails(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	writeError(w, errors.New("pq: password authentication failed for user iot"))
+
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if problem.Detail != "Internal error" {
+		t.Errorf("expected the cause to be hidden, got %q", problem.Detail)
+	}
+}
+
+func TestWriteReferenceError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	writeReferenceError(w, fmt.Errorf("moving sensor: %w", domain.ErrDeviceNotFound))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
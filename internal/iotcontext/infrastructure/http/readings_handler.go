@@ -1,12 +1,17 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+const defaultSeriesMaxPoints = 500
+
 type ReadingsHandler struct {
 	readingsUsecase application.ReadingsUsecase
 }
@@ -17,52 +22,126 @@ func NewReadingsHandler(readingsUsecase application.ReadingsUsecase) *ReadingsHa
 	}
 }
 
-func (h *ReadingsHandler) GetPaginatedReadings(r *http.Request, w http.ResponseWriter) ([]domain.SensorReading, error) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return nil, nil
+// Readings handles GET /sensors/{id}/readings?from=&to=&limit=, the page
+// [from, to) of the latest limit readings of the sensor.
+func (h *ReadingsHandler) Readings(w http.ResponseWriter, r *http.Request) {
+	sensorID := resourceID(r, "sensor_id")
+	if sensorID == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor_id parameter")
+		return
 	}
 
-	sensorID, from, to, limit, _ := h.parsePaginationParams(r, w)
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'from' parameter")
+		return
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'to' parameter")
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'limit' parameter")
+		return
+	}
 
-	readings, err := h.readingsUsecase.GetPaginatedReadings(domain.SensorID(sensorID), from, to, limit)
+	readings, err := h.readingsUsecase.ForTenant(requestScope(r)).GetPaginatedReadings(domain.SensorID(sensorID), from, to, limit)
 	if err != nil {
-		http.Error(w, "Failed to retrieve readings", http.StatusInternalServerError)
-		return nil, err
+		writeError(w, err)
+		return
 	}
 
-	return readings, nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(readings); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode readings")
+		return
+	}
 }
 
-func (h *ReadingsHandler) parsePaginationParams(r *http.Request, w http.ResponseWriter) (string, int, int, int, error) {
-	sensorID := r.URL.Query().Get("sensor_id")
-	from, err := strconv.Atoi(r.URL.Query().Get("from"))
-	if err != nil {
-		http.Error(w, "Invalid 'from' parameter", http.StatusBadRequest)
-		return "", 0, 0, 0, err
+// Series handles GET /sensors/{id}/readings/series?from=&to=&max_points=.
+func (h *ReadingsHandler) Series(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	sensorID := resourceID(r, "sensor_id")
+	if sensorID == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor_id parameter")
+		return
 	}
-	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
 	if err != nil {
-		http.Error(w, "Invalid 'to' parameter", http.StatusBadRequest)
-		return "", 0, 0, 0, err
+		writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'from' parameter, expected RFC3339")
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		to, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'to' parameter, expected RFC3339")
+			return
+		}
 	}
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	maxPoints := defaultSeriesMaxPoints
+	if value := query.Get("max_points"); value != "" {
+		maxPoints, err = strconv.Atoi(value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid 'max_points' parameter")
+			return
+		}
+	}
+
+	series, err := h.readingsUsecase.ForTenant(requestScope(r)).GetReadingSeries(domain.SensorID(sensorID), from, to, maxPoints)
 	if err != nil {
-		http.Error(w, "Invalid 'limit' parameter", http.StatusBadRequest)
-		return "", 0, 0, 0, err
+		writeError(w, err)
+		return
 	}
 
-	return sensorID, from, to, limit, nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode readings")
+		return
+	}
 }
 
-func (h *ReadingsHandler) SensorReadingsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		_, err := h.GetPaginatedReadings(r, w)
-		if err != nil {
-			return
+type IngestReadingRequest struct {
+	Value     *float64  `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Ingest handles POST /sensors/{id}/readings, where a device pushes a reading
+// of one of its own sensors. The timestamp defaults to the time of arrival.
+func (h *ReadingsHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	var req IngestReadingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.Value == nil {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing value")
+		return
+	}
+
+	deviceID, _ := AuthenticatedDevice(r.Context())
+	reading, err := h.readingsUsecase.ForTenant(requestScope(r)).IngestReading(deviceID, domain.SensorID(r.PathValue("id")), *req.Value, req.Timestamp, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, domain.ErrRateLimited) {
+			w.Header().Set("Retry-After", "60")
 		}
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(reading); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode reading")
+		return
 	}
 }
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"net"
+	"net/http"
+)
+
+// HeaderRequestID carries the id of a request. An id sent by the client is
+// kept, so a request can be followed across services; otherwise one is
+// generated. Either way it is sent back in the response.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the ids accepted from clients.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// AssignRequestID gives every request an id. It runs before anything else so
+// the id is there for logs and the audit log.
+func AssignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestID returns the id of the request, empty when AssignRequestID did
+// not run.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestActor is who the changes a request makes are audited for. The
+// source IP is the peer of the connection: behind a proxy, the proxy's.
+func requestActor(r *http.Request) domain.AuditActor {
+	actor := domain.AuditActor{
+		RequestID: RequestID(r.Context()),
+		SourceIP:  r.RemoteAddr,
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		actor.SourceIP = host
+	}
+
+	if principal, ok := RequestPrincipal(r.Context()); ok {
+		actor.Subject = principal.Subject
+		actor.Role = principal.Role
+	}
+
+	return actor
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Devices and sensors are addressed by path: /devices/{id}, /sensors/{id}
+// and their subresources. The routes that took the id in the query string
+// (/devices?id=, /readings?sensor_id=, ...) remain as deprecated aliases of
+// those until clients have moved.
+
+// resourceID returns the {id} of the route or, on a deprecated alias, the
+// param query parameter.
+func resourceID(r *http.Request, param string) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+
+	return r.URL.Query().Get(param)
+}
+
+// Deprecated serves a deprecated alias with next, telling clients in the
+// Deprecation and Link headers which route replaces it. successor is that
+// route's path, whose {id} is filled from the param query parameter.
+func Deprecated(next http.HandlerFunc, successor string, param string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successorURL(r, successor, param)+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// successorURL moves the param query parameter of r into the {id} of
+// successor, keeping the rest of the query.
+func successorURL(r *http.Request, successor string, param string) string {
+	query := r.URL.Query()
+	target := strings.Replace(successor, "{id}", url.PathEscape(query.Get(param)), 1)
+	query.Del(param)
+
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+
+	return target
+}
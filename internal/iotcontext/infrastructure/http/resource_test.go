@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeprecatedAlias(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		successor    string
+		param        string
+		expectedID   string
+		expectedLink string
+	}{
+		{
+			name:         "id moves into the path",
+			target:       "/devices?id=device-1",
+			successor:    "/devices/{id}",
+			param:        "id",
+			expectedID:   "device-1",
+			expectedLink: `</devices/device-1>; rel="successor-version"`,
+		},
+		{
+			name:         "rest of the query is kept",
+			target:       "/readings?sensor_id=sensor-1&from=0&to=10&limit=10",
+			successor:    "/sensors/{id}/readings",
+			param:        "sensor_id",
+			expectedID:   "sensor-1",
+			expectedLink: `</sensors/sensor-1/readings?from=0&limit=10&to=10>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id string
+			handler := Deprecated(func(w http.ResponseWriter, r *http.Request) {
+				id = resourceID(r, tt.param)
+			}, tt.successor, tt.param)
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if id != tt.expectedID {
+				t.Errorf("expected id %q, got %q", tt.expectedID, id)
+			}
+			if w.Header().Get("Deprecation") != "true" {
+				t.Errorf("expected a Deprecation header")
+			}
+			if link := w.Header().Get("Link"); link != tt.expectedLink {
+				t.Errorf("expected Link %q, got %q", tt.expectedLink, link)
+			}
+		})
+	}
+}
+
+func TestResourceIDPrefersThePath(t *testing.T) {
+	mux := http.NewServeMux()
+	var id string
+	mux.HandleFunc("GET /devices/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id = resourceID(r, "id")
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/devices/device-1?id=device-2", nil))
+
+	if id != "device-1" {
+		t.Errorf("expected the id of the path, got %q", id)
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"github.com/google/uuid"
 	"net/http"
+	"strconv"
 )
 
 type SensorHandlers struct {
 	application.SensorUseCase
+	groupUseCase *application.GroupUseCase
 }
 
-func NewSensorHandlers(sensorUseCase application.SensorUseCase) *SensorHandlers {
+func NewSensorHandlers(sensorUseCase application.SensorUseCase, groupUseCase *application.GroupUseCase) *SensorHandlers {
 	return &SensorHandlers{
 		SensorUseCase: sensorUseCase,
+		groupUseCase:  groupUseCase,
 	}
 }
 
@@ -24,45 +27,50 @@ type CreateSensorRequest struct {
 	Type     string                 `json:"type"`
 	DeviceID string                 `json:"device_id"`
 	Config   map[string]interface{} `json:"config"`
+	Labels   domain.Labels          `json:"labels"`
 }
 
-func (h *SensorHandlers) SensorsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		if r.URL.Path != "/sensors?" {
-			h.GetAllSensors(w)
-		} else {
-			id := r.URL.Query().Get("id")
-			if id == "" {
-				http.Error(w, "Missing sensor ID", http.StatusBadRequest)
-				return
-			}
+// List handles GET /sensors. With the id query parameter it is the
+// deprecated alias of GET /sensors/{id}.
+func (h *SensorHandlers) List(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("id") {
+		Deprecated(h.GetSensorByID, "/sensors/{id}", "id")(w, r)
+		return
+	}
 
-			h.GetSensorByID(w, r)
-		}
-	case http.MethodPost:
-		h.CreateSensor(w, r)
-	case http.MethodPut:
-		h.UpdateSensorConfigById(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	h.GetAllSensors(w, r)
+}
+
+// UpdateSensors handles PUT /sensors?selector=...&group=.... With the id
+// query parameter it is the deprecated alias of PUT /sensors/{id}/config.
+func (h *SensorHandlers) UpdateSensors(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("id") {
+		Deprecated(h.UpdateSensorConfigById, "/sensors/{id}/config", "id")(w, r)
+		return
 	}
+
+	if !hasTarget(r) {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing selector or group parameter")
+		return
+	}
+
+	h.UpdateSensorConfigs(w, r)
 }
 
 func (h *SensorHandlers) CreateSensor(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req CreateSensorRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
 		return
 	}
 
 	if err := h.validateCreateRequest(req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -70,20 +78,21 @@ func (h *SensorHandlers) CreateSensor(w http.ResponseWriter, r *http.Request) {
 
 	sensorConfig, err := h.unmarshalConfig(req.Config)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("Invalid config: %v", err))
 		return
 	}
 
 	sensorConfig.SensorID = sensorID
 
-	if err := h.SensorUseCase.CreateSensor(
+	if err := h.SensorUseCase.ForTenant(requestScope(r)).CreateSensor(
 		sensorID,
 		domain.DeviceID(req.DeviceID),
 		req.Name,
 		domain.SensorType(req.Type),
 		sensorConfig,
+		req.Labels,
 	); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create sensor: %v", err), http.StatusInternalServerError)
+		writeReferenceError(w, err)
 		return
 	}
 
@@ -97,82 +106,364 @@ func (h *SensorHandlers) CreateSensor(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *SensorHandlers) GetSensorByID(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := resourceID(r, "id")
 	if id == "" {
-		http.Error(w, "Missing sensor ID", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor ID")
 		return
 	}
 
-	sensor, err := h.SensorUseCase.GetSensorByID(domain.SensorID(id))
+	sensor, err := h.SensorUseCase.ForTenant(requestScope(r)).GetSensorByID(domain.SensorID(id))
 	if err != nil {
-		http.Error(w, "Sensor not found", http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setVersionETag(w, sensor.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(sensor); err != nil {
-		http.Error(w, "Failed to encode sensor", http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensor")
 		return
 	}
 }
 
-func (h *SensorHandlers) GetAllSensors(w http.ResponseWriter) {
-	sensors, err := h.SensorUseCase.GetAllSensors()
+// GetAllSensors lists every sensor, or only those matching the selector
+// and/or group query parameters. Sensors are matched against their own labels
+// on top of those of their device.
+func (h *SensorHandlers) GetAllSensors(w http.ResponseWriter, r *http.Request) {
+	var sensors []*domain.Sensor
+	var err error
+	if hasTarget(r) {
+		sensors, err = h.findTargetSensors(r)
+	} else {
+		sensors, err = h.SensorUseCase.ForTenant(requestScope(r)).GetAllSensors()
+	}
 	if err != nil {
-		http.Error(w, "Failed to retrieve sensors", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(sensors); err != nil {
-		http.Error(w, "Failed to encode sensors", http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensors")
 		return
 	}
 }
 
+// UpdateSensorConfigById handles PUT /sensors/{id}/config. The sensor_id of
+// the config may be left out.
 func (h *SensorHandlers) UpdateSensorConfigById(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor ID")
 		return
 	}
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "Missing sensor ID", http.StatusBadRequest)
+	version, ok := requireIfMatch(w, r)
+	if !ok {
 		return
 	}
 
 	var req map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
 		return
 	}
 
 	sensorConfig, err := h.unmarshalConfig(req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid config: %v", err), http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("Invalid config: %v", err))
 		return
 	}
+	if sensorConfig.SensorID == "" {
+		sensorConfig.SensorID = domain.SensorID(id)
+	}
 
-	if err := h.SensorUseCase.UpdateSensorConfigById(domain.SensorID(id), sensorConfig); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update sensor config: %v", err), http.StatusInternalServerError)
+	sensor, err := h.SensorUseCase.ForTenant(requestScope(r)).ExpectVersion(version).UpdateSensorConfigById(domain.SensorID(id), sensorConfig)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
+	setVersionETag(w, sensor.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{"message": "Sensor config updated successfully"}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+		return
+	}
+}
+
+// UpdateSensorConfigs handles PUT /sensors?selector=...&group=..., applying
+// the config in the body to every matching sensor.
+func (h *SensorHandlers) UpdateSensorConfigs(w http.ResponseWriter, r *http.Request) {
+	sensors, err := h.findTargetSensors(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	// The versions take the place of If-Match, one per sensor.
+	rawVersions, ok := req["versions"]
+	if !ok {
+		writeProblem(w, http.StatusPreconditionRequired, codeIfMatchRequired, "versions required: send the version of every sensor being changed")
+		return
+	}
+	delete(req, "versions")
+
+	var versions map[domain.SensorID]int64
+	if err := remarshal(rawVersions, &versions); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("Invalid versions: %v", err))
+		return
+	}
+	for id, version := range versions {
+		if version <= 0 {
+			writeProblem(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("Invalid versions: %s must be at least 1", id))
+			return
+		}
+	}
+
+	sensorConfig, err := h.unmarshalConfig(req)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("Invalid config: %v", err))
+		return
+	}
+
+	writeGroupJSON(w, http.StatusOK, h.SensorUseCase.ForTenant(requestScope(r)).UpdateSensorConfigs(sensors, sensorConfig, versions))
+}
+
+func (h *SensorHandlers) findTargetSensors(r *http.Request) ([]*domain.Sensor, error) {
+	target, err := parseTarget(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.groupUseCase.ForTenant(requestScope(r)).Sensors(target)
+}
+
+// PatchSensor renames the sensor, replaces its labels and/or moves it to
+// another device. Either every change is made, as one new version of the
+// sensor, or none is.
+func (h *SensorHandlers) PatchSensor(w http.ResponseWriter, r *http.Request) {
+	id := domain.SensorID(resourceID(r, "id"))
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor ID")
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name     *string        `json:"name"`
+		DeviceID *string        `json:"device_id"`
+		Labels   *domain.Labels `json:"labels"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	var fields []domain.FieldError
+	if req.Name != nil && *req.Name == "" {
+		fields = append(fields, domain.FieldError{Field: "name", Reason: "must not be empty"})
+	}
+	if req.DeviceID != nil && *req.DeviceID == "" {
+		fields = append(fields, domain.FieldError{Field: "device_id", Reason: "must not be empty"})
+	}
+	if len(fields) > 0 {
+		writeError(w, domain.Invalid(domain.ErrInvalidSensor, fields...))
+		return
+	}
+
+	patch := application.SensorPatch{Name: req.Name, Labels: req.Labels}
+	if req.DeviceID != nil {
+		deviceID := domain.DeviceID(*req.DeviceID)
+		patch.DeviceID = &deviceID
+	}
+
+	sensor, err := h.SensorUseCase.ForTenant(requestScope(r)).ExpectVersion(version).PatchSensor(id, patch)
+	if err != nil {
+		writeReferenceError(w, err)
+		return
+	}
+
+	setVersionETag(w, sensor.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sensor); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensor")
+		return
+	}
+}
+
+// GetConfig handles GET /sensors/{id}/config. The ETag is the version of the
+// sensor, the one PUT /sensors/{id}/config must match.
+func (h *SensorHandlers) GetConfig(w http.ResponseWriter, r *http.Request) {
+	sensor, err := h.SensorUseCase.ForTenant(requestScope(r)).GetSensorByID(domain.SensorID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setVersionETag(w, sensor.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sensor.Config); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensor config")
+		return
+	}
+}
+
+// DeviceSensors handles GET /devices/{id}/sensors.
+func (h *SensorHandlers) DeviceSensors(w http.ResponseWriter, r *http.Request) {
+	sensors, err := h.SensorUseCase.ForTenant(requestScope(r)).GetSensorsByDevice(domain.DeviceID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sensors); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensors")
+		return
+	}
+}
+
+func (h *SensorHandlers) DeleteSensor(w http.ResponseWriter, r *http.Request) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor ID")
+		return
+	}
+
+	if err := h.SensorUseCase.ForTenant(requestScope(r)).DeleteSensor(domain.SensorID(id)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ConfigHistory handles GET /sensors/{id}/config/history, newest revision
+// first.
+func (h *SensorHandlers) ConfigHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	revisions, err := h.SensorUseCase.ForTenant(requestScope(r)).ListConfigRevisions(domain.SensorID(r.PathValue("id")), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode config history")
+		return
+	}
+}
+
+// ConfigDiff handles GET /sensors/{id}/config/diff?from=&to=, the fields
+// that changed from one revision to another.
+func (h *SensorHandlers) ConfigDiff(w http.ResponseWriter, r *http.Request) {
+	var revisions [2]int64
+	for i, name := range []string{"from", "to"} {
+		parsed, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+		if err != nil || parsed <= 0 {
+			writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "Invalid or missing '"+name+"' revision")
+			return
+		}
+		revisions[i] = parsed
+	}
+
+	diff, err := h.SensorUseCase.ForTenant(requestScope(r)).DiffConfigRevisions(domain.SensorID(r.PathValue("id")), revisions[0], revisions[1])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode config diff")
+		return
+	}
+}
+
+// RollbackConfig handles POST /sensors/{id}/config/rollback, applying the
+// config of {"revision": n} again as a new revision. If-Match is optional
+// here but honoured when sent.
+func (h *SensorHandlers) RollbackConfig(w http.ResponseWriter, r *http.Request) {
+	version, present, ok := ifMatchVersion(r)
+	if present && !ok {
+		writeError(w, domain.ErrSensorVersionConflict)
+		return
+	}
+
+	var req struct {
+		Revision int64 `json:"revision"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.Revision <= 0 {
+		writeProblem(w, http.StatusBadRequest, codeInvalidParameter, "revision must be positive")
+		return
+	}
+
+	sensor, err := h.SensorUseCase.ForTenant(requestScope(r)).ExpectVersion(version).RollbackSensorConfig(domain.SensorID(r.PathValue("id")), req.Revision)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setVersionETag(w, sensor.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sensor); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode sensor")
 		return
 	}
 }
 
 func (h *SensorHandlers) validateCreateRequest(req CreateSensorRequest) error {
-	if req.Name == "" || req.Type == "" || req.DeviceID == "" {
-		return fmt.Errorf("missing required fields: name, type, device_id")
+	var fields []domain.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"name", req.Name},
+		{"type", req.Type},
+		{"device_id", req.DeviceID},
+	} {
+		if field.value == "" {
+			fields = append(fields, domain.FieldError{Field: field.name, Reason: "must not be empty"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return domain.Invalid(domain.ErrInvalidSensor, fields...)
 	}
 	return nil
 }
@@ -193,3 +484,13 @@ func (h *SensorHandlers) unmarshalConfig(configMap map[string]interface{}) (doma
 	}
 	return sensorConfig, nil
 }
+
+// remarshal decodes a value already decoded into interface{} again, into v.
+func remarshal(value interface{}, v interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
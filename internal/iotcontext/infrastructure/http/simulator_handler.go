@@ -1,6 +1,7 @@
 package http
 
 import (
+	"encoding/json"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"net/http"
@@ -8,44 +9,96 @@ import (
 
 type SimulatorHandler struct {
 	simulatorUsecase application.SimulatorUseCase
+	groupUseCase     *application.GroupUseCase
 }
 
-func NewSimulatorHandler(simulatorUsecase application.SimulatorUseCase) *SimulatorHandler {
+func NewSimulatorHandler(simulatorUsecase application.SimulatorUseCase, groupUseCase *application.GroupUseCase) *SimulatorHandler {
 	return &SimulatorHandler{
 		simulatorUsecase: simulatorUsecase,
+		groupUseCase:     groupUseCase,
 	}
 }
 
+// ControlSensor handles POST /simulator/?sensor_id=&action=, the deprecated
+// alias of POST /sensors/{id}/simulation, and without sensor_id the bulk
+// form on a selector and/or group.
 func (h *SimulatorHandler) ControlSensor(r *http.Request, w http.ResponseWriter) error {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 		return nil
 	}
 
 	sensorID := r.URL.Query().Get("sensor_id")
 	action := r.URL.Query().Get("action")
+	if sensorID == "" && action != "" && hasTarget(r) {
+		return h.ControlSensors(r, w)
+	}
+
 	if sensorID == "" || action == "" {
-		http.Error(w, "Missing sensor_id or action parameter", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing sensor_id or action parameter")
 		return nil
 	}
 
-	if err := h.simulatorUsecase.ControlSensor(domain.SensorID(sensorID), action); err != nil {
-		switch err {
-		case domain.ErrSensorNotFound:
-			http.Error(w, "Sensor not found", http.StatusNotFound)
-		case domain.ErrInvalidAction:
-			http.Error(w, "Invalid action", http.StatusBadRequest)
-		default:
-			http.Error(w, "Failed to control sensor", http.StatusInternalServerError)
-		}
-		return err
+	Deprecated(func(w http.ResponseWriter, r *http.Request) {
+		h.control(w, r, domain.SensorID(sensorID), action)
+	}, "/sensors/{id}/simulation", "sensor_id")(w, r)
+	return nil
+}
+
+// Control handles POST /sensors/{id}/simulation with {"action": "start"},
+// "stop" or "inject_error".
+func (h *SimulatorHandler) Control(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action string `json:"action"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.Action == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing action")
+		return
+	}
+
+	h.control(w, r, domain.SensorID(r.PathValue("id")), req.Action)
+}
+
+func (h *SimulatorHandler) control(w http.ResponseWriter, r *http.Request, sensorID domain.SensorID, action string) {
+	if err := h.simulatorUsecase.ForTenant(requestScope(r)).ControlSensor(sensorID, action); err != nil {
+		writeError(w, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	_, err := w.Write([]byte("Sensor " + action + " command executed successfully"))
+	if _, err := w.Write([]byte("Sensor " + action + " command executed successfully")); err != nil {
+		return
+	}
+}
+
+// ControlSensors runs the action on every sensor matching the selector and/or
+// group query parameters and reports the outcome per sensor.
+func (h *SimulatorHandler) ControlSensors(r *http.Request, w http.ResponseWriter) error {
+	target, err := parseTarget(r)
 	if err != nil {
+		writeError(w, err)
 		return err
 	}
+
+	sensors, err := h.groupUseCase.ForTenant(requestScope(r)).Sensors(target)
+	if err != nil {
+		writeError(w, err)
+		return err
+	}
+
+	result, err := h.simulatorUsecase.ForTenant(requestScope(r)).ControlSensors(sensors, r.URL.Query().Get("action"))
+	if err != nil {
+		writeError(w, err)
+		return err
+	}
+
+	writeGroupJSON(w, http.StatusOK, result)
 	return nil
 }
 
@@ -57,6 +110,6 @@ func (h *SimulatorHandler) SimulatorsHandler(w http.ResponseWriter, r *http.Requ
 			return
 		}
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 	}
 }
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+)
+
+// HeaderTenant selects the tenant of a request that is not made by a device.
+const HeaderTenant = "X-Tenant-ID"
+
+type tenantScopeKey struct{}
+
+// TenantResolver works out the tenant every request acts for. A request
+// authenticated as a device acts for the tenant that owns the device, and one
+// made with an API key or a JWT for the tenant the credential is bound to;
+// neither may pick another one. Any other request names its tenant in
+// X-Tenant-ID and falls back to the default tenant. The scope also carries who
+// makes the request, for the audit log.
+type TenantResolver struct {
+	tenancy    *application.Tenancy
+	deviceRepo domain.DeviceRepository
+}
+
+func NewTenantResolver(tenancy *application.Tenancy, deviceRepo domain.DeviceRepository) *TenantResolver {
+	return &TenantResolver{
+		tenancy:    tenancy,
+		deviceRepo: deviceRepo,
+	}
+}
+
+// Resolve must run after the DeviceAuthenticator and the APIAuthenticator,
+// if any, so it sees who the request is made by.
+func (t *TenantResolver) Resolve(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := domain.DefaultTenant
+		if header := r.Header.Get(HeaderTenant); header != "" {
+			parsed, err := domain.ParseTenantID(header)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			tenant = parsed
+		}
+
+		if deviceID, ok := AuthenticatedDevice(r.Context()); ok {
+			device, err := t.deviceRepo.FindByID(deviceID)
+			if err != nil {
+				writeProblem(w, http.StatusUnauthorized, codeUnauthenticated, "Device authentication failed")
+				return
+			}
+
+			if r.Header.Get(HeaderTenant) != "" && tenant != device.TenantID {
+				writeProblem(w, http.StatusForbidden, codeForbidden, "Device is not allowed to act for another tenant")
+				return
+			}
+			tenant = device.TenantID
+		} else if principal, ok := RequestPrincipal(r.Context()); ok && principal.TenantID != "" {
+			if r.Header.Get(HeaderTenant) != "" && tenant != principal.TenantID {
+				writeProblem(w, http.StatusForbidden, codeForbidden, "Caller is not allowed to act for another tenant")
+				return
+			}
+			tenant = principal.TenantID
+		}
+
+		scope := t.tenancy.Scope(tenant).By(requestActor(r))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantScopeKey{}, scope)))
+	})
+}
+
+// Deployment scopes a request that acts for the whole deployment rather than
+// for a tenant. It must run after the APIAuthenticator.
+func (t *TenantResolver) Deployment(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := t.tenancy.Deployment().By(requestActor(r))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantScopeKey{}, scope)))
+	})
+}
+
+// RequestTenant returns the tenant scope of the request. Requests that did
+// not go through a TenantResolver get the zero scope, which sees every
+// tenant.
+func RequestTenant(ctx context.Context) application.TenantScope {
+	scope, _ := ctx.Value(tenantScopeKey{}).(application.TenantScope)
+	return scope
+}
+
+func requestScope(r *http.Request) application.TenantScope {
+	return RequestTenant(r.Context())
+}
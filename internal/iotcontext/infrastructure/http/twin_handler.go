@@ -0,0 +1,98 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/application"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"net/http"
+)
+
+type TwinHandler struct {
+	twinUseCase application.TwinUseCase
+}
+
+func NewTwinHandler(twinUseCase application.TwinUseCase) *TwinHandler {
+	return &TwinHandler{
+		twinUseCase: twinUseCase,
+	}
+}
+
+// twinPatchRequest carries a merge patch for one section of the twin. Version
+// is the section version the client last saw; omit it to overwrite blindly.
+type twinPatchRequest struct {
+	Version    *int64                `json:"version"`
+	Properties domain.TwinProperties `json:"properties"`
+}
+
+func (h *TwinHandler) TwinHandler(w http.ResponseWriter, r *http.Request) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	twin, err := h.twinUseCase.ForTenant(requestScope(r)).GetTwin(domain.DeviceID(id))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeTwin(w, twin)
+}
+
+func (h *TwinHandler) DesiredHandler(w http.ResponseWriter, r *http.Request) {
+	h.patch(w, r, h.twinUseCase.ForTenant(requestScope(r)).UpdateDesired)
+}
+
+func (h *TwinHandler) ReportedHandler(w http.ResponseWriter, r *http.Request) {
+	h.patch(w, r, h.twinUseCase.ForTenant(requestScope(r)).UpdateReported)
+}
+
+func (h *TwinHandler) patch(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(domain.DeviceID, domain.TwinProperties, int64) (*domain.DeviceTwin, error),
+) {
+	id := resourceID(r, "id")
+	if id == "" {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing device ID")
+		return
+	}
+
+	if !authorizeDevice(w, r, domain.DeviceID(id)) {
+		return
+	}
+
+	var req twinPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+		return
+	}
+
+	if req.Properties == nil {
+		writeProblem(w, http.StatusBadRequest, codeMissingParameter, "Missing properties")
+		return
+	}
+
+	version := domain.AnyVersion
+	if req.Version != nil {
+		version = *req.Version
+	}
+
+	twin, err := update(domain.DeviceID(id), req.Properties, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeTwin(w, twin)
+}
+
+func writeTwin(w http.ResponseWriter, twin *domain.DeviceTwin) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(twin); err != nil {
+		writeProblem(w, http.StatusInternalServerError, codeInternal, "Failed to encode twin")
+		return
+	}
+}
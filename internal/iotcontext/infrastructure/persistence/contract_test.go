@@ -0,0 +1,1806 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+// repositorySet groups the repositories of one storage backend. Every backend
+// runs the same contract so they stay interchangeable.
+type repositorySet struct {
+	devices     domain.DeviceRepository
+	sensors     domain.SensorRepository
+	readings    domain.SensorReadingRepository
+	rollups     domain.ReadingRollupRepository
+	retention   domain.SensorReadingRetentionRepository
+	twins       domain.TwinRepository
+	commands    domain.CommandRepository
+	firmwares   domain.FirmwareRepository
+	campaigns   domain.CampaignRepository
+	credentials domain.CredentialRepository
+	claimTokens domain.ClaimTokenRepository
+	groups      domain.GroupRepository
+	locations   domain.LocationRepository
+	apiKeys     domain.APIKeyRepository
+	audit       domain.AuditRepository
+	configs     domain.SensorConfigHistoryRepository
+	presence    domain.PresenceRepository
+}
+
+type repositoryFactory func(t *testing.T) repositorySet
+
+func runRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("DeviceRepository", func(t *testing.T) { runDeviceRepositoryContract(t, factory) })
+	t.Run("SensorRepository", func(t *testing.T) { runSensorRepositoryContract(t, factory) })
+	t.Run("SensorConfigHistoryRepository", func(t *testing.T) { runSensorConfigHistoryRepositoryContract(t, factory) })
+	t.Run("SensorReadingRepository", func(t *testing.T) { runSensorReadingRepositoryContract(t, factory) })
+	t.Run("ReadingRollupRepository", func(t *testing.T) { runReadingRollupRepositoryContract(t, factory) })
+	t.Run("SensorReadingRetentionRepository", func(t *testing.T) { runRetentionRepositoryContract(t, factory) })
+	t.Run("TwinRepository", func(t *testing.T) { runTwinRepositoryContract(t, factory) })
+	t.Run("CommandRepository", func(t *testing.T) { runCommandRepositoryContract(t, factory) })
+	t.Run("FirmwareRepository", func(t *testing.T) { runFirmwareRepositoryContract(t, factory) })
+	t.Run("CampaignRepository", func(t *testing.T) { runCampaignRepositoryContract(t, factory) })
+	t.Run("CredentialRepository", func(t *testing.T) { runCredentialRepositoryContract(t, factory) })
+	t.Run("ClaimTokenRepository", func(t *testing.T) { runClaimTokenRepositoryContract(t, factory) })
+	t.Run("GroupRepository", func(t *testing.T) { runGroupRepositoryContract(t, factory) })
+	t.Run("LocationRepository", func(t *testing.T) { runLocationRepositoryContract(t, factory) })
+	t.Run("APIKeyRepository", func(t *testing.T) { runAPIKeyRepositoryContract(t, factory) })
+	t.Run("AuditRepository", func(t *testing.T) { runAuditRepositoryContract(t, factory) })
+	t.Run("PresenceRepository", func(t *testing.T) { runPresenceRepositoryContract(t, factory) })
+	t.Run("Tenants", func(t *testing.T) { runTenantContract(t, factory) })
+}
+
+func runDeviceRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save and find by id", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		found, err := repos.devices.FindByID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if found.ID != device.ID || found.Name != device.Name || found.Type != device.Type || found.Status != device.Status {
+			t.Errorf("expected %+v, got %+v", device, found)
+		}
+		assertSameInstant(t, device.CreatedAt, found.CreatedAt)
+	})
+
+	t.Run("labels round trip", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		found, err := repos.devices.FindByID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Labels == nil || len(found.Labels) != 0 {
+			t.Errorf("expected empty labels, got %#v", found.Labels)
+		}
+
+		if err := device.Relabel(domain.Labels{"site": "madrid", "floor": "1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.devices.Update(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err = repos.devices.FindByID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(found.Labels) != 2 || found.Labels["site"] != "madrid" || found.Labels["floor"] != "1" {
+			t.Errorf("labels were not preserved: %v", found.Labels)
+		}
+	})
+
+	t.Run("find by id not found", func(t *testing.T) {
+		repos := factory(t)
+
+		if _, err := repos.devices.FindByID(domain.DeviceID(uuid.NewString())); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("save duplicate", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		if err := repos.devices.Save(device); !errors.Is(err, domain.ErrDeviceAlreadyExists) {
+			t.Errorf("expected ErrDeviceAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("find all ordered by creation", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		second := newContractDevice(t, repos, "Second", now)
+		first := newContractDevice(t, repos, "First", now.Add(-time.Hour))
+
+		devices, err := repos.devices.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(devices) != 2 || devices[0].ID != first.ID || devices[1].ID != second.ID {
+			t.Errorf("unexpected devices order %+v", devices)
+		}
+	})
+
+	t.Run("find by ids leaves out the missing ones", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		first := newContractDevice(t, repos, "First", now)
+		second := newContractDevice(t, repos, "Second", now)
+		newContractDevice(t, repos, "Other", now)
+
+		devices, err := repos.devices.FindByIDs([]domain.DeviceID{first.ID, second.ID, domain.DeviceID(uuid.NewString())})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found := map[domain.DeviceID]bool{}
+		for _, device := range devices {
+			found[device.ID] = true
+		}
+		if len(devices) != 2 || !found[first.ID] || !found[second.ID] {
+			t.Errorf("unexpected devices %+v", devices)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		device.Name = "Renamed"
+		if err := repos.devices.Update(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, _ := repos.devices.FindByID(device.ID)
+		if found.Name != "Renamed" {
+			t.Errorf("expected updated name, got %q", found.Name)
+		}
+	})
+
+	t.Run("update with a stale version conflicts", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		stale, _ := repos.devices.FindByID(device.ID)
+
+		device.Name = "Renamed"
+		if err := repos.devices.Update(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if device.Version != 2 {
+			t.Errorf("expected version 2, got %d", device.Version)
+		}
+
+		stale.Name = "Lost"
+		if err := repos.devices.Update(&stale); !errors.Is(err, domain.ErrDeviceVersionConflict) {
+			t.Errorf("expected ErrDeviceVersionConflict, got %v", err)
+		}
+
+		found, _ := repos.devices.FindByID(device.ID)
+		if found.Name != "Renamed" || found.Version != 2 {
+			t.Errorf("expected Renamed at version 2, got %q at %d", found.Name, found.Version)
+		}
+	})
+
+	t.Run("update not found", func(t *testing.T) {
+		repos := factory(t)
+		device, _ := domain.NewDevice(domain.DeviceID(uuid.NewString()), "Ghost", "gateway")
+
+		if err := repos.devices.Update(device); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("delete hides the device and keeps its id reserved", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		kept := newContractDevice(t, repos, "Kept", time.Now())
+
+		if err := repos.devices.Delete(device.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repos.devices.FindByID(device.ID); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+
+		devices, _ := repos.devices.FindAll()
+		if len(devices) != 1 || devices[0].ID != kept.ID {
+			t.Errorf("expected only the kept device, got %+v", devices)
+		}
+
+		if err := repos.devices.Update(device); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound on update, got %v", err)
+		}
+
+		if err := repos.devices.Delete(device.ID); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound on second delete, got %v", err)
+		}
+
+		if err := repos.devices.Save(device); !errors.Is(err, domain.ErrDeviceAlreadyExists) {
+			t.Errorf("expected ErrDeviceAlreadyExists, got %v", err)
+		}
+	})
+}
+
+func runSensorRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save and find by id", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		sensor := newContractSensor(t, repos, device.ID, domain.Temperature, time.Now())
+
+		found, err := repos.sensors.FindByID(sensor.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if found.DeviceID != device.ID || found.Name != sensor.Name || found.Type != domain.Temperature {
+			t.Errorf("expected %+v, got %+v", sensor, found)
+		}
+
+		if found.Config.SamplingRateMs != 1000 || found.Config.Thresholds.Max == nil || *found.Config.Thresholds.Max != 30 {
+			t.Errorf("config was not preserved: %+v", found.Config)
+		}
+	})
+
+	t.Run("labels round trip", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		if err := sensor.Relabel(domain.Labels{"zone": "north"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.sensors.Update(sensor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.sensors.FindByID(sensor.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(found.Labels) != 1 || found.Labels["zone"] != "north" {
+			t.Errorf("labels were not preserved: %v", found.Labels)
+		}
+	})
+
+	t.Run("find by id not found", func(t *testing.T) {
+		repos := factory(t)
+
+		if _, err := repos.sensors.FindByID(domain.SensorID(uuid.NewString())); !errors.Is(err, domain.ErrSensorNotFound) {
+			t.Errorf("expected ErrSensorNotFound, got %v", err)
+		}
+	})
+
+	t.Run("save duplicate", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		sensor := newContractSensor(t, repos, device.ID, domain.Temperature, time.Now())
+
+		if err := repos.sensors.Save(sensor); !errors.Is(err, domain.ErrSensorAlreadyExists) {
+			t.Errorf("expected ErrSensorAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("find all ordered by creation", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		device := newContractDevice(t, repos, "Gateway", now)
+		second := newContractSensor(t, repos, device.ID, domain.Humidity, now)
+		first := newContractSensor(t, repos, device.ID, domain.Temperature, now.Add(-time.Hour))
+
+		sensors, err := repos.sensors.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(sensors) != 2 || sensors[0].ID != first.ID || sensors[1].ID != second.ID {
+			t.Errorf("unexpected sensors order")
+		}
+	})
+
+	t.Run("update config", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		sensor := newContractSensor(t, repos, device.ID, domain.Temperature, time.Now())
+
+		config := sensor.Config
+		config.SamplingRateMs = 5000
+		if err := sensor.UpdateConfig(config); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := repos.sensors.Update(sensor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, _ := repos.sensors.FindByID(sensor.ID)
+		if found.Config.SamplingRateMs != 5000 {
+			t.Errorf("expected sampling rate 5000, got %d", found.Config.SamplingRateMs)
+		}
+	})
+
+	t.Run("returned sensors are detached copies", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		sensor := newContractSensor(t, repos, device.ID, domain.Temperature, time.Now())
+
+		found, _ := repos.sensors.FindByID(sensor.ID)
+		found.Name = "Changed without update"
+
+		again, _ := repos.sensors.FindByID(sensor.ID)
+		if again.Name != sensor.Name {
+			t.Errorf("expected stored name %q, got %q", sensor.Name, again.Name)
+		}
+	})
+
+	t.Run("find by device id", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		device := newContractDevice(t, repos, "Gateway", now)
+		other := newContractDevice(t, repos, "Other", now)
+		second := newContractSensor(t, repos, device.ID, domain.Humidity, now)
+		first := newContractSensor(t, repos, device.ID, domain.Temperature, now.Add(-time.Minute))
+		newContractSensor(t, repos, other.ID, domain.Temperature, now)
+
+		sensors, err := repos.sensors.FindByDeviceID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(sensors) != 2 || sensors[0].ID != first.ID || sensors[1].ID != second.ID {
+			t.Errorf("unexpected sensors %+v", sensors)
+		}
+	})
+
+	t.Run("find by device ids", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		device := newContractDevice(t, repos, "Gateway", now)
+		other := newContractDevice(t, repos, "Other", now)
+		ignored := newContractDevice(t, repos, "Ignored", now)
+		newContractSensor(t, repos, device.ID, domain.Humidity, now)
+		newContractSensor(t, repos, device.ID, domain.Temperature, now)
+		newContractSensor(t, repos, other.ID, domain.Temperature, now)
+		newContractSensor(t, repos, ignored.ID, domain.Temperature, now)
+
+		sensors, err := repos.sensors.FindByDeviceIDs([]domain.DeviceID{device.ID, other.ID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		perDevice := map[domain.DeviceID]int{}
+		for _, sensor := range sensors {
+			perDevice[sensor.DeviceID]++
+		}
+		if len(sensors) != 3 || perDevice[device.ID] != 2 || perDevice[other.ID] != 1 {
+			t.Errorf("unexpected sensors %+v", sensors)
+		}
+	})
+
+	t.Run("delete hides the sensor", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		if err := repos.sensors.Delete(sensor.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repos.sensors.FindByID(sensor.ID); !errors.Is(err, domain.ErrSensorNotFound) {
+			t.Errorf("expected ErrSensorNotFound, got %v", err)
+		}
+
+		if sensors, _ := repos.sensors.FindByDeviceID(sensor.DeviceID); len(sensors) != 0 {
+			t.Errorf("expected no sensors, got %d", len(sensors))
+		}
+
+		if err := repos.sensors.Delete(sensor.ID); !errors.Is(err, domain.ErrSensorNotFound) {
+			t.Errorf("expected ErrSensorNotFound on second delete, got %v", err)
+		}
+	})
+
+	t.Run("update with a stale version conflicts", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		sensor, _ := domain.NewSensor(domain.SensorID(uuid.NewString()), device.ID, "Temp", domain.Generic, domain.SensorConfig{SamplingRateMs: 1000})
+		if err := repos.sensors.Save(sensor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stale, _ := repos.sensors.FindByID(sensor.ID)
+
+		sensor.Name = "Renamed"
+		if err := repos.sensors.Update(sensor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sensor.Version != 2 {
+			t.Errorf("expected version 2, got %d", sensor.Version)
+		}
+
+		stale.Name = "Lost"
+		if err := repos.sensors.Update(stale); !errors.Is(err, domain.ErrSensorVersionConflict) {
+			t.Errorf("expected ErrSensorVersionConflict, got %v", err)
+		}
+
+		found, _ := repos.sensors.FindByID(sensor.ID)
+		if found.Name != "Renamed" || found.Version != 2 {
+			t.Errorf("expected Renamed at version 2, got %q at %d", found.Name, found.Version)
+		}
+	})
+
+	t.Run("update not found", func(t *testing.T) {
+		repos := factory(t)
+		sensor, _ := domain.NewSensor(domain.SensorID(uuid.NewString()), domain.DeviceID(uuid.NewString()), "Ghost", domain.Generic, domain.SensorConfig{SamplingRateMs: 1000})
+
+		if err := repos.sensors.Update(sensor); !errors.Is(err, domain.ErrSensorNotFound) {
+			t.Errorf("expected ErrSensorNotFound, got %v", err)
+		}
+	})
+}
+
+func runSensorConfigHistoryRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("append and find newest first", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		for _, rate := range []int{1000, 2000, 3000} {
+			config := sensor.Config
+			config.SamplingRateMs = rate
+			if rate > 1000 {
+				if err := sensor.UpdateConfig(config); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			revision, err := domain.NewSensorConfigRevision(sensor.Config, "alice", 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := repos.configs.Append(revision); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		revisions, err := repos.configs.FindBySensorID(sensor.ID, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(revisions) != 2 || revisions[0].Revision != 3 || revisions[1].Revision != 2 {
+			t.Fatalf("expected revisions 3 and 2, got %+v", revisions)
+		}
+
+		if revisions[0].Config.SamplingRateMs != 3000 || revisions[0].ChangedBy != "alice" {
+			t.Errorf("unexpected revision %+v", revisions[0])
+		}
+
+		found, err := repos.configs.FindRevision(sensor.ID, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if found.Config.SamplingRateMs != 1000 || found.Config.Revision != 1 {
+			t.Errorf("unexpected revision %+v", found)
+		}
+	})
+
+	t.Run("duplicate revision is a conflict", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		revision, _ := domain.NewSensorConfigRevision(sensor.Config, "alice", 0)
+		if err := repos.configs.Append(revision); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := repos.configs.Append(revision); !errors.Is(err, domain.ErrSensorConfigConflict) {
+			t.Errorf("expected ErrSensorConfigConflict, got %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		if _, err := repos.configs.FindRevision(sensor.ID, 1); !errors.Is(err, domain.ErrSensorConfigRevisionNotFound) {
+			t.Errorf("expected ErrSensorConfigRevisionNotFound, got %v", err)
+		}
+
+		revisions, err := repos.configs.FindBySensorID(sensor.ID, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(revisions) != 0 {
+			t.Errorf("expected no revisions, got %+v", revisions)
+		}
+	})
+}
+
+func runSensorReadingRepositoryContract(t *testing.T, factory repositoryFactory) {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("save assigns an id", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		reading := domain.NewSensorReading(sensor.ID, sensor.DeviceID, sensor.Type, 21.5, "°C", start)
+		if err := repos.readings.Save(&reading); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if reading.ID == "" {
+			t.Error("expected reading id to be assigned")
+		}
+	})
+
+	t.Run("find by sensor id returns newest first with limit", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+		other := newContractSensorWithDevice(t, repos)
+
+		for i := 0; i < 5; i++ {
+			saveContractReading(t, repos, sensor, float64(i), start.Add(time.Duration(i)*time.Minute))
+		}
+		saveContractReading(t, repos, other, 100, start)
+
+		readings, err := repos.readings.FindBySensorID(sensor.ID, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(readings) != 3 {
+			t.Fatalf("expected 3 readings, got %d", len(readings))
+		}
+
+		for i, expected := range []float64{4, 3, 2} {
+			if readings[i].Value != expected || readings[i].SensorID != sensor.ID {
+				t.Errorf("expected value %v at %d, got %+v", expected, i, readings[i])
+			}
+		}
+
+		all, _ := repos.readings.FindBySensorID(sensor.ID, 0)
+		if len(all) != 5 {
+			t.Errorf("expected no limit to return 5 readings, got %d", len(all))
+		}
+	})
+
+	t.Run("find by sensor id between is half open and ascending", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+
+		for i := 0; i < 5; i++ {
+			saveContractReading(t, repos, sensor, float64(i), start.Add(time.Duration(i)*time.Minute))
+		}
+
+		readings, err := repos.readings.FindBySensorIDBetween(sensor.ID, start.Add(time.Minute), start.Add(4*time.Minute), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(readings) != 3 || readings[0].Value != 1 || readings[2].Value != 3 {
+			t.Errorf("unexpected readings %+v", readings)
+		}
+		assertSameInstant(t, start.Add(time.Minute), readings[0].Timestamp)
+
+		limited, _ := repos.readings.FindBySensorIDBetween(sensor.ID, start, start.Add(time.Hour), 2)
+		if len(limited) != 2 || limited[0].Value != 0 {
+			t.Errorf("expected the two oldest readings, got %+v", limited)
+		}
+	})
+
+	t.Run("find latest by sensor ids", func(t *testing.T) {
+		repos := factory(t)
+		sensor := newContractSensorWithDevice(t, repos)
+		other := newContractSensorWithDevice(t, repos)
+		silent := newContractSensorWithDevice(t, repos)
+
+		for i := 0; i < 3; i++ {
+			saveContractReading(t, repos, sensor, float64(i), start.Add(time.Duration(i)*time.Minute))
+		}
+		saveContractReading(t, repos, other, 100, start)
+
+		readings, err := repos.readings.FindLatestBySensorIDs([]domain.SensorID{sensor.ID, other.ID, silent.ID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		latest := map[domain.SensorID]float64{}
+		for _, reading := range readings {
+			latest[reading.SensorID] = reading.Value
+		}
+		if len(readings) != 2 || latest[sensor.ID] != 2 || latest[other.ID] != 100 {
+			t.Errorf("unexpected readings %+v", readings)
+		}
+
+		none, err := repos.readings.FindLatestBySensorIDs(nil)
+		if err != nil || len(none) != 0 {
+			t.Errorf("expected no readings for no sensors, got %+v, %v", none, err)
+		}
+	})
+
+	t.Run("unknown sensor has no readings", func(t *testing.T) {
+		repos := factory(t)
+
+		readings, err := repos.readings.FindBySensorID(domain.SensorID(uuid.NewString()), 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(readings) != 0 {
+			t.Errorf("expected no readings, got %d", len(readings))
+		}
+	})
+}
+
+func runReadingRollupRepositoryContract(t *testing.T, factory repositoryFactory) {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	repos := factory(t)
+	sensor := newContractSensorWithDevice(t, repos)
+
+	values := []struct {
+		value  float64
+		offset time.Duration
+	}{
+		{20, 10 * time.Second},
+		{30, 50 * time.Second},
+		{10, 20 * time.Second},
+		{40, 70 * time.Second},
+	}
+
+	for _, v := range values {
+		reading := domain.NewSensorReading(sensor.ID, sensor.DeviceID, sensor.Type, v.value, "°C", start.Add(v.offset))
+		if err := repos.rollups.Apply(reading); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	minutes, err := repos.rollups.FindAggregates(sensor.ID, domain.ResolutionMinute, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(minutes) != 2 {
+		t.Fatalf("expected 2 minute buckets, got %d", len(minutes))
+	}
+
+	first := minutes[0]
+	if first.Min != 10 || first.Max != 30 || first.Count != 3 || first.Avg() != 20 || first.Last != 30 {
+		t.Errorf("unexpected first minute aggregate %+v", first)
+	}
+	assertSameInstant(t, start, first.Bucket)
+
+	hours, _ := repos.rollups.FindAggregates(sensor.ID, domain.ResolutionHour, start, start.Add(time.Hour))
+	if len(hours) != 1 || hours[0].Count != 4 || hours[0].Last != 40 || hours[0].Max != 40 {
+		t.Errorf("unexpected hour aggregates %+v", hours)
+	}
+
+	later, _ := repos.rollups.FindAggregates(sensor.ID, domain.ResolutionMinute, start.Add(time.Minute), start.Add(time.Hour))
+	if len(later) != 1 || later[0].Count != 1 {
+		t.Errorf("expected only the second minute bucket, got %+v", later)
+	}
+}
+
+func runRetentionRepositoryContract(t *testing.T, factory repositoryFactory) {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	repos := factory(t)
+	temperature := newContractSensorWithDevice(t, repos)
+	humidity := newContractSensor(t, repos, temperature.DeviceID, domain.Humidity, time.Now())
+
+	for i := 0; i < 3; i++ {
+		saveContractReading(t, repos, temperature, float64(i), start.Add(time.Duration(i)*time.Hour))
+		saveContractReading(t, repos, humidity, float64(i), start.Add(time.Duration(i)*time.Hour))
+	}
+
+	deleted, err := repos.retention.DeleteReadingsBefore(
+		domain.RetentionScope{SensorType: domain.Temperature},
+		start.Add(90*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("expected 2 deleted readings, got %d", deleted)
+	}
+
+	remaining, _ := repos.readings.FindBySensorID(temperature.ID, 0)
+	if len(remaining) != 1 {
+		t.Errorf("expected 1 temperature reading left, got %d", len(remaining))
+	}
+
+	deleted, _ = repos.retention.DeleteReadingsBefore(
+		domain.RetentionScope{ExcludeTypes: []domain.SensorType{domain.Humidity}},
+		start.Add(24*time.Hour),
+	)
+	if deleted != 1 {
+		t.Errorf("expected excluded humidity readings to be kept, deleted %d", deleted)
+	}
+
+	untouched, _ := repos.readings.FindBySensorID(humidity.ID, 0)
+	if len(untouched) != 3 {
+		t.Errorf("expected 3 humidity readings left, got %d", len(untouched))
+	}
+}
+
+func runTwinRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		if _, err := repos.twins.FindByDeviceID(device.ID); !errors.Is(err, domain.ErrTwinNotFound) {
+			t.Errorf("expected ErrTwinNotFound, got %v", err)
+		}
+	})
+
+	t.Run("save and reload", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		twin := domain.NewDeviceTwin(device.ID)
+		_ = twin.UpdateDesired(domain.TwinProperties{"sampling_rate_ms": 500, "thresholds": map[string]interface{}{"max": 30}}, 0)
+		if err := repos.twins.Save(twin, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		previous := twin.Version
+		_ = twin.UpdateReported(domain.TwinProperties{"sampling_rate_ms": 500}, 0)
+		if err := repos.twins.Save(twin, previous); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.twins.FindByDeviceID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if found.Version != 2 || found.DesiredVersion != 1 || found.ReportedVersion != 1 {
+			t.Errorf("unexpected versions %+v", found)
+		}
+
+		delta := found.Delta()
+		if len(delta) != 1 || delta["thresholds"] == nil {
+			t.Errorf("expected only thresholds to be pending, got %v", delta)
+		}
+	})
+
+	t.Run("stale version is rejected", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		twin := domain.NewDeviceTwin(device.ID)
+		_ = twin.UpdateDesired(domain.TwinProperties{"mode": "eco"}, 0)
+		if err := repos.twins.Save(twin, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := repos.twins.Save(twin, 0); !errors.Is(err, domain.ErrTwinVersionConflict) {
+			t.Errorf("expected ErrTwinVersionConflict on a second insert, got %v", err)
+		}
+
+		_ = twin.UpdateDesired(domain.TwinProperties{"mode": "boost"}, 1)
+		if err := repos.twins.Save(twin, 7); !errors.Is(err, domain.ErrTwinVersionConflict) {
+			t.Errorf("expected ErrTwinVersionConflict, got %v", err)
+		}
+
+		found, _ := repos.twins.FindByDeviceID(device.ID)
+		if found.Desired["mode"] != "eco" {
+			t.Errorf("expected the stale write to be discarded, got %v", found.Desired)
+		}
+	})
+}
+
+func runCommandRepositoryContract(t *testing.T, factory repositoryFactory) {
+	newCommand := func(t *testing.T, repos repositorySet, deviceID domain.DeviceID, createdAt time.Time) *domain.Command {
+		t.Helper()
+
+		params := map[string]interface{}{"sampling_rate_ms": 250}
+		command, err := domain.NewCommand(domain.CommandID(uuid.NewString()), deviceID, domain.CommandSetSamplingRate, params, time.Minute, createdAt)
+		if err != nil {
+			t.Fatalf("failed to build command: %v", err)
+		}
+
+		if err := repos.commands.Save(command); err != nil {
+			t.Fatalf("failed to save command: %v", err)
+		}
+
+		return command
+	}
+
+	t.Run("save, update and find by id", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		command := newCommand(t, repos, device.ID, time.Now())
+
+		if err := repos.commands.Save(command); !errors.Is(err, domain.ErrCommandAlreadyExists) {
+			t.Errorf("expected ErrCommandAlreadyExists, got %v", err)
+		}
+
+		_ = command.Complete(true, map[string]interface{}{"sensors": 2}, "", time.Now())
+		if err := repos.commands.Update(command); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.commands.FindByID(command.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if found.Status != domain.CommandSucceeded || found.Params["sampling_rate_ms"] != float64(250) || found.Result["sensors"] != float64(2) {
+			t.Errorf("unexpected command %+v", found)
+		}
+		if found.CompletedAt == nil || found.DeliveredAt == nil {
+			t.Errorf("expected completion timestamps, got %+v", found)
+		}
+		assertSameInstant(t, command.ExpiresAt, found.ExpiresAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+
+		if _, err := repos.commands.FindByID(domain.CommandID(uuid.NewString())); !errors.Is(err, domain.ErrCommandNotFound) {
+			t.Errorf("expected ErrCommandNotFound, got %v", err)
+		}
+
+		command, _ := domain.NewCommand(domain.CommandID(uuid.NewString()), "device", domain.CommandReboot, nil, time.Minute, time.Now())
+		if err := repos.commands.Update(command); !errors.Is(err, domain.ErrCommandNotFound) {
+			t.Errorf("expected ErrCommandNotFound, got %v", err)
+		}
+	})
+
+	t.Run("find by device newest first and open oldest first", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		other := newContractDevice(t, repos, "Other", time.Now())
+		now := time.Now()
+
+		oldest := newCommand(t, repos, device.ID, now.Add(-2*time.Minute))
+		finished := newCommand(t, repos, device.ID, now.Add(-time.Minute))
+		newest := newCommand(t, repos, device.ID, now)
+		foreign := newCommand(t, repos, other.ID, now.Add(-3*time.Minute))
+
+		_ = finished.Expire(now)
+		if err := repos.commands.Update(finished); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		commands, err := repos.commands.FindByDeviceID(device.ID, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(commands) != 2 || commands[0].ID != newest.ID || commands[1].ID != finished.ID {
+			t.Errorf("unexpected device commands %+v", commands)
+		}
+
+		open, err := repos.commands.FindOpen()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(open) != 3 || open[0].ID != foreign.ID || open[1].ID != oldest.ID || open[2].ID != newest.ID {
+			t.Errorf("unexpected open commands %+v", open)
+		}
+	})
+}
+
+func runFirmwareRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save, find and list newest first", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		older := newContractFirmware(t, repos, "1.0.0", now.Add(-time.Hour))
+		newer := newContractFirmware(t, repos, "1.1.0", now)
+
+		found, err := repos.firmwares.FindByID(older.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Version != older.Version || found.DeviceType != older.DeviceType || found.Checksum != older.Checksum || found.Size != older.Size {
+			t.Errorf("expected %+v, got %+v", older, found)
+		}
+
+		firmwares, err := repos.firmwares.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(firmwares) != 2 || firmwares[0].ID != newer.ID || firmwares[1].ID != older.ID {
+			t.Errorf("unexpected firmwares %+v", firmwares)
+		}
+	})
+
+	t.Run("duplicate version for the device type", func(t *testing.T) {
+		repos := factory(t)
+		newContractFirmware(t, repos, "1.0.0", time.Now())
+
+		duplicate, _ := domain.NewFirmware(domain.FirmwareID(uuid.NewString()), "1.0.0", "gateway", contractChecksum, 512, time.Now())
+		if err := repos.firmwares.Save(duplicate); !errors.Is(err, domain.ErrFirmwareAlreadyExists) {
+			t.Errorf("expected ErrFirmwareAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+
+		if _, err := repos.firmwares.FindByID(domain.FirmwareID(uuid.NewString())); !errors.Is(err, domain.ErrFirmwareNotFound) {
+			t.Errorf("expected ErrFirmwareNotFound, got %v", err)
+		}
+	})
+}
+
+func runCampaignRepositoryContract(t *testing.T, factory repositoryFactory) {
+	newCampaign := func(t *testing.T, repos repositorySet, firmware *domain.Firmware, createdAt time.Time, devices ...*domain.Device) (*domain.Campaign, []*domain.DeviceUpdate) {
+		t.Helper()
+
+		campaign, err := domain.NewCampaign(domain.CampaignID(uuid.NewString()), firmware, []int{50, 100}, 0.2, createdAt)
+		if err != nil {
+			t.Fatalf("failed to build campaign: %v", err)
+		}
+
+		updates := make([]*domain.DeviceUpdate, 0, len(devices))
+		for i, device := range devices {
+			updates = append(updates, domain.NewDeviceUpdate(campaign.ID, device.ID, i, createdAt))
+		}
+
+		if err := repos.campaigns.Save(campaign, updates); err != nil {
+			t.Fatalf("failed to save campaign: %v", err)
+		}
+
+		return campaign, updates
+	}
+
+	t.Run("save, update and find by id", func(t *testing.T) {
+		repos := factory(t)
+		firmware := newContractFirmware(t, repos, "1.0.0", time.Now())
+		campaign, _ := newCampaign(t, repos, firmware, time.Now())
+
+		_ = campaign.NextStage(time.Now())
+		_ = campaign.Halt("too many failures", time.Now())
+		if err := repos.campaigns.Update(campaign); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.campaigns.FindByID(campaign.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Status != domain.CampaignHalted || found.Stage != 1 || found.Reason != "too many failures" ||
+			len(found.Stages) != 2 || found.Stages[1] != 100 || found.FailureThreshold != 0.2 || found.DeviceType != "gateway" {
+			t.Errorf("unexpected campaign %+v", found)
+		}
+	})
+
+	t.Run("device updates in rollout order", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		first := newContractDevice(t, repos, "First", now)
+		second := newContractDevice(t, repos, "Second", now)
+		firmware := newContractFirmware(t, repos, "1.0.0", now)
+		campaign, updates := newCampaign(t, repos, firmware, now, second, first)
+
+		_ = updates[1].Dispatch("command-1", now)
+		_ = updates[1].Report(domain.DeviceUpdateFailed, 40, "checksum mismatch", now)
+		if err := repos.campaigns.UpdateDeviceUpdate(updates[1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.campaigns.FindDeviceUpdates(campaign.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(found) != 2 || found[0].DeviceID != second.ID || found[1].DeviceID != first.ID {
+			t.Fatalf("unexpected device updates %+v", found)
+		}
+
+		update, err := repos.campaigns.FindDeviceUpdate(campaign.ID, first.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if update.Status != domain.DeviceUpdateFailed || update.Progress != 40 || update.Error != "checksum mismatch" ||
+			update.CommandID != "command-1" || update.StartedAt == nil || update.CompletedAt == nil {
+			t.Errorf("unexpected device update %+v", update)
+		}
+	})
+
+	t.Run("active campaigns oldest first", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		firmware := newContractFirmware(t, repos, "1.0.0", now)
+		newest, _ := newCampaign(t, repos, firmware, now)
+		paused, _ := newCampaign(t, repos, firmware, now.Add(-time.Hour))
+		aborted, _ := newCampaign(t, repos, firmware, now.Add(-2*time.Hour))
+
+		_ = paused.Pause(now)
+		_ = aborted.Abort(now)
+		for _, campaign := range []*domain.Campaign{paused, aborted} {
+			if err := repos.campaigns.Update(campaign); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		active, err := repos.campaigns.FindActive()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(active) != 2 || active[0].ID != paused.ID || active[1].ID != newest.ID {
+			t.Errorf("unexpected active campaigns %+v", active)
+		}
+
+		all, err := repos.campaigns.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(all) != 3 || all[0].ID != newest.ID || all[2].ID != aborted.ID {
+			t.Errorf("unexpected campaigns %+v", all)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+
+		if _, err := repos.campaigns.FindByID(domain.CampaignID(uuid.NewString())); !errors.Is(err, domain.ErrCampaignNotFound) {
+			t.Errorf("expected ErrCampaignNotFound, got %v", err)
+		}
+
+		if _, err := repos.campaigns.FindDeviceUpdate(domain.CampaignID(uuid.NewString()), domain.DeviceID(uuid.NewString())); !errors.Is(err, domain.ErrDeviceUpdateNotFound) {
+			t.Errorf("expected ErrDeviceUpdateNotFound, got %v", err)
+		}
+
+		update := domain.NewDeviceUpdate(domain.CampaignID(uuid.NewString()), domain.DeviceID(uuid.NewString()), 0, time.Now())
+		if err := repos.campaigns.UpdateDeviceUpdate(update); !errors.Is(err, domain.ErrDeviceUpdateNotFound) {
+			t.Errorf("expected ErrDeviceUpdateNotFound, got %v", err)
+		}
+	})
+}
+
+const contractChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func runCredentialRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save, find and list by device", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		now := time.Now()
+
+		secret, _ := domain.NewHMACCredential(domain.CredentialID(uuid.NewString()), device.ID, "s3cret", now.Add(-time.Hour))
+		certificate, _ := domain.NewX509Credential(domain.CredentialID(uuid.NewString()), device.ID, contractChecksum, now.Add(time.Hour), now)
+		for _, credential := range []*domain.Credential{certificate, secret} {
+			if err := repos.credentials.Save(credential); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		found, err := repos.credentials.FindByID(secret.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.DeviceID != device.ID || found.Type != domain.CredentialHMAC || found.Secret != "s3cret" || found.ExpiresAt != nil {
+			t.Errorf("expected %+v, got %+v", secret, found)
+		}
+
+		byFingerprint, err := repos.credentials.FindByFingerprint(contractChecksum)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if byFingerprint.ID != certificate.ID || byFingerprint.ExpiresAt == nil {
+			t.Errorf("expected %+v, got %+v", certificate, byFingerprint)
+		}
+		assertSameInstant(t, *certificate.ExpiresAt, *byFingerprint.ExpiresAt)
+
+		credentials, err := repos.credentials.FindByDeviceID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(credentials) != 2 || credentials[0].ID != secret.ID || credentials[1].ID != certificate.ID {
+			t.Errorf("unexpected credentials %+v", credentials)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		credential, _ := domain.NewHMACCredential(domain.CredentialID(uuid.NewString()), device.ID, "s3cret", time.Now())
+		if err := repos.credentials.Save(credential); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		revokedAt := time.Now()
+		_ = credential.Revoke(revokedAt)
+		if err := repos.credentials.Update(credential); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, _ := repos.credentials.FindByID(credential.ID)
+		if found.RevokedAt == nil {
+			t.Fatalf("expected the credential to be revoked")
+		}
+		assertSameInstant(t, revokedAt, *found.RevokedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repos := factory(t)
+		credential, _ := domain.NewHMACCredential(domain.CredentialID(uuid.NewString()), domain.DeviceID(uuid.NewString()), "s3cret", time.Now())
+
+		if _, err := repos.credentials.FindByID(credential.ID); !errors.Is(err, domain.ErrCredentialNotFound) {
+			t.Errorf("expected ErrCredentialNotFound, got %v", err)
+		}
+		if _, err := repos.credentials.FindByFingerprint(contractChecksum); !errors.Is(err, domain.ErrCredentialNotFound) {
+			t.Errorf("expected ErrCredentialNotFound, got %v", err)
+		}
+		if _, err := repos.credentials.FindByFingerprint(""); !errors.Is(err, domain.ErrCredentialNotFound) {
+			t.Errorf("expected ErrCredentialNotFound, got %v", err)
+		}
+		if err := repos.credentials.Update(credential); !errors.Is(err, domain.ErrCredentialNotFound) {
+			t.Errorf("expected ErrCredentialNotFound, got %v", err)
+		}
+	})
+}
+
+func runClaimTokenRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("redeem until used up", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		token, _ := domain.NewClaimToken(uuid.NewString(), "claim-me", "gateway", 2, time.Hour, now)
+		if err := repos.claimTokens.Save(token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for uses := 1; uses <= 2; uses++ {
+			redeemed, err := repos.claimTokens.Redeem(token.TokenHash, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if redeemed.ID != token.ID || redeemed.DeviceType != "gateway" || redeemed.Uses != uses {
+				t.Errorf("unexpected token %+v", redeemed)
+			}
+		}
+
+		if _, err := repos.claimTokens.Redeem(token.TokenHash, now); !errors.Is(err, domain.ErrClaimTokenRejected) {
+			t.Errorf("expected ErrClaimTokenRejected, got %v", err)
+		}
+	})
+
+	t.Run("expired or unknown", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		token, _ := domain.NewClaimToken(uuid.NewString(), "claim-me", "gateway", 1, time.Hour, now)
+		if err := repos.claimTokens.Save(token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repos.claimTokens.Redeem(token.TokenHash, now.Add(time.Hour)); !errors.Is(err, domain.ErrClaimTokenRejected) {
+			t.Errorf("expected ErrClaimTokenRejected, got %v", err)
+		}
+		if _, err := repos.claimTokens.Redeem(domain.HashClaimToken("unknown"), now); !errors.Is(err, domain.ErrClaimTokenRejected) {
+			t.Errorf("expected ErrClaimTokenRejected, got %v", err)
+		}
+	})
+}
+
+func runAPIKeyRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save, find and revoke", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		key, _ := domain.NewAPIKey(domain.APIKeyID(uuid.NewString()), "ingest", domain.RoleOperator, "s3cret", time.Hour, now)
+		if err := repos.apiKeys.Save(key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.apiKeys.FindByID(key.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Name != "ingest" || found.Role != domain.RoleOperator || found.TenantID != domain.DefaultTenant || !found.Verify("s3cret") || found.ExpiresAt == nil {
+			t.Errorf("unexpected key %+v", found)
+		}
+
+		if err := found.Revoke(now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.apiKeys.Update(found); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, _ = repos.apiKeys.FindByID(key.ID)
+		if found.RevokedAt == nil || found.IsActive(now) {
+			t.Errorf("expected the key to be revoked, got %+v", found)
+		}
+	})
+
+	t.Run("list by tenant", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		for i, tenant := range []domain.TenantID{"acme", "globex", "acme"} {
+			key, _ := domain.NewAPIKey(domain.APIKeyID(uuid.NewString()), "key", domain.RoleViewer, "s3cret", 0, now.Add(time.Duration(i)*time.Second))
+			key.TenantID = tenant
+			if err := repos.apiKeys.Save(key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		acme, err := repos.apiKeys.FindByTenant("acme")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(acme) != 2 || acme[0].CreatedAt.After(acme[1].CreatedAt) {
+			t.Errorf("expected the two keys of acme oldest first, got %+v", acme)
+		}
+
+		if all, _ := repos.apiKeys.FindAll(); len(all) != 3 {
+			t.Errorf("expected 3 keys, got %d", len(all))
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		repos := factory(t)
+		key, _ := domain.NewAPIKey(domain.APIKeyID(uuid.NewString()), "ingest", domain.RoleViewer, "s3cret", 0, time.Now())
+
+		if _, err := repos.apiKeys.FindByID(key.ID); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+			t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+		}
+		if err := repos.apiKeys.Update(key); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+			t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+		}
+	})
+}
+
+func runAuditRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("append and find", func(t *testing.T) {
+		repos := factory(t)
+		actor := domain.AuditActor{Subject: "api-key:1", Role: domain.RoleOperator, RequestID: "req-1", SourceIP: "10.0.0.7"}
+		entry, _ := domain.NewAuditEntry(domain.AuditEntryID(uuid.NewString()), actor, domain.AuditSensorConfigure, "s1",
+			[]byte(`{"config":{"max_threshold":30}}`), []byte(`{"config":{"max_threshold":35}}`), time.Now())
+		entry.TenantID = "acme"
+		if err := repos.audit.Append(entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.audit.Find(domain.AuditFilter{RequestID: "req-1"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(found) != 1 || found[0].Actor != actor || found[0].TenantID != "acme" || found[0].ResourceType != "sensor" || found[0].ResourceID != "s1" {
+			t.Fatalf("unexpected entries %+v", found)
+		}
+
+		changes := found[0].Changes
+		if len(changes) != 1 || changes[0].Field != "config.max_threshold" || string(changes[0].Before) != "30" || string(changes[0].After) != "35" {
+			t.Errorf("unexpected changes %+v", changes)
+		}
+
+		var after map[string]map[string]float64
+		if err := json.Unmarshal(found[0].After, &after); err != nil || after["config"]["max_threshold"] != 35 {
+			t.Errorf("unexpected snapshot %s: %v", found[0].After, err)
+		}
+	})
+
+	t.Run("filter newest first", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now().Truncate(time.Second)
+		appends := []struct {
+			tenant domain.TenantID
+			actor  string
+			action domain.AuditAction
+		}{
+			{"acme", "alice", domain.AuditDeviceCreate},
+			{"acme", "bob", domain.AuditSensorCreate},
+			{"globex", "alice", domain.AuditDeviceCreate},
+			{"acme", "alice", domain.AuditDeviceDelete},
+		}
+		for i, a := range appends {
+			entry, _ := domain.NewAuditEntry(domain.AuditEntryID(uuid.NewString()), domain.AuditActor{Subject: a.actor}, a.action, "r", nil, []byte(`{}`), now.Add(time.Duration(i)*time.Minute))
+			entry.TenantID = a.tenant
+			if err := repos.audit.Append(entry); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		tests := []struct {
+			name     string
+			filter   domain.AuditFilter
+			expected []domain.AuditAction
+		}{
+			{name: "tenant", filter: domain.AuditFilter{TenantID: "acme"}, expected: []domain.AuditAction{domain.AuditDeviceDelete, domain.AuditSensorCreate, domain.AuditDeviceCreate}},
+			{name: "actor", filter: domain.AuditFilter{TenantID: "acme", Actor: "alice"}, expected: []domain.AuditAction{domain.AuditDeviceDelete, domain.AuditDeviceCreate}},
+			{name: "resource type", filter: domain.AuditFilter{ResourceType: "sensor"}, expected: []domain.AuditAction{domain.AuditSensorCreate}},
+			{name: "action", filter: domain.AuditFilter{Action: domain.AuditDeviceCreate}, expected: []domain.AuditAction{domain.AuditDeviceCreate, domain.AuditDeviceCreate}},
+			{name: "time range", filter: domain.AuditFilter{From: now.Add(time.Minute), To: now.Add(3 * time.Minute)}, expected: []domain.AuditAction{domain.AuditDeviceCreate, domain.AuditSensorCreate}},
+			{name: "limit", filter: domain.AuditFilter{Limit: 1}, expected: []domain.AuditAction{domain.AuditDeviceDelete}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				found, err := repos.audit.Find(tt.filter)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				actions := make([]domain.AuditAction, 0, len(found))
+				for _, entry := range found {
+					actions = append(actions, entry.Action)
+				}
+
+				if len(actions) != len(tt.expected) {
+					t.Fatalf("expected %v, got %v", tt.expected, actions)
+				}
+				for i := range actions {
+					if actions[i] != tt.expected[i] {
+						t.Errorf("expected %v, got %v", tt.expected, actions)
+						break
+					}
+				}
+			})
+		}
+	})
+}
+
+func runPresenceRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("never seen", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+
+		lastSeen, err := repos.presence.LastSeen(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !lastSeen.IsZero() {
+			t.Errorf("expected the zero time, got %v", lastSeen)
+		}
+	})
+
+	t.Run("touch only moves forward", func(t *testing.T) {
+		repos := factory(t)
+		device := newContractDevice(t, repos, "Gateway", time.Now())
+		now := time.Now()
+
+		for _, at := range []time.Time{now.Add(-time.Minute), now, now.Add(-time.Hour)} {
+			if err := repos.presence.Touch(device.ID, at); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		lastSeen, err := repos.presence.LastSeen(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertSameInstant(t, now, lastSeen)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		first := newContractDevice(t, repos, "First", now)
+		second := newContractDevice(t, repos, "Second", now)
+		newContractDevice(t, repos, "Unseen", now)
+
+		_ = repos.presence.Touch(first.ID, now.Add(-time.Minute))
+		_ = repos.presence.Touch(second.ID, now)
+
+		all, err := repos.presence.All()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(all) != 2 {
+			t.Fatalf("expected 2 devices, got %v", all)
+		}
+		assertSameInstant(t, now.Add(-time.Minute), all[first.ID])
+		assertSameInstant(t, now, all[second.ID])
+	})
+}
+
+func runGroupRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save, find and list by name", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		device := newContractDevice(t, repos, "Gateway", now)
+
+		static, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Lab", "", []domain.DeviceID{device.ID}, now)
+		dynamic, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Madrid", "site=madrid,floor in (1,2)", nil, now)
+		for _, group := range []*domain.DeviceGroup{dynamic, static} {
+			if err := repos.groups.Save(group); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		found, err := repos.groups.FindByID(static.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Name != "Lab" || found.IsDynamic() || len(found.DeviceIDs) != 1 || found.DeviceIDs[0] != device.ID {
+			t.Errorf("expected %+v, got %+v", static, found)
+		}
+		assertSameInstant(t, static.CreatedAt, found.CreatedAt)
+
+		groups, err := repos.groups.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(groups) != 2 || groups[0].ID != static.ID || groups[1].Selector != dynamic.Selector {
+			t.Errorf("expected the groups ordered by name, got %+v", groups)
+		}
+	})
+
+	t.Run("names are unique", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		first, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Lab", "", nil, now)
+		second, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Lab", "site=madrid", nil, now)
+		if err := repos.groups.Save(first); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := repos.groups.Save(second); !errors.Is(err, domain.ErrGroupAlreadyExists) {
+			t.Errorf("expected ErrGroupAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		group, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Lab", "", nil, now)
+		if err := repos.groups.Save(group); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := group.Update("Madrid", "site=madrid", nil, now.Add(time.Minute)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.groups.Update(group); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.groups.FindByID(group.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Name != "Madrid" || found.Selector != "site=madrid" || len(found.DeviceIDs) != 0 {
+			t.Errorf("expected %+v, got %+v", group, found)
+		}
+
+		if err := repos.groups.Delete(group.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repos.groups.FindByID(group.ID); !errors.Is(err, domain.ErrGroupNotFound) {
+			t.Errorf("expected ErrGroupNotFound, got %v", err)
+		}
+
+		if err := repos.groups.Delete(group.ID); !errors.Is(err, domain.ErrGroupNotFound) {
+			t.Errorf("expected ErrGroupNotFound, got %v", err)
+		}
+
+		if err := repos.groups.Update(group); !errors.Is(err, domain.ErrGroupNotFound) {
+			t.Errorf("expected ErrGroupNotFound, got %v", err)
+		}
+	})
+}
+
+func runLocationRepositoryContract(t *testing.T, factory repositoryFactory) {
+	t.Run("save, find and list by name", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		organization, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationOrganization, "Acme", "", nil, nil, now)
+		site, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationSite, "Madrid", organization.ID, &domain.GeoPoint{Lat: 40.4168, Lon: -3.7038}, nil, now)
+		area, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationArea, "Floor 2", site.ID, nil, &domain.IndoorPosition{X: 12.5, Y: 3, Floor: 2}, now)
+		for _, location := range []*domain.Location{organization, site, area} {
+			if err := repos.locations.Save(location); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		found, err := repos.locations.FindByID(site.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Kind != domain.LocationSite || found.ParentID != organization.ID || found.Geo == nil || *found.Geo != *site.Geo || found.Indoor != nil {
+			t.Errorf("expected %+v, got %+v", site, found)
+		}
+		assertSameInstant(t, site.CreatedAt, found.CreatedAt)
+
+		found, err = repos.locations.FindByID(area.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Geo != nil || found.Indoor == nil || *found.Indoor != *area.Indoor {
+			t.Errorf("expected %+v, got %+v", area, found)
+		}
+
+		locations, err := repos.locations.FindAll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(locations) != 3 || locations[0].ID != organization.ID || locations[1].ID != area.ID || locations[2].ParentID != organization.ID {
+			t.Errorf("expected the locations ordered by name, got %+v", locations)
+		}
+
+		if err := repos.locations.Save(site); !errors.Is(err, domain.ErrLocationAlreadyExists) {
+			t.Errorf("expected ErrLocationAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		organization, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationOrganization, "Acme", "", nil, nil, now)
+		site, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationSite, "Madrid", organization.ID, nil, nil, now)
+		for _, location := range []*domain.Location{organization, site} {
+			if err := repos.locations.Save(location); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if err := repos.locations.Delete(organization.ID); !errors.Is(err, domain.ErrLocationInUse) {
+			t.Errorf("expected ErrLocationInUse, got %v", err)
+		}
+
+		if err := site.Update("Bilbao", organization.ID, &domain.GeoPoint{Lat: 43.263, Lon: -2.935}, nil, now.Add(time.Minute)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.locations.Update(site); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repos.locations.FindByID(site.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Name != "Bilbao" || found.Geo == nil || found.Geo.Lat != 43.263 {
+			t.Errorf("expected %+v, got %+v", site, found)
+		}
+
+		if err := repos.locations.Delete(site.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repos.locations.FindByID(site.ID); !errors.Is(err, domain.ErrLocationNotFound) {
+			t.Errorf("expected ErrLocationNotFound, got %v", err)
+		}
+
+		if err := repos.locations.Delete(site.ID); !errors.Is(err, domain.ErrLocationNotFound) {
+			t.Errorf("expected ErrLocationNotFound, got %v", err)
+		}
+
+		if err := repos.locations.Update(site); !errors.Is(err, domain.ErrLocationNotFound) {
+			t.Errorf("expected ErrLocationNotFound, got %v", err)
+		}
+	})
+
+	t.Run("device location round trip", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		organization, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationOrganization, "Acme", "", nil, nil, now)
+		if err := repos.locations.Save(organization); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		device := newContractDevice(t, repos, "Gateway", now)
+		found, err := repos.devices.FindByID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.LocationID != "" || found.Geo != nil {
+			t.Errorf("expected an unassigned device, got %+v", found)
+		}
+
+		if err := device.Locate(organization.ID, &domain.GeoPoint{Lat: 40.4168, Lon: -3.7038}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repos.devices.Update(device); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err = repos.devices.FindByID(device.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.LocationID != organization.ID || found.Geo == nil || *found.Geo != *device.Geo {
+			t.Errorf("expected %+v, got %+v", device, found)
+		}
+	})
+}
+
+// runTenantContract checks that every aggregate keeps its tenant and that
+// FindByTenant only returns what the tenant owns.
+func runTenantContract(t *testing.T, factory repositoryFactory) {
+	t.Run("find by tenant", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+
+		owned := map[domain.TenantID]domain.DeviceID{}
+		for _, tenant := range []domain.TenantID{"acme", "globex"} {
+			device, _ := domain.NewDevice(domain.DeviceID(uuid.NewString()), "Gateway", "gateway")
+			device.TenantID = tenant
+			if err := repos.devices.Save(device); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			owned[tenant] = device.ID
+
+			sensor, _ := domain.NewSensor(domain.SensorID(uuid.NewString()), device.ID, "Sensor", domain.Temperature, domain.SensorConfig{SamplingRateMs: 1000})
+			sensor.TenantID = tenant
+			if err := repos.sensors.Save(sensor); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			group, _ := domain.NewDeviceGroup(domain.GroupID(uuid.NewString()), "Lab", "", nil, now)
+			group.TenantID = tenant
+			if err := repos.groups.Save(group); err != nil {
+				t.Fatalf("names only need to be unique within a tenant: %v", err)
+			}
+
+			location, _ := domain.NewLocation(domain.LocationID(uuid.NewString()), domain.LocationOrganization, string(tenant), "", nil, nil, now)
+			location.TenantID = tenant
+			if err := repos.locations.Save(location); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		devices, err := repos.devices.FindByTenant("acme")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(devices) != 1 || devices[0].ID != owned["acme"] || devices[0].TenantID != "acme" {
+			t.Errorf("expected the device of acme, got %+v", devices)
+		}
+
+		sensors, err := repos.sensors.FindByTenant("globex")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sensors) != 1 || sensors[0].DeviceID != owned["globex"] || sensors[0].TenantID != "globex" {
+			t.Errorf("expected the sensor of globex, got %+v", sensors)
+		}
+
+		groups, err := repos.groups.FindByTenant("acme")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(groups) != 1 || groups[0].TenantID != "acme" {
+			t.Errorf("expected the group of acme, got %+v", groups)
+		}
+
+		locations, err := repos.locations.FindByTenant("globex")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(locations) != 1 || locations[0].Name != "globex" || locations[0].TenantID != "globex" {
+			t.Errorf("expected the location of globex, got %+v", locations)
+		}
+
+		if devices, _ := repos.devices.FindByTenant(domain.DefaultTenant); len(devices) != 0 {
+			t.Errorf("expected no devices in the default tenant, got %+v", devices)
+		}
+
+		if all, _ := repos.devices.FindAll(); len(all) != 2 {
+			t.Errorf("expected FindAll to return every tenant, got %+v", all)
+		}
+	})
+
+	t.Run("readings and claim tokens keep their tenant", func(t *testing.T) {
+		repos := factory(t)
+		now := time.Now()
+		sensor := newContractSensorWithDevice(t, repos)
+		if sensor.TenantID != domain.DefaultTenant {
+			t.Errorf("expected the default tenant, got %q", sensor.TenantID)
+		}
+
+		reading := domain.NewSensorReading(sensor.ID, sensor.DeviceID, sensor.Type, 21.5, "", now)
+		reading.TenantID = "acme"
+		if err := repos.readings.Save(&reading); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		readings, err := repos.readings.FindBySensorID(sensor.ID, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(readings) != 1 || readings[0].TenantID != "acme" {
+			t.Errorf("expected the reading of acme, got %+v", readings)
+		}
+
+		token, _ := domain.NewClaimToken(uuid.NewString(), "claim-me", "gateway", 1, time.Hour, now)
+		token.TenantID = "acme"
+		if err := repos.claimTokens.Save(token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		redeemed, err := repos.claimTokens.Redeem(token.TokenHash, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if redeemed.TenantID != "acme" {
+			t.Errorf("expected the token of acme, got %+v", redeemed)
+		}
+	})
+}
+
+func newContractFirmware(t *testing.T, repos repositorySet, version string, createdAt time.Time) *domain.Firmware {
+	t.Helper()
+
+	firmware, err := domain.NewFirmware(domain.FirmwareID(uuid.NewString()), version, "gateway", contractChecksum, 512, createdAt)
+	if err != nil {
+		t.Fatalf("failed to build firmware: %v", err)
+	}
+
+	if err := repos.firmwares.Save(firmware); err != nil {
+		t.Fatalf("failed to save firmware: %v", err)
+	}
+
+	return firmware
+}
+
+func newContractDevice(t *testing.T, repos repositorySet, name string, createdAt time.Time) *domain.Device {
+	t.Helper()
+
+	device, err := domain.NewDevice(domain.DeviceID(uuid.NewString()), name, "gateway")
+	if err != nil {
+		t.Fatalf("failed to build device: %v", err)
+	}
+
+	device.CreatedAt = createdAt.UTC()
+	device.UpdatedAt = createdAt.UTC()
+
+	if err := repos.devices.Save(device); err != nil {
+		t.Fatalf("failed to save device: %v", err)
+	}
+
+	return device
+}
+
+func newContractSensor(t *testing.T, repos repositorySet, deviceID domain.DeviceID, typ domain.SensorType, createdAt time.Time) *domain.Sensor {
+	t.Helper()
+
+	id := domain.SensorID(uuid.NewString())
+	max := 30.0
+	config, _ := domain.NewSensorConfig(id, 1000, domain.Thresholds{Max: &max}, 0.1, true)
+
+	sensor, err := domain.NewSensor(id, deviceID, "Sensor "+string(typ), typ, config)
+	if err != nil {
+		t.Fatalf("failed to build sensor: %v", err)
+	}
+
+	sensor.CreatedAt = createdAt.UTC()
+	sensor.UpdatedAt = createdAt.UTC()
+
+	if err := repos.sensors.Save(sensor); err != nil {
+		t.Fatalf("failed to save sensor: %v", err)
+	}
+
+	return sensor
+}
+
+func newContractSensorWithDevice(t *testing.T, repos repositorySet) *domain.Sensor {
+	t.Helper()
+
+	device := newContractDevice(t, repos, "Gateway", time.Now())
+
+	return newContractSensor(t, repos, device.ID, domain.Temperature, time.Now())
+}
+
+func saveContractReading(t *testing.T, repos repositorySet, sensor *domain.Sensor, value float64, ts time.Time) {
+	t.Helper()
+
+	reading := domain.NewSensorReading(sensor.ID, sensor.DeviceID, sensor.Type, value, "", ts)
+	if err := repos.readings.Save(&reading); err != nil {
+		t.Fatalf("failed to save reading: %v", err)
+	}
+}
+
+func assertSameInstant(t *testing.T, expected, actual time.Time) {
+	t.Helper()
+
+	if diff := expected.Sub(actual); diff > time.Millisecond || diff < -time.Millisecond {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -1,6 +1,9 @@
 package persistence
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -14,42 +17,348 @@ type DB struct {
 }
 
 func NewDB() *DB {
-	dsn := os.Getenv("POSTGRES_DSN")
+	db, err := OpenPostgresDB(os.Getenv("POSTGRES_DSN"), logger.Info)
+	if err != nil {
+		log.Fatal("failed to connect database: ", err)
+	}
+
+	return db
+}
+
+func OpenPostgresDB(dsn string, logLevel logger.LogLevel) (*DB, error) {
 	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true,
 	})
 	if err != nil {
-		log.Fatal("failed to connect database: ", err)
+		return nil, err
+	}
+
+	return &DB{conn: conn}, nil
+}
+
+func NewSQLiteDB() *DB {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "iot.db"
+	}
+
+	db, err := OpenSQLiteDB(path, logger.Info)
+	if err != nil {
+		log.Fatal("failed to open database: ", err)
+	}
+
+	return db
+}
+
+// OpenSQLiteDB opens (or creates) a SQLite file. SQLite allows a single
+// writer, so the pool is limited to one connection to avoid "database is
+// locked" errors from the simulator goroutines.
+func OpenSQLiteDB(path string, logLevel logger.LogLevel) (*DB, error) {
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(1)
 
-	return &DB{conn: conn}
+	return &DB{conn: conn}, nil
+}
+
+func (db *DB) Dialect() string {
+	return db.conn.Dialector.Name()
+}
+
+// jsonColumn is written as text so it can back both a Postgres JSONB column
+// and a SQLite TEXT column queried with the json1 functions.
+type jsonColumn []byte
+
+func (j jsonColumn) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+
+	return string(j), nil
+}
+
+func (j *jsonColumn) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = jsonColumn(v)
+	default:
+		return fmt.Errorf("unsupported json column value %T", value)
+	}
+
+	return nil
 }
 
 type SensorModel struct {
 	ID        string `gorm:"primaryKey"`
+	TenantID  string `gorm:"index"`
 	DeviceID  string `gorm:"index"`
 	Name      string
 	Type      string
-	Config    []byte `gorm:"type:jsonb"`
+	Config    jsonColumn `gorm:"type:jsonb"`
+	Labels    jsonColumn `gorm:"type:jsonb"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type SensorConfigRevisionModel struct {
+	SensorID     string     `gorm:"primaryKey"`
+	Revision     int64      `gorm:"primaryKey"`
+	Config       jsonColumn `gorm:"type:jsonb"`
+	ChangedBy    string
+	RestoredFrom int64
+	CreatedAt    time.Time
+}
+
+func (SensorConfigRevisionModel) TableName() string {
+	return "sensor_config_revisions"
 }
 
 type SensorReadingModel struct {
 	ID        string `gorm:"primaryKey"`
+	TenantID  string `gorm:"index"`
 	SensorID  string `gorm:"index"`
 	DeviceID  string `gorm:"index"`
 	Type      string
 	Value     float64
 	Unit      string
-	Timestamp time.Time
-	Meta      []byte `gorm:"type:jsonb"`
+	Timestamp time.Time  `gorm:"primaryKey"`
+	Meta      jsonColumn `gorm:"type:jsonb"`
+}
+
+func (SensorReadingModel) TableName() string {
+	return readingsTable
 }
 
 type DeviceModel struct {
+	ID         string `gorm:"primaryKey"`
+	TenantID   string `gorm:"index"`
+	Name       string
+	Type       string
+	Status     string
+	Labels     jsonColumn `gorm:"type:jsonb"`
+	LocationID *string    `gorm:"index"`
+	Latitude   *float64
+	Longitude  *float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Version    int64
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+type ReadingRollupModel struct {
+	SensorID  string    `gorm:"primaryKey"`
+	Bucket    time.Time `gorm:"primaryKey"`
+	MinValue  float64
+	MaxValue  float64
+	SumValue  float64
+	Count     int64
+	LastValue float64
+	LastAt    time.Time
+}
+
+type DeviceTwinModel struct {
+	DeviceID        string     `gorm:"primaryKey"`
+	Desired         jsonColumn `gorm:"type:jsonb"`
+	Reported        jsonColumn `gorm:"type:jsonb"`
+	DesiredVersion  int64
+	ReportedVersion int64
+	Version         int64
+	UpdatedAt       time.Time
+}
+
+func (DeviceTwinModel) TableName() string {
+	return "device_twins"
+}
+
+type PresenceModel struct {
+	DeviceID string `gorm:"primaryKey"`
+	LastSeen time.Time
+}
+
+func (PresenceModel) TableName() string {
+	return "device_presence"
+}
+
+type CommandModel struct {
+	ID          string `gorm:"primaryKey"`
+	DeviceID    string `gorm:"index"`
+	Name        string
+	Params      jsonColumn `gorm:"type:jsonb"`
+	Status      string     `gorm:"index"`
+	Result      jsonColumn `gorm:"type:jsonb"`
+	Error       string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DeliveredAt *time.Time
+	CompletedAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func (CommandModel) TableName() string {
+	return "device_commands"
+}
+
+type FirmwareModel struct {
+	ID         string `gorm:"primaryKey"`
+	Version    string
+	DeviceType string
+	Checksum   string
+	Size       int64
+	CreatedAt  time.Time
+}
+
+func (FirmwareModel) TableName() string {
+	return "firmwares"
+}
+
+type CampaignModel struct {
+	ID               string `gorm:"primaryKey"`
+	FirmwareID       string `gorm:"index"`
+	DeviceType       string
+	Stages           jsonColumn `gorm:"type:jsonb"`
+	Stage            int
+	FailureThreshold float64
+	Status           string `gorm:"index"`
+	Reason           string
+	AcceptedFailures int
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (CampaignModel) TableName() string {
+	return "firmware_campaigns"
+}
+
+type DeviceUpdateModel struct {
+	CampaignID  string `gorm:"primaryKey"`
+	DeviceID    string `gorm:"primaryKey"`
+	Position    int
+	Status      string
+	Progress    int
+	Error       string
+	CommandID   string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func (DeviceUpdateModel) TableName() string {
+	return "firmware_device_updates"
+}
+
+type CredentialModel struct {
+	ID          string `gorm:"primaryKey"`
+	DeviceID    string `gorm:"index"`
+	Type        string
+	Secret      string
+	Fingerprint string `gorm:"index"`
+	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+}
+
+func (CredentialModel) TableName() string {
+	return "device_credentials"
+}
+
+type ClaimTokenModel struct {
+	ID         string `gorm:"primaryKey"`
+	TenantID   string
+	TokenHash  string `gorm:"uniqueIndex"`
+	DeviceType string
+	MaxUses    int
+	Uses       int
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (ClaimTokenModel) TableName() string {
+	return "claim_tokens"
+}
+
+type APIKeyModel struct {
+	ID         string `gorm:"primaryKey"`
+	TenantID   string `gorm:"index"`
+	Name       string
+	Role       string
+	SecretHash string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+func (APIKeyModel) TableName() string {
+	return "api_keys"
+}
+
+type AuditEntryModel struct {
+	ID           string `gorm:"primaryKey"`
+	TenantID     string `gorm:"index"`
+	Actor        string `gorm:"index"`
+	Role         string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Before       jsonColumn `gorm:"type:jsonb"`
+	After        jsonColumn `gorm:"type:jsonb"`
+	Changes      jsonColumn `gorm:"type:jsonb"`
+	RequestID    string     `gorm:"index"`
+	SourceIP     string
+	At           time.Time `gorm:"index"`
+}
+
+func (AuditEntryModel) TableName() string {
+	return "audit_log"
+}
+
+type DeviceGroupModel struct {
 	ID        string `gorm:"primaryKey"`
-	Name      string
-	Type      string
+	TenantID  string `gorm:"uniqueIndex:idx_device_groups_tenant_id_name"`
+	Name      string `gorm:"uniqueIndex:idx_device_groups_tenant_id_name"`
+	Selector  string
+	DeviceIDs jsonColumn `gorm:"type:jsonb"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+func (DeviceGroupModel) TableName() string {
+	return "device_groups"
+}
+
+type LocationModel struct {
+	ID          string `gorm:"primaryKey"`
+	TenantID    string `gorm:"index"`
+	Kind        string
+	Name        string
+	ParentID    *string `gorm:"index"`
+	Latitude    *float64
+	Longitude   *float64
+	IndoorX     *float64
+	IndoorY     *float64
+	IndoorFloor *int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (LocationModel) TableName() string {
+	return "locations"
+}
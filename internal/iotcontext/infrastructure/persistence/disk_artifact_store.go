@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DiskArtifactStore keeps each firmware image as a file named after its id.
+// Images are written to a temporary file and renamed, so a crash never leaves
+// a truncated image behind.
+type DiskArtifactStore struct {
+	dir string
+}
+
+func NewDiskArtifactStore(dir string) (*DiskArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &DiskArtifactStore{dir: dir}, nil
+}
+
+func (s *DiskArtifactStore) Put(id domain.FirmwareID, content io.Reader) (int64, string, error) {
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), content)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return 0, "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return 0, "", err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(id)); err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (s *DiskArtifactStore) Open(id domain.FirmwareID) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrArtifactNotFound
+	}
+
+	return file, err
+}
+
+func (s *DiskArtifactStore) Delete(id domain.FirmwareID) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *DiskArtifactStore) path(id domain.FirmwareID) string {
+	return filepath.Join(s.dir, filepath.Base(string(id))+".bin")
+}
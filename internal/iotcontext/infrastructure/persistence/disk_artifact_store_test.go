@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiskArtifactStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskArtifactStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	size, checksum, err := store.Put("firmware-1", strings.NewReader("image"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if size != 5 || checksum != "6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d" {
+		t.Errorf("unexpected size %d or checksum %s", size, checksum)
+	}
+
+	content, err := store.Open("firmware-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+
+	if string(data) != "image" {
+		t.Errorf("expected the stored image, got %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %v", entries)
+	}
+
+	if err := store.Delete("firmware-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.Open("firmware-1"); !errors.Is(err, domain.ErrArtifactNotFound) {
+		t.Errorf("expected ErrArtifactNotFound, got %v", err)
+	}
+
+	if err := store.Delete("firmware-1"); err != nil {
+		t.Errorf("expected deleting a missing image to succeed, got %v", err)
+	}
+}
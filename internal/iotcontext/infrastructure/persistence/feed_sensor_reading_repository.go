@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+)
+
+// FeedSensorReadingRepository hands every stored reading to the subscribers
+// of the process, for the clients watching readings as they arrive.
+type FeedSensorReadingRepository struct {
+	domain.SensorReadingRepository
+	subscribers map[*readingSubscriber]struct{}
+	mu          sync.RWMutex
+}
+
+type readingSubscriber struct {
+	match    func(reading domain.SensorReading) bool
+	readings chan domain.SensorReading
+}
+
+func NewFeedSensorReadingRepository(readings domain.SensorReadingRepository) *FeedSensorReadingRepository {
+	return &FeedSensorReadingRepository{
+		SensorReadingRepository: readings,
+		subscribers:             map[*readingSubscriber]struct{}{},
+	}
+}
+
+func (r *FeedSensorReadingRepository) Save(reading *domain.SensorReading) error {
+	if err := r.SensorReadingRepository.Save(reading); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for subscriber := range r.subscribers {
+		if !subscriber.match(*reading) {
+			continue
+		}
+
+		select {
+		case subscriber.readings <- *reading:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (r *FeedSensorReadingRepository) Subscribe(match func(reading domain.SensorReading) bool, buffer int) (<-chan domain.SensorReading, func()) {
+	subscriber := &readingSubscriber{
+		match:    match,
+		readings: make(chan domain.SensorReading, buffer),
+	}
+
+	r.mu.Lock()
+	r.subscribers[subscriber] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subscribers, subscriber)
+			r.mu.Unlock()
+
+			close(subscriber.readings)
+		})
+	}
+
+	return subscriber.readings, cancel
+}
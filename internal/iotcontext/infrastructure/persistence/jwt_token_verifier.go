@@ -0,0 +1,287 @@
+package persistence
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures which tokens a JWTTokenVerifier accepts. At least one
+// of HMACSecret and JWKSFile is needed; Issuer and Audience are only checked
+// when set.
+type JWTOptions struct {
+	HMACSecret string
+	JWKSFile   string
+	Issuer     string
+	Audience   string
+}
+
+// JWTTokenVerifier checks JWTs locally: HS256 tokens against a shared secret
+// and RS256 tokens against the keys of a static JWKS file. Nothing is fetched
+// at runtime, so rotating the keys of the identity provider means updating
+// the file and restarting.
+//
+// The claims it reads besides the registered ones are role, one of the API
+// roles, and tenant_id, the tenant the token is bound to. A token without
+// tenant_id is bound to the default tenant; "*" binds it to none, which lets
+// it act for the whole deployment.
+type JWTTokenVerifier struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+}
+
+func NewJWTTokenVerifier(options JWTOptions) (*JWTTokenVerifier, error) {
+	if options.HMACSecret == "" && options.JWKSFile == "" {
+		return nil, errors.New("a JWT verifier needs an HMAC secret or a JWKS file")
+	}
+
+	verifier := &JWTTokenVerifier{
+		rsaKeys:  make(map[string]*rsa.PublicKey),
+		issuer:   options.Issuer,
+		audience: options.Audience,
+	}
+
+	if options.HMACSecret != "" {
+		verifier.hmacSecret = []byte(options.HMACSecret)
+	}
+
+	if options.JWKSFile != "" {
+		keys, err := loadJWKS(options.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		verifier.rsaKeys = keys
+	}
+
+	return verifier, nil
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *float64    `json:"exp"`
+	NotBefore *float64    `json:"nbf"`
+	Role      string      `json:"role"`
+	TenantID  string      `json:"tenant_id"`
+}
+
+// jwtAudience is either a single audience or a list of them.
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+
+	return nil
+}
+
+// Verify returns who the token was issued to. Every failure wraps
+// ErrAuthenticationFailed.
+func (v *JWTTokenVerifier) Verify(token string, now time.Time) (domain.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return domain.Principal{}, fmt.Errorf("%w: malformed token", domain.ErrAuthenticationFailed)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return domain.Principal{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: malformed signature", domain.ErrAuthenticationFailed)
+	}
+
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return domain.Principal{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return domain.Principal{}, err
+	}
+
+	if err := v.checkClaims(claims, now); err != nil {
+		return domain.Principal{}, err
+	}
+
+	role, err := domain.ParseRole(claims.Role)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %v", domain.ErrAuthenticationFailed, err)
+	}
+
+	principal := domain.Principal{Subject: claims.Subject, Role: role, TenantID: domain.DefaultTenant}
+	switch claims.TenantID {
+	case "":
+	case "*":
+		principal.TenantID = ""
+	default:
+		tenant, err := domain.ParseTenantID(claims.TenantID)
+		if err != nil {
+			return domain.Principal{}, fmt.Errorf("%w: %v", domain.ErrAuthenticationFailed, err)
+		}
+		principal.TenantID = tenant
+	}
+
+	return principal, nil
+}
+
+// verifySignature only accepts the algorithm of a key that is configured, so
+// a token cannot pick a weaker check, e.g. HS256 with the RSA public key as
+// secret, or none at all.
+func (v *JWTTokenVerifier) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if v.hmacSecret == nil {
+			return fmt.Errorf("%w: HS256 tokens are not accepted", domain.ErrAuthenticationFailed)
+		}
+
+		mac := hmac.New(sha256.New, v.hmacSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: bad signature", domain.ErrAuthenticationFailed)
+		}
+
+		return nil
+	case "RS256":
+		key, ok := v.rsaKeys[header.KeyID]
+		if !ok && header.KeyID == "" && len(v.rsaKeys) == 1 {
+			for _, only := range v.rsaKeys {
+				key, ok = only, true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", domain.ErrAuthenticationFailed, header.KeyID)
+		}
+
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: bad signature", domain.ErrAuthenticationFailed)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: algorithm %q is not accepted", domain.ErrAuthenticationFailed, header.Algorithm)
+	}
+}
+
+func (v *JWTTokenVerifier) checkClaims(claims jwtClaims, now time.Time) error {
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: token without subject", domain.ErrAuthenticationFailed)
+	}
+
+	if claims.ExpiresAt == nil || !now.Before(time.Unix(int64(*claims.ExpiresAt), 0)) {
+		return fmt.Errorf("%w: token expired or without expiry", domain.ErrAuthenticationFailed)
+	}
+
+	if claims.NotBefore != nil && now.Before(time.Unix(int64(*claims.NotBefore), 0)) {
+		return fmt.Errorf("%w: token not valid yet", domain.ErrAuthenticationFailed)
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", domain.ErrAuthenticationFailed, claims.Issuer)
+	}
+
+	if v.audience != "" {
+		for _, audience := range claims.Audience {
+			if audience == v.audience {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%w: token is not meant for %q", domain.ErrAuthenticationFailed, v.audience)
+	}
+
+	return nil
+}
+
+func decodeJWTSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", domain.ErrAuthenticationFailed)
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%w: malformed token", domain.ErrAuthenticationFailed)
+	}
+
+	return nil
+}
+
+// loadJWKS reads the RSA signing keys of a JWKS file by kid. Keys of other
+// types or uses are skipped.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("JWKS file %s: %w", path, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS file %s: key %q: bad modulus", path, key.KeyID)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("JWKS file %s: key %q: bad exponent", path, key.KeyID)
+		}
+
+		keys[key.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no RSA signing keys", path)
+	}
+
+	return keys, nil
+}
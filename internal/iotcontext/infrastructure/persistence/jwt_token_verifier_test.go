@@ -0,0 +1,170 @@
+package persistence
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func signTestJWT(t *testing.T, header map[string]interface{}, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	t.Helper()
+
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(header) + "." + encode(claims)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func TestJWTTokenVerifier(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jwks := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "idp-1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}}
+	jwksData, _ := json.Marshal(jwks)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwksData, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifier, err := NewJWTTokenVerifier(JWTOptions{
+		HMACSecret: "s3cret",
+		JWKSFile:   jwksFile,
+		Issuer:     "https://idp.example.com",
+		Audience:   "iot-api",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signHS256 := func(secret string) func([]byte) []byte {
+		return func(signed []byte) []byte {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(signed)
+			return mac.Sum(nil)
+		}
+	}
+
+	signRS256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return signature
+	}
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		values := map[string]interface{}{
+			"sub":       "alice",
+			"iss":       "https://idp.example.com",
+			"aud":       []string{"iot-api", "other"},
+			"exp":       now.Add(time.Hour).Unix(),
+			"role":      "operator",
+			"tenant_id": "acme",
+		}
+		for key, value := range overrides {
+			if value == nil {
+				delete(values, key)
+				continue
+			}
+			values[key] = value
+		}
+		return values
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs256 := map[string]interface{}{"alg": "RS256", "kid": "idp-1"}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedTenant domain.TenantID
+		expectError    bool
+	}{
+		{name: "HS256", token: signTestJWT(t, hs256, claims(nil), signHS256("s3cret")), expectedTenant: "acme"},
+		{name: "RS256", token: signTestJWT(t, rs256, claims(nil), signRS256), expectedTenant: "acme"},
+		{name: "without tenant", token: signTestJWT(t, rs256, claims(map[string]interface{}{"tenant_id": nil}), signRS256), expectedTenant: domain.DefaultTenant},
+		{name: "for the deployment", token: signTestJWT(t, rs256, claims(map[string]interface{}{"tenant_id": "*"}), signRS256), expectedTenant: ""},
+		{name: "single audience", token: signTestJWT(t, hs256, claims(map[string]interface{}{"aud": "iot-api"}), signHS256("s3cret")), expectedTenant: "acme"},
+		{name: "wrong secret", token: signTestJWT(t, hs256, claims(nil), signHS256("guess")), expectError: true},
+		{name: "unknown kid", token: signTestJWT(t, map[string]interface{}{"alg": "RS256", "kid": "idp-2"}, claims(nil), signRS256), expectError: true},
+		{name: "alg none", token: signTestJWT(t, map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), expectError: true},
+		{name: "expired", token: signTestJWT(t, hs256, claims(map[string]interface{}{"exp": now.Unix()}), signHS256("s3cret")), expectError: true},
+		{name: "without expiry", token: signTestJWT(t, hs256, claims(map[string]interface{}{"exp": nil}), signHS256("s3cret")), expectError: true},
+		{name: "not yet valid", token: signTestJWT(t, hs256, claims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), signHS256("s3cret")), expectError: true},
+		{name: "other issuer", token: signTestJWT(t, hs256, claims(map[string]interface{}{"iss": "https://evil.example.com"}), signHS256("s3cret")), expectError: true},
+		{name: "other audience", token: signTestJWT(t, hs256, claims(map[string]interface{}{"aud": "billing"}), signHS256("s3cret")), expectError: true},
+		{name: "unknown role", token: signTestJWT(t, hs256, claims(map[string]interface{}{"role": "root"}), signHS256("s3cret")), expectError: true},
+		{name: "malformed", token: "not.a-token", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tt.token, now)
+
+			if tt.expectError {
+				if !errors.Is(err, domain.ErrAuthenticationFailed) {
+					t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if principal.Subject != "alice" || principal.Role != domain.RoleOperator || principal.TenantID != tt.expectedTenant {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTTokenVerifier_RejectsUnconfiguredAlgorithm(t *testing.T) {
+	verifier, err := NewJWTTokenVerifier(JWTOptions{HMACSecret: "s3cret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token := signTestJWT(t,
+		map[string]interface{}{"alg": "RS256"},
+		map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "role": "admin"},
+		func([]byte) []byte { return []byte("forged") },
+	)
+
+	if _, err := verifier.Verify(token, time.Now()); !errors.Is(err, domain.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+
+	if _, err := NewJWTTokenVerifier(JWTOptions{}); err == nil {
+		t.Error("expected error but got none")
+	}
+}
@@ -0,0 +1,191 @@
+package persistence
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	caCertificateFile = "ca.pem"
+	caPrivateKeyFile  = "ca-key.pem"
+	caValidity        = 10 * 365 * 24 * time.Hour
+)
+
+// LocalCertificateAuthority signs device client certificates with a CA kept
+// on disk. The CA is created the first time the directory is opened; losing
+// its key means every issued certificate has to be reissued.
+type LocalCertificateAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         string
+	validity    time.Duration
+}
+
+func OpenLocalCertificateAuthority(dir string, validity time.Duration) (*LocalCertificateAuthority, error) {
+	if validity <= 0 {
+		return nil, errors.New("certificate validity must be positive")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	certPath := filepath.Join(dir, caCertificateFile)
+	keyPath := filepath.Join(dir, caPrivateKeyFile)
+
+	if _, err := os.Stat(certPath); errors.Is(err, os.ErrNotExist) {
+		if err := createCertificateAuthority(certPath, keyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("certificate authority in %s is not PEM encoded", dir)
+	}
+
+	certificate, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalCertificateAuthority{
+		certificate: certificate,
+		key:         key,
+		pem:         string(certPEM),
+		validity:    validity,
+	}, nil
+}
+
+func createCertificateAuthority(certPath string, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "iot-sensor-app device CA"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
+
+// Issue creates a key pair for the device and a client certificate whose
+// common name is the device id.
+func (ca *LocalCertificateAuthority) Issue(deviceID domain.DeviceID, now time.Time) (*domain.IssuedCertificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	// Certificates only carry whole seconds.
+	now = now.UTC().Truncate(time.Second)
+	notAfter := now.Add(ca.validity)
+	if notAfter.After(ca.certificate.NotAfter) {
+		notAfter = ca.certificate.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: string(deviceID)},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := sha256.Sum256(der)
+
+	return &domain.IssuedCertificate{
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKeyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		NotAfter:       notAfter,
+	}, nil
+}
+
+func (ca *LocalCertificateAuthority) CertificatePEM() string {
+	return ca.pem
+}
+
+// CertPool is the pool the TLS server verifies client certificates against.
+func (ca *LocalCertificateAuthority) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+
+	return pool
+}
+
+func randomSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestLocalCertificateAuthority(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := OpenLocalCertificateAuthority(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	issued, err := ca.Issue("device-1", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pair, err := tls.X509KeyPair([]byte(issued.CertificatePEM), []byte(issued.PrivateKeyPEM))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if certificate.Subject.CommonName != "device-1" {
+		t.Errorf("expected the device id as common name, got %q", certificate.Subject.CommonName)
+	}
+
+	fingerprint := sha256.Sum256(certificate.Raw)
+	if issued.Fingerprint != hex.EncodeToString(fingerprint[:]) {
+		t.Errorf("expected the sha256 of the certificate, got %s", issued.Fingerprint)
+	}
+
+	if !issued.NotAfter.Equal(certificate.NotAfter) || issued.NotAfter.Sub(now) > 24*time.Hour {
+		t.Errorf("unexpected expiry %s", issued.NotAfter)
+	}
+
+	reopened, err := OpenLocalCertificateAuthority(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reopened.CertificatePEM() != ca.CertificatePEM() {
+		t.Errorf("expected reopening to keep the same CA")
+	}
+
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:     reopened.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	other, err := OpenLocalCertificateAuthority(t.TempDir(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := certificate.Verify(x509.VerifyOptions{Roots: other.CertPool()}); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
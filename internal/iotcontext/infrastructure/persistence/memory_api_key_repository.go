@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryAPIKeyRepository struct {
+	keys map[domain.APIKeyID]*APIKeyModel
+	mu   sync.RWMutex
+}
+
+func NewInMemoryAPIKeyRepository() domain.APIKeyRepository {
+	return &InMemoryAPIKeyRepository{
+		keys: make(map[domain.APIKeyID]*APIKeyModel),
+	}
+}
+
+func (r *InMemoryAPIKeyRepository) Save(key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = marshalAPIKey(key)
+
+	return nil
+}
+
+func (r *InMemoryAPIKeyRepository) Update(key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[key.ID]; !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	r.keys[key.ID] = marshalAPIKey(key)
+
+	return nil
+}
+
+func (r *InMemoryAPIKeyRepository) FindByID(id domain.APIKeyID) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.keys[id]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return unmarshalAPIKey(model), nil
+}
+
+func (r *InMemoryAPIKeyRepository) FindAll() ([]*domain.APIKey, error) {
+	return r.find(func(*APIKeyModel) bool { return true }), nil
+}
+
+func (r *InMemoryAPIKeyRepository) FindByTenant(tenant domain.TenantID) ([]*domain.APIKey, error) {
+	return r.find(func(model *APIKeyModel) bool { return model.TenantID == string(tenant) }), nil
+}
+
+func (r *InMemoryAPIKeyRepository) find(match func(model *APIKeyModel) bool) []*domain.APIKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*domain.APIKey, 0)
+	for _, model := range r.keys {
+		if match(model) {
+			keys = append(keys, unmarshalAPIKey(model))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys
+}
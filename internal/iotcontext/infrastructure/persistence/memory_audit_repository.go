@@ -0,0 +1,61 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryAuditRepository struct {
+	entries []*AuditEntryModel
+	mu      sync.RWMutex
+}
+
+func NewInMemoryAuditRepository() domain.AuditRepository {
+	return &InMemoryAuditRepository{}
+}
+
+func (r *InMemoryAuditRepository) Append(entry *domain.AuditEntry) error {
+	model, err := marshalAuditEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, model)
+
+	return nil
+}
+
+func (r *InMemoryAuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]*domain.AuditEntry, 0)
+	for _, model := range r.entries {
+		entry, err := unmarshalAuditEntry(model)
+		if err != nil {
+			return nil, err
+		}
+
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].At.Equal(entries[j].At) {
+			return entries[i].ID > entries[j].ID
+		}
+
+		return entries[i].At.After(entries[j].At)
+	})
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryCampaignRepository struct {
+	campaigns map[domain.CampaignID]*CampaignModel
+	updates   map[domain.CampaignID]map[domain.DeviceID]*DeviceUpdateModel
+	mu        sync.RWMutex
+}
+
+func NewInMemoryCampaignRepository() domain.CampaignRepository {
+	return &InMemoryCampaignRepository{
+		campaigns: make(map[domain.CampaignID]*CampaignModel),
+		updates:   make(map[domain.CampaignID]map[domain.DeviceID]*DeviceUpdateModel),
+	}
+}
+
+func (r *InMemoryCampaignRepository) Save(campaign *domain.Campaign, updates []*domain.DeviceUpdate) error {
+	model, err := marshalCampaign(campaign)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.campaigns[campaign.ID] = model
+
+	byDevice := make(map[domain.DeviceID]*DeviceUpdateModel, len(updates))
+	for _, update := range updates {
+		byDevice[update.DeviceID] = marshalDeviceUpdate(update)
+	}
+	r.updates[campaign.ID] = byDevice
+
+	return nil
+}
+
+func (r *InMemoryCampaignRepository) Update(campaign *domain.Campaign) error {
+	model, err := marshalCampaign(campaign)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.campaigns[campaign.ID]; !ok {
+		return domain.ErrCampaignNotFound
+	}
+
+	r.campaigns[campaign.ID] = model
+
+	return nil
+}
+
+func (r *InMemoryCampaignRepository) FindByID(id domain.CampaignID) (*domain.Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.campaigns[id]
+	if !ok {
+		return nil, domain.ErrCampaignNotFound
+	}
+
+	return unmarshalCampaign(model)
+}
+
+func (r *InMemoryCampaignRepository) FindAll() ([]*domain.Campaign, error) {
+	models := r.find(func(model *CampaignModel) bool { return true })
+
+	sort.Slice(models, func(i, j int) bool {
+		return campaignModelBefore(models[j], models[i])
+	})
+
+	return unmarshalCampaigns(models)
+}
+
+func (r *InMemoryCampaignRepository) FindActive() ([]*domain.Campaign, error) {
+	models := r.find(func(model *CampaignModel) bool {
+		return model.Status == string(domain.CampaignRunning) || model.Status == string(domain.CampaignPaused)
+	})
+
+	sort.Slice(models, func(i, j int) bool {
+		return campaignModelBefore(models[i], models[j])
+	})
+
+	return unmarshalCampaigns(models)
+}
+
+func (r *InMemoryCampaignRepository) FindDeviceUpdates(campaignID domain.CampaignID) ([]*domain.DeviceUpdate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	updates := make([]*domain.DeviceUpdate, 0, len(r.updates[campaignID]))
+	for _, model := range r.updates[campaignID] {
+		updates = append(updates, unmarshalDeviceUpdate(model))
+	}
+
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Position < updates[j].Position
+	})
+
+	return updates, nil
+}
+
+func (r *InMemoryCampaignRepository) FindDeviceUpdate(campaignID domain.CampaignID, deviceID domain.DeviceID) (*domain.DeviceUpdate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.updates[campaignID][deviceID]
+	if !ok {
+		return nil, domain.ErrDeviceUpdateNotFound
+	}
+
+	return unmarshalDeviceUpdate(model), nil
+}
+
+func (r *InMemoryCampaignRepository) UpdateDeviceUpdate(update *domain.DeviceUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.updates[update.CampaignID][update.DeviceID]; !ok {
+		return domain.ErrDeviceUpdateNotFound
+	}
+
+	r.updates[update.CampaignID][update.DeviceID] = marshalDeviceUpdate(update)
+
+	return nil
+}
+
+func (r *InMemoryCampaignRepository) find(match func(model *CampaignModel) bool) []CampaignModel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var models []CampaignModel
+	for _, model := range r.campaigns {
+		if match(model) {
+			models = append(models, *model)
+		}
+	}
+
+	return models
+}
+
+func campaignModelBefore(a, b CampaignModel) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID < b.ID
+	}
+
+	return a.CreatedAt.Before(b.CreatedAt)
+}
@@ -0,0 +1,41 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+	"time"
+)
+
+type InMemoryClaimTokenRepository struct {
+	tokens map[string]*ClaimTokenModel
+	mu     sync.Mutex
+}
+
+func NewInMemoryClaimTokenRepository() domain.ClaimTokenRepository {
+	return &InMemoryClaimTokenRepository{
+		tokens: make(map[string]*ClaimTokenModel),
+	}
+}
+
+func (r *InMemoryClaimTokenRepository) Save(token *domain.ClaimToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.TokenHash] = marshalClaimToken(token)
+
+	return nil
+}
+
+func (r *InMemoryClaimTokenRepository) Redeem(tokenHash string, now time.Time) (*domain.ClaimToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	model, ok := r.tokens[tokenHash]
+	if !ok || model.Uses >= model.MaxUses || !now.Before(model.ExpiresAt) {
+		return nil, domain.ErrClaimTokenRejected
+	}
+
+	model.Uses++
+
+	return unmarshalClaimToken(model), nil
+}
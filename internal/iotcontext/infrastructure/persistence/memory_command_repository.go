@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryCommandRepository struct {
+	commands map[domain.CommandID]*CommandModel
+	mu       sync.RWMutex
+}
+
+func NewInMemoryCommandRepository() domain.CommandRepository {
+	return &InMemoryCommandRepository{
+		commands: make(map[domain.CommandID]*CommandModel),
+	}
+}
+
+func (r *InMemoryCommandRepository) Save(command *domain.Command) error {
+	model, err := marshalCommand(command)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.commands[command.ID]; ok {
+		return domain.ErrCommandAlreadyExists
+	}
+
+	r.commands[command.ID] = model
+
+	return nil
+}
+
+func (r *InMemoryCommandRepository) Update(command *domain.Command) error {
+	model, err := marshalCommand(command)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.commands[command.ID]; !ok {
+		return domain.ErrCommandNotFound
+	}
+
+	r.commands[command.ID] = model
+
+	return nil
+}
+
+func (r *InMemoryCommandRepository) FindByID(id domain.CommandID) (*domain.Command, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.commands[id]
+	if !ok {
+		return nil, domain.ErrCommandNotFound
+	}
+
+	return unmarshalCommand(model)
+}
+
+func (r *InMemoryCommandRepository) FindByDeviceID(deviceID domain.DeviceID, limit int) ([]*domain.Command, error) {
+	models := r.find(func(model *CommandModel) bool {
+		return model.DeviceID == string(deviceID)
+	})
+
+	sort.Slice(models, func(i, j int) bool {
+		return commandModelBefore(models[j], models[i])
+	})
+
+	if limit > 0 && len(models) > limit {
+		models = models[:limit]
+	}
+
+	return unmarshalCommands(models)
+}
+
+func (r *InMemoryCommandRepository) FindOpen() ([]*domain.Command, error) {
+	models := r.find(func(model *CommandModel) bool {
+		return model.Status == string(domain.CommandPending) || model.Status == string(domain.CommandDelivered)
+	})
+
+	sort.Slice(models, func(i, j int) bool {
+		return commandModelBefore(models[i], models[j])
+	})
+
+	return unmarshalCommands(models)
+}
+
+func (r *InMemoryCommandRepository) find(match func(model *CommandModel) bool) []CommandModel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var models []CommandModel
+	for _, model := range r.commands {
+		if match(model) {
+			models = append(models, *model)
+		}
+	}
+
+	return models
+}
+
+func commandModelBefore(a, b CommandModel) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID < b.ID
+	}
+
+	return a.CreatedAt.Before(b.CreatedAt)
+}
@@ -0,0 +1,29 @@
+package persistence
+
+import "testing"
+
+func TestInMemoryRepositories_Contract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) repositorySet {
+		readings := NewInMemorySensorReadingRepository()
+
+		return repositorySet{
+			devices:     NewInMemoryDeviceRepository(),
+			sensors:     NewInMemorySensorRepository(),
+			readings:    readings,
+			rollups:     NewInMemoryReadingRollupRepository(),
+			retention:   NewInMemorySensorReadingRetentionRepository(readings),
+			twins:       NewInMemoryTwinRepository(),
+			commands:    NewInMemoryCommandRepository(),
+			firmwares:   NewInMemoryFirmwareRepository(),
+			campaigns:   NewInMemoryCampaignRepository(),
+			credentials: NewInMemoryCredentialRepository(),
+			claimTokens: NewInMemoryClaimTokenRepository(),
+			groups:      NewInMemoryGroupRepository(),
+			locations:   NewInMemoryLocationRepository(),
+			apiKeys:     NewInMemoryAPIKeyRepository(),
+			audit:       NewInMemoryAuditRepository(),
+			configs:     NewInMemorySensorConfigHistoryRepository(),
+			presence:    NewInMemoryPresenceRepository(),
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryCredentialRepository struct {
+	credentials map[domain.CredentialID]*CredentialModel
+	mu          sync.RWMutex
+}
+
+func NewInMemoryCredentialRepository() domain.CredentialRepository {
+	return &InMemoryCredentialRepository{
+		credentials: make(map[domain.CredentialID]*CredentialModel),
+	}
+}
+
+func (r *InMemoryCredentialRepository) Save(credential *domain.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.credentials[credential.ID] = marshalCredential(credential)
+
+	return nil
+}
+
+func (r *InMemoryCredentialRepository) Update(credential *domain.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.credentials[credential.ID]; !ok {
+		return domain.ErrCredentialNotFound
+	}
+
+	r.credentials[credential.ID] = marshalCredential(credential)
+
+	return nil
+}
+
+func (r *InMemoryCredentialRepository) FindByID(id domain.CredentialID) (*domain.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.credentials[id]
+	if !ok {
+		return nil, domain.ErrCredentialNotFound
+	}
+
+	return unmarshalCredential(model), nil
+}
+
+func (r *InMemoryCredentialRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credentials := make([]*domain.Credential, 0)
+	for _, model := range r.credentials {
+		if model.DeviceID == string(deviceID) {
+			credentials = append(credentials, unmarshalCredential(model))
+		}
+	}
+
+	sort.Slice(credentials, func(i, j int) bool {
+		if credentials[i].CreatedAt.Equal(credentials[j].CreatedAt) {
+			return credentials[i].ID < credentials[j].ID
+		}
+
+		return credentials[i].CreatedAt.Before(credentials[j].CreatedAt)
+	})
+
+	return credentials, nil
+}
+
+func (r *InMemoryCredentialRepository) FindByFingerprint(fingerprint string) (*domain.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if fingerprint == "" {
+		return nil, domain.ErrCredentialNotFound
+	}
+
+	for _, model := range r.credentials {
+		if model.Fingerprint == fingerprint {
+			return unmarshalCredential(model), nil
+		}
+	}
+
+	return nil, domain.ErrCredentialNotFound
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+// InMemoryDeviceRepository keeps tombstones for deleted devices so that, as
+// with the SQL soft delete, their ids cannot be reused.
+type InMemoryDeviceRepository struct {
+	devices map[domain.DeviceID]domain.Device
+	deleted map[domain.DeviceID]bool
+	mu      sync.RWMutex
+}
+
+func NewInMemoryDeviceRepository() domain.DeviceRepository {
+	return &InMemoryDeviceRepository{
+		devices: make(map[domain.DeviceID]domain.Device),
+		deleted: make(map[domain.DeviceID]bool),
+	}
+}
+
+func (r *InMemoryDeviceRepository) Save(device *domain.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.devices[device.ID]; ok || r.deleted[device.ID] {
+		return domain.ErrDeviceAlreadyExists
+	}
+
+	r.devices[device.ID] = cloneDevice(*device)
+
+	return nil
+}
+
+func (r *InMemoryDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	device, ok := r.devices[id]
+	if !ok {
+		return domain.Device{}, domain.ErrDeviceNotFound
+	}
+
+	return cloneDevice(device), nil
+}
+
+func (r *InMemoryDeviceRepository) FindByIDs(ids []domain.DeviceID) ([]domain.Device, error) {
+	wanted := make(map[domain.DeviceID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return r.find(func(device domain.Device) bool { return wanted[device.ID] }), nil
+}
+
+func (r *InMemoryDeviceRepository) FindAll() ([]domain.Device, error) {
+	return r.find(func(domain.Device) bool { return true }), nil
+}
+
+func (r *InMemoryDeviceRepository) FindByTenant(tenant domain.TenantID) ([]domain.Device, error) {
+	return r.find(func(device domain.Device) bool { return device.TenantID == tenant }), nil
+}
+
+func (r *InMemoryDeviceRepository) find(match func(device domain.Device) bool) []domain.Device {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var devices []domain.Device
+	for _, device := range r.devices {
+		if match(device) {
+			devices = append(devices, cloneDevice(device))
+		}
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].CreatedAt.Equal(devices[j].CreatedAt) {
+			return devices[i].CreatedAt.Before(devices[j].CreatedAt)
+		}
+		return devices[i].ID < devices[j].ID
+	})
+
+	return devices
+}
+
+func (r *InMemoryDeviceRepository) Update(device *domain.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.devices[device.ID]
+	if !ok {
+		return domain.ErrDeviceNotFound
+	}
+
+	if stored.Version != device.Version {
+		return domain.ErrDeviceVersionConflict
+	}
+
+	device.Version++
+	r.devices[device.ID] = cloneDevice(*device)
+
+	return nil
+}
+
+func (r *InMemoryDeviceRepository) Delete(id domain.DeviceID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.devices[id]; !ok {
+		return domain.ErrDeviceNotFound
+	}
+
+	delete(r.devices, id)
+	r.deleted[id] = true
+
+	return nil
+}
+
+// cloneDevice keeps callers from changing the stored labels in place.
+func cloneDevice(device domain.Device) domain.Device {
+	device.Labels = device.Labels.Copy()
+	if device.Geo != nil {
+		geo := *device.Geo
+		device.Geo = &geo
+	}
+
+	return device
+}
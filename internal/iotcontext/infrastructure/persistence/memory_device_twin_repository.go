@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+)
+
+type InMemoryTwinRepository struct {
+	twins map[domain.DeviceID]*DeviceTwinModel
+	mu    sync.RWMutex
+}
+
+func NewInMemoryTwinRepository() domain.TwinRepository {
+	return &InMemoryTwinRepository{
+		twins: make(map[domain.DeviceID]*DeviceTwinModel),
+	}
+}
+
+func (r *InMemoryTwinRepository) FindByDeviceID(deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.twins[deviceID]
+	if !ok {
+		return nil, domain.ErrTwinNotFound
+	}
+
+	return unmarshalTwin(model)
+}
+
+// Save stores the serialized twin so callers never share maps with the
+// repository.
+func (r *InMemoryTwinRepository) Save(twin *domain.DeviceTwin, previousVersion int64) error {
+	model, err := marshalTwin(twin)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.twins[twin.DeviceID]
+	if (!ok && previousVersion != 0) || (ok && current.Version != previousVersion) {
+		return domain.ErrTwinVersionConflict
+	}
+
+	r.twins[twin.DeviceID] = model
+
+	return nil
+}
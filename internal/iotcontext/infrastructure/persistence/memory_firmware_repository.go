@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryFirmwareRepository struct {
+	firmwares map[domain.FirmwareID]*FirmwareModel
+	mu        sync.RWMutex
+}
+
+func NewInMemoryFirmwareRepository() domain.FirmwareRepository {
+	return &InMemoryFirmwareRepository{
+		firmwares: make(map[domain.FirmwareID]*FirmwareModel),
+	}
+}
+
+func (r *InMemoryFirmwareRepository) Save(firmware *domain.Firmware) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, model := range r.firmwares {
+		if id == firmware.ID || (model.DeviceType == firmware.DeviceType && model.Version == firmware.Version) {
+			return domain.ErrFirmwareAlreadyExists
+		}
+	}
+
+	r.firmwares[firmware.ID] = marshalFirmware(firmware)
+
+	return nil
+}
+
+func (r *InMemoryFirmwareRepository) FindByID(id domain.FirmwareID) (*domain.Firmware, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.firmwares[id]
+	if !ok {
+		return nil, domain.ErrFirmwareNotFound
+	}
+
+	return unmarshalFirmware(model), nil
+}
+
+func (r *InMemoryFirmwareRepository) FindAll() ([]*domain.Firmware, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	firmwares := make([]*domain.Firmware, 0, len(r.firmwares))
+	for _, model := range r.firmwares {
+		firmwares = append(firmwares, unmarshalFirmware(model))
+	}
+
+	sort.Slice(firmwares, func(i, j int) bool {
+		if firmwares[i].CreatedAt.Equal(firmwares[j].CreatedAt) {
+			return firmwares[i].ID > firmwares[j].ID
+		}
+
+		return firmwares[i].CreatedAt.After(firmwares[j].CreatedAt)
+	})
+
+	return firmwares, nil
+}
@@ -0,0 +1,103 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryGroupRepository struct {
+	groups map[domain.GroupID]*DeviceGroupModel
+	mu     sync.RWMutex
+}
+
+func NewInMemoryGroupRepository() domain.GroupRepository {
+	return &InMemoryGroupRepository{
+		groups: make(map[domain.GroupID]*DeviceGroupModel),
+	}
+}
+
+func (r *InMemoryGroupRepository) Save(group *domain.DeviceGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, model := range r.groups {
+		if id == group.ID || (model.Name == group.Name && model.TenantID == string(group.TenantID)) {
+			return domain.ErrGroupAlreadyExists
+		}
+	}
+
+	r.groups[group.ID] = marshalGroup(group)
+
+	return nil
+}
+
+func (r *InMemoryGroupRepository) Update(group *domain.DeviceGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[group.ID]; !ok {
+		return domain.ErrGroupNotFound
+	}
+
+	for id, model := range r.groups {
+		if id != group.ID && model.Name == group.Name && model.TenantID == string(group.TenantID) {
+			return domain.ErrGroupAlreadyExists
+		}
+	}
+
+	r.groups[group.ID] = marshalGroup(group)
+
+	return nil
+}
+
+func (r *InMemoryGroupRepository) Delete(id domain.GroupID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[id]; !ok {
+		return domain.ErrGroupNotFound
+	}
+
+	delete(r.groups, id)
+
+	return nil
+}
+
+func (r *InMemoryGroupRepository) FindByID(id domain.GroupID) (*domain.DeviceGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.groups[id]
+	if !ok {
+		return nil, domain.ErrGroupNotFound
+	}
+
+	return unmarshalGroup(model), nil
+}
+
+func (r *InMemoryGroupRepository) FindAll() ([]*domain.DeviceGroup, error) {
+	return r.find(func(*DeviceGroupModel) bool { return true }), nil
+}
+
+func (r *InMemoryGroupRepository) FindByTenant(tenant domain.TenantID) ([]*domain.DeviceGroup, error) {
+	return r.find(func(model *DeviceGroupModel) bool { return model.TenantID == string(tenant) }), nil
+}
+
+func (r *InMemoryGroupRepository) find(match func(model *DeviceGroupModel) bool) []*domain.DeviceGroup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make([]*domain.DeviceGroup, 0, len(r.groups))
+	for _, model := range r.groups {
+		if match(model) {
+			groups = append(groups, unmarshalGroup(model))
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemoryLocationRepository struct {
+	locations map[domain.LocationID]*LocationModel
+	mu        sync.RWMutex
+}
+
+func NewInMemoryLocationRepository() domain.LocationRepository {
+	return &InMemoryLocationRepository{
+		locations: make(map[domain.LocationID]*LocationModel),
+	}
+}
+
+func (r *InMemoryLocationRepository) Save(location *domain.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.locations[location.ID]; ok {
+		return domain.ErrLocationAlreadyExists
+	}
+
+	r.locations[location.ID] = marshalLocation(cloneLocation(location))
+
+	return nil
+}
+
+func (r *InMemoryLocationRepository) Update(location *domain.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.locations[location.ID]; !ok {
+		return domain.ErrLocationNotFound
+	}
+
+	r.locations[location.ID] = marshalLocation(cloneLocation(location))
+
+	return nil
+}
+
+// Delete refuses to remove a parent, as the foreign key does in SQL.
+func (r *InMemoryLocationRepository) Delete(id domain.LocationID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.locations[id]; !ok {
+		return domain.ErrLocationNotFound
+	}
+
+	for _, model := range r.locations {
+		if model.ParentID != nil && *model.ParentID == string(id) {
+			return domain.ErrLocationInUse
+		}
+	}
+
+	delete(r.locations, id)
+
+	return nil
+}
+
+func (r *InMemoryLocationRepository) FindByID(id domain.LocationID) (*domain.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.locations[id]
+	if !ok {
+		return nil, domain.ErrLocationNotFound
+	}
+
+	return unmarshalLocation(model), nil
+}
+
+func (r *InMemoryLocationRepository) FindAll() ([]*domain.Location, error) {
+	return r.find(func(*LocationModel) bool { return true }), nil
+}
+
+func (r *InMemoryLocationRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Location, error) {
+	return r.find(func(model *LocationModel) bool { return model.TenantID == string(tenant) }), nil
+}
+
+func (r *InMemoryLocationRepository) find(match func(model *LocationModel) bool) []*domain.Location {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	locations := make([]*domain.Location, 0, len(r.locations))
+	for _, model := range r.locations {
+		if match(model) {
+			locations = append(locations, unmarshalLocation(model))
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Name != locations[j].Name {
+			return locations[i].Name < locations[j].Name
+		}
+		return locations[i].ID < locations[j].ID
+	})
+
+	return locations
+}
+
+// cloneLocation copies the positions so the stored model does not point into
+// the caller's location.
+func cloneLocation(location *domain.Location) *domain.Location {
+	clone := *location
+	if location.Geo != nil {
+		geo := *location.Geo
+		clone.Geo = &geo
+	}
+	if location.Indoor != nil {
+		indoor := *location.Indoor
+		clone.Indoor = &indoor
+	}
+
+	return &clone
+}
@@ -0,0 +1,51 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+	"time"
+)
+
+// InMemoryPresenceRepository keeps last-seen times in the process, for the
+// memory storage driver: after a restart every device looks offline until it
+// reports again.
+type InMemoryPresenceRepository struct {
+	lastSeen map[domain.DeviceID]time.Time
+	mu       sync.RWMutex
+}
+
+func NewInMemoryPresenceRepository() domain.PresenceRepository {
+	return &InMemoryPresenceRepository{
+		lastSeen: make(map[domain.DeviceID]time.Time),
+	}
+}
+
+func (r *InMemoryPresenceRepository) Touch(deviceID domain.DeviceID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if at.After(r.lastSeen[deviceID]) {
+		r.lastSeen[deviceID] = at.UTC()
+	}
+
+	return nil
+}
+
+func (r *InMemoryPresenceRepository) LastSeen(deviceID domain.DeviceID) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lastSeen[deviceID], nil
+}
+
+func (r *InMemoryPresenceRepository) All() (map[domain.DeviceID]time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make(map[domain.DeviceID]time.Time, len(r.lastSeen))
+	for deviceID, at := range r.lastSeen {
+		all[deviceID] = at
+	}
+
+	return all, nil
+}
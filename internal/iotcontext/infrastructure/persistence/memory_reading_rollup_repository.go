@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+type rollupKey struct {
+	sensorID   domain.SensorID
+	resolution domain.Resolution
+	bucket     time.Time
+}
+
+type InMemoryReadingRollupRepository struct {
+	aggregates map[rollupKey]domain.ReadingAggregate
+	mu         sync.RWMutex
+}
+
+func NewInMemoryReadingRollupRepository() domain.ReadingRollupRepository {
+	return &InMemoryReadingRollupRepository{
+		aggregates: make(map[rollupKey]domain.ReadingAggregate),
+	}
+}
+
+func (r *InMemoryReadingRollupRepository) Apply(reading domain.SensorReading) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resolution := range domain.RollupResolutions {
+		key := rollupKey{
+			sensorID:   reading.SensorID,
+			resolution: resolution,
+			bucket:     resolution.Truncate(reading.Timestamp),
+		}
+
+		aggregate, ok := r.aggregates[key]
+		if !ok {
+			r.aggregates[key] = domain.NewReadingAggregate(reading, resolution)
+			continue
+		}
+
+		aggregate.Add(reading)
+		r.aggregates[key] = aggregate
+	}
+
+	return nil
+}
+
+func (r *InMemoryReadingRollupRepository) FindAggregates(sensorID domain.SensorID, resolution domain.Resolution, from, to time.Time) ([]domain.ReadingAggregate, error) {
+	if resolution.Duration() == 0 {
+		return nil, fmt.Errorf("no rollup for resolution %q", resolution)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start := resolution.Truncate(from)
+	aggregates := []domain.ReadingAggregate{}
+	for key, aggregate := range r.aggregates {
+		if key.sensorID != sensorID || key.resolution != resolution {
+			continue
+		}
+		if key.bucket.Before(start) || !key.bucket.Before(to) {
+			continue
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregates[i].Bucket.Before(aggregates[j].Bucket)
+	})
+
+	return aggregates, nil
+}
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+)
+
+// InMemorySensorConfigHistoryRepository keeps the revisions of every sensor
+// in the order they were appended, which is also the order of their numbers.
+type InMemorySensorConfigHistoryRepository struct {
+	revisions map[domain.SensorID][]*SensorConfigRevisionModel
+	mu        sync.RWMutex
+}
+
+func NewInMemorySensorConfigHistoryRepository() domain.SensorConfigHistoryRepository {
+	return &InMemorySensorConfigHistoryRepository{
+		revisions: make(map[domain.SensorID][]*SensorConfigRevisionModel),
+	}
+}
+
+func (r *InMemorySensorConfigHistoryRepository) Append(revision *domain.SensorConfigRevision) error {
+	model, err := marshalConfigRevision(revision)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := r.revisions[revision.SensorID]
+	if len(history) > 0 && history[len(history)-1].Revision >= revision.Revision {
+		return domain.ErrSensorConfigConflict
+	}
+
+	r.revisions[revision.SensorID] = append(history, model)
+
+	return nil
+}
+
+func (r *InMemorySensorConfigHistoryRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]*domain.SensorConfigRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.revisions[sensorID]
+	revisions := make([]*domain.SensorConfigRevision, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		if limit > 0 && len(revisions) == limit {
+			break
+		}
+
+		revision, err := unmarshalConfigRevision(history[i])
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (r *InMemorySensorConfigHistoryRepository) FindRevision(sensorID domain.SensorID, revision int64) (*domain.SensorConfigRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, model := range r.revisions[sensorID] {
+		if model.Revision == revision {
+			return unmarshalConfigRevision(model)
+		}
+	}
+
+	return nil, domain.ErrSensorConfigRevisionNotFound
+}
@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemorySensorReadingRepository struct {
+	readings map[domain.SensorID][]domain.SensorReading
+	mu       sync.RWMutex
+}
+
+func NewInMemorySensorReadingRepository() *InMemorySensorReadingRepository {
+	return &InMemorySensorReadingRepository{
+		readings: make(map[domain.SensorID][]domain.SensorReading),
+	}
+}
+
+func (r *InMemorySensorReadingRepository) Save(reading *domain.SensorReading) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reading.ID == "" {
+		reading.ID = uuid.New().String()
+	}
+
+	stored := *reading
+	stored.Meta = cloneMeta(reading.Meta)
+
+	// Keep each sensor's readings sorted by timestamp so range queries can
+	// walk them in order.
+	readings := r.readings[reading.SensorID]
+	i := sort.Search(len(readings), func(i int) bool {
+		return readings[i].Timestamp.After(stored.Timestamp)
+	})
+	readings = append(readings, domain.SensorReading{})
+	copy(readings[i+1:], readings[i:])
+	readings[i] = stored
+	r.readings[reading.SensorID] = readings
+
+	return nil
+}
+
+func (r *InMemorySensorReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.readings[sensorID]
+
+	var readings []domain.SensorReading
+	for i := len(stored) - 1; i >= 0; i-- {
+		if limit > 0 && len(readings) == limit {
+			break
+		}
+		readings = append(readings, stored[i])
+	}
+
+	return readings, nil
+}
+
+func (r *InMemorySensorReadingRepository) FindBySensorIDBetween(sensorID domain.SensorID, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var readings []domain.SensorReading
+	for _, reading := range r.readings[sensorID] {
+		if reading.Timestamp.Before(from) {
+			continue
+		}
+		if !reading.Timestamp.Before(to) {
+			break
+		}
+		if limit > 0 && len(readings) == limit {
+			break
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
+
+func (r *InMemorySensorReadingRepository) FindLatestBySensorIDs(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var readings []domain.SensorReading
+	for _, sensorID := range sensorIDs {
+		if stored := r.readings[sensorID]; len(stored) > 0 {
+			readings = append(readings, stored[len(stored)-1])
+		}
+	}
+
+	return readings, nil
+}
+
+func (r *InMemorySensorReadingRepository) deleteBefore(scope domain.RetentionScope, cutoff time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for sensorID, stored := range r.readings {
+		kept := stored[:0]
+		for _, reading := range stored {
+			if reading.Timestamp.Before(cutoff) && scope.Matches(reading) {
+				deleted++
+				continue
+			}
+			kept = append(kept, reading)
+		}
+		r.readings[sensorID] = kept
+	}
+
+	return deleted
+}
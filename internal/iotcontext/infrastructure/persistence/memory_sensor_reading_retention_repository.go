@@ -0,0 +1,32 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+// InMemorySensorReadingRetentionRepository applies row retention to the
+// in-memory readings. There is no physical partitioning to manage.
+type InMemorySensorReadingRetentionRepository struct {
+	readings *InMemorySensorReadingRepository
+}
+
+func NewInMemorySensorReadingRetentionRepository(readings *InMemorySensorReadingRepository) domain.SensorReadingRetentionRepository {
+	return &InMemorySensorReadingRetentionRepository{readings: readings}
+}
+
+func (r *InMemorySensorReadingRetentionRepository) ListPartitions() ([]domain.ReadingsPartition, error) {
+	return nil, nil
+}
+
+func (r *InMemorySensorReadingRetentionRepository) CreatePartition(partition domain.ReadingsPartition) error {
+	return nil
+}
+
+func (r *InMemorySensorReadingRetentionRepository) DropPartition(partition domain.ReadingsPartition) error {
+	return nil
+}
+
+func (r *InMemorySensorReadingRetentionRepository) DeleteReadingsBefore(scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	return r.readings.deleteBefore(scope, cutoff), nil
+}
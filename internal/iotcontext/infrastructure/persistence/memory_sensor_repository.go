@@ -0,0 +1,143 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sort"
+	"sync"
+)
+
+type InMemorySensorRepository struct {
+	sensors map[domain.SensorID]domain.Sensor
+	deleted map[domain.SensorID]bool
+	mu      sync.RWMutex
+}
+
+func NewInMemorySensorRepository() domain.SensorRepository {
+	return &InMemorySensorRepository{
+		sensors: make(map[domain.SensorID]domain.Sensor),
+		deleted: make(map[domain.SensorID]bool),
+	}
+}
+
+func (r *InMemorySensorRepository) Save(sensor *domain.Sensor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sensors[sensor.ID]; ok || r.deleted[sensor.ID] {
+		return domain.ErrSensorAlreadyExists
+	}
+
+	r.sensors[sensor.ID] = cloneSensor(*sensor)
+
+	return nil
+}
+
+func (r *InMemorySensorRepository) FindByID(id domain.SensorID) (*domain.Sensor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sensor, ok := r.sensors[id]
+	if !ok {
+		return nil, domain.ErrSensorNotFound
+	}
+
+	clone := cloneSensor(sensor)
+
+	return &clone, nil
+}
+
+func (r *InMemorySensorRepository) FindAll() ([]*domain.Sensor, error) {
+	return r.find(func(*domain.Sensor) bool { return true }), nil
+}
+
+func (r *InMemorySensorRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Sensor, error) {
+	return r.find(func(sensor *domain.Sensor) bool { return sensor.TenantID == tenant }), nil
+}
+
+func (r *InMemorySensorRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	return r.find(func(sensor *domain.Sensor) bool { return sensor.DeviceID == deviceID }), nil
+}
+
+func (r *InMemorySensorRepository) FindByDeviceIDs(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	wanted := make(map[domain.DeviceID]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		wanted[id] = true
+	}
+
+	return r.find(func(sensor *domain.Sensor) bool { return wanted[sensor.DeviceID] }), nil
+}
+
+func (r *InMemorySensorRepository) find(match func(sensor *domain.Sensor) bool) []*domain.Sensor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sensors []*domain.Sensor
+	for _, sensor := range r.sensors {
+		clone := cloneSensor(sensor)
+		if match(&clone) {
+			sensors = append(sensors, &clone)
+		}
+	}
+
+	sort.Slice(sensors, func(i, j int) bool {
+		if !sensors[i].CreatedAt.Equal(sensors[j].CreatedAt) {
+			return sensors[i].CreatedAt.Before(sensors[j].CreatedAt)
+		}
+		return sensors[i].ID < sensors[j].ID
+	})
+
+	return sensors
+}
+
+func (r *InMemorySensorRepository) Update(sensor *domain.Sensor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.sensors[sensor.ID]
+	if !ok {
+		return domain.ErrSensorNotFound
+	}
+
+	if stored.Version != sensor.Version {
+		return domain.ErrSensorVersionConflict
+	}
+
+	sensor.Version++
+	r.sensors[sensor.ID] = cloneSensor(*sensor)
+
+	return nil
+}
+
+func (r *InMemorySensorRepository) Delete(id domain.SensorID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sensors[id]; !ok {
+		return domain.ErrSensorNotFound
+	}
+
+	delete(r.sensors, id)
+	r.deleted[id] = true
+
+	return nil
+}
+
+func cloneSensor(sensor domain.Sensor) domain.Sensor {
+	sensor.Config.Meta = cloneMeta(sensor.Config.Meta)
+	sensor.Labels = sensor.Labels.Copy()
+
+	return sensor
+}
+
+func cloneMeta(meta map[string]interface{}) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+
+	clone := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		clone[k] = v
+	}
+
+	return clone
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sensor_readings_models;
+DROP TABLE IF EXISTS sensor_models;
+DROP TABLE IF EXISTS device_models;
@@ -0,0 +1,38 @@
+CREATE TABLE device_models (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE sensor_models (
+    id UUID PRIMARY KEY,
+    device_id UUID REFERENCES device_models(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    config JSONB,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_sensor_models_device_id ON sensor_models (device_id);
+
+CREATE TABLE sensor_readings_models (
+    id UUID NOT NULL,
+    sensor_id UUID REFERENCES sensor_models(id) ON DELETE CASCADE,
+    device_id UUID REFERENCES device_models(id) ON DELETE CASCADE,
+    type VARCHAR(255),
+    value FLOAT NOT NULL,
+    unit VARCHAR(50),
+    timestamp TIMESTAMP NOT NULL,
+    meta JSONB,
+    PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+-- Range partitions are created ahead of time by the retention job in the app.
+-- The default partition only catches readings outside of every created range.
+CREATE TABLE sensor_readings_models_default PARTITION OF sensor_readings_models DEFAULT;
+
+CREATE INDEX idx_sensor_readings_models_sensor_id_timestamp ON sensor_readings_models (sensor_id, timestamp DESC);
+CREATE INDEX idx_sensor_readings_models_device_id_timestamp ON sensor_readings_models (device_id, timestamp DESC);
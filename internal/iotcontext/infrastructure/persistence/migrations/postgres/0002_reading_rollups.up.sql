@@ -0,0 +1,35 @@
+CREATE TABLE sensor_reading_rollups_1m (
+    sensor_id UUID NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value FLOAT NOT NULL,
+    max_value FLOAT NOT NULL,
+    sum_value FLOAT NOT NULL,
+    count BIGINT NOT NULL,
+    last_value FLOAT NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
+
+CREATE TABLE sensor_reading_rollups_1h (
+    sensor_id UUID NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value FLOAT NOT NULL,
+    max_value FLOAT NOT NULL,
+    sum_value FLOAT NOT NULL,
+    count BIGINT NOT NULL,
+    last_value FLOAT NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
+
+CREATE TABLE sensor_reading_rollups_1d (
+    sensor_id UUID NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value FLOAT NOT NULL,
+    max_value FLOAT NOT NULL,
+    sum_value FLOAT NOT NULL,
+    count BIGINT NOT NULL,
+    last_value FLOAT NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
@@ -0,0 +1,7 @@
+-- Devices created before the lifecycle existed were already in use.
+ALTER TABLE device_models ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'active';
+ALTER TABLE device_models ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE sensor_models ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_device_models_deleted_at ON device_models (deleted_at);
+CREATE INDEX idx_sensor_models_deleted_at ON sensor_models (deleted_at);
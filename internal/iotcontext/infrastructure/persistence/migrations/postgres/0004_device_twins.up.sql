@@ -0,0 +1,9 @@
+CREATE TABLE device_twins (
+    device_id UUID PRIMARY KEY REFERENCES device_models(id) ON DELETE CASCADE,
+    desired JSONB NOT NULL DEFAULT '{}',
+    reported JSONB NOT NULL DEFAULT '{}',
+    desired_version BIGINT NOT NULL DEFAULT 0,
+    reported_version BIGINT NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,17 @@
+CREATE TABLE device_commands (
+    id UUID PRIMARY KEY,
+    device_id UUID NOT NULL REFERENCES device_models(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    params JSONB,
+    status VARCHAR(32) NOT NULL,
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_device_commands_device_id ON device_commands (device_id, created_at);
+CREATE INDEX idx_device_commands_status ON device_commands (status);
@@ -0,0 +1,23 @@
+CREATE TABLE device_credentials (
+    id UUID PRIMARY KEY,
+    device_id UUID NOT NULL REFERENCES device_models(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL,
+    secret VARCHAR(128) NOT NULL DEFAULT '',
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_device_credentials_device_id ON device_credentials (device_id);
+CREATE INDEX idx_device_credentials_fingerprint ON device_credentials (fingerprint);
+
+CREATE TABLE claim_tokens (
+    id UUID PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    device_type VARCHAR(100) NOT NULL,
+    max_uses INTEGER NOT NULL,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
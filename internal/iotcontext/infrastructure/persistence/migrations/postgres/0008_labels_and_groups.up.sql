@@ -0,0 +1,12 @@
+ALTER TABLE device_models ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE sensor_models ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+
+-- A group has either a selector (dynamic) or a list of device ids (static).
+CREATE TABLE device_groups (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    selector TEXT NOT NULL DEFAULT '',
+    device_ids JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
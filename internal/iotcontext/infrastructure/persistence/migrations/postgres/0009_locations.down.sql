@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_device_models_location_id;
+
+ALTER TABLE device_models DROP COLUMN IF EXISTS longitude;
+ALTER TABLE device_models DROP COLUMN IF EXISTS latitude;
+ALTER TABLE device_models DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_device_groups_tenant_id_name;
+ALTER TABLE device_groups ADD CONSTRAINT device_groups_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_locations_tenant_id;
+DROP INDEX IF EXISTS idx_sensor_readings_models_tenant_id_timestamp;
+DROP INDEX IF EXISTS idx_sensor_models_tenant_id;
+DROP INDEX IF EXISTS idx_device_models_tenant_id;
+
+ALTER TABLE claim_tokens DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE locations DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE device_groups DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE sensor_readings_models DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE sensor_models DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE device_models DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,16 @@
+-- Every existing row belongs to the default tenant.
+ALTER TABLE device_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE sensor_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE sensor_readings_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE device_groups ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE locations ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE claim_tokens ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_device_models_tenant_id ON device_models (tenant_id);
+CREATE INDEX idx_sensor_models_tenant_id ON sensor_models (tenant_id);
+CREATE INDEX idx_sensor_readings_models_tenant_id_timestamp ON sensor_readings_models (tenant_id, timestamp DESC);
+CREATE INDEX idx_locations_tenant_id ON locations (tenant_id);
+
+-- Group names only need to be unique within a tenant.
+ALTER TABLE device_groups DROP CONSTRAINT device_groups_name_key;
+CREATE UNIQUE INDEX idx_device_groups_tenant_id_name ON device_groups (tenant_id, name);
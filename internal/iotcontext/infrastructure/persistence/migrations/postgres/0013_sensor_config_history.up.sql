@@ -0,0 +1,9 @@
+CREATE TABLE sensor_config_revisions (
+    sensor_id UUID NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL CHECK (revision > 0),
+    config JSONB NOT NULL,
+    changed_by VARCHAR(255) NOT NULL DEFAULT '',
+    restored_from BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, revision)
+);
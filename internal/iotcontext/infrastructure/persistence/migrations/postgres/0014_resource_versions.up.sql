@@ -0,0 +1,4 @@
+-- Every update moves a device or sensor to the next version, so a write
+-- based on a stale copy can be detected and rejected.
+ALTER TABLE device_models ADD COLUMN version BIGINT NOT NULL DEFAULT 1 CHECK (version > 0);
+ALTER TABLE sensor_models ADD COLUMN version BIGINT NOT NULL DEFAULT 1 CHECK (version > 0);
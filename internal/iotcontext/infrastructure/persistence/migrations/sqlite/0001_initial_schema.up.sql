@@ -0,0 +1,37 @@
+-- SQLite has no JSONB: JSON documents are stored as TEXT and can be queried
+-- with the json1 functions. Readings are not partitioned; retention deletes rows.
+CREATE TABLE device_models (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE sensor_models (
+    id TEXT PRIMARY KEY,
+    device_id TEXT REFERENCES device_models(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    config TEXT CHECK (config IS NULL OR json_valid(config)),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sensor_models_device_id ON sensor_models (device_id);
+
+CREATE TABLE sensor_readings_models (
+    id TEXT NOT NULL,
+    sensor_id TEXT REFERENCES sensor_models(id) ON DELETE CASCADE,
+    device_id TEXT REFERENCES device_models(id) ON DELETE CASCADE,
+    type VARCHAR(255),
+    value REAL NOT NULL,
+    unit VARCHAR(50),
+    timestamp TIMESTAMP NOT NULL,
+    meta TEXT CHECK (meta IS NULL OR json_valid(meta)),
+    PRIMARY KEY (id, timestamp)
+);
+
+CREATE INDEX idx_sensor_readings_models_sensor_id_timestamp ON sensor_readings_models (sensor_id, timestamp DESC);
+CREATE INDEX idx_sensor_readings_models_device_id_timestamp ON sensor_readings_models (device_id, timestamp DESC);
+CREATE INDEX idx_sensor_readings_models_timestamp ON sensor_readings_models (timestamp);
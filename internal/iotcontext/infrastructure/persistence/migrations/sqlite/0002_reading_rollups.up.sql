@@ -0,0 +1,35 @@
+CREATE TABLE sensor_reading_rollups_1m (
+    sensor_id TEXT NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    sum_value REAL NOT NULL,
+    count INTEGER NOT NULL,
+    last_value REAL NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
+
+CREATE TABLE sensor_reading_rollups_1h (
+    sensor_id TEXT NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    sum_value REAL NOT NULL,
+    count INTEGER NOT NULL,
+    last_value REAL NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
+
+CREATE TABLE sensor_reading_rollups_1d (
+    sensor_id TEXT NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    min_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    sum_value REAL NOT NULL,
+    count INTEGER NOT NULL,
+    last_value REAL NOT NULL,
+    last_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, bucket)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_sensor_models_deleted_at;
+DROP INDEX IF EXISTS idx_device_models_deleted_at;
+
+ALTER TABLE sensor_models DROP COLUMN deleted_at;
+ALTER TABLE device_models DROP COLUMN deleted_at;
+ALTER TABLE device_models DROP COLUMN status;
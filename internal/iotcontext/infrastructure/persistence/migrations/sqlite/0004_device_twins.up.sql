@@ -0,0 +1,9 @@
+CREATE TABLE device_twins (
+    device_id TEXT PRIMARY KEY REFERENCES device_models(id) ON DELETE CASCADE,
+    desired TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(desired)),
+    reported TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(reported)),
+    desired_version INTEGER NOT NULL DEFAULT 0,
+    reported_version INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
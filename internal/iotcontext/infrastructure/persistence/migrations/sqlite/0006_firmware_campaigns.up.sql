@@ -0,0 +1,41 @@
+CREATE TABLE firmwares (
+    id TEXT PRIMARY KEY,
+    version VARCHAR(100) NOT NULL,
+    device_type VARCHAR(100) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (device_type, version)
+);
+
+CREATE TABLE firmware_campaigns (
+    id TEXT PRIMARY KEY,
+    firmware_id TEXT NOT NULL REFERENCES firmwares(id),
+    device_type VARCHAR(100) NOT NULL,
+    stages TEXT NOT NULL CHECK (json_valid(stages)),
+    stage INTEGER NOT NULL DEFAULT 0,
+    failure_threshold REAL NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    accepted_failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_firmware_campaigns_status ON firmware_campaigns (status);
+
+CREATE TABLE firmware_device_updates (
+    campaign_id TEXT NOT NULL REFERENCES firmware_campaigns(id) ON DELETE CASCADE,
+    device_id TEXT NOT NULL REFERENCES device_models(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    progress INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    command_id VARCHAR(64) NOT NULL DEFAULT '',
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (campaign_id, device_id)
+);
+
+CREATE INDEX idx_firmware_device_updates_position ON firmware_device_updates (campaign_id, position);
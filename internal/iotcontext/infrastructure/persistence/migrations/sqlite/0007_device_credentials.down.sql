@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS claim_tokens;
+DROP TABLE IF EXISTS device_credentials;
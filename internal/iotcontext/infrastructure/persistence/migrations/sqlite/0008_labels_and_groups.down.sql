@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS device_groups;
+
+ALTER TABLE sensor_models DROP COLUMN labels;
+ALTER TABLE device_models DROP COLUMN labels;
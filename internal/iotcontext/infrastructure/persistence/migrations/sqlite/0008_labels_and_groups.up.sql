@@ -0,0 +1,12 @@
+ALTER TABLE device_models ADD COLUMN labels TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(labels));
+ALTER TABLE sensor_models ADD COLUMN labels TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(labels));
+
+-- A group has either a selector (dynamic) or a list of device ids (static).
+CREATE TABLE device_groups (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    selector TEXT NOT NULL DEFAULT '',
+    device_ids TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(device_ids)),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,23 @@
+-- Organization -> site -> area -> zone. The hierarchy rules are enforced by
+-- the application; the database only keeps parents from being deleted.
+CREATE TABLE locations (
+    id TEXT PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    parent_id TEXT REFERENCES locations(id) ON DELETE RESTRICT,
+    latitude REAL,
+    longitude REAL,
+    indoor_x REAL,
+    indoor_y REAL,
+    indoor_floor INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_locations_parent_id ON locations (parent_id);
+
+ALTER TABLE device_models ADD COLUMN location_id TEXT REFERENCES locations(id) ON DELETE SET NULL;
+ALTER TABLE device_models ADD COLUMN latitude REAL;
+ALTER TABLE device_models ADD COLUMN longitude REAL;
+
+CREATE INDEX idx_device_models_location_id ON device_models (location_id);
@@ -0,0 +1,25 @@
+CREATE TABLE device_groups_single (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    selector TEXT NOT NULL DEFAULT '',
+    device_ids TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(device_ids)),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO device_groups_single (id, name, selector, device_ids, created_at, updated_at)
+SELECT id, name, selector, device_ids, created_at, updated_at FROM device_groups;
+
+DROP TABLE device_groups;
+ALTER TABLE device_groups_single RENAME TO device_groups;
+
+DROP INDEX IF EXISTS idx_locations_tenant_id;
+DROP INDEX IF EXISTS idx_sensor_readings_models_tenant_id_timestamp;
+DROP INDEX IF EXISTS idx_sensor_models_tenant_id;
+DROP INDEX IF EXISTS idx_device_models_tenant_id;
+
+ALTER TABLE claim_tokens DROP COLUMN tenant_id;
+ALTER TABLE locations DROP COLUMN tenant_id;
+ALTER TABLE sensor_readings_models DROP COLUMN tenant_id;
+ALTER TABLE sensor_models DROP COLUMN tenant_id;
+ALTER TABLE device_models DROP COLUMN tenant_id;
@@ -0,0 +1,31 @@
+-- Every existing row belongs to the default tenant.
+ALTER TABLE device_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE sensor_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE sensor_readings_models ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE locations ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE claim_tokens ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_device_models_tenant_id ON device_models (tenant_id);
+CREATE INDEX idx_sensor_models_tenant_id ON sensor_models (tenant_id);
+CREATE INDEX idx_sensor_readings_models_tenant_id_timestamp ON sensor_readings_models (tenant_id, timestamp DESC);
+CREATE INDEX idx_locations_tenant_id ON locations (tenant_id);
+
+-- Group names only need to be unique within a tenant. SQLite cannot drop
+-- the inline UNIQUE constraint, so the table is rebuilt.
+CREATE TABLE device_groups_tenants (
+    id TEXT PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    name VARCHAR(255) NOT NULL,
+    selector TEXT NOT NULL DEFAULT '',
+    device_ids TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(device_ids)),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO device_groups_tenants (id, name, selector, device_ids, created_at, updated_at)
+SELECT id, name, selector, device_ids, created_at, updated_at FROM device_groups;
+
+DROP TABLE device_groups;
+ALTER TABLE device_groups_tenants RENAME TO device_groups;
+
+CREATE UNIQUE INDEX idx_device_groups_tenant_id_name ON device_groups (tenant_id, name);
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    secret_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_tenant_id ON api_keys (tenant_id);
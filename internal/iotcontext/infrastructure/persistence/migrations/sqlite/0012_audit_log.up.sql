@@ -0,0 +1,31 @@
+CREATE TABLE audit_log (
+    id TEXT PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT '',
+    action VARCHAR(64) NOT NULL,
+    resource_type VARCHAR(32) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    before TEXT,
+    after TEXT,
+    changes TEXT NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    source_ip VARCHAR(64) NOT NULL DEFAULT '',
+    at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_audit_log_tenant_id_at ON audit_log (tenant_id, at);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor);
+CREATE INDEX idx_audit_log_resource ON audit_log (resource_type, resource_id);
+CREATE INDEX idx_audit_log_request_id ON audit_log (request_id);
+
+-- The log is append only: rows can be inserted, never changed or removed.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append only');
+END;
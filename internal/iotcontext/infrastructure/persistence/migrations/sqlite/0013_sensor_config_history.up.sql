@@ -0,0 +1,9 @@
+CREATE TABLE sensor_config_revisions (
+    sensor_id TEXT NOT NULL REFERENCES sensor_models(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    config TEXT NOT NULL CHECK (json_valid(config)),
+    changed_by VARCHAR(255) NOT NULL DEFAULT '',
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (sensor_id, revision)
+);
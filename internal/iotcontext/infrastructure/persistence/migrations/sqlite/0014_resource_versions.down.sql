@@ -0,0 +1,2 @@
+ALTER TABLE sensor_models DROP COLUMN version;
+ALTER TABLE device_models DROP COLUMN version;
@@ -0,0 +1,6 @@
+-- When each device was last seen, so presence survives a restart instead of
+-- every device looking offline until it reports again.
+CREATE TABLE device_presence (
+    device_id TEXT PRIMARY KEY REFERENCES device_models(id) ON DELETE CASCADE,
+    last_seen TIMESTAMP NOT NULL
+);
@@ -0,0 +1,338 @@
+package persistence
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var embeddedMigrations embed.FS
+
+const migrationsTable = "schema_migrations"
+
+// migrationLockID is an arbitrary key for the Postgres advisory lock that
+// serializes concurrent "migrate up" runs.
+const migrationLockID = 7_356_214_001
+
+var ErrSchemaNotMigrated = errors.New("database schema has pending migrations")
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type SchemaMigrationModel struct {
+	Version   int64 `gorm:"primaryKey"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigrationModel) TableName() string {
+	return migrationsTable
+}
+
+type Migrator struct {
+	db         *DB
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations written for the dialect of db. Both
+// dialects keep the same version numbers so a schema version means the same
+// thing regardless of the backend.
+func NewMigrator(db *DB) (*Migrator, error) {
+	dialect := db.Dialect()
+	if dialect != "postgres" && dialect != "sqlite" {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	migrations, err := loadMigrations(embeddedMigrations, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		ran, err := m.apply(migration)
+		if err != nil {
+			return applied, err
+		}
+
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		ran, err := m.revert(m.migrations[i])
+		if err != nil {
+			return reverted, err
+		}
+
+		if ran {
+			reverted = append(reverted, m.migrations[i])
+		}
+	}
+
+	return reverted, nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]bool)
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if model, ok := applied[migration.Version]; ok {
+			appliedAt := model.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, model := range applied {
+		if known[version] {
+			continue
+		}
+
+		appliedAt := model.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      model.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Check refuses schemas that are behind this binary or that were migrated by
+// a newer release.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.Version > m.Latest() {
+			return fmt.Errorf("%w: found version %d, latest known is %d", ErrSchemaTooNew, status.Version, m.Latest())
+		}
+	}
+
+	for _, status := range statuses {
+		if !status.Applied {
+			return fmt.Errorf("%w: version %d (%s) not applied", ErrSchemaNotMigrated, status.Version, status.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) ensureTable() error {
+	return m.db.conn.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
+
+func (m *Migrator) applied() (map[int64]SchemaMigrationModel, error) {
+	applied := make(map[int64]SchemaMigrationModel)
+	if !m.db.conn.Migrator().HasTable(migrationsTable) {
+		return applied, nil
+	}
+
+	var models []SchemaMigrationModel
+	if err := m.db.conn.Order("version").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	for _, model := range models {
+		applied[model.Version] = model
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	ran := false
+	err := m.db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := m.lock(tx); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&SchemaMigrationModel{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := tx.Exec(migration.up).Error; err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+
+		ran = true
+		return tx.Create(&SchemaMigrationModel{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().UTC(),
+		}).Error
+	})
+
+	return ran, err
+}
+
+func (m *Migrator) revert(migration Migration) (bool, error) {
+	ran := false
+	err := m.db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := m.lock(tx); err != nil {
+			return err
+		}
+
+		result := tx.Where("version = ?", migration.Version).Delete(&SchemaMigrationModel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Exec(migration.down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+
+		ran = true
+		return nil
+	})
+
+	return ran, err
+}
+
+// lock is a no-op on SQLite: the write transaction already holds the
+// database-wide lock.
+func (m *Migrator) lock(tx *gorm.DB) error {
+	if m.db.Dialect() != "postgres" {
+		return nil
+	}
+
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			return nil, fmt.Errorf("migration versions must be contiguous, expected %d got %d", i+1, migration.Version)
+		}
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,112 @@
+package persistence
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	postgres, err := loadMigrations(embeddedMigrations, "migrations/postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(postgres) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	for i, migration := range postgres {
+		if migration.Version != int64(i+1) {
+			t.Errorf("expected version %d, got %d", i+1, migration.Version)
+		}
+
+		if migration.up == "" || migration.down == "" {
+			t.Errorf("migration %d is missing its up or down script", migration.Version)
+		}
+	}
+
+	sqlite, err := loadMigrations(embeddedMigrations, "migrations/sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("expected %d sqlite migrations, got %d", len(postgres), len(sqlite))
+	}
+
+	for i := range sqlite {
+		if sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migration %d is %q on sqlite and %q on postgres", sqlite[i].Version, sqlite[i].Name, postgres[i].Name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         fstest.MapFS
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name: "ordered pairs",
+			files: fstest.MapFS{
+				"m/0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+				"m/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+				"m/0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+				"m/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "missing down script",
+			files: fstest.MapFS{
+				"m/0001_first.up.sql": {Data: []byte("CREATE TABLE a ();")},
+			},
+			expectError: true,
+		},
+		{
+			name: "gap in versions",
+			files: fstest.MapFS{
+				"m/0001_first.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+				"m/0001_first.down.sql": {Data: []byte("DROP TABLE a;")},
+				"m/0003_third.up.sql":   {Data: []byte("CREATE TABLE c ();")},
+				"m/0003_third.down.sql": {Data: []byte("DROP TABLE c;")},
+			},
+			expectError: true,
+		},
+		{
+			name: "unexpected file name",
+			files: fstest.MapFS{
+				"m/first.sql": {Data: []byte("CREATE TABLE a ();")},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(migrations) != tt.expectedCount {
+				t.Fatalf("expected %d migrations, got %d", tt.expectedCount, len(migrations))
+			}
+
+			if migrations[0].Name != "first" || migrations[1].Name != "second" {
+				t.Errorf("unexpected order: %s, %s", migrations[0].Name, migrations[1].Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresAPIKeyRepository struct {
+	db *DB
+}
+
+func NewPostgresAPIKeyRepository(db *DB) domain.APIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+func (r *PostgresAPIKeyRepository) Save(key *domain.APIKey) error {
+	return r.db.conn.Create(marshalAPIKey(key)).Error
+}
+
+func (r *PostgresAPIKeyRepository) Update(key *domain.APIKey) error {
+	return updateAPIKey(r.db.conn, key)
+}
+
+func (r *PostgresAPIKeyRepository) FindByID(id domain.APIKeyID) (*domain.APIKey, error) {
+	return findAPIKey(r.db.conn, id)
+}
+
+func (r *PostgresAPIKeyRepository) FindAll() ([]*domain.APIKey, error) {
+	return findAPIKeys(r.db.conn)
+}
+
+func (r *PostgresAPIKeyRepository) FindByTenant(tenant domain.TenantID) ([]*domain.APIKey, error) {
+	return findAPIKeys(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
+
+func updateAPIKey(conn *gorm.DB, key *domain.APIKey) error {
+	model := marshalAPIKey(key)
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func findAPIKey(conn *gorm.DB, id domain.APIKeyID) (*domain.APIKey, error) {
+	if id == "" {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	var model APIKeyModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalAPIKey(&model), nil
+}
+
+func findAPIKeys(query *gorm.DB) ([]*domain.APIKey, error) {
+	var models []APIKeyModel
+	if err := query.Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*domain.APIKey, 0, len(models))
+	for i := range models {
+		keys = append(keys, unmarshalAPIKey(&models[i]))
+	}
+
+	return keys, nil
+}
+
+func marshalAPIKey(key *domain.APIKey) *APIKeyModel {
+	return &APIKeyModel{
+		ID:         string(key.ID),
+		TenantID:   string(key.TenantID),
+		Name:       key.Name,
+		Role:       string(key.Role),
+		SecretHash: key.SecretHash,
+		CreatedAt:  key.CreatedAt.UTC(),
+		ExpiresAt:  utcPointer(key.ExpiresAt),
+		RevokedAt:  utcPointer(key.RevokedAt),
+	}
+}
+
+func unmarshalAPIKey(model *APIKeyModel) *domain.APIKey {
+	return &domain.APIKey{
+		ID:         domain.APIKeyID(model.ID),
+		TenantID:   domain.TenantID(model.TenantID),
+		Name:       model.Name,
+		Role:       domain.Role(model.Role),
+		SecretHash: model.SecretHash,
+		CreatedAt:  model.CreatedAt.UTC(),
+		ExpiresAt:  utcPointer(model.ExpiresAt),
+		RevokedAt:  utcPointer(model.RevokedAt),
+	}
+}
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresAuditRepository struct {
+	db *DB
+}
+
+func NewPostgresAuditRepository(db *DB) domain.AuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+func (r *PostgresAuditRepository) Append(entry *domain.AuditEntry) error {
+	model, err := marshalAuditEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	return r.db.conn.Create(model).Error
+}
+
+func (r *PostgresAuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	return findAuditEntries(r.db.conn, filter)
+}
+
+func findAuditEntries(conn *gorm.DB, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query := conn.Model(&AuditEntryModel{})
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", string(filter.TenantID))
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", string(filter.Action))
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("at < ?", filter.To.UTC())
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var models []AuditEntryModel
+	if err := query.Order("at DESC, id DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*domain.AuditEntry, 0, len(models))
+	for i := range models {
+		entry, err := unmarshalAuditEntry(&models[i])
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func marshalAuditEntry(entry *domain.AuditEntry) (*AuditEntryModel, error) {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEntryModel{
+		ID:           string(entry.ID),
+		TenantID:     string(entry.TenantID),
+		Actor:        entry.Actor.Subject,
+		Role:         string(entry.Actor.Role),
+		Action:       string(entry.Action),
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Before:       jsonColumn(entry.Before),
+		After:        jsonColumn(entry.After),
+		Changes:      changes,
+		RequestID:    entry.Actor.RequestID,
+		SourceIP:     entry.Actor.SourceIP,
+		At:           entry.At.UTC(),
+	}, nil
+}
+
+func unmarshalAuditEntry(model *AuditEntryModel) (*domain.AuditEntry, error) {
+	changes := make([]domain.AuditChange, 0)
+	if len(model.Changes) > 0 {
+		if err := json.Unmarshal(model.Changes, &changes); err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.AuditEntry{
+		ID:       domain.AuditEntryID(model.ID),
+		TenantID: domain.TenantID(model.TenantID),
+		Actor: domain.AuditActor{
+			Subject:   model.Actor,
+			Role:      domain.Role(model.Role),
+			RequestID: model.RequestID,
+			SourceIP:  model.SourceIP,
+		},
+		Action:       domain.AuditAction(model.Action),
+		ResourceType: model.ResourceType,
+		ResourceID:   model.ResourceID,
+		Before:       json.RawMessage(model.Before),
+		After:        json.RawMessage(model.After),
+		Changes:      changes,
+		At:           model.At.UTC(),
+	}, nil
+}
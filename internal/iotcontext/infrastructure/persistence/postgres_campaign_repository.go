@@ -0,0 +1,245 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresCampaignRepository struct {
+	db *DB
+}
+
+func NewPostgresCampaignRepository(db *DB) domain.CampaignRepository {
+	return &PostgresCampaignRepository{db: db}
+}
+
+func (r *PostgresCampaignRepository) Save(campaign *domain.Campaign, updates []*domain.DeviceUpdate) error {
+	return saveCampaign(r.db.conn, campaign, updates)
+}
+
+func (r *PostgresCampaignRepository) Update(campaign *domain.Campaign) error {
+	return updateCampaign(r.db.conn, campaign)
+}
+
+func (r *PostgresCampaignRepository) FindByID(id domain.CampaignID) (*domain.Campaign, error) {
+	return findCampaign(r.db.conn, id)
+}
+
+func (r *PostgresCampaignRepository) FindAll() ([]*domain.Campaign, error) {
+	return findCampaigns(r.db.conn.Order("created_at DESC, id DESC"))
+}
+
+func (r *PostgresCampaignRepository) FindActive() ([]*domain.Campaign, error) {
+	return findCampaigns(r.db.conn.Where("status IN ?", activeCampaignStatuses()).Order("created_at ASC, id ASC"))
+}
+
+func (r *PostgresCampaignRepository) FindDeviceUpdates(campaignID domain.CampaignID) ([]*domain.DeviceUpdate, error) {
+	return findDeviceUpdates(r.db.conn, campaignID)
+}
+
+func (r *PostgresCampaignRepository) FindDeviceUpdate(campaignID domain.CampaignID, deviceID domain.DeviceID) (*domain.DeviceUpdate, error) {
+	return findDeviceUpdate(r.db.conn, campaignID, deviceID)
+}
+
+func (r *PostgresCampaignRepository) UpdateDeviceUpdate(update *domain.DeviceUpdate) error {
+	return updateDeviceUpdate(r.db.conn, update)
+}
+
+func activeCampaignStatuses() []string {
+	return []string{string(domain.CampaignRunning), string(domain.CampaignPaused)}
+}
+
+func saveCampaign(conn *gorm.DB, campaign *domain.Campaign, updates []*domain.DeviceUpdate) error {
+	model, err := marshalCampaign(campaign)
+	if err != nil {
+		return err
+	}
+
+	models := make([]DeviceUpdateModel, 0, len(updates))
+	for _, update := range updates {
+		models = append(models, *marshalDeviceUpdate(update))
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+
+		if len(models) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(models, 500).Error
+	})
+}
+
+func updateCampaign(conn *gorm.DB, campaign *domain.Campaign) error {
+	model, err := marshalCampaign(campaign)
+	if err != nil {
+		return err
+	}
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrCampaignNotFound
+	}
+
+	return nil
+}
+
+func findCampaign(conn *gorm.DB, id domain.CampaignID) (*domain.Campaign, error) {
+	var model CampaignModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCampaignNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalCampaign(&model)
+}
+
+func findCampaigns(query *gorm.DB) ([]*domain.Campaign, error) {
+	var models []CampaignModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalCampaigns(models)
+}
+
+func findDeviceUpdates(conn *gorm.DB, campaignID domain.CampaignID) ([]*domain.DeviceUpdate, error) {
+	var models []DeviceUpdateModel
+	if err := conn.Where("campaign_id = ?", string(campaignID)).Order("position ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	updates := make([]*domain.DeviceUpdate, 0, len(models))
+	for i := range models {
+		updates = append(updates, unmarshalDeviceUpdate(&models[i]))
+	}
+
+	return updates, nil
+}
+
+func findDeviceUpdate(conn *gorm.DB, campaignID domain.CampaignID, deviceID domain.DeviceID) (*domain.DeviceUpdate, error) {
+	var model DeviceUpdateModel
+	err := conn.First(&model, "campaign_id = ? AND device_id = ?", string(campaignID), string(deviceID)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDeviceUpdateNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalDeviceUpdate(&model), nil
+}
+
+func updateDeviceUpdate(conn *gorm.DB, update *domain.DeviceUpdate) error {
+	model := marshalDeviceUpdate(update)
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrDeviceUpdateNotFound
+	}
+
+	return nil
+}
+
+func marshalCampaign(campaign *domain.Campaign) (*CampaignModel, error) {
+	stages, err := json.Marshal(campaign.Stages)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CampaignModel{
+		ID:               string(campaign.ID),
+		FirmwareID:       string(campaign.FirmwareID),
+		DeviceType:       campaign.DeviceType,
+		Stages:           stages,
+		Stage:            campaign.Stage,
+		FailureThreshold: campaign.FailureThreshold,
+		Status:           string(campaign.Status),
+		Reason:           campaign.Reason,
+		AcceptedFailures: campaign.AcceptedFailures,
+		CreatedAt:        campaign.CreatedAt.UTC(),
+		UpdatedAt:        campaign.UpdatedAt.UTC(),
+	}, nil
+}
+
+func unmarshalCampaign(model *CampaignModel) (*domain.Campaign, error) {
+	campaign := &domain.Campaign{
+		ID:               domain.CampaignID(model.ID),
+		FirmwareID:       domain.FirmwareID(model.FirmwareID),
+		DeviceType:       model.DeviceType,
+		Stage:            model.Stage,
+		FailureThreshold: model.FailureThreshold,
+		Status:           domain.CampaignStatus(model.Status),
+		Reason:           model.Reason,
+		AcceptedFailures: model.AcceptedFailures,
+		CreatedAt:        model.CreatedAt.UTC(),
+		UpdatedAt:        model.UpdatedAt.UTC(),
+	}
+
+	if err := json.Unmarshal(model.Stages, &campaign.Stages); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+func unmarshalCampaigns(models []CampaignModel) ([]*domain.Campaign, error) {
+	campaigns := make([]*domain.Campaign, 0, len(models))
+	for i := range models {
+		campaign, err := unmarshalCampaign(&models[i])
+		if err != nil {
+			return nil, err
+		}
+
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, nil
+}
+
+func marshalDeviceUpdate(update *domain.DeviceUpdate) *DeviceUpdateModel {
+	return &DeviceUpdateModel{
+		CampaignID:  string(update.CampaignID),
+		DeviceID:    string(update.DeviceID),
+		Position:    update.Position,
+		Status:      string(update.Status),
+		Progress:    update.Progress,
+		Error:       update.Error,
+		CommandID:   string(update.CommandID),
+		StartedAt:   utcPointer(update.StartedAt),
+		CompletedAt: utcPointer(update.CompletedAt),
+		UpdatedAt:   update.UpdatedAt.UTC(),
+	}
+}
+
+func unmarshalDeviceUpdate(model *DeviceUpdateModel) *domain.DeviceUpdate {
+	return &domain.DeviceUpdate{
+		CampaignID:  domain.CampaignID(model.CampaignID),
+		DeviceID:    domain.DeviceID(model.DeviceID),
+		Position:    model.Position,
+		Status:      domain.DeviceUpdateStatus(model.Status),
+		Progress:    model.Progress,
+		Error:       model.Error,
+		CommandID:   domain.CommandID(model.CommandID),
+		StartedAt:   utcPointer(model.StartedAt),
+		CompletedAt: utcPointer(model.CompletedAt),
+		UpdatedAt:   model.UpdatedAt.UTC(),
+	}
+}
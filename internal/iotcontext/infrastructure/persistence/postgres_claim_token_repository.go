@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PostgresClaimTokenRepository struct {
+	db *DB
+}
+
+func NewPostgresClaimTokenRepository(db *DB) domain.ClaimTokenRepository {
+	return &PostgresClaimTokenRepository{db: db}
+}
+
+func (r *PostgresClaimTokenRepository) Save(token *domain.ClaimToken) error {
+	return r.db.conn.Create(marshalClaimToken(token)).Error
+}
+
+func (r *PostgresClaimTokenRepository) Redeem(tokenHash string, now time.Time) (*domain.ClaimToken, error) {
+	return redeemClaimToken(r.db.conn, tokenHash, now)
+}
+
+// redeemClaimToken counts the use in the same statement that checks the
+// token is still good, so concurrent registrations cannot overrun max uses.
+func redeemClaimToken(conn *gorm.DB, tokenHash string, now time.Time) (*domain.ClaimToken, error) {
+	var model ClaimTokenModel
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&ClaimTokenModel{}).
+			Where("token_hash = ? AND uses < max_uses AND expires_at > ?", tokenHash, now.UTC()).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return domain.ErrClaimTokenRejected
+		}
+
+		return tx.First(&model, "token_hash = ?", tokenHash).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalClaimToken(&model), nil
+}
+
+func marshalClaimToken(token *domain.ClaimToken) *ClaimTokenModel {
+	return &ClaimTokenModel{
+		ID:         token.ID,
+		TenantID:   string(token.TenantID),
+		TokenHash:  token.TokenHash,
+		DeviceType: token.DeviceType,
+		MaxUses:    token.MaxUses,
+		Uses:       token.Uses,
+		ExpiresAt:  token.ExpiresAt.UTC(),
+		CreatedAt:  token.CreatedAt.UTC(),
+	}
+}
+
+func unmarshalClaimToken(model *ClaimTokenModel) *domain.ClaimToken {
+	return &domain.ClaimToken{
+		ID:         model.ID,
+		TenantID:   domain.TenantID(model.TenantID),
+		TokenHash:  model.TokenHash,
+		DeviceType: model.DeviceType,
+		MaxUses:    model.MaxUses,
+		Uses:       model.Uses,
+		ExpiresAt:  model.ExpiresAt.UTC(),
+		CreatedAt:  model.CreatedAt.UTC(),
+	}
+}
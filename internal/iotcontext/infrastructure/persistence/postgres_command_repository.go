@@ -0,0 +1,200 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PostgresCommandRepository struct {
+	db *DB
+}
+
+func NewPostgresCommandRepository(db *DB) domain.CommandRepository {
+	return &PostgresCommandRepository{db: db}
+}
+
+func (r *PostgresCommandRepository) Save(command *domain.Command) error {
+	return saveCommand(r.db.conn, command)
+}
+
+func (r *PostgresCommandRepository) Update(command *domain.Command) error {
+	return updateCommand(r.db.conn, command)
+}
+
+func (r *PostgresCommandRepository) FindByID(id domain.CommandID) (*domain.Command, error) {
+	return findCommand(r.db.conn, id)
+}
+
+func (r *PostgresCommandRepository) FindByDeviceID(deviceID domain.DeviceID, limit int) ([]*domain.Command, error) {
+	return findCommandsByDevice(r.db.conn, deviceID, limit)
+}
+
+func (r *PostgresCommandRepository) FindOpen() ([]*domain.Command, error) {
+	return findOpenCommands(r.db.conn)
+}
+
+func saveCommand(conn *gorm.DB, command *domain.Command) error {
+	model, err := marshalCommand(command)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Create(model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrCommandAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func updateCommand(conn *gorm.DB, command *domain.Command) error {
+	model, err := marshalCommand(command)
+	if err != nil {
+		return err
+	}
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrCommandNotFound
+	}
+
+	return nil
+}
+
+func findCommand(conn *gorm.DB, id domain.CommandID) (*domain.Command, error) {
+	var model CommandModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCommandNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalCommand(&model)
+}
+
+func findCommandsByDevice(conn *gorm.DB, deviceID domain.DeviceID, limit int) ([]*domain.Command, error) {
+	query := conn.Where("device_id = ?", string(deviceID)).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var models []CommandModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalCommands(models)
+}
+
+func findOpenCommands(conn *gorm.DB) ([]*domain.Command, error) {
+	var models []CommandModel
+	err := conn.
+		Where("status IN ?", []string{string(domain.CommandPending), string(domain.CommandDelivered)}).
+		Order("created_at ASC, id ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalCommands(models)
+}
+
+func marshalCommand(command *domain.Command) (*CommandModel, error) {
+	params, err := marshalJSONColumn(command.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := marshalJSONColumn(command.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommandModel{
+		ID:          string(command.ID),
+		DeviceID:    string(command.DeviceID),
+		Name:        command.Name,
+		Params:      params,
+		Status:      string(command.Status),
+		Result:      result,
+		Error:       command.Error,
+		CreatedAt:   command.CreatedAt.UTC(),
+		ExpiresAt:   command.ExpiresAt.UTC(),
+		DeliveredAt: utcPointer(command.DeliveredAt),
+		CompletedAt: utcPointer(command.CompletedAt),
+		UpdatedAt:   command.UpdatedAt.UTC(),
+	}, nil
+}
+
+func unmarshalCommand(model *CommandModel) (*domain.Command, error) {
+	command := &domain.Command{
+		ID:          domain.CommandID(model.ID),
+		DeviceID:    domain.DeviceID(model.DeviceID),
+		Name:        model.Name,
+		Status:      domain.CommandStatus(model.Status),
+		Error:       model.Error,
+		CreatedAt:   model.CreatedAt.UTC(),
+		ExpiresAt:   model.ExpiresAt.UTC(),
+		DeliveredAt: utcPointer(model.DeliveredAt),
+		CompletedAt: utcPointer(model.CompletedAt),
+		UpdatedAt:   model.UpdatedAt.UTC(),
+	}
+
+	if len(model.Params) > 0 {
+		if err := json.Unmarshal(model.Params, &command.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(model.Result) > 0 {
+		if err := json.Unmarshal(model.Result, &command.Result); err != nil {
+			return nil, err
+		}
+	}
+
+	return command, nil
+}
+
+func unmarshalCommands(models []CommandModel) ([]*domain.Command, error) {
+	commands := make([]*domain.Command, 0, len(models))
+	for i := range models {
+		command, err := unmarshalCommand(&models[i])
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+func marshalJSONColumn(value map[string]interface{}) (jsonColumn, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(value)
+}
+
+func utcPointer(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+
+	return &utc
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+)
+
+// The Postgres contract needs a disposable database, e.g.
+// POSTGRES_TEST_DSN="host=localhost user=user password=password dbname=iot_test port=55432 sslmode=disable"
+func TestPostgresRepositories_Contract(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := OpenPostgresDB(dsn, logger.Silent)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	runRepositoryContract(t, func(t *testing.T) repositorySet {
+		err := db.conn.Exec(`TRUNCATE device_models, sensor_models, sensor_readings_models,
+			sensor_reading_rollups_1m, sensor_reading_rollups_1h, sensor_reading_rollups_1d, device_twins, device_commands,
+			firmwares, firmware_campaigns, firmware_device_updates, device_credentials, claim_tokens, device_groups, locations, api_keys, audit_log, sensor_config_revisions, device_presence CASCADE`).Error
+		if err != nil {
+			t.Fatalf("failed to clean database: %v", err)
+		}
+
+		return repositorySet{
+			devices:     NewPostgresDeviceRepository(db),
+			sensors:     NewPostgresSensorRepository(db),
+			readings:    NewPostgresSensorReadingRepository(db),
+			rollups:     NewPostgresReadingRollupRepository(db),
+			retention:   NewPostgresSensorReadingRetentionRepository(db),
+			twins:       NewPostgresTwinRepository(db),
+			commands:    NewPostgresCommandRepository(db),
+			firmwares:   NewPostgresFirmwareRepository(db),
+			campaigns:   NewPostgresCampaignRepository(db),
+			credentials: NewPostgresCredentialRepository(db),
+			claimTokens: NewPostgresClaimTokenRepository(db),
+			groups:      NewPostgresGroupRepository(db),
+			locations:   NewPostgresLocationRepository(db),
+			apiKeys:     NewPostgresAPIKeyRepository(db),
+			audit:       NewPostgresAuditRepository(db),
+			configs:     NewPostgresSensorConfigHistoryRepository(db),
+			presence:    NewPostgresPresenceRepository(db),
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresCredentialRepository struct {
+	db *DB
+}
+
+func NewPostgresCredentialRepository(db *DB) domain.CredentialRepository {
+	return &PostgresCredentialRepository{db: db}
+}
+
+func (r *PostgresCredentialRepository) Save(credential *domain.Credential) error {
+	return r.db.conn.Create(marshalCredential(credential)).Error
+}
+
+func (r *PostgresCredentialRepository) Update(credential *domain.Credential) error {
+	return updateCredential(r.db.conn, credential)
+}
+
+func (r *PostgresCredentialRepository) FindByID(id domain.CredentialID) (*domain.Credential, error) {
+	return findCredential(r.db.conn, "id = ?", string(id))
+}
+
+func (r *PostgresCredentialRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Credential, error) {
+	return findCredentialsByDevice(r.db.conn, deviceID)
+}
+
+func (r *PostgresCredentialRepository) FindByFingerprint(fingerprint string) (*domain.Credential, error) {
+	return findCredential(r.db.conn, "fingerprint = ?", fingerprint)
+}
+
+func updateCredential(conn *gorm.DB, credential *domain.Credential) error {
+	model := marshalCredential(credential)
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrCredentialNotFound
+	}
+
+	return nil
+}
+
+func findCredential(conn *gorm.DB, query string, value string) (*domain.Credential, error) {
+	if value == "" {
+		return nil, domain.ErrCredentialNotFound
+	}
+
+	var model CredentialModel
+	if err := conn.First(&model, query, value).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCredentialNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalCredential(&model), nil
+}
+
+func findCredentialsByDevice(conn *gorm.DB, deviceID domain.DeviceID) ([]*domain.Credential, error) {
+	var models []CredentialModel
+	if err := conn.Where("device_id = ?", string(deviceID)).Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	credentials := make([]*domain.Credential, 0, len(models))
+	for i := range models {
+		credentials = append(credentials, unmarshalCredential(&models[i]))
+	}
+
+	return credentials, nil
+}
+
+func marshalCredential(credential *domain.Credential) *CredentialModel {
+	return &CredentialModel{
+		ID:          string(credential.ID),
+		DeviceID:    string(credential.DeviceID),
+		Type:        string(credential.Type),
+		Secret:      credential.Secret,
+		Fingerprint: credential.Fingerprint,
+		CreatedAt:   credential.CreatedAt.UTC(),
+		ExpiresAt:   utcPointer(credential.ExpiresAt),
+		RevokedAt:   utcPointer(credential.RevokedAt),
+	}
+}
+
+func unmarshalCredential(model *CredentialModel) *domain.Credential {
+	return &domain.Credential{
+		ID:          domain.CredentialID(model.ID),
+		DeviceID:    domain.DeviceID(model.DeviceID),
+		Type:        domain.CredentialType(model.Type),
+		Secret:      model.Secret,
+		Fingerprint: model.Fingerprint,
+		CreatedAt:   model.CreatedAt.UTC(),
+		ExpiresAt:   utcPointer(model.ExpiresAt),
+		RevokedAt:   utcPointer(model.RevokedAt),
+	}
+}
@@ -1,6 +1,11 @@
 package persistence
 
-import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
 
 type PostgresDeviceRepository struct {
 	db *DB
@@ -11,61 +16,171 @@ func NewPostgresDeviceRepository(db *DB) domain.DeviceRepository {
 }
 
 func (r *PostgresDeviceRepository) Save(device *domain.Device) error {
-	model := DeviceModel{
-		ID:        string(device.ID),
-		Name:      device.Name,
-		Type:      device.Type,
-		CreatedAt: device.CreatedAt,
-		UpdatedAt: device.UpdatedAt,
+	model := marshalDevice(device)
+
+	if err := r.db.conn.Create(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrDeviceAlreadyExists
+		}
+
+		return err
 	}
 
-	return r.db.conn.Create(&model).Error
+	return nil
 }
 
 func (r *PostgresDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
 	var model DeviceModel
 	if err := r.db.conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Device{}, domain.ErrDeviceNotFound
+		}
+
 		return domain.Device{}, err
 	}
 
-	return domain.Device{
-		ID:        domain.DeviceID(model.ID),
-		Name:      model.Name,
-		Type:      model.Type,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
-	}, nil
+	return unmarshalDevice(&model), nil
+}
+
+func (r *PostgresDeviceRepository) FindByIDs(ids []domain.DeviceID) ([]domain.Device, error) {
+	return findDevices(r.db.conn.Where("id IN ?", ids))
 }
 
 func (r *PostgresDeviceRepository) FindAll() ([]domain.Device, error) {
+	return findDevices(r.db.conn)
+}
+
+func (r *PostgresDeviceRepository) FindByTenant(tenant domain.TenantID) ([]domain.Device, error) {
+	return findDevices(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
+
+func (r *PostgresDeviceRepository) Update(device *domain.Device) error {
+	return updateDevice(r.db.conn, device)
+}
+
+// Delete is a soft delete: the row keeps its id, so the id cannot be reused,
+// but it is no longer returned by any query.
+func (r *PostgresDeviceRepository) Delete(id domain.DeviceID) error {
+	result := r.db.conn.Delete(&DeviceModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+func findDevices(query *gorm.DB) ([]domain.Device, error) {
 	var models []DeviceModel
-	if err := r.db.conn.Find(&models).Error; err != nil {
+	if err := query.Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
 		return nil, err
 	}
 
 	var devices []domain.Device
 	for _, model := range models {
-		device := domain.Device{
-			ID:        domain.DeviceID(model.ID),
-			Name:      model.Name,
-			Type:      model.Type,
-			CreatedAt: model.CreatedAt,
-			UpdatedAt: model.UpdatedAt,
-		}
-		devices = append(devices, device)
+		devices = append(devices, unmarshalDevice(&model))
 	}
 
 	return devices, nil
 }
 
-func (r *PostgresDeviceRepository) Update(device *domain.Device) error {
+// updateDevice writes the device only while the stored copy still has the
+// version it was loaded with, and moves it on to the next version.
+func updateDevice(conn *gorm.DB, device *domain.Device) error {
+	model := marshalDevice(device)
+	model.Version = device.Version + 1
+
+	result := conn.Model(&DeviceModel{}).
+		Where("id = ? AND version = ?", model.ID, device.Version).
+		Select("*").
+		Updates(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := conn.Model(&DeviceModel{}).Where("id = ?", model.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return domain.ErrDeviceNotFound
+		}
+
+		return domain.ErrDeviceVersionConflict
+	}
+
+	device.Version = model.Version
+
+	return nil
+}
+
+func marshalDevice(device *domain.Device) DeviceModel {
 	model := DeviceModel{
 		ID:        string(device.ID),
+		TenantID:  string(device.TenantID),
 		Name:      device.Name,
 		Type:      device.Type,
-		CreatedAt: device.CreatedAt,
-		UpdatedAt: device.UpdatedAt,
+		Status:    string(device.Status),
+		Labels:    marshalLabels(device.Labels),
+		CreatedAt: device.CreatedAt.UTC(),
+		UpdatedAt: device.UpdatedAt.UTC(),
+		Version:   device.Version,
+	}
+
+	if device.LocationID != "" {
+		locationID := string(device.LocationID)
+		model.LocationID = &locationID
+	}
+
+	if device.Geo != nil {
+		model.Latitude, model.Longitude = &device.Geo.Lat, &device.Geo.Lon
+	}
+
+	return model
+}
+
+func unmarshalDevice(model *DeviceModel) domain.Device {
+	device := domain.Device{
+		ID:        domain.DeviceID(model.ID),
+		TenantID:  domain.TenantID(model.TenantID),
+		Name:      model.Name,
+		Type:      model.Type,
+		Status:    domain.DeviceStatus(model.Status),
+		Labels:    unmarshalLabels(model.Labels),
+		Geo:       unmarshalGeo(model.Latitude, model.Longitude),
+		CreatedAt: model.CreatedAt.UTC(),
+		UpdatedAt: model.UpdatedAt.UTC(),
+		Version:   model.Version,
+	}
+
+	if model.LocationID != nil {
+		device.LocationID = domain.LocationID(*model.LocationID)
+	}
+
+	return device
+}
+
+func marshalLabels(labels domain.Labels) []byte {
+	if labels == nil {
+		labels = domain.Labels{}
+	}
+
+	b, _ := json.Marshal(labels)
+	return b
+}
+
+// unmarshalLabels never returns nil, so an unlabelled resource is encoded
+// as {} rather than null.
+func unmarshalLabels(data []byte) domain.Labels {
+	labels := domain.Labels{}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &labels)
 	}
 
-	return r.db.conn.Save(&model).Error
+	return labels
 }
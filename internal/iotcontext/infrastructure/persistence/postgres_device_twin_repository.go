@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresTwinRepository struct {
+	db *DB
+}
+
+func NewPostgresTwinRepository(db *DB) domain.TwinRepository {
+	return &PostgresTwinRepository{db: db}
+}
+
+func (r *PostgresTwinRepository) FindByDeviceID(deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	return findTwin(r.db.conn, deviceID)
+}
+
+func (r *PostgresTwinRepository) Save(twin *domain.DeviceTwin, previousVersion int64) error {
+	return saveTwin(r.db.conn, twin, previousVersion)
+}
+
+func findTwin(conn *gorm.DB, deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	var model DeviceTwinModel
+	if err := conn.First(&model, "device_id = ?", string(deviceID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTwinNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalTwin(&model)
+}
+
+// saveTwin inserts the first version of a twin and afterwards only updates the
+// row when its version is still the one the caller loaded.
+func saveTwin(conn *gorm.DB, twin *domain.DeviceTwin, previousVersion int64) error {
+	model, err := marshalTwin(twin)
+	if err != nil {
+		return err
+	}
+
+	if previousVersion == 0 {
+		if err := conn.Create(model).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return domain.ErrTwinVersionConflict
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	result := conn.Model(&DeviceTwinModel{}).
+		Where("device_id = ? AND version = ?", model.DeviceID, previousVersion).
+		Select("*").
+		Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrTwinVersionConflict
+	}
+
+	return nil
+}
+
+func marshalTwin(twin *domain.DeviceTwin) (*DeviceTwinModel, error) {
+	desired, err := json.Marshal(twin.Desired)
+	if err != nil {
+		return nil, err
+	}
+
+	reported, err := json.Marshal(twin.Reported)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeviceTwinModel{
+		DeviceID:        string(twin.DeviceID),
+		Desired:         desired,
+		Reported:        reported,
+		DesiredVersion:  twin.DesiredVersion,
+		ReportedVersion: twin.ReportedVersion,
+		Version:         twin.Version,
+		UpdatedAt:       twin.UpdatedAt.UTC(),
+	}, nil
+}
+
+func unmarshalTwin(model *DeviceTwinModel) (*domain.DeviceTwin, error) {
+	twin := domain.NewDeviceTwin(domain.DeviceID(model.DeviceID))
+
+	if len(model.Desired) > 0 {
+		if err := json.Unmarshal(model.Desired, &twin.Desired); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(model.Reported) > 0 {
+		if err := json.Unmarshal(model.Reported, &twin.Reported); err != nil {
+			return nil, err
+		}
+	}
+
+	twin.DesiredVersion = model.DesiredVersion
+	twin.ReportedVersion = model.ReportedVersion
+	twin.Version = model.Version
+	twin.UpdatedAt = model.UpdatedAt.UTC()
+
+	return twin, nil
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresFirmwareRepository struct {
+	db *DB
+}
+
+func NewPostgresFirmwareRepository(db *DB) domain.FirmwareRepository {
+	return &PostgresFirmwareRepository{db: db}
+}
+
+func (r *PostgresFirmwareRepository) Save(firmware *domain.Firmware) error {
+	return saveFirmware(r.db.conn, firmware)
+}
+
+func (r *PostgresFirmwareRepository) FindByID(id domain.FirmwareID) (*domain.Firmware, error) {
+	return findFirmware(r.db.conn, id)
+}
+
+func (r *PostgresFirmwareRepository) FindAll() ([]*domain.Firmware, error) {
+	return findAllFirmware(r.db.conn)
+}
+
+func saveFirmware(conn *gorm.DB, firmware *domain.Firmware) error {
+	if err := conn.Create(marshalFirmware(firmware)).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrFirmwareAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func findFirmware(conn *gorm.DB, id domain.FirmwareID) (*domain.Firmware, error) {
+	var model FirmwareModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrFirmwareNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalFirmware(&model), nil
+}
+
+func findAllFirmware(conn *gorm.DB) ([]*domain.Firmware, error) {
+	var models []FirmwareModel
+	if err := conn.Order("created_at DESC, id DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	firmwares := make([]*domain.Firmware, 0, len(models))
+	for i := range models {
+		firmwares = append(firmwares, unmarshalFirmware(&models[i]))
+	}
+
+	return firmwares, nil
+}
+
+func marshalFirmware(firmware *domain.Firmware) *FirmwareModel {
+	return &FirmwareModel{
+		ID:         string(firmware.ID),
+		Version:    firmware.Version,
+		DeviceType: firmware.DeviceType,
+		Checksum:   firmware.Checksum,
+		Size:       firmware.Size,
+		CreatedAt:  firmware.CreatedAt.UTC(),
+	}
+}
+
+func unmarshalFirmware(model *FirmwareModel) *domain.Firmware {
+	return &domain.Firmware{
+		ID:         domain.FirmwareID(model.ID),
+		Version:    model.Version,
+		DeviceType: model.DeviceType,
+		Checksum:   model.Checksum,
+		Size:       model.Size,
+		CreatedAt:  model.CreatedAt.UTC(),
+	}
+}
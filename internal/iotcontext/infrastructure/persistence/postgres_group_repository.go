@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresGroupRepository struct {
+	db *DB
+}
+
+func NewPostgresGroupRepository(db *DB) domain.GroupRepository {
+	return &PostgresGroupRepository{db: db}
+}
+
+func (r *PostgresGroupRepository) Save(group *domain.DeviceGroup) error {
+	return saveGroup(r.db.conn, group)
+}
+
+func (r *PostgresGroupRepository) Update(group *domain.DeviceGroup) error {
+	return updateGroup(r.db.conn, group)
+}
+
+func (r *PostgresGroupRepository) Delete(id domain.GroupID) error {
+	return deleteGroup(r.db.conn, id)
+}
+
+func (r *PostgresGroupRepository) FindByID(id domain.GroupID) (*domain.DeviceGroup, error) {
+	return findGroup(r.db.conn, id)
+}
+
+func (r *PostgresGroupRepository) FindAll() ([]*domain.DeviceGroup, error) {
+	return findAllGroups(r.db.conn)
+}
+
+func (r *PostgresGroupRepository) FindByTenant(tenant domain.TenantID) ([]*domain.DeviceGroup, error) {
+	return findAllGroups(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
+
+func saveGroup(conn *gorm.DB, group *domain.DeviceGroup) error {
+	if err := conn.Create(marshalGroup(group)).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrGroupAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func updateGroup(conn *gorm.DB, group *domain.DeviceGroup) error {
+	model := marshalGroup(group)
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return domain.ErrGroupAlreadyExists
+		}
+
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+func deleteGroup(conn *gorm.DB, id domain.GroupID) error {
+	result := conn.Delete(&DeviceGroupModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+func findGroup(conn *gorm.DB, id domain.GroupID) (*domain.DeviceGroup, error) {
+	var model DeviceGroupModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrGroupNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalGroup(&model), nil
+}
+
+func findAllGroups(conn *gorm.DB) ([]*domain.DeviceGroup, error) {
+	var models []DeviceGroupModel
+	if err := conn.Order("name ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	groups := make([]*domain.DeviceGroup, 0, len(models))
+	for i := range models {
+		groups = append(groups, unmarshalGroup(&models[i]))
+	}
+
+	return groups, nil
+}
+
+func marshalGroup(group *domain.DeviceGroup) *DeviceGroupModel {
+	deviceIDs := group.DeviceIDs
+	if deviceIDs == nil {
+		deviceIDs = []domain.DeviceID{}
+	}
+	members, _ := json.Marshal(deviceIDs)
+
+	return &DeviceGroupModel{
+		ID:        string(group.ID),
+		TenantID:  string(group.TenantID),
+		Name:      group.Name,
+		Selector:  group.Selector,
+		DeviceIDs: members,
+		CreatedAt: group.CreatedAt.UTC(),
+		UpdatedAt: group.UpdatedAt.UTC(),
+	}
+}
+
+func unmarshalGroup(model *DeviceGroupModel) *domain.DeviceGroup {
+	var deviceIDs []domain.DeviceID
+	_ = json.Unmarshal(model.DeviceIDs, &deviceIDs)
+
+	return &domain.DeviceGroup{
+		ID:        domain.GroupID(model.ID),
+		TenantID:  domain.TenantID(model.TenantID),
+		Name:      model.Name,
+		Selector:  model.Selector,
+		DeviceIDs: deviceIDs,
+		CreatedAt: model.CreatedAt.UTC(),
+		UpdatedAt: model.UpdatedAt.UTC(),
+	}
+}
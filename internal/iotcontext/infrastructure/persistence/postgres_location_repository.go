@@ -0,0 +1,177 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresLocationRepository struct {
+	db *DB
+}
+
+func NewPostgresLocationRepository(db *DB) domain.LocationRepository {
+	return &PostgresLocationRepository{db: db}
+}
+
+func (r *PostgresLocationRepository) Save(location *domain.Location) error {
+	return saveLocation(r.db.conn, location)
+}
+
+func (r *PostgresLocationRepository) Update(location *domain.Location) error {
+	return updateLocation(r.db.conn, location)
+}
+
+func (r *PostgresLocationRepository) Delete(id domain.LocationID) error {
+	return deleteLocation(r.db.conn, id)
+}
+
+func (r *PostgresLocationRepository) FindByID(id domain.LocationID) (*domain.Location, error) {
+	return findLocation(r.db.conn, id)
+}
+
+func (r *PostgresLocationRepository) FindAll() ([]*domain.Location, error) {
+	return findAllLocations(r.db.conn)
+}
+
+func (r *PostgresLocationRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Location, error) {
+	return findAllLocations(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
+
+func saveLocation(conn *gorm.DB, location *domain.Location) error {
+	if err := conn.Create(marshalLocation(location)).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrLocationAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func updateLocation(conn *gorm.DB, location *domain.Location) error {
+	model := marshalLocation(location)
+
+	result := conn.Model(model).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrLocationNotFound
+	}
+
+	return nil
+}
+
+// deleteLocation checks for children itself instead of relying on the
+// foreign key, whose violation SQLite does not report in a portable way.
+func deleteLocation(conn *gorm.DB, id domain.LocationID) error {
+	var children int64
+	if err := conn.Model(&LocationModel{}).Where("parent_id = ?", string(id)).Count(&children).Error; err != nil {
+		return err
+	}
+
+	if children > 0 {
+		return domain.ErrLocationInUse
+	}
+
+	result := conn.Delete(&LocationModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrLocationNotFound
+	}
+
+	return nil
+}
+
+func findLocation(conn *gorm.DB, id domain.LocationID) (*domain.Location, error) {
+	var model LocationModel
+	if err := conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLocationNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalLocation(&model), nil
+}
+
+func findAllLocations(conn *gorm.DB) ([]*domain.Location, error) {
+	var models []LocationModel
+	if err := conn.Order("name ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	locations := make([]*domain.Location, 0, len(models))
+	for i := range models {
+		locations = append(locations, unmarshalLocation(&models[i]))
+	}
+
+	return locations, nil
+}
+
+func marshalLocation(location *domain.Location) *LocationModel {
+	model := &LocationModel{
+		ID:        string(location.ID),
+		TenantID:  string(location.TenantID),
+		Kind:      string(location.Kind),
+		Name:      location.Name,
+		CreatedAt: location.CreatedAt.UTC(),
+		UpdatedAt: location.UpdatedAt.UTC(),
+	}
+
+	if location.ParentID != "" {
+		parentID := string(location.ParentID)
+		model.ParentID = &parentID
+	}
+
+	if location.Geo != nil {
+		model.Latitude, model.Longitude = &location.Geo.Lat, &location.Geo.Lon
+	}
+
+	if location.Indoor != nil {
+		model.IndoorX, model.IndoorY, model.IndoorFloor = &location.Indoor.X, &location.Indoor.Y, &location.Indoor.Floor
+	}
+
+	return model
+}
+
+func unmarshalLocation(model *LocationModel) *domain.Location {
+	location := &domain.Location{
+		ID:        domain.LocationID(model.ID),
+		TenantID:  domain.TenantID(model.TenantID),
+		Kind:      domain.LocationKind(model.Kind),
+		Name:      model.Name,
+		Geo:       unmarshalGeo(model.Latitude, model.Longitude),
+		CreatedAt: model.CreatedAt.UTC(),
+		UpdatedAt: model.UpdatedAt.UTC(),
+	}
+
+	if model.ParentID != nil {
+		location.ParentID = domain.LocationID(*model.ParentID)
+	}
+
+	if model.IndoorX != nil && model.IndoorY != nil {
+		location.Indoor = &domain.IndoorPosition{X: *model.IndoorX, Y: *model.IndoorY}
+		if model.IndoorFloor != nil {
+			location.Indoor.Floor = *model.IndoorFloor
+		}
+	}
+
+	return location
+}
+
+// unmarshalGeo returns nil unless both coordinates are set.
+func unmarshalGeo(lat, lon *float64) *domain.GeoPoint {
+	if lat == nil || lon == nil {
+		return nil
+	}
+
+	return &domain.GeoPoint{Lat: *lat, Lon: *lon}
+}
@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PostgresPresenceRepository struct {
+	db *DB
+}
+
+func NewPostgresPresenceRepository(db *DB) domain.PresenceRepository {
+	return &PostgresPresenceRepository{db: db}
+}
+
+// Touch only moves last_seen forward, so a late heartbeat cannot make a
+// device look older than it is.
+func (r *PostgresPresenceRepository) Touch(deviceID domain.DeviceID, at time.Time) error {
+	return r.db.conn.Exec(`
+		INSERT INTO device_presence AS p (device_id, last_seen)
+		VALUES (?, ?)
+		ON CONFLICT (device_id) DO UPDATE SET
+			last_seen = GREATEST(p.last_seen, EXCLUDED.last_seen)`,
+		string(deviceID),
+		at.UTC(),
+	).Error
+}
+
+func (r *PostgresPresenceRepository) LastSeen(deviceID domain.DeviceID) (time.Time, error) {
+	return findLastSeen(r.db.conn, deviceID)
+}
+
+func (r *PostgresPresenceRepository) All() (map[domain.DeviceID]time.Time, error) {
+	return findAllLastSeen(r.db.conn)
+}
+
+// findLastSeen returns the zero time for a device that was never seen.
+func findLastSeen(conn *gorm.DB, deviceID domain.DeviceID) (time.Time, error) {
+	var models []PresenceModel
+	if err := conn.Where("device_id = ?", string(deviceID)).Limit(1).Find(&models).Error; err != nil {
+		return time.Time{}, err
+	}
+
+	if len(models) == 0 {
+		return time.Time{}, nil
+	}
+
+	return models[0].LastSeen.UTC(), nil
+}
+
+func findAllLastSeen(conn *gorm.DB) (map[domain.DeviceID]time.Time, error) {
+	var models []PresenceModel
+	if err := conn.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	all := make(map[domain.DeviceID]time.Time, len(models))
+	for _, model := range models {
+		all[domain.DeviceID(model.DeviceID)] = model.LastSeen.UTC()
+	}
+
+	return all, nil
+}
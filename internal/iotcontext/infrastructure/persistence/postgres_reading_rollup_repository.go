@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+var rollupTables = map[domain.Resolution]string{
+	domain.ResolutionMinute: "sensor_reading_rollups_1m",
+	domain.ResolutionHour:   "sensor_reading_rollups_1h",
+	domain.ResolutionDay:    "sensor_reading_rollups_1d",
+}
+
+type PostgresReadingRollupRepository struct {
+	db *DB
+}
+
+func NewPostgresReadingRollupRepository(db *DB) domain.ReadingRollupRepository {
+	return &PostgresReadingRollupRepository{db: db}
+}
+
+func (r *PostgresReadingRollupRepository) Apply(reading domain.SensorReading) error {
+	for _, resolution := range domain.RollupResolutions {
+		aggregate := domain.NewReadingAggregate(reading, resolution)
+
+		stmt := fmt.Sprintf(`
+			INSERT INTO %[1]s AS r (sensor_id, bucket, min_value, max_value, sum_value, count, last_value, last_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (sensor_id, bucket) DO UPDATE SET
+				min_value = LEAST(r.min_value, EXCLUDED.min_value),
+				max_value = GREATEST(r.max_value, EXCLUDED.max_value),
+				sum_value = r.sum_value + EXCLUDED.sum_value,
+				count = r.count + EXCLUDED.count,
+				last_value = CASE WHEN EXCLUDED.last_at >= r.last_at THEN EXCLUDED.last_value ELSE r.last_value END,
+				last_at = GREATEST(r.last_at, EXCLUDED.last_at)`, rollupTables[resolution])
+
+		err := r.db.conn.Exec(stmt,
+			string(aggregate.SensorID),
+			aggregate.Bucket,
+			aggregate.Min,
+			aggregate.Max,
+			aggregate.Sum,
+			aggregate.Count,
+			aggregate.Last,
+			aggregate.LastAt,
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresReadingRollupRepository) FindAggregates(sensorID domain.SensorID, resolution domain.Resolution, from, to time.Time) ([]domain.ReadingAggregate, error) {
+	table, ok := rollupTables[resolution]
+	if !ok {
+		return nil, fmt.Errorf("no rollup table for resolution %q", resolution)
+	}
+
+	var models []ReadingRollupModel
+	err := r.db.conn.Table(table).
+		Where("sensor_id = ? AND bucket >= ? AND bucket < ?", string(sensorID), resolution.Truncate(from), to.UTC()).
+		Order("bucket ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalAggregates(models, resolution), nil
+}
+
+func unmarshalAggregates(models []ReadingRollupModel, resolution domain.Resolution) []domain.ReadingAggregate {
+	aggregates := make([]domain.ReadingAggregate, 0, len(models))
+	for _, model := range models {
+		aggregates = append(aggregates, domain.ReadingAggregate{
+			SensorID:   domain.SensorID(model.SensorID),
+			Resolution: resolution,
+			Bucket:     model.Bucket.UTC(),
+			Min:        model.MinValue,
+			Max:        model.MaxValue,
+			Sum:        model.SumValue,
+			Count:      model.Count,
+			Last:       model.LastValue,
+			LastAt:     model.LastAt.UTC(),
+		})
+	}
+
+	return aggregates
+}
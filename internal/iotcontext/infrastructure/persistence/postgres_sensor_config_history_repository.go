@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type PostgresSensorConfigHistoryRepository struct {
+	db *DB
+}
+
+func NewPostgresSensorConfigHistoryRepository(db *DB) domain.SensorConfigHistoryRepository {
+	return &PostgresSensorConfigHistoryRepository{db: db}
+}
+
+func (r *PostgresSensorConfigHistoryRepository) Append(revision *domain.SensorConfigRevision) error {
+	return appendConfigRevision(r.db.conn, revision)
+}
+
+func (r *PostgresSensorConfigHistoryRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]*domain.SensorConfigRevision, error) {
+	return findConfigRevisions(r.db.conn, sensorID, limit)
+}
+
+func (r *PostgresSensorConfigHistoryRepository) FindRevision(sensorID domain.SensorID, revision int64) (*domain.SensorConfigRevision, error) {
+	return findConfigRevision(r.db.conn, sensorID, revision)
+}
+
+// appendConfigRevision relies on the primary key of (sensor_id, revision)
+// to turn a concurrent change into a conflict.
+func appendConfigRevision(conn *gorm.DB, revision *domain.SensorConfigRevision) error {
+	model, err := marshalConfigRevision(revision)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Create(model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrSensorConfigConflict
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func findConfigRevisions(conn *gorm.DB, sensorID domain.SensorID, limit int) ([]*domain.SensorConfigRevision, error) {
+	query := conn.Where("sensor_id = ?", string(sensorID)).Order("revision DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var models []SensorConfigRevisionModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*domain.SensorConfigRevision, 0, len(models))
+	for i := range models {
+		revision, err := unmarshalConfigRevision(&models[i])
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func findConfigRevision(conn *gorm.DB, sensorID domain.SensorID, revision int64) (*domain.SensorConfigRevision, error) {
+	var model SensorConfigRevisionModel
+	if err := conn.First(&model, "sensor_id = ? AND revision = ?", string(sensorID), revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSensorConfigRevisionNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalConfigRevision(&model)
+}
+
+func marshalConfigRevision(revision *domain.SensorConfigRevision) (*SensorConfigRevisionModel, error) {
+	config, err := json.Marshal(revision.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SensorConfigRevisionModel{
+		SensorID:     string(revision.SensorID),
+		Revision:     revision.Revision,
+		Config:       config,
+		ChangedBy:    revision.ChangedBy,
+		RestoredFrom: revision.RestoredFrom,
+		CreatedAt:    revision.CreatedAt.UTC(),
+	}, nil
+}
+
+func unmarshalConfigRevision(model *SensorConfigRevisionModel) (*domain.SensorConfigRevision, error) {
+	var config domain.SensorConfig
+	if err := json.Unmarshal(model.Config, &config); err != nil {
+		return nil, err
+	}
+
+	return &domain.SensorConfigRevision{
+		SensorID:     domain.SensorID(model.SensorID),
+		Revision:     model.Revision,
+		Config:       config,
+		ChangedBy:    model.ChangedBy,
+		RestoredFrom: model.RestoredFrom,
+		CreatedAt:    model.CreatedAt.UTC(),
+	}, nil
+}
@@ -3,6 +3,9 @@ package persistence
 import (
 	"encoding/json"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
 )
 
 type PostgresSensorReadingRepository struct {
@@ -14,23 +17,14 @@ func NewPostgresSensorReadingRepository(db *DB) domain.SensorReadingRepository {
 }
 
 func (r *PostgresSensorReadingRepository) Save(reading *domain.SensorReading) error {
-	model := &SensorReadingModel{
-		ID:        reading.ID,
-		SensorID:  string(reading.SensorID),
-		DeviceID:  string(reading.DeviceID),
-		Type:      string(reading.Type),
-		Value:     reading.Value,
-		Unit:      reading.Unit,
-		Timestamp: reading.Timestamp,
-		Meta:      marshalMeta(reading.Meta),
-	}
+	model := marshalReading(reading)
 
 	return r.db.conn.Create(model).Error
 }
 
 func (r *PostgresSensorReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
 	var models []SensorReadingModel
-	query := r.db.conn.Where("sensor_id = ?", string(sensorID))
+	query := r.db.conn.Where("sensor_id = ?", string(sensorID)).Order("timestamp DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -45,6 +39,63 @@ func (r *PostgresSensorReadingRepository) FindBySensorID(sensorID domain.SensorI
 	return readings, nil
 }
 
+func (r *PostgresSensorReadingRepository) FindBySensorIDBetween(sensorID domain.SensorID, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	var models []SensorReadingModel
+	query := r.db.conn.
+		Where("sensor_id = ? AND timestamp >= ? AND timestamp < ?", string(sensorID), from.UTC(), to.UTC()).
+		Order("timestamp ASC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalMeta(models), nil
+}
+
+func (r *PostgresSensorReadingRepository) FindLatestBySensorIDs(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	return findLatestReadings(r.db.conn, sensorIDs)
+}
+
+// findLatestReadings ranks the readings of each sensor newest first and keeps
+// the first, in a single query however many sensors there are.
+func findLatestReadings(conn *gorm.DB, sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	if len(sensorIDs) == 0 {
+		return nil, nil
+	}
+
+	var models []SensorReadingModel
+	ranked := conn.Model(&SensorReadingModel{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY sensor_id ORDER BY timestamp DESC) AS position").
+		Where("sensor_id IN ?", sensorIDs)
+	if err := conn.Table("(?) AS ranked", ranked).Where("position = 1").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalMeta(models), nil
+}
+
+func marshalReading(reading *domain.SensorReading) *SensorReadingModel {
+	if reading.ID == "" {
+		reading.ID = uuid.New().String()
+	}
+
+	return &SensorReadingModel{
+		ID:        reading.ID,
+		TenantID:  string(reading.TenantID),
+		SensorID:  string(reading.SensorID),
+		DeviceID:  string(reading.DeviceID),
+		Type:      string(reading.Type),
+		Value:     reading.Value,
+		Unit:      reading.Unit,
+		Timestamp: reading.Timestamp.UTC(),
+		Meta:      marshalMeta(reading.Meta),
+	}
+}
+
 func marshalMeta(meta map[string]interface{}) []byte {
 	b, _ := json.Marshal(meta)
 
@@ -59,12 +110,13 @@ func unmarshalMeta(models []SensorReadingModel) []domain.SensorReading {
 
 		reading := domain.SensorReading{
 			ID:        model.ID,
+			TenantID:  domain.TenantID(model.TenantID),
 			SensorID:  domain.SensorID(model.SensorID),
 			DeviceID:  domain.DeviceID(model.DeviceID),
 			Type:      domain.SensorType(model.Type),
 			Value:     model.Value,
 			Unit:      model.Unit,
-			Timestamp: model.Timestamp,
+			Timestamp: model.Timestamp.UTC(),
 			Meta:      meta,
 		}
 
@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const readingsTable = "sensor_readings_models"
+
+const partitionTimeLayout = "2006-01-02 15:04:05"
+
+var partitionBoundRe = regexp.MustCompile(`FROM \('([^']+)'\) TO \('([^']+)'\)`)
+
+type PostgresSensorReadingRetentionRepository struct {
+	db *DB
+}
+
+func NewPostgresSensorReadingRetentionRepository(db *DB) domain.SensorReadingRetentionRepository {
+	return &PostgresSensorReadingRetentionRepository{db: db}
+}
+
+func (r *PostgresSensorReadingRetentionRepository) ListPartitions() ([]domain.ReadingsPartition, error) {
+	var rows []struct {
+		Name  string
+		Bound string
+	}
+
+	err := r.db.conn.Raw(`
+		SELECT child.relname AS name, pg_get_expr(child.relpartbound, child.oid) AS bound
+		FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = ?
+		ORDER BY child.relname`, readingsTable).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []domain.ReadingsPartition
+	for _, row := range rows {
+		match := partitionBoundRe.FindStringSubmatch(row.Bound)
+		if match == nil {
+			continue
+		}
+
+		from, err := time.Parse(partitionTimeLayout, match[1])
+		if err != nil {
+			return nil, fmt.Errorf("partition %s: %w", row.Name, err)
+		}
+
+		to, err := time.Parse(partitionTimeLayout, match[2])
+		if err != nil {
+			return nil, fmt.Errorf("partition %s: %w", row.Name, err)
+		}
+
+		partitions = append(partitions, domain.ReadingsPartition{Name: row.Name, From: from, To: to})
+	}
+
+	return partitions, nil
+}
+
+func (r *PostgresSensorReadingRetentionRepository) CreatePartition(partition domain.ReadingsPartition) error {
+	name := partition.Name
+	if name == "" {
+		name = partitionName(partition)
+	}
+
+	stmt := fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+		quoteIdentifier(name),
+		readingsTable,
+		partition.From.UTC().Format(partitionTimeLayout),
+		partition.To.UTC().Format(partitionTimeLayout),
+	)
+
+	return r.db.conn.Exec(stmt).Error
+}
+
+func (r *PostgresSensorReadingRetentionRepository) DropPartition(partition domain.ReadingsPartition) error {
+	if !strings.HasPrefix(partition.Name, readingsTable+"_p") {
+		return fmt.Errorf("refusing to drop %q: not a readings partition", partition.Name)
+	}
+
+	return r.db.conn.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, quoteIdentifier(partition.Name))).Error
+}
+
+func (r *PostgresSensorReadingRetentionRepository) DeleteReadingsBefore(scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	return deleteReadingsBefore(r.db, scope, cutoff)
+}
+
+func deleteReadingsBefore(db *DB, scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	query := db.conn.Table(readingsTable).Where("timestamp < ?", cutoff.UTC())
+
+	if scope.SensorType != "" {
+		query = query.Where("type = ?", string(scope.SensorType))
+	}
+
+	if scope.DeviceID != "" {
+		query = query.Where("device_id = ?", string(scope.DeviceID))
+	}
+
+	if len(scope.ExcludeTypes) > 0 {
+		query = query.Where("type NOT IN ?", sensorTypeStrings(scope.ExcludeTypes))
+	}
+
+	if len(scope.ExcludeDevices) > 0 {
+		query = query.Where("device_id NOT IN ?", deviceIDStrings(scope.ExcludeDevices))
+	}
+
+	result := query.Delete(&SensorReadingModel{})
+
+	return result.RowsAffected, result.Error
+}
+
+func partitionName(partition domain.ReadingsPartition) string {
+	if partition.To.Sub(partition.From) < 28*24*time.Hour {
+		return readingsTable + "_p" + partition.From.UTC().Format("20060102")
+	}
+
+	return readingsTable + "_p" + partition.From.UTC().Format("200601")
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sensorTypeStrings(types []domain.SensorType) []string {
+	values := make([]string, 0, len(types))
+	for _, typ := range types {
+		values = append(values, string(typ))
+	}
+
+	return values
+}
+
+func deviceIDStrings(ids []domain.DeviceID) []string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, string(id))
+	}
+
+	return values
+}
@@ -30,49 +30,116 @@ func (r *PostgresSensorRepository) FindByID(id domain.SensorID) (*domain.Sensor,
 
 func (r *PostgresSensorRepository) FindAll() ([]*domain.Sensor, error) {
 	var models []SensorModel
-	if err := r.db.conn.Find(&models).Error; err != nil {
+	if err := r.db.conn.Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
 		return nil, err
 	}
 
-	var sensors []*domain.Sensor
-	for _, model := range models {
-		sensor, err := unmarshalSensor(&model)
-		if err != nil {
-			return nil, err
-		}
+	return unmarshalSensors(models)
+}
 
-		sensors = append(sensors, sensor)
+func (r *PostgresSensorRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("tenant_id = ?", string(tenant)).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
 	}
 
-	return sensors, nil
+	return unmarshalSensors(models)
+}
+
+func (r *PostgresSensorRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("device_id = ?", string(deviceID)).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
+}
+
+func (r *PostgresSensorRepository) FindByDeviceIDs(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("device_id IN ?", deviceIDs).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
 }
 
 func (r *PostgresSensorRepository) Save(sensor *domain.Sensor) error {
-	model := SensorModel{
-		ID:        string(sensor.ID),
-		DeviceID:  string(sensor.DeviceID),
-		Name:      sensor.Name,
-		Type:      string(sensor.Type),
-		Config:    marshalConfig(sensor.Config),
-		CreatedAt: sensor.CreatedAt,
-		UpdatedAt: sensor.UpdatedAt,
+	model := marshalSensor(sensor)
+
+	if err := r.db.conn.Create(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrSensorAlreadyExists
+		}
+
+		return err
 	}
 
-	return r.db.conn.Create(&model).Error
+	return nil
 }
 
 func (r *PostgresSensorRepository) Update(sensor *domain.Sensor) error {
-	model := SensorModel{
+	return updateSensor(r.db.conn, sensor)
+}
+
+// updateSensor writes the sensor only while the stored copy still has the
+// version it was loaded with, and moves it on to the next version.
+func updateSensor(conn *gorm.DB, sensor *domain.Sensor) error {
+	model := marshalSensor(sensor)
+	model.Version = sensor.Version + 1
+
+	result := conn.Model(&SensorModel{}).
+		Where("id = ? AND version = ?", model.ID, sensor.Version).
+		Select("*").
+		Updates(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := conn.Model(&SensorModel{}).Where("id = ?", model.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return domain.ErrSensorNotFound
+		}
+
+		return domain.ErrSensorVersionConflict
+	}
+
+	sensor.Version = model.Version
+
+	return nil
+}
+
+func (r *PostgresSensorRepository) Delete(id domain.SensorID) error {
+	result := r.db.conn.Delete(&SensorModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrSensorNotFound
+	}
+
+	return nil
+}
+
+func marshalSensor(sensor *domain.Sensor) SensorModel {
+	return SensorModel{
 		ID:        string(sensor.ID),
+		TenantID:  string(sensor.TenantID),
 		DeviceID:  string(sensor.DeviceID),
 		Name:      sensor.Name,
 		Type:      string(sensor.Type),
 		Config:    marshalConfig(sensor.Config),
-		CreatedAt: sensor.CreatedAt,
-		UpdatedAt: sensor.UpdatedAt,
+		Labels:    marshalLabels(sensor.Labels),
+		CreatedAt: sensor.CreatedAt.UTC(),
+		UpdatedAt: sensor.UpdatedAt.UTC(),
+		Version:   sensor.Version,
 	}
-
-	return r.db.conn.Save(&model).Error
 }
 
 func marshalConfig(config domain.SensorConfig) []byte {
@@ -80,6 +147,20 @@ func marshalConfig(config domain.SensorConfig) []byte {
 	return b
 }
 
+func unmarshalSensors(models []SensorModel) ([]*domain.Sensor, error) {
+	var sensors []*domain.Sensor
+	for _, model := range models {
+		sensor, err := unmarshalSensor(&model)
+		if err != nil {
+			return nil, err
+		}
+
+		sensors = append(sensors, sensor)
+	}
+
+	return sensors, nil
+}
+
 func unmarshalSensor(model *SensorModel) (*domain.Sensor, error) {
 	var config domain.SensorConfig
 	if err := json.Unmarshal(model.Config, &config); err != nil {
@@ -87,11 +168,14 @@ func unmarshalSensor(model *SensorModel) (*domain.Sensor, error) {
 	}
 	return &domain.Sensor{
 		ID:        domain.SensorID(model.ID),
+		TenantID:  domain.TenantID(model.TenantID),
 		DeviceID:  domain.DeviceID(model.DeviceID),
 		Name:      model.Name,
 		Type:      domain.SensorType(model.Type),
 		Config:    config,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
+		Labels:    unmarshalLabels(model.Labels),
+		CreatedAt: model.CreatedAt.UTC(),
+		UpdatedAt: model.UpdatedAt.UTC(),
+		Version:   model.Version,
 	}, nil
 }
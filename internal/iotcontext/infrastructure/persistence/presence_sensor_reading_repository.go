@@ -0,0 +1,28 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+// PresenceSensorReadingRepository marks the device of every stored reading as
+// seen, so sensors that report data count as a heartbeat.
+type PresenceSensorReadingRepository struct {
+	domain.SensorReadingRepository
+	presence domain.PresenceRepository
+}
+
+func NewPresenceSensorReadingRepository(readings domain.SensorReadingRepository, presence domain.PresenceRepository) domain.SensorReadingRepository {
+	return &PresenceSensorReadingRepository{
+		SensorReadingRepository: readings,
+		presence:                presence,
+	}
+}
+
+func (r *PresenceSensorReadingRepository) Save(reading *domain.SensorReading) error {
+	if err := r.SensorReadingRepository.Save(reading); err != nil {
+		return err
+	}
+
+	return r.presence.Touch(reading.DeviceID, time.Now().UTC())
+}
@@ -0,0 +1,25 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+// RollupSensorReadingRepository keeps the rollup tables up to date with every
+// reading stored through the wrapped repository.
+type RollupSensorReadingRepository struct {
+	domain.SensorReadingRepository
+	rollups domain.ReadingRollupRepository
+}
+
+func NewRollupSensorReadingRepository(readings domain.SensorReadingRepository, rollups domain.ReadingRollupRepository) domain.SensorReadingRepository {
+	return &RollupSensorReadingRepository{
+		SensorReadingRepository: readings,
+		rollups:                 rollups,
+	}
+}
+
+func (r *RollupSensorReadingRepository) Save(reading *domain.SensorReading) error {
+	if err := r.SensorReadingRepository.Save(reading); err != nil {
+		return err
+	}
+
+	return r.rollups.Apply(*reading)
+}
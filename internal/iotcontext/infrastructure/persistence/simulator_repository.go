@@ -2,8 +2,10 @@ package persistence
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
@@ -31,7 +33,7 @@ func (s *SimulatorRepositoryImpl) Start(sensorID domain.SensorID) error {
 	defer s.mu.Unlock()
 
 	if _, ok := s.activeSensors[sensorID]; ok {
-		return errors.New("sensor already active")
+		return domain.ErrSimulationAlreadyActive
 	}
 
 	sensor, err := s.sensorRepo.FindByID(sensorID)
@@ -39,14 +41,13 @@ func (s *SimulatorRepositoryImpl) Start(sensorID domain.SensorID) error {
 		return err
 	}
 	if !sensor.Config.Enabled {
-		return errors.New("sensor is disabled")
+		return domain.ErrSensorDisabled
 	}
 
 	state := &simulatorState{
-		sensor:      sensor,
-		stopCh:      make(chan struct{}),
-		ticker:      time.NewTicker(time.Duration(sensor.Config.SamplingRateMs) * time.Millisecond),
-		injectError: false,
+		sensor: sensor,
+		stopCh: make(chan struct{}),
+		ticker: time.NewTicker(time.Duration(sensor.Config.SamplingRateMs) * time.Millisecond),
 	}
 	s.activeSensors[sensorID] = state
 
@@ -61,7 +62,7 @@ func (s *SimulatorRepositoryImpl) Stop(sensorID domain.SensorID) error {
 
 	state, ok := s.activeSensors[sensorID]
 	if !ok {
-		return errors.New("sensor not active")
+		return domain.ErrSimulationNotActive
 	}
 
 	close(state.stopCh)
@@ -77,14 +78,89 @@ func (s *SimulatorRepositoryImpl) InjectError(sensorID domain.SensorID) error {
 
 	state, ok := s.activeSensors[sensorID]
 	if !ok {
-		return errors.New("sensor not active")
+		return domain.ErrSimulationNotActive
 	}
 
-	state.injectError = true
+	state.injectError.Store(true)
 
 	return nil
 }
 
+// Execute makes the simulator behave as the device: the command is applied to
+// the running simulations of the device's sensors.
+func (s *SimulatorRepositoryImpl) Execute(command domain.Command) (map[string]interface{}, error) {
+	sensorIDs := s.activeSensorsOf(command.DeviceID)
+	if len(sensorIDs) == 0 {
+		return nil, domain.ErrSimulationNotActive
+	}
+
+	result := map[string]interface{}{"sensors": len(sensorIDs)}
+
+	switch command.Name {
+	case domain.CommandReboot, domain.CommandUpdateFirmware:
+		// Installing a firmware image ends, like a reboot, with the sensors
+		// starting again.
+		for _, sensorID := range sensorIDs {
+			if err := s.Stop(sensorID); err != nil && !errors.Is(err, domain.ErrSimulationNotActive) {
+				return nil, err
+			}
+
+			if err := s.Start(sensorID); err != nil {
+				return nil, err
+			}
+		}
+
+		if version, ok := command.Params["version"]; ok && command.Name == domain.CommandUpdateFirmware {
+			result["version"] = version
+		}
+	case domain.CommandRecalibrate:
+		s.mu.RLock()
+		for _, sensorID := range sensorIDs {
+			if state, ok := s.activeSensors[sensorID]; ok {
+				state.injectError.Store(false)
+			}
+		}
+		s.mu.RUnlock()
+	case domain.CommandSetSamplingRate:
+		rate, ok := command.Params["sampling_rate_ms"].(float64)
+		if !ok || rate < 1 {
+			return nil, fmt.Errorf("%w: sampling_rate_ms must be a positive number", domain.ErrInvalidCommand)
+		}
+
+		s.mu.RLock()
+		for _, sensorID := range sensorIDs {
+			if state, ok := s.activeSensors[sensorID]; ok {
+				state.ticker.Reset(time.Duration(rate) * time.Millisecond)
+			}
+		}
+		s.mu.RUnlock()
+
+		result["sampling_rate_ms"] = rate
+	default:
+		return nil, fmt.Errorf("%w: unsupported command %q", domain.ErrInvalidCommand, command.Name)
+	}
+
+	return result, nil
+}
+
+func (s *SimulatorRepositoryImpl) Simulates(deviceID domain.DeviceID) bool {
+	return len(s.activeSensorsOf(deviceID)) > 0
+}
+
+func (s *SimulatorRepositoryImpl) activeSensorsOf(deviceID domain.DeviceID) []domain.SensorID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sensorIDs []domain.SensorID
+	for sensorID, state := range s.activeSensors {
+		if state.sensor.DeviceID == deviceID {
+			sensorIDs = append(sensorIDs, sensorID)
+		}
+	}
+
+	return sensorIDs
+}
+
 func (s *SimulatorRepositoryImpl) simulateReadings(sensorID domain.SensorID, state *simulatorState) {
 	defer state.ticker.Stop()
 
@@ -93,13 +169,12 @@ func (s *SimulatorRepositoryImpl) simulateReadings(sensorID domain.SensorID, sta
 		case <-state.stopCh:
 			return
 		case <-state.ticker.C:
-			if state.injectError {
+			if state.injectError.CompareAndSwap(true, false) {
 				errorEvent := &domain.SensorReadingErrorEvent{
 					SensorID: sensorID,
 					Type:     "injection",
 				}
-				_ = s.eventPublisher.Publish(errorEvent.ToDomainEvent())
-				state.injectError = false
+				_ = s.eventPublisher.Publish(errorEvent.ToDomainEvent().WithTenant(state.sensor.TenantID))
 				continue
 			}
 
@@ -118,6 +193,7 @@ func (s *SimulatorRepositoryImpl) simulateReadings(sensorID domain.SensorID, sta
 				unit,
 				time.Now().UTC(),
 			)
+			reading.TenantID = state.sensor.TenantID
 
 			if err := s.sensorReadingRepo.Save(&reading); err != nil {
 				continue
@@ -127,7 +203,7 @@ func (s *SimulatorRepositoryImpl) simulateReadings(sensorID domain.SensorID, sta
 				SensorID: sensorID,
 				Reading:  reading.ID,
 			}
-			if err := s.eventPublisher.Publish(readingEvent.ToDomainEvent()); err != nil {
+			if err := s.eventPublisher.Publish(readingEvent.ToDomainEvent().WithTenant(state.sensor.TenantID)); err != nil {
 				continue
 			}
 		}
@@ -135,10 +211,12 @@ func (s *SimulatorRepositoryImpl) simulateReadings(sensorID domain.SensorID, sta
 }
 
 type simulatorState struct {
-	ticker      *time.Ticker
-	stopCh      chan struct{}
-	sensor      *domain.Sensor
-	injectError bool
+	ticker *time.Ticker
+	stopCh chan struct{}
+	sensor *domain.Sensor
+	// injectError is set by InjectError and cleared by a recalibration or
+	// once the next tick reports the error, from different goroutines.
+	injectError atomic.Bool
 }
 
 func (s *SimulatorRepositoryImpl) generateValue(typ domain.SensorType) float64 {
@@ -0,0 +1,110 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(domain.IoTEvent) error { return nil }
+
+type errorEventCounter struct {
+	mu     sync.Mutex
+	errors int
+}
+
+func (c *errorEventCounter) Publish(event domain.IoTEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if event.Type == "sensor.reading.error" {
+		c.errors++
+	}
+	return nil
+}
+
+func (c *errorEventCounter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.errors
+}
+
+func TestSimulatorRepository_Start(t *testing.T) {
+	sensorRepo := NewInMemorySensorRepository()
+	for _, enabled := range []bool{true, false} {
+		id := domain.SensorID("sensor-enabled")
+		if !enabled {
+			id = "sensor-disabled"
+		}
+		config, _ := domain.NewSensorConfig(id, 60000, domain.Thresholds{}, 0, enabled)
+		sensor, _ := domain.NewSensor(id, "device-1", "Sensor", domain.Temperature, config)
+		sensorRepo.Save(sensor)
+	}
+
+	simulator := NewSimulatorRepository(sensorRepo, NewInMemorySensorReadingRepository(), discardPublisher{})
+	if err := simulator.Start("sensor-enabled"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer simulator.Stop("sensor-enabled")
+
+	tests := []struct {
+		name        string
+		sensorID    domain.SensorID
+		expectError error
+	}{
+		{name: "already active", sensorID: "sensor-enabled", expectError: domain.ErrSimulationAlreadyActive},
+		{name: "disabled sensor", sensorID: "sensor-disabled", expectError: domain.ErrSensorDisabled},
+		{name: "unknown sensor", sensorID: "sensor-404", expectError: domain.ErrSensorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := simulator.Start(tt.sensorID); !errors.Is(err, tt.expectError) {
+				t.Errorf("expected %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestSimulatorRepository_InjectError(t *testing.T) {
+	sensorRepo := NewInMemorySensorRepository()
+	config, _ := domain.NewSensorConfig("sensor-1", 1, domain.Thresholds{}, 0, true)
+	sensor, _ := domain.NewSensor("sensor-1", "device-1", "Sensor", domain.Temperature, config)
+	sensorRepo.Save(sensor)
+
+	publisher := &errorEventCounter{}
+	simulator := NewSimulatorRepository(sensorRepo, NewInMemorySensorReadingRepository(), publisher)
+	if err := simulator.Start("sensor-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer simulator.Stop("sensor-1")
+
+	// Injecting and recalibrating race with the ticks of the simulation,
+	// which run -race checks.
+	for i := 0; i < 20; i++ {
+		if err := simulator.InjectError("sensor-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := simulator.Execute(domain.Command{DeviceID: "device-1", Name: domain.CommandRecalibrate}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := simulator.InjectError("sensor-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for publisher.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if publisher.count() == 0 {
+		t.Error("expected the injected error to be reported")
+	}
+}
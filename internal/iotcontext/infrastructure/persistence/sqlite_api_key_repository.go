@@ -0,0 +1,31 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteAPIKeyRepository struct {
+	db *DB
+}
+
+func NewSQLiteAPIKeyRepository(db *DB) domain.APIKeyRepository {
+	return &SQLiteAPIKeyRepository{db: db}
+}
+
+func (r *SQLiteAPIKeyRepository) Save(key *domain.APIKey) error {
+	return r.db.conn.Create(marshalAPIKey(key)).Error
+}
+
+func (r *SQLiteAPIKeyRepository) Update(key *domain.APIKey) error {
+	return updateAPIKey(r.db.conn, key)
+}
+
+func (r *SQLiteAPIKeyRepository) FindByID(id domain.APIKeyID) (*domain.APIKey, error) {
+	return findAPIKey(r.db.conn, id)
+}
+
+func (r *SQLiteAPIKeyRepository) FindAll() ([]*domain.APIKey, error) {
+	return findAPIKeys(r.db.conn)
+}
+
+func (r *SQLiteAPIKeyRepository) FindByTenant(tenant domain.TenantID) ([]*domain.APIKey, error) {
+	return findAPIKeys(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
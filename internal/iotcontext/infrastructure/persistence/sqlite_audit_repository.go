@@ -0,0 +1,24 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteAuditRepository struct {
+	db *DB
+}
+
+func NewSQLiteAuditRepository(db *DB) domain.AuditRepository {
+	return &SQLiteAuditRepository{db: db}
+}
+
+func (r *SQLiteAuditRepository) Append(entry *domain.AuditEntry) error {
+	model, err := marshalAuditEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	return r.db.conn.Create(model).Error
+}
+
+func (r *SQLiteAuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	return findAuditEntries(r.db.conn, filter)
+}
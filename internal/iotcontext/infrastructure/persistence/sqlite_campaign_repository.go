@@ -0,0 +1,43 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteCampaignRepository struct {
+	db *DB
+}
+
+func NewSQLiteCampaignRepository(db *DB) domain.CampaignRepository {
+	return &SQLiteCampaignRepository{db: db}
+}
+
+func (r *SQLiteCampaignRepository) Save(campaign *domain.Campaign, updates []*domain.DeviceUpdate) error {
+	return saveCampaign(r.db.conn, campaign, updates)
+}
+
+func (r *SQLiteCampaignRepository) Update(campaign *domain.Campaign) error {
+	return updateCampaign(r.db.conn, campaign)
+}
+
+func (r *SQLiteCampaignRepository) FindByID(id domain.CampaignID) (*domain.Campaign, error) {
+	return findCampaign(r.db.conn, id)
+}
+
+func (r *SQLiteCampaignRepository) FindAll() ([]*domain.Campaign, error) {
+	return findCampaigns(r.db.conn.Order("created_at DESC, id DESC"))
+}
+
+func (r *SQLiteCampaignRepository) FindActive() ([]*domain.Campaign, error) {
+	return findCampaigns(r.db.conn.Where("status IN ?", activeCampaignStatuses()).Order("created_at ASC, id ASC"))
+}
+
+func (r *SQLiteCampaignRepository) FindDeviceUpdates(campaignID domain.CampaignID) ([]*domain.DeviceUpdate, error) {
+	return findDeviceUpdates(r.db.conn, campaignID)
+}
+
+func (r *SQLiteCampaignRepository) FindDeviceUpdate(campaignID domain.CampaignID, deviceID domain.DeviceID) (*domain.DeviceUpdate, error) {
+	return findDeviceUpdate(r.db.conn, campaignID, deviceID)
+}
+
+func (r *SQLiteCampaignRepository) UpdateDeviceUpdate(update *domain.DeviceUpdate) error {
+	return updateDeviceUpdate(r.db.conn, update)
+}
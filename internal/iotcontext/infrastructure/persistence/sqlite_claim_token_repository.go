@@ -0,0 +1,22 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+type SQLiteClaimTokenRepository struct {
+	db *DB
+}
+
+func NewSQLiteClaimTokenRepository(db *DB) domain.ClaimTokenRepository {
+	return &SQLiteClaimTokenRepository{db: db}
+}
+
+func (r *SQLiteClaimTokenRepository) Save(token *domain.ClaimToken) error {
+	return r.db.conn.Create(marshalClaimToken(token)).Error
+}
+
+func (r *SQLiteClaimTokenRepository) Redeem(tokenHash string, now time.Time) (*domain.ClaimToken, error) {
+	return redeemClaimToken(r.db.conn, tokenHash, now)
+}
@@ -0,0 +1,31 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteCommandRepository struct {
+	db *DB
+}
+
+func NewSQLiteCommandRepository(db *DB) domain.CommandRepository {
+	return &SQLiteCommandRepository{db: db}
+}
+
+func (r *SQLiteCommandRepository) Save(command *domain.Command) error {
+	return saveCommand(r.db.conn, command)
+}
+
+func (r *SQLiteCommandRepository) Update(command *domain.Command) error {
+	return updateCommand(r.db.conn, command)
+}
+
+func (r *SQLiteCommandRepository) FindByID(id domain.CommandID) (*domain.Command, error) {
+	return findCommand(r.db.conn, id)
+}
+
+func (r *SQLiteCommandRepository) FindByDeviceID(deviceID domain.DeviceID, limit int) ([]*domain.Command, error) {
+	return findCommandsByDevice(r.db.conn, deviceID, limit)
+}
+
+func (r *SQLiteCommandRepository) FindOpen() ([]*domain.Command, error) {
+	return findOpenCommands(r.db.conn)
+}
@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteRepositories_Contract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) repositorySet {
+		db, err := OpenSQLiteDB(filepath.Join(t.TempDir(), "iot.db"), logger.Silent)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+
+		t.Cleanup(func() {
+			if sqlDB, err := db.conn.DB(); err == nil {
+				_ = sqlDB.Close()
+			}
+		})
+
+		migrator, err := NewMigrator(db)
+		if err != nil {
+			t.Fatalf("failed to load migrations: %v", err)
+		}
+
+		if _, err := migrator.Up(); err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+
+		return repositorySet{
+			devices:     NewSQLiteDeviceRepository(db),
+			sensors:     NewSQLiteSensorRepository(db),
+			readings:    NewSQLiteSensorReadingRepository(db),
+			rollups:     NewSQLiteReadingRollupRepository(db),
+			retention:   NewSQLiteSensorReadingRetentionRepository(db),
+			twins:       NewSQLiteTwinRepository(db),
+			commands:    NewSQLiteCommandRepository(db),
+			firmwares:   NewSQLiteFirmwareRepository(db),
+			campaigns:   NewSQLiteCampaignRepository(db),
+			credentials: NewSQLiteCredentialRepository(db),
+			claimTokens: NewSQLiteClaimTokenRepository(db),
+			groups:      NewSQLiteGroupRepository(db),
+			locations:   NewSQLiteLocationRepository(db),
+			apiKeys:     NewSQLiteAPIKeyRepository(db),
+			audit:       NewSQLiteAuditRepository(db),
+			configs:     NewSQLiteSensorConfigHistoryRepository(db),
+			presence:    NewSQLitePresenceRepository(db),
+		}
+	})
+}
+
+func TestMigrator_SQLiteUpDown(t *testing.T) {
+	db, err := OpenSQLiteDB(filepath.Join(t.TempDir(), "iot.db"), logger.Silent)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := migrator.Check(); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if int64(len(applied)) != migrator.Latest() {
+		t.Errorf("expected %d applied migrations, got %d", migrator.Latest(), len(applied))
+	}
+
+	if err := migrator.Check(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	reverted, err := migrator.Down(len(applied))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reverted) != len(applied) {
+		t.Errorf("expected %d reverted migrations, got %d", len(applied), len(reverted))
+	}
+
+	if db.conn.Migrator().HasTable("device_models") {
+		t.Errorf("expected device_models to be dropped")
+	}
+}
@@ -0,0 +1,31 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteCredentialRepository struct {
+	db *DB
+}
+
+func NewSQLiteCredentialRepository(db *DB) domain.CredentialRepository {
+	return &SQLiteCredentialRepository{db: db}
+}
+
+func (r *SQLiteCredentialRepository) Save(credential *domain.Credential) error {
+	return r.db.conn.Create(marshalCredential(credential)).Error
+}
+
+func (r *SQLiteCredentialRepository) Update(credential *domain.Credential) error {
+	return updateCredential(r.db.conn, credential)
+}
+
+func (r *SQLiteCredentialRepository) FindByID(id domain.CredentialID) (*domain.Credential, error) {
+	return findCredential(r.db.conn, "id = ?", string(id))
+}
+
+func (r *SQLiteCredentialRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Credential, error) {
+	return findCredentialsByDevice(r.db.conn, deviceID)
+}
+
+func (r *SQLiteCredentialRepository) FindByFingerprint(fingerprint string) (*domain.Credential, error) {
+	return findCredential(r.db.conn, "fingerprint = ?", fingerprint)
+}
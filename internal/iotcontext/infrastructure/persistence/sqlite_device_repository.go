@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type SQLiteDeviceRepository struct {
+	db *DB
+}
+
+func NewSQLiteDeviceRepository(db *DB) domain.DeviceRepository {
+	return &SQLiteDeviceRepository{db: db}
+}
+
+func (r *SQLiteDeviceRepository) Save(device *domain.Device) error {
+	model := marshalDevice(device)
+
+	if err := r.db.conn.Create(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrDeviceAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteDeviceRepository) FindByID(id domain.DeviceID) (domain.Device, error) {
+	var model DeviceModel
+	if err := r.db.conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Device{}, domain.ErrDeviceNotFound
+		}
+
+		return domain.Device{}, err
+	}
+
+	return unmarshalDevice(&model), nil
+}
+
+func (r *SQLiteDeviceRepository) FindByIDs(ids []domain.DeviceID) ([]domain.Device, error) {
+	return findDevices(r.db.conn.Where("id IN ?", ids))
+}
+
+func (r *SQLiteDeviceRepository) FindAll() ([]domain.Device, error) {
+	return findDevices(r.db.conn)
+}
+
+func (r *SQLiteDeviceRepository) FindByTenant(tenant domain.TenantID) ([]domain.Device, error) {
+	return findDevices(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
+
+func (r *SQLiteDeviceRepository) Update(device *domain.Device) error {
+	return updateDevice(r.db.conn, device)
+}
+
+func (r *SQLiteDeviceRepository) Delete(id domain.DeviceID) error {
+	result := r.db.conn.Delete(&DeviceModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrDeviceNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteTwinRepository struct {
+	db *DB
+}
+
+func NewSQLiteTwinRepository(db *DB) domain.TwinRepository {
+	return &SQLiteTwinRepository{db: db}
+}
+
+func (r *SQLiteTwinRepository) FindByDeviceID(deviceID domain.DeviceID) (*domain.DeviceTwin, error) {
+	return findTwin(r.db.conn, deviceID)
+}
+
+func (r *SQLiteTwinRepository) Save(twin *domain.DeviceTwin, previousVersion int64) error {
+	return saveTwin(r.db.conn, twin, previousVersion)
+}
@@ -0,0 +1,23 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteFirmwareRepository struct {
+	db *DB
+}
+
+func NewSQLiteFirmwareRepository(db *DB) domain.FirmwareRepository {
+	return &SQLiteFirmwareRepository{db: db}
+}
+
+func (r *SQLiteFirmwareRepository) Save(firmware *domain.Firmware) error {
+	return saveFirmware(r.db.conn, firmware)
+}
+
+func (r *SQLiteFirmwareRepository) FindByID(id domain.FirmwareID) (*domain.Firmware, error) {
+	return findFirmware(r.db.conn, id)
+}
+
+func (r *SQLiteFirmwareRepository) FindAll() ([]*domain.Firmware, error) {
+	return findAllFirmware(r.db.conn)
+}
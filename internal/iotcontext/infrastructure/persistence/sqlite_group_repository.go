@@ -0,0 +1,35 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteGroupRepository struct {
+	db *DB
+}
+
+func NewSQLiteGroupRepository(db *DB) domain.GroupRepository {
+	return &SQLiteGroupRepository{db: db}
+}
+
+func (r *SQLiteGroupRepository) Save(group *domain.DeviceGroup) error {
+	return saveGroup(r.db.conn, group)
+}
+
+func (r *SQLiteGroupRepository) Update(group *domain.DeviceGroup) error {
+	return updateGroup(r.db.conn, group)
+}
+
+func (r *SQLiteGroupRepository) Delete(id domain.GroupID) error {
+	return deleteGroup(r.db.conn, id)
+}
+
+func (r *SQLiteGroupRepository) FindByID(id domain.GroupID) (*domain.DeviceGroup, error) {
+	return findGroup(r.db.conn, id)
+}
+
+func (r *SQLiteGroupRepository) FindAll() ([]*domain.DeviceGroup, error) {
+	return findAllGroups(r.db.conn)
+}
+
+func (r *SQLiteGroupRepository) FindByTenant(tenant domain.TenantID) ([]*domain.DeviceGroup, error) {
+	return findAllGroups(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
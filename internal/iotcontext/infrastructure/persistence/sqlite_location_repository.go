@@ -0,0 +1,35 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteLocationRepository struct {
+	db *DB
+}
+
+func NewSQLiteLocationRepository(db *DB) domain.LocationRepository {
+	return &SQLiteLocationRepository{db: db}
+}
+
+func (r *SQLiteLocationRepository) Save(location *domain.Location) error {
+	return saveLocation(r.db.conn, location)
+}
+
+func (r *SQLiteLocationRepository) Update(location *domain.Location) error {
+	return updateLocation(r.db.conn, location)
+}
+
+func (r *SQLiteLocationRepository) Delete(id domain.LocationID) error {
+	return deleteLocation(r.db.conn, id)
+}
+
+func (r *SQLiteLocationRepository) FindByID(id domain.LocationID) (*domain.Location, error) {
+	return findLocation(r.db.conn, id)
+}
+
+func (r *SQLiteLocationRepository) FindAll() ([]*domain.Location, error) {
+	return findAllLocations(r.db.conn)
+}
+
+func (r *SQLiteLocationRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Location, error) {
+	return findAllLocations(r.db.conn.Where("tenant_id = ?", string(tenant)))
+}
@@ -0,0 +1,35 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+type SQLitePresenceRepository struct {
+	db *DB
+}
+
+func NewSQLitePresenceRepository(db *DB) domain.PresenceRepository {
+	return &SQLitePresenceRepository{db: db}
+}
+
+// Touch uses the multi-argument MAX scalar function, SQLite's equivalent of
+// GREATEST, so last_seen only moves forward.
+func (r *SQLitePresenceRepository) Touch(deviceID domain.DeviceID, at time.Time) error {
+	return r.db.conn.Exec(`
+		INSERT INTO device_presence AS p (device_id, last_seen)
+		VALUES (?, ?)
+		ON CONFLICT (device_id) DO UPDATE SET
+			last_seen = MAX(p.last_seen, excluded.last_seen)`,
+		string(deviceID),
+		at.UTC(),
+	).Error
+}
+
+func (r *SQLitePresenceRepository) LastSeen(deviceID domain.DeviceID) (time.Time, error) {
+	return findLastSeen(r.db.conn, deviceID)
+}
+
+func (r *SQLitePresenceRepository) All() (map[domain.DeviceID]time.Time, error) {
+	return findAllLastSeen(r.db.conn)
+}
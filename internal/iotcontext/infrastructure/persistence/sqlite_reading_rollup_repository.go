@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+type SQLiteReadingRollupRepository struct {
+	db *DB
+}
+
+func NewSQLiteReadingRollupRepository(db *DB) domain.ReadingRollupRepository {
+	return &SQLiteReadingRollupRepository{db: db}
+}
+
+// Apply uses the multi-argument MIN/MAX scalar functions, SQLite's
+// equivalent of LEAST/GREATEST.
+func (r *SQLiteReadingRollupRepository) Apply(reading domain.SensorReading) error {
+	for _, resolution := range domain.RollupResolutions {
+		aggregate := domain.NewReadingAggregate(reading, resolution)
+
+		stmt := fmt.Sprintf(`
+			INSERT INTO %[1]s AS r (sensor_id, bucket, min_value, max_value, sum_value, count, last_value, last_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (sensor_id, bucket) DO UPDATE SET
+				min_value = MIN(r.min_value, excluded.min_value),
+				max_value = MAX(r.max_value, excluded.max_value),
+				sum_value = r.sum_value + excluded.sum_value,
+				count = r.count + excluded.count,
+				last_value = CASE WHEN excluded.last_at >= r.last_at THEN excluded.last_value ELSE r.last_value END,
+				last_at = MAX(r.last_at, excluded.last_at)`, rollupTables[resolution])
+
+		err := r.db.conn.Exec(stmt,
+			string(aggregate.SensorID),
+			aggregate.Bucket.UTC(),
+			aggregate.Min,
+			aggregate.Max,
+			aggregate.Sum,
+			aggregate.Count,
+			aggregate.Last,
+			aggregate.LastAt.UTC(),
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLiteReadingRollupRepository) FindAggregates(sensorID domain.SensorID, resolution domain.Resolution, from, to time.Time) ([]domain.ReadingAggregate, error) {
+	table, ok := rollupTables[resolution]
+	if !ok {
+		return nil, fmt.Errorf("no rollup table for resolution %q", resolution)
+	}
+
+	var models []ReadingRollupModel
+	err := r.db.conn.Table(table).
+		Where("sensor_id = ? AND bucket >= ? AND bucket < ?", string(sensorID), resolution.Truncate(from), to.UTC()).
+		Order("bucket ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalAggregates(models, resolution), nil
+}
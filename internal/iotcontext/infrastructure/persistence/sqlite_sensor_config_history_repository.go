@@ -0,0 +1,23 @@
+package persistence
+
+import "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+
+type SQLiteSensorConfigHistoryRepository struct {
+	db *DB
+}
+
+func NewSQLiteSensorConfigHistoryRepository(db *DB) domain.SensorConfigHistoryRepository {
+	return &SQLiteSensorConfigHistoryRepository{db: db}
+}
+
+func (r *SQLiteSensorConfigHistoryRepository) Append(revision *domain.SensorConfigRevision) error {
+	return appendConfigRevision(r.db.conn, revision)
+}
+
+func (r *SQLiteSensorConfigHistoryRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]*domain.SensorConfigRevision, error) {
+	return findConfigRevisions(r.db.conn, sensorID, limit)
+}
+
+func (r *SQLiteSensorConfigHistoryRepository) FindRevision(sensorID domain.SensorID, revision int64) (*domain.SensorConfigRevision, error) {
+	return findConfigRevision(r.db.conn, sensorID, revision)
+}
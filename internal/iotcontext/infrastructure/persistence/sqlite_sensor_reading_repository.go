@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+type SQLiteSensorReadingRepository struct {
+	db *DB
+}
+
+func NewSQLiteSensorReadingRepository(db *DB) domain.SensorReadingRepository {
+	return &SQLiteSensorReadingRepository{db: db}
+}
+
+func (r *SQLiteSensorReadingRepository) Save(reading *domain.SensorReading) error {
+	return r.db.conn.Create(marshalReading(reading)).Error
+}
+
+func (r *SQLiteSensorReadingRepository) FindBySensorID(sensorID domain.SensorID, limit int) ([]domain.SensorReading, error) {
+	var models []SensorReadingModel
+	query := r.db.conn.Where("sensor_id = ?", string(sensorID)).Order("timestamp DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalMeta(models), nil
+}
+
+// Timestamps are stored as UTC text, so range filters compare strings and
+// every bound has to be converted to UTC first.
+func (r *SQLiteSensorReadingRepository) FindBySensorIDBetween(sensorID domain.SensorID, from, to time.Time, limit int) ([]domain.SensorReading, error) {
+	var models []SensorReadingModel
+	query := r.db.conn.
+		Where("sensor_id = ? AND timestamp >= ? AND timestamp < ?", string(sensorID), from.UTC(), to.UTC()).
+		Order("timestamp ASC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalMeta(models), nil
+}
+
+func (r *SQLiteSensorReadingRepository) FindLatestBySensorIDs(sensorIDs []domain.SensorID) ([]domain.SensorReading, error) {
+	return findLatestReadings(r.db.conn, sensorIDs)
+}
@@ -0,0 +1,32 @@
+package persistence
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"time"
+)
+
+// SQLiteSensorReadingRetentionRepository only deletes rows: SQLite has no
+// table partitioning, so there are no partitions to create or drop.
+type SQLiteSensorReadingRetentionRepository struct {
+	db *DB
+}
+
+func NewSQLiteSensorReadingRetentionRepository(db *DB) domain.SensorReadingRetentionRepository {
+	return &SQLiteSensorReadingRetentionRepository{db: db}
+}
+
+func (r *SQLiteSensorReadingRetentionRepository) ListPartitions() ([]domain.ReadingsPartition, error) {
+	return nil, nil
+}
+
+func (r *SQLiteSensorReadingRetentionRepository) CreatePartition(partition domain.ReadingsPartition) error {
+	return nil
+}
+
+func (r *SQLiteSensorReadingRetentionRepository) DropPartition(partition domain.ReadingsPartition) error {
+	return nil
+}
+
+func (r *SQLiteSensorReadingRetentionRepository) DeleteReadingsBefore(scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	return deleteReadingsBefore(r.db, scope, cutoff)
+}
@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"gorm.io/gorm"
+)
+
+type SQLiteSensorRepository struct {
+	db *DB
+}
+
+func NewSQLiteSensorRepository(db *DB) domain.SensorRepository {
+	return &SQLiteSensorRepository{db: db}
+}
+
+func (r *SQLiteSensorRepository) FindByID(id domain.SensorID) (*domain.Sensor, error) {
+	var model SensorModel
+	if err := r.db.conn.First(&model, "id = ?", string(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSensorNotFound
+		}
+
+		return nil, err
+	}
+
+	return unmarshalSensor(&model)
+}
+
+func (r *SQLiteSensorRepository) FindAll() ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
+}
+
+func (r *SQLiteSensorRepository) FindByTenant(tenant domain.TenantID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("tenant_id = ?", string(tenant)).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
+}
+
+func (r *SQLiteSensorRepository) FindByDeviceID(deviceID domain.DeviceID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("device_id = ?", string(deviceID)).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
+}
+
+func (r *SQLiteSensorRepository) FindByDeviceIDs(deviceIDs []domain.DeviceID) ([]*domain.Sensor, error) {
+	var models []SensorModel
+	if err := r.db.conn.Where("device_id IN ?", deviceIDs).Order("created_at ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return unmarshalSensors(models)
+}
+
+func (r *SQLiteSensorRepository) Save(sensor *domain.Sensor) error {
+	model := marshalSensor(sensor)
+
+	if err := r.db.conn.Create(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrSensorAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteSensorRepository) Update(sensor *domain.Sensor) error {
+	return updateSensor(r.db.conn, sensor)
+}
+
+func (r *SQLiteSensorRepository) Delete(id domain.SensorID) error {
+	result := r.db.conn.Delete(&SensorModel{}, "id = ?", string(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrSensorNotFound
+	}
+
+	return nil
+}
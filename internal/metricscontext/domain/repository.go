@@ -6,3 +6,18 @@ type Metrics interface {
 	IncSensorReading(sensorType domain.SensorType, id domain.DeviceID)
 	IncSensorError(sensorType domain.SensorType, id domain.DeviceID)
 }
+
+type EventBufferMetrics interface {
+	SetEventBacklog(events int, bytes int64)
+	AddEventsDropped(count int)
+}
+
+type PresenceMetrics interface {
+	SetDevicesByPresence(status domain.PresenceStatus, count int)
+}
+
+type AuditMetrics interface {
+	IncAuditFailures()
+	SetAuditBacklog(entries int)
+	IncAuditDropped()
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	metrics_domain "github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/domain"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+const replayBatchSize = 100
+
+// flusher is a publisher whose Publish returning is not yet delivery, like
+// NatsPublisher; Flush waits until the events published so far are.
+type flusher interface {
+	Flush() error
+}
+
+// BufferedPublisher implements store-and-forward on top of another
+// publisher. While the backlog is empty events go straight through; once a
+// publish fails, this and every later event is appended to the WAL and a
+// background loop replays the backlog in order until it is drained. Events
+// leave the WAL only once the downstream has flushed them.
+type BufferedPublisher struct {
+	downstream    domain.EventPublisher
+	wal           *WAL
+	metrics       metrics_domain.EventBufferMetrics
+	retryInterval time.Duration
+
+	mu     sync.Mutex
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	closed sync.Once
+}
+
+func NewBufferedPublisher(downstream domain.EventPublisher, wal *WAL, metrics metrics_domain.EventBufferMetrics, retryInterval time.Duration) *BufferedPublisher {
+	if retryInterval <= 0 {
+		retryInterval = time.Second
+	}
+
+	p := &BufferedPublisher{
+		downstream:    downstream,
+		wal:           wal,
+		metrics:       metrics,
+		retryInterval: retryInterval,
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	p.reportBacklog()
+	go p.run()
+
+	return p
+}
+
+func (p *BufferedPublisher) Publish(event domain.IoTEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pending, _ := p.wal.Pending(); pending == 0 {
+		if err := p.downstream.Publish(event); err == nil && p.flush() == nil {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	dropped, err := p.wal.Append(data)
+	if dropped > 0 && p.metrics != nil {
+		p.metrics.AddEventsDropped(dropped)
+	}
+
+	p.reportBacklog()
+	p.Notify()
+
+	return err
+}
+
+// Notify asks the replay loop to try to drain the backlog now, e.g. when the
+// broker connection has been re-established.
+func (p *BufferedPublisher) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the replay loop and closes the downstream, when it can be, and
+// the WAL. Whatever is still pending stays on disk for the next start.
+func (p *BufferedPublisher) Close() error {
+	p.closed.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+
+	var err error
+	if closer, ok := p.downstream.(io.Closer); ok {
+		err = closer.Close()
+	}
+	if walErr := p.wal.Close(); walErr != nil {
+		err = walErr
+	}
+
+	return err
+}
+
+func (p *BufferedPublisher) flush() error {
+	if f, ok := p.downstream.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+func (p *BufferedPublisher) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.retryInterval)
+	defer ticker.Stop()
+
+	paused := false
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+
+		err := p.replay()
+		if err != nil && !paused {
+			log.Printf("event buffer: replay paused: %v", err)
+		}
+		if err == nil && paused {
+			log.Printf("event buffer: backlog delivered")
+		}
+		paused = err != nil
+	}
+}
+
+func (p *BufferedPublisher) replay() error {
+	defer p.reportBacklog()
+
+	for {
+		records, err := p.wal.Read(replayBatchSize)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		for _, record := range records {
+			var event domain.IoTEvent
+			if err := json.Unmarshal(record.Data, &event); err != nil {
+				log.Printf("event buffer: skipping unreadable event %d: %v", record.Seq, err)
+			} else if err := p.downstream.Publish(event); err != nil {
+				return err
+			}
+		}
+
+		// Acking the last record acks the whole batch, so it is only done
+		// once the broker has it all.
+		if err := p.flush(); err != nil {
+			return err
+		}
+		if err := p.wal.Ack(records[len(records)-1]); err != nil {
+			return err
+		}
+
+		select {
+		case <-p.stop:
+			return nil
+		default:
+		}
+	}
+}
+
+func (p *BufferedPublisher) reportBacklog() {
+	if p.metrics == nil {
+		return
+	}
+
+	events, bytes := p.wal.Pending()
+	p.metrics.SetEventBacklog(events, bytes)
+}
@@ -0,0 +1,221 @@
+package events
+
+import (
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+type flakyPublisher struct {
+	mu      sync.Mutex
+	online  bool
+	events  []string
+	attempt int
+}
+
+func (p *flakyPublisher) Publish(event domain.IoTEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempt++
+	if !p.online {
+		return errors.New("broker unreachable")
+	}
+
+	p.events = append(p.events, event.Type)
+	return nil
+}
+
+func (p *flakyPublisher) setOnline(online bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.online = online
+}
+
+func (p *flakyPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string{}, p.events...)
+}
+
+// unflushedPublisher accepts every event but only confirms delivery when
+// flushing is on, like a NATS connection that lost the broker.
+type unflushedPublisher struct {
+	flakyPublisher
+	flushing bool
+	closed   bool
+}
+
+func (p *unflushedPublisher) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.flushing {
+		return errors.New("flush timeout")
+	}
+	return nil
+}
+
+func (p *unflushedPublisher) setFlushing(flushing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.flushing = flushing
+}
+
+func (p *unflushedPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+type bufferMetricsSpy struct {
+	mu      sync.Mutex
+	backlog int
+	dropped int
+}
+
+func (m *bufferMetricsSpy) SetEventBacklog(events int, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.backlog = events
+}
+
+func (m *bufferMetricsSpy) AddEventsDropped(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropped += count
+}
+
+func (m *bufferMetricsSpy) values() (int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.backlog, m.dropped
+}
+
+func TestBufferedPublisher_StoresWhileOfflineAndReplaysInOrder(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir(), WALOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	downstream := &flakyPublisher{online: true}
+	metrics := &bufferMetricsSpy{}
+	publisher := NewBufferedPublisher(downstream, wal, metrics, time.Hour)
+	defer publisher.Close()
+
+	publish := func(eventType string) {
+		if err := publisher.Publish(domain.IoTEvent{Type: eventType, Timestamp: time.Now().UTC()}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	publish("a")
+	downstream.setOnline(false)
+	publish("b")
+	publish("c")
+
+	if backlog, _ := metrics.values(); backlog != 2 {
+		t.Errorf("expected a backlog of 2 events, got %d", backlog)
+	}
+
+	downstream.setOnline(true)
+	publish("d")
+	publisher.Notify()
+
+	// The backlog gauge is updated after the replayed events are acked.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if backlog, _ := metrics.values(); backlog == 0 && len(downstream.published()) >= 4 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	expected := []string{"a", "b", "c", "d"}
+	got := downstream.published()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, got)
+			break
+		}
+	}
+
+	if backlog, _ := metrics.values(); backlog != 0 {
+		t.Errorf("expected an empty backlog, got %d", backlog)
+	}
+}
+
+func TestBufferedPublisher_ReportsDroppedEvents(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir(), WALOptions{MaxBytes: 40, DropPolicy: DropNewest})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	metrics := &bufferMetricsSpy{}
+	publisher := NewBufferedPublisher(&flakyPublisher{}, wal, metrics, time.Hour)
+	defer publisher.Close()
+
+	err = publisher.Publish(domain.IoTEvent{Type: "too.large.to.fit.in.forty.bytes"})
+	if !errors.Is(err, ErrWALFull) {
+		t.Errorf("expected ErrWALFull, got %v", err)
+	}
+
+	if _, dropped := metrics.values(); dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", dropped)
+	}
+}
+
+func TestBufferedPublisher_KeepsEventsUntilFlushed(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir(), WALOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	downstream := &unflushedPublisher{flakyPublisher: flakyPublisher{online: true}}
+	metrics := &bufferMetricsSpy{}
+	publisher := NewBufferedPublisher(downstream, wal, metrics, time.Hour)
+
+	if err := publisher.Publish(domain.IoTEvent{Type: "a", Timestamp: time.Now().UTC()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if backlog, _ := metrics.values(); backlog != 1 {
+		t.Errorf("expected the unflushed event in the backlog, got %d", backlog)
+	}
+
+	downstream.setFlushing(true)
+	publisher.Notify()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if backlog, _ := metrics.values(); backlog == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if backlog, _ := metrics.values(); backlog != 0 {
+		t.Errorf("expected an empty backlog once flushed, got %d", backlog)
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !downstream.closed {
+		t.Error("expected the downstream to be closed")
+	}
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"testing"
+	"time"
+)
+
+func TestInMemoryCommandChannel_DeliversInOrder(t *testing.T) {
+	channel := NewInMemoryCommandChannel()
+
+	replies := make(chan domain.CommandReply, 4)
+	channel.OnReply(func(reply domain.CommandReply) {
+		replies <- reply
+	})
+	channel.OnCommand(func(command domain.Command) {
+		channel.Reply(domain.CommandReply{CommandID: command.ID, DeviceID: command.DeviceID, Status: domain.CommandReplyAck})
+		channel.Reply(domain.CommandReply{CommandID: command.ID, DeviceID: command.DeviceID, Status: domain.CommandReplySucceeded})
+	})
+
+	if err := channel.Dispatch(&domain.Command{ID: "command-1", DeviceID: "device-123"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []domain.CommandReplyStatus{domain.CommandReplyAck, domain.CommandReplySucceeded} {
+		select {
+		case reply := <-replies:
+			if reply.Status != expected {
+				t.Errorf("expected %s, got %s", expected, reply.Status)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", expected)
+		}
+	}
+}
+
+func TestNatsCommandChannel_RoundTrip(t *testing.T) {
+	channel, err := NewNatsCommandChannel(stringPtr("nats://localhost:4222"))
+	if err != nil {
+		t.Skipf("NATS not available: %v", err)
+	}
+	defer channel.Close()
+
+	replies := make(chan domain.CommandReply, 1)
+	if err := channel.OnReply(func(reply domain.CommandReply) {
+		replies <- reply
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The device answers claiming to be another one; the subject wins.
+	if err := channel.OnCommand(func(command domain.Command) {
+		channel.conn.Publish(CommandReplySubject(command.DeviceID), []byte(`{"command_id":"`+string(command.ID)+`","device_id":"device-999","status":"succeeded"}`))
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	channel.conn.Flush()
+
+	if err := channel.Dispatch(&domain.Command{ID: "command-1", DeviceID: "device-123", Name: domain.CommandReboot}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case reply := <-replies:
+		if reply.CommandID != "command-1" || reply.DeviceID != "device-123" || reply.Status != domain.CommandReplySucceeded {
+			t.Errorf("unexpected reply %+v", reply)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the reply")
+	}
+}
+
+func TestNatsCommandChannel_StartsWithoutBroker(t *testing.T) {
+	channel, err := NewNatsCommandChannel(stringPtr("nats://127.0.0.1:1"), StoreAndForwardOptions()...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer channel.Close()
+
+	if err := channel.OnReply(func(domain.CommandReply) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Refused rather than held in memory, so the dispatcher retries it.
+	if err := channel.Dispatch(&domain.Command{ID: "command-1", DeviceID: "device-123", Name: domain.CommandReboot}); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+)
+
+// InMemoryCommandChannel replaces NATS for commands when the app runs fully in
+// memory. Messages are delivered in order by a single goroutine, as a broker
+// would, so Dispatch never runs the device inside the caller.
+type InMemoryCommandChannel struct {
+	queue     chan func()
+	onCommand []func(command domain.Command)
+	onReply   []func(reply domain.CommandReply)
+	mu        sync.RWMutex
+}
+
+func NewInMemoryCommandChannel() *InMemoryCommandChannel {
+	c := &InMemoryCommandChannel{
+		queue: make(chan func(), 256),
+	}
+
+	go func() {
+		for deliver := range c.queue {
+			deliver()
+		}
+	}()
+
+	return c
+}
+
+func (c *InMemoryCommandChannel) Dispatch(command *domain.Command) error {
+	c.mu.RLock()
+	handlers := append([]func(command domain.Command){}, c.onCommand...)
+	c.mu.RUnlock()
+
+	copied := *command
+	c.queue <- func() {
+		for _, handler := range handlers {
+			handler(copied)
+		}
+	}
+
+	return nil
+}
+
+func (c *InMemoryCommandChannel) Reply(reply domain.CommandReply) error {
+	c.mu.RLock()
+	handlers := append([]func(reply domain.CommandReply){}, c.onReply...)
+	c.mu.RUnlock()
+
+	c.queue <- func() {
+		for _, handler := range handlers {
+			handler(reply)
+		}
+	}
+
+	return nil
+}
+
+func (c *InMemoryCommandChannel) OnCommand(handler func(command domain.Command)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onCommand = append(c.onCommand, handler)
+
+	return nil
+}
+
+func (c *InMemoryCommandChannel) OnReply(handler func(reply domain.CommandReply)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onReply = append(c.onReply, handler)
+
+	return nil
+}
+
+func (c *InMemoryCommandChannel) Close() {}
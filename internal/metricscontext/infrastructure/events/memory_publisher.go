@@ -0,0 +1,36 @@
+package events
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"sync"
+)
+
+// InMemoryPublisher delivers events synchronously to in-process subscribers.
+// It replaces NATS when the app runs fully in memory.
+type InMemoryPublisher struct {
+	subscribers []func(event domain.IoTEvent)
+	mu          sync.RWMutex
+}
+
+func NewInMemoryPublisher() *InMemoryPublisher {
+	return &InMemoryPublisher{}
+}
+
+func (p *InMemoryPublisher) Subscribe(handler func(event domain.IoTEvent)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subscribers = append(p.subscribers, handler)
+}
+
+func (p *InMemoryPublisher) Publish(event domain.IoTEvent) error {
+	p.mu.RLock()
+	subscribers := append([]func(event domain.IoTEvent){}, p.subscribers...)
+	p.mu.RUnlock()
+
+	for _, handler := range subscribers {
+		handler(event)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"testing"
+)
+
+func TestInMemoryPublisher_Publish(t *testing.T) {
+	publisher := NewInMemoryPublisher()
+
+	var received []string
+	publisher.Subscribe(func(event domain.IoTEvent) {
+		received = append(received, event.Type)
+	})
+
+	if err := publisher.Publish(domain.IoTEvent{Type: "sensor.created"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := publisher.Publish(domain.IoTEvent{Type: "sensor.config.updated"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(received) != 2 || received[0] != "sensor.created" || received[1] != "sensor.config.updated" {
+		t.Errorf("unexpected events %v", received)
+	}
+}
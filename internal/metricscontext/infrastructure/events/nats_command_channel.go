@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	"github.com/nats-io/nats.go"
+	"log"
+	"strings"
+)
+
+// CommandSubject is where a device listens for its commands; it answers on
+// CommandReplySubject.
+func CommandSubject(deviceID domain.DeviceID) string {
+	return "devices." + string(deviceID) + ".commands"
+}
+
+func CommandReplySubject(deviceID domain.DeviceID) string {
+	return CommandSubject(deviceID) + ".replies"
+}
+
+type NatsCommandChannel struct {
+	conn *nats.Conn
+}
+
+func NewNatsCommandChannel(url *string, options ...nats.Option) (*NatsCommandChannel, error) {
+	natsURL := nats.DefaultURL
+	if url != nil {
+		natsURL = *url
+	}
+
+	conn, err := nats.Connect(natsURL, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NatsCommandChannel{conn: conn}, nil
+}
+
+func (c *NatsCommandChannel) Dispatch(command *domain.Command) error {
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+
+	return c.conn.Publish(CommandSubject(command.DeviceID), payload)
+}
+
+func (c *NatsCommandChannel) Reply(reply domain.CommandReply) error {
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	return c.conn.Publish(CommandReplySubject(reply.DeviceID), payload)
+}
+
+// OnCommand subscribes to the commands of every device.
+func (c *NatsCommandChannel) OnCommand(handler func(command domain.Command)) error {
+	_, err := c.conn.Subscribe(CommandSubject("*"), func(msg *nats.Msg) {
+		var command domain.Command
+		if err := json.Unmarshal(msg.Data, &command); err != nil {
+			log.Printf("command channel: discarding unreadable command on %s: %v", msg.Subject, err)
+			return
+		}
+
+		handler(command)
+	})
+
+	return err
+}
+
+// OnReply subscribes to the replies of every device. The device id is taken
+// from the subject so a device cannot answer for another one.
+func (c *NatsCommandChannel) OnReply(handler func(reply domain.CommandReply)) error {
+	_, err := c.conn.Subscribe(CommandReplySubject("*"), func(msg *nats.Msg) {
+		var reply domain.CommandReply
+		if err := json.Unmarshal(msg.Data, &reply); err != nil {
+			log.Printf("command channel: discarding unreadable reply on %s: %v", msg.Subject, err)
+			return
+		}
+
+		reply.DeviceID = domain.DeviceID(strings.Split(msg.Subject, ".")[1])
+		handler(reply)
+	})
+
+	return err
+}
+
+func (c *NatsCommandChannel) Close() {
+	c.conn.Close()
+}
@@ -4,19 +4,24 @@ import (
 	"encoding/json"
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
 	"github.com/nats-io/nats.go"
+	"time"
 )
 
+// flushTimeout bounds how long Flush waits for the broker to confirm it has
+// received everything published so far.
+const flushTimeout = 5 * time.Second
+
 type NatsPublisher struct {
 	conn *nats.Conn
 }
 
-func NewNatsPublisher(url *string) (*NatsPublisher, error) {
+func NewNatsPublisher(url *string, options ...nats.Option) (*NatsPublisher, error) {
 	natsURL := nats.DefaultURL
 	if url != nil {
 		natsURL = *url
 	}
 
-	conn, err := nats.Connect(natsURL)
+	conn, err := nats.Connect(natsURL, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,5 +35,39 @@ func (np *NatsPublisher) Publish(event domain.IoTEvent) error {
 		return err
 	}
 
-	return np.conn.Publish(event.Type, payload)
+	return np.conn.Publish(domain.EventSubject(event), payload)
+}
+
+// Flush returns once the broker has received every event published so far.
+// Publish only hands the event to the connection, so this is what tells a
+// caller that the events were delivered.
+func (np *NatsPublisher) Flush() error {
+	return np.conn.FlushTimeout(flushTimeout)
+}
+
+// Close flushes what is still in the connection and closes it.
+func (np *NatsPublisher) Close() error {
+	err := np.Flush()
+	np.conn.Close()
+
+	return err
+}
+
+func (np *NatsPublisher) OnReconnect(handler func()) {
+	np.conn.SetReconnectHandler(func(*nats.Conn) {
+		handler()
+	})
+}
+
+// StoreAndForwardOptions make Publish fail while the broker is unreachable,
+// instead of queueing in the client's in-memory reconnect buffer, so a
+// BufferedPublisher keeps the events on disk and the command dispatcher
+// retries its commands. The connection also survives starting without a
+// broker and never stops reconnecting.
+func StoreAndForwardOptions() []nats.Option {
+	return []nats.Option{
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	}
 }
@@ -2,7 +2,8 @@ package events
 
 import (
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
-	
+	"testing"
+	"time"
 )
 
 func TestNewNatsPublisher(t *testing.T) {
@@ -73,6 +74,33 @@ func TestNatsPublisher_Publish(t *testing.T) {
 	}
 }
 
+func TestNatsPublisher_PublishTenantSubject(t *testing.T) {
+	publisher, err := NewNatsPublisher(stringPtr("nats://localhost:4222"))
+	if err != nil {
+		t.Skipf("skipping test due to NATS connection error: %v", err)
+	}
+
+	subscription, err := publisher.conn.SubscribeSync("tenants.*.device.created")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	event := domain.IoTEvent{Type: "device.created", TenantID: "acme", Timestamp: time.Now()}
+	if err := publisher.Publish(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := subscription.NextMsg(2 * time.Second)
+	if err != nil {
+		t.Fatalf("expected the event on the tenant subject: %v", err)
+	}
+
+	if msg.Subject != "tenants.acme.device.created" {
+		t.Errorf("expected subject tenants.acme.device.created, got %s", msg.Subject)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,561 @@
+package events
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type DropPolicy string
+
+const (
+	DropOldest DropPolicy = "drop-oldest"
+	DropNewest DropPolicy = "drop-newest"
+)
+
+var ErrWALFull = errors.New("event buffer is full")
+var ErrInvalidDropPolicy = errors.New("invalid drop policy")
+
+func ParseDropPolicy(value string) (DropPolicy, error) {
+	switch DropPolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case DropOldest, "":
+		return DropOldest, nil
+	case DropNewest:
+		return DropNewest, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidDropPolicy, value)
+	}
+}
+
+const (
+	walSegmentExt    = ".wal"
+	walCursorFile    = "cursor"
+	walHeaderSize    = 8
+	walDefaultSeg    = 4 << 20
+	walMaxRecordSize = 16 << 20
+)
+
+type WALOptions struct {
+	SegmentBytes int64
+	MaxBytes     int64
+	DropPolicy   DropPolicy
+	// SyncEvery is how many appends are written between two fsyncs of the
+	// active segment. A crash loses at most the records since the last one;
+	// zero or one syncs every append.
+	SyncEvery int
+}
+
+type WALRecord struct {
+	Seq  uint64
+	Data []byte
+
+	segment uint64
+	end     int64
+}
+
+type walSegment struct {
+	base  uint64
+	count uint64
+	size  int64
+	path  string
+}
+
+func (s *walSegment) last() uint64 {
+	return s.base + s.count
+}
+
+// WAL is an append-only log split in segment files named after the sequence
+// number of their first record. Each record is framed as
+// [length uint32][crc32 uint32][data]. The cursor file stores the sequence of
+// the first record that has not been acknowledged yet; delivery is therefore
+// at-least-once, a crash between publish and ack replays the record.
+type WAL struct {
+	dir  string
+	opts WALOptions
+
+	mu        sync.Mutex
+	segments  []*walSegment
+	active    *os.File
+	next      uint64
+	cursor    uint64
+	cursorOff int64
+	size      int64
+	unsynced  int
+}
+
+func OpenWAL(dir string, opts WALOptions) (*WAL, error) {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = walDefaultSeg
+	}
+
+	if opts.DropPolicy == "" {
+		opts.DropPolicy = DropOldest
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	w := &WAL{dir: dir, opts: opts}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *WAL) load() error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, walSegmentExt) {
+			continue
+		}
+
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, walSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		segment := &walSegment{base: base, path: filepath.Join(w.dir, name)}
+		if err := scanSegment(segment); err != nil {
+			return err
+		}
+
+		w.segments = append(w.segments, segment)
+		w.size += segment.size
+	}
+
+	sort.Slice(w.segments, func(i, j int) bool {
+		return w.segments[i].base < w.segments[j].base
+	})
+
+	cursor, err := readCursor(filepath.Join(w.dir, walCursorFile))
+	if err != nil {
+		return err
+	}
+
+	if len(w.segments) > 0 {
+		w.next = w.segments[len(w.segments)-1].last()
+		if cursor < w.segments[0].base {
+			cursor = w.segments[0].base
+		}
+	}
+
+	if cursor > w.next {
+		w.next = cursor
+	}
+
+	if err := w.moveCursor(cursor); err != nil {
+		return err
+	}
+
+	return w.openActive()
+}
+
+// scanSegment counts the valid records of a segment and truncates a torn or
+// corrupted tail left by a crash in the middle of a write.
+func scanSegment(segment *walSegment) error {
+	file, err := os.OpenFile(segment.path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		data, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			log.Printf("event buffer: truncating %s at offset %d: %v", segment.path, offset, err)
+			if err := file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+
+		segment.count++
+		offset += walHeaderSize + int64(len(data))
+	}
+
+	segment.size = offset
+
+	return nil
+}
+
+func readRecord(reader io.Reader) ([]byte, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("short record header: %w", err)
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > walMaxRecordSize {
+		return nil, fmt.Errorf("record of %d bytes exceeds the limit", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, fmt.Errorf("short record: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errors.New("checksum mismatch")
+	}
+
+	return data, nil
+}
+
+func readCursor(path string) (uint64, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+}
+
+func (w *WAL) openActive() error {
+	if len(w.segments) == 0 || w.segments[len(w.segments)-1].size >= w.opts.SegmentBytes || w.segments[len(w.segments)-1].last() != w.next {
+		w.segments = append(w.segments, &walSegment{
+			base: w.next,
+			path: filepath.Join(w.dir, fmt.Sprintf("%020d%s", w.next, walSegmentExt)),
+		})
+	}
+
+	file, err := os.OpenFile(w.segments[len(w.segments)-1].path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.active = file
+
+	return nil
+}
+
+func (w *WAL) rotate() error {
+	if err := w.active.Sync(); err != nil {
+		return err
+	}
+	if err := w.active.Close(); err != nil {
+		return err
+	}
+	w.unsynced = 0
+
+	w.segments = append(w.segments, &walSegment{
+		base: w.next,
+		path: filepath.Join(w.dir, fmt.Sprintf("%020d%s", w.next, walSegmentExt)),
+	})
+
+	if err := w.openActive(); err != nil {
+		return err
+	}
+
+	return syncDir(w.dir)
+}
+
+// Append stores data at the end of the log. When the log would exceed
+// MaxBytes the drop policy decides which records are lost; the number of
+// dropped records is returned together with ErrWALFull for drop-newest.
+func (w *WAL) Append(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	recordSize := int64(walHeaderSize + len(data))
+	if len(data) > walMaxRecordSize || (w.opts.MaxBytes > 0 && recordSize > w.opts.MaxBytes) {
+		return 1, ErrWALFull
+	}
+
+	dropped := 0
+	for w.opts.MaxBytes > 0 && w.size+recordSize > w.opts.MaxBytes {
+		if w.opts.DropPolicy == DropNewest {
+			return dropped + 1, ErrWALFull
+		}
+
+		n, err := w.dropOldest()
+		if err != nil {
+			return dropped, err
+		}
+		dropped += n
+	}
+
+	active := w.segments[len(w.segments)-1]
+	if active.size > 0 && active.size+recordSize > w.opts.SegmentBytes {
+		if err := w.rotate(); err != nil {
+			return dropped, err
+		}
+		active = w.segments[len(w.segments)-1]
+	}
+
+	buf := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[walHeaderSize:], data)
+
+	if _, err := w.active.Write(buf); err != nil {
+		return dropped, err
+	}
+
+	active.count++
+	active.size += recordSize
+	w.size += recordSize
+	w.next++
+
+	w.unsynced++
+	if w.unsynced >= w.opts.SyncEvery {
+		if err := w.active.Sync(); err != nil {
+			return dropped, err
+		}
+		w.unsynced = 0
+	}
+
+	return dropped, nil
+}
+
+func (w *WAL) dropOldest() (int, error) {
+	if len(w.segments) == 1 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	oldest := w.segments[0]
+	dropped := 0
+	if oldest.last() > w.cursor {
+		dropped = int(oldest.last() - max(w.cursor, oldest.base))
+	}
+
+	if err := w.removeSegment(oldest); err != nil {
+		return 0, err
+	}
+
+	if w.cursor < w.segments[0].base {
+		if err := w.moveCursor(w.segments[0].base); err != nil {
+			return dropped, err
+		}
+	}
+
+	return dropped, nil
+}
+
+func (w *WAL) removeSegment(segment *walSegment) error {
+	if err := os.Remove(segment.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	w.size -= segment.size
+	w.segments = w.segments[1:]
+
+	return nil
+}
+
+// Read returns up to limit records starting at the cursor without
+// acknowledging them.
+func (w *WAL) Read(limit int) ([]WALRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var records []WALRecord
+	seq, offset := w.cursor, w.cursorOff
+	for _, segment := range w.segments {
+		if len(records) >= limit {
+			break
+		}
+
+		if segment.last() <= seq {
+			continue
+		}
+
+		if segment.base >= seq {
+			seq, offset = segment.base, 0
+		}
+
+		batch, err := readSegment(segment, seq, offset, limit-len(records))
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, batch...)
+		if len(batch) > 0 {
+			last := batch[len(batch)-1]
+			seq, offset = last.Seq+1, last.end
+		}
+	}
+
+	return records, nil
+}
+
+func readSegment(segment *walSegment, seq uint64, offset int64, limit int) ([]WALRecord, error) {
+	file, err := os.Open(segment.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(file)
+	var records []WALRecord
+	for len(records) < limit && seq < segment.last() {
+		data, err := readRecord(reader)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", segment.path, err)
+		}
+
+		offset += walHeaderSize + int64(len(data))
+		records = append(records, WALRecord{Seq: seq, Data: data, segment: segment.base, end: offset})
+		seq++
+	}
+
+	return records, nil
+}
+
+// Ack marks every record up to and including record as delivered and removes
+// the segments that no longer hold pending records.
+func (w *WAL) Ack(record WALRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if record.Seq < w.cursor {
+		return nil
+	}
+
+	w.cursor, w.cursorOff = record.Seq+1, record.end
+	for _, segment := range w.segments {
+		if segment.base == record.segment && w.cursor >= segment.last() && segment != w.segments[len(w.segments)-1] {
+			w.cursorOff = 0
+		}
+	}
+
+	for len(w.segments) > 1 && w.segments[0].last() <= w.cursor {
+		if err := w.removeSegment(w.segments[0]); err != nil {
+			return err
+		}
+	}
+
+	if w.cursor == w.next && w.segments[0].size > 0 {
+		if err := w.active.Close(); err != nil {
+			return err
+		}
+		if err := w.removeSegment(w.segments[0]); err != nil {
+			return err
+		}
+		if err := w.openActive(); err != nil {
+			return err
+		}
+		w.cursorOff = 0
+	}
+
+	return w.writeCursor()
+}
+
+func (w *WAL) moveCursor(cursor uint64) error {
+	w.cursor, w.cursorOff = cursor, 0
+
+	for _, segment := range w.segments {
+		if segment.base > cursor || segment.last() <= cursor {
+			continue
+		}
+
+		records, err := readSegment(segment, segment.base, 0, int(cursor-segment.base))
+		if err != nil {
+			return err
+		}
+
+		if len(records) > 0 {
+			w.cursorOff = records[len(records)-1].end
+		}
+	}
+
+	return w.writeCursor()
+}
+
+// writeCursor replaces the cursor file atomically: a crash leaves either the
+// old cursor or the new one, never a torn write.
+func (w *WAL) writeCursor() error {
+	path := filepath.Join(w.dir, walCursorFile)
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(strconv.FormatUint(w.cursor, 10)); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	return syncDir(w.dir)
+}
+
+// syncDir makes the files created or renamed in dir survive a crash.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
+
+// Pending reports the records waiting for delivery and the disk used by the
+// segments that hold them.
+func (w *WAL) Pending() (int, int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return int(w.next - w.cursor), w.size
+}
+
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.active.Sync(); err != nil {
+		w.active.Close()
+		return err
+	}
+
+	return w.active.Close()
+}
@@ -0,0 +1,259 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func appendRecords(t *testing.T, wal *WAL, from, to int) {
+	t.Helper()
+
+	for i := from; i < to; i++ {
+		if _, err := wal.Append([]byte(fmt.Sprintf("event-%03d", i))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func drain(t *testing.T, wal *WAL) []string {
+	t.Helper()
+
+	var values []string
+	for {
+		records, err := wal.Read(3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(records) == 0 {
+			return values
+		}
+
+		for _, record := range records {
+			values = append(values, string(record.Data))
+			if err := wal.Ack(record); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func TestWAL_ReplaysInOrderAcrossSegments(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir(), WALOptions{SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendRecords(t, wal, 0, 10)
+
+	if pending, _ := wal.Pending(); pending != 10 {
+		t.Fatalf("expected 10 pending events, got %d", pending)
+	}
+
+	values := drain(t, wal)
+	if len(values) != 10 {
+		t.Fatalf("expected 10 events, got %d", len(values))
+	}
+
+	for i, value := range values {
+		if value != fmt.Sprintf("event-%03d", i) {
+			t.Errorf("expected event-%03d at position %d, got %s", i, i, value)
+		}
+	}
+
+	if pending, bytes := wal.Pending(); pending != 0 || bytes != 0 {
+		t.Errorf("expected empty backlog, got %d events and %d bytes", pending, bytes)
+	}
+}
+
+func TestWAL_ResumesFromCursorAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	wal, err := OpenWAL(dir, WALOptions{SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendRecords(t, wal, 0, 6)
+
+	records, err := wal.Read(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := wal.Ack(records[3]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := wal.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wal, err = OpenWAL(dir, WALOptions{SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendRecords(t, wal, 6, 8)
+
+	values := drain(t, wal)
+	expected := []string{"event-004", "event-005", "event-006", "event-007"}
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, walCursorFile+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary cursor to be renamed, got %v", err)
+	}
+}
+
+func TestWAL_SyncEvery(t *testing.T) {
+	tests := []struct {
+		name             string
+		syncEvery        int
+		expectedUnsynced int
+	}{
+		{name: "every append", syncEvery: 0, expectedUnsynced: 0},
+		{name: "batched", syncEvery: 3, expectedUnsynced: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wal, err := OpenWAL(t.TempDir(), WALOptions{SyncEvery: tt.syncEvery})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer wal.Close()
+
+			appendRecords(t, wal, 0, 4)
+
+			if wal.unsynced != tt.expectedUnsynced {
+				t.Errorf("expected %d appends waiting for fsync, got %d", tt.expectedUnsynced, wal.unsynced)
+			}
+		})
+	}
+}
+
+func TestWAL_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	wal, err := OpenWAL(dir, WALOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendRecords(t, wal, 0, 3)
+	_ = wal.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if len(segments) != 1 {
+		t.Fatalf("expected one segment, got %d", len(segments))
+	}
+
+	file, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = file.Write([]byte{0, 0, 0, 9, 1, 2})
+	_ = file.Close()
+
+	wal, err = OpenWAL(dir, WALOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendRecords(t, wal, 3, 4)
+
+	if values := drain(t, wal); len(values) != 4 || values[3] != "event-003" {
+		t.Errorf("expected the torn record to be discarded, got %v", values)
+	}
+}
+
+func TestWAL_DropPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        DropPolicy
+		expectFull    bool
+		expectedFirst string
+		expectedLast  string
+	}{
+		{name: "drop oldest keeps the newest events", policy: DropOldest, expectedFirst: "event-004", expectedLast: "event-009"},
+		{name: "drop newest keeps the oldest events", policy: DropNewest, expectFull: true, expectedFirst: "event-000", expectedLast: "event-005"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every record takes 17 bytes: two per segment, six fit in the limit.
+			wal, err := OpenWAL(t.TempDir(), WALOptions{SegmentBytes: 34, MaxBytes: 102, DropPolicy: tt.policy})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dropped := 0
+			full := false
+			for i := 0; i < 10; i++ {
+				n, err := wal.Append([]byte(fmt.Sprintf("event-%03d", i)))
+				if err == ErrWALFull {
+					full = true
+				} else if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				dropped += n
+			}
+
+			if full != tt.expectFull {
+				t.Errorf("expected full=%v, got %v", tt.expectFull, full)
+			}
+
+			if _, bytes := wal.Pending(); bytes > 102 {
+				t.Errorf("expected at most 102 bytes on disk, got %d", bytes)
+			}
+
+			values := drain(t, wal)
+			if len(values)+dropped != 10 {
+				t.Errorf("expected kept and dropped events to add up to 10, got %d and %d", len(values), dropped)
+			}
+
+			if values[0] != tt.expectedFirst || values[len(values)-1] != tt.expectedLast {
+				t.Errorf("expected %s..%s, got %v", tt.expectedFirst, tt.expectedLast, values)
+			}
+		})
+	}
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    DropPolicy
+		expectError bool
+	}{
+		{value: "", expected: DropOldest},
+		{value: "drop-newest", expected: DropNewest},
+		{value: "DROP-OLDEST", expected: DropOldest},
+		{value: "random", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			policy, err := ParseDropPolicy(tt.value)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if policy != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, policy)
+			}
+		})
+	}
+}
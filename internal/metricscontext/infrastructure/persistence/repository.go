@@ -6,8 +6,15 @@ import (
 )
 
 type PrometheusMetricsImpl struct {
-	readingsTotal *prometheus.CounterVec
-	errorsTotal   *prometheus.CounterVec
+	readingsTotal      *prometheus.CounterVec
+	errorsTotal        *prometheus.CounterVec
+	eventBacklog       prometheus.Gauge
+	eventBacklogBytes  prometheus.Gauge
+	eventsDroppedTotal prometheus.Counter
+	devicesByPresence  *prometheus.GaugeVec
+	auditFailures      prometheus.Counter
+	auditBacklog       prometheus.Gauge
+	auditDropped       prometheus.Counter
 }
 
 func NewPrometheusMetrics() *PrometheusMetricsImpl {
@@ -27,11 +34,56 @@ func NewPrometheusMetrics() *PrometheusMetricsImpl {
 		[]string{"sensor_type", "device_id"},
 	)
 
-	prometheus.MustRegister(readings, errors)
+	backlog := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "event_buffer_backlog_events",
+		Help: "Events stored on disk waiting to be delivered to the broker",
+	})
+
+	backlogBytes := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "event_buffer_backlog_bytes",
+		Help: "Disk used by the event buffer segments",
+	})
+
+	dropped := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "event_buffer_dropped_total",
+		Help: "Events discarded because the event buffer reached its size limit",
+	})
+
+	presence := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "devices_presence",
+			Help: "Number of devices per presence status",
+		},
+		[]string{"status"},
+	)
+
+	auditFailures := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_append_failures_total",
+		Help: "Audit entries the audit log failed to append, each queued for a retry",
+	})
+
+	auditBacklog := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "audit_backlog_entries",
+		Help: "Audit entries queued in memory waiting to be appended",
+	})
+
+	auditDropped := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_dropped_total",
+		Help: "Audit entries discarded because the retry queue was full",
+	})
+
+	prometheus.MustRegister(readings, errors, backlog, backlogBytes, dropped, presence, auditFailures, auditBacklog, auditDropped)
 
 	return &PrometheusMetricsImpl{
-		readingsTotal: readings,
-		errorsTotal:   errors,
+		readingsTotal:      readings,
+		errorsTotal:        errors,
+		eventBacklog:       backlog,
+		eventBacklogBytes:  backlogBytes,
+		eventsDroppedTotal: dropped,
+		devicesByPresence:  presence,
+		auditFailures:      auditFailures,
+		auditBacklog:       auditBacklog,
+		auditDropped:       auditDropped,
 	}
 }
 
@@ -42,3 +94,28 @@ func (pm *PrometheusMetricsImpl) IncSensorReading(sensorType domain.SensorType,
 func (pm *PrometheusMetricsImpl) IncSensorError(sensorType domain.SensorType, deviceID domain.DeviceID) {
 	pm.errorsTotal.WithLabelValues(string(sensorType), string(deviceID)).Inc()
 }
+
+func (pm *PrometheusMetricsImpl) SetEventBacklog(events int, bytes int64) {
+	pm.eventBacklog.Set(float64(events))
+	pm.eventBacklogBytes.Set(float64(bytes))
+}
+
+func (pm *PrometheusMetricsImpl) AddEventsDropped(count int) {
+	pm.eventsDroppedTotal.Add(float64(count))
+}
+
+func (pm *PrometheusMetricsImpl) SetDevicesByPresence(status domain.PresenceStatus, count int) {
+	pm.devicesByPresence.WithLabelValues(string(status)).Set(float64(count))
+}
+
+func (pm *PrometheusMetricsImpl) IncAuditFailures() {
+	pm.auditFailures.Inc()
+}
+
+func (pm *PrometheusMetricsImpl) SetAuditBacklog(entries int) {
+	pm.auditBacklog.Set(float64(entries))
+}
+
+func (pm *PrometheusMetricsImpl) IncAuditDropped() {
+	pm.auditDropped.Inc()
+}
@@ -2,7 +2,7 @@ package persistence
 
 import (
 	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
-	
+	"testing"
 )
 
 func TestPrometheusMetricsImpl_IncSensorReading(t *testing.T) {
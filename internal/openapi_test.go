@@ -0,0 +1,601 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/SeiyaJapon/iot-sensor-app/api"
+	"github.com/SeiyaJapon/iot-sensor-app/cmd/app"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var specMethods = []string{"get", "post", "put", "patch", "delete"}
+
+// testContainer backs the APIs under test from memory. It registers its
+// Prometheus collectors globally, so it is built once for every test.
+var (
+	testContainer *app.AppContainer
+	testRouter    *Router
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "openapi")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"STORAGE_DRIVER": "memory",
+		"EVENT_BUS":      "memory",
+		"API_AUTH":       "disabled",
+		"DEVICE_AUTH":    "optional",
+		"FIRMWARE_DIR":   filepath.Join(dir, "firmware"),
+		"DEVICE_CA_DIR":  filepath.Join(dir, "ca"),
+	} {
+		os.Setenv(name, value)
+	}
+	testContainer = app.NewAppContainer()
+	testRouter = NewRouter(testContainer)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func loadSpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(api.Spec, &spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return spec
+}
+
+// specPath turns a path of the document into one the mux routes, with every
+// parameter set to x.
+func specPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			segments[i] = "x"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	router := testRouter
+	paths := loadSpec(t)["paths"].(map[string]interface{})
+
+	described := map[string]bool{}
+	for path, item := range paths {
+		for _, method := range specMethods {
+			if _, ok := item.(map[string]interface{})[method]; !ok {
+				continue
+			}
+
+			route := strings.ToUpper(method) + " " + path
+			described[route] = true
+
+			req := httptest.NewRequest(strings.ToUpper(method), specPath(path), nil)
+			_, pattern := router.mux.Handler(req)
+			if pattern != route && pattern != path {
+				t.Errorf("%s is served by %q", route, pattern)
+			}
+		}
+	}
+
+	for _, pattern := range router.patterns {
+		method, path, found := strings.Cut(pattern, " ")
+		if !found {
+			path = method
+			if _, ok := paths[path]; !ok {
+				t.Errorf("%s is not described", pattern)
+			}
+			continue
+		}
+		if !described[method+" "+path] {
+			t.Errorf("%s is not described", pattern)
+		}
+	}
+}
+
+func TestOpenAPIOperationsAreWellFormed(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	ids := map[string]string{}
+	for path, item := range paths {
+		for _, method := range specMethods {
+			op, ok := item.(map[string]interface{})[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			route := strings.ToUpper(method) + " " + path
+
+			id, _ := op["operationId"].(string)
+			if id == "" {
+				t.Errorf("%s has no operationId", route)
+			} else if other, taken := ids[id]; taken {
+				t.Errorf("%s and %s are both %s", route, other, id)
+			}
+			ids[id] = route
+
+			if _, ok := op["summary"].(string); !ok {
+				t.Errorf("%s has no summary", route)
+			}
+			if path != "/health" && path != "/metrics" && path != "/openapi.json" {
+				if _, ok := op["responses"].(map[string]interface{})["default"]; !ok {
+					t.Errorf("%s does not describe its errors", route)
+				}
+			}
+		}
+	}
+
+	refs := map[string]bool{}
+	collectRefs(spec, refs)
+	for ref := range refs {
+		if _, err := resolveRef(spec, ref); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func collectRefs(value interface{}, refs map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				refs[ref] = true
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func resolveRef(spec map[string]interface{}, ref string) (map[string]interface{}, error) {
+	var node interface{} = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s does not resolve", ref)
+		}
+		node = object[part]
+	}
+
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s does not resolve", ref)
+	}
+	return target, nil
+}
+
+// schemaValidator checks JSON values against the schemas of the document,
+// for the subset of JSON Schema it uses.
+type schemaValidator struct {
+	spec map[string]interface{}
+}
+
+func (v schemaValidator) resolve(schema map[string]interface{}) map[string]interface{} {
+	for {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		target, err := resolveRef(v.spec, ref)
+		if err != nil {
+			return map[string]interface{}{"not": true}
+		}
+		schema = target
+	}
+}
+
+// object merges the properties and required fields of an allOf.
+func (v schemaValidator) object(schema map[string]interface{}) (map[string]interface{}, []interface{}) {
+	schema = v.resolve(schema)
+
+	properties := map[string]interface{}{}
+	var required []interface{}
+	for _, part := range asSlice(schema["allOf"]) {
+		partProperties, partRequired := v.object(part.(map[string]interface{}))
+		for name, property := range partProperties {
+			properties[name] = property
+		}
+		required = append(required, partRequired...)
+	}
+
+	for name, property := range asMap(schema["properties"]) {
+		properties[name] = property
+	}
+	return properties, append(required, asSlice(schema["required"])...)
+}
+
+func (v schemaValidator) validate(value interface{}, schema map[string]interface{}, at string) []string {
+	schema = v.resolve(schema)
+	if _, ok := schema["not"]; ok {
+		return []string{at + ": unresolved schema"}
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !matchesType(value, types) {
+		return []string{fmt.Sprintf("%s: %v is not %v", at, value, types)}
+	}
+
+	if enum := asSlice(schema["enum"]); enum != nil {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+		}
+	}
+	if constant, ok := schema["const"]; ok && constant != value {
+		return []string{fmt.Sprintf("%s: %v is not %v", at, value, constant)}
+	}
+	if text, ok := value.(string); ok && schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, text); err != nil {
+			return []string{fmt.Sprintf("%s: %q is not a date-time", at, text)}
+		}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, required := v.object(schema)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s is missing", at, name))
+			}
+		}
+
+		additional := asMap(schema["additionalProperties"])
+		for name, field := range value {
+			if property, ok := properties[name]; ok {
+				problems = append(problems, v.validate(field, property.(map[string]interface{}), at+"."+name)...)
+			} else if additional != nil {
+				problems = append(problems, v.validate(field, additional, at+"."+name)...)
+			} else if len(properties) > 0 {
+				problems = append(problems, fmt.Sprintf("%s: %s is not described", at, name))
+			}
+		}
+	case []interface{}:
+		if items := asMap(schema["items"]); items != nil {
+			for i, item := range value {
+				problems = append(problems, v.validate(item, items, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, one := range t {
+			types = append(types, one.(string))
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && value == math.Trunc(value)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func asSlice(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
+
+// apiScenario calls the API and checks every response against the document.
+type apiScenario struct {
+	t         *testing.T
+	router    *Router
+	validator schemaValidator
+	// seen are the operations called, by status.
+	seen map[string]bool
+}
+
+func (s *apiScenario) call(method, target string, body interface{}, status int, headers ...string) interface{} {
+	s.t.Helper()
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader, contentType = bytes.NewReader(b), "application/octet-stream"
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatalf("unexpected error: %v", err)
+		}
+		reader, contentType = bytes.NewReader(encoded), "application/json"
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: expected status %d, got %d: %s", method, target, status, w.Code, w.Body.String())
+	}
+
+	_, pattern := s.router.mux.Handler(req)
+	path := pattern
+	if _, p, found := strings.Cut(pattern, " "); found {
+		path = p
+	}
+	paths := s.validator.spec["paths"].(map[string]interface{})
+	op := asMap(asMap(paths[path])[strings.ToLower(method)])
+	if op == nil {
+		s.t.Fatalf("%s %s: %s is not described", method, target, pattern)
+	}
+	s.seen[fmt.Sprintf("%s %s", strings.ToLower(method), path)] = true
+
+	responses := asMap(op["responses"])
+	response := asMap(responses[fmt.Sprint(status)])
+	if response == nil {
+		if status < 400 {
+			s.t.Fatalf("%s %s: status %d is not described", method, target, status)
+		}
+		response = asMap(responses["default"])
+	}
+	response = s.validator.resolve(response)
+
+	content := asMap(response["content"])
+	if content == nil {
+		if w.Body.Len() > 0 {
+			s.t.Errorf("%s %s: expected no body, got %s", method, target, w.Body.String())
+		}
+		return nil
+	}
+
+	// Like the server, the recorder sniffs the type of bodies sent without
+	// one, but only when the handler is done.
+	sent := w.Header().Get("Content-Type")
+	if sent == "" {
+		sent = http.DetectContentType(w.Body.Bytes())
+	}
+	mediaType, _, _ := mime.ParseMediaType(sent)
+	media := asMap(content[mediaType])
+	if media == nil {
+		keys := make([]string, 0, len(content))
+		for key := range content {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		s.t.Fatalf("%s %s: answered %q, described %v", method, target, mediaType, keys)
+	}
+	if !strings.HasSuffix(mediaType, "json") {
+		return w.Body.String()
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		s.t.Fatalf("%s %s: unexpected error: %v", method, target, err)
+	}
+	for _, problem := range s.validator.validate(decoded, asMap(media["schema"]), "body") {
+		s.t.Errorf("%s %s: %s", method, target, problem)
+	}
+	return decoded
+}
+
+func field(value interface{}, name string) string {
+	s, _ := asMap(value)[name].(string)
+	return s
+}
+
+func TestOpenAPIDescribesTheResponses(t *testing.T) {
+	spec := loadSpec(t)
+	s := &apiScenario{t: t, router: testRouter, validator: schemaValidator{spec: spec}, seen: map[string]bool{}}
+
+	served := s.call("GET", "/openapi.json", nil, http.StatusOK)
+	if asMap(served)["openapi"] != "3.1.0" {
+		t.Errorf("expected the document to be served, got %v", served)
+	}
+	s.call("GET", "/health", nil, http.StatusOK)
+
+	device := s.call("POST", "/devices", map[string]interface{}{"name": "probe", "type": "thermo", "labels": map[string]string{"site": "madrid"}}, http.StatusCreated)
+	deviceID := field(device, "id")
+	s.call("POST", "/devices", map[string]interface{}{"type": "thermo"}, http.StatusBadRequest)
+	s.call("GET", "/devices", nil, http.StatusOK)
+	s.call("GET", "/devices?selector=site%3Dmadrid", nil, http.StatusOK)
+	s.call("GET", "/devices/"+deviceID, nil, http.StatusOK)
+	s.call("GET", "/devices/missing", nil, http.StatusNotFound)
+	s.call("PUT", "/devices/"+deviceID, map[string]interface{}{"name": "probe-1", "type": "thermo"}, http.StatusPreconditionRequired)
+	s.call("PUT", "/devices/"+deviceID, map[string]interface{}{"name": "probe-1", "type": "thermo"}, http.StatusOK, "If-Match", `"1"`)
+	s.call("PATCH", "/devices/"+deviceID, map[string]interface{}{"labels": map[string]string{"site": "madrid", "floor": "1"}}, http.StatusOK, "If-Match", "*")
+	s.call("POST", "/devices/"+deviceID+"/heartbeat", nil, http.StatusOK)
+
+	sensor := s.call("POST", "/sensors", map[string]interface{}{
+		"name":      "temperature",
+		"type":      "temperature",
+		"device_id": deviceID,
+		"config":    map[string]interface{}{"sampling_rate_ms": 1000, "enabled": true},
+	}, http.StatusCreated)
+	sensorID := field(sensor, "sensor_id")
+	s.call("POST", "/sensors", map[string]interface{}{}, http.StatusBadRequest)
+	s.call("GET", "/sensors", nil, http.StatusOK)
+	s.call("GET", "/sensors/"+sensorID, nil, http.StatusOK)
+	s.call("GET", "/devices/"+deviceID+"/sensors", nil, http.StatusOK)
+	s.call("GET", "/sensors/"+sensorID+"/config", nil, http.StatusOK)
+	s.call("PUT", "/sensors/"+sensorID+"/config", map[string]interface{}{
+		"sampling_rate_ms": 500,
+		"enabled":          true,
+		"thresholds":       map[string]interface{}{"min": 0, "max": nil},
+	}, http.StatusOK, "If-Match", "*")
+	s.call("PUT", "/sensors?selector=site%3Dmadrid", map[string]interface{}{"sampling_rate_ms": 2000, "enabled": true}, http.StatusPreconditionRequired)
+	s.call("PUT", "/sensors?selector=site%3Dmadrid", map[string]interface{}{
+		"sampling_rate_ms": 2000,
+		"enabled":          true,
+		"versions":         map[string]interface{}{sensorID: 2},
+	}, http.StatusOK)
+	s.call("GET", "/sensors/"+sensorID+"/config/history", nil, http.StatusOK)
+	s.call("GET", "/sensors/"+sensorID+"/config/diff?from=1&to=2", nil, http.StatusOK)
+	s.call("POST", "/sensors/"+sensorID+"/config/rollback", map[string]interface{}{"revision": 1}, http.StatusOK)
+	s.call("PATCH", "/sensors/"+sensorID, map[string]interface{}{"name": "temperature-1"}, http.StatusOK, "If-Match", "*")
+
+	s.call("POST", "/sensors/"+sensorID+"/readings", map[string]interface{}{"value": 21.5}, http.StatusCreated)
+	s.call("GET", "/sensors/"+sensorID+"/readings?from=0&to=1&limit=10", nil, http.StatusOK)
+	from := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	s.call("GET", "/sensors/"+sensorID+"/readings/series?from="+from, nil, http.StatusOK)
+	s.call("GET", "/readings?sensor_id="+sensorID+"&from=0&to=1&limit=10", nil, http.StatusOK)
+
+	s.call("POST", "/graphql", map[string]interface{}{"query": "{ devices { id sensors { id latestReading { value } } } }"}, http.StatusOK)
+	s.call("POST", "/graphql", map[string]interface{}{"variables": map[string]interface{}{}}, http.StatusBadRequest)
+	s.call("GET", "/graphql?query=%7B+sensor%28id%3A+%22"+sensorID+"%22%29+%7B+name+%7D+%7D", nil, http.StatusOK)
+	s.call("GET", "/graphql?query=%7B+sensors+%7B+id+%7D+%7D", nil, http.StatusOK, "Accept", "text/event-stream")
+
+	s.call("POST", "/sensors/"+sensorID+"/simulation", map[string]interface{}{"action": "start"}, http.StatusOK)
+	s.call("POST", "/simulator/?selector=site%3Dmadrid&action=stop", nil, http.StatusOK)
+
+	s.call("GET", "/devices/"+deviceID+"/twin", nil, http.StatusOK)
+	s.call("PATCH", "/devices/"+deviceID+"/twin/desired", map[string]interface{}{"properties": map[string]interface{}{"fan": "on"}}, http.StatusOK)
+	s.call("PATCH", "/devices/"+deviceID+"/twin/reported", map[string]interface{}{"properties": map[string]interface{}{"fan": "on"}}, http.StatusOK)
+
+	command := s.call("POST", "/devices/"+deviceID+"/commands", map[string]interface{}{"name": "reboot", "ttl": "1m"}, http.StatusAccepted)
+	s.call("GET", "/devices/"+deviceID+"/commands?limit=5", nil, http.StatusOK)
+	s.call("GET", "/devices/"+deviceID+"/commands/"+field(command, "id"), nil, http.StatusOK)
+
+	group := s.call("POST", "/groups", map[string]interface{}{"name": "madrid", "selector": "site=madrid"}, http.StatusCreated)
+	groupID := field(group, "id")
+	s.call("GET", "/groups", nil, http.StatusOK)
+	s.call("GET", "/groups/"+groupID, nil, http.StatusOK)
+	s.call("PUT", "/groups/"+groupID, map[string]interface{}{"name": "probes", "device_ids": []string{deviceID}}, http.StatusOK)
+	s.call("GET", "/groups/"+groupID+"/devices", nil, http.StatusOK)
+	s.call("GET", "/sensors?group="+groupID, nil, http.StatusOK)
+
+	organization := s.call("POST", "/locations", map[string]interface{}{"kind": "organization", "name": "acme"}, http.StatusCreated)
+	organizationID := field(organization, "id")
+	site := s.call("POST", "/locations", map[string]interface{}{
+		"kind":      "site",
+		"name":      "madrid",
+		"parent_id": organizationID,
+		"geo":       map[string]float64{"lat": 40.4168, "lon": -3.7038},
+	}, http.StatusCreated)
+	siteID := field(site, "id")
+	s.call("GET", "/locations", nil, http.StatusOK)
+	s.call("GET", "/locations/"+siteID, nil, http.StatusOK)
+	s.call("PUT", "/locations/"+siteID, map[string]interface{}{"kind": "site", "name": "Madrid", "parent_id": organizationID}, http.StatusOK)
+	placement := map[string]interface{}{
+		"location_id": siteID,
+		"geo":         map[string]float64{"lat": 40.4168, "lon": -3.7038},
+	}
+	s.call("PUT", "/devices/"+deviceID+"/location", placement, http.StatusPreconditionRequired)
+	s.call("PUT", "/devices/"+deviceID+"/location", placement, http.StatusOK, "If-Match", "*")
+	s.call("GET", "/locations/"+organizationID+"/devices", nil, http.StatusOK)
+	s.call("GET", "/locations/"+organizationID+"/readings", nil, http.StatusOK)
+	s.call("GET", "/locations/"+organizationID+"/metrics", nil, http.StatusOK)
+	s.call("GET", "/devices/nearby?lat=40.42&lon=-3.70&radius_km=5", nil, http.StatusOK)
+	s.call("DELETE", "/locations/"+siteID, nil, http.StatusConflict)
+
+	claim := s.call("POST", "/claim-tokens", map[string]interface{}{"device_type": "thermo"}, http.StatusCreated)
+	s.call("POST", "/devices/register", map[string]interface{}{"claim_token": field(claim, "token"), "name": "self-registered"}, http.StatusCreated)
+	s.call("POST", "/devices/register", map[string]interface{}{"claim_token": field(claim, "token"), "name": "again"}, http.StatusUnauthorized)
+	credential := s.call("POST", "/devices/"+deviceID+"/credentials", map[string]interface{}{"type": "hmac"}, http.StatusCreated)
+	s.call("POST", "/devices/"+deviceID+"/credentials", map[string]interface{}{"type": "x509"}, http.StatusCreated)
+	s.call("GET", "/devices/"+deviceID+"/credentials", nil, http.StatusOK)
+	rotated := s.call("POST", "/devices/"+deviceID+"/credentials/"+field(credential, "id")+"/rotate", nil, http.StatusCreated)
+	s.call("DELETE", "/devices/"+deviceID+"/credentials/"+field(rotated, "id"), nil, http.StatusOK)
+
+	key := s.call("POST", "/api-keys", map[string]interface{}{"name": "ci", "role": "viewer", "ttl": "24h"}, http.StatusCreated)
+	s.call("GET", "/api-keys", nil, http.StatusOK)
+	s.call("DELETE", "/api-keys/"+field(key, "id"), nil, http.StatusOK)
+
+	firmware := s.call("POST", "/firmware?version=1.0.0&device_type=thermo", []byte("image"), http.StatusCreated)
+	firmwareID := field(firmware, "id")
+	s.call("GET", "/firmware", nil, http.StatusOK)
+	s.call("GET", "/firmware/"+firmwareID, nil, http.StatusOK)
+	if image := s.call("GET", "/firmware/"+firmwareID+"/artifact", nil, http.StatusOK); image != "image" {
+		t.Errorf("expected the image, got %v", image)
+	}
+	campaign := s.call("POST", "/campaigns", map[string]interface{}{"firmware_id": firmwareID, "stages": []int{100}}, http.StatusCreated)
+	campaignID := field(campaign, "id")
+	s.call("GET", "/campaigns", nil, http.StatusOK)
+	s.call("GET", "/campaigns/"+campaignID, nil, http.StatusOK)
+	s.call("POST", "/campaigns/"+campaignID+"/pause", nil, http.StatusOK)
+	s.call("POST", "/campaigns/"+campaignID+"/resume", nil, http.StatusOK)
+	s.call("PUT", "/devices/"+deviceID+"/firmware", map[string]interface{}{"campaign_id": campaignID, "status": "failed", "error": "no space left"}, http.StatusOK)
+	s.call("POST", "/campaigns/"+campaignID+"/abort", nil, http.StatusOK)
+
+	s.call("GET", "/audit", nil, http.StatusOK)
+	s.call("GET", "/audit?action=device.create&limit=1", nil, http.StatusOK)
+
+	s.call("DELETE", "/groups/"+groupID, nil, http.StatusNoContent)
+	s.call("DELETE", "/sensors/"+sensorID, nil, http.StatusNoContent)
+	s.call("DELETE", "/devices/"+deviceID, nil, http.StatusNoContent)
+	s.call("DELETE", "/locations/"+siteID, nil, http.StatusNoContent)
+
+	// Every operation that is not deprecated must have been called.
+	for path, item := range spec["paths"].(map[string]interface{}) {
+		for _, method := range specMethods {
+			op := asMap(asMap(item)[method])
+			if op == nil || op["deprecated"] == true || path == "/metrics" {
+				continue
+			}
+			if !s.seen[method+" "+path] {
+				t.Errorf("%s %s is not checked", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package internal
 
 import (
+	"github.com/SeiyaJapon/iot-sensor-app/api"
 	"github.com/SeiyaJapon/iot-sensor-app/cmd/app"
+	"github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/domain"
+	iot_graphql "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/graphql"
 	iot_http "github.com/SeiyaJapon/iot-sensor-app/internal/iotcontext/infrastructure/http"
 	metrics_http "github.com/SeiyaJapon/iot-sensor-app/internal/metricscontext/infrastructure/http"
 	"log"
@@ -9,7 +12,11 @@ import (
 )
 
 type Router struct {
-	mux *http.ServeMux
+	mux     *http.ServeMux
+	handler http.Handler
+	// patterns are those of the routes, in the order they were registered,
+	// so the tests can check them against the OpenAPI document.
+	patterns []string
 }
 
 func NewRouter(container *app.AppContainer) *Router {
@@ -19,24 +26,157 @@ func NewRouter(container *app.AppContainer) *Router {
 
 	logMW := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("%s %s", r.Method, r.URL.Path)
+			log.Printf("%s %s [%s]", r.Method, r.URL.Path, iot_http.RequestID(r.Context()))
 			next.ServeHTTP(w, r)
 		})
 	}
 
-	deviceHandlers := iot_http.NewDeviceHandlers(*container.DeviceUC)
-	r.mux.Handle("/devices", logMW(http.HandlerFunc(deviceHandlers.DevicesHandler)))
+	// Every route authenticates its caller, checks the caller's role and
+	// scopes the request to the caller's tenant. secured takes the roles
+	// allowed on the route. deviceMW also guards the endpoints devices call
+	// themselves, whose tenant is the device's.
+	deviceAuth := iot_http.NewDeviceAuthenticator(container.CredentialUC, container.DeviceAuthRequired)
+	apiAuth := iot_http.NewAPIAuthenticator(container.AuthUC, container.APIAuthRequired)
+	tenants := iot_http.NewTenantResolver(container.Tenancy, container.DeviceRepo)
 
-	sensorHandlers := iot_http.NewSensorHandlers(*container.SensorUC)
-	r.mux.Handle("/sensors", logMW(http.HandlerFunc(sensorHandlers.SensorsHandler)))
+	secured := func(next http.HandlerFunc, roles ...domain.Role) http.Handler {
+		return logMW(apiAuth.Authenticate(iot_http.Allow(tenants.Resolve(next), roles...)))
+	}
+
+	deviceMW := func(next http.HandlerFunc) http.Handler {
+		return logMW(deviceAuth.Authenticate(apiAuth.Authenticate(iot_http.Allow(tenants.Resolve(next), domain.RoleDevice))))
+	}
+
+	// deployment guards what affects every tenant, so it is not scoped to
+	// one.
+	deployment := func(next http.HandlerFunc, role domain.Role) http.Handler {
+		return logMW(apiAuth.Authenticate(iot_http.AllowDeployment(tenants.Deployment(next), role)))
+	}
+
+	// legacy serves the routes that took the id of the resource in the query
+	// string, kept as deprecated aliases of the routes on its path.
+	legacy := iot_http.Deprecated
+
+	deviceHandlers := iot_http.NewDeviceHandlers(*container.DeviceUC, container.PresenceUC, container.GroupUC)
+	sensorHandlers := iot_http.NewSensorHandlers(*container.SensorUC, container.GroupUC)
+	r.handle("GET /devices", secured(deviceHandlers.List, domain.RoleViewer))
+	r.handle("POST /devices", secured(deviceHandlers.Create, domain.RoleOperator))
+	r.handle("GET /devices/{id}", secured(deviceHandlers.GetByID, domain.RoleViewer))
+	r.handle("PUT /devices/{id}", secured(deviceHandlers.Update, domain.RoleOperator))
+	r.handle("PATCH /devices/{id}", secured(deviceHandlers.Patch, domain.RoleOperator))
+	r.handle("DELETE /devices/{id}", secured(deviceHandlers.Delete, domain.RoleOperator))
+	r.handle("GET /devices/{id}/sensors", secured(sensorHandlers.DeviceSensors, domain.RoleViewer))
+	r.handle("POST /devices/{id}/heartbeat", deviceMW(deviceHandlers.Heartbeat))
+	r.handle("PUT /devices", secured(legacy(deviceHandlers.Update, "/devices/{id}", "id"), domain.RoleOperator))
+	r.handle("PATCH /devices", secured(legacy(deviceHandlers.Patch, "/devices/{id}", "id"), domain.RoleOperator))
+	r.handle("DELETE /devices", secured(legacy(deviceHandlers.Delete, "/devices/{id}", "id"), domain.RoleOperator))
+	r.handle("POST /devices/heartbeat", deviceMW(legacy(deviceHandlers.Heartbeat, "/devices/{id}/heartbeat", "id")))
+
+	groupHandler := iot_http.NewGroupHandler(container.GroupUC)
+	r.handle("POST /groups", secured(groupHandler.Create, domain.RoleOperator))
+	r.handle("GET /groups", secured(groupHandler.List, domain.RoleViewer))
+	r.handle("GET /groups/{id}", secured(groupHandler.Get, domain.RoleViewer))
+	r.handle("PUT /groups/{id}", secured(groupHandler.Update, domain.RoleOperator))
+	r.handle("DELETE /groups/{id}", secured(groupHandler.Delete, domain.RoleOperator))
+	r.handle("GET /groups/{id}/devices", secured(groupHandler.Devices, domain.RoleViewer))
+
+	locationHandler := iot_http.NewLocationHandler(container.LocationUC)
+	r.handle("POST /locations", secured(locationHandler.Create, domain.RoleOperator))
+	r.handle("GET /locations", secured(locationHandler.List, domain.RoleViewer))
+	r.handle("GET /locations/{id}", secured(locationHandler.Get, domain.RoleViewer))
+	r.handle("PUT /locations/{id}", secured(locationHandler.Update, domain.RoleOperator))
+	r.handle("DELETE /locations/{id}", secured(locationHandler.Delete, domain.RoleOperator))
+	r.handle("GET /locations/{id}/devices", secured(locationHandler.Devices, domain.RoleViewer))
+	r.handle("GET /locations/{id}/readings", secured(locationHandler.Readings, domain.RoleViewer))
+	r.handle("GET /locations/{id}/metrics", secured(locationHandler.Metrics, domain.RoleViewer))
+	r.handle("GET /devices/nearby", secured(locationHandler.Nearby, domain.RoleViewer))
+	r.handle("PUT /devices/{id}/location", secured(locationHandler.AssignDevice, domain.RoleOperator))
+
+	// Registration is authenticated by the claim token itself.
+	credentialHandler := iot_http.NewCredentialHandler(container.CredentialUC)
+	r.handle("POST /devices/register", logMW(tenants.Resolve(http.HandlerFunc(credentialHandler.Register))))
+	r.handle("POST /claim-tokens", secured(credentialHandler.CreateClaimToken, domain.RoleOperator))
+	r.handle("POST /devices/{id}/credentials", secured(credentialHandler.Issue, domain.RoleOperator))
+	r.handle("GET /devices/{id}/credentials", secured(credentialHandler.List, domain.RoleOperator))
+	r.handle("POST /devices/{id}/credentials/{credentialID}/rotate", secured(credentialHandler.Rotate, domain.RoleOperator))
+	r.handle("DELETE /devices/{id}/credentials/{credentialID}", secured(credentialHandler.Revoke, domain.RoleOperator))
+
+	apiKeyHandler := iot_http.NewAPIKeyHandler(container.AuthUC)
+	r.handle("POST /api-keys", secured(apiKeyHandler.Create, domain.RoleAdmin))
+	r.handle("GET /api-keys", secured(apiKeyHandler.List, domain.RoleAdmin))
+	r.handle("DELETE /api-keys/{id}", secured(apiKeyHandler.Revoke, domain.RoleAdmin))
+
+	// Admins of a tenant read its audit log; those of the whole deployment
+	// read every tenant's unless they name one.
+	auditHandler := iot_http.NewAuditHandler(container.AuditUC)
+	r.handle("GET /audit", secured(auditHandler.List, domain.RoleAdmin))
+
+	twinHandler := iot_http.NewTwinHandler(*container.TwinUC)
+	r.handle("GET /devices/{id}/twin", secured(twinHandler.TwinHandler, domain.RoleViewer))
+	r.handle("PATCH /devices/{id}/twin/desired", secured(twinHandler.DesiredHandler, domain.RoleOperator))
+	r.handle("PATCH /devices/{id}/twin/reported", deviceMW(twinHandler.ReportedHandler))
+	r.handle("GET /devices/twin", secured(legacy(twinHandler.TwinHandler, "/devices/{id}/twin", "id"), domain.RoleViewer))
+	r.handle("PATCH /devices/twin/desired", secured(legacy(twinHandler.DesiredHandler, "/devices/{id}/twin/desired", "id"), domain.RoleOperator))
+	r.handle("PATCH /devices/twin/reported", deviceMW(legacy(twinHandler.ReportedHandler, "/devices/{id}/twin/reported", "id")))
+
+	commandHandler := iot_http.NewCommandHandler(*container.CommandUC)
+	r.handle("POST /devices/{id}/commands", secured(commandHandler.Create, domain.RoleOperator))
+	r.handle("GET /devices/{id}/commands", secured(commandHandler.List, domain.RoleViewer))
+	r.handle("GET /devices/{id}/commands/{commandID}", secured(commandHandler.Get, domain.RoleViewer))
+
+	// Firmware and campaigns are run by the operator of the deployment for
+	// every tenant, so only credentials that are not bound to a tenant may
+	// change them. Images are downloaded by viewers and by devices, which
+	// use their own credentials when they present them.
+	firmwareHandler := iot_http.NewFirmwareHandler(container.FirmwareUC)
+	firmwareDownloads := iot_http.NewDeviceAuthenticator(container.CredentialUC, false)
+	r.handle("POST /firmware", deployment(firmwareHandler.Upload, domain.RoleOperator))
+	r.handle("GET /firmware", secured(firmwareHandler.List, domain.RoleViewer))
+	r.handle("GET /firmware/{id}", secured(firmwareHandler.Get, domain.RoleViewer))
+	r.handle("GET /firmware/{id}/artifact", logMW(firmwareDownloads.Authenticate(apiAuth.Authenticate(
+		iot_http.Allow(http.HandlerFunc(firmwareHandler.Download), domain.RoleViewer, domain.RoleDevice),
+	))))
+	r.handle("POST /campaigns", deployment(firmwareHandler.CreateCampaign, domain.RoleOperator))
+	r.handle("GET /campaigns", deployment(firmwareHandler.ListCampaigns, domain.RoleViewer))
+	r.handle("GET /campaigns/{id}", deployment(firmwareHandler.GetCampaign, domain.RoleViewer))
+	r.handle("POST /campaigns/{id}/pause", deployment(firmwareHandler.PauseCampaign, domain.RoleOperator))
+	r.handle("POST /campaigns/{id}/resume", deployment(firmwareHandler.ResumeCampaign, domain.RoleOperator))
+	r.handle("POST /campaigns/{id}/abort", deployment(firmwareHandler.AbortCampaign, domain.RoleOperator))
+	r.handle("PUT /devices/{id}/firmware", deviceMW(firmwareHandler.ReportUpdate))
+
+	r.handle("GET /sensors", secured(sensorHandlers.List, domain.RoleViewer))
+	r.handle("POST /sensors", secured(sensorHandlers.CreateSensor, domain.RoleOperator))
+	r.handle("PUT /sensors", secured(sensorHandlers.UpdateSensors, domain.RoleOperator))
+	r.handle("GET /sensors/{id}", secured(sensorHandlers.GetSensorByID, domain.RoleViewer))
+	r.handle("PATCH /sensors/{id}", secured(sensorHandlers.PatchSensor, domain.RoleOperator))
+	r.handle("DELETE /sensors/{id}", secured(sensorHandlers.DeleteSensor, domain.RoleOperator))
+	r.handle("GET /sensors/{id}/config", secured(sensorHandlers.GetConfig, domain.RoleViewer))
+	r.handle("PUT /sensors/{id}/config", secured(sensorHandlers.UpdateSensorConfigById, domain.RoleOperator))
+	r.handle("PATCH /sensors", secured(legacy(sensorHandlers.PatchSensor, "/sensors/{id}", "id"), domain.RoleOperator))
+	r.handle("DELETE /sensors", secured(legacy(sensorHandlers.DeleteSensor, "/sensors/{id}", "id"), domain.RoleOperator))
+	r.handle("GET /sensors/{id}/config/history", secured(sensorHandlers.ConfigHistory, domain.RoleViewer))
+	r.handle("GET /sensors/{id}/config/diff", secured(sensorHandlers.ConfigDiff, domain.RoleViewer))
+	r.handle("POST /sensors/{id}/config/rollback", secured(sensorHandlers.RollbackConfig, domain.RoleOperator))
 
 	readingsHandlers := iot_http.NewReadingsHandler(*container.ReadingsUC)
-	r.mux.HandleFunc("/readings", readingsHandlers.SensorReadingsHandler)
+	r.handle("GET /sensors/{id}/readings", secured(readingsHandlers.Readings, domain.RoleViewer))
+	r.handle("GET /sensors/{id}/readings/series", secured(readingsHandlers.Series, domain.RoleViewer))
+	r.handle("POST /sensors/{id}/readings", deviceMW(readingsHandlers.Ingest))
+	r.handle("GET /readings", secured(legacy(readingsHandlers.Readings, "/sensors/{id}/readings", "sensor_id"), domain.RoleViewer))
+	r.handle("GET /readings/series", secured(legacy(readingsHandlers.Series, "/sensors/{id}/readings/series", "sensor_id"), domain.RoleViewer))
 
-	simulatorHandlers := iot_http.NewSimulatorHandler(*container.SimulatorUC)
-	r.mux.HandleFunc("/simulator/", simulatorHandlers.SimulatorsHandler)
+	// Simulations put load on the database, so only operators start them.
+	simulatorHandlers := iot_http.NewSimulatorHandler(*container.SimulatorUC, container.GroupUC)
+	r.handle("POST /sensors/{id}/simulation", secured(simulatorHandlers.Control, domain.RoleOperator))
+	r.handle("/simulator/", apiAuth.Authenticate(iot_http.Allow(tenants.Resolve(http.HandlerFunc(simulatorHandlers.SimulatorsHandler)), domain.RoleOperator)))
 
-	r.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// GraphQL only reads, so viewers may query it; each request resolves
+	// with the caller's tenant like the REST routes.
+	graphqlHandler := iot_http.NewGraphQLHandler(iot_graphql.NewSchema(container.DeviceUC, container.SensorUC, container.ReadingsUC, container.GroupUC))
+	r.handle("GET /graphql", secured(graphqlHandler.Serve, domain.RoleViewer))
+	r.handle("POST /graphql", secured(graphqlHandler.Serve, domain.RoleViewer))
+
+	r.handle("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -47,14 +187,26 @@ func NewRouter(container *app.AppContainer) *Router {
 		if err != nil {
 			return
 		}
-	})
+	}))
+
+	r.handle("GET /openapi.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(api.Spec)
+	}))
 
 	metricsHandler := metrics_http.NewMetricsHandler()
 	metricsHandler.RegisterRoutes(r.mux)
 
+	r.handler = iot_http.AssignRequestID(r.mux)
+
 	return r
 }
 
+func (r *Router) handle(pattern string, handler http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+	r.mux.Handle(pattern, handler)
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	r.handler.ServeHTTP(w, req)
 }
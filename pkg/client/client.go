@@ -0,0 +1,225 @@
+// Package client is the Go client of the IoT sensor API.
+//
+// Its types and methods are generated from api/openapi.json, the document
+// the server publishes at /openapi.json, so they follow the API as it
+// changes:
+//
+//	c := client.New("https://iot.example.com", client.WithAPIKey(key))
+//	devices, err := c.ListDevices(ctx, &client.ListDevicesParams{Selector: "site=madrid"})
+//
+// Requests the server rejects return a *Problem, whose Code tells the
+// failures apart.
+package client
+
+//go:generate go run ../../cmd/clientgen -spec ../../api/openapi.json -out client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client calls the API at one base URL.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	editors    []func(*http.Request)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with httpClient instead of
+// http.DefaultClient, e.g. to set timeouts or a TLS client certificate.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey authenticates the requests with an API key.
+func WithAPIKey(key string) Option {
+	return WithRequestEditor(func(req *http.Request) {
+		req.Header.Set("X-API-Key", key)
+	})
+}
+
+// WithBearerToken authenticates the requests with a JWT.
+func WithBearerToken(token string) Option {
+	return WithRequestEditor(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// WithTenant scopes the requests to a tenant, for credentials of the whole
+// deployment.
+func WithTenant(tenantID string) Option {
+	return WithRequestEditor(func(req *http.Request) {
+		req.Header.Set("X-Tenant-ID", tenantID)
+	})
+}
+
+// WithRequestEditor changes every request before it is sent, e.g. to sign
+// it as a device.
+func WithRequestEditor(editor func(*http.Request)) Option {
+	return func(c *Client) {
+		c.editors = append(c.editors, editor)
+	}
+}
+
+// New returns a client of the API at baseURL.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error returns the detail of the problem, or its title when it has none.
+func (p *Problem) Error() string {
+	detail := p.Title
+	if p.Detail != "" {
+		detail = p.Detail
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Code, detail)
+}
+
+// VersionETag returns the ETag of a device or sensor at version, to send as
+// If-Match when changing it.
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// request is a call of an operation. Its body is sent as is when it is an
+// io.Reader and as JSON otherwise.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{}
+}
+
+func (r *request) setQuery(name, value string) {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Set(name, value)
+}
+
+func (r *request) setHeader(name, value string) {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	r.header.Set(name, value)
+}
+
+// send sends r and returns the response when it is successful.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var body io.Reader
+	contentType := ""
+	switch b := r.body.(type) {
+	case nil:
+	case io.Reader:
+		body, contentType = b, "application/octet-stream"
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("encoding the request: %w", err)
+		}
+		body, contentType = bytes.NewReader(encoded), "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, edit := range c.editors {
+		edit(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	return nil, readProblem(resp)
+}
+
+// readProblem returns the problem the server answered with. Responses that
+// are not problems, such as those of a proxy, are turned into one.
+func readProblem(resp *http.Response) error {
+	problem := &Problem{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/problem+json" {
+		if err := json.NewDecoder(resp.Body).Decode(problem); err == nil {
+			return problem
+		}
+	}
+
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	problem.Detail = strings.TrimSpace(string(text))
+	return problem
+}
+
+// do sends r and decodes the response into out: a string receives the body
+// as text and anything else its JSON. A nil out discards the body.
+func (c *Client) do(ctx context.Context, r request, out interface{}) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch out := out.(type) {
+	case nil:
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	case *string:
+		text, err := io.ReadAll(resp.Body)
+		*out = string(text)
+		return err
+	default:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decoding the response: %w", err)
+		}
+		return nil
+	}
+}
+
+// open sends r and returns the body of the response, which the caller
+// closes.
+func (c *Client) open(ctx context.Context, r request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/SeiyaJapon/iot-sensor-app/cmd/app"
+	"github.com/SeiyaJapon/iot-sensor-app/internal"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	t.Setenv("STORAGE_DRIVER", "memory")
+	t.Setenv("EVENT_BUS", "memory")
+	t.Setenv("API_AUTH", "disabled")
+	t.Setenv("DEVICE_AUTH", "optional")
+	t.Setenv("FIRMWARE_DIR", t.TempDir())
+	t.Setenv("DEVICE_CA_DIR", t.TempDir())
+
+	server := httptest.NewServer(internal.NewRouter(app.NewAppContainer()))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientAgainstTheServer(t *testing.T) {
+	ctx := context.Background()
+	c := New(newTestServer(t).URL+"/", WithTenant("acme"))
+
+	device, err := c.CreateDevice(ctx, CreateDeviceRequest{Name: "probe", Type: "thermo", Labels: Labels{"site": "madrid"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if device.TenantID != "acme" || device.Status != DeviceStatusProvisioned {
+		t.Errorf("unexpected device %+v", device)
+	}
+
+	devices, err := c.ListDevices(ctx, &ListDevicesParams{Selector: "site=madrid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(devices) != 1 || devices[0].ID != device.ID || devices[0].Presence.Status == "" {
+		t.Errorf("unexpected devices %+v", devices)
+	}
+
+	patched, err := c.PatchDevice(ctx, device.ID, &PatchDeviceParams{IfMatch: VersionETag(device.Version)}, PatchDeviceRequest{Status: DeviceStatusActive})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if patched.Status != DeviceStatusActive {
+		t.Errorf("expected the device to be active, got %q", patched.Status)
+	}
+
+	_, err = c.PatchDevice(ctx, device.ID, &PatchDeviceParams{IfMatch: VersionETag(device.Version)}, PatchDeviceRequest{Name: "stale"})
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected a problem, got %v", err)
+	}
+	if problem.Status != http.StatusPreconditionFailed || problem.Code != "device_version_conflict" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+
+	enabled := true
+	created, err := c.CreateSensor(ctx, CreateSensorRequest{
+		Name:     "temperature",
+		Type:     "temperature",
+		DeviceID: device.ID,
+		Config:   &SensorConfigInput{SamplingRateMs: 1000, Enabled: &enabled},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.IngestReading(ctx, created.SensorID, IngestReadingRequest{Value: 21.5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readings, err := c.ListReadings(ctx, created.SensorID, &ListReadingsParams{From: 0, To: 10, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(readings) != 1 || readings[0].Value != 21.5 {
+		t.Errorf("unexpected readings %+v", readings)
+	}
+
+	firmware, err := c.UploadFirmware(ctx, &UploadFirmwareParams{Version: "1.0.0", DeviceType: "thermo"}, bytes.NewReader([]byte("image")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	artifact, err := c.DownloadFirmware(ctx, firmware.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer artifact.Close()
+	if image, _ := io.ReadAll(artifact); string(image) != "image" {
+		t.Errorf("expected the image, got %q", image)
+	}
+
+	if err := c.DeleteDevice(ctx, device.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, err := c.Health(ctx); err != nil || status != "OK" {
+		t.Errorf("expected OK, got %q, %v", status, err)
+	}
+}
+
+func TestClientSendsCredentials(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithAPIKey("key"), WithBearerToken("token"), WithRequestEditor(func(req *http.Request) {
+		req.Header.Set("X-Request-ID", "req-1")
+	}))
+	if _, err := c.ListGroups(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Get("X-API-Key") != "key" || got.Get("Authorization") != "Bearer token" || got.Get("X-Request-ID") != "req-1" {
+		t.Errorf("unexpected headers %v", got)
+	}
+}
+
+func TestClientTurnsOtherFailuresIntoProblems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).ListGroups(context.Background())
+
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected a problem, got %v", err)
+	}
+	if problem.Status != http.StatusBadGateway || problem.Detail != "upstream unavailable" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+}
@@ -0,0 +1,1109 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: iot/v1/device.proto
+
+package iotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DeviceStatus int32
+
+const (
+	DeviceStatus_DEVICE_STATUS_UNSPECIFIED    DeviceStatus = 0
+	DeviceStatus_DEVICE_STATUS_PROVISIONED    DeviceStatus = 1
+	DeviceStatus_DEVICE_STATUS_ACTIVE         DeviceStatus = 2
+	DeviceStatus_DEVICE_STATUS_MAINTENANCE    DeviceStatus = 3
+	DeviceStatus_DEVICE_STATUS_DECOMMISSIONED DeviceStatus = 4
+)
+
+// Enum value maps for DeviceStatus.
+var (
+	DeviceStatus_name = map[int32]string{
+		0: "DEVICE_STATUS_UNSPECIFIED",
+		1: "DEVICE_STATUS_PROVISIONED",
+		2: "DEVICE_STATUS_ACTIVE",
+		3: "DEVICE_STATUS_MAINTENANCE",
+		4: "DEVICE_STATUS_DECOMMISSIONED",
+	}
+	DeviceStatus_value = map[string]int32{
+		"DEVICE_STATUS_UNSPECIFIED":    0,
+		"DEVICE_STATUS_PROVISIONED":    1,
+		"DEVICE_STATUS_ACTIVE":         2,
+		"DEVICE_STATUS_MAINTENANCE":    3,
+		"DEVICE_STATUS_DECOMMISSIONED": 4,
+	}
+)
+
+func (x DeviceStatus) Enum() *DeviceStatus {
+	p := new(DeviceStatus)
+	*p = x
+	return p
+}
+
+func (x DeviceStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeviceStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_iot_v1_device_proto_enumTypes[0].Descriptor()
+}
+
+func (DeviceStatus) Type() protoreflect.EnumType {
+	return &file_iot_v1_device_proto_enumTypes[0]
+}
+
+func (x DeviceStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeviceStatus.Descriptor instead.
+func (DeviceStatus) EnumDescriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{0}
+}
+
+type GeoPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeoPoint) Reset() {
+	*x = GeoPoint{}
+	mi := &file_iot_v1_device_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeoPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeoPoint) ProtoMessage() {}
+
+func (x *GeoPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeoPoint.ProtoReflect.Descriptor instead.
+func (*GeoPoint) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GeoPoint) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *GeoPoint) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+type Device struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId   string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Name       string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Type       string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Status     DeviceStatus           `protobuf:"varint,5,opt,name=status,proto3,enum=iot.v1.DeviceStatus" json:"status,omitempty"`
+	Labels     map[string]string      `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	LocationId string                 `protobuf:"bytes,7,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Geo        *GeoPoint              `protobuf:"bytes,8,opt,name=geo,proto3" json:"geo,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt  *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// version moves on with every change. Changes carry the version they were
+	// made against and fail with FAILED_PRECONDITION when it is stale.
+	Version       int64 `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_iot_v1_device_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Device) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Device) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Device) GetStatus() DeviceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DeviceStatus_DEVICE_STATUS_UNSPECIFIED
+}
+
+func (x *Device) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Device) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *Device) GetGeo() *GeoPoint {
+	if x != nil {
+		return x.Geo
+	}
+	return nil
+}
+
+func (x *Device) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Device) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Device) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type CreateDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeviceRequest) Reset() {
+	*x = CreateDeviceRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeviceRequest) ProtoMessage() {}
+
+func (x *CreateDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeviceRequest.ProtoReflect.Descriptor instead.
+func (*CreateDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateDeviceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateDeviceRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateDeviceRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type CreateDeviceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeviceResponse) Reset() {
+	*x = CreateDeviceResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeviceResponse) ProtoMessage() {}
+
+func (x *CreateDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeviceResponse.ProtoReflect.Descriptor instead.
+func (*CreateDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateDeviceResponse) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type GetDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeviceRequest) Reset() {
+	*x = GetDeviceRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceRequest) ProtoMessage() {}
+
+func (x *GetDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceRequest.ProtoReflect.Descriptor instead.
+func (*GetDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetDeviceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetDeviceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeviceResponse) Reset() {
+	*x = GetDeviceResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceResponse) ProtoMessage() {}
+
+func (x *GetDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceResponse.ProtoReflect.Descriptor instead.
+func (*GetDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetDeviceResponse) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type ListDevicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesRequest) Reset() {
+	*x = ListDevicesRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRequest) ProtoMessage() {}
+
+func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
+func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{6}
+}
+
+type ListDevicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Devices       []*Device              `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesResponse) Reset() {
+	*x = ListDevicesResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesResponse) ProtoMessage() {}
+
+func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
+func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListDevicesResponse) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type UpdateDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateDeviceRequest) Reset() {
+	*x = UpdateDeviceRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDeviceRequest) ProtoMessage() {}
+
+func (x *UpdateDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDeviceRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateDeviceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateDeviceRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateDeviceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateDeviceRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type UpdateDeviceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateDeviceResponse) Reset() {
+	*x = UpdateDeviceResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDeviceResponse) ProtoMessage() {}
+
+func (x *UpdateDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDeviceResponse.ProtoReflect.Descriptor instead.
+func (*UpdateDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateDeviceResponse) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type SetDeviceLabelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeviceLabelsRequest) Reset() {
+	*x = SetDeviceLabelsRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeviceLabelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeviceLabelsRequest) ProtoMessage() {}
+
+func (x *SetDeviceLabelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeviceLabelsRequest.ProtoReflect.Descriptor instead.
+func (*SetDeviceLabelsRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SetDeviceLabelsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetDeviceLabelsRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SetDeviceLabelsRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type SetDeviceLabelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeviceLabelsResponse) Reset() {
+	*x = SetDeviceLabelsResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeviceLabelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeviceLabelsResponse) ProtoMessage() {}
+
+func (x *SetDeviceLabelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeviceLabelsResponse.ProtoReflect.Descriptor instead.
+func (*SetDeviceLabelsResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SetDeviceLabelsResponse) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type SetDeviceStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Status        DeviceStatus           `protobuf:"varint,3,opt,name=status,proto3,enum=iot.v1.DeviceStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeviceStatusRequest) Reset() {
+	*x = SetDeviceStatusRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeviceStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeviceStatusRequest) ProtoMessage() {}
+
+func (x *SetDeviceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeviceStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetDeviceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetDeviceStatusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetDeviceStatusRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SetDeviceStatusRequest) GetStatus() DeviceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DeviceStatus_DEVICE_STATUS_UNSPECIFIED
+}
+
+type SetDeviceStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeviceStatusResponse) Reset() {
+	*x = SetDeviceStatusResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeviceStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeviceStatusResponse) ProtoMessage() {}
+
+func (x *SetDeviceStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeviceStatusResponse.ProtoReflect.Descriptor instead.
+func (*SetDeviceStatusResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SetDeviceStatusResponse) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type DeleteDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeviceRequest) Reset() {
+	*x = DeleteDeviceRequest{}
+	mi := &file_iot_v1_device_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeviceRequest) ProtoMessage() {}
+
+func (x *DeleteDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeviceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteDeviceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteDeviceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeviceResponse) Reset() {
+	*x = DeleteDeviceResponse{}
+	mi := &file_iot_v1_device_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeviceResponse) ProtoMessage() {}
+
+func (x *DeleteDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_device_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeviceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_device_proto_rawDescGZIP(), []int{15}
+}
+
+var File_iot_v1_device_proto protoreflect.FileDescriptor
+
+const file_iot_v1_device_proto_rawDesc = "" +
+	"\n" +
+	"\x13iot/v1/device.proto\x12\x06iot.v1\x1a\x1fgoogle/protobuf/timestamp.proto\".\n" +
+	"\bGeoPoint\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x02 \x01(\x01R\x03lon\"\xcf\x03\n" +
+	"\x06Device\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12,\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x14.iot.v1.DeviceStatusR\x06status\x122\n" +
+	"\x06labels\x18\x06 \x03(\v2\x1a.iot.v1.Device.LabelsEntryR\x06labels\x12\x1f\n" +
+	"\vlocation_id\x18\a \x01(\tR\n" +
+	"locationId\x12\"\n" +
+	"\x03geo\x18\b \x01(\v2\x10.iot.v1.GeoPointR\x03geo\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\v \x01(\x03R\aversion\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb9\x01\n" +
+	"\x13CreateDeviceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12?\n" +
+	"\x06labels\x18\x03 \x03(\v2'.iot.v1.CreateDeviceRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\">\n" +
+	"\x14CreateDeviceResponse\x12&\n" +
+	"\x06device\x18\x01 \x01(\v2\x0e.iot.v1.DeviceR\x06device\"\"\n" +
+	"\x10GetDeviceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\";\n" +
+	"\x11GetDeviceResponse\x12&\n" +
+	"\x06device\x18\x01 \x01(\v2\x0e.iot.v1.DeviceR\x06device\"\x14\n" +
+	"\x12ListDevicesRequest\"?\n" +
+	"\x13ListDevicesResponse\x12(\n" +
+	"\adevices\x18\x01 \x03(\v2\x0e.iot.v1.DeviceR\adevices\"g\n" +
+	"\x13UpdateDeviceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\">\n" +
+	"\x14UpdateDeviceResponse\x12&\n" +
+	"\x06device\x18\x01 \x01(\v2\x0e.iot.v1.DeviceR\x06device\"\xc1\x01\n" +
+	"\x16SetDeviceLabelsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12B\n" +
+	"\x06labels\x18\x03 \x03(\v2*.iot.v1.SetDeviceLabelsRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
+	"\x17SetDeviceLabelsResponse\x12&\n" +
+	"\x06device\x18\x01 \x01(\v2\x0e.iot.v1.DeviceR\x06device\"p\n" +
+	"\x16SetDeviceStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12,\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x14.iot.v1.DeviceStatusR\x06status\"A\n" +
+	"\x17SetDeviceStatusResponse\x12&\n" +
+	"\x06device\x18\x01 \x01(\v2\x0e.iot.v1.DeviceR\x06device\"%\n" +
+	"\x13DeleteDeviceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
+	"\x14DeleteDeviceResponse*\xa7\x01\n" +
+	"\fDeviceStatus\x12\x1d\n" +
+	"\x19DEVICE_STATUS_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19DEVICE_STATUS_PROVISIONED\x10\x01\x12\x18\n" +
+	"\x14DEVICE_STATUS_ACTIVE\x10\x02\x12\x1d\n" +
+	"\x19DEVICE_STATUS_MAINTENANCE\x10\x03\x12 \n" +
+	"\x1cDEVICE_STATUS_DECOMMISSIONED\x10\x042\xa2\x04\n" +
+	"\rDeviceService\x12I\n" +
+	"\fCreateDevice\x12\x1b.iot.v1.CreateDeviceRequest\x1a\x1c.iot.v1.CreateDeviceResponse\x12@\n" +
+	"\tGetDevice\x12\x18.iot.v1.GetDeviceRequest\x1a\x19.iot.v1.GetDeviceResponse\x12F\n" +
+	"\vListDevices\x12\x1a.iot.v1.ListDevicesRequest\x1a\x1b.iot.v1.ListDevicesResponse\x12I\n" +
+	"\fUpdateDevice\x12\x1b.iot.v1.UpdateDeviceRequest\x1a\x1c.iot.v1.UpdateDeviceResponse\x12R\n" +
+	"\x0fSetDeviceLabels\x12\x1e.iot.v1.SetDeviceLabelsRequest\x1a\x1f.iot.v1.SetDeviceLabelsResponse\x12R\n" +
+	"\x0fSetDeviceStatus\x12\x1e.iot.v1.SetDeviceStatusRequest\x1a\x1f.iot.v1.SetDeviceStatusResponse\x12I\n" +
+	"\fDeleteDevice\x12\x1b.iot.v1.DeleteDeviceRequest\x1a\x1c.iot.v1.DeleteDeviceResponseB6Z4github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1;iotv1b\x06proto3"
+
+var (
+	file_iot_v1_device_proto_rawDescOnce sync.Once
+	file_iot_v1_device_proto_rawDescData []byte
+)
+
+func file_iot_v1_device_proto_rawDescGZIP() []byte {
+	file_iot_v1_device_proto_rawDescOnce.Do(func() {
+		file_iot_v1_device_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iot_v1_device_proto_rawDesc), len(file_iot_v1_device_proto_rawDesc)))
+	})
+	return file_iot_v1_device_proto_rawDescData
+}
+
+var file_iot_v1_device_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_iot_v1_device_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_iot_v1_device_proto_goTypes = []any{
+	(DeviceStatus)(0),               // 0: iot.v1.DeviceStatus
+	(*GeoPoint)(nil),                // 1: iot.v1.GeoPoint
+	(*Device)(nil),                  // 2: iot.v1.Device
+	(*CreateDeviceRequest)(nil),     // 3: iot.v1.CreateDeviceRequest
+	(*CreateDeviceResponse)(nil),    // 4: iot.v1.CreateDeviceResponse
+	(*GetDeviceRequest)(nil),        // 5: iot.v1.GetDeviceRequest
+	(*GetDeviceResponse)(nil),       // 6: iot.v1.GetDeviceResponse
+	(*ListDevicesRequest)(nil),      // 7: iot.v1.ListDevicesRequest
+	(*ListDevicesResponse)(nil),     // 8: iot.v1.ListDevicesResponse
+	(*UpdateDeviceRequest)(nil),     // 9: iot.v1.UpdateDeviceRequest
+	(*UpdateDeviceResponse)(nil),    // 10: iot.v1.UpdateDeviceResponse
+	(*SetDeviceLabelsRequest)(nil),  // 11: iot.v1.SetDeviceLabelsRequest
+	(*SetDeviceLabelsResponse)(nil), // 12: iot.v1.SetDeviceLabelsResponse
+	(*SetDeviceStatusRequest)(nil),  // 13: iot.v1.SetDeviceStatusRequest
+	(*SetDeviceStatusResponse)(nil), // 14: iot.v1.SetDeviceStatusResponse
+	(*DeleteDeviceRequest)(nil),     // 15: iot.v1.DeleteDeviceRequest
+	(*DeleteDeviceResponse)(nil),    // 16: iot.v1.DeleteDeviceResponse
+	nil,                             // 17: iot.v1.Device.LabelsEntry
+	nil,                             // 18: iot.v1.CreateDeviceRequest.LabelsEntry
+	nil,                             // 19: iot.v1.SetDeviceLabelsRequest.LabelsEntry
+	(*timestamppb.Timestamp)(nil),   // 20: google.protobuf.Timestamp
+}
+var file_iot_v1_device_proto_depIdxs = []int32{
+	0,  // 0: iot.v1.Device.status:type_name -> iot.v1.DeviceStatus
+	17, // 1: iot.v1.Device.labels:type_name -> iot.v1.Device.LabelsEntry
+	1,  // 2: iot.v1.Device.geo:type_name -> iot.v1.GeoPoint
+	20, // 3: iot.v1.Device.created_at:type_name -> google.protobuf.Timestamp
+	20, // 4: iot.v1.Device.updated_at:type_name -> google.protobuf.Timestamp
+	18, // 5: iot.v1.CreateDeviceRequest.labels:type_name -> iot.v1.CreateDeviceRequest.LabelsEntry
+	2,  // 6: iot.v1.CreateDeviceResponse.device:type_name -> iot.v1.Device
+	2,  // 7: iot.v1.GetDeviceResponse.device:type_name -> iot.v1.Device
+	2,  // 8: iot.v1.ListDevicesResponse.devices:type_name -> iot.v1.Device
+	2,  // 9: iot.v1.UpdateDeviceResponse.device:type_name -> iot.v1.Device
+	19, // 10: iot.v1.SetDeviceLabelsRequest.labels:type_name -> iot.v1.SetDeviceLabelsRequest.LabelsEntry
+	2,  // 11: iot.v1.SetDeviceLabelsResponse.device:type_name -> iot.v1.Device
+	0,  // 12: iot.v1.SetDeviceStatusRequest.status:type_name -> iot.v1.DeviceStatus
+	2,  // 13: iot.v1.SetDeviceStatusResponse.device:type_name -> iot.v1.Device
+	3,  // 14: iot.v1.DeviceService.CreateDevice:input_type -> iot.v1.CreateDeviceRequest
+	5,  // 15: iot.v1.DeviceService.GetDevice:input_type -> iot.v1.GetDeviceRequest
+	7,  // 16: iot.v1.DeviceService.ListDevices:input_type -> iot.v1.ListDevicesRequest
+	9,  // 17: iot.v1.DeviceService.UpdateDevice:input_type -> iot.v1.UpdateDeviceRequest
+	11, // 18: iot.v1.DeviceService.SetDeviceLabels:input_type -> iot.v1.SetDeviceLabelsRequest
+	13, // 19: iot.v1.DeviceService.SetDeviceStatus:input_type -> iot.v1.SetDeviceStatusRequest
+	15, // 20: iot.v1.DeviceService.DeleteDevice:input_type -> iot.v1.DeleteDeviceRequest
+	4,  // 21: iot.v1.DeviceService.CreateDevice:output_type -> iot.v1.CreateDeviceResponse
+	6,  // 22: iot.v1.DeviceService.GetDevice:output_type -> iot.v1.GetDeviceResponse
+	8,  // 23: iot.v1.DeviceService.ListDevices:output_type -> iot.v1.ListDevicesResponse
+	10, // 24: iot.v1.DeviceService.UpdateDevice:output_type -> iot.v1.UpdateDeviceResponse
+	12, // 25: iot.v1.DeviceService.SetDeviceLabels:output_type -> iot.v1.SetDeviceLabelsResponse
+	14, // 26: iot.v1.DeviceService.SetDeviceStatus:output_type -> iot.v1.SetDeviceStatusResponse
+	16, // 27: iot.v1.DeviceService.DeleteDevice:output_type -> iot.v1.DeleteDeviceResponse
+	21, // [21:28] is the sub-list for method output_type
+	14, // [14:21] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_iot_v1_device_proto_init() }
+func file_iot_v1_device_proto_init() {
+	if File_iot_v1_device_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iot_v1_device_proto_rawDesc), len(file_iot_v1_device_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iot_v1_device_proto_goTypes,
+		DependencyIndexes: file_iot_v1_device_proto_depIdxs,
+		EnumInfos:         file_iot_v1_device_proto_enumTypes,
+		MessageInfos:      file_iot_v1_device_proto_msgTypes,
+	}.Build()
+	File_iot_v1_device_proto = out.File
+	file_iot_v1_device_proto_goTypes = nil
+	file_iot_v1_device_proto_depIdxs = nil
+}
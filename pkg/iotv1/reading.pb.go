@@ -0,0 +1,1096 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: iot/v1/reading.proto
+
+package iotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SensorReading struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	SensorId      string                 `protobuf:"bytes,3,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,4,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Type          string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	Value         float64                `protobuf:"fixed64,6,opt,name=value,proto3" json:"value,omitempty"`
+	Unit          string                 `protobuf:"bytes,7,opt,name=unit,proto3" json:"unit,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Meta          *structpb.Struct       `protobuf:"bytes,9,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorReading) Reset() {
+	*x = SensorReading{}
+	mi := &file_iot_v1_reading_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorReading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorReading) ProtoMessage() {}
+
+func (x *SensorReading) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorReading.ProtoReflect.Descriptor instead.
+func (*SensorReading) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SensorReading) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SensorReading) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *SensorReading) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *SensorReading) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *SensorReading) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SensorReading) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SensorReading) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *SensorReading) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SensorReading) GetMeta() *structpb.Struct {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type ListReadingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	From          int32                  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            int32                  `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReadingsRequest) Reset() {
+	*x = ListReadingsRequest{}
+	mi := &file_iot_v1_reading_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReadingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReadingsRequest) ProtoMessage() {}
+
+func (x *ListReadingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReadingsRequest.ProtoReflect.Descriptor instead.
+func (*ListReadingsRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListReadingsRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *ListReadingsRequest) GetFrom() int32 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *ListReadingsRequest) GetTo() int32 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *ListReadingsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListReadingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Readings      []*SensorReading       `protobuf:"bytes,1,rep,name=readings,proto3" json:"readings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReadingsResponse) Reset() {
+	*x = ListReadingsResponse{}
+	mi := &file_iot_v1_reading_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReadingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReadingsResponse) ProtoMessage() {}
+
+func (x *ListReadingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReadingsResponse.ProtoReflect.Descriptor instead.
+func (*ListReadingsResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListReadingsResponse) GetReadings() []*SensorReading {
+	if x != nil {
+		return x.Readings
+	}
+	return nil
+}
+
+type GetReadingSeriesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SensorId string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	From     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// to defaults to now.
+	To *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// max_points defaults to 500.
+	MaxPoints     int32 `protobuf:"varint,4,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReadingSeriesRequest) Reset() {
+	*x = GetReadingSeriesRequest{}
+	mi := &file_iot_v1_reading_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReadingSeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReadingSeriesRequest) ProtoMessage() {}
+
+func (x *GetReadingSeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReadingSeriesRequest.ProtoReflect.Descriptor instead.
+func (*GetReadingSeriesRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetReadingSeriesRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *GetReadingSeriesRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetReadingSeriesRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetReadingSeriesRequest) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+type ReadingPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bucket        *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Min           float64                `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Avg           float64                `protobuf:"fixed64,4,opt,name=avg,proto3" json:"avg,omitempty"`
+	Count         int64                  `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	Last          float64                `protobuf:"fixed64,6,opt,name=last,proto3" json:"last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadingPoint) Reset() {
+	*x = ReadingPoint{}
+	mi := &file_iot_v1_reading_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadingPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadingPoint) ProtoMessage() {}
+
+func (x *ReadingPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadingPoint.ProtoReflect.Descriptor instead.
+func (*ReadingPoint) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReadingPoint) GetBucket() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Bucket
+	}
+	return nil
+}
+
+func (x *ReadingPoint) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *ReadingPoint) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *ReadingPoint) GetAvg() float64 {
+	if x != nil {
+		return x.Avg
+	}
+	return 0
+}
+
+func (x *ReadingPoint) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ReadingPoint) GetLast() float64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+type ReadingSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Resolution    string                 `protobuf:"bytes,2,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Points        []*ReadingPoint        `protobuf:"bytes,5,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadingSeries) Reset() {
+	*x = ReadingSeries{}
+	mi := &file_iot_v1_reading_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadingSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadingSeries) ProtoMessage() {}
+
+func (x *ReadingSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadingSeries.ProtoReflect.Descriptor instead.
+func (*ReadingSeries) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReadingSeries) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *ReadingSeries) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *ReadingSeries) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ReadingSeries) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ReadingSeries) GetPoints() []*ReadingPoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+type GetReadingSeriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Series        *ReadingSeries         `protobuf:"bytes,1,opt,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReadingSeriesResponse) Reset() {
+	*x = GetReadingSeriesResponse{}
+	mi := &file_iot_v1_reading_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReadingSeriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReadingSeriesResponse) ProtoMessage() {}
+
+func (x *GetReadingSeriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReadingSeriesResponse.ProtoReflect.Descriptor instead.
+func (*GetReadingSeriesResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetReadingSeriesResponse) GetSeries() *ReadingSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type IngestReadingRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SensorId string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Value    float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	// timestamp defaults to the time of arrival.
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReadingRequest) Reset() {
+	*x = IngestReadingRequest{}
+	mi := &file_iot_v1_reading_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReadingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReadingRequest) ProtoMessage() {}
+
+func (x *IngestReadingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReadingRequest.ProtoReflect.Descriptor instead.
+func (*IngestReadingRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *IngestReadingRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *IngestReadingRequest) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *IngestReadingRequest) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type IngestReadingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reading       *SensorReading         `protobuf:"bytes,1,opt,name=reading,proto3" json:"reading,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReadingResponse) Reset() {
+	*x = IngestReadingResponse{}
+	mi := &file_iot_v1_reading_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReadingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReadingResponse) ProtoMessage() {}
+
+func (x *IngestReadingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReadingResponse.ProtoReflect.Descriptor instead.
+func (*IngestReadingResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IngestReadingResponse) GetReading() *SensorReading {
+	if x != nil {
+		return x.Reading
+	}
+	return nil
+}
+
+type IngestReadingsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SensorId string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Value    float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	// timestamp defaults to the time of arrival.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// sequence is echoed in the response, for the client to match them.
+	Sequence      uint64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReadingsRequest) Reset() {
+	*x = IngestReadingsRequest{}
+	mi := &file_iot_v1_reading_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReadingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReadingsRequest) ProtoMessage() {}
+
+func (x *IngestReadingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReadingsRequest.ProtoReflect.Descriptor instead.
+func (*IngestReadingsRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IngestReadingsRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *IngestReadingsRequest) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *IngestReadingsRequest) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *IngestReadingsRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type IngestReadingsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Sequence uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*IngestReadingsResponse_Reading
+	//	*IngestReadingsResponse_Error
+	Result        isIngestReadingsResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReadingsResponse) Reset() {
+	*x = IngestReadingsResponse{}
+	mi := &file_iot_v1_reading_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReadingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReadingsResponse) ProtoMessage() {}
+
+func (x *IngestReadingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReadingsResponse.ProtoReflect.Descriptor instead.
+func (*IngestReadingsResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IngestReadingsResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *IngestReadingsResponse) GetResult() isIngestReadingsResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *IngestReadingsResponse) GetReading() *SensorReading {
+	if x != nil {
+		if x, ok := x.Result.(*IngestReadingsResponse_Reading); ok {
+			return x.Reading
+		}
+	}
+	return nil
+}
+
+func (x *IngestReadingsResponse) GetError() *IngestError {
+	if x != nil {
+		if x, ok := x.Result.(*IngestReadingsResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isIngestReadingsResponse_Result interface {
+	isIngestReadingsResponse_Result()
+}
+
+type IngestReadingsResponse_Reading struct {
+	Reading *SensorReading `protobuf:"bytes,2,opt,name=reading,proto3,oneof"`
+}
+
+type IngestReadingsResponse_Error struct {
+	Error *IngestError `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*IngestReadingsResponse_Reading) isIngestReadingsResponse_Result() {}
+
+func (*IngestReadingsResponse_Error) isIngestReadingsResponse_Result() {}
+
+// IngestError is why a streamed reading was rejected. code is the one the
+// REST API answers with, such as sensor_not_found or rate_limited.
+type IngestError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestError) Reset() {
+	*x = IngestError{}
+	mi := &file_iot_v1_reading_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestError) ProtoMessage() {}
+
+func (x *IngestError) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestError.ProtoReflect.Descriptor instead.
+func (*IngestError) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IngestError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *IngestError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type WatchReadingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// sensor_ids limits the stream to these sensors; without any it carries
+	// every reading of the tenant.
+	SensorIds     []string `protobuf:"bytes,1,rep,name=sensor_ids,json=sensorIds,proto3" json:"sensor_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchReadingsRequest) Reset() {
+	*x = WatchReadingsRequest{}
+	mi := &file_iot_v1_reading_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchReadingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchReadingsRequest) ProtoMessage() {}
+
+func (x *WatchReadingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchReadingsRequest.ProtoReflect.Descriptor instead.
+func (*WatchReadingsRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchReadingsRequest) GetSensorIds() []string {
+	if x != nil {
+		return x.SensorIds
+	}
+	return nil
+}
+
+type WatchReadingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reading       *SensorReading         `protobuf:"bytes,1,opt,name=reading,proto3" json:"reading,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchReadingsResponse) Reset() {
+	*x = WatchReadingsResponse{}
+	mi := &file_iot_v1_reading_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchReadingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchReadingsResponse) ProtoMessage() {}
+
+func (x *WatchReadingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_reading_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchReadingsResponse.ProtoReflect.Descriptor instead.
+func (*WatchReadingsResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_reading_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchReadingsResponse) GetReading() *SensorReading {
+	if x != nil {
+		return x.Reading
+	}
+	return nil
+}
+
+var File_iot_v1_reading_proto protoreflect.FileDescriptor
+
+const file_iot_v1_reading_proto_rawDesc = "" +
+	"\n" +
+	"\x14iot/v1/reading.proto\x12\x06iot.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x02\n" +
+	"\rSensorReading\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tsensor_id\x18\x03 \x01(\tR\bsensorId\x12\x1b\n" +
+	"\tdevice_id\x18\x04 \x01(\tR\bdeviceId\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\x01R\x05value\x12\x12\n" +
+	"\x04unit\x18\a \x01(\tR\x04unit\x128\n" +
+	"\ttimestamp\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12+\n" +
+	"\x04meta\x18\t \x01(\v2\x17.google.protobuf.StructR\x04meta\"l\n" +
+	"\x13ListReadingsRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\x05R\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\x05R\x02to\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"I\n" +
+	"\x14ListReadingsResponse\x121\n" +
+	"\breadings\x18\x01 \x03(\v2\x15.iot.v1.SensorReadingR\breadings\"\xb1\x01\n" +
+	"\x17GetReadingSeriesRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x04 \x01(\x05R\tmaxPoints\"\xa2\x01\n" +
+	"\fReadingPoint\x122\n" +
+	"\x06bucket\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x06bucket\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x01R\x03max\x12\x10\n" +
+	"\x03avg\x18\x04 \x01(\x01R\x03avg\x12\x14\n" +
+	"\x05count\x18\x05 \x01(\x03R\x05count\x12\x12\n" +
+	"\x04last\x18\x06 \x01(\x01R\x04last\"\xd6\x01\n" +
+	"\rReadingSeries\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x02 \x01(\tR\n" +
+	"resolution\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12,\n" +
+	"\x06points\x18\x05 \x03(\v2\x14.iot.v1.ReadingPointR\x06points\"I\n" +
+	"\x18GetReadingSeriesResponse\x12-\n" +
+	"\x06series\x18\x01 \x01(\v2\x15.iot.v1.ReadingSeriesR\x06series\"\x83\x01\n" +
+	"\x14IngestReadingRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"H\n" +
+	"\x15IngestReadingResponse\x12/\n" +
+	"\areading\x18\x01 \x01(\v2\x15.iot.v1.SensorReadingR\areading\"\xa0\x01\n" +
+	"\x15IngestReadingsRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\x04R\bsequence\"\x9e\x01\n" +
+	"\x16IngestReadingsResponse\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x121\n" +
+	"\areading\x18\x02 \x01(\v2\x15.iot.v1.SensorReadingH\x00R\areading\x12+\n" +
+	"\x05error\x18\x03 \x01(\v2\x13.iot.v1.IngestErrorH\x00R\x05errorB\b\n" +
+	"\x06result\";\n" +
+	"\vIngestError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"5\n" +
+	"\x14WatchReadingsRequest\x12\x1d\n" +
+	"\n" +
+	"sensor_ids\x18\x01 \x03(\tR\tsensorIds\"H\n" +
+	"\x15WatchReadingsResponse\x12/\n" +
+	"\areading\x18\x01 \x01(\v2\x15.iot.v1.SensorReadingR\areading2\xa5\x03\n" +
+	"\x0eReadingService\x12I\n" +
+	"\fListReadings\x12\x1b.iot.v1.ListReadingsRequest\x1a\x1c.iot.v1.ListReadingsResponse\x12U\n" +
+	"\x10GetReadingSeries\x12\x1f.iot.v1.GetReadingSeriesRequest\x1a .iot.v1.GetReadingSeriesResponse\x12L\n" +
+	"\rIngestReading\x12\x1c.iot.v1.IngestReadingRequest\x1a\x1d.iot.v1.IngestReadingResponse\x12S\n" +
+	"\x0eIngestReadings\x12\x1d.iot.v1.IngestReadingsRequest\x1a\x1e.iot.v1.IngestReadingsResponse(\x010\x01\x12N\n" +
+	"\rWatchReadings\x12\x1c.iot.v1.WatchReadingsRequest\x1a\x1d.iot.v1.WatchReadingsResponse0\x01B6Z4github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1;iotv1b\x06proto3"
+
+var (
+	file_iot_v1_reading_proto_rawDescOnce sync.Once
+	file_iot_v1_reading_proto_rawDescData []byte
+)
+
+func file_iot_v1_reading_proto_rawDescGZIP() []byte {
+	file_iot_v1_reading_proto_rawDescOnce.Do(func() {
+		file_iot_v1_reading_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iot_v1_reading_proto_rawDesc), len(file_iot_v1_reading_proto_rawDesc)))
+	})
+	return file_iot_v1_reading_proto_rawDescData
+}
+
+var file_iot_v1_reading_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_iot_v1_reading_proto_goTypes = []any{
+	(*SensorReading)(nil),            // 0: iot.v1.SensorReading
+	(*ListReadingsRequest)(nil),      // 1: iot.v1.ListReadingsRequest
+	(*ListReadingsResponse)(nil),     // 2: iot.v1.ListReadingsResponse
+	(*GetReadingSeriesRequest)(nil),  // 3: iot.v1.GetReadingSeriesRequest
+	(*ReadingPoint)(nil),             // 4: iot.v1.ReadingPoint
+	(*ReadingSeries)(nil),            // 5: iot.v1.ReadingSeries
+	(*GetReadingSeriesResponse)(nil), // 6: iot.v1.GetReadingSeriesResponse
+	(*IngestReadingRequest)(nil),     // 7: iot.v1.IngestReadingRequest
+	(*IngestReadingResponse)(nil),    // 8: iot.v1.IngestReadingResponse
+	(*IngestReadingsRequest)(nil),    // 9: iot.v1.IngestReadingsRequest
+	(*IngestReadingsResponse)(nil),   // 10: iot.v1.IngestReadingsResponse
+	(*IngestError)(nil),              // 11: iot.v1.IngestError
+	(*WatchReadingsRequest)(nil),     // 12: iot.v1.WatchReadingsRequest
+	(*WatchReadingsResponse)(nil),    // 13: iot.v1.WatchReadingsResponse
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),          // 15: google.protobuf.Struct
+}
+var file_iot_v1_reading_proto_depIdxs = []int32{
+	14, // 0: iot.v1.SensorReading.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 1: iot.v1.SensorReading.meta:type_name -> google.protobuf.Struct
+	0,  // 2: iot.v1.ListReadingsResponse.readings:type_name -> iot.v1.SensorReading
+	14, // 3: iot.v1.GetReadingSeriesRequest.from:type_name -> google.protobuf.Timestamp
+	14, // 4: iot.v1.GetReadingSeriesRequest.to:type_name -> google.protobuf.Timestamp
+	14, // 5: iot.v1.ReadingPoint.bucket:type_name -> google.protobuf.Timestamp
+	14, // 6: iot.v1.ReadingSeries.from:type_name -> google.protobuf.Timestamp
+	14, // 7: iot.v1.ReadingSeries.to:type_name -> google.protobuf.Timestamp
+	4,  // 8: iot.v1.ReadingSeries.points:type_name -> iot.v1.ReadingPoint
+	5,  // 9: iot.v1.GetReadingSeriesResponse.series:type_name -> iot.v1.ReadingSeries
+	14, // 10: iot.v1.IngestReadingRequest.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 11: iot.v1.IngestReadingResponse.reading:type_name -> iot.v1.SensorReading
+	14, // 12: iot.v1.IngestReadingsRequest.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 13: iot.v1.IngestReadingsResponse.reading:type_name -> iot.v1.SensorReading
+	11, // 14: iot.v1.IngestReadingsResponse.error:type_name -> iot.v1.IngestError
+	0,  // 15: iot.v1.WatchReadingsResponse.reading:type_name -> iot.v1.SensorReading
+	1,  // 16: iot.v1.ReadingService.ListReadings:input_type -> iot.v1.ListReadingsRequest
+	3,  // 17: iot.v1.ReadingService.GetReadingSeries:input_type -> iot.v1.GetReadingSeriesRequest
+	7,  // 18: iot.v1.ReadingService.IngestReading:input_type -> iot.v1.IngestReadingRequest
+	9,  // 19: iot.v1.ReadingService.IngestReadings:input_type -> iot.v1.IngestReadingsRequest
+	12, // 20: iot.v1.ReadingService.WatchReadings:input_type -> iot.v1.WatchReadingsRequest
+	2,  // 21: iot.v1.ReadingService.ListReadings:output_type -> iot.v1.ListReadingsResponse
+	6,  // 22: iot.v1.ReadingService.GetReadingSeries:output_type -> iot.v1.GetReadingSeriesResponse
+	8,  // 23: iot.v1.ReadingService.IngestReading:output_type -> iot.v1.IngestReadingResponse
+	10, // 24: iot.v1.ReadingService.IngestReadings:output_type -> iot.v1.IngestReadingsResponse
+	13, // 25: iot.v1.ReadingService.WatchReadings:output_type -> iot.v1.WatchReadingsResponse
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_iot_v1_reading_proto_init() }
+func file_iot_v1_reading_proto_init() {
+	if File_iot_v1_reading_proto != nil {
+		return
+	}
+	file_iot_v1_reading_proto_msgTypes[10].OneofWrappers = []any{
+		(*IngestReadingsResponse_Reading)(nil),
+		(*IngestReadingsResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iot_v1_reading_proto_rawDesc), len(file_iot_v1_reading_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iot_v1_reading_proto_goTypes,
+		DependencyIndexes: file_iot_v1_reading_proto_depIdxs,
+		MessageInfos:      file_iot_v1_reading_proto_msgTypes,
+	}.Build()
+	File_iot_v1_reading_proto = out.File
+	file_iot_v1_reading_proto_goTypes = nil
+	file_iot_v1_reading_proto_depIdxs = nil
+}
@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: iot/v1/reading.proto
+
+package iotv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ReadingService_ListReadings_FullMethodName     = "/iot.v1.ReadingService/ListReadings"
+	ReadingService_GetReadingSeries_FullMethodName = "/iot.v1.ReadingService/GetReadingSeries"
+	ReadingService_IngestReading_FullMethodName    = "/iot.v1.ReadingService/IngestReading"
+	ReadingService_IngestReadings_FullMethodName   = "/iot.v1.ReadingService/IngestReadings"
+	ReadingService_WatchReadings_FullMethodName    = "/iot.v1.ReadingService/WatchReadings"
+)
+
+// ReadingServiceClient is the client API for ReadingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ReadingService ingests and serves sensor readings.
+type ReadingServiceClient interface {
+	// ListReadings returns the page [from, to) of the latest limit readings of
+	// the sensor.
+	ListReadings(ctx context.Context, in *ListReadingsRequest, opts ...grpc.CallOption) (*ListReadingsResponse, error)
+	// GetReadingSeries returns the readings of the sensor between from and to
+	// at the finest resolution that fits in max_points.
+	GetReadingSeries(ctx context.Context, in *GetReadingSeriesRequest, opts ...grpc.CallOption) (*GetReadingSeriesResponse, error)
+	// IngestReading stores a reading a device took.
+	IngestReading(ctx context.Context, in *IngestReadingRequest, opts ...grpc.CallOption) (*IngestReadingResponse, error)
+	// IngestReadings stores the readings a device streams, answering each one
+	// in order. A rejected reading is answered with its error and does not end
+	// the stream.
+	IngestReadings(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[IngestReadingsRequest, IngestReadingsResponse], error)
+	// WatchReadings streams the readings stored from the call on. Readings are
+	// dropped for a client that falls too far behind.
+	WatchReadings(ctx context.Context, in *WatchReadingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchReadingsResponse], error)
+}
+
+type readingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReadingServiceClient(cc grpc.ClientConnInterface) ReadingServiceClient {
+	return &readingServiceClient{cc}
+}
+
+func (c *readingServiceClient) ListReadings(ctx context.Context, in *ListReadingsRequest, opts ...grpc.CallOption) (*ListReadingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReadingsResponse)
+	err := c.cc.Invoke(ctx, ReadingService_ListReadings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingServiceClient) GetReadingSeries(ctx context.Context, in *GetReadingSeriesRequest, opts ...grpc.CallOption) (*GetReadingSeriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReadingSeriesResponse)
+	err := c.cc.Invoke(ctx, ReadingService_GetReadingSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingServiceClient) IngestReading(ctx context.Context, in *IngestReadingRequest, opts ...grpc.CallOption) (*IngestReadingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestReadingResponse)
+	err := c.cc.Invoke(ctx, ReadingService_IngestReading_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingServiceClient) IngestReadings(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[IngestReadingsRequest, IngestReadingsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReadingService_ServiceDesc.Streams[0], ReadingService_IngestReadings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IngestReadingsRequest, IngestReadingsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReadingService_IngestReadingsClient = grpc.BidiStreamingClient[IngestReadingsRequest, IngestReadingsResponse]
+
+func (c *readingServiceClient) WatchReadings(ctx context.Context, in *WatchReadingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchReadingsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReadingService_ServiceDesc.Streams[1], ReadingService_WatchReadings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchReadingsRequest, WatchReadingsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReadingService_WatchReadingsClient = grpc.ServerStreamingClient[WatchReadingsResponse]
+
+// ReadingServiceServer is the server API for ReadingService service.
+// All implementations must embed UnimplementedReadingServiceServer
+// for forward compatibility.
+//
+// ReadingService ingests and serves sensor readings.
+type ReadingServiceServer interface {
+	// ListReadings returns the page [from, to) of the latest limit readings of
+	// the sensor.
+	ListReadings(context.Context, *ListReadingsRequest) (*ListReadingsResponse, error)
+	// GetReadingSeries returns the readings of the sensor between from and to
+	// at the finest resolution that fits in max_points.
+	GetReadingSeries(context.Context, *GetReadingSeriesRequest) (*GetReadingSeriesResponse, error)
+	// IngestReading stores a reading a device took.
+	IngestReading(context.Context, *IngestReadingRequest) (*IngestReadingResponse, error)
+	// IngestReadings stores the readings a device streams, answering each one
+	// in order. A rejected reading is answered with its error and does not end
+	// the stream.
+	IngestReadings(grpc.BidiStreamingServer[IngestReadingsRequest, IngestReadingsResponse]) error
+	// WatchReadings streams the readings stored from the call on. Readings are
+	// dropped for a client that falls too far behind.
+	WatchReadings(*WatchReadingsRequest, grpc.ServerStreamingServer[WatchReadingsResponse]) error
+	mustEmbedUnimplementedReadingServiceServer()
+}
+
+// UnimplementedReadingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReadingServiceServer struct{}
+
+func (UnimplementedReadingServiceServer) ListReadings(context.Context, *ListReadingsRequest) (*ListReadingsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListReadings not implemented")
+}
+func (UnimplementedReadingServiceServer) GetReadingSeries(context.Context, *GetReadingSeriesRequest) (*GetReadingSeriesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetReadingSeries not implemented")
+}
+func (UnimplementedReadingServiceServer) IngestReading(context.Context, *IngestReadingRequest) (*IngestReadingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method IngestReading not implemented")
+}
+func (UnimplementedReadingServiceServer) IngestReadings(grpc.BidiStreamingServer[IngestReadingsRequest, IngestReadingsResponse]) error {
+	return status.Error(codes.Unimplemented, "method IngestReadings not implemented")
+}
+func (UnimplementedReadingServiceServer) WatchReadings(*WatchReadingsRequest, grpc.ServerStreamingServer[WatchReadingsResponse]) error {
+	return status.Error(codes.Unimplemented, "method WatchReadings not implemented")
+}
+func (UnimplementedReadingServiceServer) mustEmbedUnimplementedReadingServiceServer() {}
+func (UnimplementedReadingServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeReadingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReadingServiceServer will
+// result in compilation errors.
+type UnsafeReadingServiceServer interface {
+	mustEmbedUnimplementedReadingServiceServer()
+}
+
+func RegisterReadingServiceServer(s grpc.ServiceRegistrar, srv ReadingServiceServer) {
+	// If the following call panics, it indicates UnimplementedReadingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ReadingService_ServiceDesc, srv)
+}
+
+func _ReadingService_ListReadings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReadingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingServiceServer).ListReadings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReadingService_ListReadings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingServiceServer).ListReadings(ctx, req.(*ListReadingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingService_GetReadingSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReadingSeriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingServiceServer).GetReadingSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReadingService_GetReadingSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingServiceServer).GetReadingSeries(ctx, req.(*GetReadingSeriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingService_IngestReading_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestReadingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingServiceServer).IngestReading(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReadingService_IngestReading_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingServiceServer).IngestReading(ctx, req.(*IngestReadingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingService_IngestReadings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReadingServiceServer).IngestReadings(&grpc.GenericServerStream[IngestReadingsRequest, IngestReadingsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReadingService_IngestReadingsServer = grpc.BidiStreamingServer[IngestReadingsRequest, IngestReadingsResponse]
+
+func _ReadingService_WatchReadings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchReadingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReadingServiceServer).WatchReadings(m, &grpc.GenericServerStream[WatchReadingsRequest, WatchReadingsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReadingService_WatchReadingsServer = grpc.ServerStreamingServer[WatchReadingsResponse]
+
+// ReadingService_ServiceDesc is the grpc.ServiceDesc for ReadingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReadingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iot.v1.ReadingService",
+	HandlerType: (*ReadingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReadings",
+			Handler:    _ReadingService_ListReadings_Handler,
+		},
+		{
+			MethodName: "GetReadingSeries",
+			Handler:    _ReadingService_GetReadingSeries_Handler,
+		},
+		{
+			MethodName: "IngestReading",
+			Handler:    _ReadingService_IngestReading_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestReadings",
+			Handler:       _ReadingService_IngestReadings_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchReadings",
+			Handler:       _ReadingService_WatchReadings_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "iot/v1/reading.proto",
+}
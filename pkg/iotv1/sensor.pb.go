@@ -0,0 +1,930 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: iot/v1/sensor.proto
+
+package iotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Thresholds struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Min           *float64               `protobuf:"fixed64,1,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max           *float64               `protobuf:"fixed64,2,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thresholds) Reset() {
+	*x = Thresholds{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thresholds) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thresholds) ProtoMessage() {}
+
+func (x *Thresholds) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thresholds.ProtoReflect.Descriptor instead.
+func (*Thresholds) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Thresholds) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *Thresholds) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+type SensorConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// revision is set by the server.
+	Revision       int64                  `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	SamplingRateMs int32                  `protobuf:"varint,2,opt,name=sampling_rate_ms,json=samplingRateMs,proto3" json:"sampling_rate_ms,omitempty"`
+	Thresholds     *Thresholds            `protobuf:"bytes,3,opt,name=thresholds,proto3" json:"thresholds,omitempty"`
+	ErrorRate      float64                `protobuf:"fixed64,4,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
+	Enabled        bool                   `protobuf:"varint,5,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Meta           *structpb.Struct       `protobuf:"bytes,7,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SensorConfig) Reset() {
+	*x = SensorConfig{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorConfig) ProtoMessage() {}
+
+func (x *SensorConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorConfig.ProtoReflect.Descriptor instead.
+func (*SensorConfig) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SensorConfig) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *SensorConfig) GetSamplingRateMs() int32 {
+	if x != nil {
+		return x.SamplingRateMs
+	}
+	return 0
+}
+
+func (x *SensorConfig) GetThresholds() *Thresholds {
+	if x != nil {
+		return x.Thresholds
+	}
+	return nil
+}
+
+func (x *SensorConfig) GetErrorRate() float64 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *SensorConfig) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *SensorConfig) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *SensorConfig) GetMeta() *structpb.Struct {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type Sensor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	Config        *SensorConfig          `protobuf:"bytes,6,opt,name=config,proto3" json:"config,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version       int64                  `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sensor) Reset() {
+	*x = Sensor{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sensor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sensor) ProtoMessage() {}
+
+func (x *Sensor) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sensor.ProtoReflect.Descriptor instead.
+func (*Sensor) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Sensor) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Sensor) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *Sensor) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *Sensor) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Sensor) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Sensor) GetConfig() *SensorConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *Sensor) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Sensor) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Sensor) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Sensor) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type CreateSensorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Config        *SensorConfig          `protobuf:"bytes,4,opt,name=config,proto3" json:"config,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSensorRequest) Reset() {
+	*x = CreateSensorRequest{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSensorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSensorRequest) ProtoMessage() {}
+
+func (x *CreateSensorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSensorRequest.ProtoReflect.Descriptor instead.
+func (*CreateSensorRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateSensorRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *CreateSensorRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateSensorRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateSensorRequest) GetConfig() *SensorConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *CreateSensorRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type CreateSensorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensor        *Sensor                `protobuf:"bytes,1,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSensorResponse) Reset() {
+	*x = CreateSensorResponse{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSensorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSensorResponse) ProtoMessage() {}
+
+func (x *CreateSensorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSensorResponse.ProtoReflect.Descriptor instead.
+func (*CreateSensorResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateSensorResponse) GetSensor() *Sensor {
+	if x != nil {
+		return x.Sensor
+	}
+	return nil
+}
+
+type GetSensorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSensorRequest) Reset() {
+	*x = GetSensorRequest{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSensorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSensorRequest) ProtoMessage() {}
+
+func (x *GetSensorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSensorRequest.ProtoReflect.Descriptor instead.
+func (*GetSensorRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetSensorRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetSensorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensor        *Sensor                `protobuf:"bytes,1,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSensorResponse) Reset() {
+	*x = GetSensorResponse{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSensorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSensorResponse) ProtoMessage() {}
+
+func (x *GetSensorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSensorResponse.ProtoReflect.Descriptor instead.
+func (*GetSensorResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetSensorResponse) GetSensor() *Sensor {
+	if x != nil {
+		return x.Sensor
+	}
+	return nil
+}
+
+type ListSensorsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// device_id lists only the sensors of the device.
+	DeviceId      string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSensorsRequest) Reset() {
+	*x = ListSensorsRequest{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSensorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSensorsRequest) ProtoMessage() {}
+
+func (x *ListSensorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSensorsRequest.ProtoReflect.Descriptor instead.
+func (*ListSensorsRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListSensorsRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type ListSensorsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensors       []*Sensor              `protobuf:"bytes,1,rep,name=sensors,proto3" json:"sensors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSensorsResponse) Reset() {
+	*x = ListSensorsResponse{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSensorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSensorsResponse) ProtoMessage() {}
+
+func (x *ListSensorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSensorsResponse.ProtoReflect.Descriptor instead.
+func (*ListSensorsResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListSensorsResponse) GetSensors() []*Sensor {
+	if x != nil {
+		return x.Sensors
+	}
+	return nil
+}
+
+type UpdateSensorConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Config        *SensorConfig          `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateSensorConfigRequest) Reset() {
+	*x = UpdateSensorConfigRequest{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateSensorConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSensorConfigRequest) ProtoMessage() {}
+
+func (x *UpdateSensorConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSensorConfigRequest.ProtoReflect.Descriptor instead.
+func (*UpdateSensorConfigRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateSensorConfigRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateSensorConfigRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateSensorConfigRequest) GetConfig() *SensorConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type UpdateSensorConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensor        *Sensor                `protobuf:"bytes,1,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateSensorConfigResponse) Reset() {
+	*x = UpdateSensorConfigResponse{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateSensorConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSensorConfigResponse) ProtoMessage() {}
+
+func (x *UpdateSensorConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSensorConfigResponse.ProtoReflect.Descriptor instead.
+func (*UpdateSensorConfigResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateSensorConfigResponse) GetSensor() *Sensor {
+	if x != nil {
+		return x.Sensor
+	}
+	return nil
+}
+
+type DeleteSensorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSensorRequest) Reset() {
+	*x = DeleteSensorRequest{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSensorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSensorRequest) ProtoMessage() {}
+
+func (x *DeleteSensorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSensorRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSensorRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteSensorRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteSensorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSensorResponse) Reset() {
+	*x = DeleteSensorResponse{}
+	mi := &file_iot_v1_sensor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSensorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSensorResponse) ProtoMessage() {}
+
+func (x *DeleteSensorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_sensor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSensorResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSensorResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_sensor_proto_rawDescGZIP(), []int{12}
+}
+
+var File_iot_v1_sensor_proto protoreflect.FileDescriptor
+
+const file_iot_v1_sensor_proto_rawDesc = "" +
+	"\n" +
+	"\x13iot/v1/sensor.proto\x12\x06iot.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"J\n" +
+	"\n" +
+	"Thresholds\x12\x15\n" +
+	"\x03min\x18\x01 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x02 \x01(\x01H\x01R\x03max\x88\x01\x01B\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"\xa9\x02\n" +
+	"\fSensorConfig\x12\x1a\n" +
+	"\brevision\x18\x01 \x01(\x03R\brevision\x12(\n" +
+	"\x10sampling_rate_ms\x18\x02 \x01(\x05R\x0esamplingRateMs\x122\n" +
+	"\n" +
+	"thresholds\x18\x03 \x01(\v2\x12.iot.v1.ThresholdsR\n" +
+	"thresholds\x12\x1d\n" +
+	"\n" +
+	"error_rate\x18\x04 \x01(\x01R\terrorRate\x12\x18\n" +
+	"\aenabled\x18\x05 \x01(\bR\aenabled\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12+\n" +
+	"\x04meta\x18\a \x01(\v2\x17.google.protobuf.StructR\x04meta\"\xa7\x03\n" +
+	"\x06Sensor\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tdevice_id\x18\x03 \x01(\tR\bdeviceId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12,\n" +
+	"\x06config\x18\x06 \x01(\v2\x14.iot.v1.SensorConfigR\x06config\x122\n" +
+	"\x06labels\x18\a \x03(\v2\x1a.iot.v1.Sensor.LabelsEntryR\x06labels\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\x03R\aversion\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x84\x02\n" +
+	"\x13CreateSensorRequest\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12,\n" +
+	"\x06config\x18\x04 \x01(\v2\x14.iot.v1.SensorConfigR\x06config\x12?\n" +
+	"\x06labels\x18\x05 \x03(\v2'.iot.v1.CreateSensorRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\">\n" +
+	"\x14CreateSensorResponse\x12&\n" +
+	"\x06sensor\x18\x01 \x01(\v2\x0e.iot.v1.SensorR\x06sensor\"\"\n" +
+	"\x10GetSensorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\";\n" +
+	"\x11GetSensorResponse\x12&\n" +
+	"\x06sensor\x18\x01 \x01(\v2\x0e.iot.v1.SensorR\x06sensor\"1\n" +
+	"\x12ListSensorsRequest\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\"?\n" +
+	"\x13ListSensorsResponse\x12(\n" +
+	"\asensors\x18\x01 \x03(\v2\x0e.iot.v1.SensorR\asensors\"s\n" +
+	"\x19UpdateSensorConfigRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12,\n" +
+	"\x06config\x18\x03 \x01(\v2\x14.iot.v1.SensorConfigR\x06config\"D\n" +
+	"\x1aUpdateSensorConfigResponse\x12&\n" +
+	"\x06sensor\x18\x01 \x01(\v2\x0e.iot.v1.SensorR\x06sensor\"%\n" +
+	"\x13DeleteSensorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
+	"\x14DeleteSensorResponse2\x8c\x03\n" +
+	"\rSensorService\x12I\n" +
+	"\fCreateSensor\x12\x1b.iot.v1.CreateSensorRequest\x1a\x1c.iot.v1.CreateSensorResponse\x12@\n" +
+	"\tGetSensor\x12\x18.iot.v1.GetSensorRequest\x1a\x19.iot.v1.GetSensorResponse\x12F\n" +
+	"\vListSensors\x12\x1a.iot.v1.ListSensorsRequest\x1a\x1b.iot.v1.ListSensorsResponse\x12[\n" +
+	"\x12UpdateSensorConfig\x12!.iot.v1.UpdateSensorConfigRequest\x1a\".iot.v1.UpdateSensorConfigResponse\x12I\n" +
+	"\fDeleteSensor\x12\x1b.iot.v1.DeleteSensorRequest\x1a\x1c.iot.v1.DeleteSensorResponseB6Z4github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1;iotv1b\x06proto3"
+
+var (
+	file_iot_v1_sensor_proto_rawDescOnce sync.Once
+	file_iot_v1_sensor_proto_rawDescData []byte
+)
+
+func file_iot_v1_sensor_proto_rawDescGZIP() []byte {
+	file_iot_v1_sensor_proto_rawDescOnce.Do(func() {
+		file_iot_v1_sensor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iot_v1_sensor_proto_rawDesc), len(file_iot_v1_sensor_proto_rawDesc)))
+	})
+	return file_iot_v1_sensor_proto_rawDescData
+}
+
+var file_iot_v1_sensor_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_iot_v1_sensor_proto_goTypes = []any{
+	(*Thresholds)(nil),                 // 0: iot.v1.Thresholds
+	(*SensorConfig)(nil),               // 1: iot.v1.SensorConfig
+	(*Sensor)(nil),                     // 2: iot.v1.Sensor
+	(*CreateSensorRequest)(nil),        // 3: iot.v1.CreateSensorRequest
+	(*CreateSensorResponse)(nil),       // 4: iot.v1.CreateSensorResponse
+	(*GetSensorRequest)(nil),           // 5: iot.v1.GetSensorRequest
+	(*GetSensorResponse)(nil),          // 6: iot.v1.GetSensorResponse
+	(*ListSensorsRequest)(nil),         // 7: iot.v1.ListSensorsRequest
+	(*ListSensorsResponse)(nil),        // 8: iot.v1.ListSensorsResponse
+	(*UpdateSensorConfigRequest)(nil),  // 9: iot.v1.UpdateSensorConfigRequest
+	(*UpdateSensorConfigResponse)(nil), // 10: iot.v1.UpdateSensorConfigResponse
+	(*DeleteSensorRequest)(nil),        // 11: iot.v1.DeleteSensorRequest
+	(*DeleteSensorResponse)(nil),       // 12: iot.v1.DeleteSensorResponse
+	nil,                                // 13: iot.v1.Sensor.LabelsEntry
+	nil,                                // 14: iot.v1.CreateSensorRequest.LabelsEntry
+	(*timestamppb.Timestamp)(nil),      // 15: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),            // 16: google.protobuf.Struct
+}
+var file_iot_v1_sensor_proto_depIdxs = []int32{
+	0,  // 0: iot.v1.SensorConfig.thresholds:type_name -> iot.v1.Thresholds
+	15, // 1: iot.v1.SensorConfig.updated_at:type_name -> google.protobuf.Timestamp
+	16, // 2: iot.v1.SensorConfig.meta:type_name -> google.protobuf.Struct
+	1,  // 3: iot.v1.Sensor.config:type_name -> iot.v1.SensorConfig
+	13, // 4: iot.v1.Sensor.labels:type_name -> iot.v1.Sensor.LabelsEntry
+	15, // 5: iot.v1.Sensor.created_at:type_name -> google.protobuf.Timestamp
+	15, // 6: iot.v1.Sensor.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 7: iot.v1.CreateSensorRequest.config:type_name -> iot.v1.SensorConfig
+	14, // 8: iot.v1.CreateSensorRequest.labels:type_name -> iot.v1.CreateSensorRequest.LabelsEntry
+	2,  // 9: iot.v1.CreateSensorResponse.sensor:type_name -> iot.v1.Sensor
+	2,  // 10: iot.v1.GetSensorResponse.sensor:type_name -> iot.v1.Sensor
+	2,  // 11: iot.v1.ListSensorsResponse.sensors:type_name -> iot.v1.Sensor
+	1,  // 12: iot.v1.UpdateSensorConfigRequest.config:type_name -> iot.v1.SensorConfig
+	2,  // 13: iot.v1.UpdateSensorConfigResponse.sensor:type_name -> iot.v1.Sensor
+	3,  // 14: iot.v1.SensorService.CreateSensor:input_type -> iot.v1.CreateSensorRequest
+	5,  // 15: iot.v1.SensorService.GetSensor:input_type -> iot.v1.GetSensorRequest
+	7,  // 16: iot.v1.SensorService.ListSensors:input_type -> iot.v1.ListSensorsRequest
+	9,  // 17: iot.v1.SensorService.UpdateSensorConfig:input_type -> iot.v1.UpdateSensorConfigRequest
+	11, // 18: iot.v1.SensorService.DeleteSensor:input_type -> iot.v1.DeleteSensorRequest
+	4,  // 19: iot.v1.SensorService.CreateSensor:output_type -> iot.v1.CreateSensorResponse
+	6,  // 20: iot.v1.SensorService.GetSensor:output_type -> iot.v1.GetSensorResponse
+	8,  // 21: iot.v1.SensorService.ListSensors:output_type -> iot.v1.ListSensorsResponse
+	10, // 22: iot.v1.SensorService.UpdateSensorConfig:output_type -> iot.v1.UpdateSensorConfigResponse
+	12, // 23: iot.v1.SensorService.DeleteSensor:output_type -> iot.v1.DeleteSensorResponse
+	19, // [19:24] is the sub-list for method output_type
+	14, // [14:19] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_iot_v1_sensor_proto_init() }
+func file_iot_v1_sensor_proto_init() {
+	if File_iot_v1_sensor_proto != nil {
+		return
+	}
+	file_iot_v1_sensor_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iot_v1_sensor_proto_rawDesc), len(file_iot_v1_sensor_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iot_v1_sensor_proto_goTypes,
+		DependencyIndexes: file_iot_v1_sensor_proto_depIdxs,
+		MessageInfos:      file_iot_v1_sensor_proto_msgTypes,
+	}.Build()
+	File_iot_v1_sensor_proto = out.File
+	file_iot_v1_sensor_proto_goTypes = nil
+	file_iot_v1_sensor_proto_depIdxs = nil
+}
@@ -0,0 +1,234 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: iot/v1/simulator.proto
+
+package iotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SimulationAction int32
+
+const (
+	SimulationAction_SIMULATION_ACTION_UNSPECIFIED  SimulationAction = 0
+	SimulationAction_SIMULATION_ACTION_START        SimulationAction = 1
+	SimulationAction_SIMULATION_ACTION_STOP         SimulationAction = 2
+	SimulationAction_SIMULATION_ACTION_INJECT_ERROR SimulationAction = 3
+)
+
+// Enum value maps for SimulationAction.
+var (
+	SimulationAction_name = map[int32]string{
+		0: "SIMULATION_ACTION_UNSPECIFIED",
+		1: "SIMULATION_ACTION_START",
+		2: "SIMULATION_ACTION_STOP",
+		3: "SIMULATION_ACTION_INJECT_ERROR",
+	}
+	SimulationAction_value = map[string]int32{
+		"SIMULATION_ACTION_UNSPECIFIED":  0,
+		"SIMULATION_ACTION_START":        1,
+		"SIMULATION_ACTION_STOP":         2,
+		"SIMULATION_ACTION_INJECT_ERROR": 3,
+	}
+)
+
+func (x SimulationAction) Enum() *SimulationAction {
+	p := new(SimulationAction)
+	*p = x
+	return p
+}
+
+func (x SimulationAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SimulationAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_iot_v1_simulator_proto_enumTypes[0].Descriptor()
+}
+
+func (SimulationAction) Type() protoreflect.EnumType {
+	return &file_iot_v1_simulator_proto_enumTypes[0]
+}
+
+func (x SimulationAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SimulationAction.Descriptor instead.
+func (SimulationAction) EnumDescriptor() ([]byte, []int) {
+	return file_iot_v1_simulator_proto_rawDescGZIP(), []int{0}
+}
+
+type ControlSimulationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Action        SimulationAction       `protobuf:"varint,2,opt,name=action,proto3,enum=iot.v1.SimulationAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlSimulationRequest) Reset() {
+	*x = ControlSimulationRequest{}
+	mi := &file_iot_v1_simulator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlSimulationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlSimulationRequest) ProtoMessage() {}
+
+func (x *ControlSimulationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_simulator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlSimulationRequest.ProtoReflect.Descriptor instead.
+func (*ControlSimulationRequest) Descriptor() ([]byte, []int) {
+	return file_iot_v1_simulator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ControlSimulationRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *ControlSimulationRequest) GetAction() SimulationAction {
+	if x != nil {
+		return x.Action
+	}
+	return SimulationAction_SIMULATION_ACTION_UNSPECIFIED
+}
+
+type ControlSimulationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlSimulationResponse) Reset() {
+	*x = ControlSimulationResponse{}
+	mi := &file_iot_v1_simulator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlSimulationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlSimulationResponse) ProtoMessage() {}
+
+func (x *ControlSimulationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iot_v1_simulator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlSimulationResponse.ProtoReflect.Descriptor instead.
+func (*ControlSimulationResponse) Descriptor() ([]byte, []int) {
+	return file_iot_v1_simulator_proto_rawDescGZIP(), []int{1}
+}
+
+var File_iot_v1_simulator_proto protoreflect.FileDescriptor
+
+const file_iot_v1_simulator_proto_rawDesc = "" +
+	"\n" +
+	"\x16iot/v1/simulator.proto\x12\x06iot.v1\"i\n" +
+	"\x18ControlSimulationRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x120\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x18.iot.v1.SimulationActionR\x06action\"\x1b\n" +
+	"\x19ControlSimulationResponse*\x92\x01\n" +
+	"\x10SimulationAction\x12!\n" +
+	"\x1dSIMULATION_ACTION_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17SIMULATION_ACTION_START\x10\x01\x12\x1a\n" +
+	"\x16SIMULATION_ACTION_STOP\x10\x02\x12\"\n" +
+	"\x1eSIMULATION_ACTION_INJECT_ERROR\x10\x032l\n" +
+	"\x10SimulatorService\x12X\n" +
+	"\x11ControlSimulation\x12 .iot.v1.ControlSimulationRequest\x1a!.iot.v1.ControlSimulationResponseB6Z4github.com/SeiyaJapon/iot-sensor-app/pkg/iotv1;iotv1b\x06proto3"
+
+var (
+	file_iot_v1_simulator_proto_rawDescOnce sync.Once
+	file_iot_v1_simulator_proto_rawDescData []byte
+)
+
+func file_iot_v1_simulator_proto_rawDescGZIP() []byte {
+	file_iot_v1_simulator_proto_rawDescOnce.Do(func() {
+		file_iot_v1_simulator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iot_v1_simulator_proto_rawDesc), len(file_iot_v1_simulator_proto_rawDesc)))
+	})
+	return file_iot_v1_simulator_proto_rawDescData
+}
+
+var file_iot_v1_simulator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_iot_v1_simulator_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_iot_v1_simulator_proto_goTypes = []any{
+	(SimulationAction)(0),             // 0: iot.v1.SimulationAction
+	(*ControlSimulationRequest)(nil),  // 1: iot.v1.ControlSimulationRequest
+	(*ControlSimulationResponse)(nil), // 2: iot.v1.ControlSimulationResponse
+}
+var file_iot_v1_simulator_proto_depIdxs = []int32{
+	0, // 0: iot.v1.ControlSimulationRequest.action:type_name -> iot.v1.SimulationAction
+	1, // 1: iot.v1.SimulatorService.ControlSimulation:input_type -> iot.v1.ControlSimulationRequest
+	2, // 2: iot.v1.SimulatorService.ControlSimulation:output_type -> iot.v1.ControlSimulationResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_iot_v1_simulator_proto_init() }
+func file_iot_v1_simulator_proto_init() {
+	if File_iot_v1_simulator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iot_v1_simulator_proto_rawDesc), len(file_iot_v1_simulator_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iot_v1_simulator_proto_goTypes,
+		DependencyIndexes: file_iot_v1_simulator_proto_depIdxs,
+		EnumInfos:         file_iot_v1_simulator_proto_enumTypes,
+		MessageInfos:      file_iot_v1_simulator_proto_msgTypes,
+	}.Build()
+	File_iot_v1_simulator_proto = out.File
+	file_iot_v1_simulator_proto_goTypes = nil
+	file_iot_v1_simulator_proto_depIdxs = nil
+}
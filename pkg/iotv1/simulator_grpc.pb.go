@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: iot/v1/simulator.proto
+
+package iotv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SimulatorService_ControlSimulation_FullMethodName = "/iot.v1.SimulatorService/ControlSimulation"
+)
+
+// SimulatorServiceClient is the client API for SimulatorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SimulatorService drives the simulated sensors.
+type SimulatorServiceClient interface {
+	ControlSimulation(ctx context.Context, in *ControlSimulationRequest, opts ...grpc.CallOption) (*ControlSimulationResponse, error)
+}
+
+type simulatorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSimulatorServiceClient(cc grpc.ClientConnInterface) SimulatorServiceClient {
+	return &simulatorServiceClient{cc}
+}
+
+func (c *simulatorServiceClient) ControlSimulation(ctx context.Context, in *ControlSimulationRequest, opts ...grpc.CallOption) (*ControlSimulationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlSimulationResponse)
+	err := c.cc.Invoke(ctx, SimulatorService_ControlSimulation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SimulatorServiceServer is the server API for SimulatorService service.
+// All implementations must embed UnimplementedSimulatorServiceServer
+// for forward compatibility.
+//
+// SimulatorService drives the simulated sensors.
+type SimulatorServiceServer interface {
+	ControlSimulation(context.Context, *ControlSimulationRequest) (*ControlSimulationResponse, error)
+	mustEmbedUnimplementedSimulatorServiceServer()
+}
+
+// UnimplementedSimulatorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSimulatorServiceServer struct{}
+
+func (UnimplementedSimulatorServiceServer) ControlSimulation(context.Context, *ControlSimulationRequest) (*ControlSimulationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ControlSimulation not implemented")
+}
+func (UnimplementedSimulatorServiceServer) mustEmbedUnimplementedSimulatorServiceServer() {}
+func (UnimplementedSimulatorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeSimulatorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SimulatorServiceServer will
+// result in compilation errors.
+type UnsafeSimulatorServiceServer interface {
+	mustEmbedUnimplementedSimulatorServiceServer()
+}
+
+func RegisterSimulatorServiceServer(s grpc.ServiceRegistrar, srv SimulatorServiceServer) {
+	// If the following call panics, it indicates UnimplementedSimulatorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SimulatorService_ServiceDesc, srv)
+}
+
+func _SimulatorService_ControlSimulation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ControlSimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimulatorServiceServer).ControlSimulation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimulatorService_ControlSimulation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimulatorServiceServer).ControlSimulation(ctx, req.(*ControlSimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SimulatorService_ServiceDesc is the grpc.ServiceDesc for SimulatorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SimulatorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iot.v1.SimulatorService",
+	HandlerType: (*SimulatorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ControlSimulation",
+			Handler:    _SimulatorService_ControlSimulation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iot/v1/simulator.proto",
+}